@@ -53,4 +53,58 @@ VALIDATE_CASES = [
 # @pytest.mark.parametrize(...)
 # def test_cty_cli_validate(...): ...
 
+DEEP_VALUE_TYPES = ['"dynamic"', '["list", "dynamic"]']
+
+
+@pytest.mark.parametrize("go_harness_executable", [HARNESS_NAME], indirect=True)
+@pytest.mark.parametrize("type_spec", DEEP_VALUE_TYPES)
+def test_cty_cli_max_depth_rejects_deep_values(
+    go_harness_executable: Path, project_root: Path, request: pytest.FixtureRequest, type_spec: str
+) -> None:
+    """A value nested past --max-depth is rejected, including under a top-level dynamic type."""
+    deep_value = "[" * 50 + "]" * 50
+    exit_code, stdout, stderr = run_harness_cli(
+        go_harness_executable,
+        ["cty", "validate-value", "--type", type_spec, "--max-depth", "5", deep_value],
+        project_root=project_root,
+        harness_artifact_name=HARNESS_NAME,
+        test_id=request.node.name,
+    )
+    assert exit_code != 0, f"Expected a 50-deep value to exceed --max-depth 5.\nStdout: {stdout}"
+    assert "depth 6 exceeds maximum 5" in stderr
+
+
+NUMBER_MODE_CASES = [
+    ("float64", '"number"', "12345678901234568000000"),
+    ("float64", '"dynamic"', "12345678901234568000000"),
+    ("big", '"number"', "12345678901234567890123"),
+    ("big", '"dynamic"', "12345678901234567890123"),
+]
+
+
+@pytest.mark.parametrize("go_harness_executable", [HARNESS_NAME], indirect=True)
+@pytest.mark.parametrize("number_mode,type_spec,expected", NUMBER_MODE_CASES)
+def test_cty_cli_number_mode_applies_to_dynamic(
+    go_harness_executable: Path,
+    project_root: Path,
+    request: pytest.FixtureRequest,
+    tmp_path: Path,
+    number_mode: str,
+    type_spec: str,
+    expected: str,
+) -> None:
+    """--number-mode parses a number the same way whether it is typed number or dynamic."""
+    input_file = tmp_path / "number.json"
+    input_file.write_text("12345678901234567890123")
+    exit_code, stdout, stderr = run_harness_cli(
+        go_harness_executable,
+        ["cty", "convert", "--number-mode", number_mode, "--type", type_spec, str(input_file), "-"],
+        project_root=project_root,
+        harness_artifact_name=HARNESS_NAME,
+        test_id=request.node.name,
+    )
+    assert exit_code == 0, f"cty convert failed ({exit_code}).\nStdout: {stdout}\nStderr: {stderr}"
+    assert expected in stdout
+
+
 # 🥣🔬🔚
@@ -0,0 +1,202 @@
+#
+# SPDX-FileCopyrightText: Copyright (c) 2025 provide.io llc. All rights reserved.
+# SPDX-License-Identifier: Apache-2.0
+#
+
+"""Round trips the soup-go golden vector files through the harness CLI."""
+
+import json
+from pathlib import Path
+from typing import Any
+
+import pytest
+
+from .shared_cli_utils import run_harness_cli
+
+HARNESS_NAME = "soup-go"
+GOLDEN_DIR = Path(__file__).parent.parent.parent / "src/tofusoup/harness/go/soup-go/testdata"
+
+
+def _load_vectors(filename: str) -> list[dict[str, Any]]:
+    return json.loads((GOLDEN_DIR / filename).read_text())
+
+
+def _run_json(executable: Path, args: list[str], project_root: Path, test_id: str) -> Any:
+    exit_code, stdout, stderr = run_harness_cli(
+        executable,
+        args,
+        project_root=project_root,
+        harness_artifact_name=HARNESS_NAME,
+        test_id=test_id,
+    )
+    assert exit_code == 0, f"{' '.join(args[:3])} failed ({exit_code}).\nStdout: {stdout}\nStderr: {stderr}"
+    return json.loads(stdout)
+
+
+ATTRIBUTE_PATH_VECTORS = _load_vectors("attribute_paths.json")
+
+
+@pytest.mark.parametrize("go_harness_executable", [HARNESS_NAME], indirect=True)
+@pytest.mark.parametrize("vector", ATTRIBUTE_PATH_VECTORS, ids=[v["name"] for v in ATTRIBUTE_PATH_VECTORS])
+def test_attribute_path_golden_round_trip(
+    go_harness_executable: Path, project_root: Path, request: pytest.FixtureRequest, vector: dict[str, Any]
+) -> None:
+    """Encodes the vector's steps and decodes its wire bytes, comparing both against the golden file."""
+    test_id = request.node.name
+
+    encoded = _run_json(
+        go_harness_executable,
+        ["cty", "path", "encode", json.dumps(vector["steps"])],
+        project_root,
+        f"{test_id}_encode",
+    )
+    assert encoded["proto_hex"] == vector["proto_hex"]
+    assert encoded["string"] == vector["string"]
+
+    decoded = _run_json(
+        go_harness_executable,
+        ["cty", "path", "decode", "--input-encoding", "hex", vector["proto_hex"]],
+        project_root,
+        f"{test_id}_decode",
+    )
+    assert decoded["steps"] == vector["steps"]
+    assert decoded["string"] == vector["string"]
+    assert decoded["proto_hex"] == vector["proto_hex"]
+
+
+@pytest.mark.parametrize("go_harness_executable", [HARNESS_NAME], indirect=True)
+def test_attribute_path_golden_check(
+    go_harness_executable: Path, project_root: Path, request: pytest.FixtureRequest
+) -> None:
+    """The harness's own check of the golden file must pass every vector."""
+    report = _run_json(
+        go_harness_executable,
+        ["cty", "path", "check", str(GOLDEN_DIR / "attribute_paths.json")],
+        project_root,
+        request.node.name,
+    )
+    assert report["failed"] == 0, report["results"]
+    assert report["total"] == len(ATTRIBUTE_PATH_VECTORS)
+
+
+DIAGNOSTIC_FIXTURES = _load_vectors("provider_diagnostics.json")
+
+
+def _regenerate_diagnostic_fixture(name: str, report: dict[str, Any]) -> dict[str, Any]:
+    """Rebuilds a provider_diagnostics.json entry from a `hcl diagnostics` report."""
+    fixture: dict[str, Any] = {"name": name, "severity": report["severity"], "summary": report["summary"]}
+    if report["detail"]:
+        fixture["detail"] = report["detail"]
+    if "attribute_steps" in report:
+        fixture["attribute"] = report["attribute_steps"]
+        fixture["attribute_string"] = report["attribute"]
+    fixture["proto_hex"] = report["proto_hex"]
+    return fixture
+
+
+@pytest.mark.parametrize("go_harness_executable", [HARNESS_NAME], indirect=True)
+@pytest.mark.parametrize("fixture", DIAGNOSTIC_FIXTURES, ids=[f["name"] for f in DIAGNOSTIC_FIXTURES])
+def test_diagnostic_golden_fixture_regenerates(
+    go_harness_executable: Path, project_root: Path, request: pytest.FixtureRequest, fixture: dict[str, Any]
+) -> None:
+    """Regenerates the fixture from its inputs and its wire bytes, diffing both against the golden file."""
+    test_id = request.node.name
+    source = {key: fixture[key] for key in ("severity", "summary", "detail", "attribute") if key in fixture}
+
+    encoded = _run_json(
+        go_harness_executable,
+        ["hcl", "diagnostics", "encode", json.dumps(source)],
+        project_root,
+        f"{test_id}_encode",
+    )
+    assert _regenerate_diagnostic_fixture(fixture["name"], encoded) == fixture
+
+    decoded = _run_json(
+        go_harness_executable,
+        ["hcl", "diagnostics", "decode", "--input-encoding", "hex", fixture["proto_hex"]],
+        project_root,
+        f"{test_id}_decode",
+    )
+    assert _regenerate_diagnostic_fixture(fixture["name"], decoded) == fixture
+
+    # Invalid severities have no HCL form; every other fixture must convert losslessly
+    if fixture["severity"] == "invalid":
+        assert "hcl" not in decoded
+    else:
+        assert decoded["hcl"]["severity"] == fixture["severity"]
+        assert decoded["hcl"]["summary"] == fixture["summary"]
+        assert decoded["hcl"]["detail"] == fixture.get("detail", "")
+
+
+@pytest.mark.parametrize("go_harness_executable", [HARNESS_NAME], indirect=True)
+def test_diagnostic_golden_check(
+    go_harness_executable: Path, project_root: Path, request: pytest.FixtureRequest
+) -> None:
+    """The harness's own check of the golden file must pass every fixture."""
+    report = _run_json(
+        go_harness_executable,
+        ["hcl", "diagnostics", "check", str(GOLDEN_DIR / "provider_diagnostics.json")],
+        project_root,
+        request.node.name,
+    )
+    assert report["failed"] == 0, report["results"]
+    assert report["total"] == len(DIAGNOSTIC_FIXTURES)
+
+
+FLATMAP_VECTORS = _load_vectors("flatmap_vectors.json")
+
+
+@pytest.mark.parametrize("go_harness_executable", [HARNESS_NAME], indirect=True)
+@pytest.mark.parametrize("vector", FLATMAP_VECTORS, ids=[v["name"] for v in FLATMAP_VECTORS])
+def test_flatmap_golden_round_trip(
+    go_harness_executable: Path, project_root: Path, request: pytest.FixtureRequest, vector: dict[str, Any]
+) -> None:
+    """Decodes the golden flatmap to cty and re-encodes it, which must reproduce the flatmap."""
+    test_id = request.node.name
+    type_json = json.dumps(vector["type"])
+
+    decoded = _run_json(
+        go_harness_executable,
+        ["cty", "flatmap", "decode", "--type", type_json, json.dumps(vector["flatmap"])],
+        project_root,
+        f"{test_id}_decode",
+    )
+    # Set element order is not significant in cty JSON, so values are compared
+    # by `cty flatmap check` below rather than here
+    assert decoded["unknown_paths"] == vector.get("decoded_unknown_paths", vector.get("unknown_paths", []))
+
+    args = ["cty", "flatmap", "encode", "--type", type_json]
+    for path in decoded["unknown_paths"]:
+        args += ["--unknown", path]
+    encoded = _run_json(
+        go_harness_executable,
+        [*args, json.dumps(decoded["value"])],
+        project_root,
+        f"{test_id}_encode",
+    )
+    # Sets keyed by helper/schema hashcodes come back with index keys
+    assert encoded == vector.get("encoded", vector["flatmap"])
+
+
+@pytest.mark.parametrize("go_harness_executable", [HARNESS_NAME], indirect=True)
+def test_flatmap_golden_vectors_cover_count_keys(
+    go_harness_executable: Path, project_root: Path, request: pytest.FixtureRequest
+) -> None:
+    """The golden file must exercise list/set (#) and map (%) counts, also below a nested block."""
+    keys = [key for vector in FLATMAP_VECTORS for key in vector["flatmap"]]
+    assert any(key.endswith(".#") for key in keys)
+    assert any(key.endswith(".%") for key in keys)
+    assert any(key.count(".") >= 3 and key.endswith(".#") for key in keys)
+    assert any(key.count(".") >= 3 and key.endswith(".%") for key in keys)
+
+    report = _run_json(
+        go_harness_executable,
+        ["cty", "flatmap", "check", str(GOLDEN_DIR / "flatmap_vectors.json")],
+        project_root,
+        request.node.name,
+    )
+    assert report["failed"] == 0, report["results"]
+    assert report["total"] == len(FLATMAP_VECTORS)
+
+
+# 🥣🔬🔚
@@ -65,4 +65,67 @@ def test_hcl_cli_parse(
             assert actual_output == expected_json_output
 
 
+HCL_LOCALS_CASES = [
+    pytest.param(
+        'locals {\n  c = "${local.b}!"\n  b = "${local.a}-y"\n}\nlocals {\n  a = "x"\n}\n',
+        ["a", "b", "c"],
+        {"a": "x", "b": "x-y", "c": "x-y!"},
+        None,
+        id="locals_dependency_order",
+    ),
+    pytest.param("locals {\n  a = local.a\n}\n", [], {}, ["a", "a"], id="locals_self_cycle"),
+    pytest.param(
+        'locals {\n  a = local.b\n  b = local.a\n  c = local.a\n  d = "ok"\n}\n',
+        ["d"],
+        {"d": "ok"},
+        ["a", "b", "a"],
+        id="locals_two_node_cycle_with_dependent",
+    ),
+]
+
+
+@pytest.mark.parametrize("go_harness_executable", [HARNESS_NAME], indirect=True)
+@pytest.mark.parametrize("hcl_content, expected_order, expected_locals, expected_cycle", HCL_LOCALS_CASES)
+def test_hcl_cli_eval_locals(
+    go_harness_executable: Path,
+    project_root: Path,
+    request: pytest.FixtureRequest,
+    tmp_path: Path,
+    hcl_content: str,
+    expected_order: list[str],
+    expected_locals: dict[str, Any],
+    expected_cycle: list[str] | None,
+) -> None:
+    """Locals evaluate in dependency order; a cycle is one error and leaves out the locals it reaches."""
+    hcl_file = tmp_path / "locals.hcl"
+    hcl_file.write_text(hcl_content)
+    exit_code, stdout, stderr = run_harness_cli(
+        go_harness_executable,
+        ["hcl", "eval", str(hcl_file)],
+        project_root=project_root,
+        harness_artifact_name=HARNESS_NAME,
+        test_id=request.node.callspec.id,
+    )
+    output = json.loads(stdout)
+    assert output["locals_order"] == expected_order
+    evaluated = {}
+    for block in output["body"]["blocks"]:
+        evaluated.update(block["body"])
+    assert evaluated == expected_locals
+
+    if expected_cycle is None:
+        assert exit_code == 0, f"hcl eval failed. Exit: {exit_code}\nStderr: {stderr}"
+        assert output["success"] is True
+        return
+    assert exit_code != 0
+    assert output["success"] is False
+    assert len(output["errors"]) == 1, output["errors"]
+    error = output["errors"][0]
+    assert error["summary"] == "Cycle in local values"
+    assert error["cycle"]["locals"] == expected_cycle
+    # One range per local in the cycle, the first repeated at the end
+    assert len(error["cycle"]["ranges"]) == len(expected_cycle) - 1
+    assert error["range"] == error["cycle"]["ranges"][0]
+
+
 # 🥣🔬🔚
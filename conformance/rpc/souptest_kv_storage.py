@@ -0,0 +1,199 @@
+#
+# SPDX-FileCopyrightText: Copyright (c) 2025 provide.io llc. All rights reserved.
+# SPDX-License-Identifier: Apache-2.0
+#
+
+"""KV file store behavior of the soup-go server.
+
+Each `soup-go rpc kv` client spawns its own server through PLUGIN_SERVER_PATH,
+so concurrent clients exercise the cross-process file locking the servers
+share. A wrapper script passes the server flags under test.
+"""
+
+from concurrent.futures import ThreadPoolExecutor
+import fcntl
+import hashlib
+import os
+from pathlib import Path
+import subprocess  # nosec
+
+import pytest
+
+from tofusoup.harness.proto.kv import kv_pb2
+from tofusoup.rpc.server import KV
+
+
+def _server_env(tmp_path: Path, executable: Path, *server_flags: str) -> dict[str, str]:
+    """Environment spawning soup-go servers with server_flags over a store in tmp_path."""
+    wrapper = tmp_path / "soup-go-server.sh"
+    wrapper.write_text(f'#!/bin/sh\nexec "{executable}" "$@" {" ".join(server_flags)}\n')
+    wrapper.chmod(0o755)
+    return {
+        **os.environ,
+        "PLUGIN_SERVER_PATH": str(wrapper),
+        "KV_STORAGE_DIR": str(tmp_path / "store"),
+        "TLS_MODE": "disabled",
+    }
+
+
+def _kv(executable: Path, env: dict[str, str], *args: str) -> subprocess.CompletedProcess[str]:
+    return subprocess.run(
+        [str(executable), "rpc", "kv", *args], capture_output=True, text=True, env=env, timeout=60
+    )
+
+
+def _shard_path(env: dict[str, str], key: str) -> Path:
+    """Where the sharded layout keeps key: under the first byte of its SHA-256 in hex."""
+    shard = hashlib.sha256(key.encode()).hexdigest()[:2]
+    return Path(env["KV_STORAGE_DIR"]) / shard / f"kv-data-{key}"
+
+
+def _stored_files(env: dict[str, str]) -> list[Path]:
+    return sorted(path for path in Path(env["KV_STORAGE_DIR"]).rglob("*") if path.is_file())
+
+
+def test_kv_concurrent_put_get_leaves_no_partial_files(go_harness_executable: Path, tmp_path: Path) -> None:
+    """Concurrent writers and readers of one key only ever see whole values."""
+    env = _server_env(tmp_path, go_harness_executable)
+    values = [f"value-{i}-" * 4096 for i in range(8)]
+    result = _kv(go_harness_executable, env, "put", "contended", values[0])
+    assert result.returncode == 0, result.stderr
+
+    with ThreadPoolExecutor(max_workers=16) as pool:
+        puts = [pool.submit(_kv, go_harness_executable, env, "put", "contended", value) for value in values]
+        gets = [pool.submit(_kv, go_harness_executable, env, "get", "contended") for _ in values]
+        for future in puts:
+            assert future.result().returncode == 0, future.result().stderr
+        for future in gets:
+            result = future.result()
+            assert result.returncode == 0, result.stderr
+            # get ends the value with a newline
+            assert result.stdout.removesuffix("\n") in values, "read a torn or partial value"
+
+    files = _stored_files(env)
+    assert not [path for path in files if ".tmp-" in path.name], f"temporary files left behind: {files}"
+    data_files = [path for path in files if path.name == "kv-data-contended"]
+    assert len(data_files) == 1
+    assert data_files[0].read_text() in values
+
+
+def test_kv_sharded_layout_and_flat_migration(go_harness_executable: Path, tmp_path: Path) -> None:
+    """Values are stored in their shard, and values left in the flat layout move there when read."""
+    env = _server_env(tmp_path, go_harness_executable)
+    result = _kv(go_harness_executable, env, "put", "sharded", "sharded value")
+    assert result.returncode == 0, result.stderr
+    assert _shard_path(env, "sharded").read_text() == "sharded value"
+    assert not (Path(env["KV_STORAGE_DIR"]) / "kv-data-sharded").exists()
+
+    flat = Path(env["KV_STORAGE_DIR"]) / "kv-data-flat"
+    flat.write_text("flat value")
+    result = _kv(go_harness_executable, env, "get", "flat")
+    assert result.returncode == 0, result.stderr
+    assert result.stdout.removesuffix("\n") == "flat value"
+    assert not flat.exists()
+    assert _shard_path(env, "flat").read_text() == "flat value"
+
+
+class _Context:
+    """The parts of grpc.ServicerContext the Python KV servicer uses."""
+
+    code = None
+    details = ""
+
+    def peer(self) -> str:
+        return "local"
+
+    def set_code(self, code: object) -> None:
+        self.code = code
+
+    def set_details(self, details: str) -> None:
+        self.details = details
+
+
+def test_kv_python_and_go_servers_share_a_store(go_harness_executable: Path, tmp_path: Path) -> None:
+    """Each server reads what the other wrote, in either layout."""
+    env = _server_env(tmp_path, go_harness_executable)
+    python_kv = KV(storage_dir=env["KV_STORAGE_DIR"])
+
+    context = _Context()
+    python_kv.Put(kv_pb2.PutRequest(key="from-python", value=b"written by Python"), context)
+    assert context.code is None, context.details
+    assert _shard_path(env, "from-python").read_bytes() == b"written by Python"
+    result = _kv(go_harness_executable, env, "get", "from-python")
+    assert result.returncode == 0, result.stderr
+    assert result.stdout.removesuffix("\n") == "written by Python"
+
+    result = _kv(go_harness_executable, env, "put", "from-go", "written by Go")
+    assert result.returncode == 0, result.stderr
+    response = python_kv.Get(kv_pb2.GetRequest(key="from-go"), context)
+    assert context.code is None, context.details
+    assert response.value == b"written by Go"
+
+    flat = Path(env["KV_STORAGE_DIR"]) / "kv-data-flat-for-python"
+    flat.write_bytes(b"flat value")
+    response = python_kv.Get(kv_pb2.GetRequest(key="flat-for-python"), context)
+    assert context.code is None, context.details
+    assert response.value == b"flat value"
+    assert not flat.exists()
+    assert _shard_path(env, "flat-for-python").read_bytes() == b"flat value"
+
+
+def test_kv_checksum_mismatch_detected_on_get(go_harness_executable: Path, tmp_path: Path) -> None:
+    """A value changed behind the server's back fails Get with DataLoss under --checksum error."""
+    env = _server_env(tmp_path, go_harness_executable, "--checksum", "error")
+    result = _kv(go_harness_executable, env, "put", "checked", "original value")
+    assert result.returncode == 0, result.stderr
+    data_file = _shard_path(env, "checked")
+    checksum_file = data_file.with_name(data_file.name + ".sha256")
+    assert checksum_file.read_text() == hashlib.sha256(b"original value").hexdigest() + "\n"
+
+    data_file.write_text("corrupted value")
+    result = _kv(go_harness_executable, env, "get", "checked")
+    assert result.returncode != 0, "corrupted value was served"
+    assert "DataLoss" in result.stderr
+    assert "checksum mismatch" in result.stderr
+
+    # warn only logs the mismatch
+    env = _server_env(tmp_path, go_harness_executable, "--checksum", "warn")
+    result = _kv(go_harness_executable, env, "get", "checked")
+    assert result.returncode == 0, result.stderr
+    assert result.stdout.removesuffix("\n") == "corrupted value"
+
+
+def test_kv_checksums_written_by_python_are_verified(go_harness_executable: Path, tmp_path: Path) -> None:
+    """The Go server verifies the checksums the Python server writes next to its values."""
+    env = _server_env(tmp_path, go_harness_executable, "--checksum", "error")
+    context = _Context()
+    KV(storage_dir=env["KV_STORAGE_DIR"]).Put(
+        kv_pb2.PutRequest(key="python-checked", value=b"original value"), context
+    )
+    assert context.code is None, context.details
+    result = _kv(go_harness_executable, env, "get", "python-checked")
+    assert result.returncode == 0, result.stderr
+    assert result.stdout.removesuffix("\n") == "original value"
+
+    _shard_path(env, "python-checked").write_bytes(b"corrupted value")
+    result = _kv(go_harness_executable, env, "get", "python-checked")
+    assert result.returncode != 0, "corrupted value was served"
+    assert "DataLoss" in result.stderr
+
+
+def test_kv_lock_timeout_fails_with_unavailable(go_harness_executable: Path, tmp_path: Path) -> None:
+    """A key locked past --lock-timeout fails with Unavailable instead of waiting forever."""
+    env = _server_env(tmp_path, go_harness_executable, "--lock-timeout", "300ms")
+    lock_path = _shard_path(env, "locked").with_name("kv-data-locked.lock")
+    lock_path.parent.mkdir(parents=True)
+
+    with lock_path.open("a") as lock_file:
+        fcntl.flock(lock_file, fcntl.LOCK_EX)
+        result = _kv(go_harness_executable, env, "put", "locked", "value")
+        assert result.returncode != 0, "put succeeded while the key was locked"
+        assert "Unavailable" in result.stderr
+        assert "timed out after 300ms" in result.stderr
+        fcntl.flock(lock_file, fcntl.LOCK_UN)
+
+    result = _kv(go_harness_executable, env, "put", "locked", "value")
+    assert result.returncode == 0, result.stderr
+
+
+# 🥣🔬🔚
@@ -0,0 +1,93 @@
+#
+# SPDX-FileCopyrightText: Copyright (c) 2025 provide.io llc. All rights reserved.
+# SPDX-License-Identifier: Apache-2.0
+#
+
+"""KV API versions served by the Python KV server.
+
+The Python server registers proto.KV, kv.v1 and kv.v2 on one store, as the
+Go server does with kvplugin.RegisterKVServices. These tests serve the
+servicers in-process over an insecure channel, without the plugin handshake.
+"""
+
+from collections.abc import Iterator
+from concurrent import futures
+from pathlib import Path
+
+import grpc
+import pytest
+
+from tofusoup.harness.proto.kv import kv_pb2, kv_pb2_grpc
+from tofusoup.harness.proto.kv.v1 import kv_pb2 as kv_v1_pb2, kv_pb2_grpc as kv_v1_pb2_grpc
+from tofusoup.harness.proto.kv.v2 import kv_pb2 as kv_v2_pb2, kv_pb2_grpc as kv_v2_pb2_grpc
+from tofusoup.rpc.server import KV, add_kv_servicers_to_server
+
+
+@pytest.fixture
+def kv_channel(tmp_path: Path) -> Iterator[grpc.Channel]:
+    """A channel to a Python KV server storing in tmp_path."""
+    server = grpc.server(futures.ThreadPoolExecutor(max_workers=8))
+    add_kv_servicers_to_server(KV(storage_dir=str(tmp_path)), server)
+    port = server.add_insecure_port("127.0.0.1:0")
+    server.start()
+    channel = grpc.insecure_channel(f"127.0.0.1:{port}")
+    try:
+        yield channel
+    finally:
+        channel.close()
+        server.stop(0)
+
+
+def test_kv_versions_share_one_store(kv_channel: grpc.Channel) -> None:
+    """Values put through each version read the same through the others."""
+    legacy = kv_pb2_grpc.KVStub(kv_channel)
+    v1 = kv_v1_pb2_grpc.KVStub(kv_channel)
+    v2 = kv_v2_pb2_grpc.KVStub(kv_channel)
+
+    legacy.Put(kv_pb2.PutRequest(key="legacy", value=b"put through proto.KV"))
+    v1.Put(kv_v1_pb2.PutRequest(key="v1", value=b"put through kv.v1"))
+    v2.Put(kv_v2_pb2.PutRequest(key="v2", value=b"put through kv.v2"))
+
+    for key, value in [
+        ("legacy", b"put through proto.KV"),
+        ("v1", b"put through kv.v1"),
+        ("v2", b"put through kv.v2"),
+    ]:
+        assert legacy.Get(kv_pb2.GetRequest(key=key)).value == value
+        assert v1.Get(kv_v1_pb2.GetRequest(key=key)).value == value
+        assert v2.Get(kv_v2_pb2.GetRequest(key=key)).value == value
+        chunks = v2.GetStream(kv_v2_pb2.GetStreamRequest(key=key, chunk_size=4))
+        assert b"".join(chunk.data for chunk in chunks) == value
+
+    assert list(v2.List(kv_v2_pb2.ListRequest(prefix="")).keys) == ["legacy", "v1", "v2"]
+    assert list(v2.List(kv_v2_pb2.ListRequest(prefix="v")).keys) == ["v1", "v2"]
+
+
+def test_kv_v2_delete_and_watch(kv_channel: grpc.Channel) -> None:
+    """kv.v2 watchers see puts through any version and deletes, for their prefix only."""
+    legacy = kv_pb2_grpc.KVStub(kv_channel)
+    v2 = kv_v2_pb2_grpc.KVStub(kv_channel)
+
+    watch = v2.Watch(kv_v2_pb2.WatchRequest(prefix="watched"))
+    # Headers are sent once the watcher is registered
+    watch.initial_metadata()
+
+    legacy.Put(kv_pb2.PutRequest(key="watched", value=b"value"))
+    v2.Put(kv_v2_pb2.PutRequest(key="unwatched", value=b"other"))
+    assert v2.Delete(kv_v2_pb2.DeleteRequest(key="watched")).existed
+    assert not v2.Delete(kv_v2_pb2.DeleteRequest(key="watched")).existed
+
+    events = [next(watch), next(watch)]
+    watch.cancel()
+    assert [(event.type, event.key, event.value) for event in events] == [
+        (kv_v2_pb2.WatchEvent.TYPE_PUT, "watched", b"value"),
+        (kv_v2_pb2.WatchEvent.TYPE_DELETE, "watched", b""),
+    ]
+
+    with pytest.raises(grpc.RpcError) as exc_info:
+        v2.Get(kv_v2_pb2.GetRequest(key="watched"))
+    assert exc_info.value.code() == grpc.StatusCode.NOT_FOUND
+    assert list(v2.List(kv_v2_pb2.ListRequest(prefix="")).keys) == ["unwatched"]
+
+
+# 🥣🔬🔚
@@ -49,7 +49,9 @@ def _get_cert_fingerprint(cert_pem: str | bytes | None) -> str | None:
 def verify_kv_storage(storage_dir: Path, key: str) -> Path | None:
     """Verify that a KV storage file exists for the given key.
 
-    The server may prefix the key with "kv-data-" when writing to disk.
+    The server may prefix the key with "kv-data-" when writing to disk, and
+    may keep it in the shard directory named by the first byte of the key's
+    SHA-256 in hex.
     """
     # Try direct key name first
     storage_file = storage_dir / key
@@ -61,6 +63,12 @@ def verify_kv_storage(storage_dir: Path, key: str) -> Path | None:
     if storage_file_prefixed.exists():
         return storage_file_prefixed
 
+    # Try the sharded layout both servers use
+    shard = hashlib.sha256(key.encode()).hexdigest()[:2]
+    storage_file_sharded = storage_dir / shard / f"kv-data-{key}"
+    if storage_file_sharded.exists():
+        return storage_file_sharded
+
     # File not found - log warning and list directory contents
     logger.warning(f"⚠️  KV storage file not found: {storage_file} or {storage_file_prefixed}")
     if storage_dir.exists():
@@ -0,0 +1,150 @@
+// Command misbehave_harness_go stands in for a plugin server, as
+// PLUGIN_SERVER_PATH, and fails the go-plugin handshake in a chosen way, so
+// clients' error classification and cleanup paths get negative coverage.
+// It is configured through the environment:
+//
+//	MISBEHAVE_MODE     cookie:  reject the client's magic cookie and exit, as
+//	                            a go-plugin server given the wrong cookie does
+//	                   version: advertise an unsupported protocol version
+//	                   cert:    advertise a certificate other than the one the
+//	                            server presents
+//	                   exit:    exit partway through writing the handshake
+//	MISBEHAVE_VERSION  app protocol version advertised in version mode
+//	                   (default 99)
+//
+// In version and cert modes the harness listens on the advertised address
+// and runs until the client kills it.
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"time"
+)
+
+// Misbehavior modes
+const (
+	modeCookie  = "cookie"
+	modeVersion = "version"
+	modeCert    = "cert"
+	modeExit    = "exit"
+)
+
+// cookieMessage is what go-plugin servers print when started without the
+// expected magic cookie
+const cookieMessage = `This binary is a plugin. These are not meant to be executed directly.
+Please execute the program that consumes these plugins, which will
+load any plugins automatically`
+
+func main() {
+	mode := os.Getenv("MISBEHAVE_MODE")
+	switch mode {
+	case modeCookie:
+		fmt.Fprintln(os.Stderr, cookieMessage)
+		os.Exit(1)
+	case modeExit:
+		fmt.Fprint(os.Stdout, "1|1|tcp|")
+		logf("exiting mid-handshake")
+		os.Exit(1)
+	case modeVersion:
+		version := os.Getenv("MISBEHAVE_VERSION")
+		if version == "" {
+			version = "99"
+		}
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			fail(fmt.Errorf("failed to listen: %w", err))
+		}
+		fmt.Fprintf(os.Stdout, "1|%s|tcp|%s|grpc\n", version, listener.Addr())
+		logf("advertised protocol version %s", version)
+		serve(listener)
+	case modeCert:
+		advertised, err := selfSignedCert()
+		if err != nil {
+			fail(err)
+		}
+		presented, err := selfSignedCert()
+		if err != nil {
+			fail(err)
+		}
+		listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+			Certificates: []tls.Certificate{presented},
+			ClientAuth:   tls.RequestClientCert,
+		})
+		if err != nil {
+			fail(fmt.Errorf("failed to listen: %w", err))
+		}
+		cert := base64.RawStdEncoding.EncodeToString(advertised.Certificate[0])
+		fmt.Fprintf(os.Stdout, "1|1|tcp|%s|grpc|%s\n", listener.Addr(), cert)
+		logf("advertised a certificate the server does not present")
+		serve(listener)
+	default:
+		fail(fmt.Errorf("unknown MISBEHAVE_MODE %q: expected cookie, version, cert or exit", mode))
+	}
+}
+
+// serve accepts connections, completing TLS handshakes where the listener
+// does them, and closes each one, logging what the client did
+func serve(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			fail(fmt.Errorf("failed to accept: %w", err))
+		}
+		if tlsConn, ok := conn.(*tls.Conn); ok {
+			if err := tlsConn.Handshake(); err != nil {
+				logf("client %s failed the TLS handshake: %s", conn.RemoteAddr(), err)
+			}
+		} else {
+			logf("client %s connected", conn.RemoteAddr())
+		}
+		conn.Close()
+	}
+}
+
+// selfSignedCert generates a throwaway localhost certificate
+func selfSignedCert() (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to generate key: %w", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to create certificate: %w", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
+
+// logf writes to stderr, which go-plugin clients log, leaving stdout to the
+// handshake
+func logf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "misbehave: "+format+"\n", args...)
+}
+
+func fail(err error) {
+	logf("%s", err)
+	os.Exit(1)
+}
+
+// 🍲🥄📄🪄
@@ -1,25 +1,160 @@
 package main
 
 import (
+	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
 )
 
+// Exit codes
+const (
+	exitUsage     = 1
+	exitAssertion = 2
+)
+
+// stringList collects a repeatable flag
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// report is the --json output
+type report struct {
+	// Vars maps each printed variable to its value, or null when unset
+	Vars     map[string]*string `json:"vars"`
+	Failures []string           `json:"failures"`
+	Stdin    *string            `json:"stdin,omitempty"`
+}
+
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Fprintln(os.Stderr, "Usage: printenv_harness_go <ENV_VAR_NAME_1> [ENV_VAR_NAME_2 ...]")
-		os.Exit(1)
+	var (
+		jsonOutput bool
+		quote      bool
+		echoStdin  bool
+		require    stringList
+	)
+	flags := flag.NewFlagSet("printenv_harness_go", flag.ContinueOnError)
+	flags.BoolVar(&jsonOutput, "json", false, "Print a JSON object instead of NAME=value lines")
+	flags.BoolVar(&quote, "quote", false, "Quote values, escaping newlines and other special characters")
+	flags.BoolVar(&echoStdin, "stdin", false, "Echo stdin after the variables")
+	flags.Var(&require, "require", "Fail with exit code 2 unless NAME is set, or NAME=VALUE has that value (repeatable)")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: printenv_harness_go [flags] <ENV_VAR_NAME_OR_GLOB_1> [ENV_VAR_NAME_OR_GLOB_2 ...]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(os.Args[1:]); err != nil {
+		os.Exit(exitUsage)
+	}
+	if flags.NArg() == 0 && len(require) == 0 && !echoStdin {
+		flags.Usage()
+		os.Exit(exitUsage)
+	}
+
+	names, err := expandNames(flags.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitUsage)
+	}
+	failures := checkRequired(require)
+
+	var stdin *string
+	if echoStdin {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to read stdin: %s\n", err)
+			os.Exit(exitUsage)
+		}
+		s := string(data)
+		stdin = &s
 	}
 
-	envVarNames := os.Args[1:]
-	for _, varName := range envVarNames {
-		value := os.Getenv(varName)
-		// To make multiline content clearly visible and distinguishable from a path,
-		// let's wrap the value in quotes and escape newlines if any.
-		// However, for this initial test, a direct print is fine to see raw behavior.
-		// A more robust version might use fmt.Printf("%s=%q\n", varName, value) to quote it.
-		fmt.Printf("%s=%s\n", varName, value)
+	if jsonOutput {
+		out := report{Vars: make(map[string]*string), Failures: failures, Stdin: stdin}
+		for _, name := range names {
+			if value, ok := os.LookupEnv(name); ok {
+				out.Vars[name] = &value
+			} else {
+				out.Vars[name] = nil
+			}
+		}
+		if err := json.NewEncoder(os.Stdout).Encode(out); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to encode JSON: %s\n", err)
+			os.Exit(exitUsage)
+		}
+	} else {
+		for _, name := range names {
+			value := os.Getenv(name)
+			if quote {
+				// Quoting keeps multiline values on one line, so they can't
+				// be mistaken for further variables
+				value = strconv.Quote(value)
+			}
+			fmt.Printf("%s=%s\n", name, value)
+		}
+		if stdin != nil {
+			fmt.Print(*stdin)
+		}
+		for _, failure := range failures {
+			fmt.Fprintln(os.Stderr, failure)
+		}
+	}
+
+	if len(failures) > 0 {
+		os.Exit(exitAssertion)
+	}
+}
+
+// expandNames replaces glob patterns with the names of the set variables
+// they match, in sorted order. Plain names are kept even when unset.
+func expandNames(args []string) ([]string, error) {
+	var names []string
+	for _, arg := range args {
+		if !strings.ContainsAny(arg, "*?[") {
+			names = append(names, arg)
+			continue
+		}
+		var matched []string
+		for _, entry := range os.Environ() {
+			name, _, _ := strings.Cut(entry, "=")
+			ok, err := path.Match(arg, name)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", arg, err)
+			}
+			if ok {
+				matched = append(matched, name)
+			}
+		}
+		sort.Strings(matched)
+		names = append(names, matched...)
+	}
+	return names, nil
+}
+
+// checkRequired returns a message for every --require assertion that does
+// not hold
+func checkRequired(require []string) []string {
+	failures := []string{}
+	for _, assertion := range require {
+		name, want, hasValue := strings.Cut(assertion, "=")
+		value, ok := os.LookupEnv(name)
+		switch {
+		case !ok:
+			failures = append(failures, fmt.Sprintf("required variable %s is not set", name))
+		case hasValue && value != want:
+			failures = append(failures, fmt.Sprintf("required variable %s is %q, expected %q", name, value, want))
+		}
 	}
+	return failures
 }
 
 // 🍲🥄📄🪄
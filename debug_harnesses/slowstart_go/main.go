@@ -0,0 +1,135 @@
+// Command slowstart_harness_go stands in for a plugin server, as
+// PLUGIN_SERVER_PATH, and delays or corrupts its go-plugin handshake, so
+// client startup timeout and retry behavior can be tested deterministically.
+// It is configured through the environment, which clients in every language
+// pass through to the servers they spawn:
+//
+//	SLOWSTART_DELAY       wait before the handshake, e.g. "5s" (default 0)
+//	SLOWSTART_MODE        delay:   run SLOWSTART_SERVER after the delay (default)
+//	                      partial: write a handshake line cut off before its end
+//	                      garbled: write a complete line that is not a handshake
+//	                      silent:  never write a handshake
+//	SLOWSTART_SERVER      real server run in delay mode with this process's
+//	                      arguments, e.g. soup-go
+//	SLOWSTART_EXIT_AFTER  in the failure modes, exit with code 1 this long
+//	                      after writing (default: run until killed)
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"time"
+)
+
+// Handshake modes
+const (
+	modeDelay   = "delay"
+	modePartial = "partial"
+	modeGarbled = "garbled"
+	modeSilent  = "silent"
+)
+
+// partialHandshake is the start of a go-plugin handshake line, without the
+// address, protocol and trailing newline
+const partialHandshake = "1|1|tcp|127.0.0.1:"
+
+// garbledHandshake has the handshake's shape but no valid field
+const garbledHandshake = "not|a|go-plugin|handshake\n"
+
+func main() {
+	delay, err := durationEnv("SLOWSTART_DELAY")
+	if err != nil {
+		fail(err)
+	}
+	exitAfter, err := durationEnv("SLOWSTART_EXIT_AFTER")
+	if err != nil {
+		fail(err)
+	}
+	mode := os.Getenv("SLOWSTART_MODE")
+	if mode == "" {
+		mode = modeDelay
+	}
+
+	logf("mode %s, delaying handshake by %s", mode, delay)
+	time.Sleep(delay)
+
+	switch mode {
+	case modeDelay:
+		os.Exit(runServer(os.Getenv("SLOWSTART_SERVER"), os.Args[1:]))
+	case modePartial:
+		fmt.Fprint(os.Stdout, partialHandshake)
+	case modeGarbled:
+		fmt.Fprint(os.Stdout, garbledHandshake)
+	case modeSilent:
+	default:
+		fail(fmt.Errorf("unknown SLOWSTART_MODE %q: expected delay, partial, garbled or silent", mode))
+	}
+
+	if exitAfter > 0 {
+		time.Sleep(exitAfter)
+		logf("exiting after %s without completing the handshake", exitAfter)
+		os.Exit(1)
+	}
+	// The client is expected to give up and kill us
+	select {}
+}
+
+// runServer runs the real server with the harness's stdio, so the server's
+// handshake reaches the client unchanged, and returns its exit code
+func runServer(path string, args []string) int {
+	if path == "" {
+		fail(errors.New("SLOWSTART_SERVER must name the server to run in delay mode"))
+	}
+	cmd := exec.Command(path, args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Start(); err != nil {
+		fail(fmt.Errorf("failed to start %s: %w", path, err))
+	}
+
+	// Pass interrupts on, so the server shuts down as it would unwrapped
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	go func() {
+		for sig := range signals {
+			cmd.Process.Signal(sig)
+		}
+	}()
+
+	if err := cmd.Wait(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode()
+		}
+		fail(err)
+	}
+	return 0
+}
+
+// durationEnv parses a duration environment variable, 0 when unset
+func durationEnv(name string) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return d, nil
+}
+
+// logf writes to stderr, which go-plugin clients log, leaving stdout to the
+// handshake
+func logf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "slowstart: "+format+"\n", args...)
+}
+
+func fail(err error) {
+	logf("%s", err)
+	os.Exit(1)
+}
+
+// 🍲🥄📄🪄
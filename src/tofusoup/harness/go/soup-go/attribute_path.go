@@ -0,0 +1,456 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/spf13/cobra"
+	"github.com/zclconf/go-cty/cty"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/ctyspec"
+)
+
+// Field numbers of the tfplugin6 AttributePath messages:
+//
+//	message AttributePath {
+//	    message Step {
+//	        oneof selector {
+//	            string attribute_name = 1;
+//	            string element_key_string = 2;
+//	            int64 element_key_int = 3;
+//	        }
+//	    }
+//	    repeated Step steps = 1;
+//	}
+const (
+	attributePathStepsField       protowire.Number = 1
+	attributePathAttributeName    protowire.Number = 1
+	attributePathElementKeyString protowire.Number = 2
+	attributePathElementKeyInt    protowire.Number = 3
+)
+
+// AttributePathStep is the JSON representation of a single AttributePath
+// step. Exactly one field is set, mirroring the proto oneof.
+type AttributePathStep struct {
+	AttributeName    *string `json:"attribute_name,omitempty"`
+	ElementKeyString *string `json:"element_key_string,omitempty"`
+	ElementKeyInt    *int64  `json:"element_key_int,omitempty"`
+}
+
+// ctyPathToAttributePath converts a cty.Path into a tftypes.AttributePath.
+// Index steps keyed by set element values have no proto encoding and are
+// rejected.
+func ctyPathToAttributePath(path cty.Path) (*tftypes.AttributePath, error) {
+	ap := tftypes.NewAttributePath()
+	for i, step := range path {
+		switch s := step.(type) {
+		case cty.GetAttrStep:
+			ap = ap.WithAttributeName(s.Name)
+		case cty.IndexStep:
+			switch {
+			case s.Key.IsNull() || !s.Key.IsKnown():
+				return nil, fmt.Errorf("step %d: index key must be known and not null", i)
+			case s.Key.Type() == cty.String:
+				ap = ap.WithElementKeyString(s.Key.AsString())
+			case s.Key.Type() == cty.Number:
+				idx, accuracy := s.Key.AsBigFloat().Int64()
+				if accuracy != big.Exact {
+					return nil, fmt.Errorf("step %d: index key %s is not an integer", i, s.Key.AsBigFloat().Text('f', -1))
+				}
+				ap = appendAttributePathStep(ap, tftypes.ElementKeyInt(idx))
+			default:
+				return nil, fmt.Errorf("step %d: index keys of type %s cannot be encoded in an AttributePath", i, s.Key.Type().FriendlyName())
+			}
+		default:
+			return nil, fmt.Errorf("step %d: unsupported path step %T", i, step)
+		}
+	}
+	return ap, nil
+}
+
+// appendAttributePathStep returns a copy of ap with step appended. Unlike
+// WithElementKeyInt, this keeps the full int64 range of element keys.
+func appendAttributePathStep(ap *tftypes.AttributePath, step tftypes.AttributePathStep) *tftypes.AttributePath {
+	return tftypes.NewAttributePathWithSteps(append(ap.Steps(), step))
+}
+
+// attributePathToCtyPath converts a tftypes.AttributePath into a cty.Path
+func attributePathToCtyPath(ap *tftypes.AttributePath) (cty.Path, error) {
+	if ap == nil {
+		return nil, nil
+	}
+	path := make(cty.Path, 0, len(ap.Steps()))
+	for i, step := range ap.Steps() {
+		switch s := step.(type) {
+		case tftypes.AttributeName:
+			path = path.GetAttr(string(s))
+		case tftypes.ElementKeyString:
+			path = path.Index(cty.StringVal(string(s)))
+		case tftypes.ElementKeyInt:
+			path = path.Index(cty.NumberIntVal(int64(s)))
+		default:
+			return nil, fmt.Errorf("step %d: unsupported attribute path step %T", i, step)
+		}
+	}
+	return path, nil
+}
+
+// encodeAttributePathProto encodes an AttributePath in the tfplugin6 protobuf wire format
+func encodeAttributePathProto(ap *tftypes.AttributePath) ([]byte, error) {
+	var out []byte
+	for i, step := range ap.Steps() {
+		var stepBytes []byte
+		switch s := step.(type) {
+		case tftypes.AttributeName:
+			stepBytes = protowire.AppendTag(stepBytes, attributePathAttributeName, protowire.BytesType)
+			stepBytes = protowire.AppendString(stepBytes, string(s))
+		case tftypes.ElementKeyString:
+			stepBytes = protowire.AppendTag(stepBytes, attributePathElementKeyString, protowire.BytesType)
+			stepBytes = protowire.AppendString(stepBytes, string(s))
+		case tftypes.ElementKeyInt:
+			stepBytes = protowire.AppendTag(stepBytes, attributePathElementKeyInt, protowire.VarintType)
+			stepBytes = protowire.AppendVarint(stepBytes, uint64(int64(s)))
+		default:
+			return nil, fmt.Errorf("step %d: %T has no protobuf encoding", i, step)
+		}
+		out = protowire.AppendTag(out, attributePathStepsField, protowire.BytesType)
+		out = protowire.AppendBytes(out, stepBytes)
+	}
+	return out, nil
+}
+
+// decodeAttributePathProto decodes an AttributePath from the tfplugin6 protobuf wire format
+func decodeAttributePathProto(data []byte) (*tftypes.AttributePath, error) {
+	ap := tftypes.NewAttributePath()
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return nil, fmt.Errorf("invalid AttributePath: %w", protowire.ParseError(n))
+		}
+		data = data[n:]
+
+		if num != attributePathStepsField || typ != protowire.BytesType {
+			// Skip unknown fields, as proto3 decoders must
+			n = protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return nil, fmt.Errorf("invalid AttributePath: %w", protowire.ParseError(n))
+			}
+			data = data[n:]
+			continue
+		}
+
+		stepBytes, n := protowire.ConsumeBytes(data)
+		if n < 0 {
+			return nil, fmt.Errorf("invalid AttributePath step: %w", protowire.ParseError(n))
+		}
+		data = data[n:]
+
+		step, err := decodeAttributePathStep(stepBytes)
+		if err != nil {
+			return nil, fmt.Errorf("step %d: %w", len(ap.Steps()), err)
+		}
+		ap = appendAttributePathStep(ap, step)
+	}
+	return ap, nil
+}
+
+// decodeAttributePathStep decodes a single AttributePath.Step message. As
+// with any proto oneof, the last selector present on the wire wins.
+func decodeAttributePathStep(data []byte) (tftypes.AttributePathStep, error) {
+	var step tftypes.AttributePathStep
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		switch {
+		case num == attributePathAttributeName && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(data)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			step = tftypes.AttributeName(v)
+			data = data[n:]
+		case num == attributePathElementKeyString && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(data)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			step = tftypes.ElementKeyString(v)
+			data = data[n:]
+		case num == attributePathElementKeyInt && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(data)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			step = tftypes.ElementKeyInt(int64(v))
+			data = data[n:]
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			data = data[n:]
+		}
+	}
+	if step == nil {
+		return nil, fmt.Errorf("step has no selector set")
+	}
+	return step, nil
+}
+
+// attributePathFromSteps builds an AttributePath from its JSON step representation
+func attributePathFromSteps(steps []AttributePathStep) (*tftypes.AttributePath, error) {
+	ap := tftypes.NewAttributePath()
+	for i, step := range steps {
+		set := 0
+		if step.AttributeName != nil {
+			ap = ap.WithAttributeName(*step.AttributeName)
+			set++
+		}
+		if step.ElementKeyString != nil {
+			ap = ap.WithElementKeyString(*step.ElementKeyString)
+			set++
+		}
+		if step.ElementKeyInt != nil {
+			ap = appendAttributePathStep(ap, tftypes.ElementKeyInt(*step.ElementKeyInt))
+			set++
+		}
+		if set != 1 {
+			return nil, fmt.Errorf("step %d: exactly one of attribute_name, element_key_string, element_key_int must be set", i)
+		}
+	}
+	return ap, nil
+}
+
+// attributePathToSteps converts an AttributePath to its JSON step representation
+func attributePathToSteps(ap *tftypes.AttributePath) ([]AttributePathStep, error) {
+	steps := make([]AttributePathStep, 0, len(ap.Steps()))
+	for i, step := range ap.Steps() {
+		switch s := step.(type) {
+		case tftypes.AttributeName:
+			v := string(s)
+			steps = append(steps, AttributePathStep{AttributeName: &v})
+		case tftypes.ElementKeyString:
+			v := string(s)
+			steps = append(steps, AttributePathStep{ElementKeyString: &v})
+		case tftypes.ElementKeyInt:
+			v := int64(s)
+			steps = append(steps, AttributePathStep{ElementKeyInt: &v})
+		default:
+			return nil, fmt.Errorf("step %d: unsupported attribute path step %T", i, step)
+		}
+	}
+	return steps, nil
+}
+
+// formatCtyPath renders a cty.Path using HCL traversal syntax, e.g. foo[0]["k"]
+func formatCtyPath(path cty.Path) string {
+	return ctyspec.FormatPath(path)
+}
+
+// attributePathReport describes an AttributePath in every supported encoding
+func attributePathReport(ap *tftypes.AttributePath) (map[string]interface{}, error) {
+	steps, err := attributePathToSteps(ap)
+	if err != nil {
+		return nil, err
+	}
+	path, err := attributePathToCtyPath(ap)
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := encodeAttributePathProto(ap)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"steps":        steps,
+		"string":       formatCtyPath(path),
+		"proto_hex":    hex.EncodeToString(encoded),
+		"proto_base64": base64.StdEncoding.EncodeToString(encoded),
+	}, nil
+}
+
+// AttributePathVector is a golden test vector for AttributePath encoding
+type AttributePathVector struct {
+	Name     string              `json:"name"`
+	Steps    []AttributePathStep `json:"steps"`
+	String   string              `json:"string"`
+	ProtoHex string              `json:"proto_hex"`
+}
+
+// checkAttributePathVector verifies encode and decode of a single vector,
+// returning a list of mismatches
+func checkAttributePathVector(vector AttributePathVector) []string {
+	var failures []string
+
+	ap, err := attributePathFromSteps(vector.Steps)
+	if err != nil {
+		return []string{fmt.Sprintf("invalid steps: %s", err)}
+	}
+
+	encoded, err := encodeAttributePathProto(ap)
+	if err != nil {
+		failures = append(failures, fmt.Sprintf("encode failed: %s", err))
+	} else if got := hex.EncodeToString(encoded); got != vector.ProtoHex {
+		failures = append(failures, fmt.Sprintf("encode mismatch: expected %s, got %s", vector.ProtoHex, got))
+	}
+
+	wire, err := hex.DecodeString(vector.ProtoHex)
+	if err != nil {
+		return append(failures, fmt.Sprintf("invalid proto_hex: %s", err))
+	}
+	decoded, err := decodeAttributePathProto(wire)
+	if err != nil {
+		return append(failures, fmt.Sprintf("decode failed: %s", err))
+	}
+	if !decoded.Equal(ap) {
+		failures = append(failures, fmt.Sprintf("decode mismatch: expected %s, got %s", ap, decoded))
+	}
+
+	path, err := attributePathToCtyPath(decoded)
+	if err != nil {
+		return append(failures, fmt.Sprintf("cty conversion failed: %s", err))
+	}
+	if got := formatCtyPath(path); got != vector.String {
+		failures = append(failures, fmt.Sprintf("string mismatch: expected %s, got %s", vector.String, got))
+	}
+	roundTrip, err := ctyPathToAttributePath(path)
+	if err != nil {
+		failures = append(failures, fmt.Sprintf("cty round trip failed: %s", err))
+	} else if !roundTrip.Equal(ap) {
+		failures = append(failures, fmt.Sprintf("cty round trip mismatch: got %s", roundTrip))
+	}
+
+	return failures
+}
+
+// initCtyPathCmd creates the `cty path` command group
+func initCtyPathCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "path",
+		Short: "Encode and decode tfprotov6 AttributePath messages",
+		Long: `Convert between cty.Path values and the tfplugin6 AttributePath protobuf
+message used in provider diagnostics. Steps are given as JSON, e.g.
+[{"attribute_name":"tags"},{"element_key_string":"env"}].`,
+	}
+
+	var decodeEncoding string
+
+	encodeCmd := &cobra.Command{
+		Use:   "encode [steps-json]",
+		Short: "Encode AttributePath steps to protobuf",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var steps []AttributePathStep
+			if err := json.Unmarshal([]byte(args[0]), &steps); err != nil {
+				return fmt.Errorf("failed to parse steps: %w", err)
+			}
+			ap, err := attributePathFromSteps(steps)
+			if err != nil {
+				return err
+			}
+			report, err := attributePathReport(ap)
+			if err != nil {
+				return err
+			}
+			return json.NewEncoder(os.Stdout).Encode(report)
+		},
+	}
+
+	decodeCmd := &cobra.Command{
+		Use:   "decode [encoded-path]",
+		Short: "Decode a protobuf AttributePath",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var wire []byte
+			var err error
+			switch decodeEncoding {
+			case "base64":
+				wire, err = base64.StdEncoding.DecodeString(args[0])
+			case "hex":
+				wire, err = hex.DecodeString(args[0])
+			default:
+				return fmt.Errorf("unsupported input encoding: %s", decodeEncoding)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to decode %s input: %w", decodeEncoding, err)
+			}
+			ap, err := decodeAttributePathProto(wire)
+			if err != nil {
+				return err
+			}
+			report, err := attributePathReport(ap)
+			if err != nil {
+				return err
+			}
+			return json.NewEncoder(os.Stdout).Encode(report)
+		},
+	}
+	decodeCmd.Flags().StringVar(&decodeEncoding, "input-encoding", "base64", "Encoding of the input (base64, hex)")
+
+	checkCmd := &cobra.Command{
+		Use:   "check [vectors-file]",
+		Short: "Verify AttributePath golden vectors",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var data []byte
+			var err error
+			if args[0] == "-" {
+				data, err = io.ReadAll(os.Stdin)
+			} else {
+				data, err = os.ReadFile(args[0])
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read vectors: %w", err)
+			}
+
+			var vectors []AttributePathVector
+			if err := json.Unmarshal(data, &vectors); err != nil {
+				return fmt.Errorf("failed to parse vectors: %w", err)
+			}
+
+			failed := 0
+			results := make([]map[string]interface{}, 0, len(vectors))
+			for _, vector := range vectors {
+				failures := checkAttributePathVector(vector)
+				result := map[string]interface{}{
+					"name":   vector.Name,
+					"passed": len(failures) == 0,
+				}
+				if len(failures) > 0 {
+					result["failures"] = failures
+					failed++
+				}
+				results = append(results, result)
+			}
+
+			output := map[string]interface{}{
+				"total":   len(vectors),
+				"failed":  failed,
+				"results": results,
+			}
+			if err := json.NewEncoder(os.Stdout).Encode(output); err != nil {
+				return fmt.Errorf("failed to encode JSON: %w", err)
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d of %d vectors failed", failed, len(vectors))
+			}
+			return nil
+		},
+	}
+
+	cmd.AddCommand(encodeCmd)
+	cmd.AddCommand(decodeCmd)
+	cmd.AddCommand(checkCmd)
+	return cmd
+}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+
+	"github.com/spf13/cobra"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/ctyspec"
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/limits"
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/wirecodec"
+)
+
+// maxBatchLineSize bounds a single corpus line
+const maxBatchLineSize = 64 * 1024 * 1024
+
+// BatchItem is one line of a JSONL corpus. Input is the item's data: any
+// JSON value for the json format, or a base64 string for msgpack. Type
+// overrides the command's --type for this item.
+type BatchItem struct {
+	Name  string          `json:"name,omitempty"`
+	Type  json.RawMessage `json:"type,omitempty"`
+	Input json.RawMessage `json:"input"`
+}
+
+// BatchResult is one line of batch output, in corpus order
+type BatchResult struct {
+	Index  int         `json:"index"`
+	Name   string      `json:"name,omitempty"`
+	Output interface{} `json:"output,omitempty"`
+	Error  string      `json:"error,omitempty"`
+	// Limit details an error caused by a decoding limit
+	Limit *limits.ExceededError `json:"limit,omitempty"`
+}
+
+// batchJobs resolves a --jobs value, where zero or less means one worker
+// per CPU
+func batchJobs(jobs int) int {
+	if jobs <= 0 {
+		return runtime.NumCPU()
+	}
+	return jobs
+}
+
+// runOrdered applies work to items on a pool of jobs workers and passes
+// the results to emit in input order as soon as each prefix is complete.
+// Once emit fails the remaining results are drained and discarded.
+func runOrdered[T, R any](jobs int, items <-chan T, work func(index int, item T) R, emit func(R) error) error {
+	type indexed[V any] struct {
+		index int
+		value V
+	}
+
+	jobs = batchJobs(jobs)
+	in := make(chan indexed[T], jobs)
+	out := make(chan indexed[R], jobs)
+
+	go func() {
+		defer close(in)
+		index := 0
+		for item := range items {
+			in <- indexed[T]{index, item}
+			index++
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range in {
+				out <- indexed[R]{job.index, work(job.index, job.value)}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	var emitErr error
+	pending := make(map[int]R)
+	next := 0
+	for result := range out {
+		pending[result.index] = result.value
+		for {
+			value, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			if emitErr == nil {
+				emitErr = emit(value)
+			}
+		}
+	}
+	return emitErr
+}
+
+// sliceItems feeds a slice to runOrdered
+func sliceItems[T any](s []T) <-chan T {
+	items := make(chan T)
+	go func() {
+		defer close(items)
+		for _, item := range s {
+			items <- item
+		}
+	}()
+	return items
+}
+
+// scanCorpusLines sends each non-blank line of a JSONL corpus to lines,
+// closing it at the end of input. The returned channel yields the read
+// error, if any, once lines is closed.
+func scanCorpusLines(r io.Reader) (<-chan []byte, <-chan error) {
+	lines := make(chan []byte)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		defer close(lines)
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxBatchLineSize)
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			lines <- append([]byte(nil), line...)
+		}
+		errc <- scanner.Err()
+	}()
+	return lines, errc
+}
+
+// decodeBatchInput returns an item's input bytes for the given format
+func decodeBatchInput(input json.RawMessage, format string) ([]byte, error) {
+	if len(input) == 0 {
+		return nil, fmt.Errorf("item has no input")
+	}
+	if format != "msgpack" {
+		return input, nil
+	}
+	var encoded string
+	if err := json.Unmarshal(input, &encoded); err != nil {
+		return nil, fmt.Errorf("msgpack input must be a base64 string")
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode base64 input: %w", err)
+	}
+	return data, nil
+}
+
+// encodeBatchOutput returns output data in a form that embeds in a result
+// line: inline JSON for the json format, base64 for msgpack and a string
+// for hcl
+func encodeBatchOutput(data []byte, format string) (interface{}, error) {
+	switch format {
+	case "msgpack":
+		return base64.StdEncoding.EncodeToString(data), nil
+	case "hcl":
+		return string(data), nil
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		return nil, fmt.Errorf("output is not valid JSON: %w", err)
+	}
+	return json.RawMessage(compact.Bytes()), nil
+}
+
+// processBatchLine parses a corpus line and converts it, isolating parse
+// errors and panics to the item
+func processBatchLine(index int, line []byte, convert func(item BatchItem) (interface{}, error)) (result BatchResult) {
+	result.Index = index
+	defer func() {
+		if r := recover(); r != nil {
+			result.Output = nil
+			result.Error = fmt.Sprintf("panic: %v", r)
+		}
+	}()
+
+	var item BatchItem
+	if err := json.Unmarshal(line, &item); err != nil {
+		result.Error = fmt.Sprintf("invalid corpus line: %s", err)
+		return result
+	}
+	result.Name = item.Name
+
+	output, err := convert(item)
+	if err != nil {
+		result.Error = err.Error()
+		result.Limit = limits.As(err)
+		return result
+	}
+	result.Output = output
+	return result
+}
+
+// runBatchCorpus converts every item of the JSONL corpus at path ("-" for
+// stdin) with jobs workers, writing one BatchResult line per item to stdout
+// in corpus order. It returns an error if the corpus could not be read or
+// any item failed.
+func runBatchCorpus(path string, jobs int, output outputBufferOptions, convert func(item BatchItem) (interface{}, error)) error {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open corpus: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	lines, scanErr := scanCorpusLines(r)
+	w := newJSONLWriter(os.Stdout, output)
+	total, failed := 0, 0
+	err := runOrdered(jobs, lines, func(index int, line []byte) BatchResult {
+		return processBatchLine(index, line, convert)
+	}, func(result BatchResult) error {
+		total++
+		if result.Error != "" {
+			failed++
+		}
+		return w.WriteLine(result)
+	})
+	if flushErr := w.Flush(); err == nil {
+		err = flushErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write results: %w", err)
+	}
+	if err := <-scanErr; err != nil {
+		return fmt.Errorf("failed to read corpus: %w", err)
+	}
+
+	logger.Info("📦✅ batch complete", "total", total, "failed", failed, "jobs", batchJobs(jobs))
+	if failed > 0 {
+		return fmt.Errorf("%d of %d items failed", failed, total)
+	}
+	return nil
+}
+
+// batchTypeJSON returns an item's type, falling back to the command's
+func batchTypeJSON(item BatchItem, fallback string) string {
+	if len(item.Type) > 0 {
+		return string(item.Type)
+	}
+	return fallback
+}
+
+// initCtyBatchCmd creates the `cty batch` command
+func initCtyBatchCmd() *cobra.Command {
+	var (
+		inputFormat  string
+		outputFormat string
+		typeJSON     string
+		dialect      string
+		jobs         int
+		output       outputBufferOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "batch [corpus]",
+		Short: "Convert a JSONL corpus of CTY values in parallel",
+		Long: `Convert every item of a JSONL corpus ("-" for stdin) as 'cty convert' would,
+using --jobs workers. Each line is an object of the form
+  {"name": "...", "type": <cty JSON type>, "input": <value>}
+where input is inline JSON for --input-format json or a base64 string for
+msgpack, and type defaults to --type. One result line is written per item,
+in corpus order; a failing item records its error without stopping the rest.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if dialect != "cty" && dialect != "tftypes" {
+				return fmt.Errorf("unsupported dialect: %s", dialect)
+			}
+			return runBatchCorpus(args[0], jobs, output, func(item BatchItem) (interface{}, error) {
+				ctyType, err := parseCtyType(json.RawMessage(batchTypeJSON(item, typeJSON)))
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse type: %w", err)
+				}
+				inputData, err := decodeBatchInput(item.Input, inputFormat)
+				if err != nil {
+					return nil, err
+				}
+				var outputData []byte
+				if dialect == "tftypes" {
+					outputData, err = convertTftypesData(ctyType, inputData, inputFormat, outputFormat)
+				} else {
+					outputData, err = ctyspec.Convert(ctyType, inputData, inputFormat, outputFormat, ctyOptions())
+				}
+				if err != nil {
+					return nil, err
+				}
+				return encodeBatchOutput(outputData, outputFormat)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&inputFormat, "input-format", "json", "Input format (json, msgpack)")
+	cmd.Flags().StringVar(&outputFormat, "output-format", "json", "Output format (json, msgpack, hcl)")
+	cmd.Flags().StringVar(&typeJSON, "type", "", "CTY type specification as JSON for items without a type")
+	cmd.Flags().StringVar(&dialect, "dialect", "cty", "Value model used for decoding/encoding (cty, tftypes)")
+	cmd.Flags().IntVar(&jobs, "jobs", 0, "Number of parallel workers (default: number of CPUs)")
+	addOutputBufferFlags(cmd, &output)
+	return withProfiles(addTypeFileFlags(cmd, false, "type"))
+}
+
+// initWireBatchCmd creates the `wire batch` command
+func initWireBatchCmd() *cobra.Command {
+	var (
+		decode       bool
+		inputFormat  string
+		outputFormat string
+		typeJSON     string
+		jobs         int
+		output       outputBufferOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "batch [corpus]",
+		Short: "Encode or decode a JSONL corpus in parallel",
+		Long: `Encode every item of a JSONL corpus ("-" for stdin) as 'wire encode' would,
+or decode it as 'wire decode' would with --decode, using --jobs workers. Lines
+have the same form as for 'cty batch'; msgpack data is carried as base64. One
+result line is written per item, in corpus order; a failing item records its
+error without stopping the rest.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !cmd.Flags().Changed("input-format") && decode {
+				inputFormat = "msgpack"
+			}
+			if !cmd.Flags().Changed("output-format") && decode {
+				outputFormat = "json"
+			}
+			return runBatchCorpus(args[0], jobs, output, func(item BatchItem) (interface{}, error) {
+				inputData, err := decodeBatchInput(item.Input, inputFormat)
+				if err != nil {
+					return nil, err
+				}
+				ty, err := wireType(batchTypeJSON(item, typeJSON))
+				if err != nil {
+					return nil, err
+				}
+				var outputData []byte
+				if decode {
+					outputData, err = wirecodec.Decode(ty, inputData, inputFormat, outputFormat, ctyOptions())
+				} else {
+					outputData, err = wirecodec.Encode(ty, inputData, outputFormat, ctyOptions())
+				}
+				if err != nil {
+					return nil, err
+				}
+				return encodeBatchOutput(outputData, outputFormat)
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&decode, "decode", false, "Decode items instead of encoding them")
+	cmd.Flags().StringVar(&inputFormat, "input-format", "json", "Input format (json; msgpack with --decode)")
+	cmd.Flags().StringVar(&outputFormat, "output-format", "msgpack", "Output format (msgpack, json; json with --decode)")
+	cmd.Flags().StringVar(&typeJSON, "type", "", "Type specification as JSON for items without a type (optional)")
+	cmd.Flags().IntVar(&jobs, "jobs", 0, "Number of parallel workers (default: number of CPUs)")
+	addOutputBufferFlags(cmd, &output)
+	return withProfiles(addTypeFileFlags(cmd, false, "type"))
+}
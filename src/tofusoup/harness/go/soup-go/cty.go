@@ -2,16 +2,17 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"math/big"
 	"os"
-	"strings"
 
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
 	"github.com/spf13/cobra"
 	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
 	ctyjson "github.com/zclconf/go-cty/cty/json"
-	"github.com/zclconf/go-cty/cty/msgpack"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/ctyspec"
 )
 
 // CTY command flags
@@ -19,389 +20,404 @@ var (
 	ctyInputFormat  string
 	ctyOutputFormat string
 	ctyTypeJSON     string
+	ctyDialect      string
+	ctyTargetType   string
 )
 
 // Override the convert command with real implementation
 func initCtyConvertCmd() *cobra.Command {
+	var stream string
+	var dir, outDir, manifestPath string
+	var jobs int
+	var defaultsReport string
+
 	cmd := &cobra.Command{
 		Use:   "convert [input] [output]",
 		Short: "Convert CTY values between formats",
-		Args:  cobra.ExactArgs(2),
+		Long: `Convert a CTY value of --type between JSON and msgpack.
+
+With the cty dialect, JSON input may mark any value unknown with the object
+{"__unknown": true}, optionally refined with {"__unknown": true,
+"refinements": {...}} using the keys is_known_null, string_prefix,
+number_lower_bound, number_upper_bound ([number-string, inclusive]),
+collection_length_lower_bound and collection_length_upper_bound. Unknowns are
+written to msgpack as extension 0; JSON output cannot hold them.
+
+--output-format hcl renders the value as a formatted HCL literal expression,
+as written with hclwrite, for generating HCL fixtures from typed values. Like
+JSON it cannot hold unknowns.
+
+A type expression given as --type may declare optional attribute defaults, as
+in object({port=optional(number, 80)}). Null and missing optional attributes
+then take their defaults, as Terraform fills in variables, and
+--defaults-report writes the paths that did as {"defaulted": ["port"]}.
+
+With --stream ndjson, each line of [input] is an independent value of --type,
+converted on its own: inline JSON for --input-format json or a base64 string
+for msgpack. One result line is written per value as it is converted,
+  {"line": N, "output": <value>} or {"line": N, "error": "..."}
+with msgpack output as base64 and hcl as a string; a failing line records its
+error without stopping the rest, and the command exits non-zero if any line
+failed.
+
+With --dir and --out-dir, every file under --dir with the input format's
+extension (.json or .msgpack) is converted to the same relative path under
+--out-dir, with the output format's extension, in place of [input] and
+[output]. The type of each file comes from a manifest, by default
+manifest.json in --dir:
+  {"types": {"data_sources/ami.json": <type>, "resources/*.json": <type>}}
+Keys are slash-separated relative paths, or patterns as for Go's path.Match
+tried in sorted order when no path matches exactly. --type, if given, is the
+type of files the manifest does not cover; other files are skipped. A JSON
+summary of every file is written to stdout, and the command exits non-zero if
+any file failed.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if dir != "" {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(2)(cmd, args)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			inputPath := args[0]
-			outputPath := args[1]
-
-			// Parse the type specification
-			ctyType, err := parseCtyType(json.RawMessage(ctyTypeJSON))
-			if err != nil {
-				return fmt.Errorf("failed to parse type: %w", err)
+			if (dir == "") != (outDir == "") {
+				return fmt.Errorf("--dir and --out-dir must be given together")
+			}
+			if dir != "" && stream != "" {
+				return fmt.Errorf("--stream cannot be used with --dir")
+			}
+			if dir == "" && ctyTypeJSON == "" {
+				return fmt.Errorf(`required flag(s) "type" not set`)
 			}
 
-			// Read input
-			var inputData []byte
-			if inputPath == "-" {
-				inputData, err = io.ReadAll(os.Stdin)
-				if err != nil {
-					return fmt.Errorf("failed to read stdin: %w", err)
-				}
-			} else {
-				inputData, err = os.ReadFile(inputPath)
+			// Parse the type specification
+			ctyType := cty.NilType
+			var defaults *typeexpr.Defaults
+			var err error
+			if ctyTypeJSON != "" {
+				ctyType, defaults, err = ctyspec.ParseTypeSpecWithDefaults([]byte(ctyTypeJSON), ctyOptions())
 				if err != nil {
-					return fmt.Errorf("failed to read input file: %w", err)
+					return fmt.Errorf("failed to parse type: %w", err)
 				}
 			}
+			if defaults != nil && (ctyDialect != "cty" || ctyTargetType != "" || dir != "") {
+				return fmt.Errorf("optional attribute defaults require the cty dialect and cannot be used with --target-type or --dir")
+			}
+			if defaultsReport != "" && (stream != "" || dir != "") {
+				return fmt.Errorf("--defaults-report cannot be used with --stream or --dir")
+			}
 
-			// Convert based on formats
-			var value cty.Value
-			switch ctyInputFormat {
-			case "json":
-				value, err = buildCtyValueFromJSON(ctyType, inputData)
-				if err != nil {
-					return fmt.Errorf("failed to parse JSON input: %w", err)
+			targetType := cty.NilType
+			if ctyTargetType != "" {
+				if ctyDialect != "cty" {
+					return fmt.Errorf("--target-type requires the cty dialect")
 				}
-			case "msgpack":
-				value, err = msgpack.Unmarshal(inputData, ctyType)
+				targetType, err = parseCtyType(json.RawMessage(ctyTargetType))
 				if err != nil {
-					return fmt.Errorf("failed to unmarshal msgpack: %w", err)
+					return fmt.Errorf("failed to parse target type: %w", err)
 				}
-			default:
-				return fmt.Errorf("unsupported input format: %s", ctyInputFormat)
 			}
 
-			// Marshal to output format
-			var outputData []byte
-			switch ctyOutputFormat {
-			case "json":
-				outputData, err = ctyjson.Marshal(value, ctyType)
-				if err != nil {
-					return fmt.Errorf("failed to marshal to JSON: %w", err)
-				}
-			case "msgpack":
-				outputData, err = msgpack.Marshal(value, ctyType)
-				if err != nil {
-					return fmt.Errorf("failed to marshal to msgpack: %w", err)
-				}
-			default:
-				return fmt.Errorf("unsupported output format: %s", ctyOutputFormat)
+			if ctyDialect != "cty" && ctyDialect != "tftypes" {
+				return fmt.Errorf("unsupported dialect: %s", ctyDialect)
 			}
 
-			// Write output
-			if outputPath == "-" {
-				_, err = os.Stdout.Write(outputData)
-			} else {
-				err = os.WriteFile(outputPath, outputData, 0644)
+			// Convert using the selected dialect
+			var defaulted []string
+			convertType := func(ctyType cty.Type, inputData []byte) ([]byte, error) {
+				switch {
+				case defaults != nil:
+					outputData, paths, err := convertCtyDefaults(ctyType, defaults, inputData)
+					defaulted = paths
+					return outputData, err
+				case ctyDialect == "tftypes":
+					return convertTftypesData(ctyType, inputData, ctyInputFormat, ctyOutputFormat)
+				case targetType != cty.NilType && (stream != "" || dir != ""):
+					return convertCtyTarget(ctyType, targetType, inputData)
+				case targetType != cty.NilType:
+					return convertCtyValue(ctyType, targetType, inputData)
+				default:
+					return ctyspec.Convert(ctyType, inputData, ctyInputFormat, ctyOutputFormat, ctyOptions())
+				}
 			}
-			if err != nil {
-				return fmt.Errorf("failed to write output: %w", err)
+			convertData := func(inputData []byte) ([]byte, error) {
+				return convertType(ctyType, inputData)
 			}
 
-			return nil
-		},
-	}
-	
-	// Add flags
-	cmd.Flags().StringVar(&ctyInputFormat, "input-format", "json", "Input format (json, msgpack)")
-	cmd.Flags().StringVar(&ctyOutputFormat, "output-format", "json", "Output format (json, msgpack)")
-	cmd.Flags().StringVar(&ctyTypeJSON, "type", "", "CTY type specification as JSON")
-	cmd.MarkFlagRequired("type")
-	
-	return cmd
-}
+			if dir != "" {
+				return runDirConvert(dir, outDir, manifestPath, ctyType, jobs, convertType)
+			}
+			inputPath := args[0]
+			outputPath := args[1]
 
-// Override the validate command with real implementation
-func initCtyValidateCmd() *cobra.Command {
-	cmd := &cobra.Command{
-		Use:   "validate-value [value]",
-		Short: "Validate a CTY value",
-		Args:  cobra.ExactArgs(1),
-		RunE: func(cmd *cobra.Command, args []string) error {
-			valueJSON := args[0]
+			switch stream {
+			case "":
+			case "ndjson":
+				return runNDJSONStream(inputPath, outputPath, ctyInputFormat, ctyOutputFormat, convertData)
+			default:
+				return fmt.Errorf("unsupported stream format: %s", stream)
+			}
 
-			// Parse the type specification
-			ctyType, err := parseCtyType(json.RawMessage(ctyTypeJSON))
+			// Read input
+			inputData, err := readFileLimited(inputPath)
 			if err != nil {
-				return fmt.Errorf("failed to parse type: %w", err)
+				return fmt.Errorf("failed to read input: %w", err)
 			}
-
-			// Build and validate the value
-			_, err = buildCtyValueFromJSON(ctyType, []byte(valueJSON))
+			outputData, err := convertData(inputData)
 			if err != nil {
-				return fmt.Errorf("validation failed: %w", err)
+				if reportErr := reportCtyValueError(err); reportErr != nil {
+					return reportErr
+				}
+				return err
+			}
+
+			// Write output
+			if err := writeStreamOutput(outputPath, outputData); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
+			}
+			if defaultsReport != "" {
+				report, err := json.Marshal(map[string]interface{}{"defaulted": nonNilStrings(defaulted)})
+				if err != nil {
+					return fmt.Errorf("failed to encode defaults report: %w", err)
+				}
+				if err := writeStreamOutput(defaultsReport, append(report, '\n')); err != nil {
+					return fmt.Errorf("failed to write defaults report: %w", err)
+				}
 			}
 
-			fmt.Println("Validation Succeeded")
 			return nil
 		},
 	}
 	
 	// Add flags
-	cmd.Flags().StringVar(&ctyTypeJSON, "type", "", "CTY type specification as JSON")
-	cmd.MarkFlagRequired("type")
+	cmd.Flags().StringVar(&ctyInputFormat, "input-format", "json", "Input format (json, msgpack)")
+	cmd.Flags().StringVar(&ctyOutputFormat, "output-format", "json", "Output format (json, msgpack, hcl)")
+	cmd.Flags().StringVar(&ctyTypeJSON, "type", "", "CTY type specification as JSON or an HCL type expression")
+	cmd.Flags().StringVar(&ctyDialect, "dialect", "cty", "Value model used for decoding/encoding (cty, tftypes)")
+	cmd.Flags().StringVar(&ctyTargetType, "target-type", "", "CTY type specification to convert the value to with convert.Convert")
+	cmd.Flags().StringVar(&stream, "stream", "", "Convert each line of the input independently (ndjson)")
+	cmd.Flags().StringVar(&dir, "dir", "", "Convert every file in this directory tree")
+	cmd.Flags().StringVar(&outDir, "out-dir", "", "Directory to write --dir output to")
+	cmd.Flags().StringVar(&manifestPath, "manifest", "", "Manifest mapping --dir files to types (default: manifest.json in --dir)")
+	cmd.Flags().IntVar(&jobs, "jobs", 0, "Number of parallel workers for --dir (default: number of CPUs)")
+	cmd.Flags().StringVar(&defaultsReport, "defaults-report", "", "Write the paths of attributes that took a default to this file as JSON")
 	
-	return cmd
+	return addTypeFileFlags(cmd, false, "type", "target-type")
 }
 
-// parseCtyType parses a JSON type specification into a cty.Type
-func parseCtyType(data json.RawMessage) (cty.Type, error) {
-	var typeStr string
-	if err := json.Unmarshal(data, &typeStr); err == nil {
-		switch typeStr {
-		case "string":
-			return cty.String, nil
-		case "number":
-			return cty.Number, nil
-		case "bool":
-			return cty.Bool, nil
-		case "dynamic":
-			return cty.DynamicPseudoType, nil
-		default:
-			return cty.NilType, fmt.Errorf("unknown primitive type string: %s", typeStr)
-		}
-	}
+// ctyConversionError is reported on stdout when a value does not convert
+// to --target-type
+type ctyConversionError struct {
+	Error string `json:"error"`
+	// Path locates the value that failed, e.g. foo[0]; empty for the
+	// value as a whole
+	Path       string          `json:"path"`
+	SourceType json.RawMessage `json:"source_type"`
+	TargetType json.RawMessage `json:"target_type"`
+	// Received is the type of the value at Path
+	Received string `json:"received,omitempty"`
+}
 
-	var typeList []json.RawMessage
-	if err := json.Unmarshal(data, &typeList); err == nil {
-		if len(typeList) < 2 {
-			return cty.NilType, fmt.Errorf("type array must have at least 2 elements")
-		}
-		var typeKind string
-		if err := json.Unmarshal(typeList[0], &typeKind); err != nil {
-			return cty.NilType, err
-		}
+// ctyValueError is reported on stdout when a JSON value does not fit its
+// type
+type ctyValueError struct {
+	Error string `json:"error"`
+	// Path locates the value that failed, e.g. foo[0]; empty for the
+	// value as a whole
+	Path         string          `json:"path"`
+	ExpectedType json.RawMessage `json:"expected_type"`
+	// Received is the kind of JSON value found at Path: null, bool, number,
+	// string, array or object
+	Received string `json:"received"`
+}
 
-		switch typeKind {
-		case "list", "set", "map":
-			elemType, err := parseCtyType(typeList[1])
-			if err != nil {
-				return cty.NilType, err
-			}
-			if typeKind == "list" {
-				return cty.List(elemType), nil
-			}
-			if typeKind == "set" {
-				return cty.Set(elemType), nil
-			}
-			return cty.Map(elemType), nil
-		case "object":
-			var attrTypesRaw map[string]json.RawMessage
-			if err := json.Unmarshal(typeList[1], &attrTypesRaw); err != nil {
-				return cty.NilType, err
-			}
-			attrTypes := make(map[string]cty.Type)
-			for name, rawType := range attrTypesRaw {
-				attrType, err := parseCtyType(rawType)
-				if err != nil {
-					return cty.NilType, err
-				}
-				attrTypes[name] = attrType
-			}
-			if len(typeList) > 2 {
-				var optionals []string
-				if err := json.Unmarshal(typeList[2], &optionals); err != nil {
-					return cty.NilType, err
-				}
-				return cty.ObjectWithOptionalAttrs(attrTypes, optionals), nil
-			}
-			return cty.Object(attrTypes), nil
-		case "tuple":
-			var elemTypesRaw []json.RawMessage
-			if err := json.Unmarshal(typeList[1], &elemTypesRaw); err != nil {
-				return cty.NilType, err
-			}
-			elemTypes := make([]cty.Type, len(elemTypesRaw))
-			for i, rawType := range elemTypesRaw {
-				elemType, err := parseCtyType(rawType)
-				if err != nil {
-					return cty.NilType, err
-				}
-				elemTypes[i] = elemType
-			}
-			return cty.Tuple(elemTypes), nil
-		default:
-			return cty.NilType, fmt.Errorf("unknown complex type kind: %s", typeKind)
-		}
+// newCtyValueError returns the report of the ctyspec.ValueError in err's
+// chain, or nil if there is none
+func newCtyValueError(err error) *ctyValueError {
+	var valueErr *ctyspec.ValueError
+	if !errors.As(err, &valueErr) {
+		return nil
 	}
-	return cty.NilType, fmt.Errorf("invalid type specification format")
+	report := &ctyValueError{
+		Error:    valueErr.Error(),
+		Path:     formatCtyPath(valueErr.Path),
+		Received: valueErr.Received,
+	}
+	report.ExpectedType, _ = ctyspec.MarshalType(valueErr.Expected)
+	return report
 }
 
-// buildCtyValueFromJSON builds a cty.Value from JSON data with the given type
-func buildCtyValueFromJSON(ty cty.Type, data []byte) (cty.Value, error) {
-	// Handle simple JSON unmarshaling for basic types
-	if ty == cty.DynamicPseudoType {
-		// For dynamic types, infer the type from the JSON
-		inferredType, err := ctyjson.ImpliedType(data)
-		if err != nil {
-			return cty.NilVal, err
-		}
-		return ctyjson.Unmarshal(data, inferredType)
+// reportCtyValueError writes the report of a value error in err's chain to
+// stdout, if there is one
+func reportCtyValueError(err error) error {
+	report := newCtyValueError(err)
+	if report == nil {
+		return nil
 	}
-
-	// Parse the JSON to handle special cases
-	var rawValue interface{}
-	if err := json.Unmarshal(data, &rawValue); err != nil {
-		return cty.NilVal, err
+	if err := json.NewEncoder(os.Stdout).Encode(map[string]interface{}{"value_error": report}); err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
 	}
+	return nil
+}
 
-	return buildValueFromInterface(ty, rawValue, []string{})
+// ctyConversionFailure is the error of a value that does not convert to
+// the target type, carrying the report
+type ctyConversionFailure struct {
+	report ctyConversionError
+	err    error
 }
 
-// buildValueFromInterface recursively builds a cty.Value from an interface{}
-func buildValueFromInterface(ty cty.Type, val interface{}, path []string) (cty.Value, error) {
-	if val == nil {
-		return cty.NullVal(ty), nil
-	}
+func (e *ctyConversionFailure) Error() string {
+	return fmt.Sprintf("conversion to target type failed: %s", e.err)
+}
 
-	// Note: go-cty does NOT support unknown values in JSON format
-	// Unknown values can only be properly represented in MessagePack
-	// Attempting to marshal an unknown value to JSON will result in an error:
-	// "value is not known"
-	// This matches Terraform's behavior exactly
-
-	// Handle primitive types
-	switch ty {
-	case cty.String:
-		if s, ok := val.(string); ok {
-			return cty.StringVal(s), nil
-		}
-		return cty.NilVal, fmt.Errorf("expected string at %s", strings.Join(path, "."))
-	case cty.Number:
-		switch v := val.(type) {
-		case float64:
-			return cty.NumberFloatVal(v), nil
-		case int:
-			return cty.NumberIntVal(int64(v)), nil
-		case int64:
-			return cty.NumberIntVal(v), nil
-		case string:
-			bf := new(big.Float)
-			if _, ok := bf.SetString(v); ok {
-				return cty.NumberVal(bf), nil
-			}
-			return cty.NilVal, fmt.Errorf("invalid number string at %s", strings.Join(path, "."))
-		}
-		return cty.NilVal, fmt.Errorf("expected number at %s", strings.Join(path, "."))
-	case cty.Bool:
-		if b, ok := val.(bool); ok {
-			return cty.BoolVal(b), nil
+func (e *ctyConversionFailure) Unwrap() error {
+	return e.err
+}
+
+// convertCtyValue decodes inputData as ctyType and converts the value to
+// targetType with convert.Convert, as Terraform converts values to declared
+// types. A failed conversion is reported as a ctyConversionError.
+func convertCtyValue(ctyType, targetType cty.Type, inputData []byte) ([]byte, error) {
+	output, err := convertCtyTarget(ctyType, targetType, inputData)
+	var failure *ctyConversionFailure
+	if errors.As(err, &failure) {
+		if err := json.NewEncoder(os.Stdout).Encode(map[string]interface{}{"conversion_error": failure.report}); err != nil {
+			return nil, fmt.Errorf("failed to encode JSON: %w", err)
 		}
-		return cty.NilVal, fmt.Errorf("expected bool at %s", strings.Join(path, "."))
 	}
+	return output, err
+}
 
-	// Handle collection types
-	if ty.IsListType() || ty.IsSetType() || ty.IsTupleType() {
-		slice, ok := val.([]interface{})
-		if !ok {
-			return cty.NilVal, fmt.Errorf("expected array at %s", strings.Join(path, "."))
-		}
+// convertCtyTarget is convertCtyValue without the report on stdout; a
+// failed conversion returns a *ctyConversionFailure
+func convertCtyTarget(ctyType, targetType cty.Type, inputData []byte) ([]byte, error) {
+	value, err := ctyspec.Decode(ctyType, inputData, ctyInputFormat, ctyOptions())
+	if err != nil {
+		return nil, err
+	}
 
-		vals := make([]cty.Value, len(slice))
-		for i, elem := range slice {
-			var elemTy cty.Type
-			if ty.IsTupleType() {
-				elemTy = ty.TupleElementType(i)
-			} else {
-				elemTy = ty.ElementType()
+	converted, convErr := convert.Convert(value, targetType)
+	if convErr != nil {
+		report := ctyConversionError{Error: convErr.Error()}
+		var pathErr cty.PathError
+		if errors.As(convErr, &pathErr) {
+			report.Path = formatCtyPath(pathErr.Path)
+			if received, err := pathErr.Path.Apply(value); err == nil {
+				report.Received = received.Type().FriendlyName()
 			}
-			elemVal, err := buildValueFromInterface(elemTy, elem, append(path, fmt.Sprintf("[%d]", i)))
-			if err != nil {
-				return cty.NilVal, err
-			}
-			vals[i] = elemVal
 		}
-
-		if ty.IsListType() {
-			if len(vals) == 0 {
-				return cty.ListValEmpty(ty.ElementType()), nil
-			}
-			return cty.ListVal(vals), nil
+		if report.SourceType, err = ctyjson.MarshalType(ctyType); err != nil {
+			return nil, fmt.Errorf("failed to marshal type: %w", err)
 		}
-		if ty.IsSetType() {
-			if len(vals) == 0 {
-				return cty.SetValEmpty(ty.ElementType()), nil
-			}
-			return cty.SetVal(vals), nil
+		if report.TargetType, err = ctyjson.MarshalType(targetType); err != nil {
+			return nil, fmt.Errorf("failed to marshal type: %w", err)
 		}
-		return cty.TupleVal(vals), nil
+		return nil, &ctyConversionFailure{report: report, err: convErr}
 	}
+	return ctyspec.Encode(converted, targetType, ctyOutputFormat, ctyOptions())
+}
 
-	// Handle map and object types
-	if ty.IsMapType() || ty.IsObjectType() {
-		m, ok := val.(map[string]interface{})
-		if !ok {
-			return cty.NilVal, fmt.Errorf("expected object at %s", strings.Join(path, "."))
-		}
-
-		vals := make(map[string]cty.Value)
-		for k, v := range m {
-			var elemTy cty.Type
-			if ty.IsObjectType() {
-				elemTy = ty.AttributeType(k)
-			} else {
-				elemTy = ty.ElementType()
-			}
-			elemVal, err := buildValueFromInterface(elemTy, v, append(path, k))
-			if err != nil {
-				return cty.NilVal, err
-			}
-			vals[k] = elemVal
-		}
-
-		if ty.IsMapType() {
-			if len(vals) == 0 {
-				return cty.MapValEmpty(ty.ElementType()), nil
-			}
-			return cty.MapVal(vals), nil
-		}
-		return cty.ObjectVal(vals), nil
+// convertCtyDefaults decodes inputData as ctyType, fills in optional
+// attribute defaults and re-encodes the value, returning the paths of the
+// attributes that took a default
+func convertCtyDefaults(ctyType cty.Type, defaults *typeexpr.Defaults, inputData []byte) ([]byte, []string, error) {
+	value, err := ctyspec.Decode(ctyType, inputData, ctyInputFormat, ctyOptions())
+	if err != nil {
+		return nil, nil, err
 	}
-
-	return cty.NilVal, fmt.Errorf("cannot build value for type %s at %s", ty.FriendlyName(), strings.Join(path, "."))
+	value, paths, err := ctyspec.ApplyDefaults(value, ctyType, defaults)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to apply defaults: %w", err)
+	}
+	defaulted := make([]string, len(paths))
+	for i, path := range paths {
+		defaulted[i] = formatCtyPath(path)
+	}
+	logger.Debug("🧩 applied optional attribute defaults", "count", len(defaulted))
+	output, err := ctyspec.Encode(value, ctyType, ctyOutputFormat, ctyOptions())
+	return output, defaulted, err
 }
 
-// buildRefinedUnknown builds a refined unknown value from refinement data
-func buildRefinedUnknown(ty cty.Type, refinementsData interface{}) (cty.Value, error) {
-	refinements, ok := refinementsData.(map[string]interface{})
-	if !ok {
-		return cty.NilVal, fmt.Errorf("refinements must be an object")
-	}
+// Override the validate command with real implementation
+func initCtyValidateCmd() *cobra.Command {
+	var report bool
 
-	builder := cty.UnknownVal(ty).Refine()
+	cmd := &cobra.Command{
+		Use:   "validate-value [value]",
+		Short: "Validate a CTY value",
+		Long: `Check that the JSON [value] builds a CTY value of --type. A value that does
+not fit its type is reported on stdout as
+  {"value_error": {"error": "...", "path": "foo[0]", "expected_type": <type>,
+   "received": "string"}}
+where received is the kind of JSON value found at path.
+
+With --report, a valid value is reported as JSON listing the object attributes
+[value] leaves out, which are set to null, in place of the success message:
+  {"valid": true, "absent": [{"path": "tags", "optional": true}]}`,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			valueJSON := args[0]
 
-	if isNull, ok := refinements["is_known_null"].(bool); ok {
-		if isNull {
-			builder = builder.Null()
-		} else {
-			builder = builder.NotNull()
-		}
-	}
+			// Parse the type specification
+			ctyType, err := parseCtyType(json.RawMessage(ctyTypeJSON))
+			if err != nil {
+				return fmt.Errorf("failed to parse type: %w", err)
+			}
 
-	if prefix, ok := refinements["string_prefix"].(string); ok {
-		builder = builder.StringPrefix(prefix)
-	}
+			// Build and validate the value
+			_, err = ctyspec.BuildValue(ctyType, []byte(valueJSON), ctyOptions())
+			if err != nil {
+				if reportErr := reportCtyValueError(err); reportErr != nil {
+					return reportErr
+				}
+				return fmt.Errorf("validation failed: %w", err)
+			}
 
-	if lowerBound, ok := refinements["number_lower_bound"].([]interface{}); ok && len(lowerBound) >= 2 {
-		numStr, _ := lowerBound[0].(string)
-		inclusive, _ := lowerBound[1].(bool)
-		bf := new(big.Float)
-		bf.SetString(numStr)
-		builder = builder.NumberRangeLowerBound(cty.NumberVal(bf), inclusive)
-	}
+			if report {
+				absent, err := ctyspec.AbsentAttributes(ctyType, []byte(valueJSON))
+				if err != nil {
+					return fmt.Errorf("failed to find absent attributes: %w", err)
+				}
+				entries := []ctyAbsentAttribute{}
+				for _, attr := range absent {
+					entries = append(entries, ctyAbsentAttribute{Path: formatCtyPath(attr.Path), Optional: attr.Optional})
+				}
+				logger.Debug("🧩 absent attributes set to null", "count", len(entries))
+				if err := json.NewEncoder(os.Stdout).Encode(map[string]interface{}{"valid": true, "absent": entries}); err != nil {
+					return fmt.Errorf("failed to encode JSON: %w", err)
+				}
+				return nil
+			}
 
-	if upperBound, ok := refinements["number_upper_bound"].([]interface{}); ok && len(upperBound) >= 2 {
-		numStr, _ := upperBound[0].(string)
-		inclusive, _ := upperBound[1].(bool)
-		bf := new(big.Float)
-		bf.SetString(numStr)
-		builder = builder.NumberRangeUpperBound(cty.NumberVal(bf), inclusive)
+			fmt.Println("Validation Succeeded")
+			return nil
+		},
 	}
+	
+	// Add flags
+	cmd.Flags().StringVar(&ctyTypeJSON, "type", "", "CTY type specification as JSON or an HCL type expression")
+	cmd.Flags().BoolVar(&report, "report", false, "Report the absent object attributes set to null as JSON")
+	
+	return addTypeFileFlags(cmd, true, "type")
+}
 
-	if lower, ok := refinements["collection_length_lower_bound"].(float64); ok {
-		builder = builder.CollectionLengthLowerBound(int(lower))
-	}
+// ctyAbsentAttribute is an object attribute left out of the input of
+// `cty validate-value --report`
+type ctyAbsentAttribute struct {
+	Path     string `json:"path"`
+	Optional bool   `json:"optional"`
+}
 
-	if upper, ok := refinements["collection_length_upper_bound"].(float64); ok {
-		builder = builder.CollectionLengthUpperBound(int(upper))
+// parseCtyType parses a JSON type specification or an HCL type expression
+// into a cty.Type, caching the result for the daemon
+func parseCtyType(data json.RawMessage) (cty.Type, error) {
+	if ty, ok := sharedParseCache.cachedCtyType(data, decodeLimits); ok {
+		return ty, nil
 	}
+	ty, err := ctyspec.ParseTypeSpec(data, ctyOptions())
+	if err != nil {
+		return cty.NilType, err
+	}
+	sharedParseCache.storeCtyType(data, decodeLimits, ty)
+	return ty, nil
+}
 
-	return builder.NewValue(), nil
-}
\ No newline at end of file
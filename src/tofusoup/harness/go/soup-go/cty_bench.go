@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/ctyspec"
+)
+
+// ctyBenchResult is the throughput of one operation of `cty bench`
+type ctyBenchResult struct {
+	Format      string  `json:"format"`
+	Op          string  `json:"op"`
+	Iterations  int     `json:"iterations"`
+	NsPerOp     float64 `json:"ns_per_op"`
+	OpsPerSec   float64 `json:"ops_per_sec"`
+	AllocsPerOp float64 `json:"allocs_per_op"`
+	BytesPerOp  float64 `json:"bytes_per_op"`
+}
+
+// benchCtyOp runs op iterations times after one untimed warm-up run,
+// measuring its time and heap allocations
+func benchCtyOp(format, name string, iterations int, op func() error) (ctyBenchResult, error) {
+	if err := op(); err != nil {
+		return ctyBenchResult{}, fmt.Errorf("%s %s failed: %w", format, name, err)
+	}
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	for i := 0; i < iterations; i++ {
+		if err := op(); err != nil {
+			return ctyBenchResult{}, fmt.Errorf("%s %s failed: %w", format, name, err)
+		}
+	}
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	n := float64(iterations)
+	return ctyBenchResult{
+		Format:      format,
+		Op:          name,
+		Iterations:  iterations,
+		NsPerOp:     float64(elapsed.Nanoseconds()) / n,
+		OpsPerSec:   n / elapsed.Seconds(),
+		AllocsPerOp: float64(after.Mallocs-before.Mallocs) / n,
+		BytesPerOp:  float64(after.TotalAlloc-before.TotalAlloc) / n,
+	}, nil
+}
+
+// initCtyBenchCmd creates the `cty bench` command
+func initCtyBenchCmd() *cobra.Command {
+	var typeJSON string
+	var valuePath string
+	var iterations int
+	var seed int64
+	var maxElements int
+
+	cmd := &cobra.Command{
+		Use:   "bench",
+		Short: "Measure JSON and msgpack marshal/unmarshal throughput",
+		Long: `Marshal and unmarshal a value of --type --iterations times in each of JSON
+and msgpack, as cty convert does, and report ops/sec and heap allocations per
+operation as JSON, to compare encoders across harness languages. The value is
+read from --value as JSON, or generated as cty generate would from --seed, with
+--max-elements setting its size.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if iterations <= 0 {
+				return fmt.Errorf("--iterations must be positive")
+			}
+			if maxElements < 0 {
+				return fmt.Errorf("--max-elements must not be negative")
+			}
+			ty, err := parseCtyType(json.RawMessage(typeJSON))
+			if err != nil {
+				return fmt.Errorf("failed to parse type: %w", err)
+			}
+			valueType := ty.WithoutOptionalAttributesDeep()
+			opts := ctyOptions()
+
+			var value cty.Value
+			if valuePath != "" {
+				data, err := readFileLimited(valuePath)
+				if err != nil {
+					return fmt.Errorf("failed to read value: %w", err)
+				}
+				if value, err = ctyspec.Decode(ty, data, "json", opts); err != nil {
+					return err
+				}
+			} else {
+				gen := ctyspec.NewGenerator(seed)
+				gen.MaxElements = maxElements
+				if value, err = gen.Generate(ty); err != nil {
+					return err
+				}
+			}
+
+			report := map[string]interface{}{}
+			var results []ctyBenchResult
+			for _, format := range []string{"json", "msgpack"} {
+				encoded, err := ctyspec.Encode(value, valueType, format, opts)
+				if err != nil {
+					return err
+				}
+				report[format+"_size"] = len(encoded)
+
+				marshal, err := benchCtyOp(format, "marshal", iterations, func() error {
+					_, err := ctyspec.Encode(value, valueType, format, opts)
+					return err
+				})
+				if err != nil {
+					return err
+				}
+				unmarshal, err := benchCtyOp(format, "unmarshal", iterations, func() error {
+					_, err := ctyspec.Decode(valueType, encoded, format, opts)
+					return err
+				})
+				if err != nil {
+					return err
+				}
+				results = append(results, marshal, unmarshal)
+				logger.Debug("⏱️ cty bench format done", "format", format, "marshal_ops_per_sec", marshal.OpsPerSec, "unmarshal_ops_per_sec", unmarshal.OpsPerSec)
+			}
+			if report["type"], err = ctyspec.FormatType(ty); err != nil {
+				return fmt.Errorf("failed to format type: %w", err)
+			}
+			report["results"] = results
+
+			if err := json.NewEncoder(os.Stdout).Encode(report); err != nil {
+				return fmt.Errorf("failed to encode JSON: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&typeJSON, "type", "", "CTY type specification as JSON or an HCL type expression")
+	cmd.Flags().StringVar(&valuePath, "value", "", "JSON file of the value to benchmark (\"-\" for stdin; default: a generated value)")
+	cmd.Flags().IntVar(&iterations, "iterations", 10000, "Timed runs of each operation")
+	cmd.Flags().Int64Var(&seed, "seed", 0, "Random seed of the generated value")
+	cmd.Flags().IntVar(&maxElements, "max-elements", 3, "Maximum length of the generated value's lists, sets and maps")
+	return withProfiles(addTypeFileFlags(cmd, true, "type"))
+}
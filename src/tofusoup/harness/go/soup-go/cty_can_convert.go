@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/zclconf/go-cty/cty/convert"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// canConvertReport reports the conversions go-cty has between two types
+type canConvertReport struct {
+	From json.RawMessage `json:"from"`
+	To   json.RawMessage `json:"to"`
+	// ConversionNeeded is false when the types are already equal
+	ConversionNeeded bool `json:"conversion_needed"`
+	// Safe is whether a conversion exists that cannot fail for any value,
+	// as convert.GetConversion; Unsafe whether one exists that may, as
+	// convert.GetConversionUnsafe. A safe conversion is also unsafe.
+	Safe   bool `json:"safe"`
+	Unsafe bool `json:"unsafe"`
+}
+
+// initCtyCanConvertCmd creates the `cty can-convert` command
+func initCtyCanConvertCmd() *cobra.Command {
+	var fromJSON, toJSON string
+
+	cmd := &cobra.Command{
+		Use:   "can-convert",
+		Short: "Report whether go-cty can convert between two types",
+		Long: `Report whether go-cty's convert package has a conversion from values of
+--from to --to: safe when convert.GetConversion finds one, which cannot fail
+for any value, such as number to string, and unsafe when only
+convert.GetConversionUnsafe does, whose conversion can fail for some values,
+such as string to number. Both are false when no conversion exists.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			from, err := parseCtyType(json.RawMessage(fromJSON))
+			if err != nil {
+				return fmt.Errorf("invalid --from type: %w", err)
+			}
+			to, err := parseCtyType(json.RawMessage(toJSON))
+			if err != nil {
+				return fmt.Errorf("invalid --to type: %w", err)
+			}
+
+			report := canConvertReport{
+				ConversionNeeded: !from.Equals(to),
+				Safe:             convert.GetConversion(from, to) != nil,
+				Unsafe:           convert.GetConversionUnsafe(from, to) != nil,
+			}
+			if report.From, err = ctyjson.MarshalType(from); err != nil {
+				return fmt.Errorf("failed to marshal --from type: %w", err)
+			}
+			if report.To, err = ctyjson.MarshalType(to); err != nil {
+				return fmt.Errorf("failed to marshal --to type: %w", err)
+			}
+			return json.NewEncoder(os.Stdout).Encode(report)
+		},
+	}
+
+	cmd.Flags().StringVar(&fromJSON, "from", "", "CTY type specification to convert from, as JSON or an HCL type expression")
+	cmd.Flags().StringVar(&toJSON, "to", "", "CTY type specification to convert to, as JSON or an HCL type expression")
+	return addTypeFileFlags(cmd, true, "from", "to")
+}
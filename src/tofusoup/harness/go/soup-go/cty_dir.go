@@ -0,0 +1,207 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/limits"
+)
+
+// defaultDirManifest is the manifest `cty convert --dir` reads from the
+// input directory when --manifest is not given
+const defaultDirManifest = "manifest.json"
+
+// dirManifest maps the files of a `cty convert --dir` tree to their types.
+// Keys are slash-separated paths relative to the directory, or patterns
+// as for path.Match such as "resources/*.json".
+type dirManifest struct {
+	Types map[string]json.RawMessage `json:"types"`
+}
+
+// entryFor returns the manifest key giving rel's type: an exact entry,
+// else the first matching pattern in sorted order
+func (m dirManifest) entryFor(rel string) (string, bool) {
+	if _, ok := m.Types[rel]; ok {
+		return rel, true
+	}
+	for _, pattern := range sortedKeys(m.Types) {
+		if ok, _ := path.Match(pattern, rel); ok {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// dirConvertResult reports the conversion of one file of the tree
+type dirConvertResult struct {
+	Path   string `json:"path"`
+	Output string `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+	// ValuePath locates the value that failed to convert to --target-type
+	ValuePath string                `json:"value_path,omitempty"`
+	Limit     *limits.ExceededError `json:"limit,omitempty"`
+	// ValueError details a value that does not fit the file's type
+	ValueError *ctyValueError `json:"value_error,omitempty"`
+	// Skipped is why the file was not converted
+	Skipped string `json:"skipped,omitempty"`
+}
+
+// dirConvertReport is the summary `cty convert --dir` writes to stdout
+type dirConvertReport struct {
+	Total     int                `json:"total"`
+	Converted int                `json:"converted"`
+	Failed    int                `json:"failed"`
+	Skipped   int                `json:"skipped"`
+	Files     []dirConvertResult `json:"files"`
+}
+
+// formatExtension is the file extension of values in format
+func formatExtension(format string) string {
+	switch format {
+	case "msgpack":
+		return ".msgpack"
+	case "hcl":
+		return ".hcl"
+	}
+	return ".json"
+}
+
+// readDirManifest reads the manifest at manifestPath, or the default
+// manifest of dir if it exists. A missing default manifest is empty.
+func readDirManifest(dir, manifestPath string) (dirManifest, string, error) {
+	var manifest dirManifest
+	explicit := manifestPath != ""
+	if !explicit {
+		manifestPath = filepath.Join(dir, defaultDirManifest)
+	}
+	data, err := readFileLimited(manifestPath)
+	if err != nil {
+		if !explicit && errors.Is(err, fs.ErrNotExist) {
+			return manifest, "", nil
+		}
+		return manifest, "", fmt.Errorf("failed to read manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return manifest, "", fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	return manifest, manifestPath, nil
+}
+
+// runDirConvert converts every file with the input format's extension
+// under dir to the same relative path under outDir, with the output
+// format's extension, using jobs workers. Each file's type comes from the
+// manifest, falling back to fallback; files with neither are skipped. The
+// summary is written to stdout, and an error is returned if any file
+// failed.
+func runDirConvert(dir, outDir, manifestPath string, fallback cty.Type, jobs int, convert func(ty cty.Type, data []byte) ([]byte, error)) error {
+	manifest, manifestPath, err := readDirManifest(dir, manifestPath)
+	if err != nil {
+		return err
+	}
+	types := map[string]cty.Type{}
+	for key, spec := range manifest.Types {
+		if types[key], err = parseCtyType(spec); err != nil {
+			return fmt.Errorf("invalid type for %s in manifest: %w", key, err)
+		}
+	}
+
+	inputExt := formatExtension(ctyInputFormat)
+	var files []string
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(p) != inputExt {
+			return nil
+		}
+		if manifestPath != "" && filepath.Clean(p) == filepath.Clean(manifestPath) {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to walk %s: %w", dir, err)
+	}
+
+	report := dirConvertReport{Files: []dirConvertResult{}}
+	err = runOrdered(jobs, sliceItems(files), func(_ int, rel string) dirConvertResult {
+		result := dirConvertResult{Path: rel}
+		ty := fallback
+		if key, ok := manifest.entryFor(rel); ok {
+			ty = types[key]
+		}
+		if ty == cty.NilType {
+			result.Skipped = "no type in manifest"
+			return result
+		}
+
+		outRel := strings.TrimSuffix(rel, inputExt) + formatExtension(ctyOutputFormat)
+		outPath := filepath.Join(outDir, filepath.FromSlash(outRel))
+		err := func() error {
+			data, err := readFileLimited(filepath.Join(dir, filepath.FromSlash(rel)))
+			if err != nil {
+				return fmt.Errorf("failed to read input: %w", err)
+			}
+			output, err := convert(ty, data)
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+				return fmt.Errorf("failed to create output directory: %w", err)
+			}
+			if err := writeStreamOutput(outPath, output); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
+			}
+			return nil
+		}()
+		if err != nil {
+			result.Error = err.Error()
+			result.Limit = limits.As(err)
+			result.ValueError = newCtyValueError(err)
+			var failure *ctyConversionFailure
+			if errors.As(err, &failure) {
+				result.ValuePath = failure.report.Path
+			}
+			return result
+		}
+		result.Output = filepath.ToSlash(outRel)
+		return result
+	}, func(result dirConvertResult) error {
+		report.Total++
+		switch {
+		case result.Skipped != "":
+			report.Skipped++
+		case result.Error != "":
+			report.Failed++
+		default:
+			report.Converted++
+		}
+		report.Files = append(report.Files, result)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Info("📁✅ directory conversion complete", "total", report.Total, "converted", report.Converted, "failed", report.Failed, "skipped", report.Skipped)
+	if err := json.NewEncoder(os.Stdout).Encode(report); err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	if report.Failed > 0 {
+		return fmt.Errorf("%d of %d files failed", report.Failed, report.Total)
+	}
+	return nil
+}
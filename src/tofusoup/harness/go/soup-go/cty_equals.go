@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/ctyspec"
+)
+
+// ctyMismatch is one place where two values differ
+type ctyMismatch struct {
+	// Path locates the differing value, e.g. foo[0]; empty for the values
+	// as a whole
+	Path   string      `json:"path"`
+	Reason string      `json:"reason"`
+	A      interface{} `json:"a"`
+	B      interface{} `json:"b"`
+}
+
+// ctyEqualsReport is the verdict of `cty equals`
+type ctyEqualsReport struct {
+	// Equal is whether a.Equals(b) is known to be true
+	Equal bool `json:"equal"`
+	// Known is false when unknowns leave a.Equals(b) undecided
+	Known bool `json:"known"`
+	// RawEqual is a.RawEquals(b): identical, including which values are
+	// unknown and how they are refined
+	RawEqual   bool          `json:"raw_equal"`
+	Mismatches []ctyMismatch `json:"mismatches"`
+}
+
+// compareCtyValues compares a and b under cty semantics, where sets are
+// equal regardless of element order and an unknown equals nothing known
+func compareCtyValues(a, b cty.Value) ctyEqualsReport {
+	eq := a.Equals(b)
+	report := ctyEqualsReport{
+		Known:      eq.IsKnown(),
+		RawEqual:   a.RawEquals(b),
+		Mismatches: []ctyMismatch{},
+	}
+	report.Equal = eq.IsKnown() && eq.True()
+	if !report.RawEqual {
+		diffCtyValues(a, b, nil, &report.Mismatches)
+	}
+	return report
+}
+
+// diffCtyValues appends the paths at which a and b differ to mismatches.
+// Unknowns on both sides are not mismatches, since they may be equal.
+func diffCtyValues(a, b cty.Value, path cty.Path, mismatches *[]ctyMismatch) {
+	mismatchAt := func(path cty.Path, reason string, a, b interface{}) {
+		*mismatches = append(*mismatches, ctyMismatch{Path: formatCtyPath(path), Reason: reason, A: a, B: b})
+	}
+	mismatch := func(reason string, a, b interface{}) {
+		mismatchAt(path, reason, a, b)
+	}
+
+	a, _ = a.UnmarkDeep()
+	b, _ = b.UnmarkDeep()
+	switch {
+	case !a.Type().Equals(b.Type()):
+		mismatch("type", a.Type().FriendlyName(), b.Type().FriendlyName())
+		return
+	case !a.IsKnown() && !b.IsKnown():
+		return
+	case !a.IsKnown() || !b.IsKnown():
+		mismatch("unknown", ctyspec.ValueJSON(a), ctyspec.ValueJSON(b))
+		return
+	case a.IsNull() && b.IsNull():
+		return
+	case a.IsNull() || b.IsNull():
+		mismatch("null", ctyspec.ValueJSON(a), ctyspec.ValueJSON(b))
+		return
+	}
+
+	ty := a.Type()
+	switch {
+	case ty.IsPrimitiveType():
+		if !a.RawEquals(b) {
+			mismatch("value", ctyspec.ValueJSON(a), ctyspec.ValueJSON(b))
+		}
+	case ty.IsListType() || ty.IsTupleType():
+		if a.LengthInt() != b.LengthInt() {
+			mismatch("length", a.LengthInt(), b.LengthInt())
+			return
+		}
+		for i := 0; i < a.LengthInt(); i++ {
+			idx := cty.NumberIntVal(int64(i))
+			diffCtyValues(a.Index(idx), b.Index(idx), path.Copy().Index(idx), mismatches)
+		}
+	case ty.IsMapType() || ty.IsObjectType():
+		keys := map[string]bool{}
+		for it := a.ElementIterator(); it.Next(); {
+			k, _ := it.Element()
+			keys[k.AsString()] = true
+		}
+		for it := b.ElementIterator(); it.Next(); {
+			k, _ := it.Element()
+			keys[k.AsString()] = true
+		}
+		names := make([]string, 0, len(keys))
+		for k := range keys {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		for _, k := range names {
+			if ty.IsObjectType() {
+				diffCtyValues(a.GetAttr(k), b.GetAttr(k), path.Copy().GetAttr(k), mismatches)
+				continue
+			}
+			key := cty.StringVal(k)
+			elemPath := path.Copy().Index(key)
+			switch {
+			case a.HasIndex(key).False():
+				mismatchAt(elemPath, "missing in a", nil, ctyspec.ValueJSON(b.Index(key)))
+			case b.HasIndex(key).False():
+				mismatchAt(elemPath, "missing in b", ctyspec.ValueJSON(a.Index(key)), nil)
+			default:
+				diffCtyValues(a.Index(key), b.Index(key), elemPath, mismatches)
+			}
+		}
+	case ty.IsSetType():
+		// Set elements have no position, so differences are reported as
+		// elements present on one side only
+		if !a.IsWhollyKnown() || !b.IsWhollyKnown() {
+			if eq := a.Equals(b); eq.IsKnown() && eq.False() {
+				mismatch("set elements", ctyspec.ValueJSON(a), ctyspec.ValueJSON(b))
+			}
+			return
+		}
+		for it := a.ElementIterator(); it.Next(); {
+			_, elem := it.Element()
+			if b.HasElement(elem).False() {
+				mismatch("element only in a", ctyspec.ValueJSON(elem), nil)
+			}
+		}
+		for it := b.ElementIterator(); it.Next(); {
+			_, elem := it.Element()
+			if a.HasElement(elem).False() {
+				mismatch("element only in b", nil, ctyspec.ValueJSON(elem))
+			}
+		}
+	}
+}
+
+// initCtyEqualsCmd creates the `cty equals` command
+func initCtyEqualsCmd() *cobra.Command {
+	var typeJSON string
+	var inputFormat string
+
+	cmd := &cobra.Command{
+		Use:   "equals <a> <b>",
+		Short: "Report whether two CTY values are equal under cty semantics",
+		Long: `Decode the values in files <a> and <b> ("-" for stdin) as --type and report
+whether they are equal as go-cty's Value.Equals decides: sets are compared
+regardless of element order, which byte comparison of msgpack cannot do, and
+an unknown leaves equality undecided (known false). raw_equal also requires
+unknowns and their refinements to match. mismatches lists the paths at which
+the values differ. Exits non-zero unless the values are equal.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ty, err := parseCtyType(json.RawMessage(typeJSON))
+			if err != nil {
+				return fmt.Errorf("failed to parse type: %w", err)
+			}
+			values := make([]cty.Value, 2)
+			for i, path := range args {
+				data, err := readFileLimited(path)
+				if err != nil {
+					return fmt.Errorf("failed to read input: %w", err)
+				}
+				if values[i], err = ctyspec.Decode(ty, data, inputFormat, ctyOptions()); err != nil {
+					return fmt.Errorf("failed to decode %s: %w", path, err)
+				}
+			}
+
+			report := compareCtyValues(values[0], values[1])
+			if err := json.NewEncoder(os.Stdout).Encode(report); err != nil {
+				return fmt.Errorf("failed to encode JSON: %w", err)
+			}
+			if !report.Equal {
+				return fmt.Errorf("values are not equal")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&typeJSON, "type", "", "CTY type specification as JSON or an HCL type expression")
+	cmd.Flags().StringVar(&inputFormat, "input-format", "json", "Input format of both values (json, msgpack)")
+	return addTypeFileFlags(cmd, true, "type")
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/ctyspec"
+)
+
+// ctyExplainCollection is the element count of one collection or
+// structural value of a `cty explain` report
+type ctyExplainCollection struct {
+	Path     string `json:"path"`
+	Kind     string `json:"kind"`
+	Elements int    `json:"elements"`
+}
+
+// ctyExplainReport is the structure summary `cty explain` writes
+type ctyExplainReport struct {
+	Type string `json:"type"`
+	// Depth is the deepest nesting of collections and structural values;
+	// a primitive value has depth 0
+	Depth       int                    `json:"depth"`
+	Values      int                    `json:"values"`
+	Nulls       int                    `json:"nulls"`
+	Unknowns    int                    `json:"unknowns"`
+	Marked      int                    `json:"marked"`
+	Collections []ctyExplainCollection `json:"collections"`
+	// JSONSize is nil when the value cannot be encoded as JSON, with
+	// JSONError saying why
+	JSONSize     *int   `json:"json_size"`
+	JSONError    string `json:"json_error,omitempty"`
+	MsgpackSize  *int   `json:"msgpack_size"`
+	MsgpackError string `json:"msgpack_error,omitempty"`
+}
+
+// explainCtyValue adds v, at path and nesting depth, and the values within
+// it to report
+func explainCtyValue(report *ctyExplainReport, v cty.Value, path cty.Path, depth int) {
+	report.Values++
+	if depth > report.Depth {
+		report.Depth = depth
+	}
+	v, marks := v.Unmark()
+	if len(marks) > 0 {
+		report.Marked++
+	}
+	switch {
+	case !v.IsKnown():
+		report.Unknowns++
+		return
+	case v.IsNull():
+		report.Nulls++
+		return
+	}
+
+	ty := v.Type()
+	var kind string
+	switch {
+	case ty.IsListType():
+		kind = "list"
+	case ty.IsSetType():
+		kind = "set"
+	case ty.IsMapType():
+		kind = "map"
+	case ty.IsTupleType():
+		kind = "tuple"
+	case ty.IsObjectType():
+		kind = "object"
+	default:
+		return
+	}
+	report.Collections = append(report.Collections, ctyExplainCollection{
+		Path:     formatCtyPath(path),
+		Kind:     kind,
+		Elements: v.LengthInt(),
+	})
+	for it := v.ElementIterator(); it.Next(); {
+		key, elem := it.Element()
+		elemPath := path.Index(key)
+		switch {
+		case ty.IsObjectType():
+			elemPath = path.GetAttr(key.AsString())
+		case ty.IsSetType():
+			elemPath = path.Index(cty.DynamicVal)
+		}
+		explainCtyValue(report, elem, elemPath, depth+1)
+	}
+}
+
+// initCtyExplainCmd creates the `cty explain` command
+func initCtyExplainCmd() *cobra.Command {
+	var typeJSON string
+	var inputFormat string
+
+	cmd := &cobra.Command{
+		Use:   "explain <input>",
+		Short: "Summarize the structure and encoded sizes of a CTY value",
+		Long: `Decode the value in <input> ("-" for stdin, which may hold base64 msgpack as
+written by wire encode) as --type and report its structure as JSON, to help pick
+fixtures for matrix runs: its nesting depth, the number of values within it and
+how many are null, unknown or marked, the element count of every collection and
+structural value by path, and its encoded size in JSON and msgpack. A value
+that cannot be encoded in a format, such as one holding unknowns in JSON, has a
+null size and the reason as json_error or msgpack_error.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ty, err := parseCtyType(json.RawMessage(typeJSON))
+			if err != nil {
+				return fmt.Errorf("failed to parse type: %w", err)
+			}
+
+			in, err := openInput(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to read input: %w", err)
+			}
+			defer in.Close()
+			var r io.Reader = in
+			if inputFormat == "msgpack" && args[0] == "-" {
+				if r, err = maybeBase64Reader(in); err != nil {
+					return fmt.Errorf("failed to read input: %w", err)
+				}
+			}
+			data, err := decodeLimits.ReadAll(r)
+			if err != nil {
+				return fmt.Errorf("failed to read input: %w", err)
+			}
+			value, err := ctyspec.Decode(ty, data, inputFormat, ctyOptions())
+			if err != nil {
+				return err
+			}
+
+			report := ctyExplainReport{Collections: []ctyExplainCollection{}}
+			if report.Type, err = ctyspec.FormatType(ty); err != nil {
+				return fmt.Errorf("failed to format type: %w", err)
+			}
+			explainCtyValue(&report, value, nil, 0)
+
+			unmarked, _ := value.UnmarkDeep()
+			valueType := ty.WithoutOptionalAttributesDeep()
+			if encoded, err := ctyspec.Encode(unmarked, valueType, "json", ctyOptions()); err != nil {
+				report.JSONError = err.Error()
+			} else {
+				size := len(encoded)
+				report.JSONSize = &size
+			}
+			if encoded, err := ctyspec.Encode(unmarked, valueType, "msgpack", ctyOptions()); err != nil {
+				report.MsgpackError = err.Error()
+			} else {
+				size := len(encoded)
+				report.MsgpackSize = &size
+			}
+
+			if err := json.NewEncoder(os.Stdout).Encode(report); err != nil {
+				return fmt.Errorf("failed to encode JSON: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&typeJSON, "type", "", "CTY type specification as JSON or an HCL type expression")
+	cmd.Flags().StringVar(&inputFormat, "input-format", "json", "Input format (json, msgpack)")
+	return addTypeFileFlags(cmd, true, "type")
+}
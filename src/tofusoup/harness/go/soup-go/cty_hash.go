@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/ctyspec"
+)
+
+// initCtyHashCmd creates the `cty hash` command
+func initCtyHashCmd() *cobra.Command {
+	var typeJSON string
+	var inputFormat string
+	var showCanonical bool
+
+	cmd := &cobra.Command{
+		Use:   "hash <input>",
+		Short: "Compute a cross-language stable hash of a CTY value",
+		Long: `Decode the value in <input> ("-" for stdin) as --type and print the SHA-256 of
+its canonical JSON encoding, so matrix results can be compared by hash instead
+of shipping whole payloads. The encoding depends only on the value: numbers
+are plain decimals, map keys and object attributes are sorted by their UTF-8
+bytes, set elements are sorted by their encoded bytes and unknowns are
+{"__unknown":true}. Types are not hashed, so equal values of different types,
+such as a list and a tuple, hash alike. --show-canonical includes the encoded
+text for debugging a hash mismatch.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ty, err := parseCtyType(json.RawMessage(typeJSON))
+			if err != nil {
+				return fmt.Errorf("failed to parse type: %w", err)
+			}
+			data, err := readFileLimited(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to read input: %w", err)
+			}
+			value, err := ctyspec.Decode(ty, data, inputFormat, ctyOptions())
+			if err != nil {
+				return err
+			}
+
+			canonical, err := ctyspec.CanonicalJSON(value)
+			if err != nil {
+				return fmt.Errorf("failed to encode canonical form: %w", err)
+			}
+			hash, err := ctyspec.Hash(value)
+			if err != nil {
+				return fmt.Errorf("failed to hash value: %w", err)
+			}
+			report := map[string]interface{}{
+				"algorithm": "sha256",
+				"hash":      hash,
+			}
+			if showCanonical {
+				report["canonical"] = string(canonical)
+			}
+			if err := json.NewEncoder(os.Stdout).Encode(report); err != nil {
+				return fmt.Errorf("failed to encode JSON: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&typeJSON, "type", "", "CTY type specification as JSON or an HCL type expression")
+	cmd.Flags().StringVar(&inputFormat, "input-format", "json", "Input format (json, msgpack)")
+	cmd.Flags().BoolVar(&showCanonical, "show-canonical", false, "Include the canonical encoding that was hashed")
+	return addTypeFileFlags(cmd, true, "type")
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/ctyspec"
+)
+
+// impliedCollection reports a tuple or object in an implied type and the
+// collection it could convert to
+type impliedCollection struct {
+	// Path locates the tuple or object, e.g. foo[0]
+	Path    string `json:"path"`
+	Implied string `json:"implied"`
+	// ElementType is what convert.Unify makes of the element or attribute
+	// types, null when they do not unify and no list or map can hold them
+	ElementType json.RawMessage `json:"element_type"`
+	// CollectionType is the list or map type the value converts to when
+	// its types unify
+	CollectionType string `json:"collection_type,omitempty"`
+}
+
+// impliedCollections appends a report for every tuple and object in ty
+func impliedCollections(ty cty.Type, path cty.Path, out *[]impliedCollection) error {
+	var elemTypes []cty.Type
+	report := impliedCollection{Path: formatCtyPath(path)}
+	switch {
+	case ty.IsTupleType():
+		report.Implied = "tuple"
+		elemTypes = ty.TupleElementTypes()
+		for i, elemType := range elemTypes {
+			if err := impliedCollections(elemType, path.Copy().Index(cty.NumberIntVal(int64(i))), out); err != nil {
+				return err
+			}
+		}
+	case ty.IsObjectType():
+		report.Implied = "object"
+		for _, name := range sortedKeys(ty.AttributeTypes()) {
+			elemTypes = append(elemTypes, ty.AttributeType(name))
+			if err := impliedCollections(ty.AttributeType(name), path.Copy().GetAttr(name), out); err != nil {
+				return err
+			}
+		}
+	default:
+		return nil
+	}
+
+	report.ElementType = json.RawMessage("null")
+	if len(elemTypes) > 0 {
+		if unified, _ := convert.Unify(elemTypes); unified != cty.NilType {
+			spec, err := ctyjson.MarshalType(unified)
+			if err != nil {
+				return fmt.Errorf("failed to marshal element type: %w", err)
+			}
+			report.ElementType = spec
+			collection := cty.List(unified)
+			if ty.IsObjectType() {
+				collection = cty.Map(unified)
+			}
+			if expr, err := ctyspec.FormatType(collection); err == nil {
+				report.CollectionType = expr
+			}
+		}
+	}
+	*out = append(*out, report)
+	return nil
+}
+
+// initCtyImpliedTypeCmd creates the `cty implied-type` command
+func initCtyImpliedTypeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "implied-type <file>",
+		Short: "Print the cty type go-cty infers for raw JSON",
+		Long: `Infer the type of the JSON in <file> ("-" for stdin) with go-cty's
+json.ImpliedType, as dynamic values are decoded, and print it as a JSON spec
+and a type expression. ImpliedType always makes arrays tuples and objects
+objects; collections lists each of them with the type convert.Unify gives
+their elements, and the list or map type they could convert to, to show why a
+dynamic conversion did or did not produce a collection.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := readFileLimited(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to read input: %w", err)
+			}
+			ty, err := ctyjson.ImpliedType(data)
+			if err != nil {
+				return fmt.Errorf("failed to infer type: %w", err)
+			}
+			spec, err := ctyjson.MarshalType(ty)
+			if err != nil {
+				return fmt.Errorf("failed to marshal type: %w", err)
+			}
+			friendly, err := ctyspec.FormatType(ty)
+			if err != nil {
+				return err
+			}
+			collections := []impliedCollection{}
+			if err := impliedCollections(ty, nil, &collections); err != nil {
+				return err
+			}
+
+			return json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+				"type":        json.RawMessage(spec),
+				"friendly":    friendly,
+				"collections": collections,
+			})
+		},
+	}
+}
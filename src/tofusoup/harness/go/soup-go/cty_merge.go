@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/ctyspec"
+)
+
+// initCtyMergeCmd creates the `cty merge` command
+func initCtyMergeCmd() *cobra.Command {
+	var typeJSON string
+	var inputFormat string
+	var outputFormat string
+	var conflict string
+
+	cmd := &cobra.Command{
+		Use:   "merge <left> <right> [output]",
+		Short: "Deep-merge two CTY values of the same type",
+		Long: `Decode <left> and <right> as --type and deep-merge <right> into <left>, as for
+layering an override fixture onto a base one. Objects and maps are merged
+attribute by attribute and key by key; lists, sets, tuples and primitives are
+replaced whole. A null or absent attribute on one side takes the other side's
+value. Where both sides hold different values, --conflict keeps the left or
+right one, or with error fails, reporting the path on stdout as
+  {"merge_conflict": {"path": "tags[\"env\"]"}}
+Marks of both sides are kept on the merged value.`,
+		Args: cobra.RangeArgs(2, 3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			outputPath := "-"
+			if len(args) > 2 {
+				outputPath = args[2]
+			}
+			ty, err := parseCtyType(json.RawMessage(typeJSON))
+			if err != nil {
+				return fmt.Errorf("failed to parse type: %w", err)
+			}
+
+			values := make([]cty.Value, 2)
+			for i, path := range args[:2] {
+				data, err := readFileLimited(path)
+				if err != nil {
+					return fmt.Errorf("failed to read input: %w", err)
+				}
+				if values[i], err = ctyspec.Decode(ty, data, inputFormat, ctyOptions()); err != nil {
+					return fmt.Errorf("failed to decode %s: %w", path, err)
+				}
+			}
+
+			merged, err := ctyspec.Merge(values[0], values[1], conflict)
+			if err != nil {
+				var conflictErr *ctyspec.MergeConflictError
+				if errors.As(err, &conflictErr) {
+					report := map[string]interface{}{"path": formatCtyPath(conflictErr.Path)}
+					if err := json.NewEncoder(os.Stdout).Encode(map[string]interface{}{"merge_conflict": report}); err != nil {
+						return fmt.Errorf("failed to encode JSON: %w", err)
+					}
+				}
+				return fmt.Errorf("failed to merge values: %w", err)
+			}
+
+			output, err := ctyspec.Encode(merged, ty.WithoutOptionalAttributesDeep(), outputFormat, ctyOptions())
+			if err != nil {
+				return fmt.Errorf("failed to encode output: %w", err)
+			}
+			if err := writeStreamOutput(outputPath, output); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&typeJSON, "type", "", "CTY type specification as JSON or an HCL type expression")
+	cmd.Flags().StringVar(&inputFormat, "input-format", "json", "Input format (json, msgpack)")
+	cmd.Flags().StringVar(&outputFormat, "output-format", "json", "Output format (json, msgpack, hcl)")
+	cmd.Flags().StringVar(&conflict, "conflict", ctyspec.MergeKeepRight, "Value kept where both sides differ (left, right, error)")
+	return addTypeFileFlags(cmd, true, "type")
+}
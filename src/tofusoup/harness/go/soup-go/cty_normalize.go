@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/ctyspec"
+)
+
+// initCtyNormalizeCmd creates the `cty normalize` command
+func initCtyNormalizeCmd() *cobra.Command {
+	var typeJSON string
+	var inputFormat string
+	var outputFormat string
+	var collections string
+
+	cmd := &cobra.Command{
+		Use:   "normalize <input> [output]",
+		Short: "Rewrite a CTY value to a canonical form of its nulls, empties and unknowns",
+		Long: `Decode the value in <input> as --type and rewrite it to a canonical form, so
+that differential tests stop flagging representational differences:
+
+  - every unknown, however refined, becomes a plain unknown
+  - null and empty lists, sets and maps become null, or with
+    --collections empty an empty collection
+  - marks are dropped
+
+Objects and tuples are left as they are. JSON output is the form cty convert
+reads, with unknowns as {"__unknown":true}.`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			outputPath := "-"
+			if len(args) > 1 {
+				outputPath = args[1]
+			}
+			if collections != "null" && collections != "empty" {
+				return fmt.Errorf("unsupported --collections form: %s", collections)
+			}
+			ty, err := parseCtyType(json.RawMessage(typeJSON))
+			if err != nil {
+				return fmt.Errorf("failed to parse type: %w", err)
+			}
+
+			data, err := readFileLimited(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to read input: %w", err)
+			}
+			value, err := ctyspec.Decode(ty, data, inputFormat, ctyOptions())
+			if err != nil {
+				return err
+			}
+			value, err = ctyspec.Normalize(value, collections == "empty")
+			if err != nil {
+				return fmt.Errorf("failed to normalize value: %w", err)
+			}
+
+			var output []byte
+			if outputFormat == "json" {
+				output, err = json.Marshal(ctyspec.ValueJSON(value))
+				output = append(output, '\n')
+			} else {
+				output, err = ctyspec.Encode(value, ty, outputFormat, ctyOptions())
+			}
+			if err != nil {
+				return fmt.Errorf("failed to encode output: %w", err)
+			}
+			if err := writeStreamOutput(outputPath, output); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&typeJSON, "type", "", "CTY type specification as JSON or an HCL type expression")
+	cmd.Flags().StringVar(&inputFormat, "input-format", "json", "Input format (json, msgpack)")
+	cmd.Flags().StringVar(&outputFormat, "output-format", "json", "Output format (json, msgpack, hcl)")
+	cmd.Flags().StringVar(&collections, "collections", "null", "Canonical form of null and empty collections (null, empty)")
+	return addTypeFileFlags(cmd, true, "type")
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/ctyspec"
+)
+
+// numberRoundTrip reports how one decimal survives the wire formats
+type numberRoundTrip struct {
+	Input string `json:"input"`
+	// Parsed is the number as go-cty holds it, in plain decimal
+	Parsed string `json:"parsed,omitempty"`
+	Error  string `json:"error,omitempty"`
+
+	JSONEncoded string `json:"json_encoded,omitempty"`
+	JSONDecoded string `json:"json_decoded,omitempty"`
+	JSONExact   bool   `json:"json_exact"`
+
+	// MsgpackKind is how go-cty's msgpack encoder wrote the number: int,
+	// float64, or string for numbers neither holds exactly
+	MsgpackKind    string `json:"msgpack_kind,omitempty"`
+	MsgpackDecoded string `json:"msgpack_decoded,omitempty"`
+	MsgpackExact   bool   `json:"msgpack_exact"`
+
+	// Float64 is the nearest float64, which an implementation using
+	// doubles would hold, and Float64Loss how far it is from the number, to
+	// 17 significant digits
+	Float64      string `json:"float64,omitempty"`
+	Float64Exact bool   `json:"float64_exact"`
+	Float64Loss  string `json:"float64_loss,omitempty"`
+}
+
+// roundTripNumber parses s as a cty number and round-trips it through
+// go-cty's JSON and msgpack encodings
+func roundTripNumber(s string) numberRoundTrip {
+	r := numberRoundTrip{Input: s}
+	v, err := cty.ParseNumberVal(s)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	r.Parsed = formatBigFloat(v.AsBigFloat())
+
+	if encoded, err := ctyjson.Marshal(v, cty.Number); err != nil {
+		r.Error = fmt.Sprintf("json: %s", err)
+	} else if decoded, err := ctyjson.Unmarshal(encoded, cty.Number); err != nil {
+		r.Error = fmt.Sprintf("json: %s", err)
+	} else {
+		r.JSONEncoded = string(encoded)
+		r.JSONDecoded = formatBigFloat(decoded.AsBigFloat())
+		r.JSONExact = decoded.AsBigFloat().Cmp(v.AsBigFloat()) == 0
+	}
+
+	if encoded, err := ctyspec.MarshalMsgpack(v, cty.Number, ctyOptions()); err != nil {
+		r.Error = fmt.Sprintf("msgpack: %s", err)
+	} else if decoded, err := ctyspec.UnmarshalMsgpack(encoded, cty.Number); err != nil {
+		r.Error = fmt.Sprintf("msgpack: %s", err)
+	} else {
+		r.MsgpackKind = msgpackNumberKind(encoded)
+		r.MsgpackDecoded = formatBigFloat(decoded.AsBigFloat())
+		r.MsgpackExact = decoded.AsBigFloat().Cmp(v.AsBigFloat()) == 0
+	}
+
+	f, _ := v.AsBigFloat().Float64()
+	r.Float64 = strconv.FormatFloat(f, 'g', -1, 64)
+	loss := new(big.Float).SetPrec(v.AsBigFloat().Prec())
+	loss.Sub(v.AsBigFloat(), new(big.Float).SetFloat64(f))
+	r.Float64Exact = loss.Sign() == 0
+	if !r.Float64Exact && !loss.IsInf() {
+		r.Float64Loss = loss.Abs(loss).Text('g', 17)
+	}
+	return r
+}
+
+// formatBigFloat formats f in plain decimal
+func formatBigFloat(f *big.Float) string {
+	if f.IsInf() {
+		return f.String()
+	}
+	return f.Text('f', -1)
+}
+
+// msgpackNumberKind names the msgpack representation of an encoded number
+func msgpackNumberKind(data []byte) string {
+	switch c := data[0]; {
+	case c == 0xcb || c == 0xca:
+		return "float64"
+	case c >= 0xa0 && c <= 0xbf, c >= 0xd9 && c <= 0xdb:
+		return "string"
+	default:
+		return "int"
+	}
+}
+
+// readNumberInputs returns the decimals given as arguments followed by
+// those in file, one per line, skipping blank lines and # comments
+func readNumberInputs(args []string, file string) ([]string, error) {
+	inputs := append([]string(nil), args...)
+	if file != "" {
+		in, err := openInput(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read input: %w", err)
+		}
+		defer in.Close()
+		scanner := bufio.NewScanner(in)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			inputs = append(inputs, line)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read input: %w", err)
+		}
+	}
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("no numbers given")
+	}
+	return inputs, nil
+}
+
+// numberComparison is the result of comparing two numbers with cty's
+// Equals and LessThan
+type numberComparison struct {
+	A string `json:"a"`
+	B string `json:"b"`
+	// Cmp is -1, 0 or 1 as a is less than, equal to or greater than b
+	Cmp int `json:"cmp"`
+}
+
+// compareNumbers compares a and b as cty does
+func compareNumbers(a, b cty.Value) int {
+	switch {
+	case a.Equals(b).True():
+		return 0
+	case a.LessThan(b).True():
+		return -1
+	default:
+		return 1
+	}
+}
+
+// initCtyNumberCompareCmd creates the `cty number-compare` command
+func initCtyNumberCompareCmd() *cobra.Command {
+	var file string
+
+	cmd := &cobra.Command{
+		Use:   "number-compare [decimal]...",
+		Short: "Report the order of numbers under cty's comparison semantics",
+		Long: `Parse each decimal string as a cty number and report their ascending order and
+the result of comparing every pair with cty's Equals and LessThan, to detect
+comparison divergences between harnesses holding numbers at different
+precisions. sorted lists the inputs in order, equal numbers keeping their input
+order, and sorted_indices their positions in the input. Each comparison's cmp
+is -1, 0 or 1 as a is less than, equal to or greater than b. --file reads
+further decimals as number-roundtrip does; negative arguments must follow --.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			inputs, err := readNumberInputs(args, file)
+			if err != nil {
+				return err
+			}
+			values := make([]cty.Value, len(inputs))
+			parsed := make([]string, len(inputs))
+			for i, s := range inputs {
+				if values[i], err = cty.ParseNumberVal(s); err != nil {
+					return fmt.Errorf("invalid number %q: %w", s, err)
+				}
+				parsed[i] = formatBigFloat(values[i].AsBigFloat())
+			}
+
+			order := make([]int, len(values))
+			for i := range order {
+				order[i] = i
+			}
+			sort.SliceStable(order, func(i, j int) bool {
+				return compareNumbers(values[order[i]], values[order[j]]) < 0
+			})
+			sorted := make([]string, len(order))
+			for i, index := range order {
+				sorted[i] = inputs[index]
+			}
+
+			comparisons := []numberComparison{}
+			for i := range values {
+				for j := i + 1; j < len(values); j++ {
+					comparisons = append(comparisons, numberComparison{
+						A:   inputs[i],
+						B:   inputs[j],
+						Cmp: compareNumbers(values[i], values[j]),
+					})
+				}
+			}
+
+			if err := json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+				"inputs":         inputs,
+				"parsed":         parsed,
+				"sorted":         sorted,
+				"sorted_indices": order,
+				"comparisons":    comparisons,
+			}); err != nil {
+				return fmt.Errorf("failed to encode JSON: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&file, "file", "", "File of decimals, one per line (\"-\" for stdin)")
+	return cmd
+}
+
+// initCtyNumberRoundTripCmd creates the `cty number-roundtrip` command
+func initCtyNumberRoundTripCmd() *cobra.Command {
+	var file string
+
+	cmd := &cobra.Command{
+		Use:   "number-roundtrip [decimal]...",
+		Short: "Report precision lost round-tripping numbers through JSON and msgpack",
+		Long: `Parse each decimal string as a cty number and round-trip it through go-cty's
+JSON and msgpack encodings, reporting the decoded values and whether each is
+exact. msgpack_kind shows whether the number was written as an int, a float64
+or, when neither holds it exactly, a string. float64 and float64_loss show the
+precision an implementation holding numbers as doubles would lose. --file reads
+further decimals, one per line; blank lines and lines starting with # are
+skipped. Exits non-zero if JSON or msgpack lost precision.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			inputs, err := readNumberInputs(args, file)
+			if err != nil {
+				return err
+			}
+
+			results := make([]numberRoundTrip, len(inputs))
+			lossless := true
+			for i, s := range inputs {
+				results[i] = roundTripNumber(s)
+				lossless = lossless && results[i].Error == "" && results[i].JSONExact && results[i].MsgpackExact
+			}
+			if err := json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+				"results":  results,
+				"lossless": lossless,
+			}); err != nil {
+				return fmt.Errorf("failed to encode JSON: %w", err)
+			}
+			if !lossless {
+				return fmt.Errorf("numbers did not round-trip exactly")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&file, "file", "", "File of decimals, one per line (\"-\" for stdin)")
+	return cmd
+}
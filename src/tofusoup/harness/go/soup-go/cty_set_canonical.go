@@ -0,0 +1,311 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/vmihailenco/msgpack/v5/msgpcode"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/ctyspec"
+)
+
+// setOrderReport compares the element order of one set in the input with
+// go-cty's order
+type setOrderReport struct {
+	// Path locates the set, e.g. foo[0].tags
+	Path    string `json:"path"`
+	Matches bool   `json:"matches"`
+	// Duplicates counts input elements equal to an earlier one, which the
+	// set drops
+	Duplicates     int           `json:"duplicates"`
+	InputOrder     []interface{} `json:"input_order"`
+	CanonicalOrder []interface{} `json:"canonical_order"`
+}
+
+// splitRawArray splits an encoded array into its encoded elements, with
+// ok false for null and unknown values, which have no elements
+func splitRawArray(data []byte, format string) (elems [][]byte, ok bool, err error) {
+	if format == "json" {
+		var raw []json.RawMessage
+		if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+			return nil, false, nil
+		}
+		if err := json.Unmarshal(data, &raw); err != nil {
+			// An unknown sentinel is an object
+			return nil, false, nil
+		}
+		for _, elem := range raw {
+			elems = append(elems, elem)
+		}
+		return elems, true, nil
+	}
+
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	code, err := dec.PeekCode()
+	if err != nil {
+		return nil, false, err
+	}
+	if code == msgpcode.Nil || msgpcode.IsExt(code) {
+		return nil, false, nil
+	}
+	n, err := dec.DecodeArrayLen()
+	if err != nil {
+		return nil, false, err
+	}
+	for i := 0; i < n; i++ {
+		elem, err := dec.DecodeRaw()
+		if err != nil {
+			return nil, false, err
+		}
+		elems = append(elems, elem)
+	}
+	return elems, true, nil
+}
+
+// splitRawMap splits an encoded map or object into its encoded values by
+// key, with ok false for null and unknown values
+func splitRawMap(data []byte, format string) (entries map[string][]byte, ok bool, err error) {
+	entries = map[string][]byte{}
+	if format == "json" {
+		var raw map[string]json.RawMessage
+		if err := json.Unmarshal(data, &raw); err != nil || raw == nil {
+			return nil, false, nil
+		}
+		if _, unknown := raw[ctyspec.UnknownKey]; unknown {
+			return nil, false, nil
+		}
+		for k, v := range raw {
+			entries[k] = v
+		}
+		return entries, true, nil
+	}
+
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	code, err := dec.PeekCode()
+	if err != nil {
+		return nil, false, err
+	}
+	if code == msgpcode.Nil || msgpcode.IsExt(code) {
+		return nil, false, nil
+	}
+	n, err := dec.DecodeMapLen()
+	if err != nil {
+		return nil, false, err
+	}
+	for i := 0; i < n; i++ {
+		k, err := dec.DecodeString()
+		if err != nil {
+			return nil, false, err
+		}
+		v, err := dec.DecodeRaw()
+		if err != nil {
+			return nil, false, err
+		}
+		entries[k] = v
+	}
+	return entries, true, nil
+}
+
+// checkSetOrders walks the encoded value data of type ty and appends a
+// report for every set in it. Values of dynamic type are not walked.
+func checkSetOrders(data []byte, ty cty.Type, format string, path cty.Path, reports *[]setOrderReport) error {
+	switch {
+	case !typeHasSets(ty):
+		return nil
+	case ty.IsSetType():
+		elems, ok, err := splitRawArray(data, format)
+		if err != nil || !ok {
+			return err
+		}
+		report, err := compareSetOrder(elems, ty, format, path)
+		if err != nil {
+			return err
+		}
+		*reports = append(*reports, report)
+		for i, elem := range elems {
+			// Set elements have no key; the index is the input position
+			elemPath := path.Copy().Index(cty.NumberIntVal(int64(i)))
+			if err := checkSetOrders(elem, ty.ElementType(), format, elemPath, reports); err != nil {
+				return err
+			}
+		}
+	case ty.IsListType() || ty.IsTupleType():
+		elems, ok, err := splitRawArray(data, format)
+		if err != nil || !ok {
+			return err
+		}
+		for i, elem := range elems {
+			elemType := cty.DynamicPseudoType
+			if ty.IsListType() {
+				elemType = ty.ElementType()
+			} else if i < len(ty.TupleElementTypes()) {
+				elemType = ty.TupleElementType(i)
+			}
+			if err := checkSetOrders(elem, elemType, format, path.Copy().Index(cty.NumberIntVal(int64(i))), reports); err != nil {
+				return err
+			}
+		}
+	case ty.IsMapType() || ty.IsObjectType():
+		entries, ok, err := splitRawMap(data, format)
+		if err != nil || !ok {
+			return err
+		}
+		for _, k := range sortedKeys(entries) {
+			if ty.IsMapType() {
+				err = checkSetOrders(entries[k], ty.ElementType(), format, path.Copy().Index(cty.StringVal(k)), reports)
+			} else if ty.HasAttribute(k) {
+				err = checkSetOrders(entries[k], ty.AttributeType(k), format, path.Copy().GetAttr(k), reports)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// compareSetOrder decodes a set's encoded elements and compares their
+// order, without duplicates, with the order go-cty iterates the set in
+func compareSetOrder(elems [][]byte, ty cty.Type, format string, path cty.Path) (setOrderReport, error) {
+	report := setOrderReport{
+		Path:           formatCtyPath(path),
+		InputOrder:     []interface{}{},
+		CanonicalOrder: []interface{}{},
+	}
+
+	var input []cty.Value
+	for i, raw := range elems {
+		elem, err := ctyspec.Decode(ty.ElementType(), raw, format, ctyOptions())
+		if err != nil {
+			return report, fmt.Errorf("failed to decode element %d of set %s: %w", i, report.Path, err)
+		}
+		report.InputOrder = append(report.InputOrder, ctyspec.ValueJSON(elem))
+		duplicate := false
+		for _, seen := range input {
+			if seen.RawEquals(elem) {
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			report.Duplicates++
+			continue
+		}
+		input = append(input, elem)
+	}
+
+	var canonical []cty.Value
+	if len(input) > 0 {
+		set := cty.SetVal(input)
+		for it := set.ElementIterator(); it.Next(); {
+			_, elem := it.Element()
+			canonical = append(canonical, elem)
+			report.CanonicalOrder = append(report.CanonicalOrder, ctyspec.ValueJSON(elem))
+		}
+	}
+
+	report.Matches = report.Duplicates == 0 && len(input) == len(canonical)
+	for i := 0; report.Matches && i < len(input); i++ {
+		report.Matches = input[i].RawEquals(canonical[i])
+	}
+	return report, nil
+}
+
+// typeHasSets reports whether ty is or contains a set type
+func typeHasSets(ty cty.Type) bool {
+	switch {
+	case ty.IsSetType():
+		return true
+	case ty.IsListType() || ty.IsMapType():
+		return typeHasSets(ty.ElementType())
+	case ty.IsObjectType():
+		for _, attrType := range ty.AttributeTypes() {
+			if typeHasSets(attrType) {
+				return true
+			}
+		}
+	case ty.IsTupleType():
+		for _, elemType := range ty.TupleElementTypes() {
+			if typeHasSets(elemType) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// initCtySetCanonicalCmd creates the `cty set-canonical` command
+func initCtySetCanonicalCmd() *cobra.Command {
+	var typeJSON string
+	var inputFormat string
+
+	cmd := &cobra.Command{
+		Use:   "set-canonical <input>",
+		Short: "Check that sets in an encoded value are in go-cty's canonical order",
+		Long: `Decode the value in <input> ("-" for stdin) as --type and, for every set in
+it, compare the order its elements were encoded in with go-cty's order, which
+go-cty's msgpack and JSON encoders write, to referee ordering disagreements
+between encoders. Sets inside values of dynamic type are not checked.
+
+The report gives each set's input and canonical element order, whether the
+value is canonical throughout, and the canonical form: the value as JSON and,
+base64 encoded, as go-cty encodes it to msgpack. Exits non-zero unless the
+input is canonical.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ty, err := parseCtyType(json.RawMessage(typeJSON))
+			if err != nil {
+				return fmt.Errorf("failed to parse type: %w", err)
+			}
+			data, err := readFileLimited(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to read input: %w", err)
+			}
+			value, err := ctyspec.Decode(ty, data, inputFormat, ctyOptions())
+			if err != nil {
+				return err
+			}
+
+			sets := []setOrderReport{}
+			if err := checkSetOrders(data, ty, inputFormat, nil, &sets); err != nil {
+				return fmt.Errorf("failed to walk input: %w", err)
+			}
+			canonical := true
+			for _, set := range sets {
+				canonical = canonical && set.Matches
+			}
+			canonicalMsgpack, err := ctyspec.MarshalMsgpack(value, ty, ctyOptions())
+			if err != nil {
+				return fmt.Errorf("failed to marshal to msgpack: %w", err)
+			}
+
+			report := map[string]interface{}{
+				"canonical":         canonical,
+				"sets":              sets,
+				"canonical_value":   ctyspec.ValueJSON(value),
+				"canonical_msgpack": base64.StdEncoding.EncodeToString(canonicalMsgpack),
+			}
+			if inputFormat == "msgpack" {
+				// Other differences, such as number encodings, also count
+				report["bytes_match"] = bytes.Equal(data, canonicalMsgpack)
+			}
+			if err := json.NewEncoder(os.Stdout).Encode(report); err != nil {
+				return fmt.Errorf("failed to encode JSON: %w", err)
+			}
+			if !canonical {
+				return fmt.Errorf("set elements are not in canonical order")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&typeJSON, "type", "", "CTY type specification as JSON or an HCL type expression")
+	cmd.Flags().StringVar(&inputFormat, "input-format", "msgpack", "Input format (msgpack, json)")
+	return addTypeFileFlags(cmd, true, "type")
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/ctyspec"
+)
+
+// ctyStatsFile is the inventory entry of one fixture of `cty stats`
+type ctyStatsFile struct {
+	Path     string   `json:"path"`
+	Format   string   `json:"format,omitempty"`
+	Type     string   `json:"type,omitempty"`
+	Features []string `json:"features,omitempty"`
+	Error    string   `json:"error,omitempty"`
+	// Skipped is why the file was not analyzed
+	Skipped string `json:"skipped,omitempty"`
+}
+
+// ctyStatsReport is the inventory `cty stats` writes. Types and Features
+// count the analyzed files using each type and exercising each feature.
+type ctyStatsReport struct {
+	Total    int            `json:"total"`
+	Analyzed int            `json:"analyzed"`
+	Failed   int            `json:"failed"`
+	Skipped  int            `json:"skipped"`
+	Types    map[string]int `json:"types"`
+	Features map[string]int `json:"features"`
+	Files    []ctyStatsFile `json:"files"`
+}
+
+// typeFeatures adds the features of ty that values need not exercise to
+// features: optional attributes, dynamic types and capsules
+func typeFeatures(ty cty.Type, features map[string]bool) {
+	switch {
+	case ty == cty.DynamicPseudoType:
+		features["dynamic"] = true
+	case ty.IsCapsuleType():
+		features["capsules"] = true
+	case ty.IsCollectionType():
+		typeFeatures(ty.ElementType(), features)
+	case ty.IsTupleType():
+		for _, elemTy := range ty.TupleElementTypes() {
+			typeFeatures(elemTy, features)
+		}
+	case ty.IsObjectType():
+		for name, attrTy := range ty.AttributeTypes() {
+			if ty.AttributeOptional(name) {
+				features["optional_attrs"] = true
+			}
+			typeFeatures(attrTy, features)
+		}
+	}
+}
+
+// valueFeatures adds the features v exercises to features: the kinds of
+// non-null collection and structural values it holds, empty collections,
+// and nulls, unknowns, refined unknowns and marks
+func valueFeatures(v cty.Value, features map[string]bool) {
+	_ = cty.Walk(v, func(_ cty.Path, v cty.Value) (bool, error) {
+		v, marks := v.Unmark()
+		if len(marks) > 0 {
+			features["marks"] = true
+		}
+		switch {
+		case !v.IsKnown():
+			features["unknowns"] = true
+			if !v.RawEquals(cty.UnknownVal(v.Type())) {
+				features["refined_unknowns"] = true
+			}
+			return false, nil
+		case v.IsNull():
+			features["nulls"] = true
+			return false, nil
+		}
+		ty := v.Type()
+		switch {
+		case ty.IsListType():
+			features["lists"] = true
+		case ty.IsSetType():
+			features["sets"] = true
+		case ty.IsMapType():
+			features["maps"] = true
+		case ty.IsTupleType():
+			features["tuples"] = true
+		case ty.IsObjectType():
+			features["objects"] = true
+		}
+		if ty.IsCollectionType() && v.LengthInt() == 0 {
+			features["empty_collections"] = true
+		}
+		return true, nil
+	})
+}
+
+// statsFixture decodes one fixture as ty and lists the features it exercises
+func statsFixture(path, format string, ty cty.Type) ([]string, error) {
+	data, err := readFileLimited(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+	value, err := ctyspec.Decode(ty, data, format, ctyOptions())
+	if err != nil {
+		return nil, err
+	}
+	features := map[string]bool{}
+	typeFeatures(ty, features)
+	valueFeatures(value, features)
+	return sortedKeys(features), nil
+}
+
+// initCtyStatsCmd creates the `cty stats` command
+func initCtyStatsCmd() *cobra.Command {
+	var dir, manifestPath string
+	var typeJSON string
+
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Inventory the types and features a fixture corpus exercises",
+		Long: `Scan every .json and .msgpack file under --dir, decoding each as its type, and
+write a JSON inventory of the corpus, so gaps in matrix coverage can be found
+programmatically: how many files use each type and exercise each feature, and
+per file its type and features. Types come from a manifest as for cty convert
+--dir, by default manifest.json in --dir, with --type for files it does not
+cover; other files are skipped.
+
+Features are optional_attrs, dynamic and capsules from the type, and from the
+value lists, sets, maps, tuples, objects, empty_collections, nulls, unknowns,
+refined_unknowns and marks. The command exits non-zero if any file failed to
+decode.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fallback := cty.NilType
+			if typeJSON != "" {
+				var err error
+				if fallback, err = parseCtyType(json.RawMessage(typeJSON)); err != nil {
+					return fmt.Errorf("failed to parse type: %w", err)
+				}
+			}
+			manifest, manifestPath, err := readDirManifest(dir, manifestPath)
+			if err != nil {
+				return err
+			}
+			types := map[string]cty.Type{}
+			for key, spec := range manifest.Types {
+				if types[key], err = parseCtyType(spec); err != nil {
+					return fmt.Errorf("invalid type for %s in manifest: %w", key, err)
+				}
+			}
+
+			report := ctyStatsReport{Types: map[string]int{}, Features: map[string]int{}, Files: []ctyStatsFile{}}
+			err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				format := ""
+				switch filepath.Ext(p) {
+				case ".json":
+					format = "json"
+				case ".msgpack":
+					format = "msgpack"
+				}
+				if d.IsDir() || format == "" {
+					return nil
+				}
+				if manifestPath != "" && filepath.Clean(p) == filepath.Clean(manifestPath) {
+					return nil
+				}
+				rel, err := filepath.Rel(dir, p)
+				if err != nil {
+					return err
+				}
+				file := ctyStatsFile{Path: filepath.ToSlash(rel), Format: format}
+				report.Total++
+
+				ty := fallback
+				if key, ok := manifest.entryFor(file.Path); ok {
+					ty = types[key]
+				}
+				if ty == cty.NilType {
+					file.Skipped = "no type in manifest"
+					report.Skipped++
+					report.Files = append(report.Files, file)
+					return nil
+				}
+				if file.Type, err = ctyspec.FormatType(ty); err != nil {
+					file.Type = ty.FriendlyName()
+				}
+
+				features, err := statsFixture(p, format, ty)
+				if err != nil {
+					file.Error = err.Error()
+					report.Failed++
+					report.Files = append(report.Files, file)
+					return nil
+				}
+				file.Features = features
+				report.Analyzed++
+				report.Types[file.Type]++
+				for _, feature := range features {
+					report.Features[feature]++
+				}
+				report.Files = append(report.Files, file)
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("failed to walk %s: %w", dir, err)
+			}
+			sort.Slice(report.Files, func(i, j int) bool { return report.Files[i].Path < report.Files[j].Path })
+
+			logger.Info("📊 fixture inventory complete", "total", report.Total, "analyzed", report.Analyzed, "failed", report.Failed, "skipped", report.Skipped)
+			if err := json.NewEncoder(os.Stdout).Encode(report); err != nil {
+				return fmt.Errorf("failed to encode JSON: %w", err)
+			}
+			if report.Failed > 0 {
+				return fmt.Errorf("%d of %d files failed", report.Failed, report.Total)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&dir, "dir", "", "Fixture directory tree to scan")
+	cmd.Flags().StringVar(&manifestPath, "manifest", "", "Manifest mapping --dir files to types (default: manifest.json in --dir)")
+	cmd.Flags().StringVar(&typeJSON, "type", "", "CTY type of files the manifest does not cover, as JSON or an HCL type expression")
+	cmd.MarkFlagRequired("dir")
+	return addTypeFileFlags(cmd, false, "type")
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/limits"
+)
+
+// streamResult is one output line of `cty convert --stream ndjson`
+type streamResult struct {
+	// Line is the 1-based input line the result is for
+	Line   int         `json:"line"`
+	Output interface{} `json:"output,omitempty"`
+	Error  string      `json:"error,omitempty"`
+	// Path locates the value that failed to convert to --target-type
+	Path  string                `json:"path,omitempty"`
+	Limit *limits.ExceededError `json:"limit,omitempty"`
+	// ValueError details a value that does not fit --type
+	ValueError *ctyValueError `json:"value_error,omitempty"`
+}
+
+// convertStreamLine converts one NDJSON line, isolating errors and panics
+// to the line
+func convertStreamLine(lineNo int, line []byte, inputFormat, outputFormat string, convert func(data []byte) ([]byte, error)) (result streamResult) {
+	result.Line = lineNo
+	defer func() {
+		if r := recover(); r != nil {
+			result.Output = nil
+			result.Error = fmt.Sprintf("panic: %v", r)
+		}
+	}()
+
+	output, err := func() (interface{}, error) {
+		data, err := decodeBatchInput(json.RawMessage(line), inputFormat)
+		if err != nil {
+			return nil, err
+		}
+		outputData, err := convert(data)
+		if err != nil {
+			return nil, err
+		}
+		return encodeBatchOutput(outputData, outputFormat)
+	}()
+	if err != nil {
+		result.Error = err.Error()
+		result.Limit = limits.As(err)
+		result.ValueError = newCtyValueError(err)
+		var failure *ctyConversionFailure
+		if errors.As(err, &failure) {
+			result.Path = failure.report.Path
+		}
+		return result
+	}
+	result.Output = output
+	return result
+}
+
+// runNDJSONStream converts each line of the NDJSON input at inputPath as
+// an independent value, writing one streamResult line per value to
+// outputPath as it goes. Blank lines are skipped. It returns an error if
+// the input could not be read or any line failed.
+func runNDJSONStream(inputPath, outputPath, inputFormat, outputFormat string, convert func(data []byte) ([]byte, error)) error {
+	in, err := openInput(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to read input: %w", err)
+	}
+	defer in.Close()
+	out, err := createOutput(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+
+	w := newJSONLWriter(out, outputBufferOptions{})
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxBatchLineSize)
+	total, failed := 0, 0
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		result := convertStreamLine(lineNo, line, inputFormat, outputFormat, convert)
+		total++
+		if result.Error != "" {
+			failed++
+			logger.Debug("🌊❌ stream line failed", "line", lineNo, "error", result.Error)
+		}
+		if err := w.WriteLine(result); err != nil {
+			out.Close()
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read input: %w", err)
+	}
+
+	logger.Info("🌊✅ stream complete", "total", total, "failed", failed)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d lines failed", failed, total)
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/ctyspec"
+)
+
+// Expected outcomes of a validation suite case
+const (
+	suiteExpectValid   = "valid"
+	suiteExpectInvalid = "invalid"
+)
+
+// validationSuite is the suite file of `cty validate-suite`
+type validationSuite struct {
+	// Type is the type of cases that do not give their own
+	Type  json.RawMessage  `json:"type,omitempty"`
+	Cases []validationCase `json:"cases"`
+}
+
+// validationCase pairs a value with its type and expected outcome
+type validationCase struct {
+	Name  string          `json:"name"`
+	Type  json.RawMessage `json:"type,omitempty"`
+	Value json.RawMessage `json:"value"`
+	// Expect is valid (the default) or invalid
+	Expect string `json:"expect,omitempty"`
+	// Error, if set, must appear in the error of an invalid value
+	Error string `json:"error,omitempty"`
+}
+
+// validationCaseResult is the line `cty validate-suite` writes per case
+type validationCaseResult struct {
+	Index    int    `json:"index"`
+	Name     string `json:"name,omitempty"`
+	Pass     bool   `json:"pass"`
+	Expected string `json:"expected"`
+	Outcome  string `json:"outcome,omitempty"`
+	Error    string `json:"error,omitempty"`
+	// Reason is why a case failed
+	Reason     string         `json:"reason,omitempty"`
+	ValueError *ctyValueError `json:"value_error,omitempty"`
+}
+
+// readValidationSuite reads a suite file, as YAML if its extension is
+// .yaml or .yml and as JSON otherwise
+func readValidationSuite(path string) (validationSuite, error) {
+	var suite validationSuite
+	data, err := readFileLimited(path)
+	if err != nil {
+		return suite, fmt.Errorf("failed to read suite: %w", err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		var doc interface{}
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return suite, fmt.Errorf("failed to parse suite YAML: %w", err)
+		}
+		if data, err = json.Marshal(doc); err != nil {
+			return suite, fmt.Errorf("failed to parse suite YAML: %w", err)
+		}
+	}
+	if err := json.Unmarshal(data, &suite); err != nil {
+		return suite, fmt.Errorf("failed to parse suite: %w", err)
+	}
+	return suite, nil
+}
+
+// runValidationCase validates the value of c against its type, or the
+// suite's type, and compares the outcome with the expected one
+func runValidationCase(suite validationSuite, index int, c validationCase) validationCaseResult {
+	result := validationCaseResult{Index: index, Name: c.Name, Expected: c.Expect}
+	if result.Expected == "" {
+		result.Expected = suiteExpectValid
+	}
+	if result.Expected != suiteExpectValid && result.Expected != suiteExpectInvalid {
+		result.Reason = fmt.Sprintf("unsupported expect: %s", c.Expect)
+		return result
+	}
+	spec := c.Type
+	if len(spec) == 0 {
+		spec = suite.Type
+	}
+	if len(spec) == 0 {
+		result.Reason = "case has no type"
+		return result
+	}
+	if len(c.Value) == 0 {
+		result.Reason = "case has no value"
+		return result
+	}
+
+	ty, err := parseCtyType(spec)
+	if err != nil {
+		result.Reason = fmt.Sprintf("failed to parse type: %v", err)
+		return result
+	}
+	result.Outcome = suiteExpectValid
+	if _, err := ctyspec.BuildValue(ty, c.Value, ctyOptions()); err != nil {
+		result.Outcome = suiteExpectInvalid
+		result.Error = err.Error()
+		result.ValueError = newCtyValueError(err)
+	}
+
+	switch {
+	case result.Outcome != result.Expected:
+		result.Reason = fmt.Sprintf("expected %s, got %s", result.Expected, result.Outcome)
+	case c.Error != "" && !strings.Contains(result.Error, c.Error):
+		result.Reason = fmt.Sprintf("error does not contain %q", c.Error)
+	default:
+		result.Pass = true
+	}
+	return result
+}
+
+// initCtyValidateSuiteCmd creates the `cty validate-suite` command
+func initCtyValidateSuiteCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate-suite <file>",
+		Short: "Validate every value of a suite file against its type",
+		Long: `Run the cases of a suite file, each a JSON value to validate against a type
+as validate-value does, with its expected outcome. The suite is YAML if the
+file's extension is .yaml or .yml, and JSON otherwise:
+
+  type: object({port=number})      # used by cases without their own
+  cases:
+    - name: port is a number
+      value: {"port": 80}
+    - name: port is not a bool
+      type: object({port=number})
+      value: {"port": true}
+      expect: invalid              # valid (the default) or invalid
+      error: expected number       # must appear in the error
+
+Types are JSON type specifications or HCL type expressions. One JSON line is
+written per case,
+  {"index": 0, "name": "...", "pass": true, "expected": "valid", "outcome": "valid"}
+with the error and value_error of an invalid value and, for a case that
+failed, the reason. The command exits non-zero if any case failed.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			suite, err := readValidationSuite(args[0])
+			if err != nil {
+				return err
+			}
+
+			w := newJSONLWriter(os.Stdout, outputBufferOptions{})
+			failed := 0
+			for i, c := range suite.Cases {
+				result := runValidationCase(suite, i, c)
+				if !result.Pass {
+					failed++
+				}
+				if err := w.WriteLine(result); err != nil {
+					return fmt.Errorf("failed to write result: %w", err)
+				}
+			}
+			if err := w.Flush(); err != nil {
+				return fmt.Errorf("failed to write result: %w", err)
+			}
+
+			logger.Info("🧪 validation suite complete", "cases", len(suite.Cases), "failed", failed)
+			if failed > 0 {
+				return fmt.Errorf("%d of %d cases failed", failed, len(suite.Cases))
+			}
+			return nil
+		},
+	}
+	return cmd
+}
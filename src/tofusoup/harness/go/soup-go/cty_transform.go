@@ -0,0 +1,346 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/ctyspec"
+)
+
+// transformRules is the rules file of `cty transform`
+type transformRules struct {
+	Rules []transformRule `json:"rules"`
+}
+
+// transformRule changes the values it matches
+type transformRule struct {
+	// Path is a pattern such as items[*].name or tags.* matching the paths
+	// of the values the rule applies to; empty matches every value
+	Path string `json:"path,omitempty"`
+	// Type, when set, limits the rule to values of that type
+	Type json.RawMessage `json:"type,omitempty"`
+	// Action is null, unknown, set, upper, lower, replace_unknown or
+	// replace_null
+	Action string `json:"action"`
+	// Value is the JSON value of set, and of replace_unknown and
+	// replace_null, which use the type's zero value without it
+	Value json.RawMessage `json:"value,omitempty"`
+
+	pattern []pathPatternStep
+	ty      cty.Type
+	applied int
+}
+
+// pathPatternStep is one step of a rule's path pattern. Any matches any
+// attribute or map key, or with index any index.
+type pathPatternStep struct {
+	name  string
+	key   cty.Value
+	index bool
+	any   bool
+}
+
+// parsePathPattern parses a path pattern: attribute names separated by
+// dots, [N] and ["key"] indexes, * for any attribute or map key and [*]
+// for any index, including set elements
+func parsePathPattern(pattern string) ([]pathPatternStep, error) {
+	var steps []pathPatternStep
+	rest := pattern
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, "."):
+			rest = rest[1:]
+			if rest == "" || rest[0] == '.' || rest[0] == '[' {
+				return nil, fmt.Errorf("invalid path pattern %q: empty step", pattern)
+			}
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path pattern %q: unclosed [", pattern)
+			}
+			inner := rest[1:end]
+			rest = rest[end+1:]
+			switch {
+			case inner == "*":
+				steps = append(steps, pathPatternStep{index: true, any: true})
+			case strings.HasPrefix(inner, `"`):
+				key, err := strconv.Unquote(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid path pattern %q: bad key %s", pattern, inner)
+				}
+				steps = append(steps, pathPatternStep{index: true, key: cty.StringVal(key)})
+			default:
+				n, err := cty.ParseNumberVal(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid path pattern %q: bad index %s", pattern, inner)
+				}
+				steps = append(steps, pathPatternStep{index: true, key: n})
+			}
+		default:
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			name := rest[:end]
+			rest = rest[end:]
+			if name == "*" {
+				steps = append(steps, pathPatternStep{any: true})
+			} else {
+				steps = append(steps, pathPatternStep{name: name})
+			}
+		}
+	}
+	return steps, nil
+}
+
+// matchPathPattern reports whether path matches pattern. A name matches an
+// attribute or a map key of that name.
+func matchPathPattern(pattern []pathPatternStep, path cty.Path) bool {
+	if len(pattern) != len(path) {
+		return false
+	}
+	for i, p := range pattern {
+		switch s := path[i].(type) {
+		case cty.GetAttrStep:
+			if p.index || (!p.any && p.name != s.Name) {
+				return false
+			}
+		case cty.IndexStep:
+			isString := s.Key.IsKnown() && !s.Key.IsNull() && s.Key.Type() == cty.String
+			switch {
+			case p.index && p.any:
+			case p.index:
+				if !p.key.Type().Equals(s.Key.Type()) || !p.key.RawEquals(s.Key) {
+					return false
+				}
+			case p.any:
+				if !isString {
+					return false
+				}
+			default:
+				if !isString || s.Key.AsString() != p.name {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// compile parses the rule's pattern and type and checks its action
+func (r *transformRule) compile() error {
+	pattern, err := parsePathPattern(r.Path)
+	if err != nil {
+		return err
+	}
+	r.pattern = pattern
+	r.ty = cty.NilType
+	if len(r.Type) > 0 {
+		if r.ty, err = parseCtyType(r.Type); err != nil {
+			return fmt.Errorf("invalid type: %w", err)
+		}
+	}
+	switch r.Action {
+	case "null", "unknown", "upper", "lower", "replace_unknown", "replace_null":
+	case "set":
+		if len(r.Value) == 0 {
+			return fmt.Errorf("action set needs a value")
+		}
+	default:
+		return fmt.Errorf("unknown action %q", r.Action)
+	}
+	return nil
+}
+
+// apply returns v changed by the rule, or v when the rule does not match
+func (r *transformRule) apply(path cty.Path, v cty.Value) (cty.Value, error) {
+	if r.Path != "" && !matchPathPattern(r.pattern, path) {
+		return v, nil
+	}
+	if r.ty != cty.NilType && !v.Type().Equals(r.ty) {
+		return v, nil
+	}
+
+	var out cty.Value
+	switch r.Action {
+	case "null":
+		out = cty.NullVal(v.Type())
+	case "unknown":
+		out = cty.UnknownVal(v.Type())
+	case "upper", "lower":
+		if v.Type() != cty.String || !v.IsKnown() || v.IsNull() {
+			return v, nil
+		}
+		if r.Action == "upper" {
+			out = cty.StringVal(strings.ToUpper(v.AsString()))
+		} else {
+			out = cty.StringVal(strings.ToLower(v.AsString()))
+		}
+	case "replace_unknown", "replace_null":
+		if (r.Action == "replace_unknown" && v.IsKnown()) || (r.Action == "replace_null" && (!v.IsKnown() || !v.IsNull())) {
+			return v, nil
+		}
+		if len(r.Value) == 0 {
+			out = zeroValue(v.Type())
+			break
+		}
+		fallthrough
+	case "set":
+		built, err := ctyspec.BuildValue(v.Type(), r.Value, ctyOptions())
+		if err != nil {
+			return cty.NilVal, fmt.Errorf("value of %s rule does not fit %s: %w", r.Action, v.Type().FriendlyName(), err)
+		}
+		out = built
+	}
+	r.applied++
+	return out, nil
+}
+
+// zeroValue returns the empty value of ty: "", 0, false, empty collections
+// and objects and tuples of zero values
+func zeroValue(ty cty.Type) cty.Value {
+	switch {
+	case ty == cty.String:
+		return cty.StringVal("")
+	case ty == cty.Number:
+		return cty.Zero
+	case ty == cty.Bool:
+		return cty.False
+	case ty.IsListType():
+		return cty.ListValEmpty(ty.ElementType())
+	case ty.IsSetType():
+		return cty.SetValEmpty(ty.ElementType())
+	case ty.IsMapType():
+		return cty.MapValEmpty(ty.ElementType())
+	case ty.IsObjectType():
+		attrs := map[string]cty.Value{}
+		for name, attrType := range ty.AttributeTypes() {
+			attrs[name] = zeroValue(attrType)
+		}
+		if len(attrs) == 0 {
+			return cty.EmptyObjectVal
+		}
+		return cty.ObjectVal(attrs)
+	case ty.IsTupleType():
+		var elems []cty.Value
+		for _, elemType := range ty.TupleElementTypes() {
+			elems = append(elems, zeroValue(elemType))
+		}
+		if len(elems) == 0 {
+			return cty.EmptyTupleVal
+		}
+		return cty.TupleVal(elems)
+	case ty.IsCapsuleType():
+		return ctyspec.CapsuleVal(ty, nil)
+	}
+	return cty.NullVal(ty)
+}
+
+// initCtyTransformCmd creates the `cty transform` command
+func initCtyTransformCmd() *cobra.Command {
+	var typeJSON string
+	var rulesPath string
+	var inputFormat string
+	var outputFormat string
+
+	cmd := &cobra.Command{
+		Use:   "transform <input> [output]",
+		Short: "Apply rules from a file to a CTY value",
+		Long: `Decode the value in <input> as --type, walk it and apply the rules in the
+--rules file to each value, innermost first, then write the result, to build
+mutated values for differential tests. The rules file is JSON:
+
+  {"rules": [
+    {"path": "secret", "action": "null"},
+    {"type": "string", "action": "upper"},
+    {"path": "items[*].tags.*", "action": "set", "value": "x"},
+    {"action": "replace_unknown"}
+  ]}
+
+path matches value paths: attribute names separated by dots, [N] and ["key"]
+indexes, * for any attribute or map key and [*] for any index, including set
+elements; without a path a rule matches every value. type limits a rule to
+values of that type. Actions are null, unknown, set (to value), upper and
+lower (strings only), and replace_unknown and replace_null, which use value or
+the type's zero value. Rules apply in order, each to the result of the last.
+
+JSON output is the form cty convert reads, with unknowns as {"__unknown":true}.`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			outputPath := "-"
+			if len(args) > 1 {
+				outputPath = args[1]
+			}
+			ty, err := parseCtyType(json.RawMessage(typeJSON))
+			if err != nil {
+				return fmt.Errorf("failed to parse type: %w", err)
+			}
+
+			rulesData, err := readFileLimited(rulesPath)
+			if err != nil {
+				return fmt.Errorf("failed to read rules: %w", err)
+			}
+			var rules transformRules
+			if err := json.Unmarshal(rulesData, &rules); err != nil {
+				return fmt.Errorf("failed to parse rules: %w", err)
+			}
+			for i := range rules.Rules {
+				if err := rules.Rules[i].compile(); err != nil {
+					return fmt.Errorf("invalid rule %d: %w", i, err)
+				}
+			}
+
+			data, err := readFileLimited(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to read input: %w", err)
+			}
+			value, err := ctyspec.Decode(ty, data, inputFormat, ctyOptions())
+			if err != nil {
+				return err
+			}
+
+			value, err = cty.Transform(value, func(path cty.Path, v cty.Value) (cty.Value, error) {
+				for i := range rules.Rules {
+					var err error
+					if v, err = rules.Rules[i].apply(path, v); err != nil {
+						return cty.NilVal, fmt.Errorf("rule %d at %s: %w", i, formatCtyPath(path), err)
+					}
+				}
+				return v, nil
+			})
+			if err != nil {
+				return fmt.Errorf("failed to transform value: %w", err)
+			}
+			for i, rule := range rules.Rules {
+				logger.Debug("🔀 transform rule applied", "rule", i, "action", rule.Action, "path", rule.Path, "count", rule.applied)
+			}
+
+			var output []byte
+			if outputFormat == "json" {
+				output, err = json.Marshal(ctyspec.ValueJSON(value))
+				output = append(output, '\n')
+			} else {
+				output, err = ctyspec.Encode(value, ty, outputFormat, ctyOptions())
+			}
+			if err != nil {
+				return fmt.Errorf("failed to encode output: %w", err)
+			}
+			if err := writeStreamOutput(outputPath, output); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&typeJSON, "type", "", "CTY type specification as JSON or an HCL type expression")
+	cmd.Flags().StringVar(&rulesPath, "rules", "", "JSON rules file")
+	cmd.Flags().StringVar(&inputFormat, "input-format", "json", "Input format (json, msgpack)")
+	cmd.Flags().StringVar(&outputFormat, "output-format", "json", "Output format (json, msgpack, hcl)")
+	cmd.MarkFlagRequired("rules")
+	return addTypeFileFlags(cmd, true, "type")
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/ctyspec"
+)
+
+// initCtyTypeCmd creates the `cty type` command and its format and parse
+// subcommands
+func initCtyTypeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "type",
+		Short: "Translate types between JSON specifications and type expressions",
+		Long: `Translate between cty JSON type specifications, as harnesses exchange them,
+and HCL type expressions, as Terraform variables declare them, to debug type
+specs that do not match between harnesses. Both subcommands accept either form.`,
+	}
+
+	formatCmd := &cobra.Command{
+		Use:   "format <type>",
+		Short: "Print a type as its canonical type expression",
+		Long: `Print the type as a type expression in the canonical form of typeexpr's
+TypeString: attributes sorted, no spaces, dynamic as any and optional
+attributes as optional(type).`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ty, err := parseCtyType(json.RawMessage(args[0]))
+			if err != nil {
+				return fmt.Errorf("failed to parse type: %w", err)
+			}
+			expr, err := ctyspec.FormatType(ty)
+			if err != nil {
+				return err
+			}
+			fmt.Println(expr)
+			return nil
+		},
+	}
+
+	parseCmd := &cobra.Command{
+		Use:   "parse <type>",
+		Short: "Print a type as its JSON type specification",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ty, err := parseCtyType(json.RawMessage(args[0]))
+			if err != nil {
+				return fmt.Errorf("failed to parse type: %w", err)
+			}
+			spec, err := ctyspec.MarshalType(ty)
+			if err != nil {
+				return fmt.Errorf("failed to marshal type: %w", err)
+			}
+			fmt.Println(string(spec))
+			return nil
+		},
+	}
+
+	cmd.AddCommand(formatCmd, parseCmd)
+	return cmd
+}
+
+// initCtyValidateTypeCmd creates the `cty validate-type` command
+func initCtyValidateTypeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate-type <type>",
+		Short: "Validate a type specification without a value",
+		Long: `Validate a JSON type specification or HCL type expression and print the
+normalized JSON spec and friendly name. JSON specs are checked strictly:
+arrays must have exactly the elements their kind takes and optional attributes
+must be attributes of their object, where value commands ignore extra
+elements. friendly is the type as a canonical type expression. Invalid specs report the offending part and exit non-zero.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data := json.RawMessage(args[0])
+			// A JSON string may hold a type expression, which typeexpr
+			// validates as it parses
+			var err error
+			var src string
+			if json.Valid(data) && json.Unmarshal(data, &src) != nil {
+				err = ctyspec.ValidateTypeSpec(data)
+			}
+			report := map[string]interface{}{"valid": false}
+			if err == nil {
+				var ty cty.Type
+				if ty, err = parseCtyType(data); err == nil {
+					var spec json.RawMessage
+					if spec, err = ctyspec.MarshalType(ty); err == nil {
+						report["valid"] = true
+						report["type"] = spec
+						report["friendly"] = ty.FriendlyName()
+						if expr, exprErr := ctyspec.FormatType(ty); exprErr == nil {
+							report["friendly"] = expr
+						}
+					}
+				}
+			}
+			if err != nil {
+				report["error"] = err.Error()
+			}
+			if encErr := json.NewEncoder(os.Stdout).Encode(report); encErr != nil {
+				return fmt.Errorf("failed to encode JSON: %w", encErr)
+			}
+			if err != nil {
+				return fmt.Errorf("invalid type: %w", err)
+			}
+			return nil
+		},
+	}
+}
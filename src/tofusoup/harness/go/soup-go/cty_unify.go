@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// unifyInput reports how one input type relates to the unified type
+type unifyInput struct {
+	Type json.RawMessage `json:"type"`
+	// Convertible is whether values of the type convert to the unified
+	// type; ConversionNeeded is false when the types are already equal
+	Convertible      bool `json:"convertible"`
+	ConversionNeeded bool `json:"conversion_needed"`
+	// Safe is whether the conversion cannot fail for any value
+	Safe bool `json:"safe"`
+}
+
+// unifyTypes unifies types as convert.Unify, or convert.UnifyUnsafe when
+// unsafe is set, returning the unified type, cty.NilType when there is
+// none, and a report for each input
+func unifyTypes(types []cty.Type, unsafe bool) (cty.Type, []unifyInput, error) {
+	unify := convert.Unify
+	if unsafe {
+		unify = convert.UnifyUnsafe
+	}
+	unified, conversions := unify(types)
+
+	inputs := make([]unifyInput, len(types))
+	for i, ty := range types {
+		typeJSON, err := ctyjson.MarshalType(ty)
+		if err != nil {
+			return cty.NilType, nil, fmt.Errorf("failed to marshal input type %d: %w", i, err)
+		}
+		inputs[i].Type = typeJSON
+		if unified == cty.NilType {
+			continue
+		}
+		inputs[i].ConversionNeeded = !ty.Equals(unified)
+		inputs[i].Convertible = !inputs[i].ConversionNeeded || conversions[i] != nil
+		inputs[i].Safe = !inputs[i].ConversionNeeded || convert.GetConversion(ty, unified) != nil
+	}
+	return unified, inputs, nil
+}
+
+// initCtyUnifyCmd creates the `cty unify` command
+func initCtyUnifyCmd() *cobra.Command {
+	var unsafe bool
+
+	cmd := &cobra.Command{
+		Use:   "unify <type-json> <type-json>...",
+		Short: "Report the type go-cty unifies two or more types to",
+		Long: `Unify the given JSON type specifications with go-cty's convert.Unify, as
+Terraform does for the results of conditionals and for collection elements,
+and report the unified type and, for each input, whether its values convert
+to it and whether that conversion is safe. unified_type is null when the
+types have no unified type. --unsafe uses convert.UnifyUnsafe, which also
+allows conversions that can fail for some values, such as string to number.`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			types := make([]cty.Type, len(args))
+			for i, arg := range args {
+				ty, err := parseCtyType(json.RawMessage(arg))
+				if err != nil {
+					return fmt.Errorf("invalid type %d: %w", i, err)
+				}
+				types[i] = ty
+			}
+
+			unified, inputs, err := unifyTypes(types, unsafe)
+			if err != nil {
+				return err
+			}
+			var unifiedJSON json.RawMessage = json.RawMessage("null")
+			if unified != cty.NilType {
+				unifiedJSON, err = ctyjson.MarshalType(unified)
+				if err != nil {
+					return fmt.Errorf("failed to marshal unified type: %w", err)
+				}
+			}
+
+			return json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+				"unified_type": unifiedJSON,
+				"unified":      unified != cty.NilType,
+				"unsafe":       unsafe,
+				"inputs":       inputs,
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&unsafe, "unsafe", false, "Unify with convert.UnifyUnsafe, allowing conversions that may fail")
+	return cmd
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/ctyspec"
+)
+
+// viewTypeLabel names ty for the value tree: the type expression of
+// primitives and collections, and just the kind of structural types,
+// whose attributes and elements appear below them
+func viewTypeLabel(ty cty.Type) string {
+	switch {
+	case ty.IsObjectType():
+		return "object"
+	case ty.IsTupleType():
+		return "tuple"
+	case ty.IsCapsuleType():
+		return ty.FriendlyName()
+	}
+	if expr, err := ctyspec.FormatType(ty); err == nil {
+		return expr
+	}
+	return ty.FriendlyName()
+}
+
+// viewUnknown describes an unknown value and its refinements
+func viewUnknown(v cty.Value) string {
+	parts := []string{"unknown"}
+	rng := v.Range()
+	if rng.DefinitelyNotNull() {
+		parts = append(parts, "not null")
+	}
+	switch ty := v.Type(); {
+	case ty == cty.String:
+		if prefix := rng.StringPrefix(); prefix != "" {
+			parts = append(parts, "prefix "+strconv.Quote(prefix))
+		}
+	case ty == cty.Number:
+		if lower, inclusive := rng.NumberLowerBound(); lower.IsKnown() && !lower.RawEquals(cty.NegativeInfinity) {
+			op := ">"
+			if inclusive {
+				op = ">="
+			}
+			parts = append(parts, op+" "+lower.AsBigFloat().Text('f', -1))
+		}
+		if upper, inclusive := rng.NumberUpperBound(); upper.IsKnown() && !upper.RawEquals(cty.PositiveInfinity) {
+			op := "<"
+			if inclusive {
+				op = "<="
+			}
+			parts = append(parts, op+" "+upper.AsBigFloat().Text('f', -1))
+		}
+	case ty.IsCollectionType():
+		if lower := rng.LengthLowerBound(); lower > 0 {
+			parts = append(parts, fmt.Sprintf("length >= %d", lower))
+		}
+		if upper := rng.LengthUpperBound(); upper != math.MaxInt {
+			parts = append(parts, fmt.Sprintf("length <= %d", upper))
+		}
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+// viewMarks formats the marks of a value in sorted order
+func viewMarks(marks cty.ValueMarks) string {
+	names := make([]string, 0, len(marks))
+	for mark := range marks {
+		names = append(names, fmt.Sprint(mark))
+	}
+	sort.Strings(names)
+	return " marks=[" + strings.Join(names, ",") + "]"
+}
+
+// writeCtyTree writes v as one line per value, indented by depth, giving
+// each value's label, type and status or primitive value
+func writeCtyTree(w io.Writer, label string, v cty.Value, depth int) {
+	v, marks := v.Unmark()
+	ty := v.Type()
+	line := strings.Repeat("  ", depth) + label + ": " + viewTypeLabel(ty)
+	if len(marks) > 0 {
+		line += viewMarks(marks)
+	}
+
+	switch {
+	case !v.IsKnown():
+		fmt.Fprintln(w, line+" = "+viewUnknown(v))
+		return
+	case v.IsNull():
+		fmt.Fprintln(w, line+" = null")
+		return
+	case ty == cty.String:
+		fmt.Fprintln(w, line+" = "+strconv.Quote(v.AsString()))
+		return
+	case ty == cty.Number:
+		fmt.Fprintln(w, line+" = "+v.AsBigFloat().Text('f', -1))
+		return
+	case ty == cty.Bool:
+		fmt.Fprintln(w, line+" = "+strconv.FormatBool(v.True()))
+		return
+	case ty.IsCapsuleType():
+		if c, ok := v.EncapsulatedValue().(*ctyspec.Capsule); ok {
+			line += fmt.Sprintf(" = <%d bytes>", len(c.Payload))
+		}
+		fmt.Fprintln(w, line)
+		return
+	}
+
+	n := v.LengthInt()
+	switch {
+	case ty.IsObjectType():
+		fmt.Fprintln(w, line)
+	case n == 1:
+		fmt.Fprintln(w, line+", 1 element")
+	default:
+		fmt.Fprintf(w, "%s, %d elements\n", line, n)
+	}
+	for it := v.ElementIterator(); it.Next(); {
+		key, elem := it.Element()
+		var childLabel string
+		switch {
+		case ty.IsObjectType():
+			childLabel = key.AsString()
+		case ty.IsSetType():
+			childLabel = "-"
+		default:
+			childLabel = formatCtyPath(cty.Path{cty.IndexStep{Key: key}})
+		}
+		writeCtyTree(w, childLabel, elem, depth+1)
+	}
+}
+
+// initCtyViewCmd creates the `cty view` command
+func initCtyViewCmd() *cobra.Command {
+	var typeJSON string
+	var inputFormat string
+
+	cmd := &cobra.Command{
+		Use:   "view <input>",
+		Short: "Print a CTY value as an indented tree",
+		Long: `Decode the value in <input> ("-" for stdin, which may hold base64 msgpack as
+written by wire encode) as --type and print it as an indented tree, one line
+per value with its type, and its primitive value or whether it is null or
+unknown. Unknowns show their refinements, and marked values their marks.
+Object attributes appear by name, list, tuple and map elements by index or key,
+and set elements, which have neither, as "-".`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ty, err := parseCtyType(json.RawMessage(typeJSON))
+			if err != nil {
+				return fmt.Errorf("failed to parse type: %w", err)
+			}
+
+			in, err := openInput(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to read input: %w", err)
+			}
+			defer in.Close()
+			var r io.Reader = in
+			if inputFormat == "msgpack" && args[0] == "-" {
+				if r, err = maybeBase64Reader(in); err != nil {
+					return fmt.Errorf("failed to read input: %w", err)
+				}
+			}
+			data, err := decodeLimits.ReadAll(r)
+			if err != nil {
+				return fmt.Errorf("failed to read input: %w", err)
+			}
+			value, err := ctyspec.Decode(ty, data, inputFormat, ctyOptions())
+			if err != nil {
+				return err
+			}
+
+			w := bufio.NewWriter(os.Stdout)
+			writeCtyTree(w, "value", value, 0)
+			if err := w.Flush(); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&typeJSON, "type", "", "CTY type specification as JSON or an HCL type expression")
+	cmd.Flags().StringVar(&inputFormat, "input-format", "msgpack", "Input format (msgpack, json)")
+	return addTypeFileFlags(cmd, true, "type")
+}
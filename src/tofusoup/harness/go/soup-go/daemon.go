@@ -0,0 +1,427 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// EnvDaemonSocket names the daemon socket CLI commands route through
+const EnvDaemonSocket = "SOUP_GO_DAEMON_SOCKET"
+
+// daemonSocket is the --daemon-socket root flag. When set, cty, hcl and wire
+// commands run in the daemon listening there instead of in this process.
+var daemonSocket string
+
+// inDaemon is set while the daemon runs commands, so they are not routed
+// back to it
+var inDaemon bool
+
+// daemonRoutable lists the command groups the daemon runs
+var daemonRoutable = map[string]bool{"cty": true, "hcl": true, "wire": true}
+
+// daemonRequest is one request to the daemon: an op of "run" (the default)
+// with command arguments, or "stats" or "shutdown"
+type daemonRequest struct {
+	Op    string   `json:"op,omitempty"`
+	Args  []string `json:"args,omitempty"`
+	Stdin []byte   `json:"stdin,omitempty"`
+	Cwd   string   `json:"cwd,omitempty"`
+}
+
+type daemonResponse struct {
+	Stdout   []byte                 `json:"stdout,omitempty"`
+	Stderr   []byte                 `json:"stderr,omitempty"`
+	ExitCode int                    `json:"exit_code"`
+	Stats    map[string]interface{} `json:"stats,omitempty"`
+}
+
+// defaultDaemonSocket returns SOUP_GO_DAEMON_SOCKET, or a per-user socket
+// in the temp directory
+func defaultDaemonSocket() string {
+	if socket := os.Getenv(EnvDaemonSocket); socket != "" {
+		return socket
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("soup-go-daemon-%d.sock", os.Getuid()))
+}
+
+// soupDaemon serves requests on a unix socket. Commands run one at a time
+// in this process, since they share the command tree, flags and stdio.
+type soupDaemon struct {
+	listener net.Listener
+	mu       sync.Mutex
+	started  time.Time
+	requests int64
+	done     chan struct{}
+	once     sync.Once
+}
+
+// listenDaemon listens on socket, replacing a stale socket file left by a
+// daemon that is no longer running
+func listenDaemon(socket string) (net.Listener, error) {
+	if conn, err := net.DialTimeout("unix", socket, time.Second); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("a daemon is already listening on %s", socket)
+	}
+	if err := os.Remove(socket); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+	}
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", socket, err)
+	}
+	if err := os.Chmod(socket, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to restrict socket permissions: %w", err)
+	}
+	return listener, nil
+}
+
+func (d *soupDaemon) serve() error {
+	for {
+		conn, err := d.listener.Accept()
+		if err != nil {
+			select {
+			case <-d.done:
+				return nil
+			default:
+			}
+			return fmt.Errorf("failed to accept connection: %w", err)
+		}
+		go d.handle(conn)
+	}
+}
+
+func (d *soupDaemon) stop() {
+	d.once.Do(func() {
+		close(d.done)
+		d.listener.Close()
+	})
+}
+
+// handle answers requests on conn, one JSON line each, until it closes
+func (d *soupDaemon) handle(conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 64*1024), 256<<20)
+	encoder := json.NewEncoder(conn)
+
+	for scanner.Scan() {
+		var req daemonRequest
+		var resp daemonResponse
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			resp = daemonResponse{Stderr: []byte(fmt.Sprintf("invalid request: %v\n", err)), ExitCode: 2}
+		} else {
+			resp = d.dispatch(req)
+		}
+		if err := encoder.Encode(resp); err != nil {
+			logger.Debug("🧞❌ failed to write daemon response", "error", err)
+			return
+		}
+		if req.Op == "shutdown" {
+			d.stop()
+			return
+		}
+	}
+}
+
+func (d *soupDaemon) dispatch(req daemonRequest) daemonResponse {
+	switch req.Op {
+	case "", "run":
+		return d.run(req)
+	case "stats":
+		d.mu.Lock()
+		stats := sharedParseCache.Stats()
+		stats["requests"] = d.requests
+		stats["uptime_seconds"] = time.Since(d.started).Seconds()
+		d.mu.Unlock()
+		return daemonResponse{Stats: stats}
+	case "shutdown":
+		logger.Info("🧞🛑 daemon shutting down on request")
+		return daemonResponse{}
+	}
+	return daemonResponse{Stderr: []byte(fmt.Sprintf("unknown op %q\n", req.Op)), ExitCode: 2}
+}
+
+// run executes a command in this process with the client's arguments,
+// stdin and working directory, capturing its output
+func (d *soupDaemon) run(req daemonRequest) (resp daemonResponse) {
+	if len(req.Args) == 0 || !daemonRoutable[req.Args[0]] {
+		return daemonResponse{Stderr: []byte("the daemon only runs cty, hcl and wire commands\n"), ExitCode: 2}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.requests++
+	start := time.Now()
+
+	if req.Cwd != "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return daemonResponse{Stderr: []byte(err.Error() + "\n"), ExitCode: 1}
+		}
+		if err := os.Chdir(req.Cwd); err != nil {
+			return daemonResponse{Stderr: []byte(err.Error() + "\n"), ExitCode: 1}
+		}
+		defer os.Chdir(cwd)
+	}
+
+	stdio, err := newCapturedStdio(req.Stdin)
+	if err != nil {
+		return daemonResponse{Stderr: []byte(err.Error() + "\n"), ExitCode: 1}
+	}
+	rootCmd.SetArgs(req.Args)
+
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Fprintf(os.Stderr, "panic: %v\n", r)
+			resp.ExitCode = 1
+		}
+		rootCmd.SetArgs(nil)
+		resetFlags(rootCmd)
+		resp.Stdout, resp.Stderr = stdio.restore()
+		logger.Debug("🧞✅ daemon ran command", "args", req.Args, "exit_code", resp.ExitCode, "elapsed", time.Since(start))
+	}()
+
+	// Report errors as main does
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		resp.ExitCode = 1
+	}
+	return resp
+}
+
+// capturedStdio temporarily replaces the process's stdin, stdout and
+// stderr with pipes. The logger keeps writing to the real stderr.
+type capturedStdio struct {
+	origIn, origOut, origErr *os.File
+	inR, outW, errW          *os.File
+	stdout, stderr           bytes.Buffer
+	copied                   sync.WaitGroup
+}
+
+func newCapturedStdio(stdin []byte) (*capturedStdio, error) {
+	c := &capturedStdio{origIn: os.Stdin, origOut: os.Stdout, origErr: os.Stderr}
+	inR, inW, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	outR, outW, err := os.Pipe()
+	if err != nil {
+		inR.Close()
+		inW.Close()
+		return nil, err
+	}
+	errR, errW, err := os.Pipe()
+	if err != nil {
+		inR.Close()
+		inW.Close()
+		outR.Close()
+		outW.Close()
+		return nil, err
+	}
+
+	go func() {
+		inW.Write(stdin)
+		inW.Close()
+	}()
+	c.copied.Add(2)
+	go func() {
+		defer c.copied.Done()
+		io.Copy(&c.stdout, outR)
+		outR.Close()
+	}()
+	go func() {
+		defer c.copied.Done()
+		io.Copy(&c.stderr, errR)
+		errR.Close()
+	}()
+
+	c.inR, c.outW, c.errW = inR, outW, errW
+	os.Stdin, os.Stdout, os.Stderr = inR, outW, errW
+	return c, nil
+}
+
+// restore puts the original stdio back and returns what was captured
+func (c *capturedStdio) restore() ([]byte, []byte) {
+	os.Stdin, os.Stdout, os.Stderr = c.origIn, c.origOut, c.origErr
+	c.outW.Close()
+	c.errW.Close()
+	c.copied.Wait()
+	c.inR.Close()
+	return c.stdout.Bytes(), c.stderr.Bytes()
+}
+
+// resetFlags returns every flag of cmd and its subcommands to its default,
+// so one daemon request's flags do not carry over to the next
+func resetFlags(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if !f.Changed {
+			return
+		}
+		if slice, ok := f.Value.(pflag.SliceValue); ok {
+			slice.Replace(nil)
+		} else {
+			f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+	for _, sub := range cmd.Commands() {
+		resetFlags(sub)
+	}
+}
+
+// routeToDaemon runs the current invocation in the daemon at --daemon-socket
+// if cmd is one it runs, and exits with its exit code. When no daemon is
+// listening it returns, and the command runs in this process.
+func routeToDaemon(cmd *cobra.Command) {
+	if daemonSocket == "" || inDaemon {
+		return
+	}
+	group := cmd
+	for group.HasParent() && group.Parent().HasParent() {
+		group = group.Parent()
+	}
+	if !daemonRoutable[group.Name()] {
+		return
+	}
+
+	conn, err := net.Dial("unix", daemonSocket)
+	if err != nil {
+		logger.Debug("🧞⚠️ daemon unavailable, running locally", "socket", daemonSocket, "error", err)
+		return
+	}
+	defer conn.Close()
+
+	req := daemonRequest{Args: os.Args[1:]}
+	if cwd, err := os.Getwd(); err == nil {
+		req.Cwd = cwd
+	}
+	// Forward piped or redirected stdin, but never wait on a terminal
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice == 0 {
+		if req.Stdin, err = io.ReadAll(os.Stdin); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to read stdin: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	resp, err := daemonCall(conn, req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "daemon request failed: %v\n", err)
+		os.Exit(1)
+	}
+	os.Stdout.Write(resp.Stdout)
+	os.Stderr.Write(resp.Stderr)
+	os.Exit(resp.ExitCode)
+}
+
+// daemonCall sends one request on conn and reads its response
+func daemonCall(conn net.Conn, req daemonRequest) (*daemonResponse, error) {
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	var resp daemonResponse
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return &resp, nil
+}
+
+// initDaemonCmd creates the `daemon` command and its subcommands
+func initDaemonCmd() *cobra.Command {
+	var socket string
+
+	cmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Serve cty, hcl and wire commands from a long-running process",
+		Long: `Run a daemon that executes cty, hcl and wire commands on behalf of other
+soup-go invocations over a unix socket, keeping parsed HCL files and cty type
+specifications cached between them. Commands route through the daemon when
+--daemon-socket or SOUP_GO_DAEMON_SOCKET names its socket, and run locally
+when no daemon is listening there. This removes process startup and re-parse
+overhead from tight test loops.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			listener, err := listenDaemon(socket)
+			if err != nil {
+				return err
+			}
+			inDaemon = true
+			d := &soupDaemon{listener: listener, started: time.Now(), done: make(chan struct{})}
+
+			signals := make(chan os.Signal, 1)
+			signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+			go func() {
+				select {
+				case <-signals:
+					logger.Info("🧞🛑 daemon shutting down on signal")
+					d.stop()
+				case <-d.done:
+				}
+			}()
+
+			logger.Info("🧞🎧 daemon listening", "socket", socket)
+			err = d.serve()
+			os.Remove(socket)
+			return err
+		},
+	}
+	cmd.Flags().StringVar(&socket, "socket", defaultDaemonSocket(), "Unix socket to listen on (env SOUP_GO_DAEMON_SOCKET)")
+
+	statsCmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show a running daemon's request count and parse cache statistics",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resp, err := dialDaemon(socket, daemonRequest{Op: "stats"})
+			if err != nil {
+				return err
+			}
+			if err := json.NewEncoder(os.Stdout).Encode(resp.Stats); err != nil {
+				return fmt.Errorf("failed to encode JSON: %w", err)
+			}
+			return nil
+		},
+	}
+	stopCmd := &cobra.Command{
+		Use:   "stop",
+		Short: "Stop a running daemon",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := dialDaemon(socket, daemonRequest{Op: "shutdown"}); err != nil {
+				return err
+			}
+			fmt.Println("Daemon stopped.")
+			return nil
+		},
+	}
+	for _, sub := range []*cobra.Command{statsCmd, stopCmd} {
+		sub.Flags().StringVar(&socket, "socket", defaultDaemonSocket(), "Unix socket of the daemon (env SOUP_GO_DAEMON_SOCKET)")
+		cmd.AddCommand(sub)
+	}
+	return cmd
+}
+
+// dialDaemon sends a single request to the daemon at socket
+func dialDaemon(socket string, req daemonRequest) (*daemonResponse, error) {
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return nil, fmt.Errorf("no daemon listening on %s: %w", socket, err)
+	}
+	defer conn.Close()
+	return daemonCall(conn, req)
+}
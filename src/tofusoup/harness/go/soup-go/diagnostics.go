@@ -0,0 +1,443 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/spf13/cobra"
+	"github.com/zclconf/go-cty/cty"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/hcltools"
+)
+
+// Field numbers of the tfplugin6 Diagnostic message:
+//
+//	message Diagnostic {
+//	    Severity severity = 1;
+//	    string summary = 2;
+//	    string detail = 3;
+//	    AttributePath attribute = 4;
+//	}
+const (
+	diagnosticSeverityField  protowire.Number = 1
+	diagnosticSummaryField   protowire.Number = 2
+	diagnosticDetailField    protowire.Number = 3
+	diagnosticAttributeField protowire.Number = 4
+)
+
+// diagnosticAttributeExtra is attached to hcl.Diagnostic.Extra to carry the
+// attribute path of a provider diagnostic, which HCL has no field for
+type diagnosticAttributeExtra struct {
+	Path cty.Path
+}
+
+// hclSeverityToProto maps an HCL diagnostic severity to its tfprotov6 equivalent
+func hclSeverityToProto(severity hcl.DiagnosticSeverity) (tfprotov6.DiagnosticSeverity, error) {
+	switch severity {
+	case hcl.DiagError:
+		return tfprotov6.DiagnosticSeverityError, nil
+	case hcl.DiagWarning:
+		return tfprotov6.DiagnosticSeverityWarning, nil
+	}
+	return tfprotov6.DiagnosticSeverityInvalid, fmt.Errorf("invalid HCL diagnostic severity %d", severity)
+}
+
+// protoSeverityToHCL maps a tfprotov6 diagnostic severity to its HCL equivalent
+func protoSeverityToHCL(severity tfprotov6.DiagnosticSeverity) (hcl.DiagnosticSeverity, error) {
+	switch severity {
+	case tfprotov6.DiagnosticSeverityError:
+		return hcl.DiagError, nil
+	case tfprotov6.DiagnosticSeverityWarning:
+		return hcl.DiagWarning, nil
+	}
+	return hcl.DiagInvalid, fmt.Errorf("invalid provider diagnostic severity %s", severity)
+}
+
+// hclDiagnosticToProto converts an HCL diagnostic to a tfprotov6 diagnostic.
+// Source ranges have no protocol representation and are dropped; an
+// attribute path is carried over when present in the diagnostic's Extra.
+func hclDiagnosticToProto(diag *hcl.Diagnostic) (*tfprotov6.Diagnostic, error) {
+	severity, err := hclSeverityToProto(diag.Severity)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &tfprotov6.Diagnostic{
+		Severity: severity,
+		Summary:  diag.Summary,
+		Detail:   diag.Detail,
+	}
+	if extra, ok := hcl.DiagnosticExtra[diagnosticAttributeExtra](diag); ok {
+		result.Attribute, err = ctyPathToAttributePath(extra.Path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid attribute path: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// protoDiagnosticToHCL converts a tfprotov6 diagnostic to an HCL diagnostic,
+// keeping the attribute path in Extra
+func protoDiagnosticToHCL(diag *tfprotov6.Diagnostic) (*hcl.Diagnostic, error) {
+	severity, err := protoSeverityToHCL(diag.Severity)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &hcl.Diagnostic{
+		Severity: severity,
+		Summary:  diag.Summary,
+		Detail:   diag.Detail,
+	}
+	if diag.Attribute != nil {
+		path, err := attributePathToCtyPath(diag.Attribute)
+		if err != nil {
+			return nil, fmt.Errorf("invalid attribute path: %w", err)
+		}
+		result.Extra = diagnosticAttributeExtra{Path: path}
+	}
+	return result, nil
+}
+
+// encodeDiagnosticProto encodes a diagnostic in the tfplugin6 protobuf wire format
+func encodeDiagnosticProto(diag *tfprotov6.Diagnostic) ([]byte, error) {
+	var out []byte
+	if diag.Severity != tfprotov6.DiagnosticSeverityInvalid {
+		out = protowire.AppendTag(out, diagnosticSeverityField, protowire.VarintType)
+		out = protowire.AppendVarint(out, uint64(diag.Severity))
+	}
+	if diag.Summary != "" {
+		out = protowire.AppendTag(out, diagnosticSummaryField, protowire.BytesType)
+		out = protowire.AppendString(out, diag.Summary)
+	}
+	if diag.Detail != "" {
+		out = protowire.AppendTag(out, diagnosticDetailField, protowire.BytesType)
+		out = protowire.AppendString(out, diag.Detail)
+	}
+	if diag.Attribute != nil {
+		attr, err := encodeAttributePathProto(diag.Attribute)
+		if err != nil {
+			return nil, fmt.Errorf("attribute: %w", err)
+		}
+		out = protowire.AppendTag(out, diagnosticAttributeField, protowire.BytesType)
+		out = protowire.AppendBytes(out, attr)
+	}
+	return out, nil
+}
+
+// decodeDiagnosticProto decodes a diagnostic from the tfplugin6 protobuf wire format
+func decodeDiagnosticProto(data []byte) (*tfprotov6.Diagnostic, error) {
+	diag := &tfprotov6.Diagnostic{}
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return nil, fmt.Errorf("invalid Diagnostic: %w", protowire.ParseError(n))
+		}
+		data = data[n:]
+
+		switch {
+		case num == diagnosticSeverityField && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(data)
+			if n < 0 {
+				return nil, fmt.Errorf("invalid Diagnostic severity: %w", protowire.ParseError(n))
+			}
+			diag.Severity = tfprotov6.DiagnosticSeverity(int32(v))
+			data = data[n:]
+		case num == diagnosticSummaryField && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(data)
+			if n < 0 {
+				return nil, fmt.Errorf("invalid Diagnostic summary: %w", protowire.ParseError(n))
+			}
+			diag.Summary = v
+			data = data[n:]
+		case num == diagnosticDetailField && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(data)
+			if n < 0 {
+				return nil, fmt.Errorf("invalid Diagnostic detail: %w", protowire.ParseError(n))
+			}
+			diag.Detail = v
+			data = data[n:]
+		case num == diagnosticAttributeField && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return nil, fmt.Errorf("invalid Diagnostic attribute: %w", protowire.ParseError(n))
+			}
+			ap, err := decodeAttributePathProto(v)
+			if err != nil {
+				return nil, fmt.Errorf("attribute: %w", err)
+			}
+			diag.Attribute = ap
+			data = data[n:]
+		default:
+			// Skip unknown fields, as proto3 decoders must
+			n := protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return nil, fmt.Errorf("invalid Diagnostic: %w", protowire.ParseError(n))
+			}
+			data = data[n:]
+		}
+	}
+	return diag, nil
+}
+
+// DiagnosticVector is a golden test vector for provider diagnostic conversion
+type DiagnosticVector struct {
+	Name            string              `json:"name,omitempty"`
+	Severity        string              `json:"severity"`
+	Summary         string              `json:"summary"`
+	Detail          string              `json:"detail,omitempty"`
+	Attribute       []AttributePathStep `json:"attribute,omitempty"`
+	AttributeString string              `json:"attribute_string,omitempty"`
+	ProtoHex        string              `json:"proto_hex,omitempty"`
+}
+
+// diagnosticFromVector builds a tfprotov6 diagnostic from its JSON representation
+func diagnosticFromVector(vector DiagnosticVector) (*tfprotov6.Diagnostic, error) {
+	diag := &tfprotov6.Diagnostic{
+		Summary: vector.Summary,
+		Detail:  vector.Detail,
+	}
+	switch vector.Severity {
+	case "error":
+		diag.Severity = tfprotov6.DiagnosticSeverityError
+	case "warning":
+		diag.Severity = tfprotov6.DiagnosticSeverityWarning
+	case "invalid":
+		diag.Severity = tfprotov6.DiagnosticSeverityInvalid
+	default:
+		return nil, fmt.Errorf("unsupported severity: %s", vector.Severity)
+	}
+	if len(vector.Attribute) > 0 {
+		ap, err := attributePathFromSteps(vector.Attribute)
+		if err != nil {
+			return nil, fmt.Errorf("invalid attribute: %w", err)
+		}
+		diag.Attribute = ap
+	}
+	return diag, nil
+}
+
+// diagnosticReport describes a tfprotov6 diagnostic in every supported encoding
+func diagnosticReport(diag *tfprotov6.Diagnostic) (map[string]interface{}, error) {
+	encoded, err := encodeDiagnosticProto(diag)
+	if err != nil {
+		return nil, err
+	}
+	report := protoDiagnosticsToJSON([]*tfprotov6.Diagnostic{diag})[0]
+	if diag.Attribute != nil {
+		steps, err := attributePathToSteps(diag.Attribute)
+		if err != nil {
+			return nil, err
+		}
+		report["attribute_steps"] = steps
+	}
+
+	// Render the diagnostic as HCL would, so harnesses can compare messages
+	if hclDiag, err := protoDiagnosticToHCL(diag); err == nil {
+		report["hcl"] = hcltools.DiagnosticsToJSON(hcl.Diagnostics{hclDiag})[0]
+	}
+	report["proto_hex"] = hex.EncodeToString(encoded)
+	report["proto_base64"] = base64.StdEncoding.EncodeToString(encoded)
+	return report, nil
+}
+
+// checkDiagnosticVector verifies encode, decode and HCL round trip of a
+// single vector, returning a list of mismatches
+func checkDiagnosticVector(vector DiagnosticVector) []string {
+	var failures []string
+
+	diag, err := diagnosticFromVector(vector)
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	encoded, err := encodeDiagnosticProto(diag)
+	if err != nil {
+		failures = append(failures, fmt.Sprintf("encode failed: %s", err))
+	} else if got := hex.EncodeToString(encoded); got != vector.ProtoHex {
+		failures = append(failures, fmt.Sprintf("encode mismatch: expected %s, got %s", vector.ProtoHex, got))
+	}
+
+	wire, err := hex.DecodeString(vector.ProtoHex)
+	if err != nil {
+		return append(failures, fmt.Sprintf("invalid proto_hex: %s", err))
+	}
+	decoded, err := decodeDiagnosticProto(wire)
+	if err != nil {
+		return append(failures, fmt.Sprintf("decode failed: %s", err))
+	}
+	if !protoDiagnosticsEqual(decoded, diag) {
+		failures = append(failures, fmt.Sprintf("decode mismatch: got %s %q %q %s", decoded.Severity, decoded.Summary, decoded.Detail, decoded.Attribute))
+	}
+
+	if decoded.Attribute != nil {
+		path, err := attributePathToCtyPath(decoded.Attribute)
+		if err != nil {
+			return append(failures, fmt.Sprintf("attribute conversion failed: %s", err))
+		}
+		if got := formatCtyPath(path); got != vector.AttributeString {
+			failures = append(failures, fmt.Sprintf("attribute string mismatch: expected %s, got %s", vector.AttributeString, got))
+		}
+	}
+
+	// Diagnostics with an invalid severity have no HCL equivalent, and the
+	// conversion must say so rather than guess
+	hclDiag, err := protoDiagnosticToHCL(decoded)
+	if decoded.Severity == tfprotov6.DiagnosticSeverityInvalid {
+		if err == nil {
+			failures = append(failures, "expected HCL conversion of invalid severity to fail")
+		}
+		return failures
+	}
+	if err != nil {
+		return append(failures, fmt.Sprintf("HCL conversion failed: %s", err))
+	}
+	roundTrip, err := hclDiagnosticToProto(hclDiag)
+	if err != nil {
+		failures = append(failures, fmt.Sprintf("HCL round trip failed: %s", err))
+	} else if !protoDiagnosticsEqual(roundTrip, diag) {
+		failures = append(failures, fmt.Sprintf("HCL round trip mismatch: got %s %q %q %s", roundTrip.Severity, roundTrip.Summary, roundTrip.Detail, roundTrip.Attribute))
+	}
+
+	return failures
+}
+
+// protoDiagnosticsEqual reports whether two diagnostics carry the same content
+func protoDiagnosticsEqual(a, b *tfprotov6.Diagnostic) bool {
+	if a.Severity != b.Severity || a.Summary != b.Summary || a.Detail != b.Detail {
+		return false
+	}
+	if (a.Attribute == nil) != (b.Attribute == nil) {
+		return false
+	}
+	return a.Attribute == nil || a.Attribute.Equal(b.Attribute)
+}
+
+// initHclDiagnosticsCmd creates the `hcl diagnostics` command group
+func initHclDiagnosticsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diagnostics",
+		Short: "Convert between HCL diagnostics and tfprotov6 Diagnostic messages",
+		Long: `Encode and decode the tfplugin6 Diagnostic protobuf message that providers
+return, and verify golden fixtures of HCL <-> provider diagnostic conversion.
+Diagnostics are given as JSON, e.g.
+{"severity":"error","summary":"Bad","attribute":[{"attribute_name":"name"}]}.`,
+	}
+
+	var decodeEncoding string
+
+	encodeCmd := &cobra.Command{
+		Use:   "encode [diagnostic-json]",
+		Short: "Encode a diagnostic to protobuf",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var vector DiagnosticVector
+			if err := json.Unmarshal([]byte(args[0]), &vector); err != nil {
+				return fmt.Errorf("failed to parse diagnostic: %w", err)
+			}
+			diag, err := diagnosticFromVector(vector)
+			if err != nil {
+				return err
+			}
+			report, err := diagnosticReport(diag)
+			if err != nil {
+				return err
+			}
+			return json.NewEncoder(os.Stdout).Encode(report)
+		},
+	}
+
+	decodeCmd := &cobra.Command{
+		Use:   "decode [encoded-diagnostic]",
+		Short: "Decode a protobuf Diagnostic",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var wire []byte
+			var err error
+			switch decodeEncoding {
+			case "base64":
+				wire, err = base64.StdEncoding.DecodeString(args[0])
+			case "hex":
+				wire, err = hex.DecodeString(args[0])
+			default:
+				return fmt.Errorf("unsupported input encoding: %s", decodeEncoding)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to decode %s input: %w", decodeEncoding, err)
+			}
+			diag, err := decodeDiagnosticProto(wire)
+			if err != nil {
+				return err
+			}
+			report, err := diagnosticReport(diag)
+			if err != nil {
+				return err
+			}
+			return json.NewEncoder(os.Stdout).Encode(report)
+		},
+	}
+	decodeCmd.Flags().StringVar(&decodeEncoding, "input-encoding", "base64", "Encoding of the input (base64, hex)")
+
+	checkCmd := &cobra.Command{
+		Use:   "check [fixtures-file]",
+		Short: "Verify diagnostic golden fixtures",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var data []byte
+			var err error
+			if args[0] == "-" {
+				data, err = io.ReadAll(os.Stdin)
+			} else {
+				data, err = os.ReadFile(args[0])
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read fixtures: %w", err)
+			}
+
+			var vectors []DiagnosticVector
+			if err := json.Unmarshal(data, &vectors); err != nil {
+				return fmt.Errorf("failed to parse fixtures: %w", err)
+			}
+
+			failed := 0
+			results := make([]map[string]interface{}, 0, len(vectors))
+			for _, vector := range vectors {
+				failures := checkDiagnosticVector(vector)
+				result := map[string]interface{}{
+					"name":   vector.Name,
+					"passed": len(failures) == 0,
+				}
+				if len(failures) > 0 {
+					result["failures"] = failures
+					failed++
+				}
+				results = append(results, result)
+			}
+
+			output := map[string]interface{}{
+				"total":   len(vectors),
+				"failed":  failed,
+				"results": results,
+			}
+			if err := json.NewEncoder(os.Stdout).Encode(output); err != nil {
+				return fmt.Errorf("failed to encode JSON: %w", err)
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d of %d fixtures failed", failed, len(vectors))
+			}
+			return nil
+		},
+	}
+
+	cmd.AddCommand(encodeCmd)
+	cmd.AddCommand(decodeCmd)
+	cmd.AddCommand(checkCmd)
+	return cmd
+}
@@ -0,0 +1,579 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// FlatmapUnknownValue is the sentinel legacy Terraform used in flatmap state
+// to represent a value that is not yet known
+const FlatmapUnknownValue = "74D93920-ED26-11E3-AC10-0800200C9A66"
+
+// flatmapToCty decodes a legacy flatmap attribute map into a value of the
+// given object type. This mirrors Terraform's hcl2shim.HCL2ValueFromFlatmap.
+func flatmapToCty(m map[string]string, ty cty.Type) (cty.Value, error) {
+	if m == nil {
+		return cty.NullVal(ty), nil
+	}
+	if !ty.IsObjectType() {
+		return cty.NilVal, fmt.Errorf("flatmap can only be decoded into an object type, got %s", ty.FriendlyName())
+	}
+	return flatmapObjectToCty(m, "", ty.AttributeTypes())
+}
+
+func flatmapObjectToCty(m map[string]string, prefix string, atys map[string]cty.Type) (cty.Value, error) {
+	if len(atys) == 0 {
+		return cty.EmptyObjectVal, nil
+	}
+
+	vals := make(map[string]cty.Value, len(atys))
+	for name, aty := range atys {
+		val, err := flatmapValueToCty(m, prefix+name, aty)
+		if err != nil {
+			return cty.NilVal, err
+		}
+		vals[name] = val
+	}
+	return cty.ObjectVal(vals), nil
+}
+
+func flatmapValueToCty(m map[string]string, key string, ty cty.Type) (cty.Value, error) {
+	switch {
+	case ty.IsPrimitiveType():
+		return flatmapPrimitiveToCty(m, key, ty)
+	case ty.IsObjectType():
+		return flatmapObjectToCty(m, key+".", ty.AttributeTypes())
+	case ty.IsTupleType():
+		return flatmapTupleToCty(m, key, ty.TupleElementTypes())
+	case ty.IsMapType():
+		return flatmapMapToCty(m, key, ty)
+	case ty.IsListType():
+		return flatmapListToCty(m, key, ty)
+	case ty.IsSetType():
+		return flatmapSetToCty(m, key, ty)
+	}
+	return cty.NilVal, fmt.Errorf("cannot decode %s from flatmap at %s", ty.FriendlyName(), key)
+}
+
+func flatmapPrimitiveToCty(m map[string]string, key string, ty cty.Type) (cty.Value, error) {
+	rawVal, exists := m[key]
+	if !exists {
+		return cty.NullVal(ty), nil
+	}
+	if rawVal == FlatmapUnknownValue {
+		return cty.UnknownVal(ty), nil
+	}
+
+	val, err := convert.Convert(cty.StringVal(rawVal), ty)
+	if err != nil {
+		return cty.NilVal, fmt.Errorf("invalid value for %s in flatmap: %w", key, err)
+	}
+	return val, nil
+}
+
+// flatmapCount reads the element count stored under countKey. The returned
+// value is null or unknown (and count is -1) when the count is absent or unknown.
+func flatmapCount(m map[string]string, countKey string, ty cty.Type) (int, cty.Value, error) {
+	countStr, exists := m[countKey]
+	if !exists {
+		return -1, cty.NullVal(ty), nil
+	}
+	if countStr == FlatmapUnknownValue {
+		return -1, cty.UnknownVal(ty), nil
+	}
+	count, err := strconv.Atoi(countStr)
+	if err != nil {
+		return -1, cty.NilVal, fmt.Errorf("invalid count value for %s in flatmap: %w", countKey, err)
+	}
+	return count, cty.NilVal, nil
+}
+
+func flatmapTupleToCty(m map[string]string, key string, etys []cty.Type) (cty.Value, error) {
+	count, val, err := flatmapCount(m, key+".#", cty.Tuple(etys))
+	if err != nil || count < 0 {
+		return val, err
+	}
+	if count != len(etys) {
+		return cty.NilVal, fmt.Errorf("wrong number of values for %s in flatmap: expected %d, got %d", key, len(etys), count)
+	}
+	if count == 0 {
+		return cty.EmptyTupleVal, nil
+	}
+
+	vals := make([]cty.Value, count)
+	for i, ety := range etys {
+		vals[i], err = flatmapValueToCty(m, fmt.Sprintf("%s.%d", key, i), ety)
+		if err != nil {
+			return cty.NilVal, err
+		}
+	}
+	return cty.TupleVal(vals), nil
+}
+
+func flatmapMapToCty(m map[string]string, key string, ty cty.Type) (cty.Value, error) {
+	count, val, err := flatmapCount(m, key+".%", ty)
+	if err != nil || count < 0 {
+		return val, err
+	}
+
+	// The flatmap format can't distinguish keys containing periods from
+	// nested values, so by convention a flatmap map is always of primitive
+	// type and the remainder of the raw key is taken as the map key.
+	prefix := key + "."
+	vals := make(map[string]cty.Value)
+	for fullKey := range m {
+		if !strings.HasPrefix(fullKey, prefix) {
+			continue
+		}
+		mapKey := fullKey[len(prefix):]
+		if mapKey == "%" {
+			continue
+		}
+		vals[mapKey], err = flatmapValueToCty(m, fullKey, ty.ElementType())
+		if err != nil {
+			return cty.NilVal, err
+		}
+	}
+
+	if len(vals) == 0 {
+		return cty.MapValEmpty(ty.ElementType()), nil
+	}
+	return cty.MapVal(vals), nil
+}
+
+func flatmapListToCty(m map[string]string, key string, ty cty.Type) (cty.Value, error) {
+	count, val, err := flatmapCount(m, key+".#", ty)
+	if err != nil || count < 0 {
+		return val, err
+	}
+	if count == 0 {
+		return cty.ListValEmpty(ty.ElementType()), nil
+	}
+
+	vals := make([]cty.Value, count)
+	for i := 0; i < count; i++ {
+		vals[i], err = flatmapValueToCty(m, fmt.Sprintf("%s.%d", key, i), ty.ElementType())
+		if err != nil {
+			return cty.NilVal, err
+		}
+	}
+	return cty.ListVal(vals), nil
+}
+
+func flatmapSetToCty(m map[string]string, key string, ty cty.Type) (cty.Value, error) {
+	count, val, err := flatmapCount(m, key+".#", ty)
+	if err != nil || count < 0 {
+		return val, err
+	}
+	if count == 0 {
+		return cty.SetValEmpty(ty.ElementType()), nil
+	}
+
+	// Set element keys are arbitrary (typically hashcodes), so collect the
+	// distinct first path segments after the prefix
+	prefix := key + "."
+	seen := make(map[string]struct{})
+	for fullKey := range m {
+		if !strings.HasPrefix(fullKey, prefix) {
+			continue
+		}
+		subKey := fullKey[len(prefix):]
+		if subKey == "#" {
+			continue
+		}
+		if dot := strings.IndexByte(subKey, '.'); dot != -1 {
+			subKey = subKey[:dot]
+		}
+		seen[subKey] = struct{}{}
+	}
+
+	indices := make([]string, 0, len(seen))
+	for idx := range seen {
+		indices = append(indices, idx)
+	}
+	sort.Strings(indices)
+
+	vals := make([]cty.Value, 0, len(indices))
+	for _, idx := range indices {
+		elemVal, err := flatmapValueToCty(m, prefix+idx, ty.ElementType())
+		if err != nil {
+			return cty.NilVal, err
+		}
+		vals = append(vals, elemVal)
+	}
+	if len(vals) == 0 {
+		return cty.SetValEmpty(ty.ElementType()), nil
+	}
+	return cty.SetVal(vals), nil
+}
+
+// ctyToFlatmap encodes a value of object type as a legacy flatmap attribute
+// map. This mirrors Terraform's hcl2shim.FlatmapValueFromHCL2: nulls are
+// omitted, unknowns become FlatmapUnknownValue, and set elements are given
+// sequential indexes rather than helper/schema hashcodes.
+func ctyToFlatmap(val cty.Value) (map[string]string, error) {
+	if val.IsNull() {
+		return nil, nil
+	}
+	if !val.Type().IsObjectType() {
+		return nil, fmt.Errorf("flatmap can only be encoded from an object type, got %s", val.Type().FriendlyName())
+	}
+	m := make(map[string]string)
+	if err := flatmapFromCtyMap(m, "", val); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func flatmapFromCtyValue(m map[string]string, key string, val cty.Value) error {
+	ty := val.Type()
+	switch {
+	case ty.IsPrimitiveType() || ty == cty.DynamicPseudoType:
+		return flatmapFromCtyPrimitive(m, key, val)
+	case ty.IsObjectType() || ty.IsMapType():
+		return flatmapFromCtyMap(m, key+".", val)
+	case ty.IsTupleType() || ty.IsListType() || ty.IsSetType():
+		return flatmapFromCtySeq(m, key+".", val)
+	}
+	return fmt.Errorf("cannot encode %s to flatmap at %s", ty.FriendlyName(), key)
+}
+
+func flatmapFromCtyPrimitive(m map[string]string, key string, val cty.Value) error {
+	if !val.IsKnown() {
+		m[key] = FlatmapUnknownValue
+		return nil
+	}
+	if val.IsNull() {
+		return nil
+	}
+
+	str, err := convert.Convert(val, cty.String)
+	if err != nil {
+		return fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+	m[key] = str.AsString()
+	return nil
+}
+
+func flatmapFromCtyMap(m map[string]string, prefix string, val cty.Value) error {
+	if val.IsNull() {
+		return nil
+	}
+	if !val.IsKnown() {
+		// Whole objects can't be unknown in flatmap, so write each of their
+		// attributes as unknown instead
+		if val.Type().IsObjectType() {
+			for name, aty := range val.Type().AttributeTypes() {
+				if err := flatmapFromCtyValue(m, prefix+name, cty.UnknownVal(aty)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		m[prefix+"%"] = FlatmapUnknownValue
+		return nil
+	}
+
+	count := 0
+	for it := val.ElementIterator(); it.Next(); {
+		k, v := it.Element()
+		if err := flatmapFromCtyValue(m, prefix+k.AsString(), v); err != nil {
+			return err
+		}
+		count++
+	}
+	// Objects have a fixed attribute count, so only maps record one
+	if !val.Type().IsObjectType() {
+		m[prefix+"%"] = strconv.Itoa(count)
+	}
+	return nil
+}
+
+func flatmapFromCtySeq(m map[string]string, prefix string, val cty.Value) error {
+	if val.IsNull() {
+		return nil
+	}
+	if !val.IsKnown() {
+		m[prefix+"#"] = FlatmapUnknownValue
+		return nil
+	}
+
+	i := 0
+	for it := val.ElementIterator(); it.Next(); {
+		_, v := it.Element()
+		if err := flatmapFromCtyValue(m, prefix+strconv.Itoa(i), v); err != nil {
+			return err
+		}
+		i++
+	}
+	m[prefix+"#"] = strconv.Itoa(i)
+	return nil
+}
+
+// markUnknownPaths replaces the values at the given paths (in formatCtyPath
+// form) with unknowns, since cty JSON has no way to express them
+func markUnknownPaths(val cty.Value, paths []string) (cty.Value, error) {
+	if len(paths) == 0 {
+		return val, nil
+	}
+	pending := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		pending[p] = true
+	}
+	val, err := cty.Transform(val, func(path cty.Path, v cty.Value) (cty.Value, error) {
+		key := formatCtyPath(path)
+		if pending[key] {
+			delete(pending, key)
+			return cty.UnknownVal(v.Type()), nil
+		}
+		return v, nil
+	})
+	if err != nil {
+		return cty.NilVal, err
+	}
+	if len(pending) > 0 {
+		return cty.NilVal, fmt.Errorf("unknown paths not found in value: %s", strings.Join(sortedKeys(pending), ", "))
+	}
+	return val, nil
+}
+
+// nullUnknowns replaces unknown values with nulls so a value can be written
+// as cty JSON alongside its unknownPaths
+func nullUnknowns(val cty.Value) cty.Value {
+	val, _ = cty.Transform(val, func(path cty.Path, v cty.Value) (cty.Value, error) {
+		if !v.IsKnown() {
+			return cty.NullVal(v.Type()), nil
+		}
+		return v, nil
+	})
+	return val
+}
+
+// FlatmapVector is a golden test vector for flatmap encoding
+type FlatmapVector struct {
+	Name         string            `json:"name"`
+	Type         json.RawMessage   `json:"type"`
+	Value        json.RawMessage   `json:"value"`
+	UnknownPaths []string          `json:"unknown_paths,omitempty"`
+	Flatmap      map[string]string `json:"flatmap"`
+	// Encoded is the flatmap encoding Value yields when it differs from
+	// Flatmap, as for sets keyed by helper/schema hashcodes
+	Encoded map[string]string `json:"encoded,omitempty"`
+	// Decoded and DecodedUnknownPaths give the value decoding Flatmap
+	// yields when the encoding is lossy; otherwise it must yield Value
+	Decoded             json.RawMessage `json:"decoded,omitempty"`
+	DecodedUnknownPaths []string        `json:"decoded_unknown_paths,omitempty"`
+}
+
+// checkFlatmapVector verifies encode and decode of a single vector,
+// returning a list of mismatches
+func checkFlatmapVector(vector FlatmapVector) []string {
+	ty, err := parseCtyType(vector.Type)
+	if err != nil {
+		return []string{fmt.Sprintf("invalid type: %s", err)}
+	}
+	val, err := flatmapVectorValue(ty, vector.Value, vector.UnknownPaths)
+	if err != nil {
+		return []string{fmt.Sprintf("invalid value: %s", err)}
+	}
+
+	var failures []string
+	expectedFlatmap := vector.Flatmap
+	if vector.Encoded != nil {
+		expectedFlatmap = vector.Encoded
+	}
+	encoded, err := ctyToFlatmap(val)
+	if err != nil {
+		failures = append(failures, fmt.Sprintf("encode failed: %s", err))
+	} else if !reflect.DeepEqual(encoded, expectedFlatmap) {
+		failures = append(failures, fmt.Sprintf("encode mismatch: expected %v, got %v", expectedFlatmap, encoded))
+	}
+
+	expected := val
+	if len(vector.Decoded) > 0 {
+		expected, err = flatmapVectorValue(ty, vector.Decoded, vector.DecodedUnknownPaths)
+		if err != nil {
+			return append(failures, fmt.Sprintf("invalid decoded value: %s", err))
+		}
+	}
+	decoded, err := flatmapToCty(vector.Flatmap, ty)
+	if err != nil {
+		return append(failures, fmt.Sprintf("decode failed: %s", err))
+	}
+	if !decoded.RawEquals(expected) {
+		failures = append(failures, fmt.Sprintf("decode mismatch: expected %#v, got %#v", expected, decoded))
+	}
+
+	// Re-encoding the decoded value must reproduce the flatmap, count keys
+	// included; hashcode-keyed sets come back with index keys instead
+	roundTrip, err := ctyToFlatmap(decoded)
+	if err != nil {
+		failures = append(failures, fmt.Sprintf("round trip encode failed: %s", err))
+	} else if !reflect.DeepEqual(roundTrip, expectedFlatmap) {
+		failures = append(failures, fmt.Sprintf("round trip mismatch: expected %v, got %v", expectedFlatmap, roundTrip))
+	}
+	return failures
+}
+
+// flatmapVectorValue builds a vector value from cty JSON and unknown paths
+func flatmapVectorValue(ty cty.Type, data json.RawMessage, unknown []string) (cty.Value, error) {
+	val, err := ctyjson.Unmarshal(data, ty)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	return markUnknownPaths(val, unknown)
+}
+
+// inlineJSONOrStdin returns a JSON argument, reading stdin for "-"
+func inlineJSONOrStdin(arg string) ([]byte, error) {
+	if arg == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return []byte(arg), nil
+}
+
+// initCtyFlatmapCmd creates the `cty flatmap` command group
+func initCtyFlatmapCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "flatmap",
+		Short: "Convert between legacy flatmap attribute maps and cty values",
+		Long: `Encode and decode the legacy flatmap state encoding used by Terraform 0.11
+and earlier, still accepted by UpgradeResourceState. Values are cty JSON of
+an object type given with --type; unknown values are marked with --unknown
+paths such as 'tags["env"]' and encode as the flatmap unknown sentinel.`,
+	}
+
+	var encodeType string
+	var encodeUnknown []string
+	encodeCmd := &cobra.Command{
+		Use:   "encode [value-json]",
+		Short: "Encode a cty value as a flatmap",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ty, err := parseCtyType(json.RawMessage(encodeType))
+			if err != nil {
+				return fmt.Errorf("invalid type: %w", err)
+			}
+			data, err := inlineJSONOrStdin(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to read value: %w", err)
+			}
+			val, err := flatmapVectorValue(ty, data, encodeUnknown)
+			if err != nil {
+				return fmt.Errorf("invalid value: %w", err)
+			}
+			m, err := ctyToFlatmap(val)
+			if err != nil {
+				return err
+			}
+			return json.NewEncoder(os.Stdout).Encode(m)
+		},
+	}
+	encodeCmd.Flags().StringVar(&encodeType, "type", "", "cty JSON type of the value (an object type)")
+	encodeCmd.Flags().StringArrayVar(&encodeUnknown, "unknown", nil, "Path of a value to encode as unknown (repeatable)")
+	addTypeFileFlags(encodeCmd, true, "type")
+
+	var decodeType string
+	decodeCmd := &cobra.Command{
+		Use:   "decode [flatmap-json]",
+		Short: "Decode a flatmap into a cty value",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ty, err := parseCtyType(json.RawMessage(decodeType))
+			if err != nil {
+				return fmt.Errorf("invalid type: %w", err)
+			}
+			data, err := inlineJSONOrStdin(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to read flatmap: %w", err)
+			}
+			var m map[string]string
+			if err := json.Unmarshal(data, &m); err != nil {
+				return fmt.Errorf("failed to parse flatmap: %w", err)
+			}
+			val, err := flatmapToCty(m, ty)
+			if err != nil {
+				return err
+			}
+			valueJSON, err := ctyjson.Marshal(nullUnknowns(val), ty)
+			if err != nil {
+				return fmt.Errorf("failed to marshal value: %w", err)
+			}
+			return json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+				"value":         json.RawMessage(valueJSON),
+				"unknown_paths": nonNilStrings(unknownPaths(val)),
+			})
+		},
+	}
+	decodeCmd.Flags().StringVar(&decodeType, "type", "", "cty JSON type to decode into (an object type)")
+	addTypeFileFlags(decodeCmd, true, "type")
+
+	var checkJobs int
+	checkCmd := &cobra.Command{
+		Use:   "check [vectors-file]",
+		Short: "Verify flatmap golden vectors",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := readInputOrStdin(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to read vectors: %w", err)
+			}
+
+			var vectors []FlatmapVector
+			if err := json.Unmarshal(data, &vectors); err != nil {
+				return fmt.Errorf("failed to parse vectors: %w", err)
+			}
+
+			failed := 0
+			results := make([]map[string]interface{}, 0, len(vectors))
+			runOrdered(checkJobs, sliceItems(vectors), func(_ int, vector FlatmapVector) map[string]interface{} {
+				failures := checkFlatmapVector(vector)
+				result := map[string]interface{}{
+					"name":   vector.Name,
+					"passed": len(failures) == 0,
+				}
+				if len(failures) > 0 {
+					result["failures"] = failures
+				}
+				return result
+			}, func(result map[string]interface{}) error {
+				if _, ok := result["failures"]; ok {
+					failed++
+				}
+				results = append(results, result)
+				return nil
+			})
+
+			output := map[string]interface{}{
+				"total":   len(vectors),
+				"failed":  failed,
+				"results": results,
+			}
+			if err := json.NewEncoder(os.Stdout).Encode(output); err != nil {
+				return fmt.Errorf("failed to encode JSON: %w", err)
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d of %d vectors failed", failed, len(vectors))
+			}
+			return nil
+		},
+	}
+
+	checkCmd.Flags().IntVar(&checkJobs, "jobs", 0, "Number of parallel workers (default: number of CPUs)")
+
+	cmd.AddCommand(encodeCmd)
+	cmd.AddCommand(decodeCmd)
+	cmd.AddCommand(withProfiles(checkCmd))
+	return cmd
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/ctyspec"
+)
+
+// ctyFunctions returns the go-cty stdlib functions soup-go evaluates,
+// keyed by their Terraform-compatible names.
+func ctyFunctions() map[string]function.Function {
+	return map[string]function.Function{
+		"abs":             stdlib.AbsoluteFunc,
+		"ceil":            stdlib.CeilFunc,
+		"chomp":           stdlib.ChompFunc,
+		"chunklist":       stdlib.ChunklistFunc,
+		"coalesce":        stdlib.CoalesceFunc,
+		"coalescelist":    stdlib.CoalesceListFunc,
+		"compact":         stdlib.CompactFunc,
+		"concat":          stdlib.ConcatFunc,
+		"contains":        stdlib.ContainsFunc,
+		"csvdecode":       stdlib.CSVDecodeFunc,
+		"distinct":        stdlib.DistinctFunc,
+		"element":         stdlib.ElementFunc,
+		"flatten":         stdlib.FlattenFunc,
+		"floor":           stdlib.FloorFunc,
+		"format":          stdlib.FormatFunc,
+		"formatdate":      stdlib.FormatDateFunc,
+		"formatlist":      stdlib.FormatListFunc,
+		"indent":          stdlib.IndentFunc,
+		"index":           stdlib.IndexFunc,
+		"join":            stdlib.JoinFunc,
+		"jsondecode":      stdlib.JSONDecodeFunc,
+		"jsonencode":      stdlib.JSONEncodeFunc,
+		"keys":            stdlib.KeysFunc,
+		"length":          stdlib.LengthFunc,
+		"log":             stdlib.LogFunc,
+		"lookup":          stdlib.LookupFunc,
+		"lower":           stdlib.LowerFunc,
+		"max":             stdlib.MaxFunc,
+		"merge":           stdlib.MergeFunc,
+		"min":             stdlib.MinFunc,
+		"parseint":        stdlib.ParseIntFunc,
+		"pow":             stdlib.PowFunc,
+		"range":           stdlib.RangeFunc,
+		"regex":           stdlib.RegexFunc,
+		"regexall":        stdlib.RegexAllFunc,
+		"regex_replace":   stdlib.RegexReplaceFunc,
+		"replace":         stdlib.ReplaceFunc,
+		"reverse":         stdlib.ReverseListFunc,
+		"setintersection": stdlib.SetIntersectionFunc,
+		"setproduct":      stdlib.SetProductFunc,
+		"setsubtract":     stdlib.SetSubtractFunc,
+		"setunion":        stdlib.SetUnionFunc,
+		"signum":          stdlib.SignumFunc,
+		"slice":           stdlib.SliceFunc,
+		"sort":            stdlib.SortFunc,
+		"split":           stdlib.SplitFunc,
+		"strlen":          stdlib.StrlenFunc,
+		"strrev":          stdlib.ReverseFunc,
+		"substr":          stdlib.SubstrFunc,
+		"timeadd":         stdlib.TimeAddFunc,
+		"title":           stdlib.TitleFunc,
+		"trim":            stdlib.TrimFunc,
+		"trimprefix":      stdlib.TrimPrefixFunc,
+		"trimspace":       stdlib.TrimSpaceFunc,
+		"trimsuffix":      stdlib.TrimSuffixFunc,
+		"upper":           stdlib.UpperFunc,
+		"values":          stdlib.ValuesFunc,
+		"zipmap":          stdlib.ZipmapFunc,
+	}
+}
+
+// ctyFunctionNames returns the sorted names of all registered functions
+func ctyFunctionNames() []string {
+	funcs := ctyFunctions()
+	names := make([]string, 0, len(funcs))
+	for name := range funcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// functionSignature is the JSON form of a registered function's signature
+type functionSignature struct {
+	Name              string              `json:"name"`
+	Description       string              `json:"description"`
+	Parameters        []functionParameter `json:"parameters"`
+	VariadicParameter *functionParameter  `json:"variadic_parameter"`
+	// ReturnType is the static return type, as for provider functions
+	ReturnType json.RawMessage `json:"return_type"`
+}
+
+// functionParameter is the JSON form of a function parameter
+type functionParameter struct {
+	Name             string          `json:"name"`
+	Type             json.RawMessage `json:"type"`
+	AllowNull        bool            `json:"allow_null"`
+	AllowUnknown     bool            `json:"allow_unknown"`
+	AllowDynamicType bool            `json:"allow_dynamic_type"`
+	AllowMarked      bool            `json:"allow_marked"`
+}
+
+// ctyFunctionSignatures returns the signatures of all registered
+// functions, sorted by name
+func ctyFunctionSignatures() ([]functionSignature, error) {
+	funcs := ctyFunctions()
+	signatures := make([]functionSignature, 0, len(funcs))
+	for _, name := range ctyFunctionNames() {
+		fn := funcs[name]
+		signature := functionSignature{
+			Name:        name,
+			Description: fn.Description(),
+			Parameters:  []functionParameter{},
+		}
+		for _, param := range fn.Params() {
+			jsonParam, err := functionParameterToJSON(param)
+			if err != nil {
+				return nil, fmt.Errorf("function %s: %w", name, err)
+			}
+			signature.Parameters = append(signature.Parameters, jsonParam)
+		}
+		if varParam := fn.VarParam(); varParam != nil {
+			jsonParam, err := functionParameterToJSON(*varParam)
+			if err != nil {
+				return nil, fmt.Errorf("function %s: %w", name, err)
+			}
+			signature.VariadicParameter = &jsonParam
+		}
+		returnType, err := ctyspec.MarshalType(functionReturnType(fn))
+		if err != nil {
+			return nil, fmt.Errorf("function %s: return type: %w", name, err)
+		}
+		signature.ReturnType = returnType
+		signatures = append(signatures, signature)
+	}
+	return signatures, nil
+}
+
+// functionParameterToJSON converts a function parameter to its JSON form
+func functionParameterToJSON(param function.Parameter) (functionParameter, error) {
+	paramType, err := ctyspec.MarshalType(param.Type)
+	if err != nil {
+		return functionParameter{}, fmt.Errorf("parameter %s: %w", param.Name, err)
+	}
+	return functionParameter{
+		Name:             param.Name,
+		Type:             paramType,
+		AllowNull:        param.AllowNull,
+		AllowUnknown:     param.AllowUnknown,
+		AllowDynamicType: param.AllowDynamicType,
+		AllowMarked:      param.AllowMarked,
+	}, nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-plugin"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/kvplugin"
+)
+
+// Handshake profile names accepted by --handshake-profile
+const (
+	HandshakeProfileKV        = "kv"
+	HandshakeProfileTerraform = "terraform"
+)
+
+// HandshakeProfile bundles the go-plugin handshake parameters a server
+// advertises together with the TLS ALPN protocols it negotiates.
+type HandshakeProfile struct {
+	Name       string
+	Handshake  plugin.HandshakeConfig
+	NextProtos []string
+}
+
+// handshakeProfiles holds every profile soup-go knows how to serve.
+var handshakeProfiles = map[string]HandshakeProfile{
+	// The default TofuSoup KV handshake used across the language matrix
+	HandshakeProfileKV: {
+		Name:      HandshakeProfileKV,
+		Handshake: kvplugin.Handshake,
+	},
+	// Terraform core / OpenTofu provider handshake (protocol version 6).
+	// Recent grpc-go clients (as embedded in terraform/tofu) reject servers
+	// that don't negotiate "h2" via ALPN, so advertise it explicitly rather
+	// than relying on whichever TLS config the provider hands back.
+	HandshakeProfileTerraform: {
+		Name: HandshakeProfileTerraform,
+		Handshake: plugin.HandshakeConfig{
+			ProtocolVersion:  6,
+			MagicCookieKey:   "TF_PLUGIN_MAGIC_COOKIE",
+			MagicCookieValue: "d602bf8f470bc67ca7faa0386276bbdd4330efaf76d1a219cb4d6991ca9872b2",
+		},
+		NextProtos: []string{"h2"},
+	},
+}
+
+// getHandshakeProfile looks up a handshake profile by name
+func getHandshakeProfile(name string) (HandshakeProfile, error) {
+	profile, ok := handshakeProfiles[strings.ToLower(name)]
+	if !ok {
+		return HandshakeProfile{}, fmt.Errorf("unknown handshake profile: %s (expected one of: %s)",
+			name, strings.Join(handshakeProfileNames(), ", "))
+	}
+	return profile, nil
+}
+
+// handshakeProfileNames returns the sorted list of known profile names
+func handshakeProfileNames() []string {
+	names := make([]string, 0, len(handshakeProfiles))
+	for name := range handshakeProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// wrapTLSProvider ensures TLS configs produced by provider advertise the
+// profile's ALPN protocols. Profiles without NextProtos return provider as-is.
+func (p HandshakeProfile) wrapTLSProvider(provider func() (*tls.Config, error)) func() (*tls.Config, error) {
+	if len(p.NextProtos) == 0 {
+		return provider
+	}
+	return func() (*tls.Config, error) {
+		tlsConfig, err := provider()
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.NextProtos = append([]string(nil), p.NextProtos...)
+		return tlsConfig, nil
+	}
+}
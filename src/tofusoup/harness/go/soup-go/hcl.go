@@ -4,46 +4,53 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/hashicorp/hcl/v2"
-	"github.com/hashicorp/hcl/v2/hclparse"
-	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/spf13/cobra"
-	"github.com/zclconf/go-cty/cty"
-	"github.com/zclconf/go-cty/cty/function"
 	ctyjson "github.com/zclconf/go-cty/cty/json"
 	ctymsgpack "github.com/zclconf/go-cty/cty/msgpack"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/hcltools"
 )
 
 // HCL output format flag
 var hclOutputFormat string
 var hclConvertOutputFormat string
 
+// diagnosticContextLines is how many lines around a diagnostic's subject
+// --output-format pretty shows
+const diagnosticContextLines = 2
+
 // Override the convert command with real implementation
 func initHclConvertCmd() *cobra.Command {
+	var stringOpts hcltools.StringOptions
+
 	cmd := &cobra.Command{
 		Use:   "convert [input] [output]",
-		Short: "Convert HCL to JSON or Msgpack",
+		Short: "Convert HCL to JSON, YAML or Msgpack",
 		Args:  cobra.ExactArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			inputPath := args[0]
 			outputPath := args[1]
 
 			// Read the HCL file
-			content, err := os.ReadFile(inputPath)
+			content, err := readFileLimited(inputPath)
 			if err != nil {
 				return fmt.Errorf("failed to read input file: %w", err)
 			}
 
-			// Parse the HCL file
-			parser := hclparse.NewParser()
-			file, diags := parser.ParseHCL(content, inputPath)
+			// Parse the HCL file, as JSON syntax if it is a .json file
+			filename := hclSourceName(inputPath)
+			dialect := hcltools.DialectFor(filename)
+			file, diags := parseHCLCached(content, filename, dialect == hcltools.DialectJSON)
 			if diags.HasErrors() {
 				return fmt.Errorf("HCL parse errors: %s", diags.Error())
 			}
+			logger.Debug("📄 parsed HCL", "file", filename, "dialect", dialect)
 
 			// Convert to JSON representation first
-			jsonResult, err := hclFileToJSON(file)
+			jsonResult, err := hcltools.FileToJSONWithOptions(file, stringOpts, decodeLimits)
 			if err != nil {
 				return fmt.Errorf("failed to convert HCL to intermediate JSON: %w", err)
 			}
@@ -52,10 +59,28 @@ func initHclConvertCmd() *cobra.Command {
 			var outputData []byte
 			switch hclConvertOutputFormat {
 			case "json":
-				outputData, err = json.MarshalIndent(jsonResult, "", "  ")
+				// Encode straight to the output rather than through a buffer
+				out, err := createOutput(outputPath)
 				if err != nil {
+					return fmt.Errorf("failed to write output: %w", err)
+				}
+				encoder := json.NewEncoder(out)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(jsonResult); err != nil {
+					out.Close()
 					return fmt.Errorf("failed to marshal to JSON: %w", err)
 				}
+				if err := out.Close(); err != nil {
+					return fmt.Errorf("failed to write output: %w", err)
+				}
+				return nil
+			case "yaml":
+				// YAML is written from the same structure JSON is, for
+				// consumers of fixtures that read YAML
+				outputData, err = marshalYAML(jsonResult)
+				if err != nil {
+					return fmt.Errorf("failed to marshal to YAML: %w", err)
+				}
 			case "msgpack":
 				// For msgpack, we need to convert the JSON representation to a cty.Value first
 				// This is a simplification; a full implementation would directly convert HCL to cty.Value
@@ -85,12 +110,7 @@ func initHclConvertCmd() *cobra.Command {
 			}
 
 			// Write output
-			if outputPath == "-" {
-				_, err = os.Stdout.Write(outputData)
-			} else {
-				err = os.WriteFile(outputPath, outputData, 0644)
-			}
-			if err != nil {
+			if err := writeStreamOutput(outputPath, outputData); err != nil {
 				return fmt.Errorf("failed to write output: %w", err)
 			}
 
@@ -99,48 +119,117 @@ func initHclConvertCmd() *cobra.Command {
 	}
 	
 	// Add flags
-	cmd.Flags().StringVar(&hclConvertOutputFormat, "output-format", "json", "Output format (json, msgpack)")
+	cmd.Flags().StringVar(&hclConvertOutputFormat, "output-format", "json", "Output format (json, yaml, msgpack)")
+	addStringOptionFlags(cmd, &stringOpts)
 	
 	return cmd
 }
 
+// addStringOptionFlags adds the flags choosing how string attributes are
+// represented to cmd
+func addStringOptionFlags(cmd *cobra.Command, opts *hcltools.StringOptions) {
+	cmd.Flags().StringVar(&opts.Mode, "strings", hcltools.StringsNormalized, "String attribute representation (normalized, raw)")
+	cmd.Flags().BoolVar(&opts.HeredocMetadata, "heredoc-metadata", false, "Print heredoc attributes with their delimiter, indentation and range")
+}
+
 // Override the parse command with real implementation
 func initHclViewCmd() *cobra.Command {
+	var ast, partial bool
+	var stringOpts hcltools.StringOptions
+
 	cmd := &cobra.Command{
 		Use:   "view [file]",
 		Short: "Parse an HCL file and view its structure",
+		Long: `Parse an HCL file and print its structure as JSON, with attribute values
+evaluated without variables or functions. Files ending in .json, such as
+.tf.json, are parsed as JSON syntax, whose properties all convert as attributes
+since JSON carries no block structure without a schema; the dialect parsed,
+native or json, is printed as "dialect". Errors carry the source snippet of
+their range; --output-format pretty renders them instead with the offending
+source lines, caret markers and context lines.
+
+With --ast the unevaluated syntax tree is printed as "ast" instead of "body":
+attributes in source order and blocks, each with its source ranges, and every
+expression with its kind (the hclsyntax node type, e.g. BinaryOpExpr), source
+text and subexpressions as children. Attribute expressions also list the
+variable traversals within them, for parser conformance tests across
+implementations.
+
+String attributes are printed as the values they evaluate to, with escapes
+decoded and indented heredocs (<<-) stripped of their common indentation.
+With --strings raw, attributes that are quoted strings or heredocs are printed
+as their source text instead: between the quotes with escapes as written, or
+the heredoc's lines with their indentation, for testing heredoc handling across
+parsers. --heredoc-metadata prints heredoc attributes as
+  {"value": "...", "heredoc": {"delimiter": "EOT", "indented": true, "range": {...}}}
+
+A file with errors prints {"success": false, "errors": [...]}. With --partial,
+whatever parsed despite the errors is printed alongside them, as "body" or,
+with --ast, "ast", with "partial": true, for editors and other consumers that
+work on incomplete files. Attributes whose expressions failed to parse are
+left out of "body"; "ast" shows them with the placeholder expression the
+parser recovered with.`,
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			filename := args[0]
-
 			// Read the file
-			content, err := os.ReadFile(filename)
+			content, err := readFileLimited(args[0])
 			if err != nil {
 				return fmt.Errorf("failed to read file: %w", err)
 			}
+			filename := hclSourceName(args[0])
 
-			// Parse the HCL file
-			parser := hclparse.NewParser()
-			file, diags := parser.ParseHCL(content, filename)
+			// Parse the HCL file, as JSON syntax if it is a .json file
+			dialect := hcltools.DialectFor(filename)
+			file, diags := parseHCLCached(content, filename, dialect == hcltools.DialectJSON)
 			
 			if diags.HasErrors() {
-				if hclOutputFormat == "diagnostic" {
+				sources := map[string][]byte{filename: content}
+				switch hclOutputFormat {
+				case "diagnostic":
 					for _, diag := range diags {
 						fmt.Fprintf(os.Stderr, "%s\n", diag.Error())
 					}
 					return fmt.Errorf("parse errors occurred")
+				case "pretty":
+					if err := hcltools.WriteDiagnostics(os.Stdout, diags, sources, diagnosticContextLines); err != nil {
+						return fmt.Errorf("failed to write diagnostics: %w", err)
+					}
+					return fmt.Errorf("parse errors occurred")
 				}
 				// Return error info as JSON
 				errorOutput := map[string]interface{}{
 					"success": false,
-					"errors":  diagnosticsToJSON(diags),
+					"dialect": dialect,
+					"errors":  hcltools.DiagnosticsToJSONWithSource(diags, sources),
+				}
+				if partial {
+					errorOutput["partial"] = true
+					if err := addPartialView(errorOutput, file, ast, stringOpts); err != nil {
+						return err
+					}
 				}
 				json.NewEncoder(os.Stdout).Encode(errorOutput)
 				return nil
 			}
 
+			if ast {
+				tree, err := hcltools.FileToAST(file, decodeLimits)
+				if err != nil {
+					return fmt.Errorf("failed to build syntax tree: %w", err)
+				}
+				output := map[string]interface{}{
+					"success": true,
+					"dialect": dialect,
+					"ast":     tree,
+				}
+				if err := json.NewEncoder(os.Stdout).Encode(output); err != nil {
+					return fmt.Errorf("failed to encode JSON: %w", err)
+				}
+				return nil
+			}
+
 			// Convert to JSON representation
-			result, err := hclFileToJSON(file)
+			result, err := hcltools.FileToJSONWithOptions(file, stringOpts, decodeLimits)
 			if err != nil {
 				return fmt.Errorf("failed to convert HCL to JSON: %w", err)
 			}
@@ -149,6 +238,7 @@ func initHclViewCmd() *cobra.Command {
 			if hclOutputFormat == "json" {
 				output := map[string]interface{}{
 					"success": true,
+					"dialect": dialect,
 					"body":    result,
 				}
 				if err := json.NewEncoder(os.Stdout).Encode(output); err != nil {
@@ -161,36 +251,115 @@ func initHclViewCmd() *cobra.Command {
 	}
 	
 	// Add flags
-	cmd.Flags().StringVar(&hclOutputFormat, "output-format", "json", "Output format (json, diagnostic)")
+	cmd.Flags().StringVar(&hclOutputFormat, "output-format", "json", "Output format (json, diagnostic, pretty)")
+	cmd.Flags().BoolVar(&ast, "ast", false, "Print the unevaluated syntax tree with source ranges instead of evaluated values")
+	cmd.Flags().BoolVar(&partial, "partial", false, "On errors, also print whatever parsed successfully")
+	addStringOptionFlags(cmd, &stringOpts)
 	
 	return cmd
 }
 
+// addPartialView adds what parsed of a file with errors to the output of
+// hcl view: its syntax tree as "ast" if ast is set, and otherwise its
+// structure as "body". Nothing is added if the parser recovered no file.
+func addPartialView(output map[string]interface{}, file *hcl.File, ast bool, stringOpts hcltools.StringOptions) error {
+	if file == nil || file.Body == nil {
+		return nil
+	}
+	if ast {
+		tree, err := hcltools.FileToAST(file, decodeLimits)
+		if err != nil {
+			return fmt.Errorf("failed to build syntax tree: %w", err)
+		}
+		output["ast"] = tree
+		return nil
+	}
+	result, err := hcltools.FileToJSONWithOptions(file, stringOpts, decodeLimits)
+	if err != nil {
+		return fmt.Errorf("failed to convert HCL to JSON: %w", err)
+	}
+	output["body"] = result
+	return nil
+}
+
 // Override the validate command with real implementation
 func initHclValidateCmd() *cobra.Command {
+	var outputFormat string
+	var includes, excludes []string
+	var jobs int
+	var watch bool
+	var interval time.Duration
+
 	cmd := &cobra.Command{
-		Use:   "validate [file]",
+		Use:   "validate [file|dir]",
 		Short: "Validate HCL syntax",
+		Long: `Parse an HCL file and print {"valid": true|false, "dialect": "..."} as JSON,
+with the errors of an invalid file, each with the source snippet of its range.
+
+With --output-format pretty, diagnostics are instead rendered with the offending
+source lines, caret markers under the problem and context lines around it, and
+an invalid file makes the command exit non-zero.
+
+Given a directory, every file under it matching an --include glob and no
+--exclude glob is validated concurrently, by default .hcl, .tf and .tf.json
+files. Globs are as for Go's path.Match; those without a slash match file names
+at any depth, others whole slash-separated paths relative to the directory. An
+aggregate report is printed,
+  {"valid": false, "total": 2, "invalid": 1, "files": [
+    {"path": "main.tf", "dialect": "native", "valid": false, "errors": [...]}]}
+and the command exits non-zero if any file is invalid.
+
+With --watch, the directory is validated and then polled every --interval,
+re-validating files that are added or modified, until interrupted. One JSON
+line is written per validation run, with the files that changed or were
+removed, their results, and the totals for the whole directory:
+  {"run": 2, "time": "...", "changed": ["main.tf"], "removed": [],
+   "files": [...], "total": 2, "invalid": 0, "valid": true}`,
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			filename := args[0]
+			if watch {
+				if info, err := os.Stat(args[0]); err != nil || !info.IsDir() {
+					return fmt.Errorf("--watch requires a directory")
+				}
+				if outputFormat != "json" {
+					return fmt.Errorf("--watch writes JSON lines and does not support --output-format %s", outputFormat)
+				}
+				return runHclValidateWatch(args[0], includes, excludes, jobs, interval)
+			}
+			if info, err := os.Stat(args[0]); err == nil && info.IsDir() {
+				return runHclValidateDir(args[0], includes, excludes, jobs, outputFormat)
+			}
 
 			// Read the file
-			content, err := os.ReadFile(filename)
+			content, err := readFileLimited(args[0])
 			if err != nil {
 				return fmt.Errorf("failed to read file: %w", err)
 			}
+			filename := hclSourceName(args[0])
 
-			// Parse the HCL file for validation
-			parser := hclparse.NewParser()
-			_, diags := parser.ParseHCL(content, filename)
+			// Parse the HCL file for validation, as JSON syntax if it is a
+			// .json file
+			dialect := hcltools.DialectFor(filename)
+			_, diags := parseHCLCached(content, filename, dialect == hcltools.DialectJSON)
 
 			result := map[string]interface{}{
-				"valid": !diags.HasErrors(),
+				"valid":   !diags.HasErrors(),
+				"dialect": dialect,
+			}
+
+			sources := map[string][]byte{filename: content}
+			if outputFormat == "pretty" {
+				if err := hcltools.WriteDiagnostics(os.Stdout, diags, sources, diagnosticContextLines); err != nil {
+					return fmt.Errorf("failed to write diagnostics: %w", err)
+				}
+				if diags.HasErrors() {
+					return fmt.Errorf("%s is not valid HCL", filename)
+				}
+				return nil
 			}
 
 			if diags.HasErrors() {
-				result["errors"] = diagnosticsToJSON(diags)
+				result["errors"] = hcltools.DiagnosticsToJSONWithSource(diags, sources)
 			}
 
 			// Output validation result as JSON
@@ -202,133 +371,11 @@ func initHclValidateCmd() *cobra.Command {
 		},
 	}
 	
+	cmd.Flags().StringVar(&outputFormat, "output-format", "json", "Output format (json, pretty)")
+	cmd.Flags().StringArrayVar(&includes, "include", nil, "Glob of directory files to validate (repeatable, default: *.hcl, *.tf, *.tf.json)")
+	cmd.Flags().StringArrayVar(&excludes, "exclude", nil, "Glob of directory files to skip (repeatable)")
+	cmd.Flags().IntVar(&jobs, "jobs", 0, "Number of parallel workers for a directory (default: number of CPUs)")
+	cmd.Flags().BoolVar(&watch, "watch", false, "Re-validate the directory's files as they change, writing a JSON line per run")
+	cmd.Flags().DurationVar(&interval, "interval", time.Second, "How often --watch polls the directory for changes")
 	return cmd
 }
-
-// hclFileToJSON converts an HCL file to a JSON representation
-func hclFileToJSON(file *hcl.File) (interface{}, error) {
-	// For now, we'll work directly with the body without partial content
-	// since we're doing a general parse
-
-	result := make(map[string]interface{})
-
-	// Process attributes
-	if body, ok := file.Body.(*hclsyntax.Body); ok {
-		for name, attr := range body.Attributes {
-			val, diags := attr.Expr.Value(&hcl.EvalContext{
-				Variables: map[string]cty.Value{},
-				Functions: map[string]function.Function{},
-			})
-			if !diags.HasErrors() {
-				jsonVal, err := ctyjson.Marshal(val, val.Type())
-				if err == nil {
-					var v interface{}
-					if err := json.Unmarshal(jsonVal, &v); err == nil {
-						result[name] = v
-					}
-				}
-			}
-		}
-
-		// Process blocks
-		blocks := make([]map[string]interface{}, 0)
-		for _, block := range body.Blocks {
-			blockData := map[string]interface{}{
-				"type":   block.Type,
-				"labels": block.Labels,
-			}
-			
-			// Recursively process block body
-			if blockBody, err := hclBlockToJSON(block.Body); err == nil {
-				blockData["body"] = blockBody
-			}
-			
-			blocks = append(blocks, blockData)
-		}
-		
-		if len(blocks) > 0 {
-			result["blocks"] = blocks
-		}
-	}
-
-	return result, nil
-}
-
-// hclBlockToJSON converts an HCL block body to JSON
-func hclBlockToJSON(body hcl.Body) (interface{}, error) {
-	if syntaxBody, ok := body.(*hclsyntax.Body); ok {
-		result := make(map[string]interface{})
-		
-		// Process attributes in the block
-		for name, attr := range syntaxBody.Attributes {
-			val, diags := attr.Expr.Value(&hcl.EvalContext{
-				Variables: map[string]cty.Value{},
-				Functions: map[string]function.Function{},
-			})
-			if !diags.HasErrors() {
-				jsonVal, err := ctyjson.Marshal(val, val.Type())
-				if err == nil {
-					var v interface{}
-					if err := json.Unmarshal(jsonVal, &v); err == nil {
-						result[name] = v
-					}
-				}
-			}
-		}
-		
-		// Process nested blocks
-		if len(syntaxBody.Blocks) > 0 {
-			blocks := make([]map[string]interface{}, 0)
-			for _, block := range syntaxBody.Blocks {
-				blockData := map[string]interface{}{
-					"type":   block.Type,
-					"labels": block.Labels,
-				}
-				
-				if blockBody, err := hclBlockToJSON(block.Body); err == nil {
-					blockData["body"] = blockBody
-				}
-				
-				blocks = append(blocks, blockData)
-			}
-			result["blocks"] = blocks
-		}
-		
-		return result, nil
-	}
-	
-	return nil, fmt.Errorf("unsupported body type")
-}
-
-// diagnosticsToJSON converts HCL diagnostics to JSON
-func diagnosticsToJSON(diags hcl.Diagnostics) []map[string]interface{} {
-	result := make([]map[string]interface{}, 0, len(diags))
-	for _, diag := range diags {
-		severityStr := "error"
-		if diag.Severity == hcl.DiagWarning {
-			severityStr = "warning"
-		}
-		d := map[string]interface{}{
-			"severity": severityStr,
-			"summary":  diag.Summary,
-			"detail":   diag.Detail,
-		}
-		if diag.Subject != nil {
-			d["range"] = map[string]interface{}{
-				"filename": diag.Subject.Filename,
-				"start": map[string]int{
-					"line":   diag.Subject.Start.Line,
-					"column": diag.Subject.Start.Column,
-					"byte":   diag.Subject.Start.Byte,
-				},
-				"end": map[string]int{
-					"line":   diag.Subject.End.Line,
-					"column": diag.Subject.End.Column,
-					"byte":   diag.Subject.End.Byte,
-				},
-			}
-		}
-		result = append(result, d)
-	}
-	return result
-}
\ No newline at end of file
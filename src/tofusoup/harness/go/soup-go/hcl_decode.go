@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/spf13/cobra"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/ctyspec"
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/hcltools"
+)
+
+// initHclDecodeCmd creates the `hcl decode` command
+func initHclDecodeCmd() *cobra.Command {
+	var specPath string
+	var outputFormat string
+
+	cmd := &cobra.Command{
+		Use:   "decode --spec <spec.json> <file> [output]",
+		Short: "Decode an HCL file into a typed CTY value with an hcldec spec",
+		Long: `Decode an HCL file with the hcldec spec in --spec, the way providers and tools
+apply a schema to configuration, and write the typed value to [output] (default
+stdout) as JSON or msgpack of the type the spec implies. The spec is JSON, each
+spec an object with a single member naming its kind:
+
+  {"object": {
+     "name": {"attr": {"name": "name", "type": "string", "required": true}},
+     "tags": {"attr": {"name": "tags", "type": "map(string)"}},
+     "rules": {"block_list": {"type_name": "rule", "nested": {"object": {
+       "port": {"attr": {"name": "port", "type": "number"}}}}}}}}
+
+Kinds are object, tuple, attr, literal, block, block_list, block_set, block_map,
+block_attrs, label and default. Types are JSON type specifications or HCL type
+expressions. Files ending in .json, such as .tf.json, are parsed as JSON syntax.
+Expressions are evaluated without variables or functions. If the file does not
+parse or does not match the spec, {"success": false, "errors": [...]} is
+printed with the diagnostics and the command exits non-zero.`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			specData, err := readFileLimited(specPath)
+			if err != nil {
+				return fmt.Errorf("failed to read spec: %w", err)
+			}
+			spec, err := hcltools.ParseSpec(specData, ctyOptions())
+			if err != nil {
+				return fmt.Errorf("failed to parse spec: %w", err)
+			}
+
+			content, err := readFileLimited(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to read file: %w", err)
+			}
+			filename := hclSourceName(args[0])
+			dialect := hcltools.DialectFor(filename)
+			file, diags := parseHCLCached(content, filename, dialect == hcltools.DialectJSON)
+			value := cty.NilVal
+			if !diags.HasErrors() {
+				var decodeDiags hcl.Diagnostics
+				value, decodeDiags = hcldec.Decode(file.Body, spec, nil)
+				diags = append(diags, decodeDiags...)
+			}
+			if diags.HasErrors() {
+				errorOutput := map[string]interface{}{
+					"success": false,
+					"errors":  hcltools.DiagnosticsToJSONWithSource(diags, map[string][]byte{filename: content}),
+				}
+				if err := json.NewEncoder(os.Stdout).Encode(errorOutput); err != nil {
+					return fmt.Errorf("failed to encode JSON: %w", err)
+				}
+				return fmt.Errorf("failed to decode %s: %s", filename, diags.Error())
+			}
+
+			output, err := ctyspec.Encode(value, hcldec.ImpliedType(spec), outputFormat, ctyOptions())
+			if err != nil {
+				return err
+			}
+			outputPath := "-"
+			if len(args) > 1 {
+				outputPath = args[1]
+			}
+			if err := writeStreamOutput(outputPath, output); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
+			}
+			logger.Debug("🧩 decoded HCL with spec", "file", filename, "dialect", dialect, "spec", specPath, "format", outputFormat)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&specPath, "spec", "", "JSON hcldec spec file")
+	cmd.Flags().StringVar(&outputFormat, "output-format", "json", "Output format (json, msgpack)")
+	cmd.MarkFlagRequired("spec")
+	return cmd
+}
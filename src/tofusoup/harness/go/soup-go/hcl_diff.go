@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/hcltools"
+)
+
+// initHclDiffCmd creates the `hcl diff` command
+func initHclDiffCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff <a> <b>",
+		Short: "Structurally diff two HCL files, ignoring formatting",
+		Long: `Compare two native syntax HCL files block by block and attribute by attribute
+and print the differences as JSON:
+
+  {"success": true, "equal": false, "changes": [
+    {"kind": "changed", "element": "attribute",
+     "address": "resource.aws_instance.web.ami",
+     "a_range": {...}, "b_range": {...}, "a": "\"ami-1\"", "b": "\"ami-2\""},
+    {"kind": "added", "element": "block",
+     "address": "resource.aws_instance.web.ingress[1]", "b_range": {...}}]}
+
+Attributes are matched by name and blocks by type and labels, in order among
+the blocks sharing them; the second and later of those are addressed with
+their index. Whitespace, comments and attribute order are ignored, as are
+differences in how constant expressions are written, such as 1 and 1.0. The
+contents of added and removed blocks are not listed.
+
+The command exits non-zero if the files differ. A file that does not parse
+prints {"success": false, "errors": [...]} and the command exits non-zero.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if args[0] == "-" && args[1] == "-" {
+				return fmt.Errorf("only one of the files can be read from stdin")
+			}
+			var files []*hcl.File
+			var diags hcl.Diagnostics
+			sources := map[string][]byte{}
+			for _, path := range args {
+				content, err := readFileLimited(path)
+				if err != nil {
+					return fmt.Errorf("failed to read %s: %w", path, err)
+				}
+				filename := hclSourceName(path)
+				sources[filename] = content
+				file, parseDiags := parseHCLCached(content, filename, false)
+				diags = append(diags, parseDiags...)
+				files = append(files, file)
+			}
+			if diags.HasErrors() {
+				errorOutput := map[string]interface{}{
+					"success": false,
+					"errors":  hcltools.DiagnosticsToJSONWithSource(diags, sources),
+				}
+				if err := json.NewEncoder(os.Stdout).Encode(errorOutput); err != nil {
+					return fmt.Errorf("failed to encode JSON: %w", err)
+				}
+				return fmt.Errorf("HCL parse errors: %s", diags.Error())
+			}
+
+			changes, err := hcltools.DiffFiles(files[0], files[1], decodeLimits)
+			if err != nil {
+				return fmt.Errorf("failed to diff files: %w", err)
+			}
+			logger.Debug("🔍 diffed HCL files", "a", args[0], "b", args[1], "changes", len(changes))
+			output := map[string]interface{}{
+				"success": true,
+				"equal":   len(changes) == 0,
+				"changes": changes,
+			}
+			if err := json.NewEncoder(os.Stdout).Encode(output); err != nil {
+				return fmt.Errorf("failed to encode JSON: %w", err)
+			}
+			if len(changes) > 0 {
+				return fmt.Errorf("files differ: %d changes", len(changes))
+			}
+			return nil
+		},
+	}
+	return cmd
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/spf13/cobra"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/hcltools"
+)
+
+// newHclEvalContext returns the context `hcl eval` evaluates files in:
+// variables are attributes of var, as in Terraform configurations, and the
+// functions are those of ctyFunctions
+func newHclEvalContext(variables map[string]cty.Value) *hcl.EvalContext {
+	return &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"var": cty.ObjectVal(variables),
+		},
+		Functions: ctyFunctions(),
+	}
+}
+
+// initHclEvalCmd creates the `hcl eval` command
+func initHclEvalCmd() *cobra.Command {
+	var varsPath, schemaPath string
+
+	cmd := &cobra.Command{
+		Use:   "eval <file>",
+		Short: "Evaluate an HCL file with variables, expanding dynamic blocks",
+		Long: `Evaluate a native syntax HCL file and print its structure as JSON, in the form
+hcl view prints, with the variables of --vars, a .tfvars file or, for files
+ending in .json, a .tfvars.json file, referred to as var.<name>, and the
+functions hcl functions list prints.
+
+Dynamic blocks are expanded as Terraform expands them: each
+  dynamic "<type>" { for_each = ..., iterator = ..., labels = [...], content {...} }
+is replaced, in place, by one <type> block per element of for_each, with the
+iterator (by default named after the type) bound to its key and value in the
+content. The post-expansion blocks are printed, so discrepancies in dynamic
+block semantics show up in the structure.
+
+With --schema, a variables schema as for hcl tfvars --schema, variables are
+typed and defaulted by their declarations, and those declared sensitive are
+marked sensitive. Marks propagate through evaluation as in Terraform: every
+value derived from a sensitive one is sensitive. Values are printed unmarked,
+and "sensitive" lists the paths of the sensitive values, their attributes'
+addresses followed by the path within the value:
+  "sensitive": ["resource.db.main.password", "tags[\"token\"]"]
+Blocks sharing a type and labels are told apart by their index, e.g.
+ingress[1].cidr.
+
+The attributes of top-level locals blocks are local values, referred to as
+local.<name> anywhere in the file, including other locals. They are evaluated
+in dependency order, and those that evaluated to a value are printed in that
+order as "locals_order". Locals that refer to each other in a cycle are an
+error whose diagnostic describes the cycle,
+  {"summary": "Cycle in local values", ...,
+   "cycle": {"locals": ["a", "b", "a"], "ranges": [{...}, {...}]}}
+and are left unknown, along with everything derived from them; attributes
+with unknown values are left out of "body".
+
+The result is {"success": true, "body": {...}, "sensitive": [...],
+"locals_order": [...]}. If anything fails to evaluate, "success" is false,
+"errors" holds the diagnostics, "body" holds what did evaluate, and the
+command exits non-zero.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			content, err := readFileLimited(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to read file: %w", err)
+			}
+			filename := hclSourceName(args[0])
+			sources := map[string][]byte{filename: content}
+
+			variables := map[string]cty.Value{}
+			var varAttrs map[string]*hcl.Attribute
+			var diags hcl.Diagnostics
+			if varsPath != "" {
+				varsContent, err := readFileLimited(varsPath)
+				if err != nil {
+					return fmt.Errorf("failed to read variables: %w", err)
+				}
+				sources[hclSourceName(varsPath)] = varsContent
+				variables, varAttrs, diags = parseTfvarsFile(hclSourceName(varsPath), varsContent)
+			}
+			if schemaPath != "" && !diags.HasErrors() {
+				vars, err := loadVariablesSchema(schemaPath)
+				if err != nil {
+					return err
+				}
+				var schemaDiags hcl.Diagnostics
+				variables, _, _, schemaDiags = applyVariableSchema(variables, varAttrs, vars)
+				diags = append(diags, schemaDiags...)
+				for name, val := range variables {
+					if vars[name].sensitive {
+						variables[name] = val.Mark(hcltools.SensitiveMark)
+					}
+				}
+			}
+
+			var eval *hcltools.Evaluation
+			if !diags.HasErrors() {
+				file, parseDiags := parseHCLCached(content, filename, false)
+				diags = append(diags, parseDiags...)
+				if !parseDiags.HasErrors() {
+					var evalDiags hcl.Diagnostics
+					eval, evalDiags, err = hcltools.EvalFile(file, newHclEvalContext(variables), decodeLimits)
+					if err != nil {
+						return fmt.Errorf("failed to evaluate %s: %w", filename, err)
+					}
+					diags = append(diags, evalDiags...)
+				}
+			}
+
+			output := map[string]interface{}{
+				"success": !diags.HasErrors(),
+			}
+			if eval != nil {
+				output["body"] = eval.Body
+				output["sensitive"] = eval.Sensitive
+				output["locals_order"] = eval.LocalsOrder
+			}
+			if diags.HasErrors() {
+				output["errors"] = hcltools.DiagnosticsToJSONWithSource(diags, sources)
+			}
+			if err := json.NewEncoder(os.Stdout).Encode(output); err != nil {
+				return fmt.Errorf("failed to encode JSON: %w", err)
+			}
+			if diags.HasErrors() {
+				return fmt.Errorf("failed to evaluate %s: %s", filename, diags.Error())
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&varsPath, "vars", "", "Variables file (.tfvars or .tfvars.json)")
+	cmd.Flags().StringVar(&schemaPath, "schema", "", "Path to variables schema JSON declaring variable types, defaults and sensitivity")
+	return cmd
+}
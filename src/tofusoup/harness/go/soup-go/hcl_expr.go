@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/spf13/cobra"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/ctyspec"
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/hcltools"
+)
+
+// evalExpression evaluates a native syntax HCL expression in the context
+// hcl eval uses, with the given variables as attributes of var. For
+// compatibility, variables other than one named var may also be referred
+// to by their bare names.
+func evalExpression(src []byte, filename string, variables map[string]cty.Value) (cty.Value, hcl.Diagnostics) {
+	expr, diags := hclsyntax.ParseExpression(src, filename, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return cty.NilVal, diags
+	}
+	ctx := newHclEvalContext(variables)
+	for name, val := range variables {
+		if name != "var" {
+			ctx.Variables[name] = val
+		}
+	}
+	val, valDiags := expr.Value(ctx)
+	diags = append(diags, valDiags...)
+	if diags.HasErrors() {
+		return cty.NilVal, diags
+	}
+	return val, diags
+}
+
+// typedValueToJSON returns the JSON of a value and of its type. A null
+// value is written as null whatever its type.
+func typedValueToJSON(val cty.Value) (json.RawMessage, json.RawMessage, error) {
+	val, _ = val.UnmarkDeep()
+	typeJSON, err := ctyspec.MarshalType(val.Type())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal type: %w", err)
+	}
+	if val.IsNull() {
+		return json.RawMessage("null"), typeJSON, nil
+	}
+	valueJSON, err := ctyjson.Marshal(val, val.Type())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal value: %w", err)
+	}
+	return valueJSON, typeJSON, nil
+}
+
+// initHclExprEvalCmd creates the `hcl expr eval` command
+func initHclExprEvalCmd() *cobra.Command {
+	var varsPath string
+
+	cmd := &cobra.Command{
+		Use:   "eval <expression>",
+		Short: "Evaluate a single HCL expression",
+		Long: `Parse and evaluate a single native syntax HCL expression, without wrapping it
+in an attribute of a file, and print the result with its type:
+  soup-go hcl expr eval '[for s in var.subnets : upper(s) if s != ""]' --vars vars.json
+  {"success": true, "value": ["A", "B"], "type": ["tuple", ["string", "string"]],
+   "diagnostics": []}
+
+Variables come from --vars, a .tfvars file or, for files ending in .json, a
+.tfvars.json file, and are referred to as var.<name>, as in hcl eval; the
+functions hcl functions list prints are available. For compatibility, a
+variable may also be referred to by its bare name, unless it is named var. The expression is named <expression> in
+diagnostics, which are printed whatever their severity. If the expression fails
+to parse or evaluate, "success" is false, "value" and "type" are left out, and
+the command exits non-zero.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			filename := "<expression>"
+			src := []byte(args[0])
+			sources := map[string][]byte{filename: src}
+
+			variables := map[string]cty.Value{}
+			var diags hcl.Diagnostics
+			if varsPath != "" {
+				content, err := readFileLimited(varsPath)
+				if err != nil {
+					return fmt.Errorf("failed to read variables: %w", err)
+				}
+				sources[hclSourceName(varsPath)] = content
+				variables, _, diags = parseTfvarsFile(hclSourceName(varsPath), content)
+			}
+
+			output := map[string]interface{}{}
+			if !diags.HasErrors() {
+				val, evalDiags := evalExpression(src, filename, variables)
+				diags = append(diags, evalDiags...)
+				if !evalDiags.HasErrors() {
+					valueJSON, typeJSON, err := typedValueToJSON(val)
+					if err != nil {
+						return err
+					}
+					output["value"] = valueJSON
+					output["type"] = typeJSON
+				}
+			}
+			output["success"] = !diags.HasErrors()
+			output["diagnostics"] = hcltools.DiagnosticsToJSONWithSource(diags, sources)
+
+			if err := json.NewEncoder(os.Stdout).Encode(output); err != nil {
+				return fmt.Errorf("failed to encode JSON: %w", err)
+			}
+			if diags.HasErrors() {
+				return fmt.Errorf("failed to evaluate expression: %s", diags.Error())
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&varsPath, "vars", "", "Variables file (.tfvars or .tfvars.json)")
+	return cmd
+}
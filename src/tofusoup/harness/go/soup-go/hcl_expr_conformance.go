@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+	"gopkg.in/yaml.v3"
+)
+
+// Expected outcomes of an expression conformance case
+const (
+	exprExpectValue = "value"
+	exprExpectError = "error"
+)
+
+// exprSuite is the suite file of `hcl expr conformance`
+type exprSuite struct {
+	// Variables are available to every case, as attributes of var
+	Variables map[string]json.RawMessage `json:"variables,omitempty"`
+	Cases     []exprCase                 `json:"cases"`
+}
+
+// exprCase is an expression with its expected result
+type exprCase struct {
+	Name string `json:"name"`
+	Expr string `json:"expr"`
+	// Variables are added to, or replace, the suite's for this case
+	Variables map[string]json.RawMessage `json:"variables,omitempty"`
+	// Expect is value (the default) or error
+	Expect string `json:"expect,omitempty"`
+	// Value and Type, if set, must match the result of a value case
+	Value json.RawMessage `json:"value,omitempty"`
+	Type  json.RawMessage `json:"type,omitempty"`
+	// Error, if set, must appear in the diagnostics of an error case
+	Error string `json:"error,omitempty"`
+}
+
+// exprCaseResult is the line `hcl expr conformance` writes per case
+type exprCaseResult struct {
+	Index    int             `json:"index"`
+	Name     string          `json:"name,omitempty"`
+	Expr     string          `json:"expr"`
+	Pass     bool            `json:"pass"`
+	Expected string          `json:"expected"`
+	Outcome  string          `json:"outcome,omitempty"`
+	Value    json.RawMessage `json:"value,omitempty"`
+	Type     json.RawMessage `json:"type,omitempty"`
+	Error    string          `json:"error,omitempty"`
+	// Reason is why a case failed
+	Reason string `json:"reason,omitempty"`
+}
+
+// readExprSuite reads a suite file, as YAML if its extension is .yaml or
+// .yml and as JSON otherwise
+func readExprSuite(path string) (exprSuite, error) {
+	var suite exprSuite
+	data, err := readFileLimited(path)
+	if err != nil {
+		return suite, fmt.Errorf("failed to read suite: %w", err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		var doc interface{}
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return suite, fmt.Errorf("failed to parse suite YAML: %w", err)
+		}
+		if data, err = json.Marshal(doc); err != nil {
+			return suite, fmt.Errorf("failed to parse suite YAML: %w", err)
+		}
+	}
+	if err := json.Unmarshal(data, &suite); err != nil {
+		return suite, fmt.Errorf("failed to parse suite: %w", err)
+	}
+	return suite, nil
+}
+
+// exprVariables converts JSON variables to values of the types go-cty
+// infers from their JSON
+func exprVariables(vars map[string]json.RawMessage, into map[string]cty.Value) error {
+	for name, data := range vars {
+		ty, err := ctyjson.ImpliedType(data)
+		if err != nil {
+			return fmt.Errorf("variable %s: %w", name, err)
+		}
+		val, err := ctyjson.Unmarshal(data, ty)
+		if err != nil {
+			return fmt.Errorf("variable %s: %w", name, err)
+		}
+		into[name] = val
+	}
+	return nil
+}
+
+// sameJSON reports whether two JSON documents hold the same value
+func sameJSON(a, b json.RawMessage) bool {
+	var av, bv interface{}
+	if json.Unmarshal(a, &av) != nil || json.Unmarshal(b, &bv) != nil {
+		return false
+	}
+	return reflect.DeepEqual(av, bv)
+}
+
+// runExprCase evaluates the expression of c with the suite's and its own
+// variables and compares the outcome with the expected one
+func runExprCase(suite exprSuite, index int, c exprCase) exprCaseResult {
+	result := exprCaseResult{Index: index, Name: c.Name, Expr: c.Expr, Expected: c.Expect}
+	if result.Expected == "" {
+		result.Expected = exprExpectValue
+	}
+	if result.Expected != exprExpectValue && result.Expected != exprExpectError {
+		result.Reason = fmt.Sprintf("unsupported expect: %s", c.Expect)
+		return result
+	}
+	if c.Expr == "" {
+		result.Reason = "case has no expression"
+		return result
+	}
+
+	variables := map[string]cty.Value{}
+	if err := exprVariables(suite.Variables, variables); err != nil {
+		result.Reason = fmt.Sprintf("invalid suite variables: %v", err)
+		return result
+	}
+	if err := exprVariables(c.Variables, variables); err != nil {
+		result.Reason = fmt.Sprintf("invalid case variables: %v", err)
+		return result
+	}
+
+	val, diags := evalExpression([]byte(c.Expr), "<expression>", variables)
+	if diags.HasErrors() {
+		result.Outcome = exprExpectError
+		result.Error = diags.Error()
+	} else {
+		valueJSON, typeJSON, err := typedValueToJSON(val)
+		if err != nil {
+			result.Reason = err.Error()
+			return result
+		}
+		result.Outcome = exprExpectValue
+		result.Value, result.Type = valueJSON, typeJSON
+	}
+
+	switch {
+	case result.Outcome != result.Expected:
+		result.Reason = fmt.Sprintf("expected %s, got %s", result.Expected, result.Outcome)
+	case c.Error != "" && !strings.Contains(result.Error, c.Error):
+		result.Reason = fmt.Sprintf("error does not contain %q", c.Error)
+	case len(c.Value) > 0 && result.Outcome == exprExpectValue && !sameJSON(c.Value, result.Value):
+		result.Reason = fmt.Sprintf("expected value %s", c.Value)
+	case len(c.Type) > 0 && result.Outcome == exprExpectValue:
+		ty, err := parseCtyType(c.Type)
+		if err != nil {
+			result.Reason = fmt.Sprintf("failed to parse type: %v", err)
+		} else if !ty.Equals(val.Type()) {
+			result.Reason = fmt.Sprintf("expected type %s, got %s", ty.FriendlyName(), val.Type().FriendlyName())
+		} else {
+			result.Pass = true
+		}
+	default:
+		result.Pass = true
+	}
+	return result
+}
+
+// initHclExprConformanceCmd creates the `hcl expr conformance` command
+func initHclExprConformanceCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "conformance <suite>",
+		Short: "Evaluate a suite of expressions against their expected results",
+		Long: `Run the cases of a suite file, each an HCL expression evaluated as hcl expr
+eval does with its expected result, to compare for, splat and conditional
+expression semantics with other implementations. The suite is YAML if the
+file's extension is .yaml or .yml, and JSON otherwise:
+
+  variables:                       # available to every case, as var.<name>
+    subnets: [{"id": "a", "public": true}, {"id": "b", "public": false}]
+  cases:
+    - name: splat
+      expr: var.subnets[*].id
+      value: ["a", "b"]
+      type: tuple([string, string])
+    - name: filtered for
+      expr: '{for s in var.subnets : s.id => s if s.public}'
+    - name: conditional types must unify
+      expr: 'true ? 1 : [1]'
+      variables: {}                # added to, or replacing, the suite's
+      expect: error                # value (the default) or error
+      error: Inconsistent          # must appear in the diagnostics
+
+Variables are JSON values of the types go-cty infers from them; value is
+compared as JSON, and type, a JSON type specification or HCL type expression,
+exactly. One JSON line is written per case,
+  {"index": 0, "name": "splat", "expr": "...", "pass": true, "expected": "value",
+   "outcome": "value", "value": ["a", "b"], "type": ["tuple", ["string", "string"]]}
+with the diagnostics of an expression that failed as error and, for a case
+that failed, the reason. The command exits non-zero if any case failed.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			suite, err := readExprSuite(args[0])
+			if err != nil {
+				return err
+			}
+
+			w := newJSONLWriter(os.Stdout, outputBufferOptions{})
+			failed := 0
+			for i, c := range suite.Cases {
+				result := runExprCase(suite, i, c)
+				if !result.Pass {
+					failed++
+				}
+				if err := w.WriteLine(result); err != nil {
+					return fmt.Errorf("failed to write result: %w", err)
+				}
+			}
+			if err := w.Flush(); err != nil {
+				return fmt.Errorf("failed to write result: %w", err)
+			}
+
+			logger.Info("🧪 expression conformance suite complete", "cases", len(suite.Cases), "failed", failed)
+			if failed > 0 {
+				return fmt.Errorf("%d of %d cases failed", failed, len(suite.Cases))
+			}
+			return nil
+		},
+	}
+	return cmd
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/cobra"
+)
+
+// formatHCLFile returns the canonical formatting of an HCL file, failing on
+// syntax errors, which hclwrite's formatter would otherwise pass through
+func formatHCLFile(filename string, src []byte) ([]byte, error) {
+	_, diags := hclwrite.ParseConfig(src, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("HCL parse errors: %s", diags.Error())
+	}
+	return hclwrite.Format(src), nil
+}
+
+// initHclFmtCmd creates the `hcl fmt` command
+func initHclFmtCmd() *cobra.Command {
+	var check bool
+	var diff bool
+
+	cmd := &cobra.Command{
+		Use:   "fmt [files...]",
+		Short: "Rewrite HCL files in canonical format",
+		Long: `Rewrite HCL files in the canonical format of hclwrite's formatter, as
+terraform fmt does, printing the name of each file that changed. With no files,
+or "-", the source is read from stdin and the formatted result written to
+stdout.
+
+--check writes no files and exits non-zero if any file is not formatted, to
+verify the formatting stability of generated fixtures. --diff prints a unified
+diff of the changes each file needs in place of its name, or for stdin in
+place of the formatted result. Files with syntax errors fail without being rewritten.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				args = []string{"-"}
+			}
+
+			unformatted := 0
+			failed := 0
+			for _, filename := range args {
+				src, err := readFileLimited(filename)
+				if err != nil {
+					logger.Error("💥 failed to read file", "file", filename, "error", err)
+					failed++
+					continue
+				}
+				formatted, err := formatHCLFile(filename, src)
+				if err != nil {
+					logger.Error("💥 failed to format file", "file", filename, "error", err)
+					failed++
+					continue
+				}
+				changed := string(formatted) != string(src)
+				if changed {
+					unformatted++
+				}
+
+				if diff && changed {
+					text, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+						A:        difflib.SplitLines(string(src)),
+						B:        difflib.SplitLines(string(formatted)),
+						FromFile: "old/" + filename,
+						ToFile:   "new/" + filename,
+						Context:  3,
+					})
+					if err != nil {
+						return fmt.Errorf("failed to diff %s: %w", filename, err)
+					}
+					fmt.Print(text)
+				}
+				if filename == "-" {
+					if !check && !diff {
+						if _, err := os.Stdout.Write(formatted); err != nil {
+							return fmt.Errorf("failed to write output: %w", err)
+						}
+					}
+					continue
+				}
+				if !changed {
+					continue
+				}
+				if !check {
+					if err := os.WriteFile(filename, formatted, 0644); err != nil {
+						logger.Error("💥 failed to write file", "file", filename, "error", err)
+						failed++
+						continue
+					}
+				}
+				if !diff {
+					fmt.Println(filename)
+				}
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("%d of %d files failed", failed, len(args))
+			}
+			if check && unformatted > 0 {
+				return fmt.Errorf("%d of %d files are not formatted", unformatted, len(args))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&check, "check", false, "Write no files and exit non-zero if any file is not formatted")
+	cmd.Flags().BoolVar(&diff, "diff", false, "Print a unified diff of the formatting changes")
+	return cmd
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// initHclFunctionsListCmd creates the `hcl functions list` command
+func initHclFunctionsListCmd() *cobra.Command {
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the functions available for evaluation",
+		Long: `List the functions soup-go evaluates HCL expressions with, in hcl eval and
+hcl template eval, and serves as provider functions, so other harnesses can
+check that they expose the same function surface.
+
+By default one signature is printed per line:
+  join(separator string, ...lists list of string) string
+With --json, {"functions": [...]} is printed with each function's name,
+description, parameters, variadic parameter (null if there is none) and
+return type, types as JSON type specifications. Parameters also give whether
+they accept null, unknown, dynamically typed and marked values. Return types
+that depend on the arguments are "dynamic".`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if jsonOutput {
+				signatures, err := ctyFunctionSignatures()
+				if err != nil {
+					return fmt.Errorf("failed to describe functions: %w", err)
+				}
+				output := map[string]interface{}{
+					"functions": signatures,
+				}
+				if err := json.NewEncoder(os.Stdout).Encode(output); err != nil {
+					return fmt.Errorf("failed to encode JSON: %w", err)
+				}
+				return nil
+			}
+
+			funcs := ctyFunctions()
+			for _, name := range ctyFunctionNames() {
+				fmt.Println(functionSignatureText(name, funcs[name]))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Print the signatures as JSON")
+	return cmd
+}
+
+// functionSignatureText renders a function's signature as a line of text
+func functionSignatureText(name string, fn function.Function) string {
+	var params []string
+	for _, param := range fn.Params() {
+		params = append(params, param.Name+" "+param.Type.FriendlyNameForConstraint())
+	}
+	if varParam := fn.VarParam(); varParam != nil {
+		params = append(params, "..."+varParam.Name+" "+varParam.Type.FriendlyNameForConstraint())
+	}
+	return fmt.Sprintf("%s(%s) %s", name, strings.Join(params, ", "), functionReturnType(fn).FriendlyNameForConstraint())
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/hcltools"
+)
+
+// initHclGenerateCmd creates the `hcl generate` command
+func initHclGenerateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "generate <input.json> <output.hcl>",
+		Short: "Generate formatted HCL from a JSON document",
+		Long: `Render a JSON document as formatted native syntax HCL with hclwrite, the
+reverse of hcl convert, to produce fixtures for other parsers. The document
+takes the form hcl convert writes: members are attributes, written sorted by
+name, and "blocks" is a list of nested blocks,
+  {"region": "us-east-1",
+   "blocks": [{"type": "resource", "labels": ["aws_instance", "web"],
+               "body": {"ami": "ami-123", "blocks": []}}]}
+Attribute values are written as HCL literals of the type go-cty infers from
+their JSON.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := readFileLimited(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to read input file: %w", err)
+			}
+			output, err := hcltools.GenerateHCL(data, decodeLimits)
+			if err != nil {
+				return fmt.Errorf("failed to generate HCL: %w", err)
+			}
+			if err := writeStreamOutput(args[1], output); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
+			}
+			return nil
+		},
+	}
+	return cmd
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/hcltools"
+)
+
+// initHclRefsCmd creates the `hcl refs` command
+func initHclRefsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "refs <file>",
+		Short: "List the variable traversals in an HCL file",
+		Long: `Parse an HCL file and list every variable traversal found in its attribute
+expressions, in source order, for dependency analysis tests across harness
+languages:
+
+  {"success": true, "references": [
+    {"root": "var", "path": "var.subnets[0].id", "range": {...},
+     "attribute": "resource.aws_instance.web.subnet_id"}]}
+
+The attribute is the address of the attribute holding the expression, its
+enclosing blocks' types and labels and its name joined with dots. A file that
+does not parse prints {"success": false, "errors": [...]} and the command exits
+non-zero.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			content, err := readFileLimited(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to read file: %w", err)
+			}
+			filename := hclSourceName(args[0])
+			file, diags := parseHCLCached(content, filename, false)
+			if diags.HasErrors() {
+				errorOutput := map[string]interface{}{
+					"success": false,
+					"errors":  hcltools.DiagnosticsToJSONWithSource(diags, map[string][]byte{filename: content}),
+				}
+				if err := json.NewEncoder(os.Stdout).Encode(errorOutput); err != nil {
+					return fmt.Errorf("failed to encode JSON: %w", err)
+				}
+				return fmt.Errorf("HCL parse errors: %s", diags.Error())
+			}
+
+			refs, err := hcltools.FileReferences(file, decodeLimits)
+			if err != nil {
+				return fmt.Errorf("failed to list references: %w", err)
+			}
+			output := map[string]interface{}{
+				"success":    true,
+				"references": refs,
+			}
+			if err := json.NewEncoder(os.Stdout).Encode(output); err != nil {
+				return fmt.Errorf("failed to encode JSON: %w", err)
+			}
+			return nil
+		},
+	}
+	return cmd
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/hcltools"
+)
+
+// initHclSchemaExtractCmd creates the `hcl schema-extract` command
+func initHclSchemaExtractCmd() *cobra.Command {
+	var schemaPath string
+
+	cmd := &cobra.Command{
+		Use:   "schema-extract --schema <schema.json> <file>",
+		Short: "Extract the blocks and attributes a schema declares from an HCL file",
+		Long: `Decode an HCL file with the body schema in --schema using PartialContent, the
+schema-tolerant decoding providers and tools rely on, and print only the
+content the schema declares, with what it left over. The schema is JSON:
+
+  {"attributes": [{"name": "region", "required": true}],
+   "blocks": [{"type": "resource", "labels": ["type", "name"],
+               "body": {"attributes": [{"name": "ami"}]}}]}
+
+A block type's body, if given, is extracted from its blocks' bodies in turn.
+The result is
+  {"success": true, "content": {"attributes": {"region": {"value": ..., "range": ...}},
+   "blocks": [{"type": ..., "labels": [...], "range": ..., "content": {...}}],
+   "leftover": {"attributes": {...}, "blocks": [...]}}}
+at every level, with attribute values where they evaluate without variables or
+functions. Files ending in .json are parsed as JSON syntax, where leftover
+blocks cannot be told from attributes and are reported as attributes. If the
+file does not parse or does not fit the schema, such as when a required
+attribute is missing, "success" is false with the diagnostics as "errors",
+and the command exits non-zero.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			schemaData, err := readFileLimited(schemaPath)
+			if err != nil {
+				return fmt.Errorf("failed to read schema: %w", err)
+			}
+			schema, err := hcltools.ParseBodySchema(schemaData, decodeLimits)
+			if err != nil {
+				return fmt.Errorf("failed to parse schema: %w", err)
+			}
+
+			content, err := readFileLimited(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to read file: %w", err)
+			}
+			filename := hclSourceName(args[0])
+			dialect := hcltools.DialectFor(filename)
+			file, diags := parseHCLCached(content, filename, dialect == hcltools.DialectJSON)
+
+			output := map[string]interface{}{}
+			if !diags.HasErrors() {
+				extracted, extractDiags, err := hcltools.ExtractContent(file.Body, schema, decodeLimits)
+				if err != nil {
+					return fmt.Errorf("failed to extract content: %w", err)
+				}
+				diags = append(diags, extractDiags...)
+				output["content"] = extracted
+			}
+			output["success"] = !diags.HasErrors()
+			if len(diags) > 0 {
+				output["errors"] = hcltools.DiagnosticsToJSONWithSource(diags, map[string][]byte{filename: content})
+			}
+			if err := json.NewEncoder(os.Stdout).Encode(output); err != nil {
+				return fmt.Errorf("failed to encode JSON: %w", err)
+			}
+			if diags.HasErrors() {
+				return fmt.Errorf("failed to extract %s: %s", filename, diags.Error())
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&schemaPath, "schema", "", "JSON body schema file")
+	cmd.MarkFlagRequired("schema")
+	return cmd
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/spf13/cobra"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/hcltools"
+)
+
+// evalTemplate renders an HCL string template with the given variables and
+// the functions of ctyFunctions. The result must convert to a string, as for Terraform's
+// templatefile.
+func evalTemplate(src []byte, filename string, variables map[string]cty.Value) (string, hcl.Diagnostics) {
+	expr, diags := hclsyntax.ParseTemplate(src, filename, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return "", diags
+	}
+	val, valDiags := expr.Value(&hcl.EvalContext{Variables: variables, Functions: ctyFunctions()})
+	diags = append(diags, valDiags...)
+	if diags.HasErrors() {
+		return "", diags
+	}
+
+	val, err := convert.Convert(val, cty.String)
+	if err == nil && (val.IsNull() || !val.IsWhollyKnown()) {
+		err = fmt.Errorf("result must not be null or unknown")
+	}
+	if err != nil {
+		return "", append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid template result",
+			Detail:   fmt.Sprintf("The template result cannot be used as a string: %s.", err),
+			Subject:  expr.Range().Ptr(),
+		})
+	}
+	val, _ = val.Unmark()
+	return val.AsString(), diags
+}
+
+// initHclTemplateEvalCmd creates the `hcl template eval` command
+func initHclTemplateEvalCmd() *cobra.Command {
+	var templatePath, varsPath string
+	var outputFormat string
+
+	cmd := &cobra.Command{
+		Use:   "eval [template]",
+		Short: "Render an HCL string template",
+		Long: `Render an HCL string template, given as an argument or read from --file, and
+print the result. Templates use interpolation, ${name}, and the %{if} and
+%{for} directives, with ~ trimming whitespace; their semantics differ between
+implementations and need dedicated coverage.
+
+Variables come from --vars, a .tfvars file or, for files ending in .json, a
+.tfvars.json file, and are referred to by name as in Terraform's templatefile:
+  soup-go hcl template eval --vars vars.tfvars '%{ for s in subnets ~}${s} %{ endfor ~}'
+The functions hcl functions list prints are available. The result must
+convert to a string.
+
+With --output-format text (the default) the result is printed as is and errors
+are rendered to stderr with their source lines; with json, {"success": true, "result": "..."} is printed,
+or {"success": false, "errors": [...]} with the diagnostics. The command exits
+non-zero if the template fails to parse or render.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if outputFormat != "text" && outputFormat != "json" {
+				return fmt.Errorf("unsupported output format: %s", outputFormat)
+			}
+			if (len(args) == 1) == (templatePath != "") {
+				return fmt.Errorf("give the template either as an argument or with --file")
+			}
+			filename := "<template>"
+			var src []byte
+			if templatePath != "" {
+				var err error
+				if src, err = readFileLimited(templatePath); err != nil {
+					return fmt.Errorf("failed to read template: %w", err)
+				}
+				filename = hclSourceName(templatePath)
+			} else {
+				src = []byte(args[0])
+			}
+
+			sources := map[string][]byte{filename: src}
+			variables := map[string]cty.Value{}
+			var diags hcl.Diagnostics
+			if varsPath != "" {
+				content, err := readFileLimited(varsPath)
+				if err != nil {
+					return fmt.Errorf("failed to read variables: %w", err)
+				}
+				sources[hclSourceName(varsPath)] = content
+				variables, _, diags = parseTfvarsFile(hclSourceName(varsPath), content)
+			}
+
+			result := ""
+			if !diags.HasErrors() {
+				var evalDiags hcl.Diagnostics
+				result, evalDiags = evalTemplate(src, filename, variables)
+				diags = append(diags, evalDiags...)
+			}
+
+			if diags.HasErrors() {
+				if outputFormat == "text" {
+					if err := hcltools.WriteDiagnostics(os.Stderr, diags, sources, diagnosticContextLines); err != nil {
+						return fmt.Errorf("failed to write diagnostics: %w", err)
+					}
+				} else {
+					errorOutput := map[string]interface{}{
+						"success": false,
+						"errors":  hcltools.DiagnosticsToJSONWithSource(diags, sources),
+					}
+					if err := json.NewEncoder(os.Stdout).Encode(errorOutput); err != nil {
+						return fmt.Errorf("failed to encode JSON: %w", err)
+					}
+				}
+				return fmt.Errorf("failed to render template")
+			}
+
+			if outputFormat == "text" {
+				if _, err := os.Stdout.WriteString(result); err != nil {
+					return fmt.Errorf("failed to write output: %w", err)
+				}
+				return nil
+			}
+			output := map[string]interface{}{
+				"success": true,
+				"result":  result,
+			}
+			if err := json.NewEncoder(os.Stdout).Encode(output); err != nil {
+				return fmt.Errorf("failed to encode JSON: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&templatePath, "file", "", "Read the template from this file")
+	cmd.Flags().StringVar(&varsPath, "vars", "", "Variables file (.tfvars or .tfvars.json)")
+	cmd.Flags().StringVar(&outputFormat, "output-format", "text", "Output format (text, json)")
+	return cmd
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/spf13/cobra"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/ctyspec"
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/hcltools"
+)
+
+// VariableSchema declares an input variable the way a variable block does
+type VariableSchema struct {
+	// Type is either a type constraint expression such as
+	// "list(object({name = string, port = optional(number, 80)}))" or a
+	// cty JSON type such as ["list", "string"]
+	Type      json.RawMessage `json:"type,omitempty"`
+	Default   json.RawMessage `json:"default,omitempty"`
+	Sensitive bool            `json:"sensitive,omitempty"`
+	Nullable  *bool           `json:"nullable,omitempty"`
+}
+
+// VariablesSchema is the --schema file of `hcl tfvars`
+type VariablesSchema struct {
+	Variables map[string]*VariableSchema `json:"variables"`
+}
+
+// declaredVariable is a variable schema with its type constraint resolved
+type declaredVariable struct {
+	constraint cty.Type
+	defaults   *typeexpr.Defaults
+	def        cty.Value
+	hasDefault bool
+	sensitive  bool
+	nullable   bool
+}
+
+// loadVariablesSchema reads and resolves a variables schema file
+func loadVariablesSchema(path string) (map[string]*declaredVariable, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read variables schema: %w", err)
+	}
+	var schema VariablesSchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("failed to parse variables schema: %w", err)
+	}
+
+	vars := make(map[string]*declaredVariable, len(schema.Variables))
+	for name, vs := range schema.Variables {
+		if vs == nil {
+			vs = &VariableSchema{}
+		}
+		decl, err := resolveVariableSchema(vs)
+		if err != nil {
+			return nil, fmt.Errorf("variable %q: %w", name, err)
+		}
+		vars[name] = decl
+	}
+	return vars, nil
+}
+
+// resolveVariableSchema parses a variable's type constraint and default
+func resolveVariableSchema(vs *VariableSchema) (*declaredVariable, error) {
+	decl := &declaredVariable{
+		constraint: cty.DynamicPseudoType,
+		sensitive:  vs.Sensitive,
+		nullable:   vs.Nullable == nil || *vs.Nullable,
+	}
+
+	if len(vs.Type) > 0 {
+		var expr string
+		if err := json.Unmarshal(vs.Type, &expr); err == nil {
+			ty, defaults, err := ctyspec.ParseTypeConstraint(expr)
+			if err != nil {
+				return nil, err
+			}
+			decl.constraint, decl.defaults = ty, defaults
+		} else {
+			ty, err := parseCtyType(vs.Type)
+			if err != nil {
+				return nil, fmt.Errorf("invalid type: %w", err)
+			}
+			decl.constraint = ty
+		}
+	}
+
+	if len(vs.Default) > 0 {
+		implied, err := ctyjson.ImpliedType(vs.Default)
+		if err != nil {
+			return nil, fmt.Errorf("invalid default: %w", err)
+		}
+		raw, err := ctyjson.Unmarshal(vs.Default, implied)
+		if err != nil {
+			return nil, fmt.Errorf("invalid default: %w", err)
+		}
+		def, err := decl.convert(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid default: %w", err)
+		}
+		decl.def, decl.hasDefault = def, true
+	}
+	return decl, nil
+}
+
+// convert applies optional attribute defaults and then the type constraint,
+// in the order Terraform uses for input variables
+func (d *declaredVariable) convert(val cty.Value) (cty.Value, error) {
+	if d.defaults != nil && !val.IsNull() {
+		val = d.defaults.Apply(val)
+	}
+	return convert.Convert(val, d.constraint)
+}
+
+// parseTfvarsFile parses a .tfvars or .tfvars.json file into its raw
+// attribute values. Like Terraform, values may not refer to variables or
+// call functions.
+func parseTfvarsFile(filename string, content []byte) (map[string]cty.Value, map[string]*hcl.Attribute, hcl.Diagnostics) {
+	file, diags := parseHCLCached(content, filename, strings.HasSuffix(filename, ".json"))
+	if diags.HasErrors() {
+		return nil, nil, diags
+	}
+
+	attrs, attrDiags := file.Body.JustAttributes()
+	diags = append(diags, attrDiags...)
+
+	values := make(map[string]cty.Value, len(attrs))
+	for name, attr := range attrs {
+		val, valDiags := attr.Expr.Value(nil)
+		diags = append(diags, valDiags...)
+		if !valDiags.HasErrors() {
+			values[name] = val
+		}
+	}
+	return values, attrs, diags
+}
+
+// applyVariableSchema types tfvars values by their declarations, filling in
+// defaults for variables the file does not set. Variables that are neither
+// set nor defaulted are returned as unset.
+func applyVariableSchema(values map[string]cty.Value, attrs map[string]*hcl.Attribute, vars map[string]*declaredVariable) (map[string]cty.Value, map[string]string, []string, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	result := make(map[string]cty.Value, len(vars))
+	sources := make(map[string]string, len(vars))
+	unset := []string{}
+
+	for _, name := range sortedKeys(values) {
+		if _, declared := vars[name]; !declared {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  "Value for undeclared variable",
+				Detail:   fmt.Sprintf("The file assigns a value to %q, but no variable of that name is declared.", name),
+				Subject:  attrs[name].NameRange.Ptr(),
+			})
+		}
+	}
+
+	for _, name := range sortedKeys(vars) {
+		decl := vars[name]
+		val, set := values[name]
+		if set && val.IsNull() && !decl.nullable {
+			// A null for a non-nullable variable selects its default
+			set = false
+			if !decl.hasDefault {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Required variable not set",
+					Detail:   fmt.Sprintf("Unsuitable value for var.%s: the variable is not nullable and has no default.", name),
+					Subject:  attrs[name].Expr.Range().Ptr(),
+				})
+				continue
+			}
+		}
+
+		if !set {
+			if decl.hasDefault {
+				result[name] = decl.def
+				sources[name] = "default"
+			} else if _, given := values[name]; !given {
+				unset = append(unset, name)
+			}
+			continue
+		}
+
+		converted, err := decl.convert(val)
+		if err != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid value for input variable",
+				Detail:   fmt.Sprintf("The given value is not suitable for var.%s: %s.", name, err),
+				Subject:  attrs[name].Expr.Range().Ptr(),
+			})
+			continue
+		}
+		result[name] = converted
+		sources[name] = "file"
+	}
+	return result, sources, unset, diags
+}
+
+// initHclTfvarsCmd creates the `hcl tfvars` command
+func initHclTfvarsCmd() *cobra.Command {
+	var schemaPath string
+
+	cmd := &cobra.Command{
+		Use:   "tfvars [file]",
+		Short: "Parse a .tfvars or .tfvars.json file to typed values",
+		Long: `Parse a variable definitions file the way Terraform does and emit each value
+as cty JSON with its type. Files ending in .json use the JSON syntax. With
+--schema, values are converted to the declared variable types after optional
+attribute defaults are applied, and unset variables take their defaults.
+
+The schema is a JSON object of the form:
+  {"variables": {"name": {"type": "list(string)", "default": [],
+                          "sensitive": false, "nullable": true}}}
+where type is a type constraint expression or a cty JSON type.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			content, err := readFileLimited(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to read file: %w", err)
+			}
+			filename := hclSourceName(args[0])
+
+			var vars map[string]*declaredVariable
+			if schemaPath != "" {
+				vars, err = loadVariablesSchema(schemaPath)
+				if err != nil {
+					return err
+				}
+			}
+
+			values, attrs, diags := parseTfvarsFile(filename, content)
+			sources := make(map[string]string, len(values))
+			unset := []string{}
+			if !diags.HasErrors() {
+				if vars != nil {
+					var schemaDiags hcl.Diagnostics
+					values, sources, unset, schemaDiags = applyVariableSchema(values, attrs, vars)
+					diags = append(diags, schemaDiags...)
+				} else {
+					for name := range values {
+						sources[name] = "file"
+					}
+				}
+			}
+
+			variables := make(map[string]interface{}, len(values))
+			for name, val := range values {
+				valueJSON, err := ctyjson.Marshal(val, val.Type())
+				if err != nil {
+					return fmt.Errorf("failed to marshal var.%s: %w", name, err)
+				}
+				typeJSON, err := ctyjson.MarshalType(val.Type())
+				if err != nil {
+					return fmt.Errorf("failed to marshal type of var.%s: %w", name, err)
+				}
+				entry := map[string]interface{}{
+					"value":  json.RawMessage(valueJSON),
+					"type":   json.RawMessage(typeJSON),
+					"source": sources[name],
+				}
+				if decl, ok := vars[name]; ok && decl.sensitive {
+					entry["sensitive"] = true
+				}
+				variables[name] = entry
+			}
+
+			result := map[string]interface{}{
+				"valid":     !diags.HasErrors(),
+				"variables": variables,
+			}
+			if vars != nil {
+				result["unset"] = unset
+			}
+			if len(diags) > 0 {
+				result["diagnostics"] = hcltools.DiagnosticsToJSONWithSource(diags, map[string][]byte{filename: content})
+			}
+
+			if err := json.NewEncoder(os.Stdout).Encode(result); err != nil {
+				return fmt.Errorf("failed to encode JSON: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&schemaPath, "schema", "", "Path to variables schema JSON declaring variable types and defaults")
+
+	return cmd
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/spf13/cobra"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/ctyspec"
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/hcltools"
+)
+
+// initHclToCtyCmd creates the `hcl to-cty` command
+func initHclToCtyCmd() *cobra.Command {
+	var typeJSON string
+	var outputFormat string
+
+	cmd := &cobra.Command{
+		Use:   "to-cty <file> [output]",
+		Short: "Decode an HCL file body into a value of a CTY object type",
+		Long: `Decode the body of an HCL file into a value of the object type --type and
+write it to [output] (default stdout) as JSON or msgpack, the encodings of the
+wire commands. Each attribute of the type is an attribute of the body,
+converted to its type, and required unless it is optional; an optional
+attribute that is absent is null. Unlike hcl convert, which infers types from
+evaluated values, values keep the declared types, so numbers, sets and maps
+survive the trip.
+
+Files ending in .json, such as .tf.json, are parsed as JSON syntax.
+Expressions are evaluated without variables or functions. If the file does not
+parse, holds blocks or attributes the type does not declare, or has values that
+do not convert, {"success": false, "errors": [...]} is printed with the
+diagnostics and the command exits non-zero.`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ty, err := parseCtyType(json.RawMessage(typeJSON))
+			if err != nil {
+				return fmt.Errorf("failed to parse type: %w", err)
+			}
+			spec, err := hcltools.ObjectTypeSpec(ty)
+			if err != nil {
+				return err
+			}
+
+			content, err := readFileLimited(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to read file: %w", err)
+			}
+			filename := hclSourceName(args[0])
+			dialect := hcltools.DialectFor(filename)
+			file, diags := parseHCLCached(content, filename, dialect == hcltools.DialectJSON)
+			value := cty.NilVal
+			if !diags.HasErrors() {
+				var decodeDiags hcl.Diagnostics
+				value, decodeDiags = hcldec.Decode(file.Body, spec, nil)
+				diags = append(diags, decodeDiags...)
+			}
+			if diags.HasErrors() {
+				errorOutput := map[string]interface{}{
+					"success": false,
+					"errors":  hcltools.DiagnosticsToJSONWithSource(diags, map[string][]byte{filename: content}),
+				}
+				if err := json.NewEncoder(os.Stdout).Encode(errorOutput); err != nil {
+					return fmt.Errorf("failed to encode JSON: %w", err)
+				}
+				return fmt.Errorf("failed to decode %s: %s", filename, diags.Error())
+			}
+
+			valueType := ty.WithoutOptionalAttributesDeep()
+			if value, err = convert.Convert(value, valueType); err != nil {
+				return fmt.Errorf("failed to convert value: %w", err)
+			}
+			output, err := ctyspec.Encode(value, valueType, outputFormat, ctyOptions())
+			if err != nil {
+				return err
+			}
+			outputPath := "-"
+			if len(args) > 1 {
+				outputPath = args[1]
+			}
+			if err := writeStreamOutput(outputPath, output); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&typeJSON, "type", "", "CTY type specification as JSON or an HCL type expression")
+	cmd.Flags().StringVar(&outputFormat, "output-format", "json", "Output format (json, msgpack)")
+	return addTypeFileFlags(cmd, true, "type")
+}
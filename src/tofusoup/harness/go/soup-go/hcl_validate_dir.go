@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/hcltools"
+)
+
+// defaultValidateIncludes are the files `hcl validate` checks in a
+// directory when no --include is given
+var defaultValidateIncludes = []string{"*.hcl", "*.tf", "*.tf.json"}
+
+// hclValidateFile is the result of one file of a directory `hcl validate`
+type hclValidateFile struct {
+	Path    string                   `json:"path"`
+	Dialect string                   `json:"dialect,omitempty"`
+	Valid   bool                     `json:"valid"`
+	Errors  []map[string]interface{} `json:"errors,omitempty"`
+	// Error is why the file could not be read
+	Error string `json:"error,omitempty"`
+	// rendered holds the diagnostics for --output-format pretty
+	rendered string
+}
+
+// hclValidateReport is the aggregate report of a directory `hcl validate`
+type hclValidateReport struct {
+	Valid   bool              `json:"valid"`
+	Total   int               `json:"total"`
+	Invalid int               `json:"invalid"`
+	Files   []hclValidateFile `json:"files"`
+}
+
+// matchesGlob reports whether the slash-separated relative path rel
+// matches a pattern as for path.Match. Patterns without a slash match the
+// file's base name at any depth, and patterns with one the whole path.
+func matchesGlob(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		name := rel
+		if !strings.Contains(pattern, "/") {
+			name = path.Base(rel)
+		}
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// validateGlobs checks the --include and --exclude globs and returns the
+// includes, defaulted
+func validateGlobs(includes, excludes []string) ([]string, error) {
+	for _, pattern := range append(append([]string{}, includes...), excludes...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w", pattern, err)
+		}
+	}
+	if len(includes) == 0 {
+		includes = defaultValidateIncludes
+	}
+	return includes, nil
+}
+
+// walkValidateFiles calls visit with the slash-separated relative path of
+// every file under dir that matches includes and none of excludes
+func walkValidateFiles(dir string, includes, excludes []string, visit func(rel string, d fs.DirEntry) error) error {
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if matchesGlob(includes, rel) && !matchesGlob(excludes, rel) {
+			return visit(rel, d)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to walk %s: %w", dir, err)
+	}
+	return nil
+}
+
+// validateHCLFile validates the file rel of dir, rendering its diagnostics
+// when outputFormat is pretty
+func validateHCLFile(dir, rel, outputFormat string) hclValidateFile {
+	result := hclValidateFile{Path: rel, Dialect: hcltools.DialectFor(rel)}
+	filename := filepath.Join(dir, filepath.FromSlash(rel))
+	content, err := readFileLimited(filename)
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to read file: %v", err)
+		result.rendered = fmt.Sprintf("Error: %s: %s\n\n", rel, result.Error)
+		return result
+	}
+	_, diags := parseHCLCached(content, filename, result.Dialect == hcltools.DialectJSON)
+	result.Valid = !diags.HasErrors()
+	sources := map[string][]byte{filename: content}
+	if outputFormat == "pretty" {
+		var sb strings.Builder
+		hcltools.WriteDiagnostics(&sb, diags, sources, diagnosticContextLines)
+		result.rendered = sb.String()
+	} else if !result.Valid {
+		result.Errors = hcltools.DiagnosticsToJSONWithSource(diags, sources)
+	}
+	return result
+}
+
+// runHclValidateDir validates the files under dir that match includes and
+// none of excludes, using jobs workers, and writes the aggregate report,
+// or with outputFormat pretty the rendered diagnostics of every file.
+// An error is returned if any file is invalid.
+func runHclValidateDir(dir string, includes, excludes []string, jobs int, outputFormat string) error {
+	includes, err := validateGlobs(includes, excludes)
+	if err != nil {
+		return err
+	}
+	var files []string
+	err = walkValidateFiles(dir, includes, excludes, func(rel string, _ fs.DirEntry) error {
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	report := hclValidateReport{Valid: true, Files: []hclValidateFile{}}
+	var pretty strings.Builder
+	err = runOrdered(jobs, sliceItems(files), func(_ int, rel string) hclValidateFile {
+		return validateHCLFile(dir, rel, outputFormat)
+	}, func(result hclValidateFile) error {
+		report.Total++
+		if !result.Valid {
+			report.Invalid++
+			report.Valid = false
+		}
+		if outputFormat == "pretty" {
+			pretty.WriteString(result.rendered)
+		}
+		report.Files = append(report.Files, result)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Info("📁✅ directory validation complete", "total", report.Total, "invalid", report.Invalid)
+	if outputFormat == "pretty" {
+		if _, err := os.Stdout.WriteString(pretty.String()); err != nil {
+			return fmt.Errorf("failed to write diagnostics: %w", err)
+		}
+	} else if err := json.NewEncoder(os.Stdout).Encode(report); err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	if report.Invalid > 0 {
+		return fmt.Errorf("%d of %d files are not valid HCL", report.Invalid, report.Total)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+)
+
+// watchedFile is what `hcl validate --watch` compares between scans to
+// tell that a file changed
+type watchedFile struct {
+	modTime time.Time
+	size    int64
+}
+
+// hclWatchEvent is the line `hcl validate --watch` writes per validation
+// run
+type hclWatchEvent struct {
+	Run  int    `json:"run"`
+	Time string `json:"time"`
+	// Changed are the files added or modified since the last run, and
+	// Removed those deleted; the first run lists every file as changed
+	Changed []string          `json:"changed"`
+	Removed []string          `json:"removed"`
+	Files   []hclValidateFile `json:"files"`
+	// Total and Invalid count the files of the whole directory after the
+	// run
+	Total   int  `json:"total"`
+	Invalid int  `json:"invalid"`
+	Valid   bool `json:"valid"`
+}
+
+// scanWatchedFiles returns the modification time and size of every file
+// validate would check under dir
+func scanWatchedFiles(dir string, includes, excludes []string) (map[string]watchedFile, error) {
+	files := map[string]watchedFile{}
+	err := walkValidateFiles(dir, includes, excludes, func(rel string, d fs.DirEntry) error {
+		info, err := d.Info()
+		if err != nil {
+			// The file went away between listing and stat
+			return nil
+		}
+		files[rel] = watchedFile{modTime: info.ModTime(), size: info.Size()}
+		return nil
+	})
+	return files, err
+}
+
+// runHclValidateWatch validates the files under dir that match includes
+// and none of excludes, then polls the directory every interval and
+// re-validates the files that changed, writing one JSON line per run,
+// until interrupted
+func runHclValidateWatch(dir string, includes, excludes []string, jobs int, interval time.Duration) error {
+	includes, err := validateGlobs(includes, excludes)
+	if err != nil {
+		return err
+	}
+	if interval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+
+	w := newJSONLWriter(os.Stdout, outputBufferOptions{})
+	seen := map[string]watchedFile{}
+	invalid := map[string]bool{}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	logger.Info("👀 watching directory", "dir", dir, "interval", interval)
+	for run := 1; ; {
+		current, err := scanWatchedFiles(dir, includes, excludes)
+		if err != nil {
+			return err
+		}
+		event := hclWatchEvent{Changed: []string{}, Removed: []string{}, Files: []hclValidateFile{}}
+		for rel, file := range current {
+			if prev, ok := seen[rel]; !ok || prev != file {
+				event.Changed = append(event.Changed, rel)
+			}
+		}
+		for rel := range seen {
+			if _, ok := current[rel]; !ok {
+				event.Removed = append(event.Removed, rel)
+				delete(invalid, rel)
+			}
+		}
+		seen = current
+
+		if run == 1 || len(event.Changed) > 0 || len(event.Removed) > 0 {
+			sort.Strings(event.Changed)
+			sort.Strings(event.Removed)
+			err := runOrdered(jobs, sliceItems(event.Changed), func(_ int, rel string) hclValidateFile {
+				return validateHCLFile(dir, rel, "json")
+			}, func(result hclValidateFile) error {
+				if result.Valid {
+					delete(invalid, result.Path)
+				} else {
+					invalid[result.Path] = true
+				}
+				event.Files = append(event.Files, result)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			event.Run = run
+			event.Time = time.Now().UTC().Format(time.RFC3339Nano)
+			event.Total = len(seen)
+			event.Invalid = len(invalid)
+			event.Valid = event.Invalid == 0
+			if err := w.WriteLine(event); err != nil {
+				return fmt.Errorf("failed to write event: %w", err)
+			}
+			logger.Debug("👀 validation run complete", "run", run, "changed", len(event.Changed), "removed", len(event.Removed), "invalid", event.Invalid)
+			run++
+		}
+
+		select {
+		case <-stop:
+			logger.Info("👀🛑 stopped watching directory", "dir", dir)
+			return w.Flush()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// marshalYAML encodes the JSON representation of v as YAML. Numbers are
+// written as they appear in the JSON, so large and precise numbers keep
+// their exact value, object keys are sorted, and strings YAML 1.1 readers
+// would take for booleans are quoted.
+func marshalYAML(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	node, err := jsonToYAMLNode(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// jsonToYAMLNode converts a value decoded from JSON with UseNumber to a
+// YAML node
+func jsonToYAMLNode(v interface{}) (*yaml.Node, error) {
+	switch v := v.(type) {
+	case nil:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	case bool:
+		value := "false"
+		if v {
+			value = "true"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: value}, nil
+	case json.Number:
+		tag := "!!int"
+		if strings.ContainsAny(v.String(), ".eE") {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: v.String()}, nil
+	case string:
+		return yamlString(v), nil
+	case []interface{}:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, elem := range v {
+			child, err := jsonToYAMLNode(elem)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, child)
+		}
+		return node, nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, key := range keys {
+			child, err := jsonToYAMLNode(v[key])
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, yamlString(key), child)
+		}
+		return node, nil
+	}
+	return nil, fmt.Errorf("unsupported JSON value of type %T", v)
+}
+
+// yaml11Booleans are the plain scalars YAML 1.1 reads as booleans, which
+// YAML 1.2 encoders leave unquoted
+var yaml11Booleans = map[string]bool{
+	"y": true, "yes": true, "n": true, "no": true,
+	"on": true, "off": true, "true": true, "false": true,
+}
+
+// yamlString returns the YAML node of a string, quoted if a YAML 1.1
+// reader would take it for a boolean
+func yamlString(s string) *yaml.Node {
+	node := &yaml.Node{}
+	node.SetString(s)
+	if yaml11Booleans[strings.ToLower(s)] {
+		node.Style = yaml.DoubleQuotedStyle
+	}
+	return node
+}
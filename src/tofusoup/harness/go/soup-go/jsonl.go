@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultOutputBufferSize is the output buffer of JSONL commands
+const defaultOutputBufferSize = 256 * 1024
+
+// outputBufferOptions controls how a JSONL command buffers its output
+type outputBufferOptions struct {
+	// size is the buffer size in bytes; 0 writes every line through
+	size int
+	// flushEvery flushes the buffer after this many lines; 0 flushes only
+	// when the buffer fills and when the command finishes
+	flushEvery int
+}
+
+// addOutputBufferFlags registers --output-buffer-size and --flush-every on
+// a command that writes JSON lines
+func addOutputBufferFlags(cmd *cobra.Command, opts *outputBufferOptions) {
+	cmd.Flags().IntVar(&opts.size, "output-buffer-size", defaultOutputBufferSize, "Size in bytes of the output buffer (0 writes each line unbuffered)")
+	cmd.Flags().IntVar(&opts.flushEvery, "flush-every", 0, "Flush output after this many lines, for consumers reading results as they arrive (0 flushes only when the buffer fills)")
+}
+
+// jsonlWriter writes JSON lines through a buffer, flushing it when it
+// fills, every flushEvery lines and on Flush. Callers must call Flush once
+// done.
+type jsonlWriter struct {
+	buf        *bufio.Writer
+	encoder    *json.Encoder
+	flushEvery int
+	pending    int
+}
+
+func newJSONLWriter(w io.Writer, opts outputBufferOptions) *jsonlWriter {
+	l := &jsonlWriter{flushEvery: opts.flushEvery}
+	if opts.size > 0 {
+		l.buf = bufio.NewWriterSize(w, opts.size)
+		w = l.buf
+	}
+	l.encoder = json.NewEncoder(w)
+	return l
+}
+
+// WriteLine writes v as one JSON line
+func (l *jsonlWriter) WriteLine(v interface{}) error {
+	if err := l.encoder.Encode(v); err != nil {
+		return err
+	}
+	l.pending++
+	if l.flushEvery > 0 && l.pending >= l.flushEvery {
+		return l.Flush()
+	}
+	return nil
+}
+
+// Flush writes out any buffered lines
+func (l *jsonlWriter) Flush() error {
+	l.pending = 0
+	if l.buf == nil {
+		return nil
+	}
+	return l.buf.Flush()
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/kvplugin"
+)
+
+// parseAge parses a duration that may also use d (days) and w (weeks)
+// units, such as "7d" or "2w"
+func parseAge(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			v, err := strconv.ParseFloat(n, 64)
+			if err != nil || v < 0 {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			return time.Duration(v * float64(unit)), nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+// initKVGCCmd creates the `rpc kv gc` command
+func initKVGCCmd() *cobra.Command {
+	var olderThan string
+	var storageDir string
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Remove aged KV entries and compact the storage directory",
+		Long: `Remove entries of the file KV store that were last written longer than
+--older-than ago (units include d and w, e.g. 7d), then compact the storage
+directory by removing temporary files abandoned by interrupted writes and the
+lock and checksum files of keys that no longer exist. Reports the removed keys and reclaimed
+space as JSON.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			age, err := parseAge(olderThan)
+			if err != nil {
+				return err
+			}
+			if storageDir == "" {
+				storageDir = kvplugin.StorageDir()
+			}
+
+			report, err := kvplugin.NewKVImpl(logger.Named("kv"), storageDir).GC(age, dryRun)
+			if err != nil {
+				return err
+			}
+			logger.Info("🗄️🧹 kv gc complete",
+				"removed", len(report.RemovedKeys),
+				"reclaimed_bytes", report.ReclaimedBytes,
+				"dry_run", dryRun)
+
+			if err := json.NewEncoder(os.Stdout).Encode(report); err != nil {
+				return fmt.Errorf("failed to encode JSON: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&olderThan, "older-than", "7d", "Remove entries last written longer ago than this")
+	cmd.Flags().StringVar(&storageDir, "storage-dir", "", "KV storage directory (default: KV_STORAGE_DIR or the cache dir)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Report what would be removed without removing it")
+	return cmd
+}
@@ -4,10 +4,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-plugin"
 	"github.com/spf13/cobra"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/kvplugin"
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/ctyspec"
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/limits"
 )
 
 const version = "0.1.0"
@@ -19,6 +24,21 @@ var (
 	logger   hclog.Logger
 )
 
+// Decoding limits and cty value options, bound to the root flags and the
+// flags of the cty and wire commands in init
+var (
+	decodeLimits   = limits.DefaultConfig()
+	ctyFlagOptions = ctyspec.DefaultOptions()
+)
+
+// ctyOptions returns the ctyspec options set by the cty and wire command
+// flags, with the decoding limits of the root flags
+func ctyOptions() ctyspec.Options {
+	opts := ctyFlagOptions
+	opts.Limits = decodeLimits
+	return opts
+}
+
 // Root command
 var rootCmd = &cobra.Command{
 	Use:   "soup-go",
@@ -27,11 +47,13 @@ var rootCmd = &cobra.Command{
 CTY, HCL, Wire, and RPC functionality for cross-language testing.`,
 	Version: version,
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
-		// Reinitialize logger if log level was changed via flag
-		if cmd.Flags().Changed("log-level") {
+		// Reinitialize logger if log level was changed via flag. The daemon
+		// keeps its own logger, which writes to its real stderr.
+		if cmd.Flags().Changed("log-level") && !inDaemon {
 			initLogger()
 		}
 		logger.Debug("executing command", "cmd", cmd.Name(), "args", args)
+		routeToDaemon(cmd)
 	},
 }
 
@@ -45,18 +67,103 @@ var ctyCmd = &cobra.Command{
 // These will be initialized with real implementations
 var ctyValidateCmd *cobra.Command
 var ctyConvertCmd *cobra.Command
+var ctyPathCmd *cobra.Command
+var ctyFlatmapCmd *cobra.Command
+var ctyBatchCmd *cobra.Command
+var ctyUnifyCmd *cobra.Command
+var ctyGenerateCmd *cobra.Command
+var ctyTypeCmd *cobra.Command
+var ctyEqualsCmd *cobra.Command
+var ctySetCanonicalCmd *cobra.Command
+var ctyHashCmd *cobra.Command
+var ctyNumberRoundTripCmd *cobra.Command
+var ctyValidateTypeCmd *cobra.Command
+var ctyImpliedTypeCmd *cobra.Command
+var ctyTransformCmd *cobra.Command
+var ctyBenchCmd *cobra.Command
+var ctyViewCmd *cobra.Command
+var ctyNormalizeCmd *cobra.Command
+var ctyNumberCompareCmd *cobra.Command
+var ctyExplainCmd *cobra.Command
+var ctyValidateSuiteCmd *cobra.Command
+var ctyCanConvertCmd *cobra.Command
+var ctyMergeCmd *cobra.Command
+var ctyStatsCmd *cobra.Command
 
 // HCL command
 var hclCmd = &cobra.Command{
 	Use:   "hcl",
 	Short: "HCL parsing and processing",
-	Long:  `Parse and process HashiCorp Configuration Language (HCL) files.`,
+	Long: `Parse and process HashiCorp Configuration Language (HCL) files.
+
+Input files may be given as "-" to read stdin, for pipelines from generators.
+Stdin is parsed as native syntax and named <stdin> in diagnostics.`,
+}
+
+var hclTemplateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "HCL string template operations",
+}
+
+var hclFunctionsCmd = &cobra.Command{
+	Use:   "functions",
+	Short: "HCL evaluation function operations",
+}
+
+var hclExprCmd = &cobra.Command{
+	Use:   "expr",
+	Short: "HCL expression operations",
 }
 
 // These will be initialized with real implementations
 var hclViewCmd *cobra.Command
 var hclValidateCmd *cobra.Command
 var hclConvertCmd *cobra.Command
+var hclDiagnosticsCmd *cobra.Command
+var hclTfvarsCmd *cobra.Command
+var hclGenerateCmd *cobra.Command
+var hclFmtCmd *cobra.Command
+var hclDecodeCmd *cobra.Command
+var hclRefsCmd *cobra.Command
+var hclTemplateEvalCmd *cobra.Command
+var hclToCtyCmd *cobra.Command
+var hclEvalCmd *cobra.Command
+var hclSchemaExtractCmd *cobra.Command
+var hclDiffCmd *cobra.Command
+var hclFunctionsListCmd *cobra.Command
+var hclExprEvalCmd *cobra.Command
+var hclExprConformanceCmd *cobra.Command
+
+// Plan command
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Terraform plan JSON operations",
+	Long:  `Validate and inspect plan JSON documents produced by terraform show -json.`,
+}
+
+// These will be initialized with real implementations
+var planValidateJSONCmd *cobra.Command
+
+// Registry command
+var registryCmd = &cobra.Command{
+	Use:   "registry",
+	Short: "Provider registry protocol operations",
+	Long:  `Serve the Terraform provider registry protocol for offline client testing.`,
+}
+
+// These will be initialized with real implementations
+var registryServeCmd *cobra.Command
+
+// State command
+var stateCmd = &cobra.Command{
+	Use:   "state",
+	Short: "Terraform/OpenTofu state operations",
+	Long:  `Encrypt and decrypt state payloads using OpenTofu state encryption.`,
+}
+
+// These will be initialized with real implementations
+var stateEncryptCmd *cobra.Command
+var stateDecryptCmd *cobra.Command
 
 // Wire command
 var wireCmd = &cobra.Command{
@@ -68,6 +175,8 @@ var wireCmd = &cobra.Command{
 // These will be initialized with real implementations
 var wireEncodeCmd *cobra.Command
 var wireDecodeCmd *cobra.Command
+var wireBatchCmd *cobra.Command
+var wireCompareCmd *cobra.Command
 
 // RPC command
 var rpcCmd = &cobra.Command{
@@ -81,19 +190,37 @@ var kvCmd = &cobra.Command{
 	Short: "Key-Value store operations",
 }
 
+var providerCmd = &cobra.Command{
+	Use:   "provider",
+	Short: "Mock Terraform provider protocol operations",
+}
+
 var validateCmd = &cobra.Command{
 	Use:   "validate",
 	Short: "Validation operations",
 }
 
 var (
-	rpcPort       int
-	rpcTLSMode    string
-	rpcTLSKeyType string
-	rpcTLSCurve   string
-	rpcCertFile   string
-	rpcKeyFile    string
-	rpcStandalone bool
+	rpcPort            int
+	rpcTLSMode         string
+	rpcTLSKeyType      string
+	rpcTLSCurve        string
+	rpcCertFile        string
+	rpcKeyFile         string
+	rpcStandalone      bool
+	rpcHandshake       string
+	rpcProviderSchemas map[string]string
+	rpcEmitStdout      []string
+	rpcEmitStderr      []string
+	rpcEmitPattern     stdioPattern
+	rpcCacheSize       int64
+	rpcPprofPort       int
+	rpcChecksumMode    string
+	rpcStreamChunkSize int
+	rpcLockTimeout     time.Duration
+	rpcLockBackoff     time.Duration
+	rpcLockMaxBackoff  time.Duration
+	rpcMmapThreshold   int64
 )
 
 var serverCmd = &cobra.Command{
@@ -103,6 +230,21 @@ var serverCmd = &cobra.Command{
 which is suitable for spawning by plugin clients. Use --standalone flag to run as
 a standalone gRPC server on a specific port for manual testing.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		if err := kvplugin.ValidateChecksumMode(rpcChecksumMode); err != nil {
+			logger.Error("Invalid checksum mode", "error", err)
+			os.Exit(1)
+		}
+		if rpcEmitPattern.count > 0 && rpcEmitPattern.interval <= 0 {
+			logger.Error("Invalid --emit-pattern-interval", "interval", rpcEmitPattern.interval)
+			os.Exit(1)
+		}
+		if rpcPprofPort >= 0 {
+			if err := startPprofServer(rpcPprofPort); err != nil {
+				logger.Error("pprof server failed", "error", err)
+				os.Exit(1)
+			}
+		}
+
 		if rpcStandalone {
 			// Standalone mode - run as standalone gRPC server
 			logger.Info("Starting RPC server in standalone mode",
@@ -120,24 +262,58 @@ a standalone gRPC server on a specific port for manual testing.`,
 			}
 		} else {
 			// Plugin mode (default) - run as go-plugin server
+			profile, err := getHandshakeProfile(rpcHandshake)
+			if err != nil {
+				logger.Error("Invalid handshake profile", "error", err)
+				os.Exit(1)
+			}
+
 			logger.Info("Starting RPC server in plugin mode (go-plugin protocol)",
+				"handshake_profile", profile.Name,
+				"protocol_version", profile.Handshake.ProtocolVersion,
 				"tls_mode", rpcTLSMode,
 				"tls_key_type", rpcTLSKeyType,
 				"tls_curve", rpcTLSCurve)
 
 			// Create KV implementation with XDG-compliant storage directory
-			storageDir := GetKVStorageDir()
+			storageDir := kvplugin.StorageDir()
 			logger.Debug("Using KV storage directory", "path", storageDir)
 
+			impl := kvplugin.NewKVImpl(logger.Named("kv"), storageDir)
+			impl.SetChecksumMode(rpcChecksumMode)
+			impl.SetLockPolicy(rpcLockTimeout, rpcLockBackoff, rpcLockMaxBackoff)
+			impl.SetMmapThreshold(rpcMmapThreshold)
+			var kv kvplugin.KV = impl
+			var cache *kvplugin.CachingKV
+			if rpcCacheSize > 0 {
+				cache = kvplugin.NewCachingKV(kv, rpcCacheSize)
+				kv = cache
+			}
+			if len(rpcEmitStdout) > 0 || len(rpcEmitStderr) > 0 || rpcEmitPattern.count > 0 {
+				kv = newStdioEmittingKV(kv, rpcEmitStdout, rpcEmitStderr, rpcEmitPattern)
+			}
+
+			plugins := map[string]plugin.Plugin{
+				"kv_grpc": &kvplugin.KVGRPCPlugin{
+					Impl:      kv,
+					ChunkSize: rpcStreamChunkSize,
+				},
+			}
+			// Terraform/tofu dispense "provider", so serve the mock provider alongside KV
+			if profile.Name == HandshakeProfileTerraform {
+				mockProvider, err := newMockProviderWithSchemas(logger.Named("provider"), rpcProviderSchemas)
+				if err != nil {
+					logger.Error("Invalid provider schema", "error", err)
+					os.Exit(1)
+				}
+				plugins["provider"] = newMockProviderPlugin(mockProvider)
+			}
+
 			// Build plugin.ServeConfig
 			serveConfig := &plugin.ServeConfig{
-				HandshakeConfig: Handshake,
-				Plugins: map[string]plugin.Plugin{
-					"kv_grpc": &KVGRPCPlugin{
-						Impl: NewKVImpl(logger.Named("kv"), storageDir),
-					},
-				},
-				GRPCServer: plugin.DefaultGRPCServer,
+				HandshakeConfig: profile.Handshake,
+				Plugins:         plugins,
+				GRPCServer:      plugin.DefaultGRPCServer,
 			}
 
 		// Configure TLS: only use custom TLSProvider for specific curves
@@ -146,13 +322,18 @@ a standalone gRPC server on a specific port for manual testing.`,
 			// Use custom TLSProvider for specific curves (secp256r1, secp384r1)
 			logger.Info("Configuring go-plugin TLSProvider for custom curve support", "curve", rpcTLSCurve)
 			provider := createTLSProvider(logger.Named("tls"), rpcTLSCurve)
-			serveConfig.TLSProvider = provider
+			serveConfig.TLSProvider = profile.wrapTLSProvider(provider)
 		} else if rpcTLSMode == "auto" {
 			// No TLSProvider = go-plugin uses native AutoMTLS (P-521)
 			logger.Info("Using go-plugin native AutoMTLS (P-521 - no custom TLSProvider)")
 		}
 
+			// Serve returns once GRPCController.Shutdown stops the server
 			plugin.Serve(serveConfig)
+			if cache != nil {
+				logger.Info("🗄️📊 read cache stats", "stats", cache.Stats())
+			}
+			logger.Info("🗄️✅ plugin server exited")
 		}
 	},
 }
@@ -160,8 +341,18 @@ a standalone gRPC server on a specific port for manual testing.`,
 var getCmd *cobra.Command
 var putCmd *cobra.Command
 var connectionCmd *cobra.Command
-
-
+var stdioCmd *cobra.Command
+var shutdownCmd *cobra.Command
+var benchCmd *cobra.Command
+var soakCmd *cobra.Command
+var importCmd *cobra.Command
+var signalCmd *cobra.Command
+var hostileEnvCmd *cobra.Command
+var gcCmd *cobra.Command
+var versionsCmd *cobra.Command
+var snapshotCmd *cobra.Command
+var providerUpgradeStateCmd *cobra.Command
+var providerSimulateCmd *cobra.Command
 
 // Harness command (for compatibility testing)
 var harnessCmd = &cobra.Command{
@@ -233,6 +424,8 @@ var configShowCmd = &cobra.Command{
 	},
 }
 
+var daemonCmd *cobra.Command
+
 var generateCmd = &cobra.Command{
 	Use:   "generate",
 	Short: "Generate test data or configurations",
@@ -245,18 +438,85 @@ func init() {
 	// Initialize commands with real implementations
 	ctyValidateCmd = initCtyValidateCmd()
 	ctyConvertCmd = initCtyConvertCmd()
+	ctyPathCmd = initCtyPathCmd()
+	ctyFlatmapCmd = initCtyFlatmapCmd()
+	ctyBatchCmd = initCtyBatchCmd()
+	ctyUnifyCmd = initCtyUnifyCmd()
+	ctyGenerateCmd = initCtyGenerateCmd()
+	ctyTypeCmd = initCtyTypeCmd()
+	ctyEqualsCmd = initCtyEqualsCmd()
+	ctySetCanonicalCmd = initCtySetCanonicalCmd()
+	ctyHashCmd = initCtyHashCmd()
+	ctyNumberRoundTripCmd = initCtyNumberRoundTripCmd()
+	ctyValidateTypeCmd = initCtyValidateTypeCmd()
+	ctyImpliedTypeCmd = initCtyImpliedTypeCmd()
+	ctyTransformCmd = initCtyTransformCmd()
+	ctyBenchCmd = initCtyBenchCmd()
+	ctyViewCmd = initCtyViewCmd()
+	ctyNormalizeCmd = initCtyNormalizeCmd()
+	ctyNumberCompareCmd = initCtyNumberCompareCmd()
+	ctyExplainCmd = initCtyExplainCmd()
+	ctyValidateSuiteCmd = initCtyValidateSuiteCmd()
+	ctyCanConvertCmd = initCtyCanConvertCmd()
+	ctyMergeCmd = initCtyMergeCmd()
+	ctyStatsCmd = initCtyStatsCmd()
 	hclViewCmd = initHclViewCmd()
 	hclValidateCmd = initHclValidateCmd()
 	hclConvertCmd = initHclConvertCmd()
+	hclDiagnosticsCmd = initHclDiagnosticsCmd()
+	hclTfvarsCmd = initHclTfvarsCmd()
+	hclGenerateCmd = initHclGenerateCmd()
+	hclFmtCmd = initHclFmtCmd()
+	hclDecodeCmd = initHclDecodeCmd()
+	hclRefsCmd = initHclRefsCmd()
+	hclTemplateEvalCmd = initHclTemplateEvalCmd()
+	hclToCtyCmd = initHclToCtyCmd()
+	hclEvalCmd = initHclEvalCmd()
+	hclSchemaExtractCmd = initHclSchemaExtractCmd()
+	hclDiffCmd = initHclDiffCmd()
+	hclFunctionsListCmd = initHclFunctionsListCmd()
+	hclExprEvalCmd = initHclExprEvalCmd()
+	hclExprConformanceCmd = initHclExprConformanceCmd()
+	planValidateJSONCmd = initPlanValidateJSONCmd()
+	registryServeCmd = initRegistryServeCmd()
+	stateEncryptCmd = initStateEncryptCmd()
+	stateDecryptCmd = initStateDecryptCmd()
 	wireEncodeCmd = initWireEncodeCmd()
 	wireDecodeCmd = initWireDecodeCmd()
+	wireBatchCmd = initWireBatchCmd()
+	wireCompareCmd = initWireCompareCmd()
 	getCmd = initKVGetCmd()
 	putCmd = initKVPutCmd()
 	connectionCmd = initValidateConnectionCmd()
-	
+	stdioCmd = initKVStdioCmd()
+	shutdownCmd = initKVShutdownCmd()
+	benchCmd = initKVBenchCmd()
+	soakCmd = initKVSoakCmd()
+	importCmd = initKVImportCmd()
+	signalCmd = initKVSignalCmd()
+	hostileEnvCmd = initKVHostileEnvCmd()
+	gcCmd = initKVGCCmd()
+	versionsCmd = initKVVersionsCmd()
+	snapshotCmd = initKVSnapshotCmd()
+	daemonCmd = initDaemonCmd()
+	providerUpgradeStateCmd = initProviderUpgradeStateCmd()
+	providerSimulateCmd = initProviderSimulateCmd()
+
 	// Global flags
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose output")
 	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "info", "Set log level (trace, debug, info, warn, error)")
+	rootCmd.PersistentFlags().IntVar(&decodeLimits.MaxDepth, "max-depth", limits.DefaultMaxDepth, "Maximum nesting depth of decoded values, type specifications and HCL blocks (0 disables)")
+	rootCmd.PersistentFlags().Int64Var(&decodeLimits.MaxInputSize, "max-input-size", 0, "Maximum size in bytes of a decoded input (0 disables)")
+	rootCmd.PersistentFlags().StringVar(&daemonSocket, "daemon-socket", os.Getenv(EnvDaemonSocket), "Run cty, hcl and wire commands in the daemon listening on this socket, if one is (env SOUP_GO_DAEMON_SOCKET)")
+	for _, cmd := range []*cobra.Command{ctyCmd, wireCmd} {
+		cmd.PersistentFlags().BoolVar(&ctyFlagOptions.StrictCoercion, "strict", false, "Reject JSON primitives of the wrong kind, including numbers given as strings")
+		cmd.PersistentFlags().BoolVar(&ctyFlagOptions.LenientCoercion, "lenient", false, "Convert JSON strings, numbers and bools to the expected primitive type as Terraform does")
+		cmd.MarkFlagsMutuallyExclusive("strict", "lenient")
+		cmd.PersistentFlags().BoolVar(&ctyFlagOptions.Canonical, "canonical", false, "Write byte-stable JSON and msgpack output, with sorted keys and set elements, for goldens")
+		cmd.PersistentFlags().BoolVar(&ctyFlagOptions.MsgpackUnknowns, "msgpack-unknowns", true, "Write unknowns to msgpack as extension 0; when false, values holding unknowns fail to encode")
+		cmd.PersistentFlags().BoolVar(&ctyFlagOptions.MsgpackRefinements, "msgpack-refinements", true, "Write refined unknowns to msgpack as extension 12; when false, as plain unknowns")
+		cmd.PersistentFlags().StringVar(&ctyFlagOptions.NumberMode, "number-mode", ctyspec.NumberModeFloat64, "How JSON numbers are parsed: through float64, losing precision, or lossless into big.Float (float64, big)")
+	}
 	
 	// Add JSON output flag to relevant commands
 	harnessListCmd.Flags().Bool("json", false, "Output in JSON format")
@@ -270,42 +530,126 @@ func init() {
 	serverCmd.Flags().StringVar(&rpcTLSCurve, "tls-curve", "secp384r1", "Elliptic curve for EC key type: 'secp256r1', 'secp384r1', 'secp521r1', or 'auto' (AutoMTLS P-521) - default secp384r1 for Python compatibility")
 	serverCmd.Flags().StringVar(&rpcCertFile, "cert-file", "", "Path to certificate file (required for manual TLS, only used in standalone mode)")
 	serverCmd.Flags().StringVar(&rpcKeyFile, "key-file", "", "Path to private key file (required for manual TLS, only used in standalone mode)")
+	serverCmd.Flags().StringVar(&rpcHandshake, "handshake-profile", HandshakeProfileKV, "Handshake profile for plugin mode: 'kv' (TofuSoup KV) or 'terraform' (TF_PLUGIN_MAGIC_COOKIE, protocol 6, h2 ALPN)")
+	serverCmd.Flags().StringToStringVar(&rpcProviderSchemas, "provider-schema", nil, "Resource schemas served by the mock provider as type_name=schema.json (terraform profile only)")
+	serverCmd.Flags().StringArrayVar(&rpcEmitStdout, "emit-stdout", nil, "Line to write to stdout on the first request, forwarded over GRPCStdio (plugin mode only, repeatable)")
+	serverCmd.Flags().StringArrayVar(&rpcEmitStderr, "emit-stderr", nil, "Line to write to stderr on the first request, forwarded over GRPCStdio (plugin mode only, repeatable)")
+	serverCmd.Flags().IntVar(&rpcEmitPattern.count, "emit-pattern-count", 0, "Numbered lines to write to stdout and stderr after the first request, one per --emit-pattern-interval (plugin mode only)")
+	serverCmd.Flags().DurationVar(&rpcEmitPattern.interval, "emit-pattern-interval", 100*time.Millisecond, "Interval between --emit-pattern-count lines")
+	serverCmd.Flags().Int64Var(&rpcCacheSize, "cache-size", 0, "Size in bytes of an in-memory LRU read cache in front of the file store (0 disables)")
+	serverCmd.Flags().StringVar(&rpcChecksumMode, "checksum", kvplugin.ChecksumWarn, "Checksum stored values and verify them on Get: off, warn (log mismatches) or error (fail with DataLoss)")
+	serverCmd.Flags().IntVar(&rpcStreamChunkSize, "stream-chunk-size", kvplugin.DefaultStreamChunkSize, "GetStream chunk size in bytes when the client does not request one")
+	serverCmd.Flags().DurationVar(&rpcLockTimeout, "lock-timeout", kvplugin.DefaultLockTimeout, "How long to wait for a key's file lock before failing with Unavailable (0 waits indefinitely)")
+	serverCmd.Flags().DurationVar(&rpcLockBackoff, "lock-backoff", kvplugin.DefaultLockBackoff, "Initial delay between lock attempts, doubling after each attempt")
+	serverCmd.Flags().DurationVar(&rpcLockMaxBackoff, "lock-max-backoff", kvplugin.DefaultLockMaxBackoff, "Maximum delay between lock attempts")
+	serverCmd.Flags().Int64Var(&rpcMmapThreshold, "mmap-threshold", 0, "Memory-map stored values of at least this many bytes when serving GetStream, instead of reading them into memory (0 disables)")
+	addPprofPortFlag(serverCmd, &rpcPprofPort)
 	
 	// Build command tree
 	rootCmd.AddCommand(ctyCmd)
 	rootCmd.AddCommand(hclCmd)
+	rootCmd.AddCommand(planCmd)
+	rootCmd.AddCommand(registryCmd)
+	rootCmd.AddCommand(stateCmd)
 	rootCmd.AddCommand(wireCmd)
 	rootCmd.AddCommand(rpcCmd)
 	rootCmd.AddCommand(harnessCmd)
 	rootCmd.AddCommand(configCmd)
 	rootCmd.AddCommand(generateCmd)
+	rootCmd.AddCommand(daemonCmd)
 	
 	// CTY subcommands
 	ctyCmd.AddCommand(ctyValidateCmd)
 	ctyCmd.AddCommand(ctyConvertCmd)
+	ctyCmd.AddCommand(ctyPathCmd)
+	ctyCmd.AddCommand(ctyFlatmapCmd)
+	ctyCmd.AddCommand(ctyBatchCmd)
+	ctyCmd.AddCommand(ctyUnifyCmd)
+	ctyCmd.AddCommand(ctyGenerateCmd)
+	ctyCmd.AddCommand(ctyTypeCmd)
+	ctyCmd.AddCommand(ctyEqualsCmd)
+	ctyCmd.AddCommand(ctySetCanonicalCmd)
+	ctyCmd.AddCommand(ctyHashCmd)
+	ctyCmd.AddCommand(ctyNumberRoundTripCmd)
+	ctyCmd.AddCommand(ctyValidateTypeCmd)
+	ctyCmd.AddCommand(ctyImpliedTypeCmd)
+	ctyCmd.AddCommand(ctyTransformCmd)
+	ctyCmd.AddCommand(ctyBenchCmd)
+	ctyCmd.AddCommand(ctyViewCmd)
+	ctyCmd.AddCommand(ctyNormalizeCmd)
+	ctyCmd.AddCommand(ctyNumberCompareCmd)
+	ctyCmd.AddCommand(ctyExplainCmd)
+	ctyCmd.AddCommand(ctyValidateSuiteCmd)
+	ctyCmd.AddCommand(ctyCanConvertCmd)
+	ctyCmd.AddCommand(ctyMergeCmd)
+	ctyCmd.AddCommand(ctyStatsCmd)
 	
 	// HCL subcommands
 	hclCmd.AddCommand(hclViewCmd)
 	hclCmd.AddCommand(hclValidateCmd)
 	hclCmd.AddCommand(hclConvertCmd)
+	hclCmd.AddCommand(hclDiagnosticsCmd)
+	hclCmd.AddCommand(hclTfvarsCmd)
+	hclCmd.AddCommand(hclGenerateCmd)
+	hclCmd.AddCommand(hclFmtCmd)
+	hclCmd.AddCommand(hclDecodeCmd)
+	hclCmd.AddCommand(hclRefsCmd)
+	hclCmd.AddCommand(hclTemplateCmd)
+	hclCmd.AddCommand(hclToCtyCmd)
+	hclCmd.AddCommand(hclEvalCmd)
+	hclCmd.AddCommand(hclSchemaExtractCmd)
+	hclCmd.AddCommand(hclDiffCmd)
+	hclCmd.AddCommand(hclFunctionsCmd)
+	hclCmd.AddCommand(hclExprCmd)
+
+	hclTemplateCmd.AddCommand(hclTemplateEvalCmd)
+	hclFunctionsCmd.AddCommand(hclFunctionsListCmd)
+	hclExprCmd.AddCommand(hclExprEvalCmd)
+	hclExprCmd.AddCommand(hclExprConformanceCmd)
+	
+	// Plan subcommands
+	planCmd.AddCommand(planValidateJSONCmd)
+	
+	// Registry subcommands
+	registryCmd.AddCommand(registryServeCmd)
+	
+	// State subcommands
+	stateCmd.AddCommand(stateEncryptCmd)
+	stateCmd.AddCommand(stateDecryptCmd)
 	
 	// Wire subcommands
 	wireCmd.AddCommand(wireEncodeCmd)
 	wireCmd.AddCommand(wireDecodeCmd)
-	
+	wireCmd.AddCommand(wireBatchCmd)
+	wireCmd.AddCommand(wireCompareCmd)
+
 	// RPC subcommands
 	rpcCmd.AddCommand(kvCmd)
 	rpcCmd.AddCommand(validateCmd)
-
+	rpcCmd.AddCommand(providerCmd)
 
 	// KV subcommands
 	kvCmd.AddCommand(getCmd)
 	kvCmd.AddCommand(putCmd)
 	kvCmd.AddCommand(serverCmd)
+	kvCmd.AddCommand(stdioCmd)
+	kvCmd.AddCommand(shutdownCmd)
+	kvCmd.AddCommand(benchCmd)
+	kvCmd.AddCommand(soakCmd)
+	kvCmd.AddCommand(importCmd)
+	kvCmd.AddCommand(signalCmd)
+	kvCmd.AddCommand(hostileEnvCmd)
+	kvCmd.AddCommand(gcCmd)
+	kvCmd.AddCommand(versionsCmd)
+	kvCmd.AddCommand(snapshotCmd)
 
 	// Validate subcommands
 	validateCmd.AddCommand(connectionCmd)
-	
+
+	// Provider subcommands
+	providerCmd.AddCommand(providerUpgradeStateCmd)
+	providerCmd.AddCommand(providerSimulateCmd)
+
 	// Harness subcommands
 	harnessCmd.AddCommand(harnessListCmd)
 	harnessCmd.AddCommand(harnessTestCmd)
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"sync"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/hcltools"
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/limits"
+)
+
+// maxParseCacheEntries bounds each of the parse caches
+const maxParseCacheEntries = 1024
+
+// parseCache keeps parsed HCL files and cty type specifications, so a
+// long-running daemon does not re-parse inputs its clients repeat. Files
+// are keyed by name and content hash, since hclparse.Parser's own cache is
+// keyed by name alone and would serve edited files stale.
+type parseCache struct {
+	mu    sync.Mutex
+	files map[[sha256.Size]byte]parsedHCL
+	types map[ctyTypeKey]cty.Type
+
+	fileHits, fileMisses int64
+	typeHits, typeMisses int64
+}
+
+type parsedHCL struct {
+	file  *hcl.File
+	diags hcl.Diagnostics
+}
+
+// ctyTypeKey identifies a parsed type specification. A specification
+// parsed under one --max-depth may be too deep for another, so the limit
+// is part of the key.
+type ctyTypeKey struct {
+	spec     string
+	maxDepth int
+}
+
+var sharedParseCache = &parseCache{
+	files: make(map[[sha256.Size]byte]parsedHCL),
+	types: make(map[ctyTypeKey]cty.Type),
+}
+
+// parseHCLCached parses HCL native syntax, or JSON syntax when jsonSyntax is
+// set, reusing an earlier parse of the same file content
+func parseHCLCached(content []byte, filename string, jsonSyntax bool) (*hcl.File, hcl.Diagnostics) {
+	h := sha256.New()
+	h.Write([]byte(filename))
+	h.Write([]byte{0})
+	if jsonSyntax {
+		h.Write([]byte{1})
+	}
+	h.Write(content)
+	var key [sha256.Size]byte
+	copy(key[:], h.Sum(nil))
+
+	c := sharedParseCache
+	c.mu.Lock()
+	if parsed, ok := c.files[key]; ok {
+		c.fileHits++
+		c.mu.Unlock()
+		return parsed.file, parsed.diags
+	}
+	c.fileMisses++
+	c.mu.Unlock()
+
+	file, diags := hcltools.Parse(content, filename, jsonSyntax)
+
+	c.mu.Lock()
+	if len(c.files) >= maxParseCacheEntries {
+		for k := range c.files {
+			delete(c.files, k)
+			break
+		}
+	}
+	c.files[key] = parsedHCL{file: file, diags: diags}
+	c.mu.Unlock()
+	return file, diags
+}
+
+// cachedCtyType returns a type specification previously parsed under lim
+func (c *parseCache) cachedCtyType(data json.RawMessage, lim limits.Config) (cty.Type, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ty, ok := c.types[ctyTypeKey{spec: string(data), maxDepth: lim.MaxDepth}]
+	if ok {
+		c.typeHits++
+	} else {
+		c.typeMisses++
+	}
+	return ty, ok
+}
+
+// storeCtyType records a type specification successfully parsed under lim
+func (c *parseCache) storeCtyType(data json.RawMessage, lim limits.Config, ty cty.Type) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.types) >= maxParseCacheEntries {
+		for k := range c.types {
+			delete(c.types, k)
+			break
+		}
+	}
+	c.types[ctyTypeKey{spec: string(data), maxDepth: lim.MaxDepth}] = ty
+}
+
+// Stats reports the cache sizes and hit counts
+func (c *parseCache) Stats() map[string]interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return map[string]interface{}{
+		"hcl_files":        len(c.files),
+		"hcl_file_hits":    c.fileHits,
+		"hcl_file_misses":  c.fileMisses,
+		"type_specs":       len(c.types),
+		"type_spec_hits":   c.typeHits,
+		"type_spec_misses": c.typeMisses,
+	}
+}
@@ -0,0 +1,97 @@
+package ctyspec
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// AbsentAttribute is an object attribute missing from the JSON input,
+// which BuildValue sets to null
+type AbsentAttribute struct {
+	Path cty.Path
+	// Optional is whether ty declares the attribute optional; a missing
+	// required attribute is null too
+	Optional bool
+}
+
+// AbsentAttributes returns the object attributes of type ty that the JSON
+// data leaves out, in the order BuildValue meets them. Attributes within
+// set elements, which have no index, are located with an unknown key.
+// Unknown and dynamically typed values are not looked into.
+func AbsentAttributes(ty cty.Type, data []byte) ([]AbsentAttribute, error) {
+	var rawValue interface{}
+	if err := json.Unmarshal(data, &rawValue); err != nil {
+		return nil, err
+	}
+	var absent []AbsentAttribute
+	absentAttributes(ty, rawValue, nil, &absent)
+	return absent, nil
+}
+
+// absentAttributes appends the attributes missing from val, a decoded
+// JSON value of type ty at path
+func absentAttributes(ty cty.Type, val interface{}, path cty.Path, absent *[]AbsentAttribute) {
+	if val == nil || ty == cty.DynamicPseudoType {
+		return
+	}
+	if _, ok := unknownSentinel(val); ok {
+		return
+	}
+
+	switch {
+	case ty.IsListType() || ty.IsSetType() || ty.IsTupleType():
+		slice, ok := val.([]interface{})
+		if !ok {
+			return
+		}
+		for i, elem := range slice {
+			elemTy, elemPath := cty.NilType, path.Index(cty.NumberIntVal(int64(i)))
+			switch {
+			case ty.IsTupleType():
+				if i >= ty.Length() {
+					return
+				}
+				elemTy = ty.TupleElementType(i)
+			case ty.IsSetType():
+				elemTy, elemPath = ty.ElementType(), path.Index(cty.DynamicVal)
+			default:
+				elemTy = ty.ElementType()
+			}
+			absentAttributes(elemTy, elem, elemPath, absent)
+		}
+	case ty.IsMapType():
+		m, ok := val.(map[string]interface{})
+		if !ok {
+			return
+		}
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			absentAttributes(ty.ElementType(), m[k], path.Index(cty.StringVal(k)), absent)
+		}
+	case ty.IsObjectType():
+		m, ok := val.(map[string]interface{})
+		if !ok {
+			return
+		}
+		names := make([]string, 0, len(ty.AttributeTypes()))
+		for name := range ty.AttributeTypes() {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			attrPath := path.GetAttr(name)
+			elem, present := m[name]
+			if !present {
+				*absent = append(*absent, AbsentAttribute{Path: attrPath, Optional: ty.AttributeOptional(name)})
+				continue
+			}
+			absentAttributes(ty.AttributeType(name), elem, attrPath, absent)
+		}
+	}
+}
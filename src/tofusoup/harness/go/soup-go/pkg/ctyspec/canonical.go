@@ -0,0 +1,296 @@
+package ctyspec
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// CanonicalJSON encodes v as compact JSON in a form that depends only on
+// the value, so any language can reproduce it byte for byte:
+//
+//   - null is null and an unknown, however refined, is {"__unknown":true}
+//   - numbers are plain decimals with no exponent, no trailing fraction
+//     zeros and no "+"; negative zero is 0, and the infinities are the bare
+//     tokens Infinity and -Infinity
+//   - strings escape only '"', '\' and control characters below U+0020,
+//     as \", \\ and \u00XX with lowercase hex; everything else is UTF-8
+//   - lists and tuples keep their order; set elements are sorted by the
+//     bytes of their canonical encoding
+//   - map keys and object attributes are sorted by their UTF-8 bytes
+//   - capsules are their {"__capsule","payload"} wrapper
+//
+// Marks are dropped and types are not encoded: a list and a tuple with
+// equal elements encode alike.
+func CanonicalJSON(v cty.Value) ([]byte, error) {
+	var b bytes.Buffer
+	if err := writeCanonical(&b, v, cty.NilType); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// CanonicalTypedJSON encodes v as ty in go-cty's JSON encoding, with the
+// byte-level rules of CanonicalJSON: a value where ty is dynamic is written
+// as {"type":...,"value":...}, its keys sorted like any object's. Unknowns
+// cannot be encoded.
+func CanonicalTypedJSON(v cty.Value, ty cty.Type) ([]byte, error) {
+	if !v.IsWhollyKnown() {
+		return nil, fmt.Errorf("value is not known")
+	}
+	var b bytes.Buffer
+	if err := writeCanonical(&b, v, ty); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Hash returns the lowercase hex SHA-256 of v's CanonicalJSON
+func Hash(v cty.Value) (string, error) {
+	data, err := CanonicalJSON(v)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// writeCanonical writes v, wrapping values where declared is dynamic with
+// their type; cty.NilType writes no types at all
+func writeCanonical(b *bytes.Buffer, v cty.Value, declared cty.Type) error {
+	v, _ = v.Unmark()
+	if declared == cty.DynamicPseudoType {
+		typeJSON, err := ctyjson.MarshalType(v.Type())
+		if err != nil {
+			return err
+		}
+		b.WriteString(`{"type":`)
+		b.Write(typeJSON)
+		b.WriteString(`,"value":`)
+		if err := writeCanonical(b, v, v.Type()); err != nil {
+			return err
+		}
+		b.WriteByte('}')
+		return nil
+	}
+	switch {
+	case !v.IsKnown():
+		b.WriteString(`{"` + UnknownKey + `":true}`)
+		return nil
+	case v.IsNull():
+		b.WriteString("null")
+		return nil
+	}
+
+	ty := v.Type()
+	switch {
+	case ty == cty.String:
+		writeCanonicalString(b, v.AsString())
+	case ty == cty.Number:
+		bf := v.AsBigFloat()
+		switch {
+		case bf.IsInf():
+			if bf.Sign() < 0 {
+				b.WriteByte('-')
+			}
+			b.WriteString("Infinity")
+		case bf.Sign() == 0:
+			b.WriteByte('0')
+		default:
+			b.WriteString(bf.Text('f', -1))
+		}
+	case ty == cty.Bool:
+		if v.True() {
+			b.WriteString("true")
+		} else {
+			b.WriteString("false")
+		}
+	case ty.IsCapsuleType():
+		c, ok := v.EncapsulatedValue().(*Capsule)
+		if !ok {
+			return fmt.Errorf("cannot encode capsule type %s", ty.FriendlyName())
+		}
+		return writeCanonical(b, cty.ObjectVal(map[string]cty.Value{
+			CapsuleKey:        cty.StringVal(c.Name),
+			CapsulePayloadKey: cty.StringVal(base64.StdEncoding.EncodeToString(c.Payload)),
+		}), cty.NilType)
+	case ty.IsListType() || ty.IsTupleType():
+		b.WriteByte('[')
+		for i, it := 0, v.ElementIterator(); it.Next(); i++ {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			_, elem := it.Element()
+			if err := writeCanonical(b, elem, elementDeclared(declared, i, "")); err != nil {
+				return err
+			}
+		}
+		b.WriteByte(']')
+	case ty.IsSetType():
+		var elems [][]byte
+		for it := v.ElementIterator(); it.Next(); {
+			_, elem := it.Element()
+			var eb bytes.Buffer
+			if err := writeCanonical(&eb, elem, elementDeclared(declared, 0, "")); err != nil {
+				return err
+			}
+			elems = append(elems, eb.Bytes())
+		}
+		sort.Slice(elems, func(i, j int) bool { return bytes.Compare(elems[i], elems[j]) < 0 })
+		b.WriteByte('[')
+		b.Write(bytes.Join(elems, []byte(",")))
+		b.WriteByte(']')
+	case ty.IsMapType() || ty.IsObjectType():
+		// Go compares strings by their UTF-8 bytes
+		entries := map[string]cty.Value{}
+		for it := v.ElementIterator(); it.Next(); {
+			key, elem := it.Element()
+			entries[key.AsString()] = elem
+		}
+		keys := make([]string, 0, len(entries))
+		for k := range entries {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		b.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			writeCanonicalString(b, k)
+			b.WriteByte(':')
+			if err := writeCanonical(b, entries[k], elementDeclared(declared, 0, k)); err != nil {
+				return err
+			}
+		}
+		b.WriteByte('}')
+	default:
+		return fmt.Errorf("cannot encode values of type %s", ty.FriendlyName())
+	}
+	return nil
+}
+
+// elementDeclared is the declared type of the element of a value declared
+// as declared at tuple index i or object attribute name
+func elementDeclared(declared cty.Type, i int, name string) cty.Type {
+	switch {
+	case declared == cty.NilType:
+		return cty.NilType
+	case declared.IsCollectionType():
+		return declared.ElementType()
+	case declared.IsTupleType():
+		return declared.TupleElementType(i)
+	case declared.IsObjectType():
+		return declared.AttributeType(name)
+	}
+	return cty.NilType
+}
+
+func writeCanonicalString(b *bytes.Buffer, s string) {
+	const hexDigits = "0123456789abcdef"
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20:
+			b.WriteString(`\u00`)
+			b.WriteByte(hexDigits[r>>4])
+			b.WriteByte(hexDigits[r&0xf])
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+}
+
+// MarshalJSON encodes v as ty like go-cty's json.Marshal, or as
+// CanonicalTypedJSON under opts.Canonical
+func MarshalJSON(v cty.Value, ty cty.Type, opts Options) ([]byte, error) {
+	if opts.Canonical {
+		return CanonicalTypedJSON(v, ty)
+	}
+	return ctyjson.Marshal(v, ty)
+}
+
+// canonicalMsgpack encodes v as ty like marshalMsgpack, but writes set
+// elements sorted by the bytes of their encoding
+func canonicalMsgpack(v cty.Value, ty cty.Type) ([]byte, error) {
+	if v.IsMarked() {
+		return nil, fmt.Errorf("value has marks, so it cannot be serialized")
+	}
+	valTy := v.Type()
+	isContainer := valTy.IsCollectionType() || valTy.IsTupleType() || valTy.IsObjectType()
+	if !isContainer || !v.IsKnown() || v.IsNull() {
+		return marshalMsgpack(v, ty)
+	}
+
+	var b bytes.Buffer
+	enc := msgpack.NewEncoder(&b)
+	if ty == cty.DynamicPseudoType {
+		// go-cty's wrapper for a value whose type is only known at runtime
+		typeJSON, err := ctyjson.MarshalType(valTy)
+		if err != nil {
+			return nil, err
+		}
+		if err := enc.EncodeArrayLen(2); err != nil {
+			return nil, err
+		}
+		if err := enc.EncodeBytes(typeJSON); err != nil {
+			return nil, err
+		}
+		data, err := canonicalMsgpack(v, valTy)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(data)
+		return b.Bytes(), nil
+	}
+
+	switch {
+	case ty.IsListType() || ty.IsSetType() || ty.IsTupleType():
+		var elems [][]byte
+		for i, it := 0, v.ElementIterator(); it.Next(); i++ {
+			_, elem := it.Element()
+			data, err := canonicalMsgpack(elem, elementDeclared(ty, i, ""))
+			if err != nil {
+				return nil, err
+			}
+			elems = append(elems, data)
+		}
+		if ty.IsSetType() {
+			sort.Slice(elems, func(i, j int) bool { return bytes.Compare(elems[i], elems[j]) < 0 })
+		}
+		if err := enc.EncodeArrayLen(len(elems)); err != nil {
+			return nil, err
+		}
+		b.Write(bytes.Join(elems, nil))
+	case ty.IsMapType() || ty.IsObjectType():
+		if err := enc.EncodeMapLen(v.LengthInt()); err != nil {
+			return nil, err
+		}
+		// The iterator yields keys and attributes in sorted order
+		for it := v.ElementIterator(); it.Next(); {
+			key, elem := it.Element()
+			if err := enc.EncodeString(key.AsString()); err != nil {
+				return nil, err
+			}
+			data, err := canonicalMsgpack(elem, elementDeclared(ty, 0, key.AsString()))
+			if err != nil {
+				return nil, err
+			}
+			b.Write(data)
+		}
+	default:
+		return marshalMsgpack(v, ty)
+	}
+	return b.Bytes(), nil
+}
@@ -0,0 +1,397 @@
+package ctyspec
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+	ctymsgpack "github.com/zclconf/go-cty/cty/msgpack"
+)
+
+// CapsuleKey and CapsulePayloadKey name the fields of the wrapper capsule
+// values are serialized as: {"__capsule": "<name>", "payload": "<base64>"}
+// in JSON, and a map of the same two strings in msgpack, where go-cty has
+// no capsule encoding. The payload is opaque bytes.
+const (
+	CapsuleKey        = "__capsule"
+	CapsulePayloadKey = "payload"
+)
+
+// Capsule is the Go value encapsulated by capsule types from the registry
+type Capsule struct {
+	Name    string `json:"__capsule"`
+	Payload []byte `json:"payload"`
+}
+
+var (
+	capsulesMu sync.Mutex
+	capsules   = map[string]cty.Type{}
+)
+
+// capsuleWireType is the object a capsule value is encoded as in msgpack
+var capsuleWireType = cty.Object(map[string]cty.Type{
+	CapsuleKey:        cty.String,
+	CapsulePayloadKey: cty.String,
+})
+
+// RegisterCapsule declares the capsule type name, returning the existing
+// type if it was declared before. go-cty compares capsule types by
+// identity, so every use of a name must share one type; ParseType declares
+// the names in ["capsule", "<name>"] specifications.
+func RegisterCapsule(name string) (cty.Type, error) {
+	if name == "" {
+		return cty.NilType, fmt.Errorf("capsule type name must not be empty")
+	}
+	capsulesMu.Lock()
+	defer capsulesMu.Unlock()
+	if ty, ok := capsules[name]; ok {
+		return ty, nil
+	}
+	ty := cty.CapsuleWithOps(name, reflect.TypeOf(Capsule{}), &cty.CapsuleOps{
+		GoString: func(val interface{}) string {
+			c := val.(*Capsule)
+			return fmt.Sprintf("ctyspec.Capsule{Name: %q, Payload: %#v}", c.Name, c.Payload)
+		},
+		TypeGoString: func(reflect.Type) string {
+			return fmt.Sprintf("ctyspec.CapsuleType(%q)", name)
+		},
+		RawEquals: func(a, b interface{}) bool {
+			ca, cb := a.(*Capsule), b.(*Capsule)
+			return ca.Name == cb.Name && bytes.Equal(ca.Payload, cb.Payload)
+		},
+		HashKey: func(v interface{}) string {
+			return hex.EncodeToString(v.(*Capsule).Payload)
+		},
+	})
+	capsules[name] = ty
+	return ty, nil
+}
+
+// CapsuleType returns the capsule type declared as name
+func CapsuleType(name string) (cty.Type, bool) {
+	capsulesMu.Lock()
+	defer capsulesMu.Unlock()
+	ty, ok := capsules[name]
+	return ty, ok
+}
+
+// CapsuleNames returns the declared capsule type names in lexical order
+func CapsuleNames() []string {
+	capsulesMu.Lock()
+	defer capsulesMu.Unlock()
+	names := make([]string, 0, len(capsules))
+	for name := range capsules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CapsuleVal returns a value of the declared capsule type ty
+func CapsuleVal(ty cty.Type, payload []byte) cty.Value {
+	return cty.CapsuleVal(ty, &Capsule{Name: ty.FriendlyName(), Payload: payload})
+}
+
+// buildCapsule builds a capsule value from its decoded JSON wrapper
+func buildCapsule(ty cty.Type, val interface{}, path cty.Path) (cty.Value, error) {
+	m, ok := val.(map[string]interface{})
+	if !ok {
+		return cty.NilVal, valueError(path, ty, val, nil, "expected capsule wrapper")
+	}
+	if name, _ := m[CapsuleKey].(string); name != ty.FriendlyName() {
+		return cty.NilVal, valueError(path, ty, val, nil, "expected capsule %s, got %q", ty.FriendlyName(), name)
+	}
+	encoded, _ := m[CapsulePayloadKey].(string)
+	payload, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return cty.NilVal, valueError(path, ty, val, err, "invalid capsule payload")
+	}
+	return CapsuleVal(ty, payload), nil
+}
+
+// capsuleJSON returns the JSON wrapper of a known capsule value
+func capsuleJSON(v cty.Value) interface{} {
+	c, ok := v.EncapsulatedValue().(*Capsule)
+	if !ok {
+		return v.GoString()
+	}
+	return map[string]interface{}{
+		CapsuleKey:        c.Name,
+		CapsulePayloadKey: base64.StdEncoding.EncodeToString(c.Payload),
+	}
+}
+
+// MarshalMsgpack encodes v as ty like go-cty's msgpack.Marshal, writing
+// capsule values as their wrapper map, with the extensions opts allows
+func MarshalMsgpack(v cty.Value, ty cty.Type, opts Options) ([]byte, error) {
+	if !v.IsWhollyKnown() {
+		if !opts.MsgpackUnknowns {
+			return nil, fmt.Errorf("value is not known and msgpack unknowns are disabled")
+		}
+		if !opts.MsgpackRefinements {
+			v = unrefineUnknowns(v)
+		}
+	}
+	if opts.Canonical {
+		return canonicalMsgpack(v, ty)
+	}
+	return marshalMsgpack(v, ty)
+}
+
+// marshalMsgpack is MarshalMsgpack in go-cty's own order
+func marshalMsgpack(v cty.Value, ty cty.Type) ([]byte, error) {
+	if !typeHasCapsules(ty) {
+		return ctymsgpack.Marshal(v, ty)
+	}
+	wire, err := capsulesToWire(v, ty)
+	if err != nil {
+		return nil, err
+	}
+	return ctymsgpack.Marshal(wire, capsuleWire(ty))
+}
+
+// unrefineUnknowns replaces the refined unknowns within v with plain ones
+func unrefineUnknowns(v cty.Value) cty.Value {
+	v, _ = cty.Transform(v, func(_ cty.Path, v cty.Value) (cty.Value, error) {
+		if v.IsKnown() {
+			return v, nil
+		}
+		v, marks := v.Unmark()
+		return cty.UnknownVal(v.Type()).WithMarks(marks), nil
+	})
+	return v
+}
+
+// UnmarshalMsgpack decodes data as ty like go-cty's msgpack.Unmarshal,
+// reading capsule values from their wrapper map
+func UnmarshalMsgpack(data []byte, ty cty.Type) (cty.Value, error) {
+	if !typeHasCapsules(ty) {
+		return ctymsgpack.Unmarshal(data, ty)
+	}
+	wire, err := ctymsgpack.Unmarshal(data, capsuleWire(ty))
+	if err != nil {
+		return cty.NilVal, err
+	}
+	return capsulesFromWire(wire, ty)
+}
+
+// typeHasCapsules reports whether ty is or contains a capsule type
+func typeHasCapsules(ty cty.Type) bool {
+	switch {
+	case ty.IsCapsuleType():
+		return true
+	case ty.IsCollectionType():
+		return typeHasCapsules(ty.ElementType())
+	case ty.IsObjectType():
+		for _, attrType := range ty.AttributeTypes() {
+			if typeHasCapsules(attrType) {
+				return true
+			}
+		}
+	case ty.IsTupleType():
+		for _, elemType := range ty.TupleElementTypes() {
+			if typeHasCapsules(elemType) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// capsuleWire returns ty with its capsule types replaced by the wrapper
+// object
+func capsuleWire(ty cty.Type) cty.Type {
+	switch {
+	case ty.IsCapsuleType():
+		return capsuleWireType
+	case ty.IsListType():
+		return cty.List(capsuleWire(ty.ElementType()))
+	case ty.IsSetType():
+		return cty.Set(capsuleWire(ty.ElementType()))
+	case ty.IsMapType():
+		return cty.Map(capsuleWire(ty.ElementType()))
+	case ty.IsObjectType():
+		attrTypes := make(map[string]cty.Type)
+		for name, attrType := range ty.AttributeTypes() {
+			attrTypes[name] = capsuleWire(attrType)
+		}
+		return cty.Object(attrTypes)
+	case ty.IsTupleType():
+		elemTypes := make([]cty.Type, len(ty.TupleElementTypes()))
+		for i, elemType := range ty.TupleElementTypes() {
+			elemTypes[i] = capsuleWire(elemType)
+		}
+		return cty.Tuple(elemTypes)
+	}
+	return ty
+}
+
+// capsulesToWire replaces the capsule values in v, of type ty, with their
+// wrapper objects
+func capsulesToWire(v cty.Value, ty cty.Type) (cty.Value, error) {
+	return rebuild(v, ty, capsuleWire(ty), func(v cty.Value, ty cty.Type) (cty.Value, error) {
+		c := v.EncapsulatedValue().(*Capsule)
+		return cty.ObjectVal(map[string]cty.Value{
+			CapsuleKey:        cty.StringVal(c.Name),
+			CapsulePayloadKey: cty.StringVal(base64.StdEncoding.EncodeToString(c.Payload)),
+		}), nil
+	})
+}
+
+// capsulesFromWire replaces the wrapper objects in v with capsule values
+// of the capsule types at the same place in ty
+func capsulesFromWire(v cty.Value, ty cty.Type) (cty.Value, error) {
+	return rebuild(v, capsuleWire(ty), ty, func(v cty.Value, ty cty.Type) (cty.Value, error) {
+		return buildCapsule(ty, map[string]interface{}{
+			CapsuleKey:        v.GetAttr(CapsuleKey).AsString(),
+			CapsulePayloadKey: v.GetAttr(CapsulePayloadKey).AsString(),
+		}, nil)
+	})
+}
+
+// rebuild rebuilds v, of type from, as type to, which differs only where
+// from has a capsule type or the wrapper object, calling leaf for each
+// known, non-null value there
+func rebuild(v cty.Value, from, to cty.Type, leaf func(cty.Value, cty.Type) (cty.Value, error)) (cty.Value, error) {
+	switch {
+	case !v.IsKnown():
+		return cty.UnknownVal(to), nil
+	case v.IsNull():
+		return cty.NullVal(to), nil
+	case from.IsCapsuleType() || to.IsCapsuleType():
+		return leaf(v, to)
+	case from.IsListType() || from.IsSetType() || from.IsTupleType():
+		var vals []cty.Value
+		for i, it := 0, v.ElementIterator(); it.Next(); i++ {
+			_, elem := it.Element()
+			var fromElem, toElem cty.Type
+			if from.IsTupleType() {
+				fromElem, toElem = from.TupleElementType(i), to.TupleElementType(i)
+			} else {
+				fromElem, toElem = from.ElementType(), to.ElementType()
+			}
+			converted, err := rebuild(elem, fromElem, toElem, leaf)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			vals = append(vals, converted)
+		}
+		switch {
+		case from.IsTupleType():
+			if len(vals) == 0 {
+				return cty.EmptyTupleVal, nil
+			}
+			return cty.TupleVal(vals), nil
+		case len(vals) == 0 && from.IsListType():
+			return cty.ListValEmpty(to.ElementType()), nil
+		case len(vals) == 0:
+			return cty.SetValEmpty(to.ElementType()), nil
+		case from.IsListType():
+			return cty.ListVal(vals), nil
+		}
+		return cty.SetVal(vals), nil
+	case from.IsMapType() || from.IsObjectType():
+		vals := map[string]cty.Value{}
+		for it := v.ElementIterator(); it.Next(); {
+			key, elem := it.Element()
+			var fromElem, toElem cty.Type
+			if from.IsObjectType() {
+				fromElem, toElem = from.AttributeType(key.AsString()), to.AttributeType(key.AsString())
+			} else {
+				fromElem, toElem = from.ElementType(), to.ElementType()
+			}
+			converted, err := rebuild(elem, fromElem, toElem, leaf)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			vals[key.AsString()] = converted
+		}
+		switch {
+		case from.IsObjectType():
+			if len(vals) == 0 {
+				return cty.EmptyObjectVal, nil
+			}
+			return cty.ObjectVal(vals), nil
+		case len(vals) == 0:
+			return cty.MapValEmpty(to.ElementType()), nil
+		}
+		return cty.MapVal(vals), nil
+	}
+	return v, nil
+}
+
+// MarshalType encodes ty as a JSON type specification like go-cty's
+// json.MarshalType, writing capsule types from the registry as
+// ["capsule", "<name>"] where go-cty fails
+func MarshalType(ty cty.Type) (json.RawMessage, error) {
+	if !typeHasCapsules(ty) {
+		return ctyjson.MarshalType(ty)
+	}
+	spec, err := typeSpec(ty)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(spec)
+}
+
+// typeSpec returns ty's JSON type specification as a value to encode
+func typeSpec(ty cty.Type) (interface{}, error) {
+	switch {
+	case ty.IsCapsuleType():
+		if _, ok := CapsuleType(ty.FriendlyName()); !ok {
+			return nil, fmt.Errorf("capsule type %s is not registered", ty.FriendlyName())
+		}
+		return []interface{}{"capsule", ty.FriendlyName()}, nil
+	case !typeHasCapsules(ty):
+		spec, err := ctyjson.MarshalType(ty)
+		return json.RawMessage(spec), err
+	case ty.IsListType() || ty.IsSetType() || ty.IsMapType():
+		elem, err := typeSpec(ty.ElementType())
+		if err != nil {
+			return nil, err
+		}
+		kind := "list"
+		if ty.IsSetType() {
+			kind = "set"
+		} else if ty.IsMapType() {
+			kind = "map"
+		}
+		return []interface{}{kind, elem}, nil
+	case ty.IsObjectType():
+		attrs := map[string]interface{}{}
+		var optional []string
+		for name, attrType := range ty.AttributeTypes() {
+			attr, err := typeSpec(attrType)
+			if err != nil {
+				return nil, err
+			}
+			attrs[name] = attr
+			if ty.AttributeOptional(name) {
+				optional = append(optional, name)
+			}
+		}
+		if len(optional) > 0 {
+			sort.Strings(optional)
+			return []interface{}{"object", attrs, optional}, nil
+		}
+		return []interface{}{"object", attrs}, nil
+	case ty.IsTupleType():
+		elems := []interface{}{}
+		for _, elemType := range ty.TupleElementTypes() {
+			elem, err := typeSpec(elemType)
+			if err != nil {
+				return nil, err
+			}
+			elems = append(elems, elem)
+		}
+		return []interface{}{"tuple", elems}, nil
+	}
+	return nil, fmt.Errorf("cannot marshal type %s", ty.FriendlyName())
+}
@@ -0,0 +1,702 @@
+// Package ctyspec parses the JSON type specifications soup-go accepts and
+// builds and converts go-cty values from them.
+package ctyspec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/limits"
+)
+
+// Convert decodes inputData in inputFormat (json or msgpack) and re-encodes
+// it in outputFormat using go-cty
+func Convert(ctyType cty.Type, inputData []byte, inputFormat, outputFormat string, opts Options) ([]byte, error) {
+	value, err := Decode(ctyType, inputData, inputFormat, opts)
+	if err != nil {
+		return nil, err
+	}
+	return Encode(value, ctyType, outputFormat, opts)
+}
+
+// Decode decodes a value of type ctyType from data in format (json or
+// msgpack)
+func Decode(ctyType cty.Type, data []byte, format string, opts Options) (cty.Value, error) {
+	switch format {
+	case "json":
+		value, err := BuildValue(ctyType, data, opts)
+		if err != nil {
+			return cty.NilVal, fmt.Errorf("failed to parse JSON input: %w", err)
+		}
+		return value, nil
+	case "msgpack":
+		if err := opts.Limits.CheckMsgpack(data); err != nil {
+			return cty.NilVal, fmt.Errorf("failed to unmarshal msgpack: %w", err)
+		}
+		value, err := UnmarshalMsgpack(data, ctyType)
+		if err != nil {
+			return cty.NilVal, fmt.Errorf("failed to unmarshal msgpack: %w", err)
+		}
+		return value, nil
+	default:
+		return cty.NilVal, fmt.Errorf("unsupported input format: %s", format)
+	}
+}
+
+// Encode encodes value as ctyType in format (json or msgpack)
+func Encode(value cty.Value, ctyType cty.Type, format string, opts Options) ([]byte, error) {
+	switch format {
+	case "json":
+		// go-cty writes an unknown within a dynamic value as an empty
+		// JSON value rather than failing on it
+		if !value.IsWhollyKnown() {
+			return nil, fmt.Errorf("failed to marshal to JSON: value is not known")
+		}
+		data, err := MarshalJSON(value, ctyType, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal to JSON: %w", err)
+		}
+		return data, nil
+	case "msgpack":
+		data, err := MarshalMsgpack(value, ctyType, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal to msgpack: %w", err)
+		}
+		return data, nil
+	case "hcl":
+		data, err := MarshalHCL(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal to HCL: %w", err)
+		}
+		return data, nil
+	default:
+		return nil, fmt.Errorf("unsupported output format: %s", format)
+	}
+}
+
+// ParseType parses a JSON type specification, as used by Terraform's
+// provider protocol: a primitive type name such as "string", or a
+// ["list", elem], ["object", {attrs}, [optional]] or ["tuple", [elems]]
+// array. ["capsule", "<name>"] declares and names a capsule type, as
+// RegisterCapsule. Specifications nested deeper than opts.Limits allows
+// are rejected.
+func ParseType(data json.RawMessage, opts Options) (cty.Type, error) {
+	return parseType(data, "$", 0, opts)
+}
+
+// parseType parses the specification found at path, depth levels into the
+// whole specification
+func parseType(data json.RawMessage, path string, depth int, opts Options) (cty.Type, error) {
+	if err := opts.Limits.CheckDepth(depth, path); err != nil {
+		return cty.NilType, err
+	}
+
+	var typeStr string
+	if err := json.Unmarshal(data, &typeStr); err == nil {
+		switch typeStr {
+		case "string":
+			return cty.String, nil
+		case "number":
+			return cty.Number, nil
+		case "bool":
+			return cty.Bool, nil
+		case "dynamic":
+			return cty.DynamicPseudoType, nil
+		default:
+			return cty.NilType, fmt.Errorf("unknown primitive type string: %s", typeStr)
+		}
+	}
+
+	var typeList []json.RawMessage
+	if err := json.Unmarshal(data, &typeList); err == nil {
+		if len(typeList) < 2 {
+			return cty.NilType, fmt.Errorf("type array must have at least 2 elements")
+		}
+		var typeKind string
+		if err := json.Unmarshal(typeList[0], &typeKind); err != nil {
+			return cty.NilType, err
+		}
+
+		switch typeKind {
+		case "list", "set", "map":
+			elemType, err := parseType(typeList[1], path+"[1]", depth+1, opts)
+			if err != nil {
+				return cty.NilType, err
+			}
+			if typeKind == "list" {
+				return cty.List(elemType), nil
+			}
+			if typeKind == "set" {
+				return cty.Set(elemType), nil
+			}
+			return cty.Map(elemType), nil
+		case "object":
+			var attrTypesRaw map[string]json.RawMessage
+			if err := json.Unmarshal(typeList[1], &attrTypesRaw); err != nil {
+				return cty.NilType, err
+			}
+			attrTypes := make(map[string]cty.Type)
+			for name, rawType := range attrTypesRaw {
+				attrType, err := parseType(rawType, path+"[1]."+name, depth+1, opts)
+				if err != nil {
+					return cty.NilType, err
+				}
+				attrTypes[name] = attrType
+			}
+			if len(typeList) > 2 {
+				var optionals []string
+				if err := json.Unmarshal(typeList[2], &optionals); err != nil {
+					return cty.NilType, err
+				}
+				for _, name := range optionals {
+					if _, ok := attrTypes[name]; !ok {
+						return cty.NilType, fmt.Errorf("optional attribute %q is not an attribute of the object", name)
+					}
+				}
+				return cty.ObjectWithOptionalAttrs(attrTypes, optionals), nil
+			}
+			return cty.Object(attrTypes), nil
+		case "capsule":
+			var name string
+			if err := json.Unmarshal(typeList[1], &name); err != nil {
+				return cty.NilType, fmt.Errorf("capsule type name must be a string: %w", err)
+			}
+			return RegisterCapsule(name)
+		case "tuple":
+			var elemTypesRaw []json.RawMessage
+			if err := json.Unmarshal(typeList[1], &elemTypesRaw); err != nil {
+				return cty.NilType, err
+			}
+			elemTypes := make([]cty.Type, len(elemTypesRaw))
+			for i, rawType := range elemTypesRaw {
+				elemType, err := parseType(rawType, fmt.Sprintf("%s[1][%d]", path, i), depth+1, opts)
+				if err != nil {
+					return cty.NilType, err
+				}
+				elemTypes[i] = elemType
+			}
+			return cty.Tuple(elemTypes), nil
+		default:
+			return cty.NilType, fmt.Errorf("unknown complex type kind: %s", typeKind)
+		}
+	}
+	return cty.NilType, fmt.Errorf("invalid type specification format")
+}
+
+// Number modes for JSON input. NumberModeFloat64 parses numbers through
+// float64 as encoding/json does, losing precision beyond it; NumberModeBig
+// parses their text into a big.Float at cty's full precision.
+const (
+	NumberModeFloat64 = "float64"
+	NumberModeBig     = "big"
+)
+
+// Options controls how values are built from JSON and encoded. soup-go
+// builds them from the flags of its cty and wire commands.
+type Options struct {
+	// StrictCoercion and LenientCoercion set how JSON primitives of the
+	// wrong kind are handled. By default only a number may be given as a
+	// decimal string; StrictCoercion rejects that too, and LenientCoercion
+	// additionally converts strings, numbers and bools between each other
+	// as Terraform's type conversion does, e.g. "true" to a bool and 5 to
+	// a string.
+	StrictCoercion  bool
+	LenientCoercion bool
+
+	// NumberMode is how BuildValue parses JSON numbers; empty is
+	// NumberModeFloat64
+	NumberMode string
+
+	// Canonical makes Encode, MarshalMsgpack and MarshalJSON write
+	// byte-stable output: JSON as CanonicalTypedJSON, and msgpack with set
+	// elements sorted by the bytes of their encoding. go-cty already sorts
+	// map keys and object attributes and renders numbers one way; only its
+	// set order is its own.
+	Canonical bool
+
+	// MsgpackUnknowns and MsgpackRefinements select the msgpack extensions
+	// MarshalMsgpack may write, for fixtures aimed at clients that lack
+	// them. Without MsgpackUnknowns a value holding unknowns fails to
+	// encode rather than writing extension 0; without MsgpackRefinements
+	// refined unknowns are written as plain unknowns, extension 0, rather
+	// than extension 12.
+	MsgpackUnknowns    bool
+	MsgpackRefinements bool
+
+	// Limits bounds the type specifications and values read
+	Limits limits.Config
+}
+
+// DefaultOptions returns the options soup-go uses when no flags are given
+func DefaultOptions() Options {
+	return Options{
+		NumberMode:         NumberModeFloat64,
+		MsgpackUnknowns:    true,
+		MsgpackRefinements: true,
+		Limits:             limits.DefaultConfig(),
+	}
+}
+
+// unmarshalJSON decodes data into an interface{} with numbers as float64,
+// or as json.Number under NumberModeBig
+func unmarshalJSON(data []byte, numberMode string) (interface{}, error) {
+	var val interface{}
+	switch numberMode {
+	case "", NumberModeFloat64:
+		err := json.Unmarshal(data, &val)
+		return val, err
+	case NumberModeBig:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if err := dec.Decode(&val); err != nil {
+			return nil, err
+		}
+		if _, err := dec.Token(); err != io.EOF {
+			return nil, fmt.Errorf("invalid data after top-level JSON value")
+		}
+		return val, nil
+	}
+	return nil, fmt.Errorf("unsupported number mode: %s", numberMode)
+}
+
+// BuildValue builds a cty.Value of type ty from JSON data. A dynamic type is
+// inferred from the data, with its numbers parsed under opts.NumberMode.
+func BuildValue(ty cty.Type, data []byte, opts Options) (cty.Value, error) {
+	// Parse the JSON to handle special cases
+	rawValue, err := unmarshalJSON(data, opts.NumberMode)
+	if err != nil {
+		return cty.NilVal, err
+	}
+
+	// Dynamic types are inferred from rawValue too, rather than from data,
+	// so they are held to the same depth limit and number mode as any other
+	// type
+	return buildValue(ty, rawValue, nil, opts)
+}
+
+// coercePrimitive converts a JSON primitive of another kind to the
+// primitive type ty with go-cty's convert package, with ok false when val
+// is not such a primitive
+func coercePrimitive(ty cty.Type, val interface{}, path cty.Path) (cty.Value, bool, error) {
+	var natural cty.Value
+	switch v := val.(type) {
+	case string:
+		natural = cty.StringVal(v)
+	case float64:
+		natural = cty.NumberFloatVal(v)
+	case json.Number:
+		n, err := cty.ParseNumberVal(v.String())
+		if err != nil {
+			return cty.NilVal, true, valueError(path, ty, val, err, "invalid number")
+		}
+		natural = n
+	case bool:
+		natural = cty.BoolVal(v)
+	default:
+		return cty.NilVal, false, nil
+	}
+	if natural.Type().Equals(ty) {
+		return cty.NilVal, false, nil
+	}
+	converted, err := convert.Convert(natural, ty)
+	if err != nil {
+		return cty.NilVal, true, valueError(path, ty, val, err, "cannot coerce %s to %s", natural.Type().FriendlyName(), ty.FriendlyName())
+	}
+	return converted, true, nil
+}
+
+// UnknownKey marks a JSON object as an unknown value rather than a value of
+// its type: {"__unknown": true} builds cty.UnknownVal of the type expected
+// there, and {"__unknown": true, "refinements": {...}} a refined unknown as
+// BuildRefinedUnknown. An unknown of a dynamic type is cty.DynamicVal, which
+// takes no refinements. JSON output cannot hold unknowns; msgpack encodes
+// them as extension 0, as Terraform does.
+const UnknownKey = "__unknown"
+
+// ValueJSON returns v as a JSON-encodable value in the form BuildValue
+// reads, with unknowns, however refined, as {"__unknown": true}, numbers
+// at full precision and marks dropped. Unlike cty's JSON encoding it
+// handles values that are not wholly known, for reports.
+func ValueJSON(v cty.Value) interface{} {
+	v, _ = v.UnmarkDeep()
+	switch {
+	case !v.IsKnown():
+		return map[string]interface{}{UnknownKey: true}
+	case v.IsNull():
+		return nil
+	}
+
+	ty := v.Type()
+	switch {
+	case ty == cty.String:
+		return v.AsString()
+	case ty == cty.Number:
+		bf := v.AsBigFloat()
+		if bf.IsInf() {
+			// JSON has no infinities; cty's JSON encoding fails on them
+			return bf.String()
+		}
+		return json.Number(bf.Text('f', -1))
+	case ty == cty.Bool:
+		return v.True()
+	case ty.IsCapsuleType():
+		return capsuleJSON(v)
+	case ty.IsListType() || ty.IsSetType() || ty.IsTupleType():
+		out := []interface{}{}
+		for it := v.ElementIterator(); it.Next(); {
+			_, elem := it.Element()
+			out = append(out, ValueJSON(elem))
+		}
+		return out
+	case ty.IsMapType() || ty.IsObjectType():
+		out := map[string]interface{}{}
+		for it := v.ElementIterator(); it.Next(); {
+			key, elem := it.Element()
+			out[key.AsString()] = ValueJSON(elem)
+		}
+		return out
+	}
+	return v.GoString()
+}
+
+// unknownSentinel returns the refinements of val if it is an unknown
+// sentinel object, with ok false when it is not one. An object with other
+// keys is an ordinary value.
+func unknownSentinel(val interface{}) (refinements interface{}, ok bool) {
+	m, isObject := val.(map[string]interface{})
+	if !isObject {
+		return nil, false
+	}
+	if marker, _ := m[UnknownKey].(bool); !marker {
+		return nil, false
+	}
+	for k := range m {
+		if k != UnknownKey && k != "refinements" {
+			return nil, false
+		}
+	}
+	return m["refinements"], true
+}
+
+// buildUnknown builds the unknown value of type ty for a sentinel,
+// reporting refinements that do not apply to ty as errors
+func buildUnknown(ty cty.Type, refinements interface{}, path cty.Path) (val cty.Value, err error) {
+	if refinements == nil || ty == cty.DynamicPseudoType {
+		return cty.UnknownVal(ty), nil
+	}
+	// go-cty panics on refinements that do not apply to the type, such
+	// as a string prefix on a number
+	defer func() {
+		if r := recover(); r != nil {
+			val, err = cty.NilVal, &ValueError{Path: path, Expected: ty, Received: "object", Reason: "invalid refinements for " + ty.FriendlyName(), Err: fmt.Errorf("%v", r)}
+		}
+	}()
+	return BuildRefinedUnknown(ty, refinements)
+}
+
+// buildDynamicValue builds a value whose type is inferred from the decoded
+// JSON val, keeping unknown sentinels within it
+func buildDynamicValue(val interface{}, path cty.Path, opts Options) (cty.Value, error) {
+	switch v := val.(type) {
+	case []interface{}:
+		vals := make([]cty.Value, len(v))
+		for i, elem := range v {
+			elemVal, err := buildValue(cty.DynamicPseudoType, elem, path.Index(cty.NumberIntVal(int64(i))), opts)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			vals[i] = elemVal
+		}
+		return cty.TupleVal(vals), nil
+	case map[string]interface{}:
+		vals := make(map[string]cty.Value, len(v))
+		for k, elem := range v {
+			elemVal, err := buildValue(cty.DynamicPseudoType, elem, path.GetAttr(k), opts)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			vals[k] = elemVal
+		}
+		return cty.ObjectVal(vals), nil
+	}
+
+	data, err := json.Marshal(val)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	inferredType, err := ctyjson.ImpliedType(data)
+	if err != nil {
+		return cty.NilVal, valueError(path, cty.DynamicPseudoType, val, err, "cannot infer type")
+	}
+	return ctyjson.Unmarshal(data, inferredType)
+}
+
+// buildValue recursively builds a cty.Value from a decoded JSON value
+func buildValue(ty cty.Type, val interface{}, path cty.Path, opts Options) (cty.Value, error) {
+	if err := opts.Limits.CheckDepth(len(path), FormatPath(path)); err != nil {
+		return cty.NilVal, err
+	}
+	if val == nil {
+		return cty.NullVal(ty), nil
+	}
+
+	// Note: go-cty does NOT support unknown values in JSON format, so they
+	// are written as UnknownKey sentinels in input. Unknown values can only
+	// be properly represented in MessagePack; attempting to marshal an
+	// unknown value to JSON will result in an error: "value is not known"
+	// This matches Terraform's behavior exactly
+	if refinements, ok := unknownSentinel(val); ok {
+		return buildUnknown(ty, refinements, path)
+	}
+	if ty == cty.DynamicPseudoType {
+		return buildDynamicValue(val, path, opts)
+	}
+	if ty.IsCapsuleType() {
+		return buildCapsule(ty, val, path)
+	}
+
+	if ty.IsPrimitiveType() && opts.LenientCoercion {
+		if v, ok, err := coercePrimitive(ty, val, path); ok {
+			return v, err
+		}
+	}
+
+	// Handle primitive types
+	switch ty {
+	case cty.String:
+		if s, ok := val.(string); ok {
+			return cty.StringVal(s), nil
+		}
+		return cty.NilVal, valueError(path, ty, val, nil, "expected string")
+	case cty.Number:
+		switch v := val.(type) {
+		case float64:
+			return cty.NumberFloatVal(v), nil
+		case json.Number:
+			n, err := cty.ParseNumberVal(v.String())
+			if err != nil {
+				return cty.NilVal, valueError(path, ty, val, err, "invalid number")
+			}
+			return n, nil
+		case int:
+			return cty.NumberIntVal(int64(v)), nil
+		case int64:
+			return cty.NumberIntVal(v), nil
+		case string:
+			if opts.StrictCoercion {
+				return cty.NilVal, valueError(path, ty, val, nil, "expected number, got string (strict)")
+			}
+			bf := new(big.Float)
+			if _, ok := bf.SetString(v); ok {
+				return cty.NumberVal(bf), nil
+			}
+			return cty.NilVal, valueError(path, ty, val, nil, "invalid number string")
+		}
+		return cty.NilVal, valueError(path, ty, val, nil, "expected number")
+	case cty.Bool:
+		if b, ok := val.(bool); ok {
+			return cty.BoolVal(b), nil
+		}
+		return cty.NilVal, valueError(path, ty, val, nil, "expected bool")
+	}
+
+	// Handle collection types
+	if ty.IsListType() || ty.IsSetType() || ty.IsTupleType() {
+		slice, ok := val.([]interface{})
+		if !ok {
+			return cty.NilVal, valueError(path, ty, val, nil, "expected array")
+		}
+
+		vals := make([]cty.Value, len(slice))
+		for i, elem := range slice {
+			var elemTy cty.Type
+			if ty.IsTupleType() {
+				elemTy = ty.TupleElementType(i)
+			} else {
+				elemTy = ty.ElementType()
+			}
+			elemVal, err := buildValue(elemTy, elem, path.Index(cty.NumberIntVal(int64(i))), opts)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			vals[i] = elemVal
+		}
+		if !ty.IsTupleType() {
+			var err error
+			if vals, err = unifyElements(ty, vals, path, val); err != nil {
+				return cty.NilVal, err
+			}
+		}
+
+		if ty.IsListType() {
+			if len(vals) == 0 {
+				return cty.ListValEmpty(ty.ElementType()), nil
+			}
+			return cty.ListVal(vals), nil
+		}
+		if ty.IsSetType() {
+			if len(vals) == 0 {
+				return cty.SetValEmpty(ty.ElementType()), nil
+			}
+			return cty.SetVal(vals), nil
+		}
+		return cty.TupleVal(vals), nil
+	}
+
+	// Handle map and object types
+	if ty.IsMapType() || ty.IsObjectType() {
+		m, ok := val.(map[string]interface{})
+		if !ok {
+			return cty.NilVal, valueError(path, ty, val, nil, "expected object")
+		}
+
+		vals := make(map[string]cty.Value)
+		for k, v := range m {
+			var elemTy cty.Type
+			var elemPath cty.Path
+			if ty.IsObjectType() {
+				if !ty.HasAttribute(k) {
+					return cty.NilVal, valueError(path, ty, val, nil, "unsupported attribute %q", k)
+				}
+				elemTy, elemPath = ty.AttributeType(k), path.GetAttr(k)
+			} else {
+				elemTy, elemPath = ty.ElementType(), path.Index(cty.StringVal(k))
+			}
+			elemVal, err := buildValue(elemTy, v, elemPath, opts)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			vals[k] = elemVal
+		}
+
+		if ty.IsMapType() {
+			if len(vals) == 0 {
+				return cty.MapValEmpty(ty.ElementType()), nil
+			}
+			keys := make([]string, 0, len(vals))
+			elems := make([]cty.Value, 0, len(vals))
+			for k, v := range vals {
+				keys = append(keys, k)
+				elems = append(elems, v)
+			}
+			elems, err := unifyElements(ty, elems, path, val)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			for i, k := range keys {
+				vals[k] = elems[i]
+			}
+			return cty.MapVal(vals), nil
+		}
+		// Absent attributes are null, as in go-cty's JSON decoding
+		for name, attrTy := range ty.AttributeTypes() {
+			if _, ok := vals[name]; !ok {
+				vals[name] = cty.NullVal(attrTy.WithoutOptionalAttributesDeep())
+			}
+		}
+		return cty.ObjectVal(vals), nil
+	}
+
+	return cty.NilVal, valueError(path, ty, val, nil, "cannot build value for type %s", ty.FriendlyName())
+}
+
+// unifyElements converts the elements of a list, set or map of type ty
+// whose element type is or contains dynamic to the one type go-cty unifies
+// their types to, as Terraform does for list(any), since a collection's
+// elements must all have the same type. Elements of a dynamic type, such
+// as unknowns given no type, take the unified type too.
+func unifyElements(ty cty.Type, vals []cty.Value, path cty.Path, val interface{}) ([]cty.Value, error) {
+	if !ty.ElementType().HasDynamicTypes() {
+		return vals, nil
+	}
+	var types []cty.Type
+	for _, v := range vals {
+		if v.Type() != cty.DynamicPseudoType {
+			types = append(types, v.Type())
+		}
+	}
+	if len(types) == 0 {
+		return vals, nil
+	}
+	unified, _ := convert.Unify(types)
+	if unified == cty.NilType {
+		return nil, valueError(path, ty, val, nil, "elements have no common type")
+	}
+	out := make([]cty.Value, len(vals))
+	for i, v := range vals {
+		converted, err := convert.Convert(v, unified)
+		if err != nil {
+			return nil, valueError(path, ty, val, err, "cannot convert elements to %s", unified.FriendlyName())
+		}
+		out[i] = converted
+	}
+	return out, nil
+}
+
+// BuildRefinedUnknown builds a refined unknown value from refinement data
+func BuildRefinedUnknown(ty cty.Type, refinementsData interface{}) (cty.Value, error) {
+	refinements, ok := refinementsData.(map[string]interface{})
+	if !ok {
+		return cty.NilVal, fmt.Errorf("refinements must be an object")
+	}
+
+	builder := cty.UnknownVal(ty).Refine()
+
+	if isNull, ok := refinements["is_known_null"].(bool); ok {
+		if isNull {
+			builder = builder.Null()
+		} else {
+			builder = builder.NotNull()
+		}
+	}
+
+	if prefix, ok := refinements["string_prefix"].(string); ok {
+		builder = builder.StringPrefix(prefix)
+	}
+
+	if lowerBound, ok := refinements["number_lower_bound"].([]interface{}); ok && len(lowerBound) >= 2 {
+		numStr, _ := lowerBound[0].(string)
+		inclusive, _ := lowerBound[1].(bool)
+		bf := new(big.Float)
+		bf.SetString(numStr)
+		builder = builder.NumberRangeLowerBound(cty.NumberVal(bf), inclusive)
+	}
+
+	if upperBound, ok := refinements["number_upper_bound"].([]interface{}); ok && len(upperBound) >= 2 {
+		numStr, _ := upperBound[0].(string)
+		inclusive, _ := upperBound[1].(bool)
+		bf := new(big.Float)
+		bf.SetString(numStr)
+		builder = builder.NumberRangeUpperBound(cty.NumberVal(bf), inclusive)
+	}
+
+	if lower, ok := jsonInt(refinements["collection_length_lower_bound"]); ok {
+		builder = builder.CollectionLengthLowerBound(lower)
+	}
+
+	if upper, ok := jsonInt(refinements["collection_length_upper_bound"]); ok {
+		builder = builder.CollectionLengthUpperBound(upper)
+	}
+
+	return builder.NewValue(), nil
+}
+
+// jsonInt returns a decoded JSON number as an int, whichever number mode
+// it was decoded in
+func jsonInt(val interface{}) (int, bool) {
+	switch v := val.(type) {
+	case float64:
+		return int(v), true
+	case json.Number:
+		n, err := v.Int64()
+		return int(n), err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,76 @@
+package ctyspec
+
+import (
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// ApplyDefaults fills the null and missing optional attributes of v with
+// their defaults and converts the result to ty, in the order Terraform uses
+// for input variables. It also returns the paths of the attributes that
+// took a default; those inside sets are not reported, since set elements
+// change identity when defaulted.
+func ApplyDefaults(v cty.Value, ty cty.Type, defaults *typeexpr.Defaults) (cty.Value, []cty.Path, error) {
+	applied := v
+	if defaults != nil && !v.IsNull() {
+		applied = defaults.Apply(v)
+	}
+	var paths []cty.Path
+	defaultedPaths(v, true, applied, nil, &paths)
+	converted, err := convert.Convert(applied, ty)
+	if err != nil {
+		return cty.NilVal, nil, err
+	}
+	return converted, paths, nil
+}
+
+// defaultedPaths appends the paths where after has a value but before,
+// present or not, had none
+func defaultedPaths(before cty.Value, present bool, after cty.Value, path cty.Path, paths *[]cty.Path) {
+	if !after.IsKnown() || after.IsNull() {
+		return
+	}
+	if !present || before.IsNull() {
+		*paths = append(*paths, path)
+		return
+	}
+	if !before.IsKnown() {
+		return
+	}
+
+	before, _ = before.Unmark()
+	after, _ = after.Unmark()
+	ty := after.Type()
+	switch {
+	case ty.IsObjectType() || ty.IsMapType():
+		for it := after.ElementIterator(); it.Next(); {
+			key, elem := it.Element()
+			name := key.AsString()
+			var prev cty.Value
+			var ok bool
+			if before.Type().IsObjectType() {
+				if ok = before.Type().HasAttribute(name); ok {
+					prev = before.GetAttr(name)
+				}
+			} else if ok = before.HasIndex(key).True(); ok {
+				prev = before.Index(key)
+			}
+			elemPath := path.Index(key)
+			if ty.IsObjectType() {
+				elemPath = path.GetAttr(name)
+			}
+			defaultedPaths(prev, ok, elem, elemPath, paths)
+		}
+	case ty.IsListType() || ty.IsTupleType():
+		for it := after.ElementIterator(); it.Next(); {
+			key, elem := it.Element()
+			ok := before.HasIndex(key).True()
+			var prev cty.Value
+			if ok {
+				prev = before.Index(key)
+			}
+			defaultedPaths(prev, ok, elem, path.Index(key), paths)
+		}
+	}
+}
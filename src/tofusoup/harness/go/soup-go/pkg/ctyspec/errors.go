@@ -0,0 +1,97 @@
+package ctyspec
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ValueError is a JSON value that does not fit the type expected where it
+// was found, as reported by BuildValue
+type ValueError struct {
+	// Path locates the value within the whole
+	Path cty.Path
+	// Expected is the type expected at Path
+	Expected cty.Type
+	// Received is the kind of JSON value found there: null, bool, number,
+	// string, array or object
+	Received string
+	// Reason describes the failure without its location
+	Reason string
+	// Err is the underlying error, if any
+	Err error
+}
+
+func (e *ValueError) Error() string {
+	msg := e.Reason
+	if len(e.Path) > 0 {
+		msg += " at " + FormatPath(e.Path)
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *ValueError) Unwrap() error {
+	return e.Err
+}
+
+// valueError returns a ValueError for the decoded JSON val at path
+func valueError(path cty.Path, expected cty.Type, val interface{}, err error, reason string, args ...interface{}) *ValueError {
+	return &ValueError{
+		Path:     path,
+		Expected: expected,
+		Received: JSONKind(val),
+		Reason:   fmt.Sprintf(reason, args...),
+		Err:      err,
+	}
+}
+
+// JSONKind names the kind of a value decoded by encoding/json
+func JSONKind(val interface{}) string {
+	switch val.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case float64, json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", val)
+}
+
+// FormatPath renders a cty.Path using HCL traversal syntax, e.g. foo[0]["k"]
+func FormatPath(path cty.Path) string {
+	var b strings.Builder
+	for _, step := range path {
+		switch s := step.(type) {
+		case cty.GetAttrStep:
+			if b.Len() > 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString(s.Name)
+		case cty.IndexStep:
+			switch {
+			case !s.Key.IsKnown() || s.Key.IsNull():
+				b.WriteString("[?]")
+			case s.Key.Type() == cty.String:
+				quoted, _ := json.Marshal(s.Key.AsString())
+				fmt.Fprintf(&b, "[%s]", quoted)
+			case s.Key.Type() == cty.Number:
+				fmt.Fprintf(&b, "[%s]", s.Key.AsBigFloat().Text('f', -1))
+			default:
+				b.WriteString("[<" + s.Key.Type().FriendlyName() + ">]")
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,196 @@
+package ctyspec
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// stringRunes are the runes generated strings are drawn from, with some
+// outside ASCII so corpora exercise UTF-8 handling
+var stringRunes = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789 _-./éß✓🍲")
+
+// keyRunes are the runes generated map keys are drawn from
+var keyRunes = []rune("abcdefghijklmnopqrstuvwxyz0123456789_")
+
+// Generator produces random values of a type. The same seed yields the
+// same values, so generated corpora can be reproduced.
+type Generator struct {
+	rand *rand.Rand
+	// MaxElements bounds the length of generated lists, sets and maps
+	MaxElements int
+	// MaxStringLength bounds the length in runes of generated strings
+	MaxStringLength int
+}
+
+// NewGenerator returns a Generator seeded with seed
+func NewGenerator(seed int64) *Generator {
+	return &Generator{
+		rand:            rand.New(rand.NewSource(seed)),
+		MaxElements:     3,
+		MaxStringLength: 8,
+	}
+}
+
+// Generate returns a random known value of ty. Optional object attributes
+// are null about half the time; a dynamic type yields a random primitive.
+// The value has ty's optional attribute markers removed, as values do.
+func (g *Generator) Generate(ty cty.Type) (cty.Value, error) {
+	return g.generate(ty, 0)
+}
+
+func (g *Generator) generate(ty cty.Type, depth int) (cty.Value, error) {
+	if depth > 64 {
+		return cty.NilVal, fmt.Errorf("type %s nests too deeply to generate", ty.FriendlyName())
+	}
+
+	switch {
+	case ty == cty.String:
+		return cty.StringVal(g.string(stringRunes, 0, g.MaxStringLength)), nil
+	case ty == cty.Number:
+		if g.rand.Intn(2) == 0 {
+			return cty.NumberIntVal(g.rand.Int63n(2001) - 1000), nil
+		}
+		return cty.NumberFloatVal(float64(g.rand.Int63n(200001)-100000) / 100), nil
+	case ty == cty.Bool:
+		return cty.BoolVal(g.rand.Intn(2) == 0), nil
+	case ty == cty.DynamicPseudoType:
+		return g.generate(g.concrete(ty), depth+1)
+	case ty.IsCapsuleType():
+		payload := make([]byte, g.rand.Intn(g.MaxStringLength+1))
+		g.rand.Read(payload)
+		return CapsuleVal(ty, payload), nil
+	case ty.IsListType() || ty.IsSetType():
+		elemType := g.concrete(ty.ElementType())
+		n := g.rand.Intn(g.MaxElements + 1)
+		vals := make([]cty.Value, n)
+		for i := range vals {
+			v, err := g.generate(elemType, depth+1)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			vals[i] = v
+		}
+		if ty.IsListType() {
+			if n == 0 {
+				return cty.ListValEmpty(elemType.WithoutOptionalAttributesDeep()), nil
+			}
+			return cty.ListVal(vals), nil
+		}
+		if n == 0 {
+			return cty.SetValEmpty(elemType.WithoutOptionalAttributesDeep()), nil
+		}
+		return cty.SetVal(vals), nil
+	case ty.IsMapType():
+		elemType := g.concrete(ty.ElementType())
+		n := g.rand.Intn(g.MaxElements + 1)
+		vals := make(map[string]cty.Value, n)
+		for i := 0; i < n; i++ {
+			v, err := g.generate(elemType, depth+1)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			vals[g.string(keyRunes, 1, 6)] = v
+		}
+		if len(vals) == 0 {
+			return cty.MapValEmpty(elemType.WithoutOptionalAttributesDeep()), nil
+		}
+		return cty.MapVal(vals), nil
+	case ty.IsObjectType():
+		// Attributes are generated in name order so the seed alone fixes
+		// the value
+		attrTypes := ty.AttributeTypes()
+		names := make([]string, 0, len(attrTypes))
+		for name := range attrTypes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		vals := make(map[string]cty.Value, len(names))
+		for _, name := range names {
+			attrType := attrTypes[name]
+			if ty.AttributeOptional(name) && g.rand.Intn(2) == 0 {
+				vals[name] = cty.NullVal(attrType.WithoutOptionalAttributesDeep())
+				continue
+			}
+			v, err := g.generate(attrType, depth+1)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			vals[name] = v
+		}
+		if len(vals) == 0 {
+			return cty.EmptyObjectVal, nil
+		}
+		return cty.ObjectVal(vals), nil
+	case ty.IsTupleType():
+		elemTypes := ty.TupleElementTypes()
+		if len(elemTypes) == 0 {
+			return cty.EmptyTupleVal, nil
+		}
+		vals := make([]cty.Value, len(elemTypes))
+		for i, elemType := range elemTypes {
+			v, err := g.generate(elemType, depth+1)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			vals[i] = v
+		}
+		return cty.TupleVal(vals), nil
+	}
+	return cty.NilVal, fmt.Errorf("cannot generate values of type %s", ty.FriendlyName())
+}
+
+// concrete replaces the dynamic types within ty with random primitive
+// types, so all elements of a collection share one type
+func (g *Generator) concrete(ty cty.Type) cty.Type {
+	switch {
+	case ty == cty.DynamicPseudoType:
+		primitives := []cty.Type{cty.String, cty.Number, cty.Bool}
+		return primitives[g.rand.Intn(len(primitives))]
+	case !ty.HasDynamicTypes():
+		return ty
+	case ty.IsListType():
+		return cty.List(g.concrete(ty.ElementType()))
+	case ty.IsSetType():
+		return cty.Set(g.concrete(ty.ElementType()))
+	case ty.IsMapType():
+		return cty.Map(g.concrete(ty.ElementType()))
+	case ty.IsObjectType():
+		names := make([]string, 0, len(ty.AttributeTypes()))
+		for name := range ty.AttributeTypes() {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		attrTypes := make(map[string]cty.Type)
+		var optional []string
+		for _, name := range names {
+			attrTypes[name] = g.concrete(ty.AttributeType(name))
+			if ty.AttributeOptional(name) {
+				optional = append(optional, name)
+			}
+		}
+		return cty.ObjectWithOptionalAttrs(attrTypes, optional)
+	case ty.IsTupleType():
+		elemTypes := make([]cty.Type, len(ty.TupleElementTypes()))
+		for i, elemType := range ty.TupleElementTypes() {
+			elemTypes[i] = g.concrete(elemType)
+		}
+		return cty.Tuple(elemTypes)
+	}
+	return ty
+}
+
+// string returns a random string of min to max runes drawn from runes
+func (g *Generator) string(runes []rune, min, max int) string {
+	if max < min {
+		max = min
+	}
+	n := min + g.rand.Intn(max-min+1)
+	out := make([]rune, n)
+	for i := range out {
+		out[i] = runes[g.rand.Intn(len(runes))]
+	}
+	return string(out)
+}
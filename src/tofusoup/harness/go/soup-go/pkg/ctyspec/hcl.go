@@ -0,0 +1,24 @@
+package ctyspec
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// MarshalHCL renders v as a formatted HCL literal expression, such as
+// {name = "a", ports = [80, 443]} spread over lines, ending in a newline.
+// Like JSON, HCL literals cannot hold unknown values; capsules have no
+// literal form either.
+func MarshalHCL(v cty.Value) ([]byte, error) {
+	v, _ = v.UnmarkDeep()
+	if !v.IsWhollyKnown() {
+		return nil, fmt.Errorf("value is not known")
+	}
+	if typeHasCapsules(v.Type()) {
+		return nil, fmt.Errorf("capsule values have no HCL literal form")
+	}
+	src := hclwrite.Format(hclwrite.TokensForValue(v).Bytes())
+	return append(src, '\n'), nil
+}
@@ -0,0 +1,105 @@
+package ctyspec
+
+import (
+	"fmt"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Conflict policies for Merge: which side's value to keep where both
+// values hold different leaves, or to fail
+const (
+	MergeKeepLeft  = "left"
+	MergeKeepRight = "right"
+	MergeError     = "error"
+)
+
+// MergeConflictError is a conflict Merge found under MergeError
+type MergeConflictError struct {
+	Path cty.Path
+}
+
+func (e *MergeConflictError) Error() string {
+	if len(e.Path) == 0 {
+		return "conflicting values"
+	}
+	return "conflicting values at " + FormatPath(e.Path)
+}
+
+// Merge deep-merges right into left, values of the same type. Objects and
+// maps are merged attribute by attribute and key by key; anything else,
+// including lists, sets and tuples, is a leaf. A null on either side takes
+// the other side's value, and leaves that differ are resolved by policy.
+// Marks are kept: a merged object or map carries the marks of both sides,
+// and a leaf those of the side it came from.
+func Merge(left, right cty.Value, policy string) (cty.Value, error) {
+	switch policy {
+	case MergeKeepLeft, MergeKeepRight, MergeError:
+	default:
+		return cty.NilVal, fmt.Errorf("unsupported conflict policy: %s", policy)
+	}
+	if !left.Type().Equals(right.Type()) {
+		return cty.NilVal, fmt.Errorf("cannot merge %s with %s", left.Type().FriendlyName(), right.Type().FriendlyName())
+	}
+	return mergeValues(left, right, policy, nil)
+}
+
+// mergeValues merges the values at path
+func mergeValues(left, right cty.Value, policy string, path cty.Path) (cty.Value, error) {
+	switch {
+	case right.IsNull():
+		return left, nil
+	case left.IsNull():
+		return right, nil
+	}
+
+	l, leftMarks := left.Unmark()
+	r, rightMarks := right.Unmark()
+	ty := l.Type()
+	if !l.IsKnown() || !r.IsKnown() || !(ty.IsObjectType() || ty.IsMapType()) {
+		switch {
+		case left.RawEquals(right):
+			return left, nil
+		case policy == MergeKeepLeft:
+			return left, nil
+		case policy == MergeKeepRight:
+			return right, nil
+		}
+		return cty.NilVal, &MergeConflictError{Path: path}
+	}
+
+	merged := map[string]cty.Value{}
+	for it := l.ElementIterator(); it.Next(); {
+		key, elem := it.Element()
+		merged[key.AsString()] = elem
+	}
+	for it := r.ElementIterator(); it.Next(); {
+		key, elem := it.Element()
+		name := key.AsString()
+		prev, ok := merged[name]
+		if !ok {
+			merged[name] = elem
+			continue
+		}
+		elemPath := path.Index(key)
+		if ty.IsObjectType() {
+			elemPath = path.GetAttr(name)
+		}
+		value, err := mergeValues(prev, elem, policy, elemPath)
+		if err != nil {
+			return cty.NilVal, err
+		}
+		merged[name] = value
+	}
+
+	var result cty.Value
+	switch {
+	case ty.IsObjectType():
+		result = cty.ObjectVal(merged)
+	case len(merged) == 0:
+		result = cty.MapValEmpty(ty.ElementType())
+	default:
+		result = cty.MapVal(merged)
+	}
+	return result.WithMarks(leftMarks, rightMarks), nil
+}
@@ -0,0 +1,45 @@
+package ctyspec
+
+import (
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Normalize rewrites v to a canonical form so that values differential
+// tests should treat as alike compare equal:
+//
+//   - every unknown, however refined, becomes a plain unknown of its type
+//   - null and empty lists, sets and maps become null, or with
+//     emptyCollections an empty collection of their type
+//   - marks are dropped
+//
+// Objects and tuples are left as they are: their nulls and empty values
+// differ in type, not just in representation.
+func Normalize(v cty.Value, emptyCollections bool) (cty.Value, error) {
+	v, _ = v.UnmarkDeep()
+	return cty.Transform(v, func(_ cty.Path, v cty.Value) (cty.Value, error) {
+		ty := v.Type()
+		switch {
+		case !v.IsKnown():
+			return cty.UnknownVal(ty), nil
+		case !ty.IsCollectionType():
+			return v, nil
+		case v.IsNull() && emptyCollections:
+			return emptyCollection(ty), nil
+		case !v.IsNull() && v.LengthInt() == 0 && !emptyCollections:
+			return cty.NullVal(ty), nil
+		}
+		return v, nil
+	})
+}
+
+// emptyCollection returns the empty list, set or map of type ty
+func emptyCollection(ty cty.Type) cty.Value {
+	switch {
+	case ty.IsListType():
+		return cty.ListValEmpty(ty.ElementType())
+	case ty.IsSetType():
+		return cty.SetValEmpty(ty.ElementType())
+	default:
+		return cty.MapValEmpty(ty.ElementType())
+	}
+}
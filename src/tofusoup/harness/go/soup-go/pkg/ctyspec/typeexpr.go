@@ -0,0 +1,164 @@
+package ctyspec
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ParseTypeSpec parses a type given either as a JSON type specification,
+// as ParseType, or as an HCL type expression such as
+// list(object({name=string, tags=optional(map(string))})). An expression
+// may also be given as a JSON string, so "list(string)" parses as
+// list(string).
+func ParseTypeSpec(data []byte, opts Options) (cty.Type, error) {
+	if src, ok := typeExprSource(data); ok {
+		return ParseTypeExpr(src)
+	}
+	return ParseType(data, opts)
+}
+
+// ParseTypeSpecWithDefaults is ParseTypeSpec, but also accepts optional
+// attribute defaults in a type expression, as in
+// object({port=optional(number, 80)}), and returns them. Defaults are nil
+// when the spec has none.
+func ParseTypeSpecWithDefaults(data []byte, opts Options) (cty.Type, *typeexpr.Defaults, error) {
+	src, ok := typeExprSource(data)
+	if !ok {
+		ty, err := ParseType(data, opts)
+		return ty, nil, err
+	}
+	ty, defaults, err := ParseTypeConstraint(src)
+	if err != nil || !hasDefaults(defaults) {
+		return ty, nil, err
+	}
+	return ty, defaults, nil
+}
+
+// typeExprSource returns the type expression data holds, with ok false
+// when data is a JSON type specification
+func typeExprSource(data []byte) (string, bool) {
+	if !json.Valid(data) {
+		return string(data), true
+	}
+	var src string
+	if err := json.Unmarshal(data, &src); err == nil && !isPrimitiveTypeName(src) {
+		return src, true
+	}
+	return "", false
+}
+
+// isPrimitiveTypeName reports whether name is a primitive type in a JSON
+// type specification
+func isPrimitiveTypeName(name string) bool {
+	switch name {
+	case "string", "number", "bool", "dynamic":
+		return true
+	}
+	return false
+}
+
+// ParseTypeExpr parses an HCL type expression, accepting "any" and
+// optional attributes as Terraform variable types do. Optional attribute
+// defaults have no place in a type and are rejected.
+func ParseTypeExpr(src string) (cty.Type, error) {
+	ty, defaults, err := ParseTypeConstraint(src)
+	if err != nil {
+		return cty.NilType, err
+	}
+	if hasDefaults(defaults) {
+		return cty.NilType, fmt.Errorf("invalid type constraint: optional attribute defaults are not allowed here")
+	}
+	return ty, nil
+}
+
+// ParseTypeConstraint parses a type constraint expression, including
+// optional attribute defaults
+func ParseTypeConstraint(src string) (cty.Type, *typeexpr.Defaults, error) {
+	expr, diags := hclsyntax.ParseExpression([]byte(src), "type", hcl.InitialPos)
+	if diags.HasErrors() {
+		return cty.NilType, nil, fmt.Errorf("invalid type constraint: %s", diags.Error())
+	}
+	ty, defaults, diags := typeexpr.TypeConstraintWithDefaults(expr)
+	if diags.HasErrors() {
+		return cty.NilType, nil, fmt.Errorf("invalid type constraint: %s", diags.Error())
+	}
+	return ty, defaults, nil
+}
+
+// FormatType returns ty as an HCL type expression in the canonical form of
+// typeexpr.TypeString, which ParseTypeExpr reads back. Unlike TypeString
+// it keeps optional attributes, as optional(type).
+func FormatType(ty cty.Type) (string, error) {
+	switch {
+	case ty == cty.String, ty == cty.Number, ty == cty.Bool, ty == cty.DynamicPseudoType:
+		return typeexpr.TypeString(ty), nil
+	case ty.IsListType() || ty.IsSetType() || ty.IsMapType():
+		elem, err := FormatType(ty.ElementType())
+		if err != nil {
+			return "", err
+		}
+		kind := "list"
+		if ty.IsSetType() {
+			kind = "set"
+		} else if ty.IsMapType() {
+			kind = "map"
+		}
+		return kind + "(" + elem + ")", nil
+	case ty.IsObjectType():
+		attrTypes := ty.AttributeTypes()
+		names := make([]string, 0, len(attrTypes))
+		for name := range attrTypes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		attrs := make([]string, len(names))
+		for i, name := range names {
+			attr, err := FormatType(attrTypes[name])
+			if err != nil {
+				return "", err
+			}
+			if ty.AttributeOptional(name) {
+				attr = "optional(" + attr + ")"
+			}
+			if !hclsyntax.ValidIdentifier(name) {
+				name = fmt.Sprintf("%q", name)
+			}
+			attrs[i] = name + "=" + attr
+		}
+		return "object({" + strings.Join(attrs, ",") + "})", nil
+	case ty.IsTupleType():
+		elems := make([]string, len(ty.TupleElementTypes()))
+		for i, elemType := range ty.TupleElementTypes() {
+			elem, err := FormatType(elemType)
+			if err != nil {
+				return "", err
+			}
+			elems[i] = elem
+		}
+		return "tuple([" + strings.Join(elems, ",") + "])", nil
+	}
+	return "", fmt.Errorf("cannot format type %s as a type expression", ty.GoString())
+}
+
+// hasDefaults reports whether d holds a default value at any depth
+func hasDefaults(d *typeexpr.Defaults) bool {
+	if d == nil {
+		return false
+	}
+	if len(d.DefaultValues) > 0 {
+		return true
+	}
+	for _, child := range d.Children {
+		if hasDefaults(child) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package ctyspec
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ValidateTypeSpec checks a JSON type specification more strictly than
+// ParseType, which ignores what it does not need: arrays must have exactly
+// the elements their kind takes, and optional attribute names must be
+// unique. Errors name the offending part of the spec as a path such as
+// $[1].tags.
+func ValidateTypeSpec(data json.RawMessage) error {
+	return validateTypeSpec(data, "$")
+}
+
+func validateTypeSpec(data json.RawMessage, path string) error {
+	var typeStr string
+	if err := json.Unmarshal(data, &typeStr); err == nil {
+		if !isPrimitiveTypeName(typeStr) {
+			return fmt.Errorf("%s: unknown primitive type %q", path, typeStr)
+		}
+		return nil
+	}
+
+	var typeList []json.RawMessage
+	if err := json.Unmarshal(data, &typeList); err != nil {
+		return fmt.Errorf("%s: a type must be a primitive type name or an array", path)
+	}
+	if len(typeList) == 0 {
+		return fmt.Errorf("%s: empty type array", path)
+	}
+	var kind string
+	if err := json.Unmarshal(typeList[0], &kind); err != nil {
+		return fmt.Errorf("%s[0]: type kind must be a string", path)
+	}
+
+	want := 2
+	if kind == "object" && len(typeList) == 3 {
+		want = 3
+	}
+	if len(typeList) != want {
+		return fmt.Errorf("%s: %q type takes %d elements, got %d", path, kind, want, len(typeList))
+	}
+
+	switch kind {
+	case "list", "set", "map":
+		return validateTypeSpec(typeList[1], path+"[1]")
+	case "object":
+		var attrs map[string]json.RawMessage
+		if err := json.Unmarshal(typeList[1], &attrs); err != nil || attrs == nil {
+			return fmt.Errorf("%s[1]: object attributes must be an object", path)
+		}
+		for _, name := range sortedNames(attrs) {
+			if err := validateTypeSpec(attrs[name], path+"[1]."+name); err != nil {
+				return err
+			}
+		}
+		if len(typeList) == 3 {
+			var optionals []string
+			if err := json.Unmarshal(typeList[2], &optionals); err != nil {
+				return fmt.Errorf("%s[2]: optional attributes must be an array of names", path)
+			}
+			seen := map[string]bool{}
+			for _, name := range optionals {
+				if _, ok := attrs[name]; !ok {
+					return fmt.Errorf("%s[2]: optional attribute %q is not an attribute of the object", path, name)
+				}
+				if seen[name] {
+					return fmt.Errorf("%s[2]: optional attribute %q is listed twice", path, name)
+				}
+				seen[name] = true
+			}
+		}
+		return nil
+	case "tuple":
+		var elems []json.RawMessage
+		if err := json.Unmarshal(typeList[1], &elems); err != nil || elems == nil {
+			return fmt.Errorf("%s[1]: tuple elements must be an array", path)
+		}
+		for i, elem := range elems {
+			if err := validateTypeSpec(elem, fmt.Sprintf("%s[1][%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "capsule":
+		var name string
+		if err := json.Unmarshal(typeList[1], &name); err != nil || strings.TrimSpace(name) == "" {
+			return fmt.Errorf("%s[1]: capsule type name must be a non-empty string", path)
+		}
+		return nil
+	}
+	return fmt.Errorf("%s[0]: unknown type kind %q", path, kind)
+}
+
+// sortedNames returns m's keys in lexical order, so the first error found
+// is the same on every run
+func sortedNames(m map[string]json.RawMessage) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,159 @@
+package hcltools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/ctyspec"
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/limits"
+)
+
+// FileToAST converts a native syntax HCL file to its unevaluated syntax
+// tree: attributes in source order with their expression trees, and blocks
+// with their labels and bodies. Every node has its source range, and every
+// expression its source text, kind, as the name of its hclsyntax type, and
+// children; an attribute's expression also lists the variable traversals
+// within it.
+func FileToAST(file *hcl.File, lim limits.Config) (interface{}, error) {
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, fmt.Errorf("syntax trees are only available for native syntax HCL")
+	}
+	return bodyToAST(body, file.Bytes, 0, lim)
+}
+
+// bodyToAST converts a body and the blocks nested in it
+func bodyToAST(body *hclsyntax.Body, src []byte, depth int, lim limits.Config) (map[string]interface{}, error) {
+	if err := lim.CheckDepth(depth, body.SrcRange.String()); err != nil {
+		return nil, err
+	}
+
+	attrs := make([]*hclsyntax.Attribute, 0, len(body.Attributes))
+	for _, attr := range body.Attributes {
+		attrs = append(attrs, attr)
+	}
+	sort.Slice(attrs, func(i, j int) bool { return attrs[i].SrcRange.Start.Byte < attrs[j].SrcRange.Start.Byte })
+
+	attributes := make([]map[string]interface{}, 0, len(attrs))
+	for _, attr := range attrs {
+		expr := exprToAST(attr.Expr, src)
+		traversals := make([]map[string]interface{}, 0)
+		for _, traversal := range attr.Expr.Variables() {
+			traversals = append(traversals, map[string]interface{}{
+				"root":  traversal.RootName(),
+				"path":  TraversalString(traversal),
+				"range": RangeToJSON(traversal.SourceRange()),
+			})
+		}
+		expr["traversals"] = traversals
+		attributes = append(attributes, map[string]interface{}{
+			"name":       attr.Name,
+			"range":      RangeToJSON(attr.SrcRange),
+			"name_range": RangeToJSON(attr.NameRange),
+			"expr":       expr,
+		})
+	}
+
+	blocks := make([]map[string]interface{}, 0, len(body.Blocks))
+	for _, block := range body.Blocks {
+		blockBody, err := bodyToAST(block.Body, src, depth+1, lim)
+		if err != nil {
+			return nil, err
+		}
+		labelRanges := make([]interface{}, len(block.LabelRanges))
+		for i, rng := range block.LabelRanges {
+			labelRanges[i] = RangeToJSON(rng)
+		}
+		blocks = append(blocks, map[string]interface{}{
+			"type":         block.Type,
+			"labels":       block.Labels,
+			"range":        RangeToJSON(block.Range()),
+			"type_range":   RangeToJSON(block.TypeRange),
+			"label_ranges": labelRanges,
+			"body":         blockBody,
+		})
+	}
+
+	return map[string]interface{}{
+		"range":      RangeToJSON(body.SrcRange),
+		"attributes": attributes,
+		"blocks":     blocks,
+	}, nil
+}
+
+// astBuilder builds the tree of an expression as hclsyntax.Walk visits it
+type astBuilder struct {
+	src   []byte
+	stack []map[string]interface{}
+	root  map[string]interface{}
+}
+
+func (b *astBuilder) Enter(node hclsyntax.Node) hcl.Diagnostics {
+	rng := node.Range()
+	n := map[string]interface{}{
+		"kind":     strings.TrimPrefix(strings.TrimPrefix(fmt.Sprintf("%T", node), "*"), "hclsyntax."),
+		"range":    RangeToJSON(rng),
+		"children": []map[string]interface{}{},
+	}
+	if rng.Start.Byte <= rng.End.Byte && rng.End.Byte <= len(b.src) {
+		n["source"] = string(rng.SliceBytes(b.src))
+	}
+	switch e := node.(type) {
+	case *hclsyntax.LiteralValueExpr:
+		n["value"] = ctyspec.ValueJSON(e.Val)
+	case *hclsyntax.ScopeTraversalExpr:
+		n["traversal"] = TraversalString(e.Traversal)
+	case *hclsyntax.RelativeTraversalExpr:
+		n["traversal"] = TraversalString(e.Traversal)
+	case *hclsyntax.FunctionCallExpr:
+		n["name"] = e.Name
+	case *hclsyntax.ForExpr:
+		n["key_var"] = e.KeyVar
+		n["value_var"] = e.ValVar
+	}
+
+	if len(b.stack) == 0 {
+		b.root = n
+	} else {
+		parent := b.stack[len(b.stack)-1]
+		parent["children"] = append(parent["children"].([]map[string]interface{}), n)
+	}
+	b.stack = append(b.stack, n)
+	return nil
+}
+
+func (b *astBuilder) Exit(node hclsyntax.Node) hcl.Diagnostics {
+	b.stack = b.stack[:len(b.stack)-1]
+	return nil
+}
+
+// exprToAST converts an expression and its subexpressions
+func exprToAST(expr hclsyntax.Expression, src []byte) map[string]interface{} {
+	b := &astBuilder{src: src}
+	hclsyntax.Walk(expr, b)
+	return b.root
+}
+
+// TraversalString renders a traversal as HCL source, e.g. var.list[0].name
+func TraversalString(traversal hcl.Traversal) string {
+	var sb strings.Builder
+	for _, step := range traversal {
+		switch s := step.(type) {
+		case hcl.TraverseRoot:
+			sb.WriteString(s.Name)
+		case hcl.TraverseAttr:
+			sb.WriteString("." + s.Name)
+		case hcl.TraverseIndex:
+			key, _ := s.Key.Unmark()
+			sb.WriteString(ctyspec.FormatPath(cty.Path{cty.IndexStep{Key: key}}))
+		case hcl.TraverseSplat:
+			sb.WriteString("[*]")
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,155 @@
+package hcltools
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// lineSpan is the byte range of one source line, without its newline
+type lineSpan struct {
+	start, end int
+}
+
+// sourceLines splits src into lines
+func sourceLines(src []byte) []lineSpan {
+	var lines []lineSpan
+	start := 0
+	for {
+		i := bytes.IndexByte(src[start:], '\n')
+		if i < 0 {
+			return append(lines, lineSpan{start, len(src)})
+		}
+		end := start + i
+		if end > start && src[end-1] == '\r' {
+			end--
+		}
+		lines = append(lines, lineSpan{start, end})
+		start += i + 1
+	}
+}
+
+// subjectLines returns the first and last line, 1-based, of rng in lines,
+// with ok false when rng does not fit the source
+func subjectLines(lines []lineSpan, rng hcl.Range) (first, last int, ok bool) {
+	first, last = rng.Start.Line, rng.End.Line
+	if last < first {
+		last = first
+	}
+	if first < 1 || last > len(lines) || rng.Start.Byte < lines[first-1].start || rng.End.Byte < rng.Start.Byte {
+		return 0, 0, false
+	}
+	// A range ending at the start of a line does not cover that line
+	if last > first && rng.End.Byte <= lines[last-1].start {
+		last--
+	}
+	return first, last, true
+}
+
+// Snippet returns the source lines covered by rng as JSON, with the
+// 1-based line they start on and the byte offsets of rng within them, as
+// Terraform's JSON diagnostics do. It is nil when rng does not fit src.
+func Snippet(src []byte, rng hcl.Range) map[string]interface{} {
+	lines := sourceLines(src)
+	first, last, ok := subjectLines(lines, rng)
+	if !ok {
+		return nil
+	}
+	start, end := lines[first-1].start, lines[last-1].end
+	highlightEnd := rng.End.Byte - start
+	if highlightEnd > end-start {
+		highlightEnd = end - start
+	}
+	return map[string]interface{}{
+		"start_line":             first,
+		"code":                   string(src[start:end]),
+		"highlight_start_offset": rng.Start.Byte - start,
+		"highlight_end_offset":   highlightEnd,
+	}
+}
+
+// WriteDiagnostics renders diagnostics for people: the severity and
+// summary, then, where the source of the subject is in sources, keyed by
+// filename, the subject's lines with contextLines lines around them and
+// carets under the subject, and then the detail.
+func WriteDiagnostics(w io.Writer, diags hcl.Diagnostics, sources map[string][]byte, contextLines int) error {
+	var sb strings.Builder
+	for _, diag := range diags {
+		severity := "Error"
+		if diag.Severity == hcl.DiagWarning {
+			severity = "Warning"
+		}
+		fmt.Fprintf(&sb, "%s: %s\n\n", severity, diag.Summary)
+		if diag.Subject != nil {
+			writeSourceExcerpt(&sb, *diag.Subject, sources[diag.Subject.Filename], contextLines)
+		}
+		if diag.Detail != "" {
+			fmt.Fprintf(&sb, "%s\n\n", diag.Detail)
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// writeSourceExcerpt writes the lines of src around rng, with carets under
+// rng
+func writeSourceExcerpt(sb *strings.Builder, rng hcl.Range, src []byte, contextLines int) {
+	lines := sourceLines(src)
+	first, last, ok := subjectLines(lines, rng)
+	if src == nil || !ok {
+		fmt.Fprintf(sb, "  on %s line %d:\n  (source code not available)\n\n", rng.Filename, rng.Start.Line)
+		return
+	}
+	fmt.Fprintf(sb, "  on %s line %d:\n", rng.Filename, rng.Start.Line)
+
+	from, to := first-contextLines, last+contextLines
+	if from < 1 {
+		from = 1
+	}
+	if to > len(lines) {
+		to = len(lines)
+	}
+	// The empty line after a final newline is only context noise
+	if to > last && to == len(lines) && lines[to-1].start == lines[to-1].end {
+		to--
+	}
+	for n := from; n <= to; n++ {
+		line := lines[n-1]
+		fmt.Fprintf(sb, "%4d: %s\n", n, src[line.start:line.end])
+		if n < first || n > last {
+			continue
+		}
+
+		// Carets span the part of the line within rng, and an empty
+		// range, which points between characters, gets one
+		hlStart, hlEnd := rng.Start.Byte, rng.End.Byte
+		if hlStart < line.start {
+			hlStart = line.start
+		}
+		if hlStart > line.end {
+			hlStart = line.end
+		}
+		if hlEnd > line.end {
+			hlEnd = line.end
+		}
+		carets := 1
+		if hlEnd > hlStart {
+			carets = utf8.RuneCount(src[hlStart:hlEnd])
+		} else if n != first {
+			continue
+		}
+		// Keep tabs so carets line up however tabs are displayed
+		indent := []rune(string(src[line.start:hlStart]))
+		for i, r := range indent {
+			if r != '\t' {
+				indent[i] = ' '
+			}
+		}
+		fmt.Fprintf(sb, "      %s%s\n", string(indent), strings.Repeat("^", carets))
+	}
+	sb.WriteString("\n")
+}
@@ -0,0 +1,197 @@
+package hcltools
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/limits"
+)
+
+// Change is a difference between two HCL files found by DiffFiles
+type Change struct {
+	// Kind is added, removed or changed
+	Kind string `json:"kind"`
+	// Element is attribute or block
+	Element string `json:"element"`
+	// Address is the address of the element, as in Reference.Attribute;
+	// blocks sharing a type and labels are told apart by their index
+	// among them, e.g. resource.aws_instance.web.ingress[1]
+	Address string `json:"address"`
+	// ARange and BRange locate the element in each file holding it, and
+	// A and B are the source of changed attribute expressions
+	ARange map[string]interface{} `json:"a_range,omitempty"`
+	BRange map[string]interface{} `json:"b_range,omitempty"`
+	A      string                 `json:"a,omitempty"`
+	B      string                 `json:"b,omitempty"`
+}
+
+// DiffFiles compares two native syntax HCL files structurally: attributes
+// by name and blocks by type and labels, in order among those sharing
+// them. Attributes whose expressions differ only in whitespace, comments
+// or, for expressions that evaluate without variables or functions, not
+// at all in value are equal. The contents of added and removed blocks are
+// not listed separately.
+func DiffFiles(a, b *hcl.File, lim limits.Config) ([]Change, error) {
+	aBody, ok := a.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, fmt.Errorf("diff is only available for native syntax HCL")
+	}
+	bBody, ok := b.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, fmt.Errorf("diff is only available for native syntax HCL")
+	}
+	changes := []Change{}
+	if err := diffBodies(aBody, bBody, a.Bytes, b.Bytes, "", 0, lim, &changes); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// diffBodies adds the differences between the bodies at address, and the
+// blocks nested in them, to changes
+func diffBodies(a, b *hclsyntax.Body, aSrc, bSrc []byte, address string, depth int, lim limits.Config, changes *[]Change) error {
+	if err := lim.CheckDepth(depth, a.SrcRange.String()); err != nil {
+		return err
+	}
+	names := map[string]bool{}
+	for name := range a.Attributes {
+		names[name] = true
+	}
+	for name := range b.Attributes {
+		names[name] = true
+	}
+	for _, name := range sortedNames(names) {
+		aAttr, inA := a.Attributes[name]
+		bAttr, inB := b.Attributes[name]
+		change := Change{Element: "attribute", Address: joinAddress(address, name)}
+		switch {
+		case !inB:
+			change.Kind = "removed"
+			change.ARange = RangeToJSON(aAttr.SrcRange)
+		case !inA:
+			change.Kind = "added"
+			change.BRange = RangeToJSON(bAttr.SrcRange)
+		case !sameExpression(aAttr.Expr, bAttr.Expr, aSrc, bSrc):
+			change.Kind = "changed"
+			change.ARange = RangeToJSON(aAttr.SrcRange)
+			change.BRange = RangeToJSON(bAttr.SrcRange)
+			change.A = string(aAttr.Expr.Range().SliceBytes(aSrc))
+			change.B = string(bAttr.Expr.Range().SliceBytes(bSrc))
+		default:
+			continue
+		}
+		*changes = append(*changes, change)
+	}
+
+	aBlocks := indexBlocks(a.Blocks)
+	bBlocks := indexBlocks(b.Blocks)
+	for _, entry := range aBlocks {
+		bBlock, ok := findBlock(bBlocks, entry.key)
+		if !ok {
+			*changes = append(*changes, Change{
+				Kind:    "removed",
+				Element: "block",
+				Address: joinAddress(address, entry.key),
+				ARange:  RangeToJSON(entry.block.Range()),
+			})
+			continue
+		}
+		if err := diffBodies(entry.block.Body, bBlock.Body, aSrc, bSrc, joinAddress(address, entry.key), depth+1, lim, changes); err != nil {
+			return err
+		}
+	}
+	for _, entry := range bBlocks {
+		if _, ok := findBlock(aBlocks, entry.key); !ok {
+			*changes = append(*changes, Change{
+				Kind:    "added",
+				Element: "block",
+				Address: joinAddress(address, entry.key),
+				BRange:  RangeToJSON(entry.block.Range()),
+			})
+		}
+	}
+	return nil
+}
+
+// indexedBlock is a block with the key it is matched by
+type indexedBlock struct {
+	key   string
+	block *hclsyntax.Block
+}
+
+// indexBlocks keys blocks by their type and labels, adding the index
+// among the blocks sharing them to all but the first
+func indexBlocks(blocks hclsyntax.Blocks) []indexedBlock {
+	seen := map[string]int{}
+	indexed := make([]indexedBlock, 0, len(blocks))
+	for _, block := range blocks {
+		key := strings.Join(append([]string{block.Type}, block.Labels...), ".")
+		if n := seen[key]; n > 0 {
+			seen[key] = n + 1
+			key = fmt.Sprintf("%s[%d]", key, n)
+		} else {
+			seen[key] = 1
+		}
+		indexed = append(indexed, indexedBlock{key: key, block: block})
+	}
+	return indexed
+}
+
+// findBlock returns the block of blocks with key
+func findBlock(blocks []indexedBlock, key string) (*hclsyntax.Block, bool) {
+	for _, entry := range blocks {
+		if entry.key == key {
+			return entry.block, true
+		}
+	}
+	return nil, false
+}
+
+// joinAddress appends name to address
+func joinAddress(address, name string) string {
+	if address == "" {
+		return name
+	}
+	return address + "." + name
+}
+
+// sameExpression reports whether two expressions are equal: in value if
+// both evaluate without variables or functions, and otherwise token by
+// token, ignoring newlines and comments
+func sameExpression(a, b hclsyntax.Expression, aSrc, bSrc []byte) bool {
+	aVal, aDiags := a.Value(nil)
+	bVal, bDiags := b.Value(nil)
+	if !aDiags.HasErrors() && !bDiags.HasErrors() && aVal.IsWhollyKnown() && bVal.IsWhollyKnown() {
+		return aVal.Type().Equals(bVal.Type()) && aVal.Equals(bVal).True()
+	}
+	aTokens := expressionTokens(a, aSrc)
+	bTokens := expressionTokens(b, bSrc)
+	if len(aTokens) != len(bTokens) {
+		return false
+	}
+	for i := range aTokens {
+		if aTokens[i] != bTokens[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// expressionTokens returns the tokens of an expression's source, less
+// newlines and comments, as type and bytes
+func expressionTokens(expr hclsyntax.Expression, src []byte) []string {
+	rng := expr.Range()
+	tokens, _ := hclsyntax.LexExpression(rng.SliceBytes(src), rng.Filename, rng.Start)
+	result := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		switch token.Type {
+		case hclsyntax.TokenNewline, hclsyntax.TokenComment, hclsyntax.TokenEOF:
+			continue
+		}
+		result = append(result, fmt.Sprintf("%s:%s", token.Type, token.Bytes))
+	}
+	return result
+}
@@ -0,0 +1,235 @@
+package hcltools
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/dynblock"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/ctyspec"
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/limits"
+)
+
+// valueMark is the type of the marks EvalFile tracks, as Terraform's are
+type valueMark string
+
+// SensitiveMark marks sensitive values. Values derived from them carry it
+// through evaluation as go-cty propagates marks.
+const SensitiveMark = valueMark("sensitive")
+
+// bodySchema is the schema of a body inferred from its syntax, with the
+// schemas of the bodies of its block types
+type bodySchema struct {
+	schema *hcl.BodySchema
+	blocks map[string]*bodySchema
+}
+
+// inferSchema returns the schema of the native syntax bodies, merged, so
+// that every attribute and block type they hold, including those of
+// dynamic blocks, is decoded. Dynamic blocks contribute their content
+// bodies to the block type they generate.
+func inferSchema(bodies []*hclsyntax.Body, depth int, lim limits.Config) (*bodySchema, error) {
+	if len(bodies) > 0 {
+		if err := lim.CheckDepth(depth, bodies[0].SrcRange.String()); err != nil {
+			return nil, err
+		}
+	}
+	attrs := map[string]bool{}
+	labels := map[string]int{}
+	nested := map[string][]*hclsyntax.Body{}
+	var types []string
+	addBlock := func(typeName string, labelCount int, body *hclsyntax.Body) {
+		if _, ok := labels[typeName]; !ok {
+			types = append(types, typeName)
+			labels[typeName] = labelCount
+		}
+		if labelCount > labels[typeName] {
+			labels[typeName] = labelCount
+		}
+		if body != nil {
+			nested[typeName] = append(nested[typeName], body)
+		}
+	}
+
+	for _, body := range bodies {
+		for name := range body.Attributes {
+			attrs[name] = true
+		}
+		for _, block := range body.Blocks {
+			if block.Type != "dynamic" || len(block.Labels) != 1 {
+				addBlock(block.Type, len(block.Labels), block.Body)
+				continue
+			}
+			labelCount := 0
+			if attr, ok := block.Body.Attributes["labels"]; ok {
+				if tuple, ok := attr.Expr.(*hclsyntax.TupleConsExpr); ok {
+					labelCount = len(tuple.Exprs)
+				}
+			}
+			var content *hclsyntax.Body
+			for _, inner := range block.Body.Blocks {
+				if inner.Type == "content" {
+					content = inner.Body
+				}
+			}
+			addBlock(block.Labels[0], labelCount, content)
+		}
+	}
+
+	result := &bodySchema{schema: &hcl.BodySchema{}, blocks: map[string]*bodySchema{}}
+	for _, name := range sortedNames(attrs) {
+		result.schema.Attributes = append(result.schema.Attributes, hcl.AttributeSchema{Name: name})
+	}
+	for _, typeName := range types {
+		labelNames := make([]string, labels[typeName])
+		for i := range labelNames {
+			labelNames[i] = fmt.Sprintf("label%d", i)
+		}
+		result.schema.Blocks = append(result.schema.Blocks, hcl.BlockHeaderSchema{Type: typeName, LabelNames: labelNames})
+		child, err := inferSchema(nested[typeName], depth+1, lim)
+		if err != nil {
+			return nil, err
+		}
+		result.blocks[typeName] = child
+	}
+	return result, nil
+}
+
+// sortedNames returns the keys of a set in order
+func sortedNames(set map[string]bool) []string {
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Evaluation is the result of EvalFile
+type Evaluation struct {
+	// Body is the file in the JSON representation FileToJSON produces
+	Body map[string]interface{}
+	// Sensitive are the paths of the values that carry SensitiveMark,
+	// sorted
+	Sensitive []string
+	// LocalsOrder is the order the local values that evaluated to a value
+	// were evaluated in
+	LocalsOrder []string
+}
+
+// EvalFile evaluates a native syntax HCL file with ctx to the JSON
+// representation FileToJSON produces, after expanding its dynamic blocks:
+// each dynamic block is replaced by the blocks its for_each generates,
+// in order, with the iterator bound in their content. Attributes that fail
+// to evaluate are left out and their diagnostics returned.
+//
+// The attributes of top-level locals blocks are evaluated first, each
+// after the locals it refers to, and are available everywhere as
+// attributes of local. Each cycle of locals that refer to each other is
+// reported by a diagnostic with the *LocalsCycle as its Extra, and its
+// locals are left unknown; attributes whose values are unknown, for
+// failing to evaluate or deriving from locals that did, are left out
+// without further diagnostics.
+//
+// Values are written without their marks; the paths of the values that
+// carry SensitiveMark are the addresses of their attributes followed by
+// the path within the value, e.g. resource.db.main.password or
+// tags["token"]. Blocks sharing a type and labels are told apart by their
+// index among them, e.g. ingress[1].port.
+func EvalFile(file *hcl.File, ctx *hcl.EvalContext, lim limits.Config) (*Evaluation, hcl.Diagnostics, error) {
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, nil, fmt.Errorf("evaluation is only available for native syntax HCL")
+	}
+	schema, err := inferSchema([]*hclsyntax.Body{body}, 0, lim)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	locals, order, diags := evalLocals(body, ctx)
+	if ctx == nil {
+		ctx = &hcl.EvalContext{}
+	}
+	ctx = ctx.NewChild()
+	ctx.Variables = map[string]cty.Value{"local": cty.ObjectVal(locals)}
+
+	eval := &Evaluation{Sensitive: []string{}, LocalsOrder: order}
+	result, bodyDiags, err := evalBody(dynblock.Expand(body, ctx), schema, ctx, nil, &eval.Sensitive)
+	if err != nil {
+		return nil, nil, err
+	}
+	diags = append(diags, bodyDiags...)
+	sort.Strings(eval.Sensitive)
+	eval.Body = result
+	return eval, diags, nil
+}
+
+// evalBody evaluates a body at address, decoded with schema, and the blocks
+// nested in it, adding the paths of sensitive values to sensitive
+func evalBody(body hcl.Body, schema *bodySchema, ctx *hcl.EvalContext, address cty.Path, sensitive *[]string) (map[string]interface{}, hcl.Diagnostics, error) {
+	content, diags := body.Content(schema.schema)
+	result := map[string]interface{}{}
+	for name, attr := range content.Attributes {
+		val, valDiags := attr.Expr.Value(ctx)
+		diags = append(diags, valDiags...)
+		if valDiags.HasErrors() || !val.IsWhollyKnown() {
+			continue
+		}
+		val, marks := val.UnmarkDeepWithPaths()
+		attrPath := append(address.Copy(), cty.GetAttrStep{Name: name})
+		for _, pvm := range marks {
+			if _, ok := pvm.Marks[SensitiveMark]; ok {
+				*sensitive = append(*sensitive, ctyspec.FormatPath(append(attrPath.Copy(), pvm.Path...)))
+			}
+		}
+		jsonVal, err := ctyjson.Marshal(val, val.Type())
+		if err != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Unrepresentable value",
+				Detail:   fmt.Sprintf("The value of %s cannot be represented as JSON: %s.", name, err),
+				Subject:  attr.Expr.Range().Ptr(),
+			})
+			continue
+		}
+		result[name] = json.RawMessage(jsonVal)
+	}
+
+	blocks := make([]map[string]interface{}, 0, len(content.Blocks))
+	seen := map[string]int{}
+	for _, block := range content.Blocks {
+		blockAddress := address.Copy()
+		for _, name := range append([]string{block.Type}, block.Labels...) {
+			blockAddress = append(blockAddress, cty.GetAttrStep{Name: name})
+		}
+		key := ctyspec.FormatPath(blockAddress)
+		n := seen[key]
+		seen[key] = n + 1
+		if n > 0 {
+			blockAddress = blockAddress.Index(cty.NumberIntVal(int64(n)))
+		}
+		blockBody, blockDiags, err := evalBody(block.Body, schema.blocks[block.Type], ctx, blockAddress, sensitive)
+		if err != nil {
+			return nil, nil, err
+		}
+		diags = append(diags, blockDiags...)
+		labels := block.Labels
+		if labels == nil {
+			labels = []string{}
+		}
+		blocks = append(blocks, map[string]interface{}{
+			"type":   block.Type,
+			"labels": labels,
+			"body":   blockBody,
+		})
+	}
+	if len(blocks) > 0 {
+		result["blocks"] = blocks
+	}
+	return result, diags, nil
+}
@@ -0,0 +1,86 @@
+package hcltools
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/limits"
+)
+
+// jsonBlock is a block of the JSON representation FileToJSON produces
+type jsonBlock struct {
+	Type   string                     `json:"type"`
+	Labels []string                   `json:"labels"`
+	Body   map[string]json.RawMessage `json:"body"`
+}
+
+// GenerateHCL renders a JSON document in the representation FileToJSON
+// produces as formatted native syntax HCL: its members as attributes,
+// sorted by name, and its "blocks" list as nested blocks, in order.
+// Attribute values are JSON values of the type go-cty infers for them.
+func GenerateHCL(data []byte, lim limits.Config) ([]byte, error) {
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, fmt.Errorf("document must be a JSON object: %w", err)
+	}
+	f := hclwrite.NewEmptyFile()
+	if err := writeBody(f.Body(), body, "", 0, lim); err != nil {
+		return nil, err
+	}
+	return hclwrite.Format(f.Bytes()), nil
+}
+
+// writeBody writes the attributes and blocks of a JSON body to body; path
+// locates it in the document for errors
+func writeBody(body *hclwrite.Body, members map[string]json.RawMessage, path string, depth int, lim limits.Config) error {
+	if err := lim.CheckDepth(depth, path); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(members))
+	for name := range members {
+		if name != "blocks" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		raw := members[name]
+		ty, err := ctyjson.ImpliedType(raw)
+		if err != nil {
+			return fmt.Errorf("invalid value of %s%s: %w", path, name, err)
+		}
+		val, err := ctyjson.Unmarshal(raw, ty)
+		if err != nil {
+			return fmt.Errorf("invalid value of %s%s: %w", path, name, err)
+		}
+		body.SetAttributeValue(name, val)
+	}
+
+	raw, ok := members["blocks"]
+	if !ok {
+		return nil
+	}
+	var blocks []jsonBlock
+	if err := json.Unmarshal(raw, &blocks); err != nil {
+		return fmt.Errorf("invalid %sblocks: %w", path, err)
+	}
+	for i, b := range blocks {
+		blockPath := fmt.Sprintf("%sblocks[%d].", path, i)
+		if b.Type == "" {
+			return fmt.Errorf("%stype must be set", blockPath)
+		}
+		if len(names) > 0 || i > 0 {
+			body.AppendNewline()
+		}
+		block := body.AppendNewBlock(b.Type, b.Labels)
+		if err := writeBody(block.Body(), b.Body, blockPath+"body.", depth+1, lim); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+// Package hcltools parses HCL and converts files and diagnostics to the
+// JSON representation the soup-go hcl commands print.
+package hcltools
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/limits"
+)
+
+// HCL dialects, as the hcl commands report them
+const (
+	DialectNative = "native"
+	DialectJSON   = "json"
+)
+
+// DialectFor returns the dialect of a file by its name: JSON syntax for
+// .json files, such as .tf.json, and native syntax otherwise
+func DialectFor(filename string) string {
+	if strings.HasSuffix(strings.ToLower(filename), ".json") {
+		return DialectJSON
+	}
+	return DialectNative
+}
+
+// Parse parses HCL native syntax, or JSON syntax when jsonSyntax is set
+func Parse(content []byte, filename string, jsonSyntax bool) (*hcl.File, hcl.Diagnostics) {
+	parser := hclparse.NewParser()
+	if jsonSyntax {
+		return parser.ParseJSON(content, filename)
+	}
+	return parser.ParseHCL(content, filename)
+}
+
+// FileToJSON converts an HCL file to a JSON representation: attribute
+// values evaluated without variables or functions, and a "blocks" list of
+// type, labels and body. JSON syntax carries no block structure without a
+// schema, so every property of a JSON syntax file converts as an attribute.
+// Blocks nested deeper than lim allows fail the conversion.
+func FileToJSON(file *hcl.File, lim limits.Config) (interface{}, error) {
+	return FileToJSONWithOptions(file, StringOptions{}, lim)
+}
+
+// FileToJSONWithOptions converts an HCL file to the JSON representation
+// FileToJSON produces, with the string attributes of native syntax files
+// represented as opts asks.
+func FileToJSONWithOptions(file *hcl.File, opts StringOptions, lim limits.Config) (interface{}, error) {
+	if err := opts.check(); err != nil {
+		return nil, err
+	}
+	if _, ok := file.Body.(*hclsyntax.Body); !ok {
+		return attributesToJSON(file.Body)
+	}
+
+	// For now, we'll work directly with the body without partial content
+	// since we're doing a general parse
+
+	result := make(map[string]interface{})
+
+	// Process attributes
+	if body, ok := file.Body.(*hclsyntax.Body); ok {
+		for name, attr := range body.Attributes {
+			if v, ok := syntaxAttributeToJSON(attr, file.Bytes, opts); ok {
+				result[name] = v
+			}
+		}
+
+		// Process blocks
+		blocks := make([]map[string]interface{}, 0)
+		for _, block := range body.Blocks {
+			blockData := map[string]interface{}{
+				"type":   block.Type,
+				"labels": block.Labels,
+			}
+
+			// Recursively process block body
+			blockBody, err := blockToJSON(block.Body, file.Bytes, opts, 1, lim)
+			if limitErr := limits.As(err); limitErr != nil {
+				return nil, limitErr
+			}
+			if err == nil {
+				blockData["body"] = blockBody
+			}
+
+			blocks = append(blocks, blockData)
+		}
+
+		if len(blocks) > 0 {
+			result["blocks"] = blocks
+		}
+	}
+
+	return result, nil
+}
+
+// attributesToJSON converts the attributes of a body without block
+// structure, such as a JSON syntax body, to JSON
+func attributesToJSON(body hcl.Body) (interface{}, error) {
+	attrs, diags := body.JustAttributes()
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to read attributes: %s", diags.Error())
+	}
+	result := make(map[string]interface{}, len(attrs))
+	for name, attr := range attrs {
+		val, diags := attr.Expr.Value(&hcl.EvalContext{
+			Variables: map[string]cty.Value{},
+			Functions: map[string]function.Function{},
+		})
+		if diags.HasErrors() {
+			continue
+		}
+		jsonVal, err := ctyjson.Marshal(val, val.Type())
+		if err != nil {
+			continue
+		}
+		var v interface{}
+		if err := json.Unmarshal(jsonVal, &v); err == nil {
+			result[name] = v
+		}
+	}
+	return result, nil
+}
+
+// syntaxAttributeToJSON converts a native syntax attribute, parsed from
+// src, to JSON: its value evaluated without variables or functions, or its
+// string representation under opts. It reports false if there is nothing
+// to write.
+func syntaxAttributeToJSON(attr *hclsyntax.Attribute, src []byte, opts StringOptions) (interface{}, bool) {
+	var value interface{}
+	evaluated := false
+	val, diags := attr.Expr.Value(&hcl.EvalContext{
+		Variables: map[string]cty.Value{},
+		Functions: map[string]function.Function{},
+	})
+	if !diags.HasErrors() {
+		jsonVal, err := ctyjson.Marshal(val, val.Type())
+		if err == nil {
+			if err := json.Unmarshal(jsonVal, &value); err == nil {
+				evaluated = true
+			}
+		}
+	}
+	return stringAttributeToJSON(attr.Expr, src, value, evaluated, opts)
+}
+
+// blockToJSON converts an HCL block body, parsed from src, to JSON
+func blockToJSON(body hcl.Body, src []byte, opts StringOptions, depth int, lim limits.Config) (interface{}, error) {
+	if err := lim.CheckDepth(depth, body.MissingItemRange().String()); err != nil {
+		return nil, err
+	}
+	if syntaxBody, ok := body.(*hclsyntax.Body); ok {
+		result := make(map[string]interface{})
+
+		// Process attributes in the block
+		for name, attr := range syntaxBody.Attributes {
+			if v, ok := syntaxAttributeToJSON(attr, src, opts); ok {
+				result[name] = v
+			}
+		}
+
+		// Process nested blocks
+		if len(syntaxBody.Blocks) > 0 {
+			blocks := make([]map[string]interface{}, 0)
+			for _, block := range syntaxBody.Blocks {
+				blockData := map[string]interface{}{
+					"type":   block.Type,
+					"labels": block.Labels,
+				}
+
+				blockBody, err := blockToJSON(block.Body, src, opts, depth+1, lim)
+				if limitErr := limits.As(err); limitErr != nil {
+					return nil, limitErr
+				}
+				if err == nil {
+					blockData["body"] = blockBody
+				}
+
+				blocks = append(blocks, blockData)
+			}
+			result["blocks"] = blocks
+		}
+
+		return result, nil
+	}
+
+	return nil, fmt.Errorf("unsupported body type")
+}
+
+// DiagnosticsToJSON converts HCL diagnostics to JSON
+func DiagnosticsToJSON(diags hcl.Diagnostics) []map[string]interface{} {
+	return DiagnosticsToJSONWithSource(diags, nil)
+}
+
+// DiagnosticsToJSONWithSource converts HCL diagnostics to JSON, adding the
+// source snippet of each subject found in sources, keyed by filename
+func DiagnosticsToJSONWithSource(diags hcl.Diagnostics, sources map[string][]byte) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(diags))
+	for _, diag := range diags {
+		severityStr := "error"
+		if diag.Severity == hcl.DiagWarning {
+			severityStr = "warning"
+		}
+		d := map[string]interface{}{
+			"severity": severityStr,
+			"summary":  diag.Summary,
+			"detail":   diag.Detail,
+		}
+		if diag.Subject != nil {
+			d["range"] = RangeToJSON(*diag.Subject)
+			if src, ok := sources[diag.Subject.Filename]; ok {
+				if snippet := Snippet(src, *diag.Subject); snippet != nil {
+					d["snippet"] = snippet
+				}
+			}
+		}
+		if cycle, ok := diag.Extra.(*LocalsCycle); ok {
+			d["cycle"] = cycle
+		}
+		result = append(result, d)
+	}
+	return result
+}
+
+// RangeToJSON converts a source range to JSON
+func RangeToJSON(rng hcl.Range) map[string]interface{} {
+	return map[string]interface{}{
+		"filename": rng.Filename,
+		"start": map[string]int{
+			"line":   rng.Start.Line,
+			"column": rng.Start.Column,
+			"byte":   rng.Start.Byte,
+		},
+		"end": map[string]int{
+			"line":   rng.End.Line,
+			"column": rng.End.Column,
+			"byte":   rng.End.Byte,
+		},
+	}
+}
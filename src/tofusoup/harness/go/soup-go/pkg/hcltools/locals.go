@@ -0,0 +1,155 @@
+package hcltools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// LocalsCycle is a cycle of local values that refer to each other
+type LocalsCycle struct {
+	// Locals are the names along the cycle, starting and ending with the
+	// same one, e.g. [a, b, a] for a referring to b and b to a
+	Locals []string `json:"locals"`
+	// Ranges are the source ranges of the locals along the cycle, less
+	// the repeated last one
+	Ranges []map[string]interface{} `json:"ranges"`
+}
+
+// localDeps returns the names of the locals expr refers to, sorted
+func localDeps(expr hclsyntax.Expression) []string {
+	set := map[string]bool{}
+	for _, traversal := range expr.Variables() {
+		if traversal.RootName() != "local" || len(traversal) < 2 {
+			continue
+		}
+		if step, ok := traversal[1].(hcl.TraverseAttr); ok {
+			set[step.Name] = true
+		}
+	}
+	return sortedNames(set)
+}
+
+// evalLocals evaluates the attributes of the top-level locals blocks of
+// body in dependency order, each with ctx and the locals evaluated before
+// it as attributes of local. It returns their values and the order the
+// locals that evaluated to a value were evaluated in. Locals that fail to
+// evaluate, are part of a cycle or derive from either are unknown, without
+// further diagnostics, and left out of the order. Each cycle is reported
+// by a diagnostic whose Extra is its *LocalsCycle.
+func evalLocals(body *hclsyntax.Body, ctx *hcl.EvalContext) (map[string]cty.Value, []string, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	attrs := map[string]*hclsyntax.Attribute{}
+	for _, block := range body.Blocks {
+		if block.Type != "locals" {
+			continue
+		}
+		for name, attr := range block.Body.Attributes {
+			if prev, ok := attrs[name]; ok {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Duplicate local value definition",
+					Detail:   fmt.Sprintf("A local value named %q was already defined at %s. Local value names must be unique.", name, prev.NameRange),
+					Subject:  attr.NameRange.Ptr(),
+				})
+				continue
+			}
+			attrs[name] = attr
+		}
+	}
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Order the locals depth first, each after those it refers to, noting
+	// a cycle for every reference back to a local still being visited
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[string]int{}
+	var sorted []string
+	var cycles []LocalsCycle
+	inCycle := map[string]bool{}
+	var stack []string
+	var visit func(name string)
+	visit = func(name string) {
+		state[name] = visiting
+		stack = append(stack, name)
+		for _, dep := range localDeps(attrs[name].Expr) {
+			if _, ok := attrs[dep]; !ok {
+				// Left for evaluation to report
+				continue
+			}
+			switch state[dep] {
+			case unvisited:
+				visit(dep)
+			case visiting:
+				start := len(stack) - 1
+				for stack[start] != dep {
+					start--
+				}
+				cycle := LocalsCycle{Locals: append(append([]string{}, stack[start:]...), dep)}
+				for _, member := range stack[start:] {
+					inCycle[member] = true
+					cycle.Ranges = append(cycle.Ranges, RangeToJSON(attrs[member].SrcRange))
+				}
+				cycles = append(cycles, cycle)
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[name] = visited
+		sorted = append(sorted, name)
+	}
+	for _, name := range names {
+		if state[name] == unvisited {
+			visit(name)
+		}
+	}
+
+	for i := range cycles {
+		cycle := cycles[i]
+		refs := make([]string, len(cycle.Locals))
+		for i, name := range cycle.Locals {
+			refs[i] = "local." + name
+		}
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Cycle in local values",
+			Detail:   fmt.Sprintf("Local values cannot refer to themselves, directly or through others: %s.", strings.Join(refs, " -> ")),
+			Subject:  attrs[cycle.Locals[0]].SrcRange.Ptr(),
+			Extra:    &cycle,
+		})
+	}
+
+	values := map[string]cty.Value{}
+	order := []string{}
+	if ctx == nil {
+		ctx = &hcl.EvalContext{}
+	}
+	localCtx := ctx.NewChild()
+	for _, name := range sorted {
+		if inCycle[name] {
+			values[name] = cty.DynamicVal
+			continue
+		}
+		localCtx.Variables = map[string]cty.Value{"local": cty.ObjectVal(values)}
+		val, valDiags := attrs[name].Expr.Value(localCtx)
+		diags = append(diags, valDiags...)
+		if valDiags.HasErrors() {
+			val = cty.DynamicVal
+		}
+		values[name] = val
+		if val.IsWhollyKnown() {
+			order = append(order, name)
+		}
+	}
+	return values, order, diags
+}
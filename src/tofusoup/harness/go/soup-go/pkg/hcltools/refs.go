@@ -0,0 +1,68 @@
+package hcltools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/limits"
+)
+
+// Reference is a variable traversal found in an attribute expression
+type Reference struct {
+	Root string `json:"root"`
+	// Path is the whole traversal as HCL source, e.g. var.list[0].name
+	Path  string                 `json:"path"`
+	Range map[string]interface{} `json:"range"`
+	// Attribute is the address of the attribute holding the expression:
+	// the types and labels of its enclosing blocks and its name, joined
+	// with dots, e.g. resource.aws_instance.web.ami
+	Attribute string `json:"attribute"`
+	rng       hcl.Range
+}
+
+// FileReferences lists the variable traversals of every attribute
+// expression in a native syntax HCL file, in source order
+func FileReferences(file *hcl.File, lim limits.Config) ([]Reference, error) {
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, fmt.Errorf("references are only available for native syntax HCL")
+	}
+	refs := []Reference{}
+	if err := bodyReferences(body, nil, 0, lim, &refs); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(refs, func(i, j int) bool { return refs[i].rng.Start.Byte < refs[j].rng.Start.Byte })
+	return refs, nil
+}
+
+// bodyReferences adds the references in body, whose enclosing blocks have
+// address, and the blocks nested in it to refs
+func bodyReferences(body *hclsyntax.Body, address []string, depth int, lim limits.Config, refs *[]Reference) error {
+	if err := lim.CheckDepth(depth, body.SrcRange.String()); err != nil {
+		return err
+	}
+	for name, attr := range body.Attributes {
+		attribute := strings.Join(append(append([]string{}, address...), name), ".")
+		for _, traversal := range attr.Expr.Variables() {
+			rng := traversal.SourceRange()
+			*refs = append(*refs, Reference{
+				Root:      traversal.RootName(),
+				Path:      TraversalString(traversal),
+				Range:     RangeToJSON(rng),
+				Attribute: attribute,
+				rng:       rng,
+			})
+		}
+	}
+	for _, block := range body.Blocks {
+		blockAddress := append(append(append([]string{}, address...), block.Type), block.Labels...)
+		if err := bodyReferences(block.Body, blockAddress, depth+1, lim, refs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package hcltools
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/limits"
+)
+
+// BodySchema is a JSON hcl.BodySchema whose block types may give the
+// schemas of their bodies:
+//
+//	{"attributes": [{"name": "region", "required": true}],
+//	 "blocks": [{"type": "resource", "labels": ["type", "name"],
+//	             "body": {"attributes": [{"name": "ami"}]}}]}
+type BodySchema struct {
+	Attributes []AttributeSchema `json:"attributes"`
+	Blocks     []BlockSchema     `json:"blocks"`
+}
+
+// AttributeSchema is an attribute of a BodySchema
+type AttributeSchema struct {
+	Name     string `json:"name"`
+	Required bool   `json:"required"`
+}
+
+// BlockSchema is a block type of a BodySchema. Body, if set, is extracted
+// from the bodies of its blocks in turn.
+type BlockSchema struct {
+	Type   string      `json:"type"`
+	Labels []string    `json:"labels"`
+	Body   *BodySchema `json:"body"`
+}
+
+// ParseBodySchema parses a JSON body schema
+func ParseBodySchema(data []byte, lim limits.Config) (*BodySchema, error) {
+	var schema BodySchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("schema must be a JSON object: %w", err)
+	}
+	if err := schema.check("schema", 0, lim); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+// check reports missing names in the schema at path
+func (s *BodySchema) check(path string, depth int, lim limits.Config) error {
+	if err := lim.CheckDepth(depth, path); err != nil {
+		return err
+	}
+	for i, attr := range s.Attributes {
+		if attr.Name == "" {
+			return fmt.Errorf("%s.attributes[%d]: name is required", path, i)
+		}
+	}
+	for i, block := range s.Blocks {
+		if block.Type == "" {
+			return fmt.Errorf("%s.blocks[%d]: type is required", path, i)
+		}
+		if block.Body != nil {
+			if err := block.Body.check(fmt.Sprintf("%s.blocks[%d].body", path, i), depth+1, lim); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// hclSchema is the hcl.BodySchema s describes
+func (s *BodySchema) hclSchema() *hcl.BodySchema {
+	schema := &hcl.BodySchema{}
+	for _, attr := range s.Attributes {
+		schema.Attributes = append(schema.Attributes, hcl.AttributeSchema{Name: attr.Name, Required: attr.Required})
+	}
+	for _, block := range s.Blocks {
+		schema.Blocks = append(schema.Blocks, hcl.BlockHeaderSchema{Type: block.Type, LabelNames: block.Labels})
+	}
+	return schema
+}
+
+// ExtractContent decodes the content schema declares from body with
+// PartialContent, recursing into the blocks whose type gives a body
+// schema, and reports what was left over. The result has the declared
+// attributes, with their values where they evaluate without variables or
+// functions, the declared blocks, and as "leftover" the attributes and
+// blocks the schema does not declare. Leftover blocks of JSON syntax
+// bodies, which cannot be told from attributes without a schema, are
+// reported as attributes.
+func ExtractContent(body hcl.Body, schema *BodySchema, lim limits.Config) (map[string]interface{}, hcl.Diagnostics, error) {
+	return extractContent(body, schema, 0, lim)
+}
+
+func extractContent(body hcl.Body, schema *BodySchema, depth int, lim limits.Config) (map[string]interface{}, hcl.Diagnostics, error) {
+	if err := lim.CheckDepth(depth, body.MissingItemRange().String()); err != nil {
+		return nil, nil, err
+	}
+	content, remain, diags := body.PartialContent(schema.hclSchema())
+
+	attributes := map[string]interface{}{}
+	for name, attr := range content.Attributes {
+		attributes[name] = attributeToJSON(attr)
+	}
+
+	bodies := map[string]*BodySchema{}
+	for _, block := range schema.Blocks {
+		bodies[block.Type] = block.Body
+	}
+	blocks := make([]map[string]interface{}, 0, len(content.Blocks))
+	for _, block := range content.Blocks {
+		labels := block.Labels
+		if labels == nil {
+			labels = []string{}
+		}
+		entry := map[string]interface{}{
+			"type":   block.Type,
+			"labels": labels,
+			"range":  RangeToJSON(block.DefRange),
+		}
+		if nested := bodies[block.Type]; nested != nil {
+			blockContent, blockDiags, err := extractContent(block.Body, nested, depth+1, lim)
+			if err != nil {
+				return nil, nil, err
+			}
+			diags = append(diags, blockDiags...)
+			entry["content"] = blockContent
+		}
+		blocks = append(blocks, entry)
+	}
+
+	leftover, leftoverDiags := leftoverContent(body, remain, schema)
+	diags = append(diags, leftoverDiags...)
+	return map[string]interface{}{
+		"attributes": attributes,
+		"blocks":     blocks,
+		"leftover":   leftover,
+	}, diags, nil
+}
+
+// attributeToJSON converts a decoded attribute to JSON: its range, and its
+// value if it evaluates without variables or functions
+func attributeToJSON(attr *hcl.Attribute) map[string]interface{} {
+	entry := map[string]interface{}{
+		"range": RangeToJSON(attr.Range),
+	}
+	if val, diags := attr.Expr.Value(nil); !diags.HasErrors() {
+		if jsonVal, err := ctyjson.Marshal(val, val.Type()); err == nil {
+			entry["value"] = json.RawMessage(jsonVal)
+		}
+	}
+	return entry
+}
+
+// leftoverContent lists the attributes and blocks of body that schema does
+// not declare. Native syntax bodies are compared with the schema directly;
+// other bodies report the attributes of remain.
+func leftoverContent(body, remain hcl.Body, schema *BodySchema) (map[string]interface{}, hcl.Diagnostics) {
+	attributes := map[string]interface{}{}
+	blocks := []map[string]interface{}{}
+	leftover := map[string]interface{}{
+		"attributes": attributes,
+		"blocks":     blocks,
+	}
+
+	syntaxBody, ok := body.(*hclsyntax.Body)
+	if !ok {
+		attrs, diags := remain.JustAttributes()
+		for name, attr := range attrs {
+			attributes[name] = attributeToJSON(attr)
+		}
+		return leftover, diags
+	}
+
+	declared := map[string]bool{}
+	for _, attr := range schema.Attributes {
+		declared[attr.Name] = true
+	}
+	for name, attr := range syntaxBody.Attributes {
+		if !declared[name] {
+			attributes[name] = attributeToJSON(attr.AsHCLAttribute())
+		}
+	}
+	declaredBlocks := map[string]bool{}
+	for _, block := range schema.Blocks {
+		declaredBlocks[block.Type] = true
+	}
+	for _, block := range syntaxBody.Blocks {
+		if declaredBlocks[block.Type] {
+			continue
+		}
+		labels := block.Labels
+		if labels == nil {
+			labels = []string{}
+		}
+		blocks = append(blocks, map[string]interface{}{
+			"type":   block.Type,
+			"labels": labels,
+			"range":  RangeToJSON(block.DefRange()),
+		})
+	}
+	leftover["blocks"] = blocks
+	return leftover, nil
+}
@@ -0,0 +1,260 @@
+package hcltools
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/ctyspec"
+)
+
+// specAttr is the body of an "attr" spec
+type specAttr struct {
+	Name     string          `json:"name"`
+	Type     json.RawMessage `json:"type"`
+	Required bool            `json:"required"`
+}
+
+// specLiteral is the body of a "literal" spec
+type specLiteral struct {
+	Value json.RawMessage `json:"value"`
+	Type  json.RawMessage `json:"type"`
+}
+
+// specBlock is the body of the block specs; each uses the fields that
+// apply to it
+type specBlock struct {
+	TypeName    string          `json:"type_name"`
+	Required    bool            `json:"required"`
+	MinItems    int             `json:"min_items"`
+	MaxItems    int             `json:"max_items"`
+	Labels      []string        `json:"labels"`
+	ElementType json.RawMessage `json:"element_type"`
+	Nested      json.RawMessage `json:"nested"`
+}
+
+// specLabel is the body of a "label" spec
+type specLabel struct {
+	Name  string `json:"name"`
+	Index int    `json:"index"`
+}
+
+// specDefault is the body of a "default" spec
+type specDefault struct {
+	Primary json.RawMessage `json:"primary"`
+	Default json.RawMessage `json:"default"`
+}
+
+// ParseSpec parses a JSON hcldec specification. Every spec is an object
+// with a single member naming its kind, mirroring the spec files of the
+// hcldec tool:
+//
+//	{"object": {"<name>": <spec>, ...}}
+//	{"tuple": [<spec>, ...]}
+//	{"attr": {"name": "...", "type": <type>, "required": false}}
+//	{"literal": {"value": <json>, "type": <type>}}
+//	{"block": {"type_name": "...", "required": false, "nested": <spec>}}
+//	{"block_list": {"type_name": "...", "min_items": 0, "max_items": 0, "nested": <spec>}}
+//	{"block_set": {"type_name": "...", "min_items": 0, "max_items": 0, "nested": <spec>}}
+//	{"block_map": {"type_name": "...", "labels": ["..."], "nested": <spec>}}
+//	{"block_attrs": {"type_name": "...", "element_type": <type>, "required": false}}
+//	{"label": {"name": "...", "index": 0}}
+//	{"default": {"primary": <spec>, "default": <spec>}}
+//
+// Types are JSON type specifications or HCL type expressions, and a
+// literal without a type takes the one go-cty infers from its JSON; one
+// with a type is built from its JSON with opts.
+func ParseSpec(data []byte, opts ctyspec.Options) (hcldec.Spec, error) {
+	return parseSpec(data, "spec", 0, opts)
+}
+
+// parseSpec parses the spec at path
+func parseSpec(data json.RawMessage, path string, depth int, opts ctyspec.Options) (hcldec.Spec, error) {
+	if err := opts.Limits.CheckDepth(depth, path); err != nil {
+		return nil, err
+	}
+	var node map[string]json.RawMessage
+	if err := json.Unmarshal(data, &node); err != nil {
+		return nil, fmt.Errorf("%s: spec must be a JSON object: %w", path, err)
+	}
+	if len(node) != 1 {
+		return nil, fmt.Errorf("%s: spec must have exactly one member naming its kind, got %d", path, len(node))
+	}
+	var kind string
+	var body json.RawMessage
+	for kind, body = range node {
+	}
+	path += "." + kind
+
+	switch kind {
+	case "object":
+		var members map[string]json.RawMessage
+		if err := json.Unmarshal(body, &members); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		names := make([]string, 0, len(members))
+		for name := range members {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		spec := hcldec.ObjectSpec{}
+		for _, name := range names {
+			member, err := parseSpec(members[name], path+"."+name, depth+1, opts)
+			if err != nil {
+				return nil, err
+			}
+			spec[name] = member
+		}
+		return spec, nil
+
+	case "tuple":
+		var elems []json.RawMessage
+		if err := json.Unmarshal(body, &elems); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		spec := make(hcldec.TupleSpec, len(elems))
+		for i, elem := range elems {
+			var err error
+			if spec[i], err = parseSpec(elem, fmt.Sprintf("%s[%d]", path, i), depth+1, opts); err != nil {
+				return nil, err
+			}
+		}
+		return spec, nil
+
+	case "attr":
+		var attr specAttr
+		if err := json.Unmarshal(body, &attr); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if attr.Name == "" {
+			return nil, fmt.Errorf("%s: name is required", path)
+		}
+		ty, err := parseSpecType(attr.Type, path, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &hcldec.AttrSpec{Name: attr.Name, Type: ty, Required: attr.Required}, nil
+
+	case "literal":
+		var lit specLiteral
+		if err := json.Unmarshal(body, &lit); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if len(lit.Value) == 0 {
+			return nil, fmt.Errorf("%s: value is required", path)
+		}
+		var value cty.Value
+		if len(lit.Type) == 0 {
+			ty, err := ctyjson.ImpliedType(lit.Value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			if value, err = ctyjson.Unmarshal(lit.Value, ty); err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+		} else {
+			ty, err := parseSpecType(lit.Type, path, opts)
+			if err != nil {
+				return nil, err
+			}
+			if value, err = ctyspec.BuildValue(ty, lit.Value, opts); err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+		}
+		return &hcldec.LiteralSpec{Value: value}, nil
+
+	case "block", "block_list", "block_set", "block_map", "block_attrs":
+		var block specBlock
+		if err := json.Unmarshal(body, &block); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if block.TypeName == "" {
+			return nil, fmt.Errorf("%s: type_name is required", path)
+		}
+		if kind == "block_attrs" {
+			ty, err := parseSpecType(block.ElementType, path, opts)
+			if err != nil {
+				return nil, err
+			}
+			return &hcldec.BlockAttrsSpec{TypeName: block.TypeName, ElementType: ty, Required: block.Required}, nil
+		}
+		if len(block.Nested) == 0 {
+			return nil, fmt.Errorf("%s: nested is required", path)
+		}
+		nested, err := parseSpec(block.Nested, path+".nested", depth+1, opts)
+		if err != nil {
+			return nil, err
+		}
+		switch kind {
+		case "block":
+			return &hcldec.BlockSpec{TypeName: block.TypeName, Nested: nested, Required: block.Required}, nil
+		case "block_list":
+			return &hcldec.BlockListSpec{TypeName: block.TypeName, Nested: nested, MinItems: block.MinItems, MaxItems: block.MaxItems}, nil
+		case "block_set":
+			return &hcldec.BlockSetSpec{TypeName: block.TypeName, Nested: nested, MinItems: block.MinItems, MaxItems: block.MaxItems}, nil
+		}
+		if len(block.Labels) == 0 {
+			return nil, fmt.Errorf("%s: labels is required", path)
+		}
+		return &hcldec.BlockMapSpec{TypeName: block.TypeName, LabelNames: block.Labels, Nested: nested}, nil
+
+	case "label":
+		var label specLabel
+		if err := json.Unmarshal(body, &label); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if label.Name == "" {
+			return nil, fmt.Errorf("%s: name is required", path)
+		}
+		return &hcldec.BlockLabelSpec{Name: label.Name, Index: label.Index}, nil
+
+	case "default":
+		var def specDefault
+		if err := json.Unmarshal(body, &def); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if len(def.Primary) == 0 || len(def.Default) == 0 {
+			return nil, fmt.Errorf("%s: primary and default are required", path)
+		}
+		primary, err := parseSpec(def.Primary, path+".primary", depth+1, opts)
+		if err != nil {
+			return nil, err
+		}
+		fallback, err := parseSpec(def.Default, path+".default", depth+1, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &hcldec.DefaultSpec{Primary: primary, Default: fallback}, nil
+	}
+	return nil, fmt.Errorf("%s: unsupported spec kind", path)
+}
+
+// parseSpecType parses the type of the spec at path
+func parseSpecType(data json.RawMessage, path string, opts ctyspec.Options) (cty.Type, error) {
+	if len(data) == 0 {
+		return cty.NilType, fmt.Errorf("%s: type is required", path)
+	}
+	ty, err := ctyspec.ParseTypeSpec(data, opts)
+	if err != nil {
+		return cty.NilType, fmt.Errorf("%s: invalid type: %w", path, err)
+	}
+	return ty, nil
+}
+
+// ObjectTypeSpec returns the hcldec spec decoding a body as an object of
+// type ty: each attribute of ty as an attribute of the body, required
+// unless it is optional. Bodies decoded with it may not hold blocks.
+func ObjectTypeSpec(ty cty.Type) (hcldec.Spec, error) {
+	if !ty.IsObjectType() {
+		return nil, fmt.Errorf("type must be an object type, got %s", ty.FriendlyName())
+	}
+	spec := hcldec.ObjectSpec{}
+	for name, attrTy := range ty.AttributeTypes() {
+		spec[name] = &hcldec.AttrSpec{Name: name, Type: attrTy, Required: !ty.AttributeOptional(name)}
+	}
+	return spec, nil
+}
@@ -0,0 +1,107 @@
+package hcltools
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// String representations FileToJSONWithOptions writes string attributes in
+const (
+	// StringsNormalized writes the value strings evaluate to: escapes
+	// decoded and the common indentation of indented heredocs removed
+	StringsNormalized = "normalized"
+	// StringsRaw writes the source text of strings: between the quotes of
+	// quoted strings, escapes undecoded, and between the delimiter lines
+	// of heredocs, indentation kept
+	StringsRaw = "raw"
+)
+
+// StringOptions controls how FileToJSONWithOptions represents attributes
+// whose expression is a quoted string or heredoc template. Strings nested
+// in other expressions, such as list elements, are always normalized.
+type StringOptions struct {
+	// Mode is StringsNormalized, the default if empty, or StringsRaw
+	Mode string
+	// HeredocMetadata writes heredoc attributes as
+	// {"value": ..., "heredoc": {"delimiter", "indented", "range"}}
+	HeredocMetadata bool
+}
+
+// check reports an unsupported mode
+func (o StringOptions) check() error {
+	switch o.Mode {
+	case "", StringsNormalized, StringsRaw:
+		return nil
+	}
+	return fmt.Errorf("unsupported string representation: %s", o.Mode)
+}
+
+// stringLiteral is the source form of a quoted string or heredoc
+type stringLiteral struct {
+	raw       string
+	heredoc   bool
+	indented  bool
+	delimiter string
+}
+
+// parseStringLiteral returns the source form of expr, if it is a quoted
+// string or heredoc template
+func parseStringLiteral(expr hclsyntax.Expression, src []byte) (stringLiteral, bool) {
+	switch expr.(type) {
+	case *hclsyntax.TemplateExpr, *hclsyntax.TemplateWrapExpr:
+	default:
+		return stringLiteral{}, false
+	}
+	text := expr.Range().SliceBytes(src)
+
+	if len(text) >= 2 && text[0] == '"' && text[len(text)-1] == '"' {
+		return stringLiteral{raw: string(text[1 : len(text)-1])}, true
+	}
+	if !bytes.HasPrefix(text, []byte("<<")) {
+		return stringLiteral{}, false
+	}
+	lit := stringLiteral{heredoc: true}
+	opener := text[2:]
+	if bytes.HasPrefix(opener, []byte("-")) {
+		lit.indented = true
+		opener = opener[1:]
+	}
+	newline := bytes.IndexByte(opener, '\n')
+	if newline < 0 {
+		return stringLiteral{}, false
+	}
+	lit.delimiter = string(bytes.TrimRight(opener[:newline], "\r"))
+	body := opener[newline+1:]
+	// The closing delimiter is on the last line; the content is everything
+	// up to and including the newline before it
+	if last := bytes.LastIndexByte(body, '\n'); last >= 0 {
+		lit.raw = string(body[:last+1])
+	}
+	return lit, true
+}
+
+// stringAttributeToJSON applies opts to an attribute whose expression is
+// expr and whose normalized JSON value, if it evaluated, is value. It
+// returns what to write for the attribute and whether to write anything.
+func stringAttributeToJSON(expr hclsyntax.Expression, src []byte, value interface{}, evaluated bool, opts StringOptions) (interface{}, bool) {
+	lit, ok := parseStringLiteral(expr, src)
+	if !ok || src == nil {
+		return value, evaluated
+	}
+	if opts.Mode == StringsRaw {
+		value, evaluated = lit.raw, true
+	}
+	if !evaluated || !opts.HeredocMetadata || !lit.heredoc {
+		return value, evaluated
+	}
+	return map[string]interface{}{
+		"value": value,
+		"heredoc": map[string]interface{}{
+			"delimiter": lit.delimiter,
+			"indented":  lit.indented,
+			"range":     RangeToJSON(expr.Range()),
+		},
+	}, true
+}
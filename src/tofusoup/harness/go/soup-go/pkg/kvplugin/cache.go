@@ -0,0 +1,127 @@
+package kvplugin
+
+import (
+	"container/list"
+	"sync"
+)
+
+// CachingKV is a size-bounded LRU read cache in front of a KV backend.
+// Writes go through to the backend and invalidate the cached entry, so the
+// cache never serves a value older than the last Put made through it.
+type CachingKV struct {
+	KV
+	mu       sync.Mutex
+	maxBytes int64
+	size     int64
+	order    *list.List
+	entries  map[string]*list.Element
+	// generation advances on every invalidation so a Get that raced with a
+	// Put does not cache the value it read before the write
+	generation uint64
+	hits       uint64
+	misses     uint64
+}
+
+type cacheEntry struct {
+	key   string
+	value []byte
+}
+
+// NewCachingKV wraps kv with a read cache holding up to maxBytes of values
+func NewCachingKV(kv KV, maxBytes int64) *CachingKV {
+	return &CachingKV{
+		KV:       kv,
+		maxBytes: maxBytes,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+func (c *CachingKV) Get(key string) ([]byte, error) {
+	c.mu.Lock()
+	if elem, ok := c.entries[key]; ok {
+		c.order.MoveToFront(elem)
+		c.hits++
+		value := append([]byte(nil), elem.Value.(*cacheEntry).value...)
+		c.mu.Unlock()
+		return value, nil
+	}
+	c.misses++
+	generation := c.generation
+	c.mu.Unlock()
+
+	value, err := c.KV.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if c.generation == generation {
+		c.add(key, append([]byte(nil), value...))
+	}
+	c.mu.Unlock()
+	return value, nil
+}
+
+func (c *CachingKV) Put(key string, value []byte) error {
+	err := c.KV.Put(key, value)
+	// Invalidate even on failure, since the backend may have changed
+	c.Invalidate(key)
+	return err
+}
+
+func (c *CachingKV) Delete(key string) (bool, error) {
+	existed, err := Delete(c.KV, key)
+	c.Invalidate(key)
+	return existed, err
+}
+
+func (c *CachingKV) List(prefix string) ([]string, error) {
+	return List(c.KV, prefix)
+}
+
+// Invalidate drops a key from the cache, for backends that change or
+// delete values other than through Put
+func (c *CachingKV) Invalidate(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+}
+
+// add inserts an entry and evicts least recently used entries until the
+// cache fits. Values larger than the whole cache are not cached.
+func (c *CachingKV) add(key string, value []byte) {
+	if int64(len(value)) > c.maxBytes {
+		return
+	}
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, value: value})
+	c.size += int64(len(value))
+	for c.size > c.maxBytes {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *CachingKV) remove(elem *list.Element) {
+	entry := c.order.Remove(elem).(*cacheEntry)
+	delete(c.entries, entry.key)
+	c.size -= int64(len(entry.value))
+}
+
+// Stats reports cache effectiveness
+func (c *CachingKV) Stats() map[string]interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return map[string]interface{}{
+		"hits":      c.hits,
+		"misses":    c.misses,
+		"entries":   len(c.entries),
+		"bytes":     c.size,
+		"max_bytes": c.maxBytes,
+	}
+}
@@ -0,0 +1,101 @@
+package kvplugin
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+)
+
+// Checksum verification modes for stored values
+const (
+	ChecksumOff   = "off"
+	ChecksumWarn  = "warn"
+	ChecksumError = "error"
+)
+
+// checksumSuffix names the file holding a value's SHA-256 next to it
+const checksumSuffix = ".sha256"
+
+// ChecksumMismatchError reports a stored value that no longer matches the
+// checksum written with it. The gRPC server surfaces it as DataLoss.
+type ChecksumMismatchError struct {
+	Key      string
+	Expected string
+	Actual   string
+}
+
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("checksum mismatch for key %s: stored sha256 %s, value has sha256 %s", e.Key, e.Expected, e.Actual)
+}
+
+// ValidateChecksumMode checks a --checksum flag value
+func ValidateChecksumMode(mode string) error {
+	switch mode {
+	case ChecksumOff, ChecksumWarn, ChecksumError:
+		return nil
+	}
+	return fmt.Errorf("invalid checksum mode %q: expected off, warn or error", mode)
+}
+
+// SetChecksumMode selects whether values are checksummed on Put and
+// verified on Get. With off, no checksums are written, and any left by an
+// earlier server are dropped as their values are replaced.
+func (k *KVImpl) SetChecksumMode(mode string) {
+	k.checksumMode = mode
+}
+
+// kvChecksumPath returns the file holding a key's checksum
+func (k *KVImpl) kvChecksumPath(key string) string {
+	return k.kvDataPath(key) + checksumSuffix
+}
+
+// storeChecksum records value's checksum for key, or removes a stale one
+// when checksums are off. The caller holds the key's lock.
+func (k *KVImpl) storeChecksum(key string, value []byte) error {
+	if k.checksumMode == ChecksumOff {
+		if err := os.Remove(k.kvChecksumPath(key)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove stale checksum for key %s: %w", key, err)
+		}
+		return nil
+	}
+	sum := sha256.Sum256(value)
+	digest := []byte(hex.EncodeToString(sum[:]) + "\n")
+	if err := k.writeAtomic(k.kvChecksumPath(key), digest); err != nil {
+		return fmt.Errorf("failed to store checksum for key %s: %w", key, err)
+	}
+	return nil
+}
+
+// verifyChecksum compares value with its stored checksum. Values written
+// without one, by the flat layout or before checksums were kept, are not
+// verified.
+// The caller holds the key's lock.
+func (k *KVImpl) verifyChecksum(key string, value []byte) error {
+	if k.checksumMode == ChecksumOff {
+		return nil
+	}
+	stored, err := os.ReadFile(k.kvChecksumPath(key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read checksum for key %s: %w", key, err)
+	}
+
+	sum := sha256.Sum256(value)
+	actual := hex.EncodeToString(sum[:])
+	expected := string(bytes.TrimSpace(stored))
+	if expected == actual {
+		return nil
+	}
+
+	mismatch := &ChecksumMismatchError{Key: key, Expected: expected, Actual: actual}
+	if k.checksumMode == ChecksumWarn {
+		k.logger.Warn("🗄️⚠️ stored value failed checksum verification", "key", key, "expected", expected, "actual", actual)
+		return nil
+	}
+	k.logger.Error("🗄️❌ stored value failed checksum verification", "key", key, "expected", expected, "actual", actual)
+	return mismatch
+}
@@ -1,4 +1,4 @@
-package main
+package kvplugin
 
 // =================================
 // Application constants
@@ -13,6 +13,9 @@ const (
 	// KVStoreDirName is the KV storage subdirectory name
 	KVStoreDirName = "kv-store"
 
+	// KVSnapshotsDirName is the KV snapshot subdirectory name
+	KVSnapshotsDirName = "kv-snapshots"
+
 	// HarnessesDirName is the harness binaries subdirectory name
 	HarnessesDirName = "harnesses"
 
@@ -1,4 +1,4 @@
-package main
+package kvplugin
 
 import (
 	"os"
@@ -6,13 +6,13 @@ import (
 	"runtime"
 )
 
-// GetCacheDir returns the XDG-compliant cache directory for tofusoup.
+// CacheDir returns the XDG-compliant cache directory for tofusoup.
 // Priority (highest to lowest):
 // 1. TOFUSOUP_CACHE_DIR environment variable (explicit override)
 // 2. XDG_CACHE_HOME environment variable (XDG standard)
 // 3. Platform-specific defaults (macOS, Linux, Windows)
 // 4. System temp directory (last resort)
-func GetCacheDir() string {
+func CacheDir() string {
 	// Check explicit override first
 	if cacheDir := os.Getenv(EnvTofuSoupCacheDir); cacheDir != "" {
 		return cacheDir
@@ -45,16 +45,22 @@ func GetCacheDir() string {
 	return filepath.Join(os.TempDir(), AppName, CacheDirName)
 }
 
-// GetKVStorageDir returns the directory for KV storage.
+// StorageDir returns the directory for KV storage.
 // Priority (highest to lowest):
 // 1. KV_STORAGE_DIR environment variable (explicit override, for backward compatibility)
 // 2. Subdirectory within cache directory
-func GetKVStorageDir() string {
+func StorageDir() string {
 	// Check explicit override first (backward compatibility)
 	if storageDir := os.Getenv(EnvKVStorageDir); storageDir != "" {
 		return storageDir
 	}
 
 	// Use cache directory as base
-	return filepath.Join(GetCacheDir(), KVStoreDirName)
+	return filepath.Join(CacheDir(), KVStoreDirName)
+}
+
+// SnapshotsDir returns the directory holding KV snapshots, within the cache
+// directory
+func SnapshotsDir() string {
+	return filepath.Join(CacheDir(), KVSnapshotsDirName)
 }
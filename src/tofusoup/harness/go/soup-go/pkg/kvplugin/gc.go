@@ -0,0 +1,218 @@
+package kvplugin
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// GCReport summarizes a garbage collection pass over a KV storage dir
+type GCReport struct {
+	StorageDir     string   `json:"storage_dir"`
+	OlderThan      string   `json:"older_than"`
+	DryRun         bool     `json:"dry_run"`
+	RemovedKeys    []string `json:"removed_keys"`
+	ReclaimedBytes int64    `json:"reclaimed_bytes"`
+	RemainingKeys  int      `json:"remaining_keys"`
+	// Compaction removes files no entry needs: temporary files left by
+	// interrupted Puts, and lock and checksum files of keys that no longer
+	// exist
+	TempFilesRemoved int `json:"temp_files_removed"`
+	LockFilesRemoved int `json:"lock_files_removed"`
+}
+
+// GC removes entries not written within olderThan and compacts the storage
+// directory. Each entry is removed under its key lock, so GC is safe to run
+// beside a server; a server's read cache may still serve removed values.
+func (k *KVImpl) GC(olderThan time.Duration, dryRun bool) (*GCReport, error) {
+	report := &GCReport{
+		StorageDir:  k.storageDir,
+		OlderThan:   olderThan.String(),
+		DryRun:      dryRun,
+		RemovedKeys: []string{},
+	}
+
+	files, err := k.storedFiles()
+	if err != nil {
+		return nil, err
+	}
+	cutoff := time.Now().Add(-olderThan)
+	present := make(map[string]bool)
+
+	for _, file := range files {
+		name := file.info.Name()
+		if !strings.HasPrefix(name, "kv-data-") || strings.HasSuffix(name, ".lock") || strings.HasSuffix(name, checksumSuffix) {
+			continue
+		}
+		key := strings.TrimPrefix(name, "kv-data-")
+		if !file.info.ModTime().Before(cutoff) {
+			present[key] = true
+			report.RemainingKeys++
+			continue
+		}
+
+		if !dryRun {
+			removed, err := k.removeIfOlder(key, file.path, cutoff)
+			if err != nil {
+				return nil, err
+			}
+			if !removed {
+				present[key] = true
+				report.RemainingKeys++
+				continue
+			}
+		}
+		report.RemovedKeys = append(report.RemovedKeys, key)
+		report.ReclaimedBytes += file.info.Size()
+	}
+
+	// Taking a key's lock creates its lock file, so clear checksum files
+	// before the lock files beside them
+	sort.SliceStable(files, func(i, j int) bool {
+		return !strings.HasSuffix(files[i].path, ".lock") && strings.HasSuffix(files[j].path, ".lock")
+	})
+	for _, file := range files {
+		name := file.info.Name()
+		switch {
+		case strings.HasPrefix(name, ".kv-data-") && strings.Contains(name, ".tmp-"):
+			// A Put holds its temporary file only briefly; older ones were
+			// abandoned by a crash
+			if file.info.ModTime().After(time.Now().Add(-time.Hour)) {
+				continue
+			}
+			if !dryRun {
+				if err := os.Remove(file.path); err != nil && !os.IsNotExist(err) {
+					return nil, fmt.Errorf("failed to remove %s: %w", name, err)
+				}
+			}
+			report.TempFilesRemoved++
+			report.ReclaimedBytes += file.info.Size()
+		case strings.HasPrefix(name, "kv-data-") && (strings.HasSuffix(name, ".lock") || strings.HasSuffix(name, checksumSuffix)):
+			key := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(name, "kv-data-"), ".lock"), checksumSuffix)
+			if present[key] {
+				continue
+			}
+			if !dryRun {
+				removed, err := k.removeUnusedLock(key, file.path)
+				if err != nil {
+					return nil, err
+				}
+				if !removed {
+					continue
+				}
+			}
+			report.LockFilesRemoved++
+		}
+	}
+
+	sort.Strings(report.RemovedKeys)
+	return report, nil
+}
+
+// storedFile is a regular file in the storage directory or a shard
+type storedFile struct {
+	path string
+	info os.FileInfo
+}
+
+// storedFiles lists the files of both the flat and the sharded layout
+func (k *KVImpl) storedFiles() ([]storedFile, error) {
+	var files []storedFile
+	var walk func(dir string, top bool) error
+	walk = func(dir string, top bool) error {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return fmt.Errorf("failed to read storage directory: %w", err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				if top && isShardDirName(entry.Name()) {
+					if err := walk(filepath.Join(dir, entry.Name()), false); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			files = append(files, storedFile{path: filepath.Join(dir, entry.Name()), info: info})
+		}
+		return nil
+	}
+	return files, walk(k.storageDir, true)
+}
+
+// isShardDirName reports whether name is a shard directory's name
+func isShardDirName(name string) bool {
+	if len(name) != 2 {
+		return false
+	}
+	_, err := hex.DecodeString(name)
+	return err == nil && strings.ToLower(name) == name
+}
+
+// removeIfOlder removes a key's data file at path under the key's lock if
+// it still has not been written since cutoff
+func (k *KVImpl) removeIfOlder(key, path string, cutoff time.Time) (bool, error) {
+	lock, err := k.kvLock(key)
+	if err != nil {
+		return false, err
+	}
+	if err := k.acquire(lock, key, false); err != nil {
+		return false, err
+	}
+	defer k.unlock(lock, key)
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !info.ModTime().Before(cutoff) {
+		return false, nil
+	}
+	if err := os.Remove(path); err != nil {
+		return false, fmt.Errorf("failed to remove key %s: %w", key, err)
+	}
+	if err := os.Remove(k.kvChecksumPath(key)); err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("failed to remove checksum for key %s: %w", key, err)
+	}
+	k.logger.Debug("🗄️🧹 removed entry", "key", key, "modified", info.ModTime())
+	return true, nil
+}
+
+// removeUnusedLock removes a lock or checksum file at path for a key with
+// no data in either layout, unless the key's lock is currently held
+func (k *KVImpl) removeUnusedLock(key, path string) (bool, error) {
+	// The file may have gone with its entry
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return false, nil
+	}
+	lock, err := k.kvLock(key)
+	if err != nil {
+		return false, err
+	}
+	locked, err := lock.TryLock()
+	if err != nil || !locked {
+		return false, nil
+	}
+	defer k.unlock(lock, key)
+
+	for _, path := range []string{k.kvDataPath(key), k.kvFlatPath(key)} {
+		if _, err := os.Stat(path); err == nil {
+			return false, nil
+		}
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("failed to remove %s: %w", filepath.Base(path), err)
+	}
+	return true, nil
+}
@@ -0,0 +1,110 @@
+package kvplugin
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ErrUnsupported is returned by Delete and List for KV backends without
+// the operation
+var ErrUnsupported = errors.New("operation not supported by this KV backend")
+
+// deleter is implemented by KV backends that can remove keys
+type deleter interface {
+	Delete(key string) (bool, error)
+}
+
+// lister is implemented by KV backends that can enumerate their keys
+type lister interface {
+	List(prefix string) ([]string, error)
+}
+
+// Delete removes a key through kv's Delete, reporting whether it existed
+func Delete(kv KV, key string) (bool, error) {
+	if d, ok := kv.(deleter); ok {
+		return d.Delete(key)
+	}
+	return false, ErrUnsupported
+}
+
+// List returns kv's keys starting with prefix in lexical order
+func List(kv KV, prefix string) ([]string, error) {
+	if l, ok := kv.(lister); ok {
+		return l.List(prefix)
+	}
+	return nil, ErrUnsupported
+}
+
+// Delete removes a key's value and checksum, in both layouts, under the
+// key's lock. The lock file is left for GC to compact, since removing it
+// while another process waits on it would let two writers in at once.
+func (k *KVImpl) Delete(key string) (bool, error) {
+	if key == "" {
+		return false, nil
+	}
+
+	lock, err := k.kvLock(key)
+	if err != nil {
+		return false, err
+	}
+	if err := k.acquire(lock, key, false); err != nil {
+		return false, err
+	}
+	defer k.unlock(lock, key)
+
+	existed := false
+	for _, path := range []string{k.kvDataPath(key), k.kvFlatPath(key)} {
+		if err := os.Remove(path); err == nil {
+			existed = true
+		} else if !os.IsNotExist(err) {
+			return existed, fmt.Errorf("failed to delete key %s: %w", key, err)
+		}
+	}
+	if err := os.Remove(k.kvChecksumPath(key)); err != nil && !os.IsNotExist(err) {
+		return existed, fmt.Errorf("failed to remove checksum for key %s: %w", key, err)
+	}
+	if !existed {
+		return false, nil
+	}
+
+	if err := syncDir(k.kvShardDir(key)); err != nil {
+		return true, fmt.Errorf("failed to sync shard directory: %w", err)
+	}
+	if err := syncDir(k.storageDir); err != nil {
+		return true, fmt.Errorf("failed to sync storage directory: %w", err)
+	}
+	k.logger.Debug("🗄️🗑️ deleted entry", "key", key)
+	return true, nil
+}
+
+// List returns the keys starting with prefix, in both layouts, in lexical
+// order
+func (k *KVImpl) List(prefix string) ([]string, error) {
+	files, err := k.storedFiles()
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	keys := []string{}
+	for _, file := range files {
+		name := file.info.Name()
+		if !strings.HasPrefix(name, "kv-data-") || strings.HasSuffix(name, ".lock") || strings.HasSuffix(name, checksumSuffix) {
+			continue
+		}
+		key := strings.TrimPrefix(name, "kv-data-")
+		if !strings.HasPrefix(key, prefix) || seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
@@ -0,0 +1,678 @@
+// Package kvplugin implements the TofuSoup KV service: the go-plugin
+// plugin and gRPC client and server, and the file-backed store behind them.
+package kvplugin
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gofrs/flock"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-plugin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/provide-io/tofusoup/proto/kv"
+)
+
+// Handshake is a common handshake that is shared by plugin and host.
+var Handshake = plugin.HandshakeConfig{
+	ProtocolVersion:  1,
+	MagicCookieKey:   "BASIC_PLUGIN",
+	MagicCookieValue: "hello",
+}
+
+// getEnvOrDefault retrieves environment variable or returns default value
+func getEnvOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// KV is the interface that we're exposing as a plugin.
+type KV interface {
+	Put(key string, value []byte) error
+	Get(key string) ([]byte, error)
+}
+
+// KVGRPCPlugin is the implementation of plugin.GRPCPlugin so we can serve/consume this.
+type KVGRPCPlugin struct {
+	plugin.Plugin
+	// Concrete implementation, written in Go.
+	Impl KV
+	// ChunkSize is the server's default GetStream chunk size
+	ChunkSize int
+}
+
+func (p *KVGRPCPlugin) GRPCClient(ctx context.Context, broker *plugin.GRPCBroker, c *grpc.ClientConn) (interface{}, error) {
+	logger := hclog.New(&hclog.LoggerOptions{
+		Name:  "🔌🌐 kv-grpc-client",
+		Level: hclog.Debug,
+	})
+
+	if c == nil {
+		logger.Error("🌐❌ received nil gRPC connection")
+		return nil, fmt.Errorf("nil gRPC connection")
+	}
+
+	logger.Debug("🌐🔄 initializing new gRPC client connection",
+		"connection_state", c.GetState().String(),
+		"target", c.Target())
+
+	grpcClient := &GRPCClient{
+		client: proto.NewKVClient(c),
+		logger: logger,
+	}
+
+	logger.Debug("🌐✨ GRPCClient wrapper initialized successfully",
+		"client_implementation", fmt.Sprintf("%T", grpcClient))
+	return grpcClient, nil
+}
+
+func (p *KVGRPCPlugin) GRPCServer(broker *plugin.GRPCBroker, s *grpc.Server) error {
+	logger := hclog.New(&hclog.LoggerOptions{
+		Name:  "🔌📡 kv-grpc-server",
+		Level: hclog.Debug,
+	})
+
+	logger.Debug("📡🔄 initializing gRPC server registration")
+
+	if p.Impl == nil {
+		logger.Warn("📡⚠️ no implementation provided, using default implementation")
+		// Use XDG-compliant cache directory
+		storageDir := StorageDir()
+		p.Impl = NewKVImpl(logger.Named("kv"), storageDir)
+	}
+
+	server := RegisterKVServices(s, p.Impl, logger, p.ChunkSize)
+	logger.Info("📡✅ gRPC server registered successfully",
+		"server_type", fmt.Sprintf("%T", server))
+	return nil
+}
+
+// GRPCClient is an implementation of KV that talks over RPC.
+type GRPCClient struct {
+	client proto.KVClient
+	logger hclog.Logger
+}
+
+func (m *GRPCClient) Put(key string, value []byte) error {
+	m.logger.Debug("🌐📤 initiating Put request",
+		"key", key,
+		"value_size", len(value))
+
+	_, err := m.client.Put(context.Background(), &proto.PutRequest{
+		Key:   key,
+		Value: value,
+	})
+
+	if err != nil {
+		m.logger.Error("🌐❌ Put request failed",
+			"key", key,
+			"error", err)
+		return err
+	}
+
+	m.logger.Debug("🌐✅ Put request completed successfully",
+		"key", key)
+	return nil
+}
+
+func (m *GRPCClient) Get(key string) ([]byte, error) {
+	m.logger.Debug("🌐📥 initiating Get request", "key", key)
+
+	resp, err := m.client.Get(context.Background(), &proto.GetRequest{
+		Key: key,
+	})
+	if err != nil {
+		m.logger.Error("🌐❌ Get request failed", "key", key, "error", err)
+		return nil, err
+	}
+
+	m.logger.Debug("🌐✅ Get request completed successfully", "key", key, "value_size", len(resp.Value))
+	return resp.Value, nil
+}
+
+// GetStream reads a value with the GetStream RPC, writing its chunks to w
+// as they arrive, and returns the number of bytes written. A chunkSize of 0
+// lets the server choose.
+func (m *GRPCClient) GetStream(key string, chunkSize int, w io.Writer) (int64, error) {
+	m.logger.Debug("🌐📥 initiating GetStream request", "key", key, "chunk_size", chunkSize)
+
+	stream, err := m.client.GetStream(context.Background(), &proto.GetStreamRequest{
+		Key:       key,
+		ChunkSize: int32(chunkSize),
+	})
+	if err != nil {
+		m.logger.Error("🌐❌ GetStream request failed", "key", key, "error", err)
+		return 0, err
+	}
+
+	var written int64
+	chunks := 0
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			m.logger.Error("🌐❌ GetStream request failed", "key", key, "error", err)
+			return written, err
+		}
+		n, err := w.Write(chunk.Data)
+		written += int64(n)
+		if err != nil {
+			return written, fmt.Errorf("failed to write value: %w", err)
+		}
+		chunks++
+	}
+
+	m.logger.Debug("🌐✅ GetStream request completed successfully", "key", key, "value_size", written, "chunks", chunks)
+	return written, nil
+}
+
+// GRPCServer is the gRPC server that GRPCClient talks to.
+type GRPCServer struct {
+	proto.UnimplementedKVServer
+	Impl      KV
+	logger    hclog.Logger
+	startTime time.Time
+	// ChunkSize is the GetStream chunk size used when a request leaves it
+	// to the server
+	ChunkSize int
+}
+
+// NewGRPCServer serves impl over gRPC outside go-plugin, as the standalone
+// server does. A chunkSize of 0 selects DefaultStreamChunkSize.
+func NewGRPCServer(impl KV, logger hclog.Logger, chunkSize int) *GRPCServer {
+	return &GRPCServer{
+		Impl:      impl,
+		logger:    logger,
+		startTime: time.Now(),
+		ChunkSize: chunkSize,
+	}
+}
+
+// Stream chunk sizes. Chunks are capped below gRPC's default 4MiB maximum
+// message size, leaving room for message framing.
+const (
+	DefaultStreamChunkSize = 1 << 20
+	maxStreamChunkSize     = 4<<20 - 64<<10
+)
+
+// enrichJSONWithHandshake enriches JSON values with server handshake information.
+// If the value is valid JSON object, adds a 'server_handshake' field with connection metadata.
+// If not JSON, returns the original bytes unchanged.
+func (m *GRPCServer) enrichJSONWithHandshake(ctx context.Context, value []byte) ([]byte, error) {
+	// Try to parse as JSON
+	var jsonData map[string]interface{}
+	if err := json.Unmarshal(value, &jsonData); err != nil {
+		// Not JSON or not an object - return original
+		m.logger.Debug("Value is not JSON object, storing as-is")
+		return value, nil
+	}
+
+	// Get peer information from context
+	peerInfo, ok := peer.FromContext(ctx)
+	endpoint := "unknown"
+	if ok && peerInfo.Addr != nil {
+		endpoint = peerInfo.Addr.String()
+	}
+
+	// Build server handshake information with combo identification
+	serverHandshake := map[string]interface{}{
+		"endpoint":         endpoint,
+		"protocol_version": getEnvOrDefault("PLUGIN_PROTOCOL_VERSIONS", "1"),
+		"tls_mode":         getEnvOrDefault("TLS_MODE", "unknown"),
+		"timestamp":        time.Now().UTC().Format(time.RFC3339),
+		"received_at":      time.Since(m.startTime).Seconds(),
+		// Combo identification
+		"server_language": getEnvOrDefault("SERVER_LANGUAGE", "go"),
+		"client_language": getEnvOrDefault("CLIENT_LANGUAGE", "unknown"),
+		"combo_id":        getEnvOrDefault("COMBO_ID", "unknown"),
+	}
+
+	// Add enhanced crypto configuration
+	tlsKeyType := os.Getenv("TLS_KEY_TYPE")
+	tlsKeySize := os.Getenv("TLS_KEY_SIZE")
+	tlsCurve := os.Getenv("TLS_CURVE")
+
+	if tlsKeyType != "" {
+		cryptoConfig := map[string]interface{}{
+			"key_type": tlsKeyType,
+		}
+
+		if tlsKeyType == "rsa" && tlsKeySize != "" {
+			// Parse key size as integer
+			var keySize int
+			fmt.Sscanf(tlsKeySize, "%d", &keySize)
+			cryptoConfig["key_size"] = keySize
+		} else if tlsKeyType == "ec" && tlsCurve != "" {
+			cryptoConfig["curve"] = tlsCurve
+			// Map curve to key size for reference
+			curveSizes := map[string]int{
+				"secp256r1": 256,
+				"secp384r1": 384,
+				"secp521r1": 521,
+			}
+			if size, ok := curveSizes[tlsCurve]; ok {
+				cryptoConfig["key_size"] = size
+			}
+		}
+
+		serverHandshake["crypto_config"] = cryptoConfig
+	}
+
+	// Add certificate fingerprint if mTLS is enabled
+	serverCertPath := os.Getenv("PLUGIN_SERVER_CERT")
+	if serverCertPath != "" {
+		certData, err := os.ReadFile(serverCertPath)
+		if err == nil {
+			hash := sha256.Sum256(certData)
+			serverHandshake["cert_fingerprint"] = hex.EncodeToString(hash[:])
+		} else {
+			serverHandshake["cert_fingerprint"] = nil
+		}
+	} else {
+		serverHandshake["cert_fingerprint"] = nil
+	}
+
+	// Add server handshake to JSON
+	jsonData["server_handshake"] = serverHandshake
+
+	// Marshal back to JSON
+	enrichedJSON, err := json.Marshal(jsonData)
+	if err != nil {
+		m.logger.Warn("Failed to marshal enriched JSON, using original", "error", err)
+		return value, nil
+	}
+
+	m.logger.Debug("Enriched JSON value with server handshake",
+		"original_size", len(value),
+		"enriched_size", len(enrichedJSON))
+	return enrichedJSON, nil
+}
+
+func (m *GRPCServer) Put(ctx context.Context, req *proto.PutRequest) (*proto.Empty, error) {
+	m.logger.Debug("📡📤 handling Put request",
+		"key", req.Key,
+		"value_size", len(req.Value))
+
+	// Store raw value without enrichment (enrichment happens on Get)
+	if err := m.Impl.Put(req.Key, req.Value); err != nil {
+		m.logger.Error("📡❌ Put operation failed",
+			"key", req.Key,
+			"error", err)
+		if IsLockTimeout(err) {
+			return nil, status.Error(codes.Unavailable, err.Error())
+		}
+		return nil, err
+	}
+
+	m.logger.Debug("📡✅ Put operation completed successfully",
+		"key", req.Key,
+		"stored_size", len(req.Value))
+	return &proto.Empty{}, nil
+}
+
+func (m *GRPCServer) Get(ctx context.Context, req *proto.GetRequest) (*proto.GetResponse, error) {
+	m.logger.Debug("📡📥 handling Get request",
+		"key", req.Key)
+
+	rawValue, err := m.Impl.Get(req.Key)
+	if err != nil {
+		return nil, m.getError(req.Key, err)
+	}
+
+	// Enrich JSON values with server handshake information on Get
+	enrichedValue, err := m.enrichJSONWithHandshake(ctx, rawValue)
+	if err != nil {
+		m.logger.Error("📡❌ Failed to enrich value",
+			"key", req.Key,
+			"error", err)
+		return nil, err
+	}
+
+	m.logger.Debug("📡✅ Get operation completed successfully",
+		"key", req.Key,
+		"raw_size", len(rawValue),
+		"enriched_size", len(enrichedValue))
+	return &proto.GetResponse{Value: enrichedValue}, nil
+}
+
+// GetStream sends a value in chunks of the requested size, so values larger
+// than the maximum message size can be read
+func (m *GRPCServer) GetStream(req *proto.GetStreamRequest, stream proto.KV_GetStreamServer) error {
+	chunkSize := int(req.ChunkSize)
+	if chunkSize <= 0 {
+		chunkSize = m.ChunkSize
+	}
+	if chunkSize <= 0 {
+		chunkSize = DefaultStreamChunkSize
+	}
+	if chunkSize > maxStreamChunkSize {
+		chunkSize = maxStreamChunkSize
+	}
+	m.logger.Debug("📡📥 handling GetStream request",
+		"key", req.Key,
+		"chunk_size", chunkSize)
+
+	// Large values may be memory-mapped; they stay mapped until sent
+	rawValue, release, err := GetMapped(m.Impl, req.Key)
+	if err != nil {
+		return m.getError(req.Key, err)
+	}
+	defer release()
+
+	// Values are enriched exactly as Get enriches them
+	value, err := m.enrichJSONWithHandshake(stream.Context(), rawValue)
+	if err != nil {
+		m.logger.Error("📡❌ Failed to enrich value",
+			"key", req.Key,
+			"error", err)
+		return err
+	}
+
+	chunks := 0
+	for offset := 0; offset < len(value); offset += chunkSize {
+		end := offset + chunkSize
+		if end > len(value) {
+			end = len(value)
+		}
+		if err := stream.Send(&proto.GetStreamChunk{Data: value[offset:end]}); err != nil {
+			m.logger.Error("📡❌ GetStream send failed",
+				"key", req.Key,
+				"error", err)
+			return err
+		}
+		chunks++
+	}
+
+	m.logger.Debug("📡✅ GetStream operation completed successfully",
+		"key", req.Key,
+		"value_size", len(value),
+		"chunks", chunks)
+	return nil
+}
+
+// getError maps a KV Get error to the status returned to clients
+func (m *GRPCServer) getError(key string, err error) error {
+	// Check if this is a file not found error (key doesn't exist)
+	if os.IsNotExist(err) {
+		m.logger.Debug("📡📥 key not found",
+			"key", key)
+		return status.Errorf(codes.NotFound, "key not found: %s", key)
+	}
+	var mismatch *ChecksumMismatchError
+	if errors.As(err, &mismatch) {
+		return status.Error(codes.DataLoss, mismatch.Error())
+	}
+	if IsLockTimeout(err) {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	m.logger.Error("📡❌ Get operation failed",
+		"key", key,
+		"error", err)
+	return err
+}
+
+// KVImpl provides a simple file-based KV implementation
+type KVImpl struct {
+	logger       hclog.Logger
+	mu           sync.RWMutex
+	storageDir   string
+	checksumMode string
+
+	lockTimeout    time.Duration
+	lockBackoff    time.Duration
+	lockMaxBackoff time.Duration
+
+	mmapThreshold int64
+}
+
+// NewKVImpl creates a new KVImpl with a configurable storage directory
+func NewKVImpl(logger hclog.Logger, storageDir string) *KVImpl {
+	if storageDir == "" {
+		storageDir = StorageDir()
+	}
+	logger.Debug("Initializing KVImpl", "storage_dir", storageDir)
+	return &KVImpl{
+		logger:       logger,
+		mu:           sync.RWMutex{},
+		storageDir:   storageDir,
+		checksumMode: ChecksumWarn,
+
+		lockTimeout:    DefaultLockTimeout,
+		lockBackoff:    DefaultLockBackoff,
+		lockMaxBackoff: DefaultLockMaxBackoff,
+	}
+}
+
+// kvShardDir returns the fan-out subdirectory holding a key: the first
+// byte of the key's SHA-256 in hex. Sharding keeps directories small, since
+// some CI filesystems degrade badly with hundreds of thousands of entries.
+// The Python server uses the same layout, so both can share a store.
+func (k *KVImpl) kvShardDir(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(k.storageDir, hex.EncodeToString(sum[:1]))
+}
+
+// kvDataPath returns the file holding a key's value
+func (k *KVImpl) kvDataPath(key string) string {
+	return filepath.Join(k.kvShardDir(key), "kv-data-"+key)
+}
+
+// kvFlatPath returns where the flat layout, used by both servers before
+// sharding, kept a key's value
+func (k *KVImpl) kvFlatPath(key string) string {
+	return k.storageDir + "/kv-data-" + key
+}
+
+// kvLock returns the lock guarding a key, creating its shard directory.
+// The lock lives in its own file because Put replaces the data file, and a
+// lock held on a replaced file would no longer exclude anyone.
+func (k *KVImpl) kvLock(key string) (*flock.Flock, error) {
+	if err := os.MkdirAll(k.kvShardDir(key), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create shard directory for key %s: %w", key, err)
+	}
+	return flock.New(k.kvDataPath(key) + ".lock"), nil
+}
+
+// unlock releases a key lock, logging failures
+func (k *KVImpl) unlock(lock *flock.Flock, key string) {
+	if err := lock.Unlock(); err != nil {
+		k.logger.Error("failed to unlock file", "key", key, "error", err)
+	}
+}
+
+// Put stores a value crash-consistently: it is written to a temporary file
+// in the key's shard directory, synced, and atomically renamed over the
+// key's data file, and the directory is synced so the rename itself is
+// durable. Readers therefore see either the old or the new value, never a
+// partial write. A copy left in the flat layout is removed.
+func (k *KVImpl) Put(key string, value []byte) error {
+	if key == "" {
+		return nil
+	}
+
+	filePath := k.kvDataPath(key)
+	lock, err := k.kvLock(key)
+	if err != nil {
+		return err
+	}
+	if err := k.acquire(lock, key, false); err != nil {
+		return err
+	}
+	defer k.unlock(lock, key)
+
+	if err := k.writeAtomic(filePath, value); err != nil {
+		return fmt.Errorf("failed to store key %s: %w", key, err)
+	}
+	if err := k.storeChecksum(key, value); err != nil {
+		return err
+	}
+
+	if err := os.Remove(k.kvFlatPath(key)); err == nil {
+		k.logger.Debug("🗄️🔀 replaced flat layout entry", "key", key)
+		if err := syncDir(k.storageDir); err != nil {
+			return fmt.Errorf("failed to sync storage directory: %w", err)
+		}
+	}
+	return nil
+}
+
+// writeAtomic replaces path with data via a synced temporary file in the
+// same directory and a rename
+func (k *KVImpl) writeAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	committed := false
+	defer func() {
+		if !committed {
+			os.Remove(tmpPath)
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write value: %w", err)
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	// fsync to ensure data is flushed to disk before it becomes visible
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync value: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to commit value: %w", err)
+	}
+	committed = true
+
+	if err := syncDir(dir); err != nil {
+		return fmt.Errorf("failed to sync directory: %w", err)
+	}
+	return nil
+}
+
+func (k *KVImpl) Get(key string) ([]byte, error) {
+	value, _, err := k.get(key, false)
+	return value, err
+}
+
+// get reads a key's value. When mapped is set and the value file reaches
+// the mmap threshold, the value is memory-mapped rather than read, and the
+// returned release func unmaps it; callers must call release once done.
+func (k *KVImpl) get(key string, mapped bool) ([]byte, func(), error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	noRelease := func() {}
+	if key == "" {
+		return nil, noRelease, nil
+	}
+
+	k.logger.Debug("🗄️📥 getting value", "key", key)
+	filePath := k.kvDataPath(key)
+
+	// Missing keys are reported without creating a lock file for them
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		if _, flatErr := os.Stat(k.kvFlatPath(key)); flatErr != nil {
+			return nil, noRelease, err
+		}
+		if err := k.migrateFlat(key); err != nil {
+			return nil, noRelease, err
+		}
+	} else if err != nil {
+		return nil, noRelease, err
+	}
+
+	lock, err := k.kvLock(key)
+	if err != nil {
+		return nil, noRelease, err
+	}
+	if err := k.acquire(lock, key, true); err != nil {
+		return nil, noRelease, err
+	}
+	defer k.unlock(lock, key)
+
+	var value []byte
+	release := noRelease
+	if mapped {
+		value, release, err = k.readMapped(key, filePath)
+	} else {
+		value, err = os.ReadFile(filePath)
+	}
+	if err != nil {
+		return nil, noRelease, err
+	}
+	if err := k.verifyChecksum(key, value); err != nil {
+		release()
+		return nil, noRelease, err
+	}
+	return value, release, nil
+}
+
+// migrateFlat moves a key from the flat layout into its shard, so stores
+// written before sharding stay readable
+func (k *KVImpl) migrateFlat(key string) error {
+	lock, err := k.kvLock(key)
+	if err != nil {
+		return err
+	}
+	if err := k.acquire(lock, key, false); err != nil {
+		return err
+	}
+	defer k.unlock(lock, key)
+
+	// A Put may have sharded the key while we waited for the lock
+	if _, err := os.Stat(k.kvDataPath(key)); err == nil {
+		return nil
+	}
+	if err := os.Rename(k.kvFlatPath(key), k.kvDataPath(key)); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to migrate key %s to sharded layout: %w", key, err)
+	}
+	if err := syncDir(k.kvShardDir(key)); err != nil {
+		return fmt.Errorf("failed to sync shard directory: %w", err)
+	}
+	if err := syncDir(k.storageDir); err != nil {
+		return fmt.Errorf("failed to sync storage directory: %w", err)
+	}
+	k.logger.Debug("🗄️🔀 migrated entry to sharded layout", "key", key)
+	return nil
+}
+
+// syncDir fsyncs a directory so that renames within it survive a crash
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
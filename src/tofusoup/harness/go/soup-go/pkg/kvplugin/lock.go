@@ -0,0 +1,98 @@
+package kvplugin
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/gofrs/flock"
+)
+
+// Default lock acquisition policy of KVImpl
+const (
+	DefaultLockTimeout    = 30 * time.Second
+	DefaultLockBackoff    = 10 * time.Millisecond
+	DefaultLockMaxBackoff = time.Second
+)
+
+// LockTimeoutError reports that a key's file lock could not be acquired
+// in time, typically because another server holds it. The gRPC server
+// surfaces it as Unavailable.
+type LockTimeoutError struct {
+	Key      string
+	Timeout  time.Duration
+	Attempts int
+}
+
+func (e *LockTimeoutError) Error() string {
+	return fmt.Sprintf("timed out after %s acquiring lock for key %s (%d attempts)", e.Timeout, e.Key, e.Attempts)
+}
+
+// IsLockTimeout reports whether err is or wraps a LockTimeoutError
+func IsLockTimeout(err error) bool {
+	var timeoutErr *LockTimeoutError
+	return errors.As(err, &timeoutErr)
+}
+
+// SetLockPolicy sets how long KVImpl waits for a key's file lock and how
+// it retries meanwhile: after each failed attempt it sleeps for a backoff
+// that starts at backoff and doubles up to maxBackoff. A timeout of 0 waits
+// indefinitely.
+func (k *KVImpl) SetLockPolicy(timeout, backoff, maxBackoff time.Duration) {
+	k.lockTimeout = timeout
+	k.lockBackoff = backoff
+	k.lockMaxBackoff = maxBackoff
+}
+
+// acquire takes a key's lock, shared for readers, within the lock timeout
+func (k *KVImpl) acquire(lock *flock.Flock, key string, shared bool) error {
+	kind := "lock"
+	tryLock, blockingLock := lock.TryLock, lock.Lock
+	if shared {
+		kind = "read lock"
+		tryLock, blockingLock = lock.TryRLock, lock.RLock
+	}
+
+	if k.lockTimeout <= 0 {
+		if err := blockingLock(); err != nil {
+			return fmt.Errorf("failed to acquire %s for key %s: %w", kind, key, err)
+		}
+		return nil
+	}
+
+	deadline := time.Now().Add(k.lockTimeout)
+	backoff := k.lockBackoff
+	if backoff <= 0 {
+		backoff = DefaultLockBackoff
+	}
+	for attempts := 1; ; attempts++ {
+		locked, err := tryLock()
+		if err != nil {
+			return fmt.Errorf("failed to acquire %s for key %s: %w", kind, key, err)
+		}
+		if locked {
+			if attempts > 1 {
+				k.logger.Debug("🗄️🔒 acquired contended lock", "key", key, "attempts", attempts)
+			}
+			return nil
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			k.logger.Warn("🗄️⏳ lock acquisition timed out", "key", key, "timeout", k.lockTimeout, "attempts", attempts)
+			return &LockTimeoutError{Key: key, Timeout: k.lockTimeout, Attempts: attempts}
+		}
+		// Jitter keeps competing servers from retrying in lockstep
+		sleep := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		if sleep > remaining {
+			sleep = remaining
+		}
+		time.Sleep(sleep)
+
+		backoff *= 2
+		if k.lockMaxBackoff > 0 && backoff > k.lockMaxBackoff {
+			backoff = k.lockMaxBackoff
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package kvplugin
+
+import (
+	"fmt"
+	"os"
+)
+
+// mappedGetter is implemented by KV backends that can hand out values
+// without copying them into the heap. The returned release func must be
+// called once the value is no longer used.
+type mappedGetter interface {
+	GetMapped(key string) ([]byte, func(), error)
+}
+
+// GetMapped reads a key through kv's GetMapped when it has one, and through
+// Get otherwise
+func GetMapped(kv KV, key string) ([]byte, func(), error) {
+	if mg, ok := kv.(mappedGetter); ok {
+		return mg.GetMapped(key)
+	}
+	value, err := kv.Get(key)
+	return value, func() {}, err
+}
+
+// SetMmapThreshold makes GetMapped memory-map value files of at least
+// threshold bytes instead of reading them. 0 disables mapping.
+func (k *KVImpl) SetMmapThreshold(threshold int64) {
+	k.mmapThreshold = threshold
+}
+
+// GetMapped is Get for callers that stream the value out, such as
+// GetStream. Large values are memory-mapped so serving them does not
+// allocate a buffer the size of the value.
+func (k *KVImpl) GetMapped(key string) ([]byte, func(), error) {
+	return k.get(key, true)
+}
+
+// readMapped maps a value file when it reaches the mmap threshold and
+// reads it otherwise. Put replaces value files by rename rather than
+// rewriting them, so a mapping stays valid after the key's lock is
+// released.
+func (k *KVImpl) readMapped(key, filePath string) ([]byte, func(), error) {
+	noRelease := func() {}
+	if k.mmapThreshold <= 0 {
+		value, err := os.ReadFile(filePath)
+		return value, noRelease, err
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, noRelease, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, noRelease, err
+	}
+	if info.Size() < k.mmapThreshold {
+		value, err := os.ReadFile(filePath)
+		return value, noRelease, err
+	}
+
+	value, unmap, err := mmapFile(f, info.Size())
+	if err != nil {
+		return nil, noRelease, fmt.Errorf("failed to map value for key %s: %w", key, err)
+	}
+	k.logger.Debug("🗄️🗺️ mapped value", "key", key, "size", info.Size())
+	return value, func() {
+		if err := unmap(); err != nil {
+			k.logger.Warn("🗄️⚠️ failed to unmap value", "key", key, "error", err)
+		}
+	}, nil
+}
+
+func (c *CachingKV) GetMapped(key string) ([]byte, func(), error) {
+	c.mu.Lock()
+	if elem, ok := c.entries[key]; ok {
+		c.order.MoveToFront(elem)
+		c.hits++
+		value := append([]byte(nil), elem.Value.(*cacheEntry).value...)
+		c.mu.Unlock()
+		return value, func() {}, nil
+	}
+	c.misses++
+	generation := c.generation
+	c.mu.Unlock()
+
+	value, release, err := GetMapped(c.KV, key)
+	if err != nil {
+		return nil, release, err
+	}
+
+	// Only values the cache can hold are copied out of a mapping
+	c.mu.Lock()
+	if c.generation == generation && int64(len(value)) <= c.maxBytes {
+		c.add(key, append([]byte(nil), value...))
+	}
+	c.mu.Unlock()
+	return value, release, nil
+}
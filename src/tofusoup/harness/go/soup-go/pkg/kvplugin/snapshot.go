@@ -0,0 +1,257 @@
+package kvplugin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// snapshotManifest is the file describing a snapshot, beside its entries
+const snapshotManifest = "snapshot.json"
+
+var snapshotNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// SnapshotReport describes a snapshot taken or restored
+type SnapshotReport struct {
+	Name string `json:"name"`
+	// Path is the snapshot's directory
+	Path       string `json:"path"`
+	StorageDir string `json:"storage_dir"`
+	CreatedAt  string `json:"created_at"`
+	Keys       int    `json:"keys"`
+	Bytes      int64  `json:"bytes"`
+}
+
+// ValidateSnapshotName rejects names that are not a single path element
+func ValidateSnapshotName(name string) error {
+	if !snapshotNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid snapshot name %q: use letters, digits, '.', '_' and '-'", name)
+	}
+	return nil
+}
+
+// Snapshot copies the store's entries, with their checksums and
+// modification times, into dir/name, replacing any snapshot of that name.
+// Each entry is copied under its key's read lock, so entries are never torn,
+// but entries written while the snapshot runs may or may not be included.
+func (k *KVImpl) Snapshot(dir, name string) (*SnapshotReport, error) {
+	if err := ValidateSnapshotName(name); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	staging, err := os.MkdirTemp(dir, "."+name+".tmp-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create snapshot staging directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+	// MkdirTemp creates the directory private; it is renamed into place
+	if err := os.Chmod(staging, 0755); err != nil {
+		return nil, err
+	}
+
+	report := &SnapshotReport{
+		Name:       name,
+		Path:       filepath.Join(dir, name),
+		StorageDir: k.storageDir,
+		CreatedAt:  time.Now().UTC().Format(time.RFC3339),
+	}
+
+	files, err := k.storedFiles()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	keys := make(map[string]bool)
+	for _, file := range files {
+		name := file.info.Name()
+		if !strings.HasPrefix(name, "kv-data-") || strings.HasSuffix(name, ".lock") || strings.HasSuffix(name, checksumSuffix) {
+			continue
+		}
+		key := strings.TrimPrefix(name, "kv-data-")
+		copied, err := k.snapshotEntry(key, file.path, staging)
+		if err != nil {
+			return nil, err
+		}
+		report.Bytes += copied
+		keys[key] = true
+	}
+	report.Keys = len(keys)
+
+	manifest, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode snapshot manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(staging, snapshotManifest), manifest, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write snapshot manifest: %w", err)
+	}
+	if err := replaceDir(staging, report.Path); err != nil {
+		return nil, fmt.Errorf("failed to commit snapshot %s: %w", name, err)
+	}
+	k.logger.Debug("🗄️📸 created snapshot", "name", name, "keys", report.Keys, "bytes", report.Bytes)
+	return report, nil
+}
+
+// snapshotEntry copies a key's data file at path and its checksum file
+// into staging under the key's read lock, returning the bytes of data
+// copied. A file gone by the time the lock is held was deleted meanwhile.
+func (k *KVImpl) snapshotEntry(key, path, staging string) (int64, error) {
+	lock, err := k.kvLock(key)
+	if err != nil {
+		return 0, err
+	}
+	if err := k.acquire(lock, key, true); err != nil {
+		return 0, err
+	}
+	defer k.unlock(lock, key)
+
+	rel, err := filepath.Rel(k.storageDir, path)
+	if err != nil {
+		return 0, err
+	}
+	n, err := copyFile(path, filepath.Join(staging, rel))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to snapshot key %s: %w", key, err)
+	}
+	if _, err := copyFile(path+checksumSuffix, filepath.Join(staging, rel+checksumSuffix)); err != nil && !os.IsNotExist(err) {
+		return 0, fmt.Errorf("failed to snapshot checksum for key %s: %w", key, err)
+	}
+	return n, nil
+}
+
+// ReadSnapshot returns the manifest of the snapshot dir/name
+func ReadSnapshot(dir, name string) (*SnapshotReport, error) {
+	if err := ValidateSnapshotName(name); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, name, snapshotManifest))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("snapshot %s not found in %s", name, dir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot manifest: %w", err)
+	}
+	var report SnapshotReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot manifest: %w", err)
+	}
+	report.Path = filepath.Join(dir, name)
+	return &report, nil
+}
+
+// Restore replaces the storage directory with the snapshot dir/name. The
+// snapshot is copied beside the storage directory and renamed into place,
+// so readers never see a mix of old and restored entries, though they may
+// briefly find the directory missing. Servers should be idle while
+// restoring: a write in flight lands in the replaced directory and is lost.
+func (k *KVImpl) Restore(dir, name string) (*SnapshotReport, error) {
+	report, err := ReadSnapshot(dir, name)
+	if err != nil {
+		return nil, err
+	}
+
+	parent := filepath.Dir(filepath.Clean(k.storageDir))
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create storage parent directory: %w", err)
+	}
+	staging, err := os.MkdirTemp(parent, "."+filepath.Base(k.storageDir)+".restore-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create restore staging directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+	if err := os.Chmod(staging, 0755); err != nil {
+		return nil, err
+	}
+
+	err = filepath.Walk(report.Path, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(report.Path, path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || rel == snapshotManifest {
+			return nil
+		}
+		_, err = copyFile(path, filepath.Join(staging, rel))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy snapshot %s: %w", name, err)
+	}
+
+	if err := replaceDir(staging, k.storageDir); err != nil {
+		return nil, fmt.Errorf("failed to restore snapshot %s: %w", name, err)
+	}
+	report.StorageDir = k.storageDir
+	k.logger.Debug("🗄️📸 restored snapshot", "name", name, "keys", report.Keys, "bytes", report.Bytes)
+	return report, nil
+}
+
+// replaceDir renames src over dst, moving any existing dst aside first and
+// removing it once src is in place
+func replaceDir(src, dst string) error {
+	var old string
+	if _, err := os.Stat(dst); err == nil {
+		old = fmt.Sprintf("%s.old-%d", dst, time.Now().UnixNano())
+		if err := os.Rename(dst, old); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(src, dst); err != nil {
+		if old != "" {
+			os.Rename(old, dst)
+		}
+		return err
+	}
+	if err := syncDir(filepath.Dir(dst)); err != nil {
+		return fmt.Errorf("failed to sync %s: %w", filepath.Dir(dst), err)
+	}
+	if old != "" {
+		return os.RemoveAll(old)
+	}
+	return nil
+}
+
+// copyFile copies src to dst, creating dst's directory, syncing it and
+// keeping src's modification time so GC ages restored entries as before
+func copyFile(src, dst string) (int64, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return 0, err
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(out, in)
+	if err == nil {
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return n, err
+	}
+	return n, os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
@@ -0,0 +1,168 @@
+package kvplugin
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hashicorp/go-hclog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/provide-io/tofusoup/proto/kv"
+	kvv1 "github.com/provide-io/tofusoup/proto/kv/v1"
+	kvv2 "github.com/provide-io/tofusoup/proto/kv/v2"
+)
+
+// KV API versions a server registers, by gRPC service name
+const (
+	ServiceLegacy = "proto.KV"
+	ServiceV1     = "kv.v1.KV"
+	ServiceV2     = "kv.v2.KV"
+)
+
+// RegisterKVServices registers every KV API version on s, all backed by
+// impl: the unversioned proto.KV, kept for clients built before versioning,
+// kv.v1 and kv.v2. Changes made through any version reach kv.v2 watchers.
+func RegisterKVServices(s grpc.ServiceRegistrar, impl KV, logger hclog.Logger, chunkSize int) *GRPCServer {
+	hub := newWatchHub()
+	server := NewGRPCServer(&watchedKV{KV: impl, hub: hub}, logger, chunkSize)
+	proto.RegisterKVServer(s, server)
+	kvv1.RegisterKVServer(s, &v1Server{base: server})
+	kvv2.RegisterKVServer(s, &v2Server{base: server, hub: hub})
+	return server
+}
+
+// chunkSender adapts a versioned GetStream stream to the unversioned one
+// GRPCServer.GetStream sends on
+type chunkSender struct {
+	grpc.ServerStream
+	send func(data []byte) error
+}
+
+func (c chunkSender) Send(chunk *proto.GetStreamChunk) error {
+	return c.send(chunk.Data)
+}
+
+// v1Server serves kv.v1 through the unversioned server
+type v1Server struct {
+	kvv1.UnimplementedKVServer
+	base *GRPCServer
+}
+
+func (s *v1Server) Get(ctx context.Context, req *kvv1.GetRequest) (*kvv1.GetResponse, error) {
+	resp, err := s.base.Get(ctx, &proto.GetRequest{Key: req.Key})
+	if err != nil {
+		return nil, err
+	}
+	return &kvv1.GetResponse{Value: resp.Value}, nil
+}
+
+func (s *v1Server) Put(ctx context.Context, req *kvv1.PutRequest) (*kvv1.PutResponse, error) {
+	if _, err := s.base.Put(ctx, &proto.PutRequest{Key: req.Key, Value: req.Value}); err != nil {
+		return nil, err
+	}
+	return &kvv1.PutResponse{}, nil
+}
+
+func (s *v1Server) GetStream(req *kvv1.GetStreamRequest, stream kvv1.KV_GetStreamServer) error {
+	return s.base.GetStream(&proto.GetStreamRequest{Key: req.Key, ChunkSize: req.ChunkSize}, chunkSender{
+		ServerStream: stream,
+		send:         func(data []byte) error { return stream.Send(&kvv1.GetStreamChunk{Data: data}) },
+	})
+}
+
+// v2Server serves kv.v2 through the unversioned server, adding Delete,
+// List and Watch
+type v2Server struct {
+	kvv2.UnimplementedKVServer
+	base *GRPCServer
+	hub  *watchHub
+}
+
+func (s *v2Server) Get(ctx context.Context, req *kvv2.GetRequest) (*kvv2.GetResponse, error) {
+	resp, err := s.base.Get(ctx, &proto.GetRequest{Key: req.Key})
+	if err != nil {
+		return nil, err
+	}
+	return &kvv2.GetResponse{Value: resp.Value}, nil
+}
+
+func (s *v2Server) Put(ctx context.Context, req *kvv2.PutRequest) (*kvv2.PutResponse, error) {
+	if _, err := s.base.Put(ctx, &proto.PutRequest{Key: req.Key, Value: req.Value}); err != nil {
+		return nil, err
+	}
+	return &kvv2.PutResponse{}, nil
+}
+
+func (s *v2Server) GetStream(req *kvv2.GetStreamRequest, stream kvv2.KV_GetStreamServer) error {
+	return s.base.GetStream(&proto.GetStreamRequest{Key: req.Key, ChunkSize: req.ChunkSize}, chunkSender{
+		ServerStream: stream,
+		send:         func(data []byte) error { return stream.Send(&kvv2.GetStreamChunk{Data: data}) },
+	})
+}
+
+func (s *v2Server) Delete(ctx context.Context, req *kvv2.DeleteRequest) (*kvv2.DeleteResponse, error) {
+	s.base.logger.Debug("📡🗑️ handling Delete request", "key", req.Key)
+	existed, err := Delete(s.base.Impl, req.Key)
+	if err != nil {
+		s.base.logger.Error("📡❌ Delete operation failed", "key", req.Key, "error", err)
+		return nil, statusError(err)
+	}
+	return &kvv2.DeleteResponse{Existed: existed}, nil
+}
+
+func (s *v2Server) List(ctx context.Context, req *kvv2.ListRequest) (*kvv2.ListResponse, error) {
+	s.base.logger.Debug("📡📋 handling List request", "prefix", req.Prefix)
+	keys, err := List(s.base.Impl, req.Prefix)
+	if err != nil {
+		s.base.logger.Error("📡❌ List operation failed", "prefix", req.Prefix, "error", err)
+		return nil, statusError(err)
+	}
+	return &kvv2.ListResponse{Keys: keys}, nil
+}
+
+// Watch streams changes until the client cancels. Headers are sent once
+// the watcher is registered, so clients can wait for them before making
+// changes they expect to see.
+func (s *v2Server) Watch(req *kvv2.WatchRequest, stream kvv2.KV_WatchServer) error {
+	s.base.logger.Debug("📡👀 handling Watch request", "prefix", req.Prefix)
+	w, cancel := s.hub.subscribe(req.Prefix)
+	defer cancel()
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event, ok := <-w.events:
+			if !ok {
+				if w.dropped {
+					return status.Error(codes.ResourceExhausted, "watcher fell too far behind")
+				}
+				return nil
+			}
+			msg := &kvv2.WatchEvent{Type: kvv2.WatchEvent_TYPE_PUT, Key: event.key, Value: event.value}
+			if event.deleted {
+				msg = &kvv2.WatchEvent{Type: kvv2.WatchEvent_TYPE_DELETE, Key: event.key}
+			}
+			if err := stream.Send(msg); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// statusError maps a KV error to the status returned to clients
+func statusError(err error) error {
+	switch {
+	case errors.Is(err, ErrUnsupported):
+		return status.Error(codes.Unimplemented, err.Error())
+	case IsLockTimeout(err):
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	return err
+}
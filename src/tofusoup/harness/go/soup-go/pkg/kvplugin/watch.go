@@ -0,0 +1,106 @@
+package kvplugin
+
+import (
+	"strings"
+	"sync"
+)
+
+// watchBuffer is how many events a watcher may fall behind by before it is
+// dropped
+const watchBuffer = 256
+
+// watchEvent is a change made through a watchedKV
+type watchEvent struct {
+	key     string
+	value   []byte
+	deleted bool
+}
+
+// watchHub fans changes out to the watchers whose prefix they match
+type watchHub struct {
+	mu       sync.Mutex
+	next     int
+	watchers map[int]*watcher
+}
+
+type watcher struct {
+	prefix string
+	events chan watchEvent
+	// dropped is set when the watcher fell behind and its channel was
+	// closed
+	dropped bool
+}
+
+func newWatchHub() *watchHub {
+	return &watchHub{watchers: make(map[int]*watcher)}
+}
+
+// subscribe registers a watcher for keys starting with prefix. Its channel
+// is closed if it falls more than watchBuffer events behind; cancel
+// unregisters it and must always be called.
+func (h *watchHub) subscribe(prefix string) (*watcher, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	id := h.next
+	h.next++
+	w := &watcher{prefix: prefix, events: make(chan watchEvent, watchBuffer)}
+	h.watchers[id] = w
+	return w, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.watchers[id]; ok {
+			delete(h.watchers, id)
+			close(w.events)
+		}
+	}
+}
+
+// publish sends an event to every matching watcher without blocking
+func (h *watchHub) publish(event watchEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for id, w := range h.watchers {
+		if !strings.HasPrefix(event.key, w.prefix) {
+			continue
+		}
+		select {
+		case w.events <- event:
+		default:
+			w.dropped = true
+			delete(h.watchers, id)
+			close(w.events)
+		}
+	}
+}
+
+// watchedKV publishes the changes made through it to a watchHub. Only
+// changes made through this server are seen; other processes sharing the
+// storage directory change it unobserved.
+type watchedKV struct {
+	KV
+	hub *watchHub
+}
+
+func (w *watchedKV) Put(key string, value []byte) error {
+	if err := w.KV.Put(key, value); err != nil {
+		return err
+	}
+	w.hub.publish(watchEvent{key: key, value: value})
+	return nil
+}
+
+func (w *watchedKV) GetMapped(key string) ([]byte, func(), error) {
+	return GetMapped(w.KV, key)
+}
+
+func (w *watchedKV) Delete(key string) (bool, error) {
+	existed, err := Delete(w.KV, key)
+	if err == nil && existed {
+		w.hub.publish(watchEvent{key: key, deleted: true})
+	}
+	return existed, err
+}
+
+func (w *watchedKV) List(prefix string) ([]string, error) {
+	return List(w.KV, prefix)
+}
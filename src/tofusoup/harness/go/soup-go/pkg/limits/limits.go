@@ -0,0 +1,274 @@
+// Package limits bounds the inputs soup-go decodes, so adversarial generated
+// inputs fail cleanly instead of exhausting the stack or memory.
+package limits
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// DefaultMaxDepth is the nesting depth DefaultConfig allows
+const DefaultMaxDepth = 512
+
+// Config holds decoding limits. soup-go builds it from its --max-depth and
+// --max-input-size root flags; a zero field disables its limit.
+type Config struct {
+	MaxDepth     int
+	MaxInputSize int64
+}
+
+// DefaultConfig returns the limits soup-go applies when no flags are given
+func DefaultConfig() Config {
+	return Config{MaxDepth: DefaultMaxDepth}
+}
+
+// ExceededError reports that an input exceeded a decoding limit
+type ExceededError struct {
+	Limit  string `json:"limit"`
+	Max    int64  `json:"max"`
+	Actual int64  `json:"actual"`
+	Path   string `json:"path,omitempty"`
+}
+
+func (e *ExceededError) Error() string {
+	msg := fmt.Sprintf("limit exceeded: %s %d exceeds maximum %d", e.Limit, e.Actual, e.Max)
+	if e.Path != "" {
+		msg += " at " + e.Path
+	}
+	return msg
+}
+
+// As returns the ExceededError in err's chain, if any
+func As(err error) *ExceededError {
+	var limitErr *ExceededError
+	if errors.As(err, &limitErr) {
+		return limitErr
+	}
+	return nil
+}
+
+// CheckDepth fails once depth passes MaxDepth
+func (c Config) CheckDepth(depth int, path string) error {
+	if c.MaxDepth > 0 && depth > c.MaxDepth {
+		// Paths at the limit are as deep as the limit, so keep them readable
+		if len(path) > 128 {
+			path = path[:128] + "..."
+		}
+		return &ExceededError{Limit: "depth", Max: int64(c.MaxDepth), Actual: int64(depth), Path: path}
+	}
+	return nil
+}
+
+// ReadAll reads r to the end, failing once it passes MaxInputSize
+func (c Config) ReadAll(r io.Reader) ([]byte, error) {
+	if c.MaxInputSize <= 0 {
+		return io.ReadAll(r)
+	}
+	data, err := io.ReadAll(io.LimitReader(r, c.MaxInputSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > c.MaxInputSize {
+		return nil, &ExceededError{Limit: "input_size", Max: c.MaxInputSize, Actual: int64(len(data))}
+	}
+	return data, nil
+}
+
+// Reader bounds a streaming decode by MaxInputSize
+func (c Config) Reader(r io.Reader) io.Reader {
+	if c.MaxInputSize <= 0 {
+		return r
+	}
+	return &sizeLimitReader{r: r, max: c.MaxInputSize, remaining: c.MaxInputSize}
+}
+
+type sizeLimitReader struct {
+	r         io.Reader
+	max       int64
+	remaining int64
+	read      int64
+}
+
+func (l *sizeLimitReader) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		// Probe for more data, so input of exactly the limit still succeeds
+		var probe [1]byte
+		if n, _ := l.r.Read(probe[:]); n > 0 {
+			return 0, &ExceededError{Limit: "input_size", Max: l.max, Actual: l.read + int64(n)}
+		}
+		return 0, io.EOF
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	l.read += int64(n)
+	return n, err
+}
+
+// msgpackLimitWalker follows the structure of a msgpack stream byte by
+// byte without decoding it, failing when nesting passes MaxDepth or, when
+// the input size is known, a declared length cannot fit in the rest of the
+// input. go-cty's and the generic msgpack decoders trust declared lengths
+// and recurse without bound, so input is walked before or as they read it.
+type msgpackLimitWalker struct {
+	config Config
+	// pending holds the number of items still expected at each open level
+	pending []int64
+	// size is the total input size, or -1 when streaming
+	size int64
+	pos  int64
+
+	skip    int64
+	hdr     int64
+	hdrNeed int
+	hdrKind byte
+}
+
+func newMsgpackLimitWalker(config Config, size int64) *msgpackLimitWalker {
+	return &msgpackLimitWalker{config: config, pending: []int64{1}, size: size}
+}
+
+// fits checks a declared length against the rest of a sized input
+func (w *msgpackLimitWalker) fits(n int64) error {
+	if w.size >= 0 && n > w.size-w.pos {
+		return &ExceededError{Limit: "declared_length", Max: w.size - w.pos, Actual: n, Path: fmt.Sprintf("offset %d", w.pos)}
+	}
+	return nil
+}
+
+// open starts a container of n items
+func (w *msgpackLimitWalker) open(n int64) error {
+	if n == 0 {
+		return nil
+	}
+	// Every item takes at least one byte
+	if err := w.fits(n); err != nil {
+		return err
+	}
+	if err := w.config.CheckDepth(len(w.pending), fmt.Sprintf("offset %d", w.pos)); err != nil {
+		return err
+	}
+	w.pending = append(w.pending, n)
+	return nil
+}
+
+// payload skips n bytes of string, binary or extension data
+func (w *msgpackLimitWalker) payload(n int64) error {
+	if err := w.fits(n); err != nil {
+		return err
+	}
+	w.skip = n
+	return nil
+}
+
+// finishHeader handles a completed multi-byte length or skipped scalar
+func (w *msgpackLimitWalker) finishHeader() error {
+	switch w.hdrKind {
+	case 0xc4, 0xc5, 0xc6, 0xd9, 0xda, 0xdb:
+		return w.payload(w.hdr)
+	case 0xc7, 0xc8, 0xc9:
+		return w.payload(w.hdr + 1)
+	case 0xdc, 0xdd:
+		return w.open(w.hdr)
+	case 0xde, 0xdf:
+		return w.open(2 * w.hdr)
+	}
+	return nil
+}
+
+// header starts reading an n-byte big-endian length
+func (w *msgpackLimitWalker) header(kind byte, n int) {
+	w.hdrKind, w.hdr, w.hdrNeed = kind, 0, n
+}
+
+// feed advances the walk by one input byte
+func (w *msgpackLimitWalker) feed(b byte) error {
+	w.pos++
+	if w.skip > 0 {
+		w.skip--
+		return nil
+	}
+	if w.hdrNeed > 0 {
+		w.hdr = w.hdr<<8 | int64(b)
+		w.hdrNeed--
+		if w.hdrNeed == 0 {
+			return w.finishHeader()
+		}
+		return nil
+	}
+
+	for len(w.pending) > 0 && w.pending[len(w.pending)-1] == 0 {
+		w.pending = w.pending[:len(w.pending)-1]
+	}
+	if len(w.pending) == 0 {
+		// Trailing data is left for the decoder to accept or reject
+		return nil
+	}
+	w.pending[len(w.pending)-1]--
+
+	switch {
+	case b <= 0x7f, b >= 0xe0, b == 0xc0, b == 0xc2, b == 0xc3:
+	case b >= 0x80 && b <= 0x8f:
+		return w.open(2 * int64(b&0x0f))
+	case b >= 0x90 && b <= 0x9f:
+		return w.open(int64(b & 0x0f))
+	case b >= 0xa0 && b <= 0xbf:
+		return w.payload(int64(b & 0x1f))
+	case b == 0xc4, b == 0xc7, b == 0xd9:
+		w.header(b, 1)
+	case b == 0xc5, b == 0xc8, b == 0xda, b == 0xdc, b == 0xde:
+		w.header(b, 2)
+	case b == 0xc6, b == 0xc9, b == 0xdb, b == 0xdd, b == 0xdf:
+		w.header(b, 4)
+	case b == 0xcc, b == 0xd0:
+		w.skip = 1
+	case b == 0xcd, b == 0xd1:
+		w.skip = 2
+	case b == 0xca, b == 0xce, b == 0xd2:
+		w.skip = 4
+	case b == 0xcb, b == 0xcf, b == 0xd3:
+		w.skip = 8
+	case b >= 0xd4 && b <= 0xd8:
+		w.skip = 1 + int64(1)<<(b-0xd4)
+	default:
+		return fmt.Errorf("invalid msgpack type byte 0x%02x at offset %d", b, w.pos-1)
+	}
+	return nil
+}
+
+// CheckMsgpack walks a complete msgpack document before decoding it
+func (c Config) CheckMsgpack(data []byte) error {
+	w := newMsgpackLimitWalker(c, int64(len(data)))
+	for _, b := range data {
+		if err := w.feed(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// msgpackLimitReader walks a msgpack stream as a decoder reads it, so a
+// streaming decode fails on a limit before the decoder descends into it
+type msgpackLimitReader struct {
+	r io.Reader
+	w *msgpackLimitWalker
+}
+
+// NewMsgpackReader returns a reader that walks the msgpack stream read
+// through it
+func (c Config) NewMsgpackReader(r io.Reader) io.Reader {
+	return &msgpackLimitReader{r: r, w: newMsgpackLimitWalker(c, -1)}
+}
+
+func (m *msgpackLimitReader) Read(p []byte) (int, error) {
+	n, err := m.r.Read(p)
+	for _, b := range p[:n] {
+		if walkErr := m.w.feed(b); walkErr != nil {
+			return 0, walkErr
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,149 @@
+// Package wirecodec encodes and decodes values in the msgpack and JSON wire
+// formats of Terraform's provider protocol, with go-cty for typed values and
+// vmihailenco/msgpack for untyped ones.
+package wirecodec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/ctyspec"
+)
+
+// Encode encodes JSON input to outputFormat (msgpack or json), using go-cty
+// when ty is a type and generic msgpack when it is cty.NilType
+func Encode(ty cty.Type, inputData []byte, outputFormat string, opts ctyspec.Options) ([]byte, error) {
+	var out bytes.Buffer
+	if err := EncodeStream(ty, bytes.NewReader(inputData), &out, outputFormat, opts); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// Decode decodes inputFormat data and re-encodes it in outputFormat, using
+// go-cty when ty is a type and generic msgpack when it is cty.NilType
+func Decode(ty cty.Type, inputData []byte, inputFormat, outputFormat string, opts ctyspec.Options) ([]byte, error) {
+	var out bytes.Buffer
+	if err := DecodeStream(ty, bytes.NewReader(inputData), &out, inputFormat, outputFormat, opts); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// EncodeStream encodes JSON read from r to outputFormat on w. Generic
+// msgpack is encoded without buffering the raw input; go-cty has no
+// streaming decoder, so typed input is read whole.
+func EncodeStream(ty cty.Type, r io.Reader, w io.Writer, outputFormat string, opts ctyspec.Options) error {
+	// If a type is given, use CTY encoding
+	if ty != cty.NilType {
+		inputData, err := opts.Limits.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("failed to read input: %w", err)
+		}
+
+		// Parse input as JSON and build CTY value
+		value, err := ctyspec.BuildValue(ty, inputData, opts)
+		if err != nil {
+			return fmt.Errorf("failed to build value: %w", err)
+		}
+
+		// Encode to wire format
+		var outputData []byte
+		switch outputFormat {
+		case "msgpack":
+			outputData, err = ctyspec.MarshalMsgpack(value, ty, opts)
+		case "json":
+			outputData, err = ctyspec.MarshalJSON(value, ty, opts)
+		default:
+			return fmt.Errorf("unsupported output format: %s", outputFormat)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to encode: %w", err)
+		}
+		if _, err := w.Write(outputData); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+		return nil
+	}
+
+	// Generic msgpack encoding without CTY type
+	var data interface{}
+	if err := json.NewDecoder(opts.Limits.Reader(r)).Decode(&data); err != nil {
+		return fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	enc := msgpack.NewEncoder(w)
+	enc.SetSortMapKeys(opts.Canonical)
+	if err := enc.Encode(data); err != nil {
+		return fmt.Errorf("failed to encode msgpack: %w", err)
+	}
+	return nil
+}
+
+// DecodeStream decodes inputFormat data read from r and re-encodes it
+// in outputFormat on w. Generic msgpack is decoded incrementally from r;
+// typed input is read whole.
+func DecodeStream(ty cty.Type, r io.Reader, w io.Writer, inputFormat, outputFormat string, opts ctyspec.Options) error {
+	// If a type is given, use CTY decoding
+	if ty != cty.NilType {
+		inputData, err := opts.Limits.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("failed to read input: %w", err)
+		}
+
+		// Decode from wire format
+		var value cty.Value
+		switch inputFormat {
+		case "msgpack":
+			if err := opts.Limits.CheckMsgpack(inputData); err != nil {
+				return fmt.Errorf("failed to decode: %w", err)
+			}
+			value, err = ctyspec.UnmarshalMsgpack(inputData, ty)
+		case "json":
+			value, err = ctyjson.Unmarshal(inputData, ty)
+		default:
+			return fmt.Errorf("unsupported input format: %s", inputFormat)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to decode: %w", err)
+		}
+
+		// Encode to output format
+		var outputData []byte
+		switch outputFormat {
+		case "json":
+			outputData, err = ctyspec.MarshalJSON(value, ty, opts)
+		case "msgpack":
+			outputData, err = ctyspec.MarshalMsgpack(value, ty, opts)
+		default:
+			return fmt.Errorf("unsupported output format: %s", outputFormat)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to encode output: %w", err)
+		}
+		if _, err := w.Write(outputData); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+		return nil
+	}
+
+	// Generic msgpack decoding without CTY type
+	var data interface{}
+	if err := msgpack.NewDecoder(opts.Limits.NewMsgpackReader(opts.Limits.Reader(r))).Decode(&data); err != nil {
+		return fmt.Errorf("failed to decode msgpack: %w", err)
+	}
+
+	outputData, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	if _, err := w.Write(outputData); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
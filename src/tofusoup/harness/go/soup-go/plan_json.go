@@ -0,0 +1,630 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+	ctymsgpack "github.com/zclconf/go-cty/cty/msgpack"
+)
+
+// planFormatMajorVersion is the plan JSON format major version understood by
+// the validator. Minor versions only add properties.
+const planFormatMajorVersion = "1"
+
+// validPlanActions are the action lists a plan JSON change may contain
+var validPlanActions = map[string]bool{
+	"no-op":         true,
+	"create":        true,
+	"read":          true,
+	"update":        true,
+	"delete":        true,
+	"forget":        true,
+	"delete,create": true,
+	"create,delete": true,
+	"create,forget": true,
+}
+
+// PlanJSON is the machine-readable plan produced by `terraform show -json`.
+// Only the properties checked by `plan validate-json` are modelled.
+type PlanJSON struct {
+	FormatVersion    string                 `json:"format_version"`
+	TerraformVersion string                 `json:"terraform_version"`
+	ResourceChanges  []*PlanResourceChange  `json:"resource_changes"`
+	ResourceDrift    []*PlanResourceChange  `json:"resource_drift"`
+	OutputChanges    map[string]*PlanChange `json:"output_changes"`
+}
+
+// PlanResourceChange is a single entry of resource_changes or resource_drift
+type PlanResourceChange struct {
+	Address       string          `json:"address"`
+	ModuleAddress string          `json:"module_address"`
+	Mode          string          `json:"mode"`
+	Type          string          `json:"type"`
+	Name          string          `json:"name"`
+	Index         json.RawMessage `json:"index"`
+	ProviderName  string          `json:"provider_name"`
+	Change        *PlanChange     `json:"change"`
+}
+
+// PlanChange is the change representation shared by resources and outputs
+type PlanChange struct {
+	Actions         []string        `json:"actions"`
+	Before          json.RawMessage `json:"before"`
+	After           json.RawMessage `json:"after"`
+	AfterUnknown    json.RawMessage `json:"after_unknown"`
+	BeforeSensitive json.RawMessage `json:"before_sensitive"`
+	AfterSensitive  json.RawMessage `json:"after_sensitive"`
+}
+
+// PlanValidationError is a single problem found in a plan JSON document
+type PlanValidationError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// validatePlanJSON parses a plan JSON document and checks it against the
+// documented plan representation
+func validatePlanJSON(data []byte) (*PlanJSON, []PlanValidationError) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, []PlanValidationError{{Message: fmt.Sprintf("invalid JSON: %s", err)}}
+	}
+	var plan PlanJSON
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, []PlanValidationError{{Message: fmt.Sprintf("invalid plan: %s", err)}}
+	}
+
+	var errs []PlanValidationError
+	if _, ok := raw["format_version"]; !ok {
+		errs = append(errs, PlanValidationError{Path: "format_version", Message: "required property is missing"})
+	} else if major, _, _ := strings.Cut(plan.FormatVersion, "."); major != planFormatMajorVersion {
+		errs = append(errs, PlanValidationError{
+			Path:    "format_version",
+			Message: fmt.Sprintf("unsupported format version %q: expected %s.x", plan.FormatVersion, planFormatMajorVersion),
+		})
+	}
+
+	for i, rc := range plan.ResourceChanges {
+		errs = append(errs, validatePlanResourceChange(fmt.Sprintf("resource_changes[%d]", i), rc)...)
+	}
+	for i, rc := range plan.ResourceDrift {
+		errs = append(errs, validatePlanResourceChange(fmt.Sprintf("resource_drift[%d]", i), rc)...)
+	}
+	for _, name := range sortedKeys(plan.OutputChanges) {
+		errs = append(errs, validatePlanChange(fmt.Sprintf("output_changes.%s", name), plan.OutputChanges[name])...)
+	}
+	return &plan, errs
+}
+
+// validatePlanResourceChange checks a single resource change entry
+func validatePlanResourceChange(path string, rc *PlanResourceChange) []PlanValidationError {
+	if rc == nil {
+		return []PlanValidationError{{Path: path, Message: "resource change must be an object"}}
+	}
+
+	var errs []PlanValidationError
+	for _, field := range []struct{ name, value string }{
+		{"address", rc.Address},
+		{"mode", rc.Mode},
+		{"type", rc.Type},
+		{"name", rc.Name},
+		{"provider_name", rc.ProviderName},
+	} {
+		if field.value == "" {
+			errs = append(errs, PlanValidationError{Path: path + "." + field.name, Message: "required property is missing or empty"})
+		}
+	}
+	if rc.Mode != "" && rc.Mode != "managed" && rc.Mode != "data" {
+		errs = append(errs, PlanValidationError{Path: path + ".mode", Message: fmt.Sprintf("invalid mode %q: expected managed or data", rc.Mode)})
+	}
+
+	if rc.Type != "" && rc.Name != "" {
+		expected := rc.Type + "." + rc.Name
+		if rc.Mode == "data" {
+			expected = "data." + expected
+		}
+		if rc.ModuleAddress != "" {
+			expected = rc.ModuleAddress + "." + expected
+		}
+		if rc.Address != expected && !strings.HasPrefix(rc.Address, expected+"[") {
+			errs = append(errs, PlanValidationError{
+				Path:    path + ".address",
+				Message: fmt.Sprintf("address %q does not match module_address, mode, type and name (expected %q)", rc.Address, expected),
+			})
+		}
+	}
+
+	return append(errs, validatePlanChange(path+".change", rc.Change)...)
+}
+
+// validatePlanChange checks the actions of a change and their consistency
+// with the before and after values
+func validatePlanChange(path string, change *PlanChange) []PlanValidationError {
+	if change == nil {
+		return []PlanValidationError{{Path: path, Message: "required property is missing"}}
+	}
+
+	var errs []PlanValidationError
+	actions := strings.Join(change.Actions, ",")
+	if !validPlanActions[actions] {
+		errs = append(errs, PlanValidationError{Path: path + ".actions", Message: fmt.Sprintf("invalid actions [%s]", actions)})
+	}
+	if actions == "create" && !isJSONNull(change.Before) {
+		errs = append(errs, PlanValidationError{Path: path + ".before", Message: "must be null for a create action"})
+	}
+	if (actions == "delete" || actions == "forget") && !isJSONNull(change.After) {
+		errs = append(errs, PlanValidationError{Path: path + ".after", Message: fmt.Sprintf("must be null for a %s action", actions)})
+	}
+
+	// The marker properties mirror the value's structure with true leaves
+	for _, marker := range []struct {
+		name string
+		data json.RawMessage
+	}{
+		{"after_unknown", change.AfterUnknown},
+		{"before_sensitive", change.BeforeSensitive},
+		{"after_sensitive", change.AfterSensitive},
+	} {
+		if len(marker.data) == 0 {
+			continue
+		}
+		switch bytes.TrimSpace(marker.data)[0] {
+		case 't', 'f', '{', '[', 'n':
+		default:
+			errs = append(errs, PlanValidationError{Path: path + "." + marker.name, Message: "must be a boolean, object or array"})
+		}
+	}
+	return errs
+}
+
+// isJSONNull reports whether a raw JSON property is absent or null
+func isJSONNull(data json.RawMessage) bool {
+	trimmed := bytes.TrimSpace(data)
+	return len(trimmed) == 0 || string(trimmed) == "null"
+}
+
+// extractPlanResourceChange decodes the before and after values of a
+// resource change as typed cty values. The type comes from the provider
+// schemas when available and is otherwise implied from the JSON values.
+func extractPlanResourceChange(rc *PlanResourceChange, schemas *ProviderSchemas) (map[string]interface{}, error) {
+	change := rc.Change
+	before, err := decodeJSONValue(change.Before)
+	if err != nil {
+		return nil, fmt.Errorf("before: %w", err)
+	}
+	after, err := decodeJSONValue(change.After)
+	if err != nil {
+		return nil, fmt.Errorf("after: %w", err)
+	}
+
+	typeSource := "implied"
+	beforeTy, afterTy := cty.DynamicPseudoType, cty.DynamicPseudoType
+	if schemas != nil {
+		if schema, ok := schemas.ResourceSchema(rc.ProviderName, rc.Mode, rc.Type); ok {
+			ty, err := schema.ImpliedType()
+			if err != nil {
+				return nil, fmt.Errorf("invalid schema for %s: %w", rc.Type, err)
+			}
+			beforeTy, afterTy = ty, ty
+			typeSource = "schema"
+		}
+	}
+
+	beforeVal, err := planValueToCty(before, beforeTy)
+	if err != nil {
+		return nil, fmt.Errorf("before: %w", err)
+	}
+	afterVal, err := planValueToCty(after, afterTy)
+	if err != nil {
+		return nil, fmt.Errorf("after: %w", err)
+	}
+
+	// Unknown values are omitted from after, so restore them from the markers
+	afterUnknown, err := decodeJSONValue(change.AfterUnknown)
+	if err != nil {
+		return nil, fmt.Errorf("after_unknown: %w", err)
+	}
+	afterVal = applyUnknownMarkers(afterVal, afterUnknown)
+
+	beforeSensitive, err := decodeJSONValue(change.BeforeSensitive)
+	if err != nil {
+		return nil, fmt.Errorf("before_sensitive: %w", err)
+	}
+	afterSensitive, err := decodeJSONValue(change.AfterSensitive)
+	if err != nil {
+		return nil, fmt.Errorf("after_sensitive: %w", err)
+	}
+
+	beforeTypeJSON, err := ctyjson.MarshalType(beforeVal.Type())
+	if err != nil {
+		return nil, err
+	}
+	afterTypeJSON, err := ctyjson.MarshalType(afterVal.Type())
+	if err != nil {
+		return nil, err
+	}
+	beforeJSON, err := ctyjson.Marshal(beforeVal, beforeVal.Type())
+	if err != nil {
+		return nil, fmt.Errorf("before: %w", err)
+	}
+	afterMsgpack, err := ctymsgpack.Marshal(afterVal, afterVal.Type())
+	if err != nil {
+		return nil, fmt.Errorf("after: %w", err)
+	}
+
+	result := map[string]interface{}{
+		"address":                rc.Address,
+		"mode":                   rc.Mode,
+		"type":                   rc.Type,
+		"provider_name":          rc.ProviderName,
+		"actions":                change.Actions,
+		"type_source":            typeSource,
+		"before_type":            json.RawMessage(beforeTypeJSON),
+		"after_type":             json.RawMessage(afterTypeJSON),
+		"before":                 json.RawMessage(beforeJSON),
+		"after_msgpack":          base64.StdEncoding.EncodeToString(afterMsgpack),
+		"unknown_paths":          nonNilStrings(unknownPaths(afterVal)),
+		"before_sensitive_paths": markerPaths(beforeSensitive, beforeVal.Type(), nil),
+		"after_sensitive_paths":  markerPaths(afterSensitive, afterVal.Type(), nil),
+		"changed_attributes":     changedAttributes(beforeVal, afterVal),
+	}
+	if afterVal.IsWhollyKnown() {
+		afterJSON, err := ctyjson.Marshal(afterVal, afterVal.Type())
+		if err != nil {
+			return nil, fmt.Errorf("after: %w", err)
+		}
+		result["after"] = json.RawMessage(afterJSON)
+	}
+	return result, nil
+}
+
+// decodeJSONValue decodes raw JSON into generic values, keeping numbers exact
+func decodeJSONValue(data json.RawMessage) (interface{}, error) {
+	if isJSONNull(data) {
+		return nil, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// planValueToCty builds a cty value of type ty from a generic JSON value.
+// Unlike ctyjson, dynamic types take the type implied by the JSON value, as
+// plan JSON carries no type information, and object attributes missing from
+// the JSON are null, as plan JSON omits unknown attributes.
+func planValueToCty(v interface{}, ty cty.Type) (cty.Value, error) {
+	if v == nil {
+		return cty.NullVal(ty), nil
+	}
+
+	if ty == cty.DynamicPseudoType {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return cty.NilVal, err
+		}
+		implied, err := ctyjson.ImpliedType(data)
+		if err != nil {
+			return cty.NilVal, err
+		}
+		return ctyjson.Unmarshal(data, implied)
+	}
+
+	switch {
+	case ty.IsPrimitiveType():
+		var val cty.Value
+		switch tv := v.(type) {
+		case string:
+			val = cty.StringVal(tv)
+		case json.Number:
+			n, err := cty.ParseNumberVal(tv.String())
+			if err != nil {
+				return cty.NilVal, err
+			}
+			val = n
+		case bool:
+			val = cty.BoolVal(tv)
+		default:
+			return cty.NilVal, fmt.Errorf("%s required, but have %T", ty.FriendlyName(), v)
+		}
+		return convert.Convert(val, ty)
+	case ty.IsListType() || ty.IsSetType() || ty.IsTupleType():
+		items, ok := v.([]interface{})
+		if !ok {
+			return cty.NilVal, fmt.Errorf("%s required, but have %T", ty.FriendlyName(), v)
+		}
+		if ty.IsTupleType() && len(items) != len(ty.TupleElementTypes()) {
+			return cty.NilVal, fmt.Errorf("tuple requires %d elements, but have %d", len(ty.TupleElementTypes()), len(items))
+		}
+		vals := make([]cty.Value, len(items))
+		for i, item := range items {
+			elemTy := cty.DynamicPseudoType
+			if ty.IsTupleType() {
+				elemTy = ty.TupleElementType(i)
+			} else {
+				elemTy = ty.ElementType()
+			}
+			val, err := planValueToCty(item, elemTy)
+			if err != nil {
+				return cty.NilVal, fmt.Errorf("[%d]: %w", i, err)
+			}
+			vals[i] = val
+		}
+		switch {
+		case ty.IsTupleType():
+			return cty.TupleVal(vals), nil
+		case len(vals) == 0 && ty.IsListType():
+			return cty.ListValEmpty(ty.ElementType()), nil
+		case len(vals) == 0:
+			return cty.SetValEmpty(ty.ElementType()), nil
+		}
+		// Elements of a dynamic element type may have differing types, so
+		// let convert unify them
+		return convert.Convert(cty.TupleVal(vals), ty)
+	case ty.IsMapType() || ty.IsObjectType():
+		items, ok := v.(map[string]interface{})
+		if !ok {
+			return cty.NilVal, fmt.Errorf("%s required, but have %T", ty.FriendlyName(), v)
+		}
+		if ty.IsMapType() {
+			if len(items) == 0 {
+				return cty.MapValEmpty(ty.ElementType()), nil
+			}
+			vals := make(map[string]cty.Value, len(items))
+			for k, item := range items {
+				val, err := planValueToCty(item, ty.ElementType())
+				if err != nil {
+					return cty.NilVal, fmt.Errorf("[%q]: %w", k, err)
+				}
+				vals[k] = val
+			}
+			return convert.Convert(cty.ObjectVal(vals), ty)
+		}
+		for k := range items {
+			if !ty.HasAttribute(k) {
+				return cty.NilVal, fmt.Errorf("unsupported attribute %q", k)
+			}
+		}
+		vals := make(map[string]cty.Value, len(ty.AttributeTypes()))
+		for name, attrTy := range ty.AttributeTypes() {
+			val, err := planValueToCty(items[name], attrTy)
+			if err != nil {
+				return cty.NilVal, fmt.Errorf(".%s: %w", name, err)
+			}
+			vals[name] = val
+		}
+		return cty.ObjectVal(vals), nil
+	}
+	return cty.NilVal, fmt.Errorf("unsupported type %s", ty.FriendlyName())
+}
+
+// applyUnknownMarkers marks the parts of val flagged true in an after_unknown
+// marker structure as unknown
+func applyUnknownMarkers(val cty.Value, marker interface{}) cty.Value {
+	if b, ok := marker.(bool); ok {
+		if b {
+			return cty.UnknownVal(val.Type())
+		}
+		return val
+	}
+	if val.IsNull() || !val.IsKnown() {
+		return val
+	}
+
+	ty := val.Type()
+	switch m := marker.(type) {
+	case map[string]interface{}:
+		switch {
+		case ty.IsObjectType():
+			attrs := val.AsValueMap()
+			for name, sub := range m {
+				if ty.HasAttribute(name) {
+					attrs[name] = applyUnknownMarkers(attrs[name], sub)
+				} else if sub == true {
+					// Implied types lack attributes that were omitted as unknown
+					attrs[name] = cty.DynamicVal
+				}
+			}
+			return cty.ObjectVal(attrs)
+		case ty.IsMapType():
+			elems := val.AsValueMap()
+			if elems == nil {
+				elems = make(map[string]cty.Value)
+			}
+			for key, sub := range m {
+				if elem, ok := elems[key]; ok {
+					elems[key] = applyUnknownMarkers(elem, sub)
+				} else if sub == true {
+					elems[key] = cty.UnknownVal(ty.ElementType())
+				}
+			}
+			if len(elems) == 0 {
+				return val
+			}
+			return cty.MapVal(elems)
+		}
+	case []interface{}:
+		if !ty.IsListType() && !ty.IsSetType() && !ty.IsTupleType() {
+			return val
+		}
+		elems := val.AsValueSlice()
+		if len(elems) == 0 {
+			return val
+		}
+		for i := 0; i < len(elems) && i < len(m); i++ {
+			elems[i] = applyUnknownMarkers(elems[i], m[i])
+		}
+		switch {
+		case ty.IsListType():
+			return cty.ListVal(elems)
+		case ty.IsSetType():
+			return cty.SetVal(elems)
+		}
+		return cty.TupleVal(elems)
+	}
+	return val
+}
+
+// markerPaths returns the paths flagged true in a before_sensitive or
+// after_sensitive marker structure
+func markerPaths(marker interface{}, ty cty.Type, path cty.Path) []string {
+	paths := []string{}
+	switch m := marker.(type) {
+	case bool:
+		if m {
+			paths = append(paths, formatCtyPath(path))
+		}
+	case map[string]interface{}:
+		for _, key := range sortedKeys(m) {
+			if ty.IsObjectType() {
+				elemTy := cty.DynamicPseudoType
+				if ty.HasAttribute(key) {
+					elemTy = ty.AttributeType(key)
+				}
+				paths = append(paths, markerPaths(m[key], elemTy, path.GetAttr(key))...)
+				continue
+			}
+			elemTy := cty.DynamicPseudoType
+			if ty.IsMapType() {
+				elemTy = ty.ElementType()
+			}
+			paths = append(paths, markerPaths(m[key], elemTy, path.Index(cty.StringVal(key)))...)
+		}
+	case []interface{}:
+		for i, sub := range m {
+			elemTy := cty.DynamicPseudoType
+			switch {
+			case ty.IsListType() || ty.IsSetType():
+				elemTy = ty.ElementType()
+			case ty.IsTupleType() && i < len(ty.TupleElementTypes()):
+				elemTy = ty.TupleElementType(i)
+			}
+			paths = append(paths, markerPaths(sub, elemTy, path.IndexInt(i))...)
+		}
+	}
+	return paths
+}
+
+// changedAttributes lists the top-level attributes whose before and after
+// values differ. Attributes with unknown after values count as changed.
+func changedAttributes(before, after cty.Value) []string {
+	names := make(map[string]struct{})
+	for _, val := range []cty.Value{before, after} {
+		if val.Type().IsObjectType() {
+			for name := range val.Type().AttributeTypes() {
+				names[name] = struct{}{}
+			}
+		}
+	}
+
+	changed := []string{}
+	for _, name := range sortedKeys(names) {
+		if !valuesEqual(objectAttrOrNull(before, name), objectAttrOrNull(after, name)) {
+			changed = append(changed, name)
+		}
+	}
+	return changed
+}
+
+// objectAttrOrNull returns the named attribute of an object value, or a
+// dynamic null if the value is null or has no such attribute
+func objectAttrOrNull(val cty.Value, name string) cty.Value {
+	if !val.Type().IsObjectType() || !val.Type().HasAttribute(name) || val.IsNull() || !val.IsKnown() {
+		return cty.NullVal(cty.DynamicPseudoType)
+	}
+	return val.GetAttr(name)
+}
+
+// nonNilStrings returns s, or an empty slice if s is nil, so it encodes as []
+func nonNilStrings(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+// initPlanValidateJSONCmd creates the `plan validate-json` command
+func initPlanValidateJSONCmd() *cobra.Command {
+	var schemasPath string
+
+	cmd := &cobra.Command{
+		Use:   "validate-json [plan-json]",
+		Short: "Validate terraform show -json plan output",
+		Long: `Check a plan JSON document (terraform show -json <planfile>) against the
+documented plan representation and extract the before and after value of each
+resource change as typed cty values. With --schema, values are typed by the
+provider schemas from terraform providers schema -json; otherwise types are
+implied from the JSON values.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var data []byte
+			var err error
+			if args[0] == "-" {
+				data, err = io.ReadAll(os.Stdin)
+			} else {
+				data, err = os.ReadFile(args[0])
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read plan: %w", err)
+			}
+
+			var schemas *ProviderSchemas
+			if schemasPath != "" {
+				schemas, err = loadProviderSchemas(schemasPath)
+				if err != nil {
+					return err
+				}
+			}
+
+			plan, errs := validatePlanJSON(data)
+			result := map[string]interface{}{}
+			if plan != nil {
+				result["format_version"] = plan.FormatVersion
+				result["terraform_version"] = plan.TerraformVersion
+
+				changes := make([]map[string]interface{}, 0, len(plan.ResourceChanges))
+				for i, rc := range plan.ResourceChanges {
+					if rc == nil || rc.Change == nil {
+						continue
+					}
+					extracted, err := extractPlanResourceChange(rc, schemas)
+					if err != nil {
+						errs = append(errs, PlanValidationError{
+							Path:    fmt.Sprintf("resource_changes[%d].change", i),
+							Message: err.Error(),
+						})
+						continue
+					}
+					changes = append(changes, extracted)
+				}
+				result["resource_changes"] = changes
+			}
+
+			result["valid"] = len(errs) == 0
+			if len(errs) > 0 {
+				result["errors"] = errs
+			}
+
+			if err := json.NewEncoder(os.Stdout).Encode(result); err != nil {
+				return fmt.Errorf("failed to encode JSON: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&schemasPath, "schema", "", "Path to provider schemas JSON (terraform providers schema -json output)")
+
+	return cmd
+}
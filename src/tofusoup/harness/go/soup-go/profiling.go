@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime"
+	runtimepprof "runtime/pprof"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// startPprofServer serves net/http/pprof on localhost:port in the
+// background. Port 0 picks a free port; the chosen address is logged.
+func startPprofServer(port int) error {
+	listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+	if err != nil {
+		return fmt.Errorf("failed to listen for pprof: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	logger.Info("📈🎧 pprof listening", "address", "http://"+listener.Addr().String()+"/debug/pprof/")
+	go func() {
+		if err := http.Serve(listener, mux); err != nil {
+			logger.Error("📈❌ pprof server failed", "error", err)
+		}
+	}()
+	return nil
+}
+
+// addPprofPortFlag registers --pprof-port on a long-running command
+func addPprofPortFlag(cmd *cobra.Command, port *int) {
+	cmd.Flags().IntVar(port, "pprof-port", -1, "Serve net/http/pprof on this localhost port (0 picks a free port, -1 disables)")
+}
+
+// startProfiles starts the profiles named by --profile specs of the form
+// cpu=FILE or mem=FILE. The returned function stops CPU profiling and
+// writes the heap profile, and must be called when the command finishes.
+func startProfiles(specs []string) (func() error, error) {
+	var cpuFile *os.File
+	var memPath string
+
+	for _, spec := range specs {
+		kind, path, ok := strings.Cut(spec, "=")
+		if !ok || path == "" {
+			return nil, fmt.Errorf("invalid --profile %q: expected cpu=FILE or mem=FILE", spec)
+		}
+		switch kind {
+		case "cpu":
+			if cpuFile != nil {
+				return nil, fmt.Errorf("--profile cpu given more than once")
+			}
+			f, err := os.Create(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create CPU profile: %w", err)
+			}
+			if err := runtimepprof.StartCPUProfile(f); err != nil {
+				f.Close()
+				return nil, fmt.Errorf("failed to start CPU profile: %w", err)
+			}
+			cpuFile = f
+		case "mem":
+			memPath = path
+		default:
+			return nil, fmt.Errorf("invalid --profile %q: unknown profile %q", spec, kind)
+		}
+	}
+
+	return func() error {
+		if cpuFile != nil {
+			runtimepprof.StopCPUProfile()
+			if err := cpuFile.Close(); err != nil {
+				return fmt.Errorf("failed to write CPU profile: %w", err)
+			}
+			logger.Info("📈✅ wrote CPU profile", "path", cpuFile.Name())
+		}
+		if memPath != "" {
+			f, err := os.Create(memPath)
+			if err != nil {
+				return fmt.Errorf("failed to create memory profile: %w", err)
+			}
+			defer f.Close()
+			// Collect garbage first so the profile reflects live objects
+			runtime.GC()
+			if err := runtimepprof.WriteHeapProfile(f); err != nil {
+				return fmt.Errorf("failed to write memory profile: %w", err)
+			}
+			logger.Info("📈✅ wrote memory profile", "path", memPath)
+		}
+		return nil
+	}, nil
+}
+
+// withProfiles adds a repeatable --profile flag to a batch command and
+// runs its RunE under the requested profiles
+func withProfiles(cmd *cobra.Command) *cobra.Command {
+	var specs []string
+	cmd.Flags().StringArrayVar(&specs, "profile", nil, "Write a profile of the run: cpu=FILE or mem=FILE (repeatable)")
+
+	run := cmd.RunE
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		stop, err := startProfiles(specs)
+		if err != nil {
+			return err
+		}
+		runErr := run(cmd, args)
+		if err := stop(); err != nil && runErr == nil {
+			return err
+		}
+		return runErr
+	}
+	return cmd
+}
@@ -0,0 +1,725 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6/tf6server"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+	ctymsgpack "github.com/zclconf/go-cty/cty/msgpack"
+)
+
+// MockProviderName is the provider name reported by the mock provider
+const MockProviderName = "tofusoup"
+
+// MockProvider is a tfprotov6 provider server used to exercise the Terraform
+// provider protocol from other-language clients. Resource RPCs largely echo
+// their inputs back, planning computed attributes as unknown and filling them
+// with placeholders on apply. Provider-defined functions are backed by the
+// go-cty stdlib functions from ctyFunctions.
+type MockProvider struct {
+	logger    hclog.Logger
+	functions map[string]function.Function
+	resources map[string]*mockResource
+}
+
+// mockResource is a resource type registered with the mock provider
+type mockResource struct {
+	schema *tfprotov6.Schema
+	ty     cty.Type
+}
+
+var _ tfprotov6.ProviderServer = (*MockProvider)(nil)
+
+// NewMockProvider creates a new MockProvider
+func NewMockProvider(logger hclog.Logger) *MockProvider {
+	return &MockProvider{
+		logger:    logger,
+		functions: ctyFunctions(),
+		resources: make(map[string]*mockResource),
+	}
+}
+
+// AddResource registers a resource type with the given schema
+func (p *MockProvider) AddResource(typeName string, schema *ResourceSchema) error {
+	protoSchema, err := schema.toProto()
+	if err != nil {
+		return fmt.Errorf("invalid schema for %s: %w", typeName, err)
+	}
+	ty, err := schema.ImpliedType()
+	if err != nil {
+		return fmt.Errorf("invalid schema for %s: %w", typeName, err)
+	}
+	p.resources[typeName] = &mockResource{schema: protoSchema, ty: ty}
+	return nil
+}
+
+// newMockProviderWithSchemas creates a MockProvider serving the resource
+// schemas read from a map of type name to schema file
+func newMockProviderWithSchemas(logger hclog.Logger, schemaFiles map[string]string) (*MockProvider, error) {
+	provider := NewMockProvider(logger)
+	for _, typeName := range sortedKeys(schemaFiles) {
+		schema, err := loadResourceSchema(schemaFiles[typeName])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", typeName, err)
+		}
+		if err := provider.AddResource(typeName, schema); err != nil {
+			return nil, err
+		}
+	}
+	return provider, nil
+}
+
+// newMockProviderPlugin wraps a MockProvider as a go-plugin plugin, served
+// under the "provider" name that terraform/tofu dispense.
+func newMockProviderPlugin(provider *MockProvider) *tf6server.GRPCProviderPlugin {
+	return &tf6server.GRPCProviderPlugin{
+		Name: MockProviderName,
+		GRPCProvider: func() tfprotov6.ProviderServer {
+			return provider
+		},
+	}
+}
+
+// =================================
+// Provider RPCs
+// =================================
+
+func (p *MockProvider) GetMetadata(ctx context.Context, req *tfprotov6.GetMetadataRequest) (*tfprotov6.GetMetadataResponse, error) {
+	p.logger.Debug("🧩📋 handling GetMetadata request")
+
+	functions := make([]tfprotov6.FunctionMetadata, 0, len(p.functions))
+	for _, name := range p.functionNames() {
+		functions = append(functions, tfprotov6.FunctionMetadata{Name: name})
+	}
+	resources := make([]tfprotov6.ResourceMetadata, 0, len(p.resources))
+	for _, name := range sortedKeys(p.resources) {
+		resources = append(resources, tfprotov6.ResourceMetadata{TypeName: name})
+	}
+	return &tfprotov6.GetMetadataResponse{
+		ServerCapabilities: &tfprotov6.ServerCapabilities{GetProviderSchemaOptional: true},
+		Functions:          functions,
+		Resources:          resources,
+	}, nil
+}
+
+func (p *MockProvider) GetProviderSchema(ctx context.Context, req *tfprotov6.GetProviderSchemaRequest) (*tfprotov6.GetProviderSchemaResponse, error) {
+	p.logger.Debug("🧩📋 handling GetProviderSchema request")
+
+	functions, diags := p.functionDefinitions()
+	resourceSchemas := make(map[string]*tfprotov6.Schema, len(p.resources))
+	for name, res := range p.resources {
+		resourceSchemas[name] = res.schema
+	}
+	return &tfprotov6.GetProviderSchemaResponse{
+		ServerCapabilities: &tfprotov6.ServerCapabilities{GetProviderSchemaOptional: true},
+		Provider:           &tfprotov6.Schema{Block: &tfprotov6.SchemaBlock{}},
+		ResourceSchemas:    resourceSchemas,
+		DataSourceSchemas:  map[string]*tfprotov6.Schema{},
+		Functions:          functions,
+		Diagnostics:        diags,
+	}, nil
+}
+
+func (p *MockProvider) GetResourceIdentitySchemas(ctx context.Context, req *tfprotov6.GetResourceIdentitySchemasRequest) (*tfprotov6.GetResourceIdentitySchemasResponse, error) {
+	return &tfprotov6.GetResourceIdentitySchemasResponse{
+		IdentitySchemas: map[string]*tfprotov6.ResourceIdentitySchema{},
+	}, nil
+}
+
+func (p *MockProvider) ValidateProviderConfig(ctx context.Context, req *tfprotov6.ValidateProviderConfigRequest) (*tfprotov6.ValidateProviderConfigResponse, error) {
+	return &tfprotov6.ValidateProviderConfigResponse{PreparedConfig: req.Config}, nil
+}
+
+func (p *MockProvider) ConfigureProvider(ctx context.Context, req *tfprotov6.ConfigureProviderRequest) (*tfprotov6.ConfigureProviderResponse, error) {
+	p.logger.Debug("🧩⚙️ handling ConfigureProvider request", "terraform_version", req.TerraformVersion)
+	return &tfprotov6.ConfigureProviderResponse{}, nil
+}
+
+func (p *MockProvider) StopProvider(ctx context.Context, req *tfprotov6.StopProviderRequest) (*tfprotov6.StopProviderResponse, error) {
+	p.logger.Debug("🧩🛑 handling StopProvider request")
+	return &tfprotov6.StopProviderResponse{}, nil
+}
+
+// =================================
+// Resource RPCs
+// =================================
+
+// ValidateResourceConfig checks the top-level attributes of the config
+// against the schema: required attributes must be set and computed-only
+// attributes must not be.
+func (p *MockProvider) ValidateResourceConfig(ctx context.Context, req *tfprotov6.ValidateResourceConfigRequest) (*tfprotov6.ValidateResourceConfigResponse, error) {
+	p.logger.Debug("🧩✅ handling ValidateResourceConfig request", "type_name", req.TypeName)
+
+	res, ok := p.resources[req.TypeName]
+	if !ok {
+		return &tfprotov6.ValidateResourceConfigResponse{
+			Diagnostics: []*tfprotov6.Diagnostic{unsupportedResourceDiagnostic(req.TypeName)},
+		}, nil
+	}
+	config, err := dynamicValueToCty(req.Config, res.ty)
+	if err != nil {
+		return &tfprotov6.ValidateResourceConfigResponse{
+			Diagnostics: []*tfprotov6.Diagnostic{errorDiagnostic("Failed to decode config", err.Error())},
+		}, nil
+	}
+	if config.IsNull() || !config.IsKnown() {
+		return &tfprotov6.ValidateResourceConfigResponse{}, nil
+	}
+
+	var diags []*tfprotov6.Diagnostic
+	for _, attr := range res.schema.Block.Attributes {
+		val := config.GetAttr(attr.Name)
+		switch {
+		case attr.Required && val.IsNull():
+			diags = append(diags, attributeErrorDiagnostic(attr.Name, "Missing required argument",
+				fmt.Sprintf("The argument %q is required, but no definition was found.", attr.Name)))
+		case attr.Computed && !attr.Optional && !attr.Required && !val.IsNull():
+			diags = append(diags, attributeErrorDiagnostic(attr.Name, "Value for unconfigurable attribute",
+				fmt.Sprintf("Can't configure a value for %q: its value will be decided automatically based on the result of applying this configuration.", attr.Name)))
+		}
+	}
+	return &tfprotov6.ValidateResourceConfigResponse{Diagnostics: diags}, nil
+}
+
+// UpgradeResourceState decodes the stored raw state (JSON or legacy flatmap)
+// against the current schema. The mock has no real upgraders: attributes no
+// longer present in the schema are dropped with a warning, as SDKv2 does.
+func (p *MockProvider) UpgradeResourceState(ctx context.Context, req *tfprotov6.UpgradeResourceStateRequest) (*tfprotov6.UpgradeResourceStateResponse, error) {
+	p.logger.Debug("🧩⬆️ handling UpgradeResourceState request", "type_name", req.TypeName, "version", req.Version)
+
+	res, ok := p.resources[req.TypeName]
+	if !ok {
+		return &tfprotov6.UpgradeResourceStateResponse{
+			Diagnostics: []*tfprotov6.Diagnostic{unsupportedResourceDiagnostic(req.TypeName)},
+		}, nil
+	}
+	if req.Version > res.schema.Version {
+		return &tfprotov6.UpgradeResourceStateResponse{
+			Diagnostics: []*tfprotov6.Diagnostic{
+				errorDiagnostic("Resource state is newer than schema",
+					fmt.Sprintf("Stored state version %d is newer than schema version %d for %s", req.Version, res.schema.Version, req.TypeName)),
+			},
+		}, nil
+	}
+	if req.RawState == nil {
+		return &tfprotov6.UpgradeResourceStateResponse{
+			Diagnostics: []*tfprotov6.Diagnostic{errorDiagnostic("Missing raw state", "UpgradeResourceState requires RawState")},
+		}, nil
+	}
+
+	var diags []*tfprotov6.Diagnostic
+	var val cty.Value
+	var err error
+	switch {
+	case req.RawState.JSON != nil:
+		var dropped []string
+		val, dropped, err = upgradeJSONState(req.RawState.JSON, res.ty)
+		for _, path := range dropped {
+			diags = append(diags, &tfprotov6.Diagnostic{
+				Severity: tfprotov6.DiagnosticSeverityWarning,
+				Summary:  "Attribute removed during upgrade",
+				Detail:   fmt.Sprintf("Attribute %s is not present in schema version %d and was dropped", path, res.schema.Version),
+			})
+		}
+	case req.RawState.Flatmap != nil:
+		val, err = flatmapToCty(req.RawState.Flatmap, res.ty)
+	default:
+		val = cty.NullVal(res.ty)
+	}
+	if err != nil {
+		diags = append(diags, errorDiagnostic("Failed to decode raw state", err.Error()))
+		return &tfprotov6.UpgradeResourceStateResponse{Diagnostics: diags}, nil
+	}
+
+	upgraded, err := ctyToDynamicValue(val, res.ty)
+	if err != nil {
+		diags = append(diags, errorDiagnostic("Failed to encode upgraded state", err.Error()))
+		return &tfprotov6.UpgradeResourceStateResponse{Diagnostics: diags}, nil
+	}
+	return &tfprotov6.UpgradeResourceStateResponse{UpgradedState: upgraded, Diagnostics: diags}, nil
+}
+
+func (p *MockProvider) ReadResource(ctx context.Context, req *tfprotov6.ReadResourceRequest) (*tfprotov6.ReadResourceResponse, error) {
+	return &tfprotov6.ReadResourceResponse{NewState: req.CurrentState, Private: req.Private}, nil
+}
+
+// PlanResourceChange plans the proposed new state unchanged, except that on
+// create computed attributes left null by the config are marked unknown, as
+// SDK-based providers do.
+func (p *MockProvider) PlanResourceChange(ctx context.Context, req *tfprotov6.PlanResourceChangeRequest) (*tfprotov6.PlanResourceChangeResponse, error) {
+	p.logger.Debug("🧩📝 handling PlanResourceChange request", "type_name", req.TypeName)
+
+	res, ok := p.resources[req.TypeName]
+	if !ok {
+		return &tfprotov6.PlanResourceChangeResponse{
+			Diagnostics: []*tfprotov6.Diagnostic{unsupportedResourceDiagnostic(req.TypeName)},
+		}, nil
+	}
+	prior, err := dynamicValueToCty(req.PriorState, res.ty)
+	if err != nil {
+		return &tfprotov6.PlanResourceChangeResponse{
+			Diagnostics: []*tfprotov6.Diagnostic{errorDiagnostic("Failed to decode prior state", err.Error())},
+		}, nil
+	}
+	proposed, err := dynamicValueToCty(req.ProposedNewState, res.ty)
+	if err != nil {
+		return &tfprotov6.PlanResourceChangeResponse{
+			Diagnostics: []*tfprotov6.Diagnostic{errorDiagnostic("Failed to decode proposed new state", err.Error())},
+		}, nil
+	}
+	if !prior.IsNull() || proposed.IsNull() || !proposed.IsKnown() {
+		return &tfprotov6.PlanResourceChangeResponse{PlannedState: req.ProposedNewState, PlannedPrivate: req.PriorPrivate}, nil
+	}
+
+	attrs := proposed.AsValueMap()
+	for _, attr := range res.schema.Block.Attributes {
+		if attr.Computed && attrs[attr.Name].IsNull() {
+			attrs[attr.Name] = cty.UnknownVal(res.ty.AttributeType(attr.Name))
+		}
+	}
+	planned, err := ctyToDynamicValue(cty.ObjectVal(attrs), res.ty)
+	if err != nil {
+		return &tfprotov6.PlanResourceChangeResponse{
+			Diagnostics: []*tfprotov6.Diagnostic{errorDiagnostic("Failed to encode planned state", err.Error())},
+		}, nil
+	}
+	return &tfprotov6.PlanResourceChangeResponse{PlannedState: planned, PlannedPrivate: req.PriorPrivate}, nil
+}
+
+// ApplyResourceChange returns the planned state with unknown values replaced
+// by deterministic placeholders, standing in for the values a real provider
+// would learn from its remote API.
+func (p *MockProvider) ApplyResourceChange(ctx context.Context, req *tfprotov6.ApplyResourceChangeRequest) (*tfprotov6.ApplyResourceChangeResponse, error) {
+	p.logger.Debug("🧩🚀 handling ApplyResourceChange request", "type_name", req.TypeName)
+
+	res, ok := p.resources[req.TypeName]
+	if !ok {
+		return &tfprotov6.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov6.Diagnostic{unsupportedResourceDiagnostic(req.TypeName)},
+		}, nil
+	}
+	planned, err := dynamicValueToCty(req.PlannedState, res.ty)
+	if err != nil {
+		return &tfprotov6.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov6.Diagnostic{errorDiagnostic("Failed to decode planned state", err.Error())},
+		}, nil
+	}
+	if planned.IsNull() {
+		return &tfprotov6.ApplyResourceChangeResponse{NewState: req.PlannedState, Private: req.PlannedPrivate}, nil
+	}
+
+	newState, err := cty.Transform(planned, func(path cty.Path, v cty.Value) (cty.Value, error) {
+		if v.IsKnown() {
+			return v, nil
+		}
+		return mockComputedValue(path, v.Type()), nil
+	})
+	if err != nil {
+		return &tfprotov6.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov6.Diagnostic{errorDiagnostic("Failed to resolve computed values", err.Error())},
+		}, nil
+	}
+	dv, err := ctyToDynamicValue(newState, res.ty)
+	if err != nil {
+		return &tfprotov6.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov6.Diagnostic{errorDiagnostic("Failed to encode new state", err.Error())},
+		}, nil
+	}
+	return &tfprotov6.ApplyResourceChangeResponse{NewState: dv, Private: req.PlannedPrivate}, nil
+}
+
+func (p *MockProvider) ImportResourceState(ctx context.Context, req *tfprotov6.ImportResourceStateRequest) (*tfprotov6.ImportResourceStateResponse, error) {
+	return &tfprotov6.ImportResourceStateResponse{
+		Diagnostics: []*tfprotov6.Diagnostic{
+			errorDiagnostic("Import not supported", "The mock provider does not support importing resources"),
+		},
+	}, nil
+}
+
+func (p *MockProvider) MoveResourceState(ctx context.Context, req *tfprotov6.MoveResourceStateRequest) (*tfprotov6.MoveResourceStateResponse, error) {
+	return &tfprotov6.MoveResourceStateResponse{
+		Diagnostics: []*tfprotov6.Diagnostic{
+			errorDiagnostic("Move not supported", "The mock provider does not support moving resource state"),
+		},
+	}, nil
+}
+
+func (p *MockProvider) UpgradeResourceIdentity(ctx context.Context, req *tfprotov6.UpgradeResourceIdentityRequest) (*tfprotov6.UpgradeResourceIdentityResponse, error) {
+	return &tfprotov6.UpgradeResourceIdentityResponse{
+		Diagnostics: []*tfprotov6.Diagnostic{
+			errorDiagnostic("Resource identity not supported", "The mock provider does not declare resource identities"),
+		},
+	}, nil
+}
+
+// =================================
+// Data source and ephemeral resource RPCs
+// =================================
+
+func (p *MockProvider) ValidateDataResourceConfig(ctx context.Context, req *tfprotov6.ValidateDataResourceConfigRequest) (*tfprotov6.ValidateDataResourceConfigResponse, error) {
+	return &tfprotov6.ValidateDataResourceConfigResponse{}, nil
+}
+
+func (p *MockProvider) ReadDataSource(ctx context.Context, req *tfprotov6.ReadDataSourceRequest) (*tfprotov6.ReadDataSourceResponse, error) {
+	return &tfprotov6.ReadDataSourceResponse{State: req.Config}, nil
+}
+
+func (p *MockProvider) ValidateEphemeralResourceConfig(ctx context.Context, req *tfprotov6.ValidateEphemeralResourceConfigRequest) (*tfprotov6.ValidateEphemeralResourceConfigResponse, error) {
+	return &tfprotov6.ValidateEphemeralResourceConfigResponse{}, nil
+}
+
+func (p *MockProvider) OpenEphemeralResource(ctx context.Context, req *tfprotov6.OpenEphemeralResourceRequest) (*tfprotov6.OpenEphemeralResourceResponse, error) {
+	return &tfprotov6.OpenEphemeralResourceResponse{Result: req.Config}, nil
+}
+
+func (p *MockProvider) RenewEphemeralResource(ctx context.Context, req *tfprotov6.RenewEphemeralResourceRequest) (*tfprotov6.RenewEphemeralResourceResponse, error) {
+	return &tfprotov6.RenewEphemeralResourceResponse{}, nil
+}
+
+func (p *MockProvider) CloseEphemeralResource(ctx context.Context, req *tfprotov6.CloseEphemeralResourceRequest) (*tfprotov6.CloseEphemeralResourceResponse, error) {
+	return &tfprotov6.CloseEphemeralResourceResponse{}, nil
+}
+
+// =================================
+// Function RPCs
+// =================================
+
+func (p *MockProvider) GetFunctions(ctx context.Context, req *tfprotov6.GetFunctionsRequest) (*tfprotov6.GetFunctionsResponse, error) {
+	p.logger.Debug("🧩🔧 handling GetFunctions request", "count", len(p.functions))
+
+	functions, diags := p.functionDefinitions()
+	return &tfprotov6.GetFunctionsResponse{Functions: functions, Diagnostics: diags}, nil
+}
+
+func (p *MockProvider) CallFunction(ctx context.Context, req *tfprotov6.CallFunctionRequest) (*tfprotov6.CallFunctionResponse, error) {
+	p.logger.Debug("🧩🔧 handling CallFunction request", "name", req.Name, "arguments", len(req.Arguments))
+
+	fn, ok := p.functions[req.Name]
+	if !ok {
+		return &tfprotov6.CallFunctionResponse{
+			Error: &tfprotov6.FunctionError{Text: fmt.Sprintf("unknown function: %s", req.Name)},
+		}, nil
+	}
+
+	args := make([]cty.Value, len(req.Arguments))
+	for i, arg := range req.Arguments {
+		param, ok := functionParameterAt(fn, i)
+		if !ok {
+			return &tfprotov6.CallFunctionResponse{
+				Error: functionArgumentError(i, fmt.Sprintf("too many arguments; %s accepts %d", req.Name, len(fn.Params()))),
+			}, nil
+		}
+		val, err := dynamicValueToCty(arg, param.Type)
+		if err != nil {
+			return &tfprotov6.CallFunctionResponse{
+				Error: functionArgumentError(i, fmt.Sprintf("failed to decode argument: %s", err)),
+			}, nil
+		}
+		args[i] = val
+	}
+
+	result, err := fn.Call(args)
+	if err != nil {
+		var argErr function.ArgError
+		if errors.As(err, &argErr) {
+			return &tfprotov6.CallFunctionResponse{Error: functionArgumentError(argErr.Index, argErr.Error())}, nil
+		}
+		return &tfprotov6.CallFunctionResponse{Error: &tfprotov6.FunctionError{Text: err.Error()}}, nil
+	}
+
+	returnType := functionReturnType(fn)
+	resultValue, err := ctyToDynamicValue(result, returnType)
+	if err != nil {
+		return &tfprotov6.CallFunctionResponse{
+			Error: &tfprotov6.FunctionError{Text: fmt.Sprintf("failed to encode result: %s", err)},
+		}, nil
+	}
+
+	p.logger.Debug("🧩✅ CallFunction completed", "name", req.Name, "result_type", result.Type().FriendlyName())
+	return &tfprotov6.CallFunctionResponse{Result: resultValue}, nil
+}
+
+// functionNames returns the sorted names of the provider's functions
+func (p *MockProvider) functionNames() []string {
+	names := make([]string, 0, len(p.functions))
+	for name := range p.functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// functionDefinitions describes every registered cty function as a
+// tfprotov6.Function. Functions whose signature can't be expressed in
+// tftypes are skipped with a warning diagnostic.
+func (p *MockProvider) functionDefinitions() (map[string]*tfprotov6.Function, []*tfprotov6.Diagnostic) {
+	functions := make(map[string]*tfprotov6.Function, len(p.functions))
+	var diags []*tfprotov6.Diagnostic
+	for _, name := range p.functionNames() {
+		def, err := ctyFunctionToProto(p.functions[name])
+		if err != nil {
+			diags = append(diags, &tfprotov6.Diagnostic{
+				Severity: tfprotov6.DiagnosticSeverityWarning,
+				Summary:  "Function skipped",
+				Detail:   fmt.Sprintf("Function %q cannot be described: %s", name, err),
+			})
+			continue
+		}
+		functions[name] = def
+	}
+	return functions, diags
+}
+
+// ctyFunctionToProto converts a cty function signature to a tfprotov6.Function
+func ctyFunctionToProto(fn function.Function) (*tfprotov6.Function, error) {
+	def := &tfprotov6.Function{
+		Summary:         fn.Description(),
+		Description:     fn.Description(),
+		DescriptionKind: tfprotov6.StringKindPlain,
+	}
+
+	for _, param := range fn.Params() {
+		protoParam, err := ctyParameterToProto(param)
+		if err != nil {
+			return nil, err
+		}
+		def.Parameters = append(def.Parameters, protoParam)
+	}
+
+	if varParam := fn.VarParam(); varParam != nil {
+		protoParam, err := ctyParameterToProto(*varParam)
+		if err != nil {
+			return nil, err
+		}
+		def.VariadicParameter = protoParam
+	}
+
+	returnType, err := ctyTypeToTftypes(functionReturnType(fn))
+	if err != nil {
+		return nil, err
+	}
+	def.Return = &tfprotov6.FunctionReturn{Type: returnType}
+	return def, nil
+}
+
+// ctyParameterToProto converts a cty function parameter to a tfprotov6.FunctionParameter
+func ctyParameterToProto(param function.Parameter) (*tfprotov6.FunctionParameter, error) {
+	paramType, err := ctyTypeToTftypes(param.Type)
+	if err != nil {
+		return nil, fmt.Errorf("parameter %s: %w", param.Name, err)
+	}
+	return &tfprotov6.FunctionParameter{
+		Name:               param.Name,
+		Type:               paramType,
+		AllowNullValue:     param.AllowNull,
+		AllowUnknownValues: param.AllowUnknown,
+		Description:        param.Description,
+		DescriptionKind:    tfprotov6.StringKindPlain,
+	}, nil
+}
+
+// functionParameterAt returns the parameter that receives the argument at
+// index i, taking the variadic parameter into account
+func functionParameterAt(fn function.Function, i int) (function.Parameter, bool) {
+	params := fn.Params()
+	if i < len(params) {
+		return params[i], true
+	}
+	if varParam := fn.VarParam(); varParam != nil {
+		return *varParam, true
+	}
+	return function.Parameter{}, false
+}
+
+// functionReturnType returns the static return type of fn as advertised over
+// the protocol. Functions whose return type depends on argument values or
+// types are advertised as dynamic.
+func functionReturnType(fn function.Function) cty.Type {
+	params := fn.Params()
+	argTypes := make([]cty.Type, len(params))
+	for i, param := range params {
+		argTypes[i] = param.Type
+	}
+	returnType, err := fn.ReturnType(argTypes)
+	if err != nil || returnType.HasDynamicTypes() {
+		return cty.DynamicPseudoType
+	}
+	return returnType
+}
+
+// functionArgumentError builds a FunctionError attributed to argument i
+func functionArgumentError(i int, text string) *tfprotov6.FunctionError {
+	idx := int64(i)
+	return &tfprotov6.FunctionError{Text: text, FunctionArgument: &idx}
+}
+
+// =================================
+// DynamicValue helpers
+// =================================
+
+// dynamicValueToCty decodes a tfprotov6.DynamicValue into a cty.Value of the
+// given type, preferring the msgpack encoding when both are present
+func dynamicValueToCty(dv *tfprotov6.DynamicValue, ty cty.Type) (cty.Value, error) {
+	if dv == nil {
+		return cty.NullVal(ty), nil
+	}
+	if len(dv.MsgPack) > 0 {
+		if err := decodeLimits.CheckMsgpack(dv.MsgPack); err != nil {
+			return cty.NilVal, err
+		}
+		return ctymsgpack.Unmarshal(dv.MsgPack, ty)
+	}
+	if len(dv.JSON) > 0 {
+		return ctyjson.Unmarshal(dv.JSON, ty)
+	}
+	return cty.NullVal(ty), nil
+}
+
+// ctyToDynamicValue encodes a cty.Value as a msgpack tfprotov6.DynamicValue
+func ctyToDynamicValue(val cty.Value, ty cty.Type) (*tfprotov6.DynamicValue, error) {
+	data, err := ctymsgpack.Marshal(val, ty)
+	if err != nil {
+		return nil, err
+	}
+	return &tfprotov6.DynamicValue{MsgPack: data}, nil
+}
+
+// upgradeJSONState decodes JSON raw state into ty, first pruning attributes
+// that don't exist in ty. It returns the dotted paths of dropped attributes.
+func upgradeJSONState(data []byte, ty cty.Type) (cty.Value, []string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var raw interface{}
+	if err := dec.Decode(&raw); err != nil {
+		return cty.NilVal, nil, fmt.Errorf("invalid JSON state: %w", err)
+	}
+	if raw == nil {
+		return cty.NullVal(ty), nil, nil
+	}
+
+	var dropped []string
+	pruned := pruneToType(raw, ty, "", &dropped)
+	prunedJSON, err := json.Marshal(pruned)
+	if err != nil {
+		return cty.NilVal, nil, err
+	}
+	val, err := ctyjson.Unmarshal(prunedJSON, ty)
+	if err != nil {
+		return cty.NilVal, nil, err
+	}
+	sort.Strings(dropped)
+	return val, dropped, nil
+}
+
+// pruneToType removes object attributes from a decoded JSON value that are
+// not declared by ty, recording their paths in dropped
+func pruneToType(v interface{}, ty cty.Type, path string, dropped *[]string) interface{} {
+	switch {
+	case ty.IsObjectType():
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return v
+		}
+		for k, elem := range m {
+			attrPath := k
+			if path != "" {
+				attrPath = path + "." + k
+			}
+			if !ty.HasAttribute(k) {
+				*dropped = append(*dropped, attrPath)
+				delete(m, k)
+				continue
+			}
+			m[k] = pruneToType(elem, ty.AttributeType(k), attrPath, dropped)
+		}
+		return m
+	case ty.IsMapType():
+		if m, ok := v.(map[string]interface{}); ok {
+			for k, elem := range m {
+				m[k] = pruneToType(elem, ty.ElementType(), path+"."+k, dropped)
+			}
+		}
+		return v
+	case ty.IsListType() || ty.IsSetType():
+		if s, ok := v.([]interface{}); ok {
+			for i, elem := range s {
+				s[i] = pruneToType(elem, ty.ElementType(), fmt.Sprintf("%s[%d]", path, i), dropped)
+			}
+		}
+		return v
+	case ty.IsTupleType():
+		if s, ok := v.([]interface{}); ok {
+			for i, elem := range s {
+				if i < len(ty.TupleElementTypes()) {
+					s[i] = pruneToType(elem, ty.TupleElementType(i), fmt.Sprintf("%s[%d]", path, i), dropped)
+				}
+			}
+		}
+		return v
+	}
+	return v
+}
+
+// mockComputedValue returns the placeholder the mock provider assigns to a
+// computed value at path when applying
+func mockComputedValue(path cty.Path, ty cty.Type) cty.Value {
+	switch {
+	case ty == cty.String:
+		return cty.StringVal("mock-" + formatCtyPath(path))
+	case ty == cty.Number:
+		return cty.Zero
+	case ty == cty.Bool:
+		return cty.False
+	case ty.IsListType():
+		return cty.ListValEmpty(ty.ElementType())
+	case ty.IsSetType():
+		return cty.SetValEmpty(ty.ElementType())
+	case ty.IsMapType():
+		return cty.MapValEmpty(ty.ElementType())
+	case ty.IsObjectType():
+		attrs := make(map[string]cty.Value, len(ty.AttributeTypes()))
+		for name, attrTy := range ty.AttributeTypes() {
+			attrs[name] = mockComputedValue(path.GetAttr(name), attrTy)
+		}
+		return cty.ObjectVal(attrs)
+	case ty.IsTupleType():
+		elems := make([]cty.Value, len(ty.TupleElementTypes()))
+		for i, elemTy := range ty.TupleElementTypes() {
+			elems[i] = mockComputedValue(path.IndexInt(i), elemTy)
+		}
+		return cty.TupleVal(elems)
+	}
+	return cty.NullVal(ty)
+}
+
+// unsupportedResourceDiagnostic reports a resource type the mock doesn't know
+func unsupportedResourceDiagnostic(typeName string) *tfprotov6.Diagnostic {
+	return errorDiagnostic("Unsupported resource type", fmt.Sprintf("The mock provider has no resource type %q", typeName))
+}
+
+// errorDiagnostic builds an error-severity tfprotov6.Diagnostic
+func errorDiagnostic(summary, detail string) *tfprotov6.Diagnostic {
+	return &tfprotov6.Diagnostic{
+		Severity: tfprotov6.DiagnosticSeverityError,
+		Summary:  summary,
+		Detail:   detail,
+	}
+}
+
+// attributeErrorDiagnostic builds an error-severity tfprotov6.Diagnostic
+// pointing at a top-level attribute
+func attributeErrorDiagnostic(name, summary, detail string) *tfprotov6.Diagnostic {
+	diag := errorDiagnostic(summary, detail)
+	diag.Attribute = tftypes.NewAttributePath().WithAttributeName(name)
+	return diag
+}
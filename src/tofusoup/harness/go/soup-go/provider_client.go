@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-plugin"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// providerServiceName is the fully qualified tfplugin6 provider service
+const providerServiceName = "tfplugin6.Provider"
+
+// ProviderLifecycle is the subset of the provider protocol driven by the
+// lifecycle simulator. MockProvider implements it in-process and
+// GRPCProviderClient implements it against another harness's provider.
+type ProviderLifecycle interface {
+	ValidateResourceConfig(ctx context.Context, req *tfprotov6.ValidateResourceConfigRequest) (*tfprotov6.ValidateResourceConfigResponse, error)
+	PlanResourceChange(ctx context.Context, req *tfprotov6.PlanResourceChangeRequest) (*tfprotov6.PlanResourceChangeResponse, error)
+	ApplyResourceChange(ctx context.Context, req *tfprotov6.ApplyResourceChangeRequest) (*tfprotov6.ApplyResourceChangeResponse, error)
+}
+
+var (
+	_ ProviderLifecycle = (*MockProvider)(nil)
+	_ ProviderLifecycle = (*GRPCProviderClient)(nil)
+)
+
+// GRPCProviderClient calls a tfplugin6 provider over gRPC. terraform-plugin-go
+// keeps its generated client internal, so requests are built as dynamic
+// messages from the tfplugin6 descriptors it registers.
+type GRPCProviderClient struct {
+	conn    *grpc.ClientConn
+	service protoreflect.ServiceDescriptor
+}
+
+// NewGRPCProviderClient creates a provider client on an existing connection
+func NewGRPCProviderClient(conn *grpc.ClientConn) (*GRPCProviderClient, error) {
+	desc, err := protoregistry.GlobalFiles.FindDescriptorByName(providerServiceName)
+	if err != nil {
+		return nil, fmt.Errorf("tfplugin6 descriptors not registered: %w", err)
+	}
+	service, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", providerServiceName)
+	}
+	return &GRPCProviderClient{conn: conn, service: service}, nil
+}
+
+// ProviderGRPCPlugin is the client side of a go-plugin "provider" plugin
+type ProviderGRPCPlugin struct {
+	plugin.NetRPCUnsupportedPlugin
+}
+
+func (p *ProviderGRPCPlugin) GRPCServer(broker *plugin.GRPCBroker, s *grpc.Server) error {
+	return fmt.Errorf("ProviderGRPCPlugin only supports the client side")
+}
+
+func (p *ProviderGRPCPlugin) GRPCClient(ctx context.Context, broker *plugin.GRPCBroker, c *grpc.ClientConn) (interface{}, error) {
+	return NewGRPCProviderClient(c)
+}
+
+// newProviderPluginClient launches a provider executable the way terraform
+// does, using the terraform handshake profile
+func newProviderPluginClient(path string, args []string, logger hclog.Logger) (*plugin.Client, error) {
+	profile, err := getHandshakeProfile(HandshakeProfileTerraform)
+	if err != nil {
+		return nil, err
+	}
+	return plugin.NewClient(&plugin.ClientConfig{
+		HandshakeConfig: profile.Handshake,
+		VersionedPlugins: map[int]plugin.PluginSet{
+			int(profile.Handshake.ProtocolVersion): {
+				"provider": &ProviderGRPCPlugin{},
+			},
+		},
+		Cmd:              exec.Command(path, args...),
+		Logger:           logger,
+		AutoMTLS:         true,
+		AllowedProtocols: []plugin.Protocol{plugin.ProtocolGRPC},
+	}), nil
+}
+
+func (c *GRPCProviderClient) ValidateResourceConfig(ctx context.Context, req *tfprotov6.ValidateResourceConfigRequest) (*tfprotov6.ValidateResourceConfigResponse, error) {
+	in, out, err := c.messages("ValidateResourceConfig")
+	if err != nil {
+		return nil, err
+	}
+	setString(in, "type_name", req.TypeName)
+	setDynamicValue(in, "config", req.Config)
+
+	if err := c.invoke(ctx, "ValidateResourceConfig", in, out); err != nil {
+		return nil, err
+	}
+	diags, err := getDiagnostics(out, "diagnostics")
+	if err != nil {
+		return nil, err
+	}
+	return &tfprotov6.ValidateResourceConfigResponse{Diagnostics: diags}, nil
+}
+
+func (c *GRPCProviderClient) PlanResourceChange(ctx context.Context, req *tfprotov6.PlanResourceChangeRequest) (*tfprotov6.PlanResourceChangeResponse, error) {
+	in, out, err := c.messages("PlanResourceChange")
+	if err != nil {
+		return nil, err
+	}
+	setString(in, "type_name", req.TypeName)
+	setDynamicValue(in, "prior_state", req.PriorState)
+	setDynamicValue(in, "proposed_new_state", req.ProposedNewState)
+	setDynamicValue(in, "config", req.Config)
+	setBytes(in, "prior_private", req.PriorPrivate)
+
+	if err := c.invoke(ctx, "PlanResourceChange", in, out); err != nil {
+		return nil, err
+	}
+	diags, err := getDiagnostics(out, "diagnostics")
+	if err != nil {
+		return nil, err
+	}
+	return &tfprotov6.PlanResourceChangeResponse{
+		PlannedState:   getDynamicValue(out, "planned_state"),
+		PlannedPrivate: getBytes(out, "planned_private"),
+		Diagnostics:    diags,
+	}, nil
+}
+
+func (c *GRPCProviderClient) ApplyResourceChange(ctx context.Context, req *tfprotov6.ApplyResourceChangeRequest) (*tfprotov6.ApplyResourceChangeResponse, error) {
+	in, out, err := c.messages("ApplyResourceChange")
+	if err != nil {
+		return nil, err
+	}
+	setString(in, "type_name", req.TypeName)
+	setDynamicValue(in, "prior_state", req.PriorState)
+	setDynamicValue(in, "planned_state", req.PlannedState)
+	setDynamicValue(in, "config", req.Config)
+	setBytes(in, "planned_private", req.PlannedPrivate)
+
+	if err := c.invoke(ctx, "ApplyResourceChange", in, out); err != nil {
+		return nil, err
+	}
+	diags, err := getDiagnostics(out, "diagnostics")
+	if err != nil {
+		return nil, err
+	}
+	return &tfprotov6.ApplyResourceChangeResponse{
+		NewState:    getDynamicValue(out, "new_state"),
+		Private:     getBytes(out, "private"),
+		Diagnostics: diags,
+	}, nil
+}
+
+// messages returns empty request and response messages for a provider RPC
+func (c *GRPCProviderClient) messages(method string) (*dynamicpb.Message, *dynamicpb.Message, error) {
+	md := c.service.Methods().ByName(protoreflect.Name(method))
+	if md == nil {
+		return nil, nil, fmt.Errorf("%s has no method %s", providerServiceName, method)
+	}
+	return dynamicpb.NewMessage(md.Input()), dynamicpb.NewMessage(md.Output()), nil
+}
+
+func (c *GRPCProviderClient) invoke(ctx context.Context, method string, in, out *dynamicpb.Message) error {
+	if err := c.conn.Invoke(ctx, "/"+providerServiceName+"/"+method, in, out); err != nil {
+		return fmt.Errorf("%s failed: %w", method, err)
+	}
+	return nil
+}
+
+func setString(msg *dynamicpb.Message, name, value string) {
+	msg.Set(msg.Descriptor().Fields().ByName(protoreflect.Name(name)), protoreflect.ValueOfString(value))
+}
+
+func setBytes(msg *dynamicpb.Message, name string, value []byte) {
+	if len(value) == 0 {
+		return
+	}
+	msg.Set(msg.Descriptor().Fields().ByName(protoreflect.Name(name)), protoreflect.ValueOfBytes(value))
+}
+
+func getBytes(msg *dynamicpb.Message, name string) []byte {
+	return msg.Get(msg.Descriptor().Fields().ByName(protoreflect.Name(name))).Bytes()
+}
+
+// setDynamicValue sets a DynamicValue field, leaving it unset for nil values
+func setDynamicValue(msg *dynamicpb.Message, name string, dv *tfprotov6.DynamicValue) {
+	if dv == nil {
+		return
+	}
+	fd := msg.Descriptor().Fields().ByName(protoreflect.Name(name))
+	sub := msg.NewField(fd).Message()
+	if len(dv.MsgPack) > 0 {
+		sub.Set(sub.Descriptor().Fields().ByName("msgpack"), protoreflect.ValueOfBytes(dv.MsgPack))
+	}
+	if len(dv.JSON) > 0 {
+		sub.Set(sub.Descriptor().Fields().ByName("json"), protoreflect.ValueOfBytes(dv.JSON))
+	}
+	msg.Set(fd, protoreflect.ValueOfMessage(sub))
+}
+
+// getDynamicValue reads a DynamicValue field, returning nil when it is unset
+func getDynamicValue(msg *dynamicpb.Message, name string) *tfprotov6.DynamicValue {
+	fd := msg.Descriptor().Fields().ByName(protoreflect.Name(name))
+	if !msg.Has(fd) {
+		return nil
+	}
+	sub := msg.Get(fd).Message()
+	return &tfprotov6.DynamicValue{
+		MsgPack: sub.Get(sub.Descriptor().Fields().ByName("msgpack")).Bytes(),
+		JSON:    sub.Get(sub.Descriptor().Fields().ByName("json")).Bytes(),
+	}
+}
+
+// getDiagnostics reads a repeated Diagnostic field by re-encoding each
+// element and decoding it with decodeDiagnosticProto
+func getDiagnostics(msg *dynamicpb.Message, name string) ([]*tfprotov6.Diagnostic, error) {
+	list := msg.Get(msg.Descriptor().Fields().ByName(protoreflect.Name(name))).List()
+	diags := make([]*tfprotov6.Diagnostic, 0, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		wire, err := proto.Marshal(list.Get(i).Message().Interface())
+		if err != nil {
+			return nil, fmt.Errorf("diagnostic %d: %w", i, err)
+		}
+		diag, err := decodeDiagnosticProto(wire)
+		if err != nil {
+			return nil, fmt.Errorf("diagnostic %d: %w", i, err)
+		}
+		diags = append(diags, diag)
+	}
+	return diags, nil
+}
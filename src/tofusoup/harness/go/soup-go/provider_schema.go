@@ -0,0 +1,317 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ResourceSchema is a single resource schema in the format emitted by
+// `terraform providers schema -json` (the values of "resource_schemas")
+type ResourceSchema struct {
+	Version int64        `json:"version"`
+	Block   *SchemaBlock `json:"block"`
+}
+
+// SchemaBlock is a schema block with attributes and nested block types
+type SchemaBlock struct {
+	Attributes map[string]*SchemaAttribute `json:"attributes,omitempty"`
+	BlockTypes map[string]*SchemaBlockType `json:"block_types,omitempty"`
+}
+
+// SchemaAttribute is a single attribute within a SchemaBlock. Exactly one
+// of Type and NestedType is set.
+type SchemaAttribute struct {
+	Type        json.RawMessage   `json:"type,omitempty"`
+	NestedType  *SchemaNestedType `json:"nested_type,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Required    bool              `json:"required,omitempty"`
+	Optional    bool              `json:"optional,omitempty"`
+	Computed    bool              `json:"computed,omitempty"`
+	Sensitive   bool              `json:"sensitive,omitempty"`
+}
+
+// SchemaNestedType is the nested object type of a protocol 6 nested attribute
+type SchemaNestedType struct {
+	Attributes  map[string]*SchemaAttribute `json:"attributes"`
+	NestingMode string                      `json:"nesting_mode"`
+}
+
+// SchemaBlockType is a nested block type within a SchemaBlock
+type SchemaBlockType struct {
+	NestingMode string       `json:"nesting_mode"`
+	Block       *SchemaBlock `json:"block"`
+	MinItems    int64        `json:"min_items,omitempty"`
+	MaxItems    int64        `json:"max_items,omitempty"`
+}
+
+// ProviderSchemas is the document emitted by `terraform providers schema -json`
+type ProviderSchemas struct {
+	FormatVersion string                     `json:"format_version"`
+	Schemas       map[string]*ProviderSchema `json:"provider_schemas"`
+}
+
+// ProviderSchema holds the schemas of a single provider
+type ProviderSchema struct {
+	Provider          *ResourceSchema            `json:"provider,omitempty"`
+	ResourceSchemas   map[string]*ResourceSchema `json:"resource_schemas,omitempty"`
+	DataSourceSchemas map[string]*ResourceSchema `json:"data_source_schemas,omitempty"`
+}
+
+// loadProviderSchemas reads a ProviderSchemas document from a JSON file
+func loadProviderSchemas(path string) (*ProviderSchemas, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read provider schemas file: %w", err)
+	}
+
+	var schemas ProviderSchemas
+	if err := json.Unmarshal(data, &schemas); err != nil {
+		return nil, fmt.Errorf("failed to parse provider schemas file: %w", err)
+	}
+	return &schemas, nil
+}
+
+// ResourceSchema looks up the schema of a managed resource ("managed") or
+// data source ("data") type served by providerName
+func (s *ProviderSchemas) ResourceSchema(providerName, mode, typeName string) (*ResourceSchema, bool) {
+	provider, ok := s.Schemas[providerName]
+	if !ok {
+		return nil, false
+	}
+	schemas := provider.ResourceSchemas
+	if mode == "data" {
+		schemas = provider.DataSourceSchemas
+	}
+	schema, ok := schemas[typeName]
+	if !ok || schema == nil {
+		return nil, false
+	}
+	if schema.Block == nil {
+		schema.Block = &SchemaBlock{}
+	}
+	return schema, true
+}
+
+// loadResourceSchema reads a ResourceSchema from a JSON file
+func loadResourceSchema(path string) (*ResourceSchema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema file: %w", err)
+	}
+
+	var schema ResourceSchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("failed to parse schema file: %w", err)
+	}
+	if schema.Block == nil {
+		schema.Block = &SchemaBlock{}
+	}
+	return &schema, nil
+}
+
+// ImpliedType returns the cty object type of values conforming to the schema
+func (s *ResourceSchema) ImpliedType() (cty.Type, error) {
+	return s.Block.ImpliedType()
+}
+
+// ImpliedType returns the cty object type of values conforming to the block
+func (b *SchemaBlock) ImpliedType() (cty.Type, error) {
+	attrTypes := make(map[string]cty.Type, len(b.Attributes)+len(b.BlockTypes))
+
+	for name, attr := range b.Attributes {
+		attrType, err := attr.ImpliedType()
+		if err != nil {
+			return cty.NilType, fmt.Errorf("attribute %s: %w", name, err)
+		}
+		attrTypes[name] = attrType
+	}
+
+	for name, blockType := range b.BlockTypes {
+		nested := blockType.Block
+		if nested == nil {
+			nested = &SchemaBlock{}
+		}
+		nestedType, err := nested.ImpliedType()
+		if err != nil {
+			return cty.NilType, fmt.Errorf("block %s: %w", name, err)
+		}
+		switch blockType.NestingMode {
+		case "single", "group":
+			attrTypes[name] = nestedType
+		case "list":
+			attrTypes[name] = cty.List(nestedType)
+		case "set":
+			attrTypes[name] = cty.Set(nestedType)
+		case "map":
+			attrTypes[name] = cty.Map(nestedType)
+		default:
+			return cty.NilType, fmt.Errorf("block %s: unsupported nesting mode: %s", name, blockType.NestingMode)
+		}
+	}
+
+	return cty.Object(attrTypes), nil
+}
+
+// ImpliedType returns the cty type of values of the attribute
+func (a *SchemaAttribute) ImpliedType() (cty.Type, error) {
+	if a.NestedType == nil {
+		return parseCtyType(a.Type)
+	}
+
+	attrTypes := make(map[string]cty.Type, len(a.NestedType.Attributes))
+	for name, attr := range a.NestedType.Attributes {
+		attrType, err := attr.ImpliedType()
+		if err != nil {
+			return cty.NilType, fmt.Errorf("attribute %s: %w", name, err)
+		}
+		attrTypes[name] = attrType
+	}
+	objType := cty.Object(attrTypes)
+
+	switch a.NestedType.NestingMode {
+	case "single":
+		return objType, nil
+	case "list":
+		return cty.List(objType), nil
+	case "set":
+		return cty.Set(objType), nil
+	case "map":
+		return cty.Map(objType), nil
+	}
+	return cty.NilType, fmt.Errorf("unsupported nesting mode: %s", a.NestedType.NestingMode)
+}
+
+// DecoderSpec returns an hcldec spec that decodes a configuration body
+// conforming to the block
+func (b *SchemaBlock) DecoderSpec() (hcldec.ObjectSpec, error) {
+	spec := make(hcldec.ObjectSpec, len(b.Attributes)+len(b.BlockTypes))
+
+	for name, attr := range b.Attributes {
+		attrType, err := attr.ImpliedType()
+		if err != nil {
+			return nil, fmt.Errorf("attribute %s: %w", name, err)
+		}
+		spec[name] = &hcldec.AttrSpec{Name: name, Type: attrType, Required: attr.Required}
+	}
+
+	for name, blockType := range b.BlockTypes {
+		nested := blockType.Block
+		if nested == nil {
+			nested = &SchemaBlock{}
+		}
+		nestedSpec, err := nested.DecoderSpec()
+		if err != nil {
+			return nil, fmt.Errorf("block %s: %w", name, err)
+		}
+		switch blockType.NestingMode {
+		case "single", "group":
+			spec[name] = &hcldec.BlockSpec{TypeName: name, Nested: nestedSpec, Required: blockType.MinItems > 0}
+		case "list":
+			spec[name] = &hcldec.BlockListSpec{TypeName: name, Nested: nestedSpec, MinItems: int(blockType.MinItems), MaxItems: int(blockType.MaxItems)}
+		case "set":
+			spec[name] = &hcldec.BlockSetSpec{TypeName: name, Nested: nestedSpec, MinItems: int(blockType.MinItems), MaxItems: int(blockType.MaxItems)}
+		case "map":
+			spec[name] = &hcldec.BlockMapSpec{TypeName: name, LabelNames: []string{"key"}, Nested: nestedSpec}
+		default:
+			return nil, fmt.Errorf("block %s: unsupported nesting mode: %s", name, blockType.NestingMode)
+		}
+	}
+
+	return spec, nil
+}
+
+// toProto converts the schema into its tfprotov6 representation
+func (s *ResourceSchema) toProto() (*tfprotov6.Schema, error) {
+	block, err := s.Block.toProto()
+	if err != nil {
+		return nil, err
+	}
+	block.Version = s.Version
+	return &tfprotov6.Schema{Version: s.Version, Block: block}, nil
+}
+
+// toProto converts the block into its tfprotov6 representation, with
+// attributes and block types sorted by name for deterministic output
+func (b *SchemaBlock) toProto() (*tfprotov6.SchemaBlock, error) {
+	block := &tfprotov6.SchemaBlock{}
+
+	for _, name := range sortedKeys(b.Attributes) {
+		attr := b.Attributes[name]
+		// Nested attribute types are served as plain object-typed attributes
+		attrType, err := attr.ImpliedType()
+		if err != nil {
+			return nil, fmt.Errorf("attribute %s: %w", name, err)
+		}
+		tfType, err := ctyTypeToTftypes(attrType)
+		if err != nil {
+			return nil, fmt.Errorf("attribute %s: %w", name, err)
+		}
+		block.Attributes = append(block.Attributes, &tfprotov6.SchemaAttribute{
+			Name:        name,
+			Type:        tfType,
+			Description: attr.Description,
+			Required:    attr.Required,
+			Optional:    attr.Optional,
+			Computed:    attr.Computed,
+			Sensitive:   attr.Sensitive,
+		})
+	}
+
+	for _, name := range sortedKeys(b.BlockTypes) {
+		blockType := b.BlockTypes[name]
+		nested := blockType.Block
+		if nested == nil {
+			nested = &SchemaBlock{}
+		}
+		nestedBlock, err := nested.toProto()
+		if err != nil {
+			return nil, fmt.Errorf("block %s: %w", name, err)
+		}
+		nesting, err := nestingModeToProto(blockType.NestingMode)
+		if err != nil {
+			return nil, fmt.Errorf("block %s: %w", name, err)
+		}
+		block.BlockTypes = append(block.BlockTypes, &tfprotov6.SchemaNestedBlock{
+			TypeName: name,
+			Block:    nestedBlock,
+			Nesting:  nesting,
+			MinItems: blockType.MinItems,
+			MaxItems: blockType.MaxItems,
+		})
+	}
+
+	return block, nil
+}
+
+// nestingModeToProto maps a JSON schema nesting mode to its tfprotov6 value
+func nestingModeToProto(mode string) (tfprotov6.SchemaNestedBlockNestingMode, error) {
+	switch mode {
+	case "single":
+		return tfprotov6.SchemaNestedBlockNestingModeSingle, nil
+	case "group":
+		return tfprotov6.SchemaNestedBlockNestingModeGroup, nil
+	case "list":
+		return tfprotov6.SchemaNestedBlockNestingModeList, nil
+	case "set":
+		return tfprotov6.SchemaNestedBlockNestingModeSet, nil
+	case "map":
+		return tfprotov6.SchemaNestedBlockNestingModeMap, nil
+	}
+	return tfprotov6.SchemaNestedBlockNestingModeInvalid, fmt.Errorf("unsupported nesting mode: %s", mode)
+}
+
+// sortedKeys returns the keys of m in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,399 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// resourceMetaSchema describes the Terraform meta-arguments permitted in a
+// resource block. The simulator accepts them but does not interpret them.
+var resourceMetaSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "count"},
+		{Name: "for_each"},
+		{Name: "provider"},
+		{Name: "depends_on"},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "lifecycle"},
+		{Type: "connection"},
+		{Type: "provisioner", LabelNames: []string{"type"}},
+	},
+}
+
+// simulatedResource is a resource block decoded from configuration
+type simulatedResource struct {
+	Address string
+	Config  cty.Value
+}
+
+// SimulationHop records the outcome of a single step of the lifecycle
+type SimulationHop struct {
+	Hop          string                   `json:"hop"`
+	RPC          string                   `json:"rpc,omitempty"`
+	Success      bool                     `json:"success"`
+	Failures     []string                 `json:"failures,omitempty"`
+	Diagnostics  []map[string]interface{} `json:"diagnostics,omitempty"`
+	Value        json.RawMessage          `json:"value,omitempty"`
+	UnknownPaths []string                 `json:"unknown_paths,omitempty"`
+}
+
+// loadSimulationResources parses a .tf or .tf.json file and decodes the body
+// of every resource block of typeName against the schema
+func loadSimulationResources(path, typeName string, schema *ResourceSchema) ([]simulatedResource, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	parser := hclparse.NewParser()
+	var file *hcl.File
+	var diags hcl.Diagnostics
+	if strings.HasSuffix(path, ".json") {
+		file, diags = parser.ParseJSON(content, path)
+	} else {
+		file, diags = parser.ParseHCL(content, path)
+	}
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("HCL parse errors: %s", diags.Error())
+	}
+
+	spec, err := schema.Block.DecoderSpec()
+	if err != nil {
+		return nil, err
+	}
+	ty, err := schema.ImpliedType()
+	if err != nil {
+		return nil, err
+	}
+
+	top, _, diags := file.Body.PartialContent(&hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{{Type: "resource", LabelNames: []string{"type", "name"}}},
+	})
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("HCL errors: %s", diags.Error())
+	}
+
+	evalCtx := &hcl.EvalContext{Functions: ctyFunctions()}
+	var resources []simulatedResource
+	for _, block := range top.Blocks {
+		if block.Labels[0] != typeName {
+			continue
+		}
+		address := block.Labels[0] + "." + block.Labels[1]
+
+		val, remain, diags := hcldec.PartialDecode(block.Body, spec, evalCtx)
+		_, metaDiags := remain.Content(resourceMetaSchema)
+		diags = append(diags, metaDiags...)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("%s: %s", address, diags.Error())
+		}
+
+		val, err := convert.Convert(val, ty)
+		if err != nil {
+			return nil, fmt.Errorf("%s: config does not conform to schema: %w", address, err)
+		}
+		resources = append(resources, simulatedResource{Address: address, Config: val})
+	}
+	return resources, nil
+}
+
+// simulateLifecycle drives Validate, Plan and Apply for a new resource
+// against provider, checking at every hop that values survive the wire
+// encoding and that the provider honours the protocol's consistency rules.
+// It stops at the first hop that fails.
+func simulateLifecycle(ctx context.Context, provider ProviderLifecycle, typeName string, schema *ResourceSchema, config cty.Value) ([]SimulationHop, bool) {
+	ty, err := schema.ImpliedType()
+	if err != nil {
+		return []SimulationHop{{Hop: "config", Failures: []string{err.Error()}}}, false
+	}
+
+	// Config: the decoded configuration must survive a msgpack round trip
+	configHop := SimulationHop{Hop: "config"}
+	configDV, failures := wireRoundTrip(config, ty)
+	configHop.Failures = failures
+	hops := []SimulationHop{finishHop(configHop, config, ty)}
+	if !hops[0].Success {
+		return hops, false
+	}
+	nullDV, err := ctyToDynamicValue(cty.NullVal(ty), ty)
+	if err != nil {
+		hops[0].Failures = append(hops[0].Failures, err.Error())
+		hops[0].Success = false
+		return hops, false
+	}
+
+	// Validate
+	validateHop := SimulationHop{Hop: "validate", RPC: "ValidateResourceConfig"}
+	validateResp, err := provider.ValidateResourceConfig(ctx, &tfprotov6.ValidateResourceConfigRequest{
+		TypeName: typeName,
+		Config:   configDV,
+	})
+	if err != nil {
+		validateHop.Failures = append(validateHop.Failures, err.Error())
+	} else {
+		validateHop.Diagnostics = protoDiagnosticsToJSON(validateResp.Diagnostics)
+		if protoDiagnosticsHaveErrors(validateResp.Diagnostics) {
+			validateHop.Failures = append(validateHop.Failures, "provider returned error diagnostics")
+		}
+	}
+	hops = append(hops, finishHop(validateHop, cty.NilVal, ty))
+	if len(validateHop.Failures) > 0 {
+		return hops, false
+	}
+
+	// Plan: with no prior state the proposed new state is the config itself
+	planHop := SimulationHop{Hop: "plan", RPC: "PlanResourceChange"}
+	planResp, err := provider.PlanResourceChange(ctx, &tfprotov6.PlanResourceChangeRequest{
+		TypeName:         typeName,
+		PriorState:       nullDV,
+		ProposedNewState: configDV,
+		Config:           configDV,
+	})
+	planned := cty.NilVal
+	if err != nil {
+		planHop.Failures = append(planHop.Failures, err.Error())
+	} else {
+		planHop.Diagnostics = protoDiagnosticsToJSON(planResp.Diagnostics)
+		planned, planHop.Failures = decodeHopValue(planResp.PlannedState, planResp.Diagnostics, "planned state", ty)
+		if planned.Type() != cty.NilType {
+			planHop.Failures = append(planHop.Failures, assertPlanValid(schema.Block, config, planned, nil)...)
+		}
+	}
+	hops = append(hops, finishHop(planHop, planned, ty))
+	if len(planHop.Failures) > 0 {
+		return hops, false
+	}
+
+	// Apply the plan as core would, re-encoding the decoded planned state
+	applyHop := SimulationHop{Hop: "apply", RPC: "ApplyResourceChange"}
+	plannedDV, failures := wireRoundTrip(planned, ty)
+	applyHop.Failures = failures
+	applied := cty.NilVal
+	if len(failures) == 0 {
+		applyResp, err := provider.ApplyResourceChange(ctx, &tfprotov6.ApplyResourceChangeRequest{
+			TypeName:       typeName,
+			PriorState:     nullDV,
+			PlannedState:   plannedDV,
+			Config:         configDV,
+			PlannedPrivate: planResp.PlannedPrivate,
+		})
+		if err != nil {
+			applyHop.Failures = append(applyHop.Failures, err.Error())
+		} else {
+			applyHop.Diagnostics = protoDiagnosticsToJSON(applyResp.Diagnostics)
+			applied, applyHop.Failures = decodeHopValue(applyResp.NewState, applyResp.Diagnostics, "new state", ty)
+		}
+	}
+	if applied.Type() != cty.NilType {
+		for _, path := range unknownPaths(applied) {
+			applyHop.Failures = append(applyHop.Failures, fmt.Sprintf("new state is not wholly known: %s is unknown", path))
+		}
+		applyHop.Failures = append(applyHop.Failures, assertApplyConsistent(planned, applied, nil)...)
+	}
+	hops = append(hops, finishHop(applyHop, applied, ty))
+
+	return hops, len(applyHop.Failures) == 0
+}
+
+// wireRoundTrip encodes val as a msgpack DynamicValue and reports a failure
+// if decoding it again does not reproduce val exactly
+func wireRoundTrip(val cty.Value, ty cty.Type) (*tfprotov6.DynamicValue, []string) {
+	dv, err := ctyToDynamicValue(val, ty)
+	if err != nil {
+		return nil, []string{fmt.Sprintf("failed to encode value: %s", err)}
+	}
+	decoded, err := dynamicValueToCty(dv, ty)
+	if err != nil {
+		return nil, []string{fmt.Sprintf("failed to decode encoded value: %s", err)}
+	}
+	if !decoded.RawEquals(val) {
+		return nil, []string{fmt.Sprintf("msgpack round trip changed value: sent %#v, decoded %#v", val, decoded)}
+	}
+	return dv, nil
+}
+
+// decodeHopValue decodes a state returned by the provider. A missing state
+// is only acceptable alongside error diagnostics.
+func decodeHopValue(dv *tfprotov6.DynamicValue, diags []*tfprotov6.Diagnostic, what string, ty cty.Type) (cty.Value, []string) {
+	var failures []string
+	if protoDiagnosticsHaveErrors(diags) {
+		failures = append(failures, "provider returned error diagnostics")
+	}
+	if dv == nil {
+		if len(failures) == 0 {
+			failures = append(failures, fmt.Sprintf("provider returned no %s", what))
+		}
+		return cty.NilVal, failures
+	}
+	val, err := dynamicValueToCty(dv, ty)
+	if err != nil {
+		return cty.NilVal, append(failures, fmt.Sprintf("failed to decode %s: %s", what, err))
+	}
+	if val.IsNull() {
+		failures = append(failures, fmt.Sprintf("provider returned a null %s", what))
+	}
+	return val, failures
+}
+
+// finishHop fills in the outcome and the value reported for a hop
+func finishHop(hop SimulationHop, val cty.Value, ty cty.Type) SimulationHop {
+	hop.Success = len(hop.Failures) == 0
+	if val.Type() == cty.NilType {
+		return hop
+	}
+	hop.UnknownPaths = unknownPaths(val)
+	if val.IsWhollyKnown() {
+		if data, err := ctyjson.Marshal(val, ty); err == nil {
+			hop.Value = data
+		}
+	}
+	return hop
+}
+
+// unknownPaths returns the paths of the outermost unknown values within val
+func unknownPaths(val cty.Value) []string {
+	var paths []string
+	cty.Walk(val, func(path cty.Path, v cty.Value) (bool, error) {
+		if !v.IsKnown() {
+			paths = append(paths, formatCtyPath(path))
+			return false, nil
+		}
+		return true, nil
+	})
+	return paths
+}
+
+// assertPlanValid checks that a planned value preserves every value set in
+// config, as Terraform core requires of PlanResourceChange. Only computed
+// attributes left null in config may be planned freely.
+func assertPlanValid(block *SchemaBlock, config, planned cty.Value, path cty.Path) []string {
+	if config.IsNull() || planned.IsNull() || !planned.IsKnown() {
+		if config.IsNull() && planned.IsNull() {
+			return nil
+		}
+		return []string{fmt.Sprintf("planned %#v for %s, but config is %#v", planned, describePath(path), config)}
+	}
+
+	var failures []string
+	for _, name := range sortedKeys(block.Attributes) {
+		attr := block.Attributes[name]
+		configV := config.GetAttr(name)
+		plannedV := planned.GetAttr(name)
+		if attr.Computed && configV.IsNull() {
+			continue
+		}
+		if configV.IsWhollyKnown() && !valuesEqual(configV, plannedV) {
+			failures = append(failures, fmt.Sprintf("planned %#v for %s, but config is %#v", plannedV, describePath(path.GetAttr(name)), configV))
+		}
+	}
+
+	for _, name := range sortedKeys(block.BlockTypes) {
+		blockType := block.BlockTypes[name]
+		nested := blockType.Block
+		if nested == nil {
+			nested = &SchemaBlock{}
+		}
+		configV := config.GetAttr(name)
+		plannedV := planned.GetAttr(name)
+		blockPath := path.GetAttr(name)
+
+		switch blockType.NestingMode {
+		case "single", "group":
+			failures = append(failures, assertPlanValid(nested, configV, plannedV, blockPath)...)
+		case "list", "map":
+			if configV.IsNull() || plannedV.IsNull() || !plannedV.IsKnown() || configV.LengthInt() != plannedV.LengthInt() {
+				failures = append(failures, fmt.Sprintf("planned %#v for %s, but config is %#v", plannedV, describePath(blockPath), configV))
+				continue
+			}
+			for it := configV.ElementIterator(); it.Next(); {
+				key, configElem := it.Element()
+				if blockType.NestingMode == "map" && !plannedV.HasIndex(key).True() {
+					failures = append(failures, fmt.Sprintf("planned value for %s is missing key %s", describePath(blockPath), key.AsString()))
+					continue
+				}
+				failures = append(failures, assertPlanValid(nested, configElem, plannedV.Index(key), blockPath.Index(key))...)
+			}
+		case "set":
+			// Set elements can't be correlated once computed attributes are
+			// planned, so only the element count is checked
+			if !plannedV.IsKnown() || plannedV.IsNull() || configV.LengthInt() != plannedV.LengthInt() {
+				failures = append(failures, fmt.Sprintf("planned %#v for %s, but config is %#v", plannedV, describePath(blockPath), configV))
+			}
+		}
+	}
+	return failures
+}
+
+// assertApplyConsistent checks that the applied state agrees with every known
+// value in the planned state, as Terraform core requires of ApplyResourceChange
+func assertApplyConsistent(planned, applied cty.Value, path cty.Path) []string {
+	if !planned.IsKnown() {
+		return nil
+	}
+	if planned.IsNull() || applied.IsNull() || !applied.IsKnown() {
+		if planned.IsNull() && applied.IsNull() {
+			return nil
+		}
+		return []string{fmt.Sprintf("planned %#v for %s, but applied %#v", planned, describePath(path), applied)}
+	}
+
+	ty := planned.Type()
+	switch {
+	case ty.IsObjectType():
+		var failures []string
+		for _, name := range sortedKeys(ty.AttributeTypes()) {
+			failures = append(failures, assertApplyConsistent(planned.GetAttr(name), applied.GetAttr(name), path.GetAttr(name))...)
+		}
+		return failures
+	case ty.IsListType() || ty.IsTupleType() || ty.IsMapType():
+		if planned.LengthInt() != applied.LengthInt() {
+			return []string{fmt.Sprintf("planned %d elements for %s, but applied %d", planned.LengthInt(), describePath(path), applied.LengthInt())}
+		}
+		var failures []string
+		for it := planned.ElementIterator(); it.Next(); {
+			key, plannedElem := it.Element()
+			if ty.IsMapType() && !applied.HasIndex(key).True() {
+				failures = append(failures, fmt.Sprintf("applied value for %s is missing key %s", describePath(path), key.AsString()))
+				continue
+			}
+			failures = append(failures, assertApplyConsistent(plannedElem, applied.Index(key), path.Index(key))...)
+		}
+		return failures
+	case ty.IsSetType() && !planned.IsWhollyKnown():
+		// Elements containing unknowns can't be correlated with the result
+		return nil
+	}
+
+	if !valuesEqual(planned, applied) {
+		return []string{fmt.Sprintf("planned %#v for %s, but applied %#v", planned, describePath(path), applied)}
+	}
+	return nil
+}
+
+// valuesEqual reports whether two wholly known values are equal
+func valuesEqual(a, b cty.Value) bool {
+	if !a.IsWhollyKnown() || !b.IsWhollyKnown() {
+		return false
+	}
+	return a.Equals(b).True()
+}
+
+// describePath renders a path for failure messages, naming the root object
+func describePath(path cty.Path) string {
+	if len(path) == 0 {
+		return "the resource"
+	}
+	return formatCtyPath(path)
+}
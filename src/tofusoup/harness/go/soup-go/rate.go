@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseRate parses a request rate such as "500/s" or "6000/m" into
+// requests per second. A bare number is per second; "" and 0 mean
+// unlimited.
+func parseRate(s string) (float64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	n, unit, found := strings.Cut(s, "/")
+	per := time.Second
+	if found {
+		switch unit {
+		case "s":
+		case "m":
+			per = time.Minute
+		case "h":
+			per = time.Hour
+		default:
+			return 0, fmt.Errorf("invalid rate %q: unit must be s, m or h", s)
+		}
+	}
+	v, err := strconv.ParseFloat(n, 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid rate %q: expected N/s", s)
+	}
+	return v / per.Seconds(), nil
+}
+
+// tokenBucket paces requests to a target rate. Tokens accrue at rate per
+// second up to burst, and each request spends one, so short stalls are
+// made up for by at most burst back-to-back requests.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket returns a bucket for rate requests per second, or nil
+// (which never waits) when rate is 0
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if rate <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: 1, last: time.Now()}
+}
+
+// Wait blocks until a request may be sent
+func (b *tokenBucket) Wait() {
+	if b == nil {
+		return
+	}
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		time.Sleep(wait)
+		b.tokens = 1
+		b.last = now.Add(wait)
+	}
+	b.tokens--
+}
@@ -0,0 +1,453 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/spf13/cobra"
+)
+
+// registryProvidersPath is the providers.v1 base path advertised by service
+// discovery
+const registryProvidersPath = "/v1/providers/"
+
+// registryFilesPath serves the fixture files that download URLs point at
+const registryFilesPath = "/files/"
+
+// defaultRegistryProtocols are advertised when a version has no manifest
+var defaultRegistryProtocols = []string{"6.0"}
+
+// RegistryPlatform is an os/arch pair a provider version is built for
+type RegistryPlatform struct {
+	OS   string `json:"os"`
+	Arch string `json:"arch"`
+}
+
+// RegistryVersion is an entry of the provider versions response
+type RegistryVersion struct {
+	Version   string             `json:"version"`
+	Protocols []string           `json:"protocols"`
+	Platforms []RegistryPlatform `json:"platforms"`
+}
+
+// RegistryGPGPublicKey is a key the shasums signature can be verified with
+type RegistryGPGPublicKey struct {
+	KeyID          string `json:"key_id"`
+	ASCIIArmor     string `json:"ascii_armor"`
+	TrustSignature string `json:"trust_signature"`
+	Source         string `json:"source"`
+	SourceURL      string `json:"source_url"`
+}
+
+// RegistrySigningKeys is the signing_keys property of a download response
+type RegistrySigningKeys struct {
+	GPGPublicKeys []RegistryGPGPublicKey `json:"gpg_public_keys"`
+}
+
+// RegistryDownload is the provider package download response. The
+// signature URL is omitted for versions without a SHA256SUMS.sig fixture.
+type RegistryDownload struct {
+	Protocols           []string            `json:"protocols"`
+	OS                  string              `json:"os"`
+	Arch                string              `json:"arch"`
+	Filename            string              `json:"filename"`
+	DownloadURL         string              `json:"download_url"`
+	SHASumsURL          string              `json:"shasums_url"`
+	SHASumsSignatureURL string              `json:"shasums_signature_url,omitempty"`
+	SHASum              string              `json:"shasum"`
+	SigningKeys         RegistrySigningKeys `json:"signing_keys"`
+}
+
+// registryFixtures reads provider packages from a fixtures directory laid
+// out as <namespace>/<type>/<version>/ holding the release files named the
+// way the public registry names them:
+//
+//	terraform-provider-<type>_<version>_<os>_<arch>.zip
+//	terraform-provider-<type>_<version>_SHA256SUMS      (generated if absent)
+//	terraform-provider-<type>_<version>_SHA256SUMS.sig  (optional)
+//	terraform-provider-<type>_<version>_manifest.json   (optional protocols)
+//
+// plus an optional <namespace>/signing_keys.json in the signing_keys form.
+// The signature is a detached binary GPG signature of SHA256SUMS by one of
+// the namespace's signing keys, so it cannot be generated when SHA256SUMS
+// is.
+// Fixtures are read on every request so they can change while serving.
+type registryFixtures struct {
+	dir    string
+	logger hclog.Logger
+}
+
+// packagePrefix is the file name prefix of a provider version's files
+func packagePrefix(typeName, version string) string {
+	return fmt.Sprintf("terraform-provider-%s_%s_", typeName, version)
+}
+
+// versions lists the versions of a provider with their platforms
+func (f *registryFixtures) versions(namespace, typeName string) ([]RegistryVersion, error) {
+	entries, err := os.ReadDir(filepath.Join(f.dir, namespace, typeName))
+	if err != nil {
+		return nil, err
+	}
+
+	versions := []RegistryVersion{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		platforms, err := f.platforms(namespace, typeName, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		if len(platforms) == 0 {
+			continue
+		}
+		versions = append(versions, RegistryVersion{
+			Version:   entry.Name(),
+			Protocols: f.protocols(namespace, typeName, entry.Name()),
+			Platforms: platforms,
+		})
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return compareVersions(versions[i].Version, versions[j].Version) < 0
+	})
+	return versions, nil
+}
+
+// platforms lists the os/arch pairs that have a package for a version
+func (f *registryFixtures) platforms(namespace, typeName, version string) ([]RegistryPlatform, error) {
+	entries, err := os.ReadDir(filepath.Join(f.dir, namespace, typeName, version))
+	if err != nil {
+		return nil, err
+	}
+	prefix := packagePrefix(typeName, version)
+	platforms := []RegistryPlatform{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ".zip") {
+			continue
+		}
+		osName, arch, ok := strings.Cut(strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".zip"), "_")
+		if !ok {
+			continue
+		}
+		platforms = append(platforms, RegistryPlatform{OS: osName, Arch: arch})
+	}
+	return platforms, nil
+}
+
+// protocols reads the protocol versions from a version's manifest
+func (f *registryFixtures) protocols(namespace, typeName, version string) []string {
+	path := filepath.Join(f.dir, namespace, typeName, version, packagePrefix(typeName, version)+"manifest.json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return defaultRegistryProtocols
+	}
+	var manifest struct {
+		Metadata struct {
+			ProtocolVersions []string `json:"protocol_versions"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil || len(manifest.Metadata.ProtocolVersions) == 0 {
+		f.logger.Warn("ignoring invalid registry manifest", "path", path, "error", err)
+		return defaultRegistryProtocols
+	}
+	return manifest.Metadata.ProtocolVersions
+}
+
+// shasums returns the SHA256SUMS document for a version, generating it from
+// the packages when the fixture does not include one
+func (f *registryFixtures) shasums(namespace, typeName, version string) ([]byte, error) {
+	versionDir := filepath.Join(f.dir, namespace, typeName, version)
+	prefix := packagePrefix(typeName, version)
+	if data, err := os.ReadFile(filepath.Join(versionDir, prefix+"SHA256SUMS")); err == nil {
+		return data, nil
+	}
+
+	platforms, err := f.platforms(namespace, typeName, version)
+	if err != nil {
+		return nil, err
+	}
+	var sums strings.Builder
+	for _, p := range platforms {
+		filename := fmt.Sprintf("%s%s_%s.zip", prefix, p.OS, p.Arch)
+		data, err := os.ReadFile(filepath.Join(versionDir, filename))
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(data)
+		fmt.Fprintf(&sums, "%s  %s\n", hex.EncodeToString(sum[:]), filename)
+	}
+	return []byte(sums.String()), nil
+}
+
+// signingKeys reads a namespace's signing keys, if any
+func (f *registryFixtures) signingKeys(namespace string) RegistrySigningKeys {
+	keys := RegistrySigningKeys{GPGPublicKeys: []RegistryGPGPublicKey{}}
+	data, err := os.ReadFile(filepath.Join(f.dir, namespace, "signing_keys.json"))
+	if err != nil {
+		return keys
+	}
+	if err := json.Unmarshal(data, &keys); err != nil {
+		f.logger.Warn("ignoring invalid signing keys", "namespace", namespace, "error", err)
+		return RegistrySigningKeys{GPGPublicKeys: []RegistryGPGPublicKey{}}
+	}
+	return keys
+}
+
+// download builds the download response for one platform of a version
+func (f *registryFixtures) download(baseURL, namespace, typeName, version, osName, arch string) (*RegistryDownload, error) {
+	prefix := packagePrefix(typeName, version)
+	filename := fmt.Sprintf("%s%s_%s.zip", prefix, osName, arch)
+	if _, err := os.Stat(filepath.Join(f.dir, namespace, typeName, version, filename)); err != nil {
+		return nil, err
+	}
+
+	sums, err := f.shasums(namespace, typeName, version)
+	if err != nil {
+		return nil, err
+	}
+	var shasum string
+	for _, line := range strings.Split(string(sums), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[1] == filename {
+			shasum = fields[0]
+			break
+		}
+	}
+	if shasum == "" {
+		return nil, fmt.Errorf("%s is not listed in the SHA256SUMS file", filename)
+	}
+
+	filesURL := baseURL + registryFilesPath + strings.Join([]string{namespace, typeName, version}, "/") + "/"
+	download := &RegistryDownload{
+		Protocols:   f.protocols(namespace, typeName, version),
+		OS:          osName,
+		Arch:        arch,
+		Filename:    filename,
+		DownloadURL: filesURL + filename,
+		SHASumsURL:  filesURL + prefix + "SHA256SUMS",
+		SHASum:      shasum,
+		SigningKeys: f.signingKeys(namespace),
+	}
+	if _, err := os.Stat(filepath.Join(f.dir, namespace, typeName, version, prefix+"SHA256SUMS.sig")); err == nil {
+		download.SHASumsSignatureURL = filesURL + prefix + "SHA256SUMS.sig"
+	}
+	return download, nil
+}
+
+// compareVersions orders dotted versions numerically, falling back to a
+// string comparison for non-numeric parts such as prerelease suffixes
+func compareVersions(a, b string) int {
+	aParts, bParts := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(aParts) && i < len(bParts); i++ {
+		an, aErr := strconv.Atoi(aParts[i])
+		bn, bErr := strconv.Atoi(bParts[i])
+		switch {
+		case aErr == nil && bErr == nil && an != bn:
+			if an < bn {
+				return -1
+			}
+			return 1
+		case (aErr != nil || bErr != nil) && aParts[i] != bParts[i]:
+			return strings.Compare(aParts[i], bParts[i])
+		}
+	}
+	return len(aParts) - len(bParts)
+}
+
+// newRegistryHandler serves service discovery, the providers.v1 protocol
+// and the fixture files
+func newRegistryHandler(fixtures *registryFixtures, logger hclog.Logger) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/.well-known/terraform.json", func(w http.ResponseWriter, r *http.Request) {
+		writeRegistryJSON(w, http.StatusOK, map[string]string{"providers.v1": registryProvidersPath})
+	})
+
+	mux.HandleFunc(registryProvidersPath, func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, registryProvidersPath), "/"), "/")
+		switch {
+		case len(parts) == 3 && parts[2] == "versions":
+			versions, err := fixtures.versions(parts[0], parts[1])
+			if err != nil {
+				logger.Debug("📦❌ provider not found", "provider", parts[0]+"/"+parts[1], "error", err)
+				writeRegistryError(w, http.StatusNotFound, "Not Found")
+				return
+			}
+			writeRegistryJSON(w, http.StatusOK, map[string]interface{}{"versions": versions})
+		case len(parts) == 6 && parts[3] == "download":
+			download, err := fixtures.download(requestBaseURL(r), parts[0], parts[1], parts[2], parts[4], parts[5])
+			if err != nil {
+				logger.Debug("📦❌ package not found", "path", r.URL.Path, "error", err)
+				writeRegistryError(w, http.StatusNotFound, "Not Found")
+				return
+			}
+			writeRegistryJSON(w, http.StatusOK, download)
+		default:
+			writeRegistryError(w, http.StatusNotFound, "Not Found")
+		}
+	})
+
+	mux.HandleFunc(registryFilesPath, func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, registryFilesPath), "/")
+		if len(parts) != 4 {
+			http.NotFound(w, r)
+			return
+		}
+		namespace, typeName, version, filename := parts[0], parts[1], parts[2], parts[3]
+		for _, part := range parts {
+			if part == "" || part == "." || part == ".." {
+				http.NotFound(w, r)
+				return
+			}
+		}
+		// SHA256SUMS may be generated rather than present on disk
+		if filename == packagePrefix(typeName, version)+"SHA256SUMS" {
+			sums, err := fixtures.shasums(namespace, typeName, version)
+			if err != nil {
+				http.NotFound(w, r)
+				return
+			}
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.Write(sums)
+			return
+		}
+		http.ServeFile(w, r, filepath.Join(fixtures.dir, namespace, typeName, version, filename))
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.Debug("📦📥 handling registry request", "method", r.Method, "path", r.URL.Path)
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// requestBaseURL is the scheme and host the client used to reach the server
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+func writeRegistryJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeRegistryError writes an error in the registry's {"errors": [...]} form
+func writeRegistryError(w http.ResponseWriter, status int, message string) {
+	writeRegistryJSON(w, status, map[string][]string{"errors": {message}})
+}
+
+// initRegistryServeCmd creates the `registry serve` command
+func initRegistryServeCmd() *cobra.Command {
+	var fixturesDir string
+	var listenAddr string
+	var useTLS bool
+	var certOut string
+	var pprofPort int
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve the provider registry protocol from local fixtures",
+		Long: `Serve service discovery (/.well-known/terraform.json) and the providers.v1
+registry protocol (versions and download metadata with signing keys) for
+provider packages in a fixtures directory, so registry clients can be tested
+offline. The directory is laid out as <namespace>/<type>/<version>/ holding
+terraform-provider-<type>_<version>_<os>_<arch>.zip packages, with optional
+SHA256SUMS, SHA256SUMS.sig and manifest.json files named the same way, and an
+optional <namespace>/signing_keys.json.
+
+Signatures are served, not made: SHA256SUMS.sig must be a detached GPG
+signature of the SHA256SUMS fixture by a key in signing_keys.json. Versions
+without one get no shasums_signature_url, which clients that verify
+signatures, such as Terraform and OpenTofu, reject. testdata/registry is
+signed with a throwaway key whose private half was discarded.
+
+Terraform only talks to registries over HTTPS; --tls serves with a generated
+self-signed certificate for localhost, written to --cert-out for clients to
+trust.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if info, err := os.Stat(fixturesDir); err != nil || !info.IsDir() {
+				return fmt.Errorf("fixtures directory %q not found", fixturesDir)
+			}
+
+			if pprofPort >= 0 {
+				if err := startPprofServer(pprofPort); err != nil {
+					return err
+				}
+			}
+
+			fixtures := &registryFixtures{dir: fixturesDir, logger: logger.Named("registry")}
+			server := &http.Server{Handler: newRegistryHandler(fixtures, logger.Named("registry"))}
+
+			listener, err := net.Listen("tcp", listenAddr)
+			if err != nil {
+				return fmt.Errorf("failed to listen on %s: %w", listenAddr, err)
+			}
+
+			scheme := "http"
+			if useTLS {
+				certPEM, keyPEM, err := generateCertWithCurve(logger, "secp256r1")
+				if err != nil {
+					return fmt.Errorf("failed to generate certificate: %w", err)
+				}
+				cert, err := tls.X509KeyPair(certPEM, keyPEM)
+				if err != nil {
+					return fmt.Errorf("failed to load certificate: %w", err)
+				}
+				if certOut != "" {
+					if err := os.WriteFile(certOut, certPEM, 0644); err != nil {
+						return fmt.Errorf("failed to write certificate: %w", err)
+					}
+				}
+				listener = tls.NewListener(listener, &tls.Config{
+					Certificates: []tls.Certificate{cert},
+					MinVersion:   tls.VersionTLS12,
+				})
+				scheme = "https"
+			}
+
+			shutdown := make(chan os.Signal, 1)
+			signal.Notify(shutdown, syscall.SIGINT, syscall.SIGTERM)
+			go func() {
+				sig := <-shutdown
+				logger.Info("📦🛑 shutting down registry", "signal", sig)
+				server.Close()
+			}()
+
+			logger.Info("📦🎧 Registry listening", "address", listener.Addr().String(), "fixtures", fixturesDir)
+			fmt.Printf("Registry listening on %s://%s\n", scheme, listener.Addr().String())
+
+			if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+				return fmt.Errorf("registry failed: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&fixturesDir, "fixtures", "", "Directory of provider package fixtures")
+	cmd.Flags().StringVar(&listenAddr, "listen", "127.0.0.1:0", "Address to listen on")
+	cmd.Flags().BoolVar(&useTLS, "tls", false, "Serve HTTPS with a generated self-signed certificate")
+	cmd.Flags().StringVar(&certOut, "cert-out", "", "Write the generated certificate PEM to this path (with --tls)")
+	addPprofPortFlag(cmd, &pprofPort)
+	cmd.MarkFlagRequired("fixtures")
+
+	return cmd
+}
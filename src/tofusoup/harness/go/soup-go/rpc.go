@@ -1,17 +1,26 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/hashicorp/go-plugin"
 	"github.com/spf13/cobra"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/kvplugin"
 )
 
 // getCurve returns the elliptic curve for the given curve name
 func initKVGetCmd() *cobra.Command {
 	var address string
 	var tlsCurve string
+	var stream bool
+	var chunkSize int
+	var output string
 
 	cmd := &cobra.Command{
 		Use:   "get [key]",
@@ -19,21 +28,14 @@ func initKVGetCmd() *cobra.Command {
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			key := args[0]
+			if err := validateClientOutput(output); err != nil {
+				return err
+			}
 
-			var client *plugin.Client
-			var err error
-
-			// Use reattach if --address is provided, otherwise spawn server
-			if address != "" {
-				client, err = newReattachClient(address, tlsCurve, logger)
-				if err != nil {
-					return err
-				}
-			} else {
-				client, err = newRPCClient(logger)
-				if err != nil {
-					return err
-				}
+			timeline := newSpawnTimeline()
+			client, err := newKVCommandClient(address, tlsCurve, timeline)
+			if err != nil {
+				return err
 			}
 			defer client.Kill()
 
@@ -47,13 +49,46 @@ func initKVGetCmd() *cobra.Command {
 			if err != nil {
 				return fmt.Errorf("failed to dispense plugin: %w", err)
 			}
-			kv := raw.(KV)
+			kv := raw.(kvplugin.KV)
 
-			value, err := kv.Get(key)
-			if err != nil {
-				return fmt.Errorf("failed to get key %s: %w", key, err)
+			if stream && output == "text" {
+				grpcClient, ok := raw.(*kvplugin.GRPCClient)
+				if !ok {
+					return fmt.Errorf("plugin client %T does not support GetStream", raw)
+				}
+				out := bufio.NewWriter(os.Stdout)
+				if _, err := grpcClient.GetStream(key, chunkSize, out); err != nil {
+					return fmt.Errorf("failed to get key %s: %w", key, err)
+				}
+				out.WriteString("\n")
+				return out.Flush()
+			}
+
+			var value []byte
+			if stream {
+				grpcClient, ok := raw.(*kvplugin.GRPCClient)
+				if !ok {
+					return fmt.Errorf("plugin client %T does not support GetStream", raw)
+				}
+				var buf bytes.Buffer
+				if _, err := grpcClient.GetStream(key, chunkSize, &buf); err != nil {
+					return fmt.Errorf("failed to get key %s: %w", key, err)
+				}
+				value = buf.Bytes()
+			} else {
+				value, err = kv.Get(key)
+				if err != nil {
+					return fmt.Errorf("failed to get key %s: %w", key, err)
+				}
 			}
 
+			if output == "json" {
+				return encodeClientResult(map[string]interface{}{
+					"key":     key,
+					"value":   string(value),
+					"timings": timeline.report(),
+				})
+			}
 			fmt.Printf("%s\n", value)
 			return nil
 		},
@@ -61,6 +96,9 @@ func initKVGetCmd() *cobra.Command {
 
 	cmd.Flags().StringVar(&address, "address", "", "Address of existing server (e.g., 127.0.0.1:50051)")
 	cmd.Flags().StringVar(&tlsCurve, "tls-curve", "auto", "Client cert curve: auto (detect from server), secp256r1, secp384r1, secp521r1")
+	cmd.Flags().BoolVar(&stream, "stream", false, "Read the value with the GetStream RPC, for values larger than the maximum message size")
+	cmd.Flags().IntVar(&chunkSize, "chunk-size", 0, "Chunk size in bytes to request with --stream (0 lets the server choose)")
+	cmd.Flags().StringVar(&output, "output", "text", "Output format: text, or json with the value and client lifecycle timings")
 	return cmd
 }
 
@@ -68,6 +106,7 @@ func initKVGetCmd() *cobra.Command {
 func initKVPutCmd() *cobra.Command {
 	var address string
 	var tlsCurve string
+	var output string
 
 	cmd := &cobra.Command{
 		Use:   "put [key] [value]",
@@ -76,21 +115,14 @@ func initKVPutCmd() *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			key := args[0]
 			value := []byte(args[1])
+			if err := validateClientOutput(output); err != nil {
+				return err
+			}
 
-			var client *plugin.Client
-			var err error
-
-			// Use reattach if --address is provided, otherwise spawn server
-			if address != "" {
-				client, err = newReattachClient(address, tlsCurve, logger)
-				if err != nil {
-					return err
-				}
-			} else {
-				client, err = newRPCClient(logger)
-				if err != nil {
-					return err
-				}
+			timeline := newSpawnTimeline()
+			client, err := newKVCommandClient(address, tlsCurve, timeline)
+			if err != nil {
+				return err
 			}
 			defer client.Kill()
 
@@ -104,12 +136,18 @@ func initKVPutCmd() *cobra.Command {
 			if err != nil {
 				return fmt.Errorf("failed to dispense plugin: %w", err)
 			}
-			kv := raw.(KV)
+			kv := raw.(kvplugin.KV)
 
 			if err := kv.Put(key, value); err != nil {
 				return fmt.Errorf("failed to put key %s: %w", key, err)
 			}
 
+			if output == "json" {
+				return encodeClientResult(map[string]interface{}{
+					"key":     key,
+					"timings": timeline.report(),
+				})
+			}
 			fmt.Printf("Key %s put successfully.\n", key)
 			return nil
 		},
@@ -117,9 +155,36 @@ func initKVPutCmd() *cobra.Command {
 
 	cmd.Flags().StringVar(&address, "address", "", "Address of existing server (e.g., 127.0.0.1:50051)")
 	cmd.Flags().StringVar(&tlsCurve, "tls-curve", "auto", "Client cert curve: auto (detect from server), secp256r1, secp384r1, secp521r1")
+	cmd.Flags().StringVar(&output, "output", "text", "Output format: text, or json with client lifecycle timings")
 	return cmd
 }
 
+// newKVCommandClient reattaches to the server at address when one is given
+// and otherwise spawns one, recording lifecycle stages on timeline
+func newKVCommandClient(address, tlsCurve string, timeline *spawnTimeline) (*plugin.Client, error) {
+	if address != "" {
+		return newReattachClient(address, tlsCurve, logger, timeline)
+	}
+	client, _, err := newSpawnedRPCClient(logger, nil, nil, nil, timeline)
+	return client, err
+}
+
+// validateClientOutput checks a client command's --output flag
+func validateClientOutput(output string) error {
+	if output != "text" && output != "json" {
+		return fmt.Errorf("invalid output format %q: expected text or json", output)
+	}
+	return nil
+}
+
+// encodeClientResult writes a client command's JSON output
+func encodeClientResult(result map[string]interface{}) error {
+	if err := json.NewEncoder(os.Stdout).Encode(result); err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	return nil
+}
+
 // Override the validateconnection command with real implementation
 func initValidateConnectionCmd() *cobra.Command {
 	cmd := &cobra.Command{
@@ -139,7 +204,7 @@ func initValidateConnectionCmd() *cobra.Command {
 			if err != nil {
 				return fmt.Errorf("failed to create RPC client: %w", err)
 			}
-			kv := raw.(KV)
+			kv := raw.(kvplugin.KV)
 
 			// Perform a simple Get on a non-existent key to validate connection
 			_, err = kv.Get("__connection_test_key__")
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// kvBenchResult summarizes one benchmark run against a spawned server
+type kvBenchResult struct {
+	Mode         string  `json:"mode"`
+	CacheSize    int64   `json:"cache_size"`
+	Puts         int     `json:"puts"`
+	Gets         int     `json:"gets"`
+	PutOpsPerSec float64 `json:"put_ops_per_sec"`
+	GetOpsPerSec float64 `json:"get_ops_per_sec"`
+	GetP50Micros int64   `json:"get_p50_us"`
+	GetP99Micros int64   `json:"get_p99_us"`
+	// TargetRate is the --rate requests were paced to, if any
+	TargetRate float64 `json:"target_rate,omitempty"`
+}
+
+// kvBenchOptions configures the workload of a benchmark run
+type kvBenchOptions struct {
+	keyPrefix string
+	keys      int
+	reads     int
+	valueSize int
+	seed      int64
+	// rate paces each phase to this many requests per second (0 is
+	// unlimited), allowing burst back-to-back requests after a stall
+	rate  float64
+	burst int
+	// address and tlsCurve select an existing server instead of spawning
+	address  string
+	tlsCurve string
+}
+
+// percentile returns the p-th percentile of sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(float64(len(sorted)-1) * p)
+	return sorted[i]
+}
+
+// runKVBench connects through pool to the server at opts.address, or
+// spawns one with serverArgs, writes keys values and then reads random
+// keys, timing each phase
+func runKVBench(pool *clientPool, mode string, cacheSize int64, serverArgs []string, opts kvBenchOptions) (*kvBenchResult, error) {
+	keys, reads, keyPrefix := opts.keys, opts.reads, opts.keyPrefix
+
+	kv, err := pool.Get(opts.address, opts.tlsCurve, serverArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	value := make([]byte, opts.valueSize)
+	for i := range value {
+		value[i] = byte('a' + i%26)
+	}
+
+	limiter := newTokenBucket(opts.rate, opts.burst)
+	start := time.Now()
+	for i := 0; i < keys; i++ {
+		limiter.Wait()
+		if err := kv.Put(keyPrefix+strconv.Itoa(i), value); err != nil {
+			return nil, fmt.Errorf("put failed: %w", err)
+		}
+	}
+	putElapsed := time.Since(start)
+
+	rng := rand.New(rand.NewSource(opts.seed))
+	latencies := make([]time.Duration, 0, reads)
+	limiter = newTokenBucket(opts.rate, opts.burst)
+	start = time.Now()
+	for i := 0; i < reads; i++ {
+		key := keyPrefix + strconv.Itoa(rng.Intn(keys))
+		limiter.Wait()
+		opStart := time.Now()
+		if _, err := kv.Get(key); err != nil {
+			return nil, fmt.Errorf("get %s failed: %w", key, err)
+		}
+		latencies = append(latencies, time.Since(opStart))
+	}
+	getElapsed := time.Since(start)
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	return &kvBenchResult{
+		Mode:         mode,
+		CacheSize:    cacheSize,
+		Puts:         keys,
+		Gets:         reads,
+		PutOpsPerSec: float64(keys) / putElapsed.Seconds(),
+		GetOpsPerSec: float64(reads) / getElapsed.Seconds(),
+		GetP50Micros: percentile(latencies, 0.50).Microseconds(),
+		GetP99Micros: percentile(latencies, 0.99).Microseconds(),
+		TargetRate:   opts.rate,
+	}, nil
+}
+
+// initKVBenchCmd creates the `rpc kv bench` command
+func initKVBenchCmd() *cobra.Command {
+	var (
+		keys       int
+		reads      int
+		valueSize  int
+		cacheSize  int64
+		keyPrefix  string
+		seed       int64
+		cachedOnly bool
+		rate       string
+		burst      int
+		address    string
+		tlsCurve   string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "bench",
+		Short: "Benchmark Put/Get against a spawned KV server, with and without its read cache",
+		Long: `Spawn the KV server named by PLUGIN_SERVER_PATH, write --keys values and then
+read --reads random keys, once without a read cache and once with
+--cache-size bytes of cache, so the cached and uncached paths can be compared.
+With --rate, requests are paced to a target rate instead of sent as fast as
+possible, so latency under a given load can be compared across servers.
+With --address, a single run is made against that server instead. Runs
+connect through a client pool, whose dial counts are included in the output.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if keys <= 0 || reads < 0 {
+				return fmt.Errorf("--keys must be positive and --reads non-negative")
+			}
+			targetRate, err := parseRate(rate)
+			if err != nil {
+				return err
+			}
+			opts := kvBenchOptions{
+				keyPrefix: keyPrefix,
+				keys:      keys,
+				reads:     reads,
+				valueSize: valueSize,
+				seed:      seed,
+				rate:      targetRate,
+				burst:     burst,
+				address:   address,
+				tlsCurve:  tlsCurve,
+			}
+
+			pool := newClientPool()
+			defer pool.Close()
+
+			results := []*kvBenchResult{}
+			if address != "" {
+				result, err := runKVBench(pool, "existing", 0, nil, opts)
+				if err != nil {
+					return fmt.Errorf("run against %s failed: %w", address, err)
+				}
+				results = append(results, result)
+			} else {
+				if !cachedOnly {
+					result, err := runKVBench(pool, "uncached", 0, nil, opts)
+					if err != nil {
+						return fmt.Errorf("uncached run failed: %w", err)
+					}
+					results = append(results, result)
+				}
+				if cacheSize > 0 {
+					serverArgs := []string{"--cache-size", strconv.FormatInt(cacheSize, 10)}
+					result, err := runKVBench(pool, "cached", cacheSize, serverArgs, opts)
+					if err != nil {
+						return fmt.Errorf("cached run failed: %w", err)
+					}
+					results = append(results, result)
+				}
+			}
+
+			if err := json.NewEncoder(os.Stdout).Encode(map[string]interface{}{"results": results, "pool": pool.Stats()}); err != nil {
+				return fmt.Errorf("failed to encode JSON: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&keys, "keys", 100, "Number of keys to write")
+	cmd.Flags().IntVar(&reads, "reads", 1000, "Number of random reads")
+	cmd.Flags().IntVar(&valueSize, "value-size", 1024, "Size of each value in bytes")
+	cmd.Flags().Int64Var(&cacheSize, "cache-size", 64<<20, "Server read cache size in bytes for the cached run (0 skips it)")
+	cmd.Flags().StringVar(&keyPrefix, "key-prefix", "bench-", "Prefix of the keys written")
+	cmd.Flags().Int64Var(&seed, "seed", 1, "Seed for the read key sequence")
+	cmd.Flags().BoolVar(&cachedOnly, "cached-only", false, "Skip the uncached run")
+	cmd.Flags().StringVar(&rate, "rate", "", "Pace requests to this rate, e.g. 500/s or 6000/m (default: as fast as possible)")
+	cmd.Flags().IntVar(&burst, "burst", 1, "Requests that may be sent back to back with --rate after a stall")
+	cmd.Flags().StringVar(&address, "address", "", "Address or handshake line of an existing server to benchmark instead of spawning servers")
+	cmd.Flags().StringVar(&tlsCurve, "tls-curve", "auto", "Client cert curve with --address: auto (detect from server), secp256r1, secp384r1, secp521r1")
+	return withProfiles(cmd)
+}
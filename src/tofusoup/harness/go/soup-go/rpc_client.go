@@ -4,6 +4,7 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"os/exec"
@@ -13,13 +14,26 @@ import (
 	"github.com/hashicorp/go-plugin"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/kvplugin"
 )
 
 func newRPCClient(logger hclog.Logger) (*plugin.Client, error) {
+	client, _, err := newSpawnedRPCClient(logger, nil, nil, nil, nil)
+	return client, err
+}
+
+// newSpawnedRPCClient spawns the server named by PLUGIN_SERVER_PATH with any
+// extra server arguments. Output the server writes to its stdout and stderr
+// while serving arrives over the GRPCStdio stream and is copied to stdout and
+// stderr when they are non-nil. The returned command reports the server's
+// exit status once the client has seen it exit. A non-nil timeline records
+// the client's lifecycle stages.
+func newSpawnedRPCClient(logger hclog.Logger, extraArgs []string, stdout, stderr io.Writer, timeline *spawnTimeline) (*plugin.Client, *exec.Cmd, error) {
 	// Create command with environment variables
 	serverPath := os.Getenv("PLUGIN_SERVER_PATH")
 	if serverPath == "" {
-		return nil, fmt.Errorf("PLUGIN_SERVER_PATH environment variable not set")
+		return nil, nil, fmt.Errorf("PLUGIN_SERVER_PATH environment variable not set")
 	}
 
 	// Build command with TLS flags for Python server compatibility
@@ -49,31 +63,38 @@ func newRPCClient(logger hclog.Logger) (*plugin.Client, error) {
 	} else {
 		logger.Info("Spawning server without TLS (disabled mode)")
 	}
+	cmdArgs = append(cmdArgs, extraArgs...)
 
 	cmd := exec.Command(serverPath, cmdArgs...)
 	cmd.Env = append(os.Environ(),
 		"PLUGIN_AUTO_MTLS=true",                            // Explicitly enable AutoMTLS for Go servers
-		fmt.Sprintf("KV_STORAGE_DIR=%s", GetKVStorageDir()), // Set XDG-compliant storage directory
+		fmt.Sprintf("KV_STORAGE_DIR=%s", kvplugin.StorageDir()), // Set XDG-compliant storage directory
 		// Add go-plugin magic cookies for Python server detection
 		"PLUGIN_MAGIC_COOKIE_KEY=BASIC_PLUGIN",
 		"BASIC_PLUGIN=hello",
 	)
 
 	// Create client
-	client := plugin.NewClient(&plugin.ClientConfig{
-		HandshakeConfig:  Handshake,
+	clientConfig := &plugin.ClientConfig{
+		HandshakeConfig:  kvplugin.Handshake,
 		VersionedPlugins: map[int]plugin.PluginSet{
 			1: {
-				"kv_grpc": &KVGRPCPlugin{},
+				"kv_grpc": &kvplugin.KVGRPCPlugin{},
 			},
 		},
 		Cmd:             cmd,
 		Logger:          logger,
 		AutoMTLS:        true,
 		AllowedProtocols: []plugin.Protocol{plugin.ProtocolGRPC},
-	})
+		SyncStdout:      stdout,
+		SyncStderr:      stderr,
+	}
+	if timeline != nil {
+		timeline.instrument(clientConfig)
+	}
+	client := plugin.NewClient(clientConfig)
 
-	return client, nil
+	return client, cmd, nil
 }
 
 // parseHandshakeOrAddress parses either a simple address or a full go-plugin handshake line
@@ -151,8 +172,9 @@ func parseHandshakeOrAddress(addressOrHandshake string, logger hclog.Logger) (*p
 }
 
 // newReattachClient creates a go-plugin client that reattaches to an existing server
-// This is used when --address flag is provided
-func newReattachClient(addressOrHandshake string, tlsCurve string, logger hclog.Logger) (*plugin.Client, error) {
+// This is used when --address flag is provided. A non-nil timeline records
+// the client's lifecycle stages.
+func newReattachClient(addressOrHandshake string, tlsCurve string, logger hclog.Logger, timeline *spawnTimeline) (*plugin.Client, error) {
 	logger.Info("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	logger.Info("🔌 Creating reattach client for existing server")
 	logger.Info("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
@@ -173,13 +195,13 @@ func newReattachClient(addressOrHandshake string, tlsCurve string, logger hclog.
 
 	// Build client config
 	clientConfig := &plugin.ClientConfig{
-		HandshakeConfig: Handshake,
+		HandshakeConfig: kvplugin.Handshake,
 		Plugins: map[string]plugin.Plugin{
-			"kv_grpc": &KVGRPCPlugin{},
+			"kv_grpc": &kvplugin.KVGRPCPlugin{},
 		},
 		VersionedPlugins: map[int]plugin.PluginSet{
 			1: {
-				"kv_grpc": &KVGRPCPlugin{},
+				"kv_grpc": &kvplugin.KVGRPCPlugin{},
 			},
 		},
 		Reattach:         reattachConfig,
@@ -247,6 +269,13 @@ func newReattachClient(addressOrHandshake string, tlsCurve string, logger hclog.
 		logger.Info("ℹ️  No TLS config found, using insecure connection")
 	}
 
+	if timeline != nil {
+		if tlsConfig == nil {
+			timeline.transportStage = stageConnected
+		}
+		timeline.instrument(clientConfig)
+	}
+
 	// Create client with reattach config
 	client := plugin.NewClient(clientConfig)
 
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-plugin"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// grpcControllerServiceName is go-plugin's controller service. Its Shutdown
+// RPC is how plugin hosts ask a plugin to exit, and non-Go hosts call it
+// directly instead of relying on go-plugin's client.
+const grpcControllerServiceName = "plugin.GRPCController"
+
+// grpcControllerShutdownMethod is the full method name of Shutdown. Its
+// request and response are plugin.Empty, which is wire-compatible with
+// google.protobuf.Empty.
+const grpcControllerShutdownMethod = "/" + grpcControllerServiceName + "/Shutdown"
+
+// grpcControllerServer is implemented by servers that handle Shutdown
+type grpcControllerServer interface {
+	Shutdown(ctx context.Context) error
+}
+
+// grpcControllerServiceDesc describes the controller service for servers
+// that are not run by plugin.Serve, which registers its own
+var grpcControllerServiceDesc = grpc.ServiceDesc{
+	ServiceName: grpcControllerServiceName,
+	HandlerType: (*grpcControllerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Shutdown",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				in := new(emptypb.Empty)
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+					return &emptypb.Empty{}, srv.(grpcControllerServer).Shutdown(ctx)
+				}
+				if interceptor == nil {
+					return handler(ctx, in)
+				}
+				info := &grpc.UnaryServerInfo{Server: srv, FullMethod: grpcControllerShutdownMethod}
+				return interceptor(ctx, in, info, handler)
+			},
+		},
+	},
+	Metadata: "plugin/grpc_controller.proto",
+}
+
+// standaloneController stops a standalone gRPC server on Shutdown
+type standaloneController struct {
+	server *grpc.Server
+	logger hclog.Logger
+}
+
+func (c *standaloneController) Shutdown(ctx context.Context) error {
+	c.logger.Info("🗄️🛑 shutting down server", "reason", "GRPCController.Shutdown")
+	// GracefulStop waits for in-flight RPCs, including this one, so it
+	// must not block the handler
+	go c.server.GracefulStop()
+	return nil
+}
+
+// invokeControllerShutdown calls GRPCController.Shutdown on a connection and
+// reports how the call completed: "ok" when the server replied, or
+// "connection_closed" when it stopped before replying. go-plugin's own server
+// hard-stops inside the handler, so its callers always see the latter.
+func invokeControllerShutdown(ctx context.Context, conn *grpc.ClientConn) (string, error) {
+	err := conn.Invoke(ctx, grpcControllerShutdownMethod, &emptypb.Empty{}, &emptypb.Empty{})
+	switch status.Code(err) {
+	case codes.OK:
+		return "ok", nil
+	case codes.Unavailable:
+		return "connection_closed", nil
+	}
+	return "", fmt.Errorf("GRPCController.Shutdown failed: %w", err)
+}
+
+// waitForListenerClosed polls addr until connections are refused or the
+// timeout passes, reporting whether the listener closed
+func waitForListenerClosed(addr net.Addr, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		conn, err := net.DialTimeout(addr.Network(), addr.String(), 100*time.Millisecond)
+		if err != nil {
+			return true
+		}
+		conn.Close()
+		time.Sleep(50 * time.Millisecond)
+	}
+	return false
+}
+
+// initKVShutdownCmd creates the `rpc kv shutdown` command
+func initKVShutdownCmd() *cobra.Command {
+	var address string
+	var tlsCurve string
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "shutdown",
+		Short: "Invoke GRPCController.Shutdown and verify the server exits",
+		Long: `Call go-plugin's GRPCController.Shutdown RPC directly, as non-Go plugin hosts
+do, and verify an orderly exit. A server spawned from PLUGIN_SERVER_PATH must
+exit on its own with status 0 before --timeout; a server reached with --address
+must stop accepting connections before --timeout.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var client *plugin.Client
+			var serverCmd *exec.Cmd
+			var addr net.Addr
+			var err error
+
+			mode := "spawned"
+			if address != "" {
+				mode = "reattach"
+				reattach, _, _, _, err := parseHandshakeOrAddress(address, logger)
+				if err != nil {
+					return err
+				}
+				addr = reattach.Addr
+				client, err = newReattachClient(address, tlsCurve, logger, nil)
+				if err != nil {
+					return err
+				}
+			} else {
+				client, serverCmd, err = newSpawnedRPCClient(logger, nil, nil, nil, nil)
+				if err != nil {
+					return err
+				}
+			}
+			defer client.Kill()
+
+			rpcClient, err := client.Client()
+			if err != nil {
+				return fmt.Errorf("failed to create RPC client: %w", err)
+			}
+			grpcClient, ok := rpcClient.(*plugin.GRPCClient)
+			if !ok {
+				return fmt.Errorf("server did not negotiate gRPC")
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			start := time.Now()
+			rpcResult, err := invokeControllerShutdown(ctx, grpcClient.Conn)
+			if err != nil {
+				return err
+			}
+
+			result := map[string]interface{}{
+				"mode":         mode,
+				"shutdown_rpc": rpcResult,
+			}
+			var problem string
+			if serverCmd != nil {
+				exited := false
+				for deadline := time.Now().Add(timeout); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+					if client.Exited() {
+						exited = true
+						break
+					}
+				}
+				result["exited"] = exited
+				if !exited {
+					problem = fmt.Sprintf("server still running %s after Shutdown", timeout)
+				} else if code := serverCmd.ProcessState.ExitCode(); code != 0 {
+					result["exit_code"] = code
+					problem = fmt.Sprintf("server exited with status %d after Shutdown", code)
+				} else {
+					result["exit_code"] = code
+				}
+			} else {
+				closed := waitForListenerClosed(addr, timeout)
+				result["listener_closed"] = closed
+				if !closed {
+					problem = fmt.Sprintf("server still accepting connections on %s %s after Shutdown", addr, timeout)
+				}
+			}
+			result["elapsed_ms"] = time.Since(start).Milliseconds()
+			result["passed"] = problem == ""
+
+			if err := json.NewEncoder(os.Stdout).Encode(result); err != nil {
+				return fmt.Errorf("failed to encode JSON: %w", err)
+			}
+			if problem != "" {
+				return fmt.Errorf("%s", problem)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&address, "address", "", "Address or handshake line of an existing server (default: spawn PLUGIN_SERVER_PATH)")
+	cmd.Flags().StringVar(&tlsCurve, "tls-curve", "auto", "Client cert curve: auto (detect from server), secp256r1, secp384r1, secp521r1")
+	cmd.Flags().DurationVar(&timeout, "timeout", 10*time.Second, "How long to wait for the server to exit")
+	return cmd
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/kvplugin"
+)
+
+// hostileEnvCase is an adversarial change to the environment a server is
+// spawned with
+type hostileEnvCase struct {
+	name        string
+	description string
+	// set adds or overrides variables; unset removes them
+	set   map[string]string
+	unset []string
+}
+
+// hostileEnvCases returns the cases `rpc kv hostile-env` knows, with values
+// of hugeSize bytes where a case needs a large value
+func hostileEnvCases(hugeSize, manyVars int) []hostileEnvCase {
+	many := make(map[string]string, manyVars)
+	for i := 0; i < manyVars; i++ {
+		many[fmt.Sprintf("SOUP_HOSTILE_VAR_%d", i)] = fmt.Sprintf("value-%d", i)
+	}
+	return []hostileEnvCase{
+		{
+			name:        "baseline",
+			description: "the environment unchanged",
+		},
+		{
+			name:        "huge-value",
+			description: "one variable with a very large value",
+			set:         map[string]string{"SOUP_HOSTILE_HUGE": strings.Repeat("x", hugeSize)},
+		},
+		{
+			name:        "many-vars",
+			description: "thousands of extra variables",
+			set:         many,
+		},
+		{
+			name:        "invalid-utf8",
+			description: "values that are not valid UTF-8, including in variables servers read",
+			set: map[string]string{
+				"SOUP_HOSTILE_UTF8": "\xff\xfe\x80invalid\xc3\x28",
+				"COMBO_ID":          "\xff\xfe",
+				"CLIENT_LANGUAGE":   "go\xc3\x28",
+			},
+		},
+		{
+			name:        "tls-conflict",
+			description: "TLS_* variables that contradict each other",
+			set: map[string]string{
+				"TLS_MODE":     "manual",
+				"TLS_KEY_TYPE": "rsa",
+				"TLS_CURVE":    "secp384r1",
+				"TLS_KEY_SIZE": "not-a-number",
+			},
+		},
+		{
+			name:        "no-home",
+			description: "HOME and the cache and storage overrides unset",
+			unset: []string{
+				kvplugin.EnvHome,
+				kvplugin.EnvXDGCacheHome,
+				kvplugin.EnvTofuSoupCacheDir,
+				kvplugin.EnvKVStorageDir,
+			},
+		},
+	}
+}
+
+// apply returns env with the case's changes made
+func (c hostileEnvCase) apply(env []string) []string {
+	drop := make(map[string]bool, len(c.set)+len(c.unset))
+	for name := range c.set {
+		drop[name] = true
+	}
+	for _, name := range c.unset {
+		drop[name] = true
+	}
+	out := make([]string, 0, len(env)+len(c.set))
+	for _, entry := range env {
+		name, _, _ := strings.Cut(entry, "=")
+		if !drop[name] {
+			out = append(out, entry)
+		}
+	}
+	for name, value := range c.set {
+		out = append(out, name+"="+value)
+	}
+	return out
+}
+
+// hostileEnvResult records how a server behaved under one case
+type hostileEnvResult struct {
+	Case        string `json:"case"`
+	Description string `json:"description"`
+	EnvBytes    int    `json:"env_bytes"`
+	// Started is whether the server completed the go-plugin handshake
+	Started     bool    `json:"started"`
+	HandshakeMs float64 `json:"handshake_ms,omitempty"`
+	PutOK       bool    `json:"put_ok"`
+	GetOK       bool    `json:"get_ok"`
+	// ValueMatches is whether Get returned what Put stored
+	ValueMatches bool   `json:"value_matches"`
+	Error        string `json:"error,omitempty"`
+}
+
+// setProcessEnv makes the case's changes to this process's environment and
+// returns a func restoring it. go-plugin appends the host environment to the
+// server's, so changes made only to the server command would be overridden.
+func (c hostileEnvCase) setProcessEnv() func() {
+	previous := make(map[string]*string)
+	save := func(name string) {
+		if _, ok := previous[name]; ok {
+			return
+		}
+		if value, ok := os.LookupEnv(name); ok {
+			previous[name] = &value
+		} else {
+			previous[name] = nil
+		}
+	}
+	for name, value := range c.set {
+		save(name)
+		os.Setenv(name, value)
+	}
+	for _, name := range c.unset {
+		save(name)
+		os.Unsetenv(name)
+	}
+	return func() {
+		for name, value := range previous {
+			if value != nil {
+				os.Setenv(name, *value)
+			} else {
+				os.Unsetenv(name)
+			}
+		}
+	}
+}
+
+// runHostileEnvCase spawns the server with the case's environment and makes
+// one Put and Get
+func runHostileEnvCase(c hostileEnvCase, timeout time.Duration) *hostileEnvResult {
+	result := &hostileEnvResult{Case: c.name, Description: c.description}
+
+	restoreEnv := c.setProcessEnv()
+	defer restoreEnv()
+	client, serverCmd, err := newSpawnedRPCClient(logger, nil, nil, nil, nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer client.Kill()
+	// Drop variables the client adds itself, such as KV_STORAGE_DIR
+	serverCmd.Env = c.apply(serverCmd.Env)
+
+	start := time.Now()
+	type dispensed struct {
+		kv  kvplugin.KV
+		err error
+	}
+	ready := make(chan dispensed, 1)
+	go func() {
+		rpcClient, err := client.Client()
+		if err != nil {
+			ready <- dispensed{err: fmt.Errorf("failed to create RPC client: %w", err)}
+			return
+		}
+		raw, err := rpcClient.Dispense("kv_grpc")
+		if err != nil {
+			ready <- dispensed{err: fmt.Errorf("failed to dispense plugin: %w", err)}
+			return
+		}
+		ready <- dispensed{kv: raw.(kvplugin.KV)}
+	}()
+
+	var kv kvplugin.KV
+	select {
+	case d := <-ready:
+		kv, err = d.kv, d.err
+	case <-time.After(timeout):
+		err = fmt.Errorf("server did not complete the handshake within %s", timeout)
+	}
+	// go-plugin has completed the server's environment by now
+	for _, entry := range serverCmd.Env {
+		result.EnvBytes += len(entry) + 1
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Started = true
+	result.HandshakeMs = float64(time.Since(start).Microseconds()) / 1000
+
+	key := "__hostile_env_" + c.name
+	value := []byte("hostile-env " + c.name)
+	if err := kv.Put(key, value); err != nil {
+		result.Error = fmt.Sprintf("put failed: %s", err)
+		return result
+	}
+	result.PutOK = true
+	got, err := kv.Get(key)
+	if err != nil {
+		result.Error = fmt.Sprintf("get failed: %s", err)
+		return result
+	}
+	result.GetOK = true
+	result.ValueMatches = bytes.Equal(got, value)
+	return result
+}
+
+// initKVHostileEnvCmd creates the `rpc kv hostile-env` command
+func initKVHostileEnvCmd() *cobra.Command {
+	var (
+		caseNames []string
+		hugeSize  int
+		manyVars  int
+		timeout   time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "hostile-env",
+		Short: "Spawn the KV server with adversarial environments and record how it copes",
+		Long: `Spawn the KV server named by PLUGIN_SERVER_PATH once per case, each time with
+the environment changed in a hostile way, and record whether it completes the
+handshake and serves a Put and Get. Cases:
+
+  baseline      the environment unchanged
+  huge-value    one variable of --huge-size bytes
+  many-vars     --many-vars extra variables
+  invalid-utf8  values that are not valid UTF-8
+  tls-conflict  TLS_* variables that contradict each other
+  no-home       HOME and the cache and storage overrides unset
+
+Env handling differs widely across languages, so the report records behavior
+rather than judging it; the command fails only if the baseline case does, as
+then the other results say nothing. Linux rejects single variables over
+128KiB when spawning, which huge-value reports as a spawn error.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cases := hostileEnvCases(hugeSize, manyVars)
+			if len(caseNames) > 0 {
+				byName := make(map[string]hostileEnvCase, len(cases))
+				for _, c := range cases {
+					byName[c.name] = c
+				}
+				cases = cases[:0]
+				for _, name := range caseNames {
+					c, ok := byName[name]
+					if !ok {
+						return fmt.Errorf("unknown case %q", name)
+					}
+					cases = append(cases, c)
+				}
+			}
+
+			results := make([]*hostileEnvResult, 0, len(cases))
+			var baseline *hostileEnvResult
+			for _, c := range cases {
+				result := runHostileEnvCase(c, timeout)
+				logger.Info("☣️📊 hostile env case complete",
+					"case", c.name,
+					"started", result.Started,
+					"value_matches", result.ValueMatches,
+					"error", result.Error)
+				if c.name == "baseline" {
+					baseline = result
+				}
+				results = append(results, result)
+			}
+
+			if err := json.NewEncoder(os.Stdout).Encode(map[string]interface{}{"results": results}); err != nil {
+				return fmt.Errorf("failed to encode JSON: %w", err)
+			}
+			if baseline != nil && !baseline.ValueMatches {
+				return fmt.Errorf("baseline case failed: %s", baseline.Error)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&caseNames, "case", nil, "Cases to run (repeatable, default: all)")
+	cmd.Flags().IntVar(&hugeSize, "huge-size", 64*1024, "Size in bytes of the huge-value variable")
+	cmd.Flags().IntVar(&manyVars, "many-vars", 5000, "Number of variables many-vars adds")
+	cmd.Flags().DurationVar(&timeout, "timeout", 10*time.Second, "How long to wait for each server's handshake")
+	return cmd
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-plugin"
+	"github.com/spf13/cobra"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/kvplugin"
+)
+
+// clientPool shares plugin clients, and with them their gRPC connections
+// and mTLS handshakes, between the operations of a batch client command.
+// Clients are keyed by server address or handshake line, client curve and
+// spawn arguments; an empty address spawns PLUGIN_SERVER_PATH.
+type clientPool struct {
+	mu      sync.Mutex
+	clients map[string]*pooledClient
+	stats   clientPoolStats
+}
+
+type pooledClient struct {
+	client   *plugin.Client
+	protocol plugin.ClientProtocol
+	kv       kvplugin.KV
+	// reattached is set for servers the pool did not spawn
+	reattached bool
+}
+
+// close kills a spawned server. For a reattached server only the
+// connection is closed: killing the client would shut the server down.
+func (c *pooledClient) close() {
+	if !c.reattached {
+		c.client.Kill()
+		return
+	}
+	if grpcClient, ok := c.protocol.(*plugin.GRPCClient); ok {
+		grpcClient.Conn.Close()
+	}
+}
+
+// clientPoolStats counts how often the pool connected versus reused a
+// connection
+type clientPoolStats struct {
+	Acquires int `json:"acquires"`
+	Dials    int `json:"dials"`
+	Reuses   int `json:"reuses"`
+	// Discards counts connections dropped because the client asked for a
+	// fresh one or the server process had exited
+	Discards int     `json:"discards"`
+	DialMs   float64 `json:"dial_ms"`
+}
+
+func newClientPool() *clientPool {
+	return &clientPool{clients: make(map[string]*pooledClient)}
+}
+
+func clientPoolKey(address, tlsCurve string, serverArgs []string) string {
+	return strings.Join(append([]string{address, tlsCurve}, serverArgs...), "\x00")
+}
+
+// Get returns a KV client for the server, connecting only if the pool has
+// no live connection to it
+func (p *clientPool) Get(address, tlsCurve string, serverArgs []string) (kvplugin.KV, error) {
+	key := clientPoolKey(address, tlsCurve, serverArgs)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stats.Acquires++
+	if pooled, ok := p.clients[key]; ok {
+		if !pooled.client.Exited() {
+			p.stats.Reuses++
+			return pooled.kv, nil
+		}
+		p.discard(key)
+	}
+
+	start := time.Now()
+	var client *plugin.Client
+	var err error
+	if address != "" {
+		client, err = newReattachClient(address, tlsCurve, logger, nil)
+	} else {
+		client, _, err = newSpawnedRPCClient(logger, serverArgs, nil, nil, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	rpcClient, err := client.Client()
+	if err != nil {
+		client.Kill()
+		return nil, fmt.Errorf("failed to create RPC client: %w", err)
+	}
+	raw, err := rpcClient.Dispense("kv_grpc")
+	if err != nil {
+		client.Kill()
+		return nil, fmt.Errorf("failed to dispense plugin: %w", err)
+	}
+	p.stats.Dials++
+	p.stats.DialMs += float64(time.Since(start).Microseconds()) / 1000
+
+	pooled := &pooledClient{client: client, protocol: rpcClient, kv: raw.(kvplugin.KV), reattached: address != ""}
+	p.clients[key] = pooled
+	logger.Debug("🔌🆕 pooled new client connection", "address", address, "dials", p.stats.Dials)
+	return pooled.kv, nil
+}
+
+// Discard closes the pooled connection to a server, so the next Get
+// connects again
+func (p *clientPool) Discard(address, tlsCurve string, serverArgs []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.discard(clientPoolKey(address, tlsCurve, serverArgs))
+}
+
+func (p *clientPool) discard(key string) {
+	if pooled, ok := p.clients[key]; ok {
+		pooled.close()
+		delete(p.clients, key)
+		p.stats.Discards++
+	}
+}
+
+// Stats returns the pool's counters
+func (p *clientPool) Stats() clientPoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stats
+}
+
+// Close closes every pooled connection, killing spawned servers and
+// leaving reattached ones running
+func (p *clientPool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key, pooled := range p.clients {
+		pooled.close()
+		delete(p.clients, key)
+	}
+}
+
+// kvImportEntry is one line of a `kv import` file
+type kvImportEntry struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// kvImportReport summarizes a `kv import` run
+type kvImportReport struct {
+	Imported  int             `json:"imported"`
+	Failed    int             `json:"failed"`
+	Errors    []string        `json:"errors,omitempty"`
+	ElapsedMs float64         `json:"elapsed_ms"`
+	OpsPerSec float64         `json:"ops_per_sec"`
+	Pool      clientPoolStats `json:"pool"`
+}
+
+// initKVImportCmd creates the `rpc kv import` command
+func initKVImportCmd() *cobra.Command {
+	var (
+		address  string
+		tlsCurve string
+		noReuse  bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "import [file]",
+		Short: "Put every key of a JSONL file into the RPC KV server",
+		Long: `Put the entries of a JSONL file ("-" for stdin), one {"key": ..., "value": ...}
+object per line, into the server at --address or a spawned server. All Puts
+share one pooled connection; --no-reuse connects again for every entry, as
+separate put commands would, to measure what the pool saves. The report
+includes the pool's dial and reuse counts.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var r io.Reader = os.Stdin
+			if args[0] != "-" {
+				f, err := os.Open(args[0])
+				if err != nil {
+					return fmt.Errorf("failed to open import file: %w", err)
+				}
+				defer f.Close()
+				r = f
+			}
+
+			pool := newClientPool()
+			defer pool.Close()
+
+			report := &kvImportReport{}
+			start := time.Now()
+			scanner := bufio.NewScanner(r)
+			scanner.Buffer(make([]byte, 0, 64*1024), maxBatchLineSize)
+			for line := 1; scanner.Scan(); line++ {
+				text := strings.TrimSpace(scanner.Text())
+				if text == "" {
+					continue
+				}
+				var entry kvImportEntry
+				if err := json.Unmarshal([]byte(text), &entry); err != nil || entry.Key == "" {
+					report.Failed++
+					report.Errors = append(report.Errors, fmt.Sprintf("line %d: invalid entry", line))
+					continue
+				}
+
+				kv, err := pool.Get(address, tlsCurve, nil)
+				if err != nil {
+					return err
+				}
+				if err := kv.Put(entry.Key, []byte(entry.Value)); err != nil {
+					report.Failed++
+					report.Errors = append(report.Errors, fmt.Sprintf("line %d: failed to put key %s: %s", line, entry.Key, err))
+				} else {
+					report.Imported++
+				}
+				if noReuse {
+					pool.Discard(address, tlsCurve, nil)
+				}
+			}
+			if err := scanner.Err(); err != nil {
+				return fmt.Errorf("failed to read import file: %w", err)
+			}
+
+			elapsed := time.Since(start)
+			report.ElapsedMs = float64(elapsed.Microseconds()) / 1000
+			if elapsed > 0 {
+				report.OpsPerSec = float64(report.Imported) / elapsed.Seconds()
+			}
+			report.Pool = pool.Stats()
+			if err := json.NewEncoder(os.Stdout).Encode(report); err != nil {
+				return fmt.Errorf("failed to encode JSON: %w", err)
+			}
+			if report.Failed > 0 {
+				return fmt.Errorf("%d entries failed to import", report.Failed)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&address, "address", "", "Address or handshake line of an existing server (default: spawn PLUGIN_SERVER_PATH)")
+	cmd.Flags().StringVar(&tlsCurve, "tls-curve", "auto", "Client cert curve: auto (detect from server), secp256r1, secp384r1, secp521r1")
+	cmd.Flags().BoolVar(&noReuse, "no-reuse", false, "Connect again for every entry instead of reusing the pooled connection")
+	return cmd
+}
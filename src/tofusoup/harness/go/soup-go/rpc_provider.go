@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/spf13/cobra"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// initProviderUpgradeStateCmd creates the `rpc provider upgrade-state` command
+func initProviderUpgradeStateCmd() *cobra.Command {
+	var (
+		schemaPath     string
+		typeName       string
+		rawStateFormat string
+		stateVersion   int64
+		schemaVersion  int64
+	)
+
+	cmd := &cobra.Command{
+		Use:   "upgrade-state [raw-state-file]",
+		Short: "Exercise UpgradeResourceState with JSON or flatmap raw state",
+		Long: `Send a stored raw state (JSON or legacy flatmap) through the mock provider's
+UpgradeResourceState RPC and report the upgraded state as decoded by the schema.
+The raw state file for --raw-state-format flatmap is a JSON object of string values.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			schema, err := loadResourceSchema(schemaPath)
+			if err != nil {
+				return err
+			}
+			if cmd.Flags().Changed("schema-version") {
+				schema.Version = schemaVersion
+			}
+
+			provider := NewMockProvider(logger.Named("provider"))
+			if err := provider.AddResource(typeName, schema); err != nil {
+				return err
+			}
+
+			// Read raw state
+			var rawData []byte
+			if args[0] == "-" {
+				rawData, err = io.ReadAll(os.Stdin)
+			} else {
+				rawData, err = os.ReadFile(args[0])
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read raw state: %w", err)
+			}
+
+			rawState := &tfprotov6.RawState{}
+			switch rawStateFormat {
+			case "json":
+				rawState.JSON = rawData
+			case "flatmap":
+				if err := json.Unmarshal(rawData, &rawState.Flatmap); err != nil {
+					return fmt.Errorf("failed to parse flatmap raw state: %w", err)
+				}
+			default:
+				return fmt.Errorf("unsupported raw state format: %s", rawStateFormat)
+			}
+
+			logger.Debug("sending UpgradeResourceState",
+				"type_name", typeName,
+				"state_version", stateVersion,
+				"schema_version", schema.Version,
+				"raw_state_format", rawStateFormat)
+
+			resp, err := provider.UpgradeResourceState(context.Background(), &tfprotov6.UpgradeResourceStateRequest{
+				TypeName: typeName,
+				Version:  stateVersion,
+				RawState: rawState,
+			})
+			if err != nil {
+				return fmt.Errorf("UpgradeResourceState failed: %w", err)
+			}
+
+			result := map[string]interface{}{
+				"type_name":        typeName,
+				"state_version":    stateVersion,
+				"schema_version":   schema.Version,
+				"raw_state_format": rawStateFormat,
+				"success":          !protoDiagnosticsHaveErrors(resp.Diagnostics),
+				"diagnostics":      protoDiagnosticsToJSON(resp.Diagnostics),
+			}
+
+			if resp.UpgradedState != nil {
+				ty, err := schema.ImpliedType()
+				if err != nil {
+					return err
+				}
+				value, err := dynamicValueToCty(resp.UpgradedState, ty)
+				if err != nil {
+					return fmt.Errorf("failed to decode upgraded state: %w", err)
+				}
+				stateJSON, err := ctyjson.Marshal(value, ty)
+				if err != nil {
+					return fmt.Errorf("failed to marshal upgraded state: %w", err)
+				}
+				result["upgraded_state"] = json.RawMessage(stateJSON)
+				result["upgraded_state_msgpack"] = base64.StdEncoding.EncodeToString(resp.UpgradedState.MsgPack)
+			}
+
+			if err := json.NewEncoder(os.Stdout).Encode(result); err != nil {
+				return fmt.Errorf("failed to encode JSON: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&schemaPath, "schema", "", "Path to resource schema JSON (terraform providers schema -json format)")
+	cmd.Flags().StringVar(&typeName, "type-name", "tofusoup_resource", "Resource type name")
+	cmd.Flags().StringVar(&rawStateFormat, "raw-state-format", "json", "Raw state encoding (json, flatmap)")
+	cmd.Flags().Int64Var(&stateVersion, "version", 0, "Schema version the raw state was stored with")
+	cmd.Flags().Int64Var(&schemaVersion, "schema-version", 0, "Override the schema version declared in the schema file")
+	cmd.MarkFlagRequired("schema")
+
+	return cmd
+}
+
+// initProviderSimulateCmd creates the `rpc provider simulate` command
+func initProviderSimulateCmd() *cobra.Command {
+	var (
+		configPath   string
+		schemaPath   string
+		typeName     string
+		providerPath string
+		providerArgs []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "simulate",
+		Short: "Drive a Validate/Plan/Apply lifecycle against a provider",
+		Long: `Decode every resource of --type-name in --config against --schema and drive
+ValidateResourceConfig, PlanResourceChange and ApplyResourceChange for it as
+Terraform core would when creating the resource. Each hop checks that values
+survive the msgpack encoding and that the provider keeps configured values in
+its plan and planned values in its new state.
+
+By default the in-process mock provider is used. With --provider, the given
+executable is launched with the terraform handshake instead, e.g.
+  --provider ./soup-go --provider-arg=rpc --provider-arg=kv --provider-arg=server \
+  --provider-arg=--handshake-profile=terraform \
+  --provider-arg=--provider-schema=tofusoup_resource=schema.json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			schema, err := loadResourceSchema(schemaPath)
+			if err != nil {
+				return err
+			}
+			resources, err := loadSimulationResources(configPath, typeName, schema)
+			if err != nil {
+				return err
+			}
+			if len(resources) == 0 {
+				return fmt.Errorf("no %q resources found in %s", typeName, configPath)
+			}
+
+			var provider ProviderLifecycle
+			providerName := "local"
+			if providerPath == "" {
+				mock := NewMockProvider(logger.Named("provider"))
+				if err := mock.AddResource(typeName, schema); err != nil {
+					return err
+				}
+				provider = mock
+			} else {
+				providerName = providerPath
+				client, err := newProviderPluginClient(providerPath, providerArgs, logger.Named("plugin"))
+				if err != nil {
+					return err
+				}
+				defer client.Kill()
+
+				rpcClient, err := client.Client()
+				if err != nil {
+					return fmt.Errorf("failed to create RPC client: %w", err)
+				}
+				raw, err := rpcClient.Dispense("provider")
+				if err != nil {
+					return fmt.Errorf("failed to dispense plugin: %w", err)
+				}
+				provider = raw.(ProviderLifecycle)
+			}
+
+			failed := 0
+			results := make([]map[string]interface{}, 0, len(resources))
+			for _, res := range resources {
+				logger.Debug("simulating resource lifecycle", "address", res.Address, "provider", providerName)
+				hops, ok := simulateLifecycle(context.Background(), provider, typeName, schema, res.Config)
+				if !ok {
+					failed++
+				}
+				results = append(results, map[string]interface{}{
+					"address": res.Address,
+					"success": ok,
+					"hops":    hops,
+				})
+			}
+
+			output := map[string]interface{}{
+				"config":    configPath,
+				"type_name": typeName,
+				"provider":  providerName,
+				"success":   failed == 0,
+				"resources": results,
+			}
+			if err := json.NewEncoder(os.Stdout).Encode(output); err != nil {
+				return fmt.Errorf("failed to encode JSON: %w", err)
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d of %d resources failed simulation", failed, len(resources))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&configPath, "config", "", "Path to a .tf or .tf.json configuration file")
+	cmd.Flags().StringVar(&schemaPath, "schema", "", "Path to resource schema JSON (terraform providers schema -json format)")
+	cmd.Flags().StringVar(&typeName, "type-name", "tofusoup_resource", "Resource type name")
+	cmd.Flags().StringVar(&providerPath, "provider", "", "Provider executable to launch instead of the in-process mock provider")
+	cmd.Flags().StringArrayVar(&providerArgs, "provider-arg", nil, "Argument passed to the provider executable (repeatable)")
+	cmd.MarkFlagRequired("config")
+	cmd.MarkFlagRequired("schema")
+
+	return cmd
+}
+
+// protoDiagnosticsToJSON converts tfprotov6 diagnostics to JSON
+func protoDiagnosticsToJSON(diags []*tfprotov6.Diagnostic) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(diags))
+	for _, diag := range diags {
+		severityStr := "error"
+		switch diag.Severity {
+		case tfprotov6.DiagnosticSeverityWarning:
+			severityStr = "warning"
+		case tfprotov6.DiagnosticSeverityInvalid:
+			severityStr = "invalid"
+		}
+		d := map[string]interface{}{
+			"severity": severityStr,
+			"summary":  diag.Summary,
+			"detail":   diag.Detail,
+		}
+		if diag.Attribute != nil {
+			if path, err := attributePathToCtyPath(diag.Attribute); err == nil {
+				d["attribute"] = formatCtyPath(path)
+			}
+		}
+		result = append(result, d)
+	}
+	return result
+}
+
+// protoDiagnosticsHaveErrors reports whether any diagnostic is an error
+func protoDiagnosticsHaveErrors(diags []*tfprotov6.Diagnostic) bool {
+	for _, diag := range diags {
+		if diag.Severity == tfprotov6.DiagnosticSeverityError {
+			return true
+		}
+	}
+	return false
+}
@@ -7,13 +7,12 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
 	"github.com/hashicorp/go-hclog"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 
-	proto "github.com/provide-io/tofusoup/proto/kv"
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/kvplugin"
 )
 
 func startRPCServer(logger hclog.Logger, port int, tlsMode, tlsKeyType, tlsCurve, certFile, keyFile string) error {
@@ -36,9 +35,16 @@ func startRPCServer(logger hclog.Logger, port int, tlsMode, tlsKeyType, tlsCurve
 	os.Setenv("TLS_CURVE", tlsCurve)
 
 	// Create KV implementation with XDG-compliant storage directory
-	storageDir := GetKVStorageDir()
+	storageDir := kvplugin.StorageDir()
 	logger.Info("📂 Using KV storage directory", "path", storageDir)
-	kv := NewKVImpl(logger.Named("kv"), storageDir)
+	impl := kvplugin.NewKVImpl(logger.Named("kv"), storageDir)
+	impl.SetChecksumMode(rpcChecksumMode)
+	impl.SetLockPolicy(rpcLockTimeout, rpcLockBackoff, rpcLockMaxBackoff)
+	impl.SetMmapThreshold(rpcMmapThreshold)
+	var kv kvplugin.KV = impl
+	if rpcCacheSize > 0 {
+		kv = kvplugin.NewCachingKV(kv, rpcCacheSize)
+	}
 
 	// Create gRPC server
 	var serverOpts []grpc.ServerOption
@@ -90,11 +96,13 @@ func startRPCServer(logger hclog.Logger, port int, tlsMode, tlsKeyType, tlsCurve
 	// Create the gRPC server
 	grpcServer := grpc.NewServer(serverOpts...)
 
-	// Register our KV service
-	proto.RegisterKVServer(grpcServer, &GRPCServer{
-		Impl:      kv,
-		logger:    logger,
-		startTime: time.Now(),
+	// Register every KV API version
+	kvplugin.RegisterKVServices(grpcServer, kv, logger, rpcStreamChunkSize)
+
+	// Register the controller so hosts can stop the server with Shutdown
+	grpcServer.RegisterService(&grpcControllerServiceDesc, &standaloneController{
+		server: grpcServer,
+		logger: logger,
 	})
 
 	// Start listening
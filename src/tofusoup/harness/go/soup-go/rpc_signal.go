@@ -0,0 +1,397 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/kvplugin"
+)
+
+// Sides of a plugin connection `rpc kv signal` can signal
+const (
+	signalTargetServer = "server"
+	signalTargetClient = "client"
+)
+
+// signalProbePrefix is the key prefix of the signal workload; it writes the
+// single key signalProbePrefix+"0", as soak with --keys 1 does
+const signalProbePrefix = "__signal_probe_"
+
+// signalsByName are the signals `rpc kv signal` delivers
+var signalsByName = map[string]os.Signal{
+	"INT":  os.Interrupt,
+	"TERM": syscall.SIGTERM,
+	"KILL": os.Kill,
+}
+
+// kvSignalOptions configures `rpc kv signal`
+type kvSignalOptions struct {
+	targets   []string
+	signals   []string
+	settle    time.Duration
+	timeout   time.Duration
+	valueSize int
+}
+
+// kvSignalResult records how one target and signal combination went
+type kvSignalResult struct {
+	Target    string `json:"target"`
+	Signal    string `json:"signal"`
+	ServerPID int    `json:"server_pid"`
+	// TargetExited is whether the signalled process exited within the
+	// timeout; go-plugin servers ignore SIGINT, leaving it to their host
+	TargetExited bool    `json:"target_exited"`
+	ExitMs       float64 `json:"exit_ms,omitempty"`
+	// ServerOrphaned is whether the server outlived its client
+	ServerOrphaned bool `json:"server_orphaned"`
+	LockReleased   bool `json:"lock_released"`
+	// Consistent is whether the probe key holds a complete value that
+	// passes its checksum, or no value at all
+	Consistent bool `json:"consistent"`
+	// TempFiles counts temporary files left by interrupted Puts
+	TempFiles int    `json:"temp_files"`
+	Error     string `json:"error,omitempty"`
+	Passed    bool   `json:"passed"`
+}
+
+// runKVSignal runs every target and signal combination, each against a
+// fresh storage directory
+func runKVSignal(opts kvSignalOptions) ([]*kvSignalResult, error) {
+	results := []*kvSignalResult{}
+	for _, target := range opts.targets {
+		for _, name := range opts.signals {
+			result, err := runKVSignalCase(target, name, opts)
+			if err != nil {
+				return nil, err
+			}
+			logger.Info("🚦📊 signal case complete",
+				"target", target,
+				"signal", name,
+				"target_exited", result.TargetExited,
+				"server_orphaned", result.ServerOrphaned,
+				"passed", result.Passed)
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+// runKVSignalCase signals one side of a plugin connection under a Put
+// workload and then inspects the processes and the store it leaves behind
+func runKVSignalCase(target, name string, opts kvSignalOptions) (*kvSignalResult, error) {
+	result := &kvSignalResult{Target: target, Signal: name}
+
+	storageDir, err := os.MkdirTemp("", "soup-signal-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	defer os.RemoveAll(storageDir)
+	// Spawned servers, and the client spawned for the client target, find
+	// the store through the environment
+	previousDir, hadDir := os.LookupEnv(kvplugin.EnvKVStorageDir)
+	os.Setenv(kvplugin.EnvKVStorageDir, storageDir)
+	defer func() {
+		if hadDir {
+			os.Setenv(kvplugin.EnvKVStorageDir, previousDir)
+		} else {
+			os.Unsetenv(kvplugin.EnvKVStorageDir)
+		}
+	}()
+
+	var caseErr error
+	switch target {
+	case signalTargetServer:
+		caseErr = signalServer(result, signalsByName[name], opts)
+	case signalTargetClient:
+		caseErr = signalClient(result, signalsByName[name], opts)
+	}
+	if caseErr != nil {
+		result.Error = caseErr.Error()
+	}
+
+	checkSignalledStore(result, storageDir, opts)
+	result.Passed = result.Error == "" && !result.ServerOrphaned && result.LockReleased && result.Consistent
+	return result, nil
+}
+
+// signalServer spawns a server, signals it while this process writes to it,
+// and checks the server is gone once its client has cleaned up
+func signalServer(result *kvSignalResult, sig os.Signal, opts kvSignalOptions) error {
+	client, serverCmd, err := newSpawnedRPCClient(logger, nil, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer client.Kill()
+
+	rpcClient, err := client.Client()
+	if err != nil {
+		return fmt.Errorf("failed to create RPC client: %w", err)
+	}
+	result.ServerPID = serverCmd.Process.Pid
+	raw, err := rpcClient.Dispense("kv_grpc")
+	if err != nil {
+		return fmt.Errorf("failed to dispense plugin: %w", err)
+	}
+	kv := raw.(kvplugin.KV)
+
+	// Put until the server stops answering; failures are expected once
+	// it is signalled
+	var stop atomic.Bool
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		value := soakValue(opts.valueSize)
+		for !stop.Load() {
+			if err := kv.Put(signalProbePrefix+"0", value); err != nil && client.Exited() {
+				return
+			}
+		}
+	}()
+
+	time.Sleep(opts.settle)
+	signalled := time.Now()
+	if err := serverCmd.Process.Signal(sig); err != nil {
+		stop.Store(true)
+		<-done
+		return fmt.Errorf("failed to signal server: %w", err)
+	}
+	if waitFor(opts.timeout, client.Exited) {
+		result.TargetExited = true
+		result.ExitMs = float64(time.Since(signalled).Microseconds()) / 1000
+	}
+	stop.Store(true)
+	<-done
+
+	client.Kill()
+	result.ServerOrphaned = !waitFor(opts.timeout, func() bool { return !procAlive(result.ServerPID) })
+	return nil
+}
+
+// signalClient runs a soak client, which spawns its own server, signals the
+// client and checks the server does not outlive it. Orphaned servers are
+// killed once recorded.
+func signalClient(result *kvSignalResult, sig os.Signal, opts kvSignalOptions) error {
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate soup-go: %w", err)
+	}
+	child := exec.Command(self, "rpc", "kv", "soak",
+		"--duration", "24h",
+		"--warmup", "0s",
+		"--sample-interval", "24h",
+		"--keys", "1",
+		"--key-prefix", signalProbePrefix,
+		"--value-size", strconv.Itoa(opts.valueSize),
+		"--write-ratio", "1",
+		"--stream-ratio", "0",
+		"--goroutines=false",
+		"--log-level", "error")
+	if err := child.Start(); err != nil {
+		return fmt.Errorf("failed to start client: %w", err)
+	}
+	exited := make(chan struct{})
+	go func() {
+		child.Wait()
+		close(exited)
+	}()
+	defer func() {
+		child.Process.Kill()
+		<-exited
+	}()
+
+	if !waitFor(opts.timeout, func() bool {
+		pids := childPIDs(child.Process.Pid)
+		if len(pids) > 0 {
+			result.ServerPID = pids[0]
+		}
+		return result.ServerPID != 0
+	}) {
+		return fmt.Errorf("client did not spawn a server within %s", opts.timeout)
+	}
+
+	time.Sleep(opts.settle)
+	signalled := time.Now()
+	if err := child.Process.Signal(sig); err != nil {
+		return fmt.Errorf("failed to signal client: %w", err)
+	}
+	select {
+	case <-exited:
+		result.TargetExited = true
+		result.ExitMs = float64(time.Since(signalled).Microseconds()) / 1000
+	case <-time.After(opts.timeout):
+		// Kill the client so its server is judged like any other orphan
+		child.Process.Kill()
+		<-exited
+	}
+
+	if !waitFor(opts.timeout, func() bool { return !procAlive(result.ServerPID) }) {
+		result.ServerOrphaned = true
+		logger.Warn("🚦⚠️ server outlived its client, killing it", "server_pid", result.ServerPID, "signal", sig)
+		if p, err := os.FindProcess(result.ServerPID); err == nil {
+			p.Kill()
+		}
+		waitFor(opts.timeout, func() bool { return !procAlive(result.ServerPID) })
+	}
+	return nil
+}
+
+// checkSignalledStore opens the store the signalled processes used and
+// checks the probe key's lock can be taken and its value is intact
+func checkSignalledStore(result *kvSignalResult, storageDir string, opts kvSignalOptions) {
+	impl := kvplugin.NewKVImpl(logger.Named("kv"), storageDir)
+	impl.SetChecksumMode(kvplugin.ChecksumError)
+	impl.SetLockPolicy(opts.timeout, kvplugin.DefaultLockBackoff, 100*time.Millisecond)
+
+	key := signalProbePrefix + "0"
+	value, err := impl.Get(key)
+	switch {
+	case kvplugin.IsLockTimeout(err):
+		// Neither readable nor verifiable while the lock is held
+	case os.IsNotExist(err):
+		// Signalled before the first Put completed
+		result.LockReleased = true
+		result.Consistent = true
+	case err != nil:
+		result.LockReleased = true
+	default:
+		result.LockReleased = true
+		result.Consistent = bytes.Equal(value, soakValue(opts.valueSize))
+	}
+	if result.LockReleased {
+		// A Put takes the lock exclusively, which a shared Get does not test
+		if err := impl.Put(key, soakValue(opts.valueSize)); kvplugin.IsLockTimeout(err) {
+			result.LockReleased = false
+		}
+	}
+
+	filepath.Walk(storageDir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() && strings.Contains(info.Name(), ".tmp-") {
+			result.TempFiles++
+		}
+		return nil
+	})
+}
+
+// waitFor polls cond until it holds or timeout passes
+func waitFor(timeout time.Duration, cond func() bool) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		if cond() {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// procAlive reports whether pid is running, counting zombies as exited
+func procAlive(pid int) bool {
+	data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "status"))
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if state, ok := strings.CutPrefix(line, "State:"); ok {
+			return !strings.HasPrefix(strings.TrimSpace(state), "Z")
+		}
+	}
+	return true
+}
+
+// childPIDs lists the running children of ppid
+func childPIDs(ppid int) []int {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil
+	}
+	var pids []int
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		if procStatusValue(pid, "PPid") == int64(ppid) && procAlive(pid) {
+			pids = append(pids, pid)
+		}
+	}
+	return pids
+}
+
+// initKVSignalCmd creates the `rpc kv signal` command
+func initKVSignalCmd() *cobra.Command {
+	var opts kvSignalOptions
+
+	cmd := &cobra.Command{
+		Use:   "signal",
+		Short: "Signal plugin clients and servers mid-workload and check cleanup",
+		Long: `For every --target and --signal, spawn the KV server named by
+PLUGIN_SERVER_PATH under a Put workload, deliver the signal and inspect what is
+left, each time with a fresh storage directory.
+
+With target server, this process is the client and signals the server. With
+target client, a 'rpc kv soak' client is started, which spawns its own server,
+and the client is signalled. A case fails if a server outlives its client,
+the probe key's lock cannot be taken afterwards, or its value is torn or fails
+its checksum. Whether the signalled process exited is reported but not
+required: go-plugin servers ignore SIGINT, leaving shutdown to their host.
+
+Process state is read from /proc, so the command needs Linux.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := os.Stat("/proc/self/status"); err != nil {
+				return fmt.Errorf("rpc kv signal reads process state from /proc, which is unavailable")
+			}
+			for _, target := range opts.targets {
+				if target != signalTargetServer && target != signalTargetClient {
+					return fmt.Errorf("invalid target %q: expected server or client", target)
+				}
+			}
+			for i, name := range opts.signals {
+				name = strings.TrimPrefix(strings.ToUpper(name), "SIG")
+				if _, ok := signalsByName[name]; !ok {
+					return fmt.Errorf("invalid signal %q: expected INT, TERM or KILL", opts.signals[i])
+				}
+				opts.signals[i] = name
+			}
+
+			results, err := runKVSignal(opts)
+			if err != nil {
+				return err
+			}
+			if err := json.NewEncoder(os.Stdout).Encode(map[string]interface{}{"results": results}); err != nil {
+				return fmt.Errorf("failed to encode JSON: %w", err)
+			}
+
+			var failed []string
+			for _, result := range results {
+				if !result.Passed {
+					failed = append(failed, result.Target+"/"+result.Signal)
+				}
+			}
+			if len(failed) > 0 {
+				return fmt.Errorf("signal cases failed: %s", strings.Join(failed, ", "))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&opts.targets, "target", []string{signalTargetServer, signalTargetClient}, "Side to signal: server, client (repeatable)")
+	cmd.Flags().StringSliceVar(&opts.signals, "signal", []string{"INT", "TERM", "KILL"}, "Signals to deliver: INT, TERM, KILL (repeatable)")
+	cmd.Flags().DurationVar(&opts.settle, "settle", 500*time.Millisecond, "How long the workload runs before the signal")
+	cmd.Flags().DurationVar(&opts.timeout, "timeout", 5*time.Second, "How long to wait for processes to exit and locks to free")
+	cmd.Flags().IntVar(&opts.valueSize, "value-size", 64*1024, "Size of each value written")
+	return cmd
+}
@@ -0,0 +1,406 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/kvplugin"
+)
+
+// kvSoakSample is one measurement of the server during a soak run. Process
+// figures are read from /proc and are -1 where it is not available;
+// goroutines are -1 unless sampled from the server's pprof endpoint.
+type kvSoakSample struct {
+	ElapsedSec   float64 `json:"elapsed_sec"`
+	Ops          int64   `json:"ops"`
+	RSSBytes     int64   `json:"rss_bytes"`
+	Threads      int64   `json:"threads"`
+	FDs          int64   `json:"fds"`
+	Goroutines   int64   `json:"goroutines"`
+	StorageBytes int64   `json:"storage_bytes"`
+	StorageFiles int64   `json:"storage_files"`
+}
+
+// kvSoakThresholds bounds how much the server may grow between the
+// baseline and the final sample. Zero disables a check.
+type kvSoakThresholds struct {
+	rssBytes     int64
+	goroutines   int64
+	fds          int64
+	storageBytes int64
+}
+
+// kvSoakViolation is a resource that grew past its threshold
+type kvSoakViolation struct {
+	Resource  string `json:"resource"`
+	Baseline  int64  `json:"baseline"`
+	Final     int64  `json:"final"`
+	Growth    int64  `json:"growth"`
+	Threshold int64  `json:"threshold"`
+}
+
+// kvSoakReport is the output of rpc kv soak
+type kvSoakReport struct {
+	Duration   string            `json:"duration"`
+	ServerPID  int               `json:"server_pid"`
+	Puts       int64             `json:"puts"`
+	Gets       int64             `json:"gets"`
+	Streams    int64             `json:"streams"`
+	Errors     int64             `json:"errors"`
+	LastError  string            `json:"last_error,omitempty"`
+	Baseline   *kvSoakSample     `json:"baseline"`
+	Final      *kvSoakSample     `json:"final"`
+	Samples    []*kvSoakSample   `json:"samples"`
+	Violations []kvSoakViolation `json:"violations"`
+	Passed     bool              `json:"passed"`
+}
+
+// kvSoakOptions configures a soak run
+type kvSoakOptions struct {
+	duration       time.Duration
+	warmup         time.Duration
+	sampleInterval time.Duration
+	keyPrefix      string
+	keys           int
+	valueSize      int
+	writeRatio     float64
+	streamRatio    float64
+	seed           int64
+	rate           float64
+	goroutines     bool
+	thresholds     kvSoakThresholds
+}
+
+// freeLocalPort returns a localhost TCP port that is free at the time of
+// the call
+func freeLocalPort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+// procStatusValue returns a numeric field of /proc/<pid>/status, such as
+// VmRSS (in kB) or Threads, or -1 if it cannot be read
+func procStatusValue(pid int, field string) int64 {
+	f, err := os.Open(filepath.Join("/proc", strconv.Itoa(pid), "status"))
+	if err != nil {
+		return -1
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		name, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok || name != field {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			return -1
+		}
+		n, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return -1
+		}
+		return n
+	}
+	return -1
+}
+
+// procFDCount returns the number of open file descriptors of pid, or -1
+// if they cannot be listed
+func procFDCount(pid int) int64 {
+	entries, err := os.ReadDir(filepath.Join("/proc", strconv.Itoa(pid), "fd"))
+	if err != nil {
+		return -1
+	}
+	return int64(len(entries))
+}
+
+// pprofGoroutines reads the goroutine count from a pprof endpoint, or -1
+// if it cannot be read
+func pprofGoroutines(client *http.Client, port int) int64 {
+	resp, err := client.Get(fmt.Sprintf("http://127.0.0.1:%d/debug/pprof/goroutine?debug=1", port))
+	if err != nil {
+		return -1
+	}
+	defer resp.Body.Close()
+	// The first line reads "goroutine profile: total N"
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return -1
+	}
+	_, total, ok := strings.Cut(strings.TrimSpace(line), "total ")
+	if !ok {
+		return -1
+	}
+	n, err := strconv.ParseInt(total, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// storageUsage returns the total size and number of files under dir
+func storageUsage(dir string) (int64, int64) {
+	var size, files int64
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			size += info.Size()
+			files++
+		}
+		return nil
+	})
+	return size, files
+}
+
+// checkSoakGrowth records a violation when a resource grew by more than
+// threshold. Unavailable measurements are not checked.
+func checkSoakGrowth(report *kvSoakReport, resource string, baseline, final, threshold int64) {
+	if threshold <= 0 || baseline < 0 || final < 0 {
+		return
+	}
+	if growth := final - baseline; growth > threshold {
+		report.Violations = append(report.Violations, kvSoakViolation{
+			Resource:  resource,
+			Baseline:  baseline,
+			Final:     final,
+			Growth:    growth,
+			Threshold: threshold,
+		})
+	}
+}
+
+// runKVSoak spawns a server and runs a mixed workload against it for the
+// soak duration, sampling its resources. Growth is measured from the first
+// sample after the warmup to the last sample.
+func runKVSoak(opts kvSoakOptions) (*kvSoakReport, error) {
+	var serverArgs []string
+	pprofPort := -1
+	if opts.goroutines {
+		port, err := freeLocalPort()
+		if err != nil {
+			return nil, fmt.Errorf("failed to pick a pprof port: %w", err)
+		}
+		pprofPort = port
+		serverArgs = append(serverArgs, "--pprof-port", strconv.Itoa(port))
+	}
+
+	client, serverCmd, err := newSpawnedRPCClient(logger, serverArgs, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Kill()
+
+	rpcClient, err := client.Client()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create RPC client: %w", err)
+	}
+	raw, err := rpcClient.Dispense("kv_grpc")
+	if err != nil {
+		return nil, fmt.Errorf("failed to dispense plugin: %w", err)
+	}
+	kv := raw.(kvplugin.KV)
+	streamer, _ := raw.(*kvplugin.GRPCClient)
+	pid := serverCmd.Process.Pid
+	storageDir := kvplugin.StorageDir()
+	httpClient := &http.Client{Timeout: 5 * time.Second}
+
+	report := &kvSoakReport{
+		Duration:   opts.duration.String(),
+		ServerPID:  pid,
+		Samples:    []*kvSoakSample{},
+		Violations: []kvSoakViolation{},
+	}
+
+	value := soakValue(opts.valueSize)
+	// Fill the key space first so every read finds a value
+	for i := 0; i < opts.keys; i++ {
+		if err := kv.Put(opts.keyPrefix+strconv.Itoa(i), value); err != nil {
+			return nil, fmt.Errorf("put failed: %w", err)
+		}
+	}
+
+	start := time.Now()
+	sample := func() *kvSoakSample {
+		s := &kvSoakSample{
+			ElapsedSec: time.Since(start).Seconds(),
+			Ops:        report.Puts + report.Gets + report.Streams,
+			Threads:    procStatusValue(pid, "Threads"),
+			FDs:        procFDCount(pid),
+			Goroutines: -1,
+			RSSBytes:   -1,
+		}
+		if rss := procStatusValue(pid, "VmRSS"); rss >= 0 {
+			s.RSSBytes = rss * 1024
+		}
+		if pprofPort > 0 {
+			s.Goroutines = pprofGoroutines(httpClient, pprofPort)
+		}
+		s.StorageBytes, s.StorageFiles = storageUsage(storageDir)
+		report.Samples = append(report.Samples, s)
+		logger.Info("🔥📊 soak sample",
+			"elapsed", time.Since(start).Round(time.Second),
+			"ops", s.Ops,
+			"rss_bytes", s.RSSBytes,
+			"fds", s.FDs,
+			"goroutines", s.Goroutines,
+			"storage_bytes", s.StorageBytes)
+		return s
+	}
+
+	rng := rand.New(rand.NewSource(opts.seed))
+	limiter := newTokenBucket(opts.rate, 1)
+	deadline := start.Add(opts.duration)
+	nextSample := start
+	warmupEnd := start.Add(opts.warmup)
+	for now := time.Now(); now.Before(deadline); now = time.Now() {
+		if !now.Before(nextSample) {
+			s := sample()
+			if report.Baseline == nil && !now.Before(warmupEnd) {
+				report.Baseline = s
+			}
+			nextSample = now.Add(opts.sampleInterval)
+		}
+
+		limiter.Wait()
+		key := opts.keyPrefix + strconv.Itoa(rng.Intn(opts.keys))
+		var opErr error
+		switch r := rng.Float64(); {
+		case r < opts.writeRatio:
+			report.Puts++
+			opErr = kv.Put(key, value)
+		case streamer != nil && r < opts.writeRatio+opts.streamRatio:
+			report.Streams++
+			_, opErr = streamer.GetStream(key, 0, io.Discard)
+		default:
+			report.Gets++
+			_, opErr = kv.Get(key)
+		}
+		if opErr != nil {
+			report.Errors++
+			report.LastError = opErr.Error()
+		}
+	}
+
+	report.Final = sample()
+	if report.Baseline == nil {
+		report.Baseline = report.Samples[0]
+	}
+	t := opts.thresholds
+	checkSoakGrowth(report, "rss_bytes", report.Baseline.RSSBytes, report.Final.RSSBytes, t.rssBytes)
+	checkSoakGrowth(report, "goroutines", report.Baseline.Goroutines, report.Final.Goroutines, t.goroutines)
+	checkSoakGrowth(report, "fds", report.Baseline.FDs, report.Final.FDs, t.fds)
+	checkSoakGrowth(report, "storage_bytes", report.Baseline.StorageBytes, report.Final.StorageBytes, t.storageBytes)
+	report.Passed = len(report.Violations) == 0 && report.Errors == 0
+	return report, nil
+}
+
+// soakValue returns the value soak writes to every key
+func soakValue(size int) []byte {
+	value := make([]byte, size)
+	for i := range value {
+		value[i] = byte('a' + i%26)
+	}
+	return value
+}
+
+// initKVSoakCmd creates the `rpc kv soak` command
+func initKVSoakCmd() *cobra.Command {
+	var (
+		opts         kvSoakOptions
+		rate         string
+		maxRSSMB     float64
+		maxStorageMB float64
+		maxRoutines  int64
+		maxFDs       int64
+	)
+
+	cmd := &cobra.Command{
+		Use:   "soak",
+		Short: "Run a long mixed workload against a spawned KV server and check it for leaks",
+		Long: `Spawn the KV server named by PLUGIN_SERVER_PATH and run a mix of Put, Get and
+GetStream calls against it for --duration, sampling the server's RSS, thread
+and file descriptor counts (from /proc), goroutine count (from its pprof
+endpoint) and the size of the storage directory every --sample-interval.
+
+Growth is measured from the first sample after --warmup to the final sample.
+The command fails if any call failed or a resource grew past its threshold.
+Goroutines are sampled by passing --pprof-port to the server; use
+--goroutines=false for servers without that flag, such as the Python server.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.keys <= 0 || opts.duration <= 0 || opts.sampleInterval <= 0 {
+				return fmt.Errorf("--keys, --duration and --sample-interval must be positive")
+			}
+			if opts.writeRatio < 0 || opts.streamRatio < 0 || opts.writeRatio+opts.streamRatio > 1 {
+				return fmt.Errorf("--write-ratio and --stream-ratio must be non-negative and sum to at most 1")
+			}
+			var err error
+			if opts.rate, err = parseRate(rate); err != nil {
+				return err
+			}
+			opts.thresholds = kvSoakThresholds{
+				rssBytes:     int64(maxRSSMB * (1 << 20)),
+				goroutines:   maxRoutines,
+				fds:          maxFDs,
+				storageBytes: int64(maxStorageMB * (1 << 20)),
+			}
+
+			report, err := runKVSoak(opts)
+			if err != nil {
+				return err
+			}
+			if err := json.NewEncoder(os.Stdout).Encode(report); err != nil {
+				return fmt.Errorf("failed to encode JSON: %w", err)
+			}
+
+			if report.Errors > 0 {
+				return fmt.Errorf("%d calls failed, last: %s", report.Errors, report.LastError)
+			}
+			if len(report.Violations) > 0 {
+				resources := make([]string, 0, len(report.Violations))
+				for _, v := range report.Violations {
+					resources = append(resources, v.Resource)
+				}
+				return fmt.Errorf("server grew past leak thresholds: %s", strings.Join(resources, ", "))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().DurationVar(&opts.duration, "duration", 10*time.Minute, "How long to run the workload, e.g. 2h")
+	cmd.Flags().DurationVar(&opts.warmup, "warmup", 30*time.Second, "Time before the baseline sample, letting caches and pools fill")
+	cmd.Flags().DurationVar(&opts.sampleInterval, "sample-interval", 10*time.Second, "Time between resource samples")
+	cmd.Flags().StringVar(&opts.keyPrefix, "key-prefix", "soak-", "Prefix of the keys written")
+	cmd.Flags().IntVar(&opts.keys, "keys", 100, "Number of distinct keys")
+	cmd.Flags().IntVar(&opts.valueSize, "value-size", 1024, "Size of each value in bytes")
+	cmd.Flags().Float64Var(&opts.writeRatio, "write-ratio", 0.2, "Fraction of calls that are Puts")
+	cmd.Flags().Float64Var(&opts.streamRatio, "stream-ratio", 0.1, "Fraction of calls that are GetStreams; the rest are Gets")
+	cmd.Flags().Int64Var(&opts.seed, "seed", 1, "Seed for the workload sequence")
+	cmd.Flags().StringVar(&rate, "rate", "", "Pace calls to this rate, e.g. 500/s (default: as fast as possible)")
+	cmd.Flags().BoolVar(&opts.goroutines, "goroutines", true, "Sample the server's goroutine count through --pprof-port (Go servers only)")
+	cmd.Flags().Float64Var(&maxRSSMB, "max-rss-growth-mb", 128, "Fail if server RSS grows by more than this many MiB (0 disables)")
+	cmd.Flags().Int64Var(&maxRoutines, "max-goroutine-growth", 100, "Fail if the server's goroutine count grows by more than this (0 disables)")
+	cmd.Flags().Int64Var(&maxFDs, "max-fd-growth", 50, "Fail if the server's open file descriptors grow by more than this (0 disables)")
+	cmd.Flags().Float64Var(&maxStorageMB, "max-storage-growth-mb", 0, "Fail if the storage directory grows by more than this many MiB (0 disables)")
+	return withProfiles(cmd)
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/kvplugin"
+)
+
+// stdioProbeKey is read by `rpc kv stdio` to make the server emit its lines
+const stdioProbeKey = "__stdio_probe_key__"
+
+// stdioPattern configures numbered lines a server writes to both stdout and
+// stderr at an interval while it serves
+type stdioPattern struct {
+	count    int
+	interval time.Duration
+}
+
+// stdioPatternLines returns the lines a pattern writes to stream
+func stdioPatternLines(stream string, count int) []string {
+	lines := make([]string, count)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("tofusoup stdio %s pattern %d/%d", stream, i+1, count)
+	}
+	return lines
+}
+
+// stdioEmittingKV wraps a KV implementation and writes configured lines to
+// stdout and stderr on the first request, followed by its pattern lines.
+// go-plugin only redirects the process's stdout and stderr into the
+// GRPCStdio stream once serving starts, so the lines are written lazily
+// rather than at startup.
+type stdioEmittingKV struct {
+	kvplugin.KV
+	once    sync.Once
+	stdout  []string
+	stderr  []string
+	pattern stdioPattern
+}
+
+// newStdioEmittingKV wraps kv so the given lines are emitted on first use
+func newStdioEmittingKV(kv kvplugin.KV, stdout, stderr []string, pattern stdioPattern) *stdioEmittingKV {
+	return &stdioEmittingKV{KV: kv, stdout: stdout, stderr: stderr, pattern: pattern}
+}
+
+func (k *stdioEmittingKV) emit() {
+	k.once.Do(func() {
+		for _, line := range k.stdout {
+			fmt.Fprintln(os.Stdout, line)
+		}
+		for _, line := range k.stderr {
+			fmt.Fprintln(os.Stderr, line)
+		}
+		if k.pattern.count > 0 {
+			go k.emitPattern()
+		}
+	})
+}
+
+// emitPattern writes one pattern line to each stream per interval
+func (k *stdioEmittingKV) emitPattern() {
+	stdoutLines := stdioPatternLines("stdout", k.pattern.count)
+	stderrLines := stdioPatternLines("stderr", k.pattern.count)
+	ticker := time.NewTicker(k.pattern.interval)
+	defer ticker.Stop()
+	for i := 0; i < k.pattern.count; i++ {
+		<-ticker.C
+		fmt.Fprintln(os.Stdout, stdoutLines[i])
+		fmt.Fprintln(os.Stderr, stderrLines[i])
+	}
+}
+
+func (k *stdioEmittingKV) Put(key string, value []byte) error {
+	k.emit()
+	return k.KV.Put(key, value)
+}
+
+func (k *stdioEmittingKV) Get(key string) ([]byte, error) {
+	k.emit()
+	return k.KV.Get(key)
+}
+
+func (k *stdioEmittingKV) GetMapped(key string) ([]byte, func(), error) {
+	k.emit()
+	return kvplugin.GetMapped(k.KV, key)
+}
+
+func (k *stdioEmittingKV) Delete(key string) (bool, error) {
+	k.emit()
+	return kvplugin.Delete(k.KV, key)
+}
+
+func (k *stdioEmittingKV) List(prefix string) ([]string, error) {
+	k.emit()
+	return kvplugin.List(k.KV, prefix)
+}
+
+// stdioLineCollector is an io.Writer that splits forwarded stdio data into
+// lines and lets callers wait for expected lines to arrive
+type stdioLineCollector struct {
+	mu      sync.Mutex
+	partial strings.Builder
+	lines   []string
+	notify  chan struct{}
+}
+
+func newStdioLineCollector() *stdioLineCollector {
+	return &stdioLineCollector{notify: make(chan struct{}, 1)}
+}
+
+func (c *stdioLineCollector) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, b := range p {
+		if b == '\n' {
+			c.lines = append(c.lines, strings.TrimSuffix(c.partial.String(), "\r"))
+			c.partial.Reset()
+			continue
+		}
+		c.partial.WriteByte(b)
+	}
+
+	select {
+	case c.notify <- struct{}{}:
+	default:
+	}
+	return len(p), nil
+}
+
+// Lines returns the complete lines received so far
+func (c *stdioLineCollector) Lines() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string{}, c.lines...)
+}
+
+// missing returns the expected lines that have not been received
+func (c *stdioLineCollector) missing(expected []string) []string {
+	received := make(map[string]int)
+	for _, line := range c.Lines() {
+		received[line]++
+	}
+	missing := []string{}
+	for _, line := range expected {
+		if received[line] > 0 {
+			received[line]--
+			continue
+		}
+		missing = append(missing, line)
+	}
+	return missing
+}
+
+// inOrder reports whether the expected lines that were received arrived in
+// the expected order
+func (c *stdioLineCollector) inOrder(expected []string) bool {
+	position := make(map[string]int, len(expected))
+	for i, line := range expected {
+		position[line] = i
+	}
+	last := -1
+	for _, line := range c.Lines() {
+		i, ok := position[line]
+		if !ok {
+			continue
+		}
+		if i < last {
+			return false
+		}
+		last = i
+	}
+	return true
+}
+
+// waitForStdioLines waits until every expected line has arrived on its
+// collector or the deadline passes, returning the lines still missing
+func waitForStdioLines(stdout, stderr *stdioLineCollector, expectStdout, expectStderr []string, timeout time.Duration) ([]string, []string) {
+	deadline := time.After(timeout)
+	for {
+		missingStdout := stdout.missing(expectStdout)
+		missingStderr := stderr.missing(expectStderr)
+		if len(missingStdout) == 0 && len(missingStderr) == 0 {
+			return missingStdout, missingStderr
+		}
+		select {
+		case <-stdout.notify:
+		case <-stderr.notify:
+		case <-deadline:
+			return missingStdout, missingStderr
+		}
+	}
+}
+
+// initKVStdioCmd creates the `rpc kv stdio` command
+func initKVStdioCmd() *cobra.Command {
+	var stdoutLines []string
+	var stderrLines []string
+	var emit bool
+	var timeout time.Duration
+	var pattern stdioPattern
+
+	cmd := &cobra.Command{
+		Use:   "stdio",
+		Short: "Verify plugin stdout/stderr forwarding over the GRPCStdio service",
+		Long: `Spawn the KV server named by PLUGIN_SERVER_PATH, make it write the given lines
+to its stdout and stderr, and assert that they arrive through go-plugin's
+GRPCStdio stream rather than the process pipes. With --emit=false the lines are
+only expected, for servers configured to write them by other means.
+
+With --pattern-count, the server also writes that many numbered lines to each
+stream, one per --pattern-interval, while it keeps serving; all of them must
+arrive, in order, covering forwarding of output written after startup.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var serverArgs []string
+			if emit {
+				for _, line := range stdoutLines {
+					serverArgs = append(serverArgs, "--emit-stdout", line)
+				}
+				for _, line := range stderrLines {
+					serverArgs = append(serverArgs, "--emit-stderr", line)
+				}
+			}
+			expectStdout, expectStderr := stdoutLines, stderrLines
+			if pattern.count > 0 {
+				if pattern.interval <= 0 {
+					return fmt.Errorf("--pattern-interval must be positive")
+				}
+				serverArgs = append(serverArgs,
+					"--emit-pattern-count", strconv.Itoa(pattern.count),
+					"--emit-pattern-interval", pattern.interval.String())
+				expectStdout = append(append([]string{}, stdoutLines...), stdioPatternLines("stdout", pattern.count)...)
+				expectStderr = append(append([]string{}, stderrLines...), stdioPatternLines("stderr", pattern.count)...)
+				// The pattern takes count intervals to be written
+				timeout += time.Duration(pattern.count) * pattern.interval
+			}
+
+			stdout := newStdioLineCollector()
+			stderr := newStdioLineCollector()
+			client, _, err := newSpawnedRPCClient(logger, serverArgs, stdout, stderr, nil)
+			if err != nil {
+				return err
+			}
+			defer client.Kill()
+
+			rpcClient, err := client.Client()
+			if err != nil {
+				return fmt.Errorf("failed to create RPC client: %w", err)
+			}
+			raw, err := rpcClient.Dispense("kv_grpc")
+			if err != nil {
+				return fmt.Errorf("failed to dispense plugin: %w", err)
+			}
+			kv := raw.(kvplugin.KV)
+
+			// Any request triggers the server's lines; the probe key never exists
+			if _, err := kv.Get(stdioProbeKey); err != nil && !strings.Contains(err.Error(), "key not found") {
+				return fmt.Errorf("probe request failed: %w", err)
+			}
+
+			missingStdout, missingStderr := waitForStdioLines(stdout, stderr, expectStdout, expectStderr, timeout)
+			passed := len(missingStdout) == 0 && len(missingStderr) == 0
+			inOrder := stdout.inOrder(expectStdout) && stderr.inOrder(expectStderr)
+
+			result := map[string]interface{}{
+				"passed":         passed,
+				"stdout_lines":   nonNilStrings(stdout.Lines()),
+				"stderr_lines":   nonNilStrings(stderr.Lines()),
+				"missing_stdout": missingStdout,
+				"missing_stderr": missingStderr,
+			}
+			if pattern.count > 0 {
+				result["pattern_in_order"] = inOrder
+				passed = passed && inOrder
+				result["passed"] = passed
+			}
+			if err := json.NewEncoder(os.Stdout).Encode(result); err != nil {
+				return fmt.Errorf("failed to encode JSON: %w", err)
+			}
+			if len(missingStdout) > 0 || len(missingStderr) > 0 {
+				return fmt.Errorf("%d stdout and %d stderr lines did not arrive over GRPCStdio within %s",
+					len(missingStdout), len(missingStderr), timeout)
+			}
+			if !passed {
+				return fmt.Errorf("pattern lines arrived over GRPCStdio out of order")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringArrayVar(&stdoutLines, "stdout-line", []string{"tofusoup stdio stdout probe"}, "Line expected on the forwarded stdout (repeatable)")
+	cmd.Flags().StringArrayVar(&stderrLines, "stderr-line", []string{"tofusoup stdio stderr probe"}, "Line expected on the forwarded stderr (repeatable)")
+	cmd.Flags().BoolVar(&emit, "emit", true, "Pass the lines to the server with --emit-stdout/--emit-stderr")
+	cmd.Flags().DurationVar(&timeout, "timeout", 10*time.Second, "How long to wait for the lines to arrive")
+	cmd.Flags().IntVar(&pattern.count, "pattern-count", 0, "Numbered lines the server writes to each stream while serving (0 disables)")
+	cmd.Flags().DurationVar(&pattern.interval, "pattern-interval", 100*time.Millisecond, "Interval between the server's pattern lines")
+	return cmd
+}
@@ -0,0 +1,420 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-plugin"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/kvplugin"
+	proto "github.com/provide-io/tofusoup/proto/kv"
+	kvv1 "github.com/provide-io/tofusoup/proto/kv/v1"
+	kvv2 "github.com/provide-io/tofusoup/proto/kv/v2"
+)
+
+// Outcomes of a version check
+const (
+	checkOK            = "ok"
+	checkUnimplemented = "unimplemented"
+	checkFailed        = "failed"
+)
+
+// versionCheck is the outcome of one call in `rpc kv versions`
+type versionCheck struct {
+	Check  string `json:"check"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// versionResult records which of a KV API version's methods a server serves
+type versionResult struct {
+	Service string `json:"service"`
+	// Served is false when the server does not register the service at all
+	Served bool           `json:"served"`
+	Checks []versionCheck `json:"checks"`
+}
+
+// record adds a check's outcome, classifying Unimplemented errors apart
+// from failures
+func record(checks *[]versionCheck, check string, err error) bool {
+	c := versionCheck{Check: check, Status: checkOK}
+	if err != nil {
+		c.Status = checkFailed
+		if status.Code(err) == codes.Unimplemented {
+			c.Status = checkUnimplemented
+		}
+		c.Error = err.Error()
+	}
+	*checks = append(*checks, c)
+	return err == nil
+}
+
+// unknownService reports whether err is gRPC's answer for a service the
+// server does not register
+func unknownService(err error) bool {
+	return status.Code(err) == codes.Unimplemented && strings.Contains(status.Convert(err).Message(), "unknown service")
+}
+
+// expectValue checks a Get's result
+func expectValue(got []byte, err error, want []byte) error {
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(got, want) {
+		return fmt.Errorf("got %q, expected %q", got, want)
+	}
+	return nil
+}
+
+// readStream concatenates a GetStream's chunks
+func readStream(recv func() ([]byte, error)) ([]byte, error) {
+	var buf bytes.Buffer
+	for {
+		data, err := recv()
+		if err == io.EOF {
+			return buf.Bytes(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(data)
+	}
+}
+
+// kvVersionProbe runs the version checks over one connection
+type kvVersionProbe struct {
+	conn    *grpc.ClientConn
+	key     string
+	timeout time.Duration
+}
+
+func (p *kvVersionProbe) ctx() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), p.timeout)
+}
+
+// probeLegacy checks the unversioned proto.KV service
+func (p *kvVersionProbe) probeLegacy() *versionResult {
+	result := &versionResult{Service: kvplugin.ServiceLegacy}
+	client := proto.NewKVClient(p.conn)
+	key, value := p.key+"-legacy", []byte("legacy value")
+	ctx, cancel := p.ctx()
+	defer cancel()
+
+	_, err := client.Put(ctx, &proto.PutRequest{Key: key, Value: value})
+	if unknownService(err) {
+		return result
+	}
+	result.Served = true
+	record(&result.Checks, "Put", err)
+	resp, err := client.Get(ctx, &proto.GetRequest{Key: key})
+	if err == nil {
+		err = expectValue(resp.Value, nil, value)
+	}
+	record(&result.Checks, "Get", err)
+	stream, err := client.GetStream(ctx, &proto.GetStreamRequest{Key: key, ChunkSize: 4})
+	if err == nil {
+		got, recvErr := readStream(func() ([]byte, error) {
+			chunk, err := stream.Recv()
+			if err != nil {
+				return nil, err
+			}
+			return chunk.Data, nil
+		})
+		err = expectValue(got, recvErr, value)
+	}
+	record(&result.Checks, "GetStream", err)
+	return result
+}
+
+// probeV1 checks kv.v1
+func (p *kvVersionProbe) probeV1() *versionResult {
+	result := &versionResult{Service: kvplugin.ServiceV1}
+	client := kvv1.NewKVClient(p.conn)
+	key, value := p.key+"-v1", []byte("v1 value")
+	ctx, cancel := p.ctx()
+	defer cancel()
+
+	_, err := client.Put(ctx, &kvv1.PutRequest{Key: key, Value: value})
+	if unknownService(err) {
+		return result
+	}
+	result.Served = true
+	record(&result.Checks, "Put", err)
+	resp, err := client.Get(ctx, &kvv1.GetRequest{Key: key})
+	if err == nil {
+		err = expectValue(resp.Value, nil, value)
+	}
+	record(&result.Checks, "Get", err)
+	stream, err := client.GetStream(ctx, &kvv1.GetStreamRequest{Key: key, ChunkSize: 4})
+	if err == nil {
+		got, recvErr := readStream(func() ([]byte, error) {
+			chunk, err := stream.Recv()
+			if err != nil {
+				return nil, err
+			}
+			return chunk.Data, nil
+		})
+		err = expectValue(got, recvErr, value)
+	}
+	record(&result.Checks, "GetStream", err)
+	return result
+}
+
+// probeV2 checks kv.v2, watching the key while it is put and deleted
+func (p *kvVersionProbe) probeV2() *versionResult {
+	result := &versionResult{Service: kvplugin.ServiceV2}
+	client := kvv2.NewKVClient(p.conn)
+	key, value := p.key+"-v2", []byte("v2 value")
+	ctx, cancel := p.ctx()
+	defer cancel()
+
+	_, err := client.List(ctx, &kvv2.ListRequest{Prefix: key})
+	if unknownService(err) {
+		return result
+	}
+	result.Served = true
+
+	// Watch first, so the Put and Delete below are seen
+	watchCtx, stopWatch := context.WithCancel(ctx)
+	defer stopWatch()
+	watch, watchErr := client.Watch(watchCtx, &kvv2.WatchRequest{Prefix: key})
+	if watchErr == nil {
+		_, watchErr = watch.Header()
+	}
+
+	_, err = client.Put(ctx, &kvv2.PutRequest{Key: key, Value: value})
+	record(&result.Checks, "Put", err)
+	resp, err := client.Get(ctx, &kvv2.GetRequest{Key: key})
+	if err == nil {
+		err = expectValue(resp.Value, nil, value)
+	}
+	record(&result.Checks, "Get", err)
+	stream, err := client.GetStream(ctx, &kvv2.GetStreamRequest{Key: key, ChunkSize: 4})
+	if err == nil {
+		got, recvErr := readStream(func() ([]byte, error) {
+			chunk, err := stream.Recv()
+			if err != nil {
+				return nil, err
+			}
+			return chunk.Data, nil
+		})
+		err = expectValue(got, recvErr, value)
+	}
+	record(&result.Checks, "GetStream", err)
+
+	list, err := client.List(ctx, &kvv2.ListRequest{Prefix: key})
+	if err == nil && (len(list.Keys) != 1 || list.Keys[0] != key) {
+		err = fmt.Errorf("listed %q, expected [%q]", list.Keys, key)
+	}
+	record(&result.Checks, "List", err)
+
+	deleted, err := client.Delete(ctx, &kvv2.DeleteRequest{Key: key})
+	if err == nil && !deleted.Existed {
+		err = fmt.Errorf("Delete reported the key missing")
+	}
+	if err == nil {
+		_, err = client.Get(ctx, &kvv2.GetRequest{Key: key})
+		if status.Code(err) == codes.NotFound {
+			err = nil
+		} else if err == nil {
+			err = fmt.Errorf("key still readable after Delete")
+		}
+	}
+	record(&result.Checks, "Delete", err)
+
+	if watchErr == nil {
+		watchErr = expectWatchEvents(watch, []*kvv2.WatchEvent{
+			{Type: kvv2.WatchEvent_TYPE_PUT, Key: key, Value: value},
+			{Type: kvv2.WatchEvent_TYPE_DELETE, Key: key},
+		})
+	}
+	record(&result.Checks, "Watch", watchErr)
+	return result
+}
+
+// expectWatchEvents reads len(want) events from a watch and compares them
+func expectWatchEvents(watch kvv2.KV_WatchClient, want []*kvv2.WatchEvent) error {
+	for i, w := range want {
+		event, err := watch.Recv()
+		if err != nil {
+			return fmt.Errorf("event %d: %w", i, err)
+		}
+		if event.Type != w.Type || event.Key != w.Key || !bytes.Equal(event.Value, w.Value) {
+			return fmt.Errorf("event %d was %s %q, expected %s %q", i, event.Type, event.Key, w.Type, w.Key)
+		}
+	}
+	return nil
+}
+
+// probeCompat checks values written through each version read the same
+// through the others
+func (p *kvVersionProbe) probeCompat(results []*versionResult) []versionCheck {
+	served := make(map[string]bool)
+	for _, r := range results {
+		served[r.Service] = r.Served
+	}
+	ctx, cancel := p.ctx()
+	defer cancel()
+
+	type version struct {
+		service string
+		put     func(key string, value []byte) error
+		get     func(key string) ([]byte, error)
+	}
+	legacy, v1, v2 := proto.NewKVClient(p.conn), kvv1.NewKVClient(p.conn), kvv2.NewKVClient(p.conn)
+	versions := []version{
+		{
+			kvplugin.ServiceLegacy,
+			func(key string, value []byte) error {
+				_, err := legacy.Put(ctx, &proto.PutRequest{Key: key, Value: value})
+				return err
+			},
+			func(key string) ([]byte, error) {
+				resp, err := legacy.Get(ctx, &proto.GetRequest{Key: key})
+				return resp.GetValue(), err
+			},
+		},
+		{
+			kvplugin.ServiceV1,
+			func(key string, value []byte) error {
+				_, err := v1.Put(ctx, &kvv1.PutRequest{Key: key, Value: value})
+				return err
+			},
+			func(key string) ([]byte, error) {
+				resp, err := v1.Get(ctx, &kvv1.GetRequest{Key: key})
+				return resp.GetValue(), err
+			},
+		},
+		{
+			kvplugin.ServiceV2,
+			func(key string, value []byte) error {
+				_, err := v2.Put(ctx, &kvv2.PutRequest{Key: key, Value: value})
+				return err
+			},
+			func(key string) ([]byte, error) {
+				resp, err := v2.Get(ctx, &kvv2.GetRequest{Key: key})
+				return resp.GetValue(), err
+			},
+		},
+	}
+
+	checks := []versionCheck{}
+	for i, writer := range versions {
+		if !served[writer.service] {
+			continue
+		}
+		key := fmt.Sprintf("%s-compat-%d", p.key, i)
+		value := []byte("written through " + writer.service)
+		if !record(&checks, writer.service+" Put", writer.put(key, value)) {
+			continue
+		}
+		for _, reader := range versions {
+			if reader.service == writer.service || !served[reader.service] {
+				continue
+			}
+			got, err := reader.get(key)
+			record(&checks, fmt.Sprintf("%s Put, %s Get", writer.service, reader.service), expectValue(got, err, value))
+		}
+	}
+	return checks
+}
+
+// cleanup removes the probe's keys where the server can delete them
+func (p *kvVersionProbe) cleanup() {
+	ctx, cancel := p.ctx()
+	defer cancel()
+	client := kvv2.NewKVClient(p.conn)
+	list, err := client.List(ctx, &kvv2.ListRequest{Prefix: p.key})
+	if err != nil {
+		return
+	}
+	for _, key := range list.Keys {
+		client.Delete(ctx, &kvv2.DeleteRequest{Key: key})
+	}
+}
+
+// initKVVersionsCmd creates the `rpc kv versions` command
+func initKVVersionsCmd() *cobra.Command {
+	var (
+		address  string
+		tlsCurve string
+		timeout  time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "versions",
+		Short: "Report which KV API versions a server serves and check they agree",
+		Long: `Call every method of each KV API version on one server: the unversioned
+proto.KV, kv.v1 (Get, Put, GetStream) and kv.v2 (adding Delete, List and
+Watch). Versions a server does not register are reported as not served rather
+than failed, so older servers and other languages can be compared. Values put
+through each served version are then read through the others.
+
+The command fails if any check of a served version fails; methods a server
+registers but reports Unimplemented are recorded without failing.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := newKVCommandClient(address, tlsCurve, nil)
+			if err != nil {
+				return err
+			}
+			defer client.Kill()
+
+			rpcClient, err := client.Client()
+			if err != nil {
+				return fmt.Errorf("failed to create RPC client: %w", err)
+			}
+			grpcClient, ok := rpcClient.(*plugin.GRPCClient)
+			if !ok {
+				return fmt.Errorf("server did not negotiate gRPC")
+			}
+
+			probe := &kvVersionProbe{
+				conn:    grpcClient.Conn,
+				key:     fmt.Sprintf("__kv_versions_%d", time.Now().UnixNano()),
+				timeout: timeout,
+			}
+			results := []*versionResult{probe.probeLegacy(), probe.probeV1(), probe.probeV2()}
+			compat := probe.probeCompat(results)
+			probe.cleanup()
+
+			passed := true
+			for _, r := range results {
+				for _, c := range r.Checks {
+					passed = passed && c.Status != checkFailed
+				}
+				logger.Info("🔢📊 KV API version probed", "service", r.Service, "served", r.Served, "checks", len(r.Checks))
+			}
+			for _, c := range compat {
+				passed = passed && c.Status != checkFailed
+			}
+
+			if err := json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+				"versions": results,
+				"compat":   compat,
+				"passed":   passed,
+			}); err != nil {
+				return fmt.Errorf("failed to encode JSON: %w", err)
+			}
+			if !passed {
+				return fmt.Errorf("KV API version checks failed")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&address, "address", "", "Address of existing server (e.g., 127.0.0.1:50051)")
+	cmd.Flags().StringVar(&tlsCurve, "tls-curve", "auto", "Client cert curve: auto (detect from server), secp256r1, secp384r1, secp521r1")
+	cmd.Flags().DurationVar(&timeout, "timeout", 10*time.Second, "Deadline for each version's checks")
+	return cmd
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-plugin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/stats"
+)
+
+// Plugin client lifecycle stages, in the order they complete
+const (
+	stageSpawn         = "spawn"
+	stageHandshakeRead = "handshake_read"
+	stageTLSComplete   = "tls_complete"
+	stageConnected     = "connected"
+	stageFirstRPC      = "first_rpc"
+)
+
+var spawnStageOrder = []string{stageSpawn, stageHandshakeRead, stageTLSComplete, stageConnected, stageFirstRPC}
+
+// spawnTimeline records when each stage of starting a plugin client and
+// making its first KV call completed, so slow server startup can be
+// attributed to the process, the handshake, TLS or the first call
+type spawnTimeline struct {
+	mu     sync.Mutex
+	start  time.Time
+	stages map[string]time.Time
+	// transportStage names the stage marked once the connection is up:
+	// tls_complete for TLS connections, connected otherwise
+	transportStage string
+}
+
+func newSpawnTimeline() *spawnTimeline {
+	return &spawnTimeline{
+		start:          time.Now(),
+		stages:         make(map[string]time.Time),
+		transportStage: stageTLSComplete,
+	}
+}
+
+// mark records the first time a stage completed
+func (t *spawnTimeline) mark(stage string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.stages[stage]; !ok {
+		t.stages[stage] = time.Now()
+	}
+}
+
+// spawnStageTiming is one stage of a spawn timing report
+type spawnStageTiming struct {
+	Stage string `json:"stage"`
+	At    string `json:"at"`
+	// SinceStartMs is the time from the start of the command's client setup
+	SinceStartMs float64 `json:"since_start_ms"`
+}
+
+// spawnTimingReport is the lifecycle timing of one client command
+type spawnTimingReport struct {
+	Start  string             `json:"start"`
+	Stages []spawnStageTiming `json:"stages"`
+}
+
+// report returns the recorded stages in lifecycle order
+func (t *spawnTimeline) report() *spawnTimingReport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	report := &spawnTimingReport{
+		Start:  t.start.UTC().Format(time.RFC3339Nano),
+		Stages: []spawnStageTiming{},
+	}
+	for _, stage := range spawnStageOrder {
+		at, ok := t.stages[stage]
+		if !ok {
+			continue
+		}
+		report.Stages = append(report.Stages, spawnStageTiming{
+			Stage:        stage,
+			At:           at.UTC().Format(time.RFC3339Nano),
+			SinceStartMs: float64(at.Sub(t.start).Microseconds()) / 1000,
+		})
+	}
+	return report
+}
+
+// instrument hooks the timeline into a plugin client config: go-plugin's
+// log messages mark the spawn and handshake, and a gRPC stats handler marks
+// the connection and the first KV call
+func (t *spawnTimeline) instrument(config *plugin.ClientConfig) {
+	if config.Logger != nil {
+		config.Logger = &timelineLogger{Logger: config.Logger, timeline: t}
+	}
+	config.GRPCDialOptions = append(config.GRPCDialOptions, grpc.WithStatsHandler(&timelineStatsHandler{timeline: t}))
+}
+
+// timelineLogger marks stages from the messages go-plugin logs as it
+// starts a plugin
+type timelineLogger struct {
+	hclog.Logger
+	timeline *spawnTimeline
+}
+
+func (l *timelineLogger) Debug(msg string, args ...interface{}) {
+	switch msg {
+	case "plugin started":
+		l.timeline.mark(stageSpawn)
+	case "using plugin":
+		l.timeline.mark(stageHandshakeRead)
+	}
+	l.Logger.Debug(msg, args...)
+}
+
+// timelineStatsHandler marks when the connection is established, after any
+// TLS handshake, and when the first KV call completes. Calls go-plugin makes
+// itself, such as the stdio stream, are not counted.
+type timelineStatsHandler struct {
+	timeline *spawnTimeline
+}
+
+type rpcMethodKey struct{}
+
+func (h *timelineStatsHandler) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	return context.WithValue(ctx, rpcMethodKey{}, info.FullMethodName)
+}
+
+func (h *timelineStatsHandler) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	if _, ok := s.(*stats.End); !ok {
+		return
+	}
+	if method, _ := ctx.Value(rpcMethodKey{}).(string); strings.HasPrefix(method, "/proto.KV/") {
+		h.timeline.mark(stageFirstRPC)
+	}
+}
+
+func (h *timelineStatsHandler) TagConn(ctx context.Context, info *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (h *timelineStatsHandler) HandleConn(ctx context.Context, s stats.ConnStats) {
+	if _, ok := s.(*stats.ConnBegin); ok {
+		h.timeline.mark(h.timeline.transportStage)
+	}
+}
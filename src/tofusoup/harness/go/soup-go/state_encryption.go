@@ -0,0 +1,351 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// OpenTofu state encryption uses a JSON envelope around the encrypted payload.
+// Key provider metadata is stored under "key_provider.<type>.<name>" so the
+// decrypting side can rebuild the key, and encryption_version doubles as the
+// sigil that distinguishes encrypted from plain state.
+const (
+	stateEncryptionVersion  = "v0"
+	pbkdf2MinPassphraseLen  = 16
+	pbkdf2MinIterations     = 200000
+	pbkdf2DefaultIterations = 600000
+	pbkdf2DefaultSaltLength = 32
+	pbkdf2DefaultKeyLength  = 32
+	pbkdf2DefaultHash       = "sha512"
+)
+
+// encryptedState is the envelope OpenTofu writes for encrypted state and plan
+// payloads. Byte slices encode as base64, as in OpenTofu.
+type encryptedState struct {
+	Meta    map[string][]byte `json:"meta"`
+	Data    []byte            `json:"encrypted_data"`
+	Version string            `json:"encryption_version"`
+}
+
+// pbkdf2Metadata is the pbkdf2 key provider metadata stored in the envelope
+type pbkdf2Metadata struct {
+	Salt         []byte `json:"salt"`
+	Iterations   int    `json:"iterations"`
+	HashFunction string `json:"hash_function"`
+	KeyLength    int    `json:"key_length"`
+}
+
+// pbkdf2KeyProvider is a pbkdf2 key_provider block
+type pbkdf2KeyProvider struct {
+	Name         string
+	Passphrase   string
+	Iterations   int
+	HashFunction string
+	SaltLength   int
+	KeyLength    int
+}
+
+// metaKey is the envelope meta key of the key provider
+func (p *pbkdf2KeyProvider) metaKey() string {
+	return "key_provider.pbkdf2." + p.Name
+}
+
+func (p *pbkdf2KeyProvider) validate() error {
+	if len(p.Passphrase) < pbkdf2MinPassphraseLen {
+		return fmt.Errorf("passphrase must be at least %d characters", pbkdf2MinPassphraseLen)
+	}
+	if p.Iterations < pbkdf2MinIterations {
+		return fmt.Errorf("iterations must be at least %d", pbkdf2MinIterations)
+	}
+	if _, err := pbkdf2HashFunction(p.HashFunction); err != nil {
+		return err
+	}
+	if p.SaltLength <= 0 {
+		return fmt.Errorf("salt_length must be positive")
+	}
+	return validateAESKeyLength(p.KeyLength)
+}
+
+// deriveKey derives a key from the passphrase with the given metadata
+func (p *pbkdf2KeyProvider) deriveKey(meta pbkdf2Metadata) ([]byte, error) {
+	h, err := pbkdf2HashFunction(meta.HashFunction)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateAESKeyLength(meta.KeyLength); err != nil {
+		return nil, err
+	}
+	if len(meta.Salt) == 0 || meta.Iterations <= 0 {
+		return nil, fmt.Errorf("invalid pbkdf2 metadata: missing salt or iterations")
+	}
+	return pbkdf2.Key(h, p.Passphrase, meta.Salt, meta.Iterations, meta.KeyLength)
+}
+
+func pbkdf2HashFunction(name string) (func() hash.Hash, error) {
+	switch name {
+	case "sha256":
+		return sha256.New, nil
+	case "sha512":
+		return sha512.New, nil
+	}
+	return nil, fmt.Errorf("unsupported hash_function %q: expected sha256 or sha512", name)
+}
+
+// validateAESKeyLength checks a key length is usable by the aes_gcm method
+func validateAESKeyLength(n int) error {
+	switch n {
+	case 16, 24, 32:
+		return nil
+	}
+	return fmt.Errorf("key_length must be 16, 24 or 32 for aes_gcm, got %d", n)
+}
+
+// newAESGCM creates the aes_gcm method's cipher
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptStatePayload encrypts a state payload with aes_gcm using a key from
+// the pbkdf2 key provider, generating a fresh salt and nonce
+func encryptStatePayload(plaintext []byte, provider *pbkdf2KeyProvider, aad []byte) ([]byte, error) {
+	if err := provider.validate(); err != nil {
+		return nil, err
+	}
+
+	meta := pbkdf2Metadata{
+		Salt:         make([]byte, provider.SaltLength),
+		Iterations:   provider.Iterations,
+		HashFunction: provider.HashFunction,
+		KeyLength:    provider.KeyLength,
+	}
+	if _, err := io.ReadFull(rand.Reader, meta.Salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	key, err := provider.deriveKey(meta)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := newAESGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	metaJSON, err := json.Marshal(meta)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(encryptedState{
+		Meta:    map[string][]byte{provider.metaKey(): metaJSON},
+		Data:    gcm.Seal(nonce, nonce, plaintext, aad),
+		Version: stateEncryptionVersion,
+	})
+}
+
+// errStateNotEncrypted is returned when a payload lacks the encryption sigil
+var errStateNotEncrypted = fmt.Errorf("payload is not encrypted state (no encryption_version)")
+
+// decryptStatePayload decrypts an OpenTofu encrypted state payload. When the
+// provider has no name, the envelope must hold exactly one pbkdf2 key.
+func decryptStatePayload(data []byte, provider *pbkdf2KeyProvider, aad []byte) ([]byte, error) {
+	var envelope encryptedState
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to parse payload: %w", err)
+	}
+	if envelope.Version == "" {
+		return nil, errStateNotEncrypted
+	}
+	if envelope.Version != stateEncryptionVersion {
+		return nil, fmt.Errorf("unsupported encryption_version %q", envelope.Version)
+	}
+
+	metaJSON, err := pbkdf2MetaFor(envelope.Meta, provider.Name)
+	if err != nil {
+		return nil, err
+	}
+	var meta pbkdf2Metadata
+	if err := json.Unmarshal(metaJSON, &meta); err != nil {
+		return nil, fmt.Errorf("failed to parse pbkdf2 metadata: %w", err)
+	}
+	key, err := provider.deriveKey(meta)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := newAESGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(envelope.Data) < gcm.NonceSize()+gcm.Overhead() {
+		return nil, fmt.Errorf("encrypted_data is too short")
+	}
+	nonce, ciphertext := envelope.Data[:gcm.NonceSize()], envelope.Data[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: wrong passphrase or corrupted payload: %w", err)
+	}
+	return plaintext, nil
+}
+
+// pbkdf2MetaFor finds the pbkdf2 key provider metadata in an envelope
+func pbkdf2MetaFor(meta map[string][]byte, name string) ([]byte, error) {
+	if name != "" {
+		data, ok := meta["key_provider.pbkdf2."+name]
+		if !ok {
+			return nil, fmt.Errorf("no metadata for key_provider.pbkdf2.%s (have %s)", name, strings.Join(sortedKeys(meta), ", "))
+		}
+		return data, nil
+	}
+
+	var found []string
+	for key := range meta {
+		if strings.HasPrefix(key, "key_provider.pbkdf2.") {
+			found = append(found, key)
+		}
+	}
+	if len(found) != 1 {
+		return nil, fmt.Errorf("expected one pbkdf2 key provider in metadata, found %d: use --key-provider", len(found))
+	}
+	return meta[found[0]], nil
+}
+
+// statePassphrase reads the passphrase from a flag or an environment variable
+func statePassphrase(passphrase, envName string) (string, error) {
+	if passphrase != "" {
+		return passphrase, nil
+	}
+	if value := os.Getenv(envName); value != "" {
+		return value, nil
+	}
+	return "", fmt.Errorf("no passphrase: use --passphrase or set %s", envName)
+}
+
+// readInputOrStdin reads a file argument, with "-" meaning stdin
+func readInputOrStdin(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(path)
+}
+
+// writeOutputOrStdout writes to a file argument, with "-" meaning stdout
+func writeOutputOrStdout(path string, data []byte) error {
+	if path == "-" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// addPbkdf2Flags registers the key provider flags shared by encrypt and decrypt
+func addPbkdf2Flags(cmd *cobra.Command, passphrase, passphraseEnv, aad *string) {
+	cmd.Flags().StringVar(passphrase, "passphrase", "", "pbkdf2 passphrase (at least 16 characters)")
+	cmd.Flags().StringVar(passphraseEnv, "passphrase-env", "TOFUSOUP_STATE_PASSPHRASE", "Environment variable holding the passphrase when --passphrase is not set")
+	cmd.Flags().StringVar(aad, "aad", "", "Additional authenticated data for aes_gcm")
+}
+
+// initStateEncryptCmd creates the `state encrypt` command
+func initStateEncryptCmd() *cobra.Command {
+	provider := &pbkdf2KeyProvider{}
+	var passphrase, passphraseEnv, aad string
+
+	cmd := &cobra.Command{
+		Use:   "encrypt [input] [output]",
+		Short: "Encrypt a state payload as OpenTofu does",
+		Long: `Encrypt a state (or plan) payload with OpenTofu's aes_gcm method and a key from
+the pbkdf2 key provider, writing the JSON envelope OpenTofu stores. Use "-" for
+stdin or stdout.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			plaintext, err := readInputOrStdin(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to read input: %w", err)
+			}
+			provider.Passphrase, err = statePassphrase(passphrase, passphraseEnv)
+			if err != nil {
+				return err
+			}
+			encrypted, err := encryptStatePayload(plaintext, provider, []byte(aad))
+			if err != nil {
+				return fmt.Errorf("failed to encrypt state: %w", err)
+			}
+			if err := writeOutputOrStdout(args[1], encrypted); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
+			}
+			return nil
+		},
+	}
+
+	addPbkdf2Flags(cmd, &passphrase, &passphraseEnv, &aad)
+	cmd.Flags().StringVar(&provider.Name, "key-provider", "default", "Name of the pbkdf2 key_provider block")
+	cmd.Flags().IntVar(&provider.Iterations, "iterations", pbkdf2DefaultIterations, "pbkdf2 iterations")
+	cmd.Flags().StringVar(&provider.HashFunction, "hash-function", pbkdf2DefaultHash, "pbkdf2 hash function: sha256 or sha512")
+	cmd.Flags().IntVar(&provider.SaltLength, "salt-length", pbkdf2DefaultSaltLength, "pbkdf2 salt length in bytes")
+	cmd.Flags().IntVar(&provider.KeyLength, "key-length", pbkdf2DefaultKeyLength, "Derived key length in bytes: 16, 24 or 32")
+
+	return cmd
+}
+
+// initStateDecryptCmd creates the `state decrypt` command
+func initStateDecryptCmd() *cobra.Command {
+	provider := &pbkdf2KeyProvider{}
+	var passphrase, passphraseEnv, aad string
+	var fallback bool
+
+	cmd := &cobra.Command{
+		Use:   "decrypt [input] [output]",
+		Short: "Decrypt an OpenTofu encrypted state payload",
+		Long: `Decrypt a state (or plan) payload encrypted by OpenTofu's aes_gcm method with a
+pbkdf2 key provider. The key parameters come from the payload's metadata, so
+only the passphrase is needed. Use "-" for stdin or stdout.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := readInputOrStdin(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to read input: %w", err)
+			}
+			provider.Passphrase, err = statePassphrase(passphrase, passphraseEnv)
+			if err != nil {
+				return err
+			}
+			plaintext, err := decryptStatePayload(data, provider, []byte(aad))
+			if err == errStateNotEncrypted && fallback {
+				// Like an unencrypted fallback block, pass plain state through
+				plaintext, err = data, nil
+			}
+			if err != nil {
+				return fmt.Errorf("failed to decrypt state: %w", err)
+			}
+			if err := writeOutputOrStdout(args[1], plaintext); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
+			}
+			return nil
+		},
+	}
+
+	addPbkdf2Flags(cmd, &passphrase, &passphraseEnv, &aad)
+	cmd.Flags().StringVar(&provider.Name, "key-provider", "", "Name of the pbkdf2 key_provider block (default: the only one in the payload)")
+	cmd.Flags().BoolVar(&fallback, "allow-unencrypted", false, "Pass through payloads that are not encrypted")
+
+	return cmd
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"io"
+	"os"
+)
+
+// base64SniffSize is how much input is inspected to decide whether stdin
+// carries base64 text rather than raw msgpack
+const base64SniffSize = 4096
+
+// openInput opens a file argument for streaming, with "-" meaning stdin
+func openInput(path string) (io.ReadCloser, error) {
+	if path == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(path)
+}
+
+// stdinFilename is the name HCL read from stdin has in diagnostics
+const stdinFilename = "<stdin>"
+
+// hclSourceName is the name to parse HCL read from path as: the path, or
+// for "-", a synthetic name for stdin
+func hclSourceName(path string) string {
+	if path == "-" {
+		return stdinFilename
+	}
+	return path
+}
+
+// readFileLimited reads a file argument, with "-" meaning stdin, subject to
+// --max-input-size
+func readFileLimited(path string) ([]byte, error) {
+	in, err := openInput(path)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+	return decodeLimits.ReadAll(in)
+}
+
+// bufferedOutput is a buffered writer over stdout or a created file
+type bufferedOutput struct {
+	*bufio.Writer
+	file *os.File
+}
+
+// Close flushes buffered output and closes the file, if any
+func (o *bufferedOutput) Close() error {
+	err := o.Flush()
+	if o.file != nil {
+		if closeErr := o.file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// createOutput opens a file argument for buffered streaming writes, with
+// "-" meaning stdout. Close must be called to flush the output.
+func createOutput(path string) (io.WriteCloser, error) {
+	if path == "-" {
+		return &bufferedOutput{Writer: bufio.NewWriter(os.Stdout)}, nil
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &bufferedOutput{Writer: bufio.NewWriter(f), file: f}, nil
+}
+
+// isBase64Text reports whether data holds only standard base64 characters
+// and line breaks
+func isBase64Text(data []byte) bool {
+	for _, b := range data {
+		switch {
+		case b >= 'A' && b <= 'Z', b >= 'a' && b <= 'z', b >= '0' && b <= '9':
+		case b == '+', b == '/', b == '=', b == '\r', b == '\n':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// maybeBase64Reader returns a reader over r that transparently decodes
+// base64 text, as written by `wire encode` to stdout, and passes raw data
+// through. Input larger than the sniff window is decoded in chunks as it
+// is read. Short input is decoded whole and falls back to the raw bytes if
+// it is not valid base64, since a few raw msgpack bytes can look like text.
+func maybeBase64Reader(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReaderSize(r, base64SniffSize)
+	head, err := br.Peek(base64SniffSize)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if len(head) < base64SniffSize {
+		data := append([]byte(nil), head...)
+		if decoded, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(data))); err == nil && len(data) > 0 {
+			return bytes.NewReader(decoded), nil
+		}
+		return bytes.NewReader(data), nil
+	}
+	if isBase64Text(head) {
+		return base64.NewDecoder(base64.StdEncoding, br), nil
+	}
+	return br, nil
+}
+
+// writeStreamOutput writes data to a file argument through createOutput
+func writeStreamOutput(path string, data []byte) error {
+	out, err := createOutput(path)
+	if err != nil {
+		return err
+	}
+	if _, err := out.Write(data); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
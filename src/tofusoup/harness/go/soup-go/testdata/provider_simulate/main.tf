@@ -0,0 +1,37 @@
+resource "tofusoup_resource" "minimal" {
+  name = "minimal"
+}
+
+resource "tofusoup_resource" "full" {
+  name    = "full"
+  size    = 1.5
+  enabled = true
+  tags = {
+    env  = "test"
+    team = upper("soup")
+  }
+  settings = {
+    replicas = 3
+    zones    = ["a", "b"]
+  }
+
+  rule {
+    port  = 443
+    cidrs = ["10.0.0.0/8", "192.168.0.0/16"]
+  }
+  rule {
+    port = 80
+  }
+
+  timeouts {
+    create = "5m"
+  }
+
+  lifecycle {
+    create_before_destroy = true
+  }
+}
+
+resource "other_resource" "ignored" {
+  value = 1
+}
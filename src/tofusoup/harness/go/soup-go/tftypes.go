@@ -0,0 +1,337 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// ctyTypeToTftypes converts a cty.Type into the equivalent tftypes.Type
+func ctyTypeToTftypes(ty cty.Type) (tftypes.Type, error) {
+	switch {
+	case ty == cty.String:
+		return tftypes.String, nil
+	case ty == cty.Number:
+		return tftypes.Number, nil
+	case ty == cty.Bool:
+		return tftypes.Bool, nil
+	case ty == cty.DynamicPseudoType:
+		return tftypes.DynamicPseudoType, nil
+	case ty.IsListType():
+		elemType, err := ctyTypeToTftypes(ty.ElementType())
+		if err != nil {
+			return nil, err
+		}
+		return tftypes.List{ElementType: elemType}, nil
+	case ty.IsSetType():
+		elemType, err := ctyTypeToTftypes(ty.ElementType())
+		if err != nil {
+			return nil, err
+		}
+		return tftypes.Set{ElementType: elemType}, nil
+	case ty.IsMapType():
+		elemType, err := ctyTypeToTftypes(ty.ElementType())
+		if err != nil {
+			return nil, err
+		}
+		return tftypes.Map{ElementType: elemType}, nil
+	case ty.IsTupleType():
+		elemTypes := make([]tftypes.Type, len(ty.TupleElementTypes()))
+		for i, elemTy := range ty.TupleElementTypes() {
+			elemType, err := ctyTypeToTftypes(elemTy)
+			if err != nil {
+				return nil, err
+			}
+			elemTypes[i] = elemType
+		}
+		return tftypes.Tuple{ElementTypes: elemTypes}, nil
+	case ty.IsObjectType():
+		attrTypes := make(map[string]tftypes.Type, len(ty.AttributeTypes()))
+		var optionals map[string]struct{}
+		for name, attrTy := range ty.AttributeTypes() {
+			attrType, err := ctyTypeToTftypes(attrTy)
+			if err != nil {
+				return nil, err
+			}
+			attrTypes[name] = attrType
+			if ty.AttributeOptional(name) {
+				if optionals == nil {
+					optionals = make(map[string]struct{})
+				}
+				optionals[name] = struct{}{}
+			}
+		}
+		return tftypes.Object{AttributeTypes: attrTypes, OptionalAttributes: optionals}, nil
+	}
+	return nil, fmt.Errorf("cannot convert cty type %s to tftypes", ty.FriendlyName())
+}
+
+// tftypesTypeToCty converts a tftypes.Type into the equivalent cty.Type
+func tftypesTypeToCty(t tftypes.Type) (cty.Type, error) {
+	switch {
+	case t == nil:
+		return cty.NilType, fmt.Errorf("cannot convert nil tftypes type")
+	case t.Is(tftypes.String):
+		return cty.String, nil
+	case t.Is(tftypes.Number):
+		return cty.Number, nil
+	case t.Is(tftypes.Bool):
+		return cty.Bool, nil
+	case t.Is(tftypes.DynamicPseudoType):
+		return cty.DynamicPseudoType, nil
+	}
+
+	switch tt := t.(type) {
+	case tftypes.List:
+		elemType, err := tftypesTypeToCty(tt.ElementType)
+		if err != nil {
+			return cty.NilType, err
+		}
+		return cty.List(elemType), nil
+	case tftypes.Set:
+		elemType, err := tftypesTypeToCty(tt.ElementType)
+		if err != nil {
+			return cty.NilType, err
+		}
+		return cty.Set(elemType), nil
+	case tftypes.Map:
+		elemType, err := tftypesTypeToCty(tt.ElementType)
+		if err != nil {
+			return cty.NilType, err
+		}
+		return cty.Map(elemType), nil
+	case tftypes.Tuple:
+		elemTypes := make([]cty.Type, len(tt.ElementTypes))
+		for i, et := range tt.ElementTypes {
+			elemType, err := tftypesTypeToCty(et)
+			if err != nil {
+				return cty.NilType, err
+			}
+			elemTypes[i] = elemType
+		}
+		return cty.Tuple(elemTypes), nil
+	case tftypes.Object:
+		attrTypes := make(map[string]cty.Type, len(tt.AttributeTypes))
+		optionals := make([]string, 0, len(tt.OptionalAttributes))
+		for name, at := range tt.AttributeTypes {
+			attrType, err := tftypesTypeToCty(at)
+			if err != nil {
+				return cty.NilType, err
+			}
+			attrTypes[name] = attrType
+			if _, ok := tt.OptionalAttributes[name]; ok {
+				optionals = append(optionals, name)
+			}
+		}
+		if len(optionals) > 0 {
+			return cty.ObjectWithOptionalAttrs(attrTypes, optionals), nil
+		}
+		return cty.Object(attrTypes), nil
+	}
+	return cty.NilType, fmt.Errorf("cannot convert tftypes type %s to cty", t)
+}
+
+// ctyValueToTftypes converts a cty.Value into the equivalent tftypes.Value.
+// Marks are dropped and unknown value refinements are not carried over, as
+// tftypes has no representation for either.
+func ctyValueToTftypes(val cty.Value) (tftypes.Value, error) {
+	val, _ = val.UnmarkDeep()
+	ty := val.Type()
+
+	tfType, err := ctyTypeToTftypes(ty)
+	if err != nil {
+		return tftypes.Value{}, err
+	}
+
+	switch {
+	case !val.IsKnown():
+		return tftypes.NewValue(tfType, tftypes.UnknownValue), nil
+	case val.IsNull():
+		return tftypes.NewValue(tfType, nil), nil
+	case ty == cty.String:
+		return tftypes.NewValue(tfType, val.AsString()), nil
+	case ty == cty.Number:
+		return tftypes.NewValue(tfType, new(big.Float).Copy(val.AsBigFloat())), nil
+	case ty == cty.Bool:
+		return tftypes.NewValue(tfType, val.True()), nil
+	case ty.IsListType() || ty.IsSetType() || ty.IsTupleType():
+		elems := make([]tftypes.Value, 0, val.LengthInt())
+		for it := val.ElementIterator(); it.Next(); {
+			_, elem := it.Element()
+			tfElem, err := ctyValueToTftypes(elem)
+			if err != nil {
+				return tftypes.Value{}, err
+			}
+			elems = append(elems, tfElem)
+		}
+		return tftypes.NewValue(tfType, elems), nil
+	case ty.IsMapType() || ty.IsObjectType():
+		attrs := make(map[string]tftypes.Value, val.LengthInt())
+		for it := val.ElementIterator(); it.Next(); {
+			key, elem := it.Element()
+			tfElem, err := ctyValueToTftypes(elem)
+			if err != nil {
+				return tftypes.Value{}, err
+			}
+			attrs[key.AsString()] = tfElem
+		}
+		return tftypes.NewValue(tfType, attrs), nil
+	}
+	return tftypes.Value{}, fmt.Errorf("cannot convert cty value of type %s to tftypes", ty.FriendlyName())
+}
+
+// tftypesValueToCty converts a tftypes.Value into a cty.Value of type ty.
+// When ty is dynamic, the concrete type carried by the tftypes value is used.
+func tftypesValueToCty(v tftypes.Value, ty cty.Type) (cty.Value, error) {
+	if ty == cty.DynamicPseudoType && !v.Type().Is(tftypes.DynamicPseudoType) {
+		concrete, err := tftypesTypeToCty(v.Type())
+		if err != nil {
+			return cty.NilVal, err
+		}
+		ty = concrete
+	}
+
+	if !v.IsKnown() {
+		return cty.UnknownVal(ty), nil
+	}
+	if v.IsNull() {
+		return cty.NullVal(ty), nil
+	}
+
+	switch {
+	case ty == cty.String:
+		var s string
+		if err := v.As(&s); err != nil {
+			return cty.NilVal, err
+		}
+		return cty.StringVal(s), nil
+	case ty == cty.Number:
+		n := new(big.Float)
+		if err := v.As(&n); err != nil {
+			return cty.NilVal, err
+		}
+		return cty.NumberVal(n), nil
+	case ty == cty.Bool:
+		var b bool
+		if err := v.As(&b); err != nil {
+			return cty.NilVal, err
+		}
+		return cty.BoolVal(b), nil
+	case ty.IsListType() || ty.IsSetType() || ty.IsTupleType():
+		var elems []tftypes.Value
+		if err := v.As(&elems); err != nil {
+			return cty.NilVal, err
+		}
+		vals := make([]cty.Value, len(elems))
+		for i, elem := range elems {
+			elemTy := cty.DynamicPseudoType
+			switch {
+			case ty.IsTupleType():
+				if i >= len(ty.TupleElementTypes()) {
+					return cty.NilVal, fmt.Errorf("tuple has too many elements: expected %d", len(ty.TupleElementTypes()))
+				}
+				elemTy = ty.TupleElementType(i)
+			default:
+				elemTy = ty.ElementType()
+			}
+			val, err := tftypesValueToCty(elem, elemTy)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			vals[i] = val
+		}
+		switch {
+		case ty.IsTupleType():
+			return cty.TupleVal(vals), nil
+		case len(vals) == 0 && ty.IsListType():
+			return cty.ListValEmpty(ty.ElementType()), nil
+		case len(vals) == 0:
+			return cty.SetValEmpty(ty.ElementType()), nil
+		case ty.IsListType():
+			return cty.ListVal(vals), nil
+		}
+		return cty.SetVal(vals), nil
+	case ty.IsMapType() || ty.IsObjectType():
+		var attrs map[string]tftypes.Value
+		if err := v.As(&attrs); err != nil {
+			return cty.NilVal, err
+		}
+		vals := make(map[string]cty.Value, len(attrs))
+		for name, attr := range attrs {
+			elemTy := cty.DynamicPseudoType
+			if ty.IsObjectType() {
+				if !ty.HasAttribute(name) {
+					return cty.NilVal, fmt.Errorf("unexpected attribute %q", name)
+				}
+				elemTy = ty.AttributeType(name)
+			} else {
+				elemTy = ty.ElementType()
+			}
+			val, err := tftypesValueToCty(attr, elemTy)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			vals[name] = val
+		}
+		if ty.IsObjectType() {
+			return cty.ObjectVal(vals), nil
+		}
+		if len(vals) == 0 {
+			return cty.MapValEmpty(ty.ElementType()), nil
+		}
+		return cty.MapVal(vals), nil
+	}
+	return cty.NilVal, fmt.Errorf("cannot convert tftypes value to cty type %s", ty.FriendlyName())
+}
+
+// convertTftypesData decodes inputData in inputFormat and re-encodes it in
+// outputFormat using terraform-plugin-go's tftypes value model. tftypes has
+// no JSON encoder, so JSON output is produced from the equivalent cty value.
+func convertTftypesData(ctyType cty.Type, inputData []byte, inputFormat, outputFormat string) ([]byte, error) {
+	tfType, err := ctyTypeToTftypes(ctyType)
+	if err != nil {
+		return nil, err
+	}
+
+	var dv tfprotov6.DynamicValue
+	switch inputFormat {
+	case "json":
+		dv.JSON = inputData
+	case "msgpack":
+		if err := decodeLimits.CheckMsgpack(inputData); err != nil {
+			return nil, fmt.Errorf("failed to decode msgpack input with tftypes: %w", err)
+		}
+		dv.MsgPack = inputData
+	default:
+		return nil, fmt.Errorf("unsupported input format: %s", inputFormat)
+	}
+
+	tfValue, err := dv.Unmarshal(tfType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s input with tftypes: %w", inputFormat, err)
+	}
+
+	switch outputFormat {
+	case "msgpack":
+		out, err := tfprotov6.NewDynamicValue(tfType, tfValue)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal to msgpack with tftypes: %w", err)
+		}
+		return out.MsgPack, nil
+	case "json":
+		value, err := tftypesValueToCty(tfValue, ctyType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert tftypes value to cty: %w", err)
+		}
+		outputData, err := ctyjson.Marshal(value, ctyType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal to JSON: %w", err)
+		}
+		return outputData, nil
+	}
+	return nil, fmt.Errorf("unsupported output format: %s", outputFormat)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// addTypeFileFlags registers a --<name>-file flag for each named type spec
+// flag of cmd, reading the spec from a file ("-" for stdin) for schemas too
+// large to pass as an argument. Each pair is mutually exclusive; when
+// required, one of them must be given. Cobra checks flag groups after
+// PreRunE, by which time the spec flag is set from the file, so the
+// exclusion is checked here.
+func addTypeFileFlags(cmd *cobra.Command, required bool, names ...string) *cobra.Command {
+	for _, name := range names {
+		fileFlag := name + "-file"
+		cmd.Flags().String(fileFlag, "", fmt.Sprintf("Read the --%s specification from a file (\"-\" for stdin)", name))
+		if required {
+			cmd.MarkFlagsOneRequired(name, fileFlag)
+		}
+	}
+
+	preRun := cmd.PreRunE
+	cmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		for _, name := range names {
+			path, _ := cmd.Flags().GetString(name + "-file")
+			if path == "" {
+				continue
+			}
+			if cmd.Flags().Changed(name) {
+				return fmt.Errorf("--%s and --%s-file cannot be used together", name, name)
+			}
+			data, err := readFileLimited(path)
+			if err != nil {
+				return fmt.Errorf("failed to read --%s-file: %w", name, err)
+			}
+			// Setting the flag marks it changed, so the daemon resets it
+			// after the request
+			if err := cmd.Flags().Set(name, strings.TrimSpace(string(data))); err != nil {
+				return err
+			}
+		}
+		if preRun != nil {
+			return preRun(cmd, args)
+		}
+		return nil
+	}
+	return cmd
+}
@@ -5,13 +5,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"os"
 
 	"github.com/spf13/cobra"
-	"github.com/vmihailenco/msgpack/v5"
 	"github.com/zclconf/go-cty/cty"
-	ctyjson "github.com/zclconf/go-cty/cty/json"
-	ctymsgpack "github.com/zclconf/go-cty/cty/msgpack"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/wirecodec"
 )
 
 // Override the encode command with real implementation
@@ -26,6 +24,11 @@ func initWireEncodeCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "encode [input] [output]",
 		Short: "Encode data to wire format",
+		Long: `Encode JSON input to the wire format, as a CTY value of --type when given.
+
+Typed input may mark any value unknown with {"__unknown": true}, optionally
+with "refinements" as accepted by cty convert; unknowns encode to msgpack
+extension 0 as in Terraform's provider protocol.`,
 		Args:  cobra.RangeArgs(1, 2),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			inputPath := args[0]
@@ -33,72 +36,41 @@ func initWireEncodeCmd() *cobra.Command {
 			if len(args) > 1 {
 				outputPath = args[1]
 			}
-
-			// Read input
-			var inputData []byte
-			var err error
-			if inputPath == "-" {
-				inputData, err = io.ReadAll(os.Stdin)
-			} else {
-				inputData, err = os.ReadFile(inputPath)
+			ty, err := wireType(wireTypeJSON)
+			if err != nil {
+				return err
 			}
+
+			in, err := openInput(inputPath)
 			if err != nil {
 				return fmt.Errorf("failed to read input: %w", err)
 			}
+			defer in.Close()
 
-			var outputData []byte
-
-			// If a type is specified, use CTY encoding
-			if wireTypeJSON != "" {
-				ctyType, err := parseCtyType(json.RawMessage(wireTypeJSON))
-				if err != nil {
-					return fmt.Errorf("failed to parse type: %w", err)
-				}
-
-				// Parse input as JSON and build CTY value
-				value, err := buildCtyValueFromJSON(ctyType, inputData)
-				if err != nil {
-					return fmt.Errorf("failed to build value: %w", err)
-				}
-
-				// Encode to wire format
-				switch wireOutputFormat {
-				case "msgpack":
-					outputData, err = ctymsgpack.Marshal(value, ctyType)
-				case "json":
-					outputData, err = ctyjson.Marshal(value, ctyType)
-				default:
-					return fmt.Errorf("unsupported output format: %s", wireOutputFormat)
-				}
-				if err != nil {
-					return fmt.Errorf("failed to encode: %w", err)
-				}
-			} else {
-				// Generic msgpack encoding without CTY type
-				var data interface{}
-				if err := json.Unmarshal(inputData, &data); err != nil {
-					return fmt.Errorf("failed to parse JSON: %w", err)
-				}
+			out, err := createOutput(outputPath)
+			if err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
+			}
 
-				outputData, err = msgpack.Marshal(data)
-				if err != nil {
-					return fmt.Errorf("failed to encode msgpack: %w", err)
-				}
+			// For stdout with msgpack output, encode as base64 for safe text transmission
+			var w io.Writer = out
+			var b64 io.WriteCloser
+			if outputPath == "-" && wireOutputFormat == "msgpack" {
+				b64 = base64.NewEncoder(base64.StdEncoding, out)
+				w = b64
 			}
 
-			// Write output
-			if outputPath == "-" {
-				// For stdout with msgpack output, encode as base64 for safe text transmission
-				if wireOutputFormat == "msgpack" {
-					encoded := base64.StdEncoding.EncodeToString(outputData)
-					_, err = os.Stdout.WriteString(encoded)
-				} else {
-					_, err = os.Stdout.Write(outputData)
+			if err := wirecodec.EncodeStream(ty, in, w, wireOutputFormat, ctyOptions()); err != nil {
+				out.Close()
+				return err
+			}
+			if b64 != nil {
+				if err := b64.Close(); err != nil {
+					out.Close()
+					return fmt.Errorf("failed to write output: %w", err)
 				}
-			} else {
-				err = os.WriteFile(outputPath, outputData, 0644)
 			}
-			if err != nil {
+			if err := out.Close(); err != nil {
 				return fmt.Errorf("failed to write output: %w", err)
 			}
 
@@ -109,9 +81,9 @@ func initWireEncodeCmd() *cobra.Command {
 	// Add flags
 	cmd.Flags().StringVar(&wireInputFormat, "input-format", "json", "Input format (json)")
 	cmd.Flags().StringVar(&wireOutputFormat, "output-format", "msgpack", "Output format (msgpack, json)")
-	cmd.Flags().StringVar(&wireTypeJSON, "type", "", "Type specification as JSON (optional)")
+	cmd.Flags().StringVar(&wireTypeJSON, "type", "", "Type specification as JSON or an HCL type expression (optional)")
 	
-	return cmd
+	return addTypeFileFlags(cmd, false, "type")
 }
 
 // Override the decode command with real implementation
@@ -133,85 +105,37 @@ func initWireDecodeCmd() *cobra.Command {
 			if len(args) > 1 {
 				outputPath = args[1]
 			}
-
-			// Read input
-			var inputData []byte
-			var err error
-			if inputPath == "-" {
-				inputData, err = io.ReadAll(os.Stdin)
-			} else {
-				inputData, err = os.ReadFile(inputPath)
+			ty, err := wireType(wireTypeJSON)
+			if err != nil {
+				return err
 			}
+
+			in, err := openInput(inputPath)
 			if err != nil {
 				return fmt.Errorf("failed to read input: %w", err)
 			}
+			defer in.Close()
 
-			// If input looks like base64 (no binary bytes), try to decode it
-			// This handles the case where encode outputs base64 to stdout
+			// Stdin may carry the base64 text that encode writes to stdout
+			var r io.Reader = in
 			if wireInputFormat == "msgpack" && inputPath == "-" {
-				// Try to decode as base64 if it looks like text
-				if decoded, err := base64.StdEncoding.DecodeString(string(inputData)); err == nil {
-					inputData = decoded
-				}
-			}
-
-			var outputData []byte
-
-			// If a type is specified, use CTY decoding
-			if wireTypeJSON != "" {
-				ctyType, err := parseCtyType(json.RawMessage(wireTypeJSON))
+				r, err = maybeBase64Reader(in)
 				if err != nil {
-					return fmt.Errorf("failed to parse type: %w", err)
-				}
-
-				// Decode from wire format
-				var value cty.Value
-				switch wireInputFormat {
-				case "msgpack":
-					value, err = ctymsgpack.Unmarshal(inputData, ctyType)
-				case "json":
-					value, err = ctyjson.Unmarshal(inputData, ctyType)
-				default:
-					return fmt.Errorf("unsupported input format: %s", wireInputFormat)
-				}
-				if err != nil {
-					return fmt.Errorf("failed to decode: %w", err)
-				}
-
-				// Encode to output format
-				switch wireOutputFormat {
-				case "json":
-					outputData, err = ctyjson.Marshal(value, ctyType)
-				case "msgpack":
-					outputData, err = ctymsgpack.Marshal(value, ctyType)
-				default:
-					return fmt.Errorf("unsupported output format: %s", wireOutputFormat)
-				}
-				if err != nil {
-					return fmt.Errorf("failed to encode output: %w", err)
-				}
-			} else {
-				// Generic msgpack decoding without CTY type
-				var data interface{}
-				if err := msgpack.Unmarshal(inputData, &data); err != nil {
-					return fmt.Errorf("failed to decode msgpack: %w", err)
-				}
-
-				outputData, err = json.MarshalIndent(data, "", "  ")
-				if err != nil {
-					return fmt.Errorf("failed to encode JSON: %w", err)
+					return fmt.Errorf("failed to read input: %w", err)
 				}
 			}
 
-			// Write output
-			if outputPath == "-" {
-				_, err = os.Stdout.Write(outputData)
-			} else {
-				err = os.WriteFile(outputPath, outputData, 0644)
-			}
+			out, err := createOutput(outputPath)
 			if err != nil {
 				return fmt.Errorf("failed to write output: %w", err)
 			}
+			if err := wirecodec.DecodeStream(ty, r, out, wireInputFormat, wireOutputFormat, ctyOptions()); err != nil {
+				out.Close()
+				return err
+			}
+			if err := out.Close(); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
+			}
 
 			return nil
 		},
@@ -220,7 +144,20 @@ func initWireDecodeCmd() *cobra.Command {
 	// Add flags
 	cmd.Flags().StringVar(&wireInputFormat, "input-format", "msgpack", "Input format (msgpack)")
 	cmd.Flags().StringVar(&wireOutputFormat, "output-format", "json", "Output format (json)")
-	cmd.Flags().StringVar(&wireTypeJSON, "type", "", "Type specification as JSON (optional)")
+	cmd.Flags().StringVar(&wireTypeJSON, "type", "", "Type specification as JSON or an HCL type expression (optional)")
 	
-	return cmd
-}
\ No newline at end of file
+	return addTypeFileFlags(cmd, false, "type")
+}
+
+// wireType parses a --type flag for the wirecodec functions, where no type
+// selects generic msgpack
+func wireType(typeJSON string) (cty.Type, error) {
+	if typeJSON == "" {
+		return cty.NilType, nil
+	}
+	ty, err := parseCtyType(json.RawMessage(typeJSON))
+	if err != nil {
+		return cty.NilType, fmt.Errorf("failed to parse type: %w", err)
+	}
+	return ty, nil
+}
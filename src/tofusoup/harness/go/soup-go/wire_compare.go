@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/zclconf/go-cty/cty"
+	ctymsgpack "github.com/zclconf/go-cty/cty/msgpack"
+
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/ctyspec"
+	"github.com/provide-io/tofusoup/harness/soup-go/pkg/limits"
+)
+
+// Codecs compared by wire compare
+const (
+	codecCty         = "cty"
+	codecVmihailenco = "vmihailenco"
+)
+
+// wireCodecStats is one codec's result over the whole corpus
+type wireCodecStats struct {
+	Codec string `json:"codec"`
+	// Bytes is the encoded size of one pass over the corpus
+	Bytes           int64   `json:"bytes"`
+	EncodeOpsPerSec float64 `json:"encode_ops_per_sec"`
+	EncodeMBPerSec  float64 `json:"encode_mb_per_sec"`
+	DecodeOpsPerSec float64 `json:"decode_ops_per_sec"`
+	DecodeMBPerSec  float64 `json:"decode_mb_per_sec"`
+}
+
+// wireCompareItem describes a corpus item the two codecs encode differently
+type wireCompareItem struct {
+	Index            int    `json:"index"`
+	Name             string `json:"name,omitempty"`
+	CtyBytes         int    `json:"cty_bytes"`
+	VmihailencoBytes int    `json:"vmihailenco_bytes"`
+	// FirstDiff is the offset of the first differing byte
+	FirstDiff int `json:"first_diff"`
+}
+
+// wireCompareReport is the output of wire compare
+type wireCompareReport struct {
+	Items       int               `json:"items"`
+	Identical   int               `json:"identical"`
+	Iterations  int               `json:"iterations"`
+	Codecs      []wireCodecStats  `json:"codecs"`
+	Differences []wireCompareItem `json:"differences"`
+	Errors      []BatchResult     `json:"errors,omitempty"`
+}
+
+// wireCompareEntry is a corpus item prepared for both codecs
+type wireCompareEntry struct {
+	index   int
+	name    string
+	ty      cty.Type
+	value   cty.Value
+	generic interface{}
+	encoded map[string][]byte
+}
+
+func initWireCompareCmd() *cobra.Command {
+	var (
+		typeJSON   string
+		iterations int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "compare [corpus]",
+		Short: "Compare cty and vmihailenco msgpack encodings of a corpus",
+		Long: `Encode every item of a JSONL corpus ("-" for stdin) with both msgpack
+libraries the harness uses: go-cty's msgpack, as typed 'wire encode' does, and
+vmihailenco/msgpack, as untyped 'wire encode' does. Lines have the same form
+as for 'cty batch' with JSON input; items without a type and no --type have
+their type inferred from the input.
+
+The report gives each codec's encoded size and encode and decode throughput
+over --iterations passes, and lists the items whose encodings differ with the
+offset of the first differing byte.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if iterations < 1 {
+				return fmt.Errorf("--iterations must be at least 1")
+			}
+
+			var r io.Reader = os.Stdin
+			if args[0] != "-" {
+				f, err := os.Open(args[0])
+				if err != nil {
+					return fmt.Errorf("failed to open corpus: %w", err)
+				}
+				defer f.Close()
+				r = f
+			}
+
+			report, err := runWireCompare(r, typeJSON, iterations)
+			if err != nil {
+				return err
+			}
+			if err := json.NewEncoder(os.Stdout).Encode(report); err != nil {
+				return fmt.Errorf("failed to write report: %w", err)
+			}
+			if len(report.Errors) > 0 {
+				return fmt.Errorf("%d of %d items failed", len(report.Errors), report.Items+len(report.Errors))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&typeJSON, "type", "", "Type specification as JSON for items without a type (default: inferred)")
+	cmd.Flags().IntVar(&iterations, "iterations", 100, "Passes over the corpus per codec when measuring throughput")
+	return withProfiles(addTypeFileFlags(cmd, false, "type"))
+}
+
+// runWireCompare encodes the corpus read from r with both codecs and
+// measures them
+func runWireCompare(r io.Reader, typeJSON string, iterations int) (*wireCompareReport, error) {
+	report := &wireCompareReport{
+		Iterations:  iterations,
+		Differences: []wireCompareItem{},
+	}
+
+	var entries []*wireCompareEntry
+	lines, scanErr := scanCorpusLines(r)
+	index := 0
+	for line := range lines {
+		entry, err := prepareWireCompareEntry(index, line, typeJSON)
+		if err != nil {
+			result := BatchResult{Index: index, Error: err.Error(), Limit: limits.As(err)}
+			if entry != nil {
+				result.Name = entry.name
+			}
+			report.Errors = append(report.Errors, result)
+		} else {
+			entries = append(entries, entry)
+		}
+		index++
+	}
+	if err := <-scanErr; err != nil {
+		return nil, fmt.Errorf("failed to read corpus: %w", err)
+	}
+	report.Items = len(entries)
+
+	for _, codec := range []string{codecCty, codecVmihailenco} {
+		stats, err := measureWireCodec(codec, entries, iterations)
+		if err != nil {
+			return nil, err
+		}
+		report.Codecs = append(report.Codecs, *stats)
+	}
+
+	for _, entry := range entries {
+		a, b := entry.encoded[codecCty], entry.encoded[codecVmihailenco]
+		if bytes.Equal(a, b) {
+			report.Identical++
+			continue
+		}
+		report.Differences = append(report.Differences, wireCompareItem{
+			Index:            entry.index,
+			Name:             entry.name,
+			CtyBytes:         len(a),
+			VmihailencoBytes: len(b),
+			FirstDiff:        firstDiff(a, b),
+		})
+	}
+
+	logger.Info("📦✅ wire compare complete", "items", report.Items, "identical", report.Identical, "failed", len(report.Errors))
+	return report, nil
+}
+
+// prepareWireCompareEntry parses a corpus line into the value each codec
+// encodes: a cty value for cty, and the generic decoding of the JSON input
+// for vmihailenco
+func prepareWireCompareEntry(index int, line []byte, typeJSON string) (*wireCompareEntry, error) {
+	var item BatchItem
+	if err := json.Unmarshal(line, &item); err != nil {
+		return nil, fmt.Errorf("invalid corpus line: %s", err)
+	}
+	entry := &wireCompareEntry{index: index, name: item.Name, encoded: make(map[string][]byte)}
+	input, err := decodeBatchInput(item.Input, "json")
+	if err != nil {
+		return entry, err
+	}
+
+	spec := batchTypeJSON(item, typeJSON)
+	entry.ty = cty.DynamicPseudoType
+	if spec != "" {
+		if entry.ty, err = parseCtyType(json.RawMessage(spec)); err != nil {
+			return entry, fmt.Errorf("failed to parse type: %w", err)
+		}
+	}
+	if entry.value, err = ctyspec.BuildValue(entry.ty, input, ctyOptions()); err != nil {
+		return entry, fmt.Errorf("failed to build value: %w", err)
+	}
+	if spec == "" {
+		// Encode with the inferred type rather than as a dynamic value,
+		// which would prefix the encoding with the type
+		entry.ty = entry.value.Type()
+	}
+
+	if err := json.NewDecoder(decodeLimits.Reader(bytes.NewReader(input))).Decode(&entry.generic); err != nil {
+		return entry, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	return entry, nil
+}
+
+// measureWireCodec encodes and decodes every entry iterations times with
+// codec, keeping each entry's encoding for comparison
+func measureWireCodec(codec string, entries []*wireCompareEntry, iterations int) (*wireCodecStats, error) {
+	stats := &wireCodecStats{Codec: codec}
+	for _, entry := range entries {
+		var data []byte
+		var err error
+		switch codec {
+		case codecCty:
+			data, err = ctymsgpack.Marshal(entry.value, entry.ty)
+		case codecVmihailenco:
+			data, err = msgpack.Marshal(entry.generic)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s failed to encode item %d: %w", codec, entry.index, err)
+		}
+		entry.encoded[codec] = data
+		stats.Bytes += int64(len(data))
+	}
+
+	start := time.Now()
+	for i := 0; i < iterations; i++ {
+		for _, entry := range entries {
+			switch codec {
+			case codecCty:
+				_, _ = ctymsgpack.Marshal(entry.value, entry.ty)
+			case codecVmihailenco:
+				_, _ = msgpack.Marshal(entry.generic)
+			}
+		}
+	}
+	stats.EncodeOpsPerSec, stats.EncodeMBPerSec = wireThroughput(len(entries), stats.Bytes, iterations, time.Since(start))
+
+	start = time.Now()
+	for i := 0; i < iterations; i++ {
+		for _, entry := range entries {
+			switch codec {
+			case codecCty:
+				_, _ = ctymsgpack.Unmarshal(entry.encoded[codec], entry.ty)
+			case codecVmihailenco:
+				var decoded interface{}
+				_ = msgpack.Unmarshal(entry.encoded[codec], &decoded)
+			}
+		}
+	}
+	stats.DecodeOpsPerSec, stats.DecodeMBPerSec = wireThroughput(len(entries), stats.Bytes, iterations, time.Since(start))
+	return stats, nil
+}
+
+// wireThroughput converts a timed run into operations and encoded
+// megabytes per second
+func wireThroughput(items int, bytes int64, iterations int, elapsed time.Duration) (float64, float64) {
+	seconds := elapsed.Seconds()
+	if seconds <= 0 {
+		return 0, 0
+	}
+	ops := float64(items*iterations) / seconds
+	mb := float64(bytes) * float64(iterations) / (1 << 20) / seconds
+	return ops, mb
+}
+
+// firstDiff returns the offset of the first byte at which a and b differ
+func firstDiff(a, b []byte) int {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
@@ -22,8 +22,9 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	KV_Get_FullMethodName = "/proto.KV/Get"
-	KV_Put_FullMethodName = "/proto.KV/Put"
+	KV_Get_FullMethodName       = "/proto.KV/Get"
+	KV_Put_FullMethodName       = "/proto.KV/Put"
+	KV_GetStream_FullMethodName = "/proto.KV/GetStream"
 )
 
 // KVClient is the client API for KV service.
@@ -32,6 +33,9 @@ const (
 type KVClient interface {
 	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error)
 	Put(ctx context.Context, in *PutRequest, opts ...grpc.CallOption) (*Empty, error)
+	// GetStream returns a value in chunks, for values larger than the
+	// maximum gRPC message size
+	GetStream(ctx context.Context, in *GetStreamRequest, opts ...grpc.CallOption) (KV_GetStreamClient, error)
 }
 
 type kVClient struct {
@@ -60,12 +64,47 @@ func (c *kVClient) Put(ctx context.Context, in *PutRequest, opts ...grpc.CallOpt
 	return out, nil
 }
 
+func (c *kVClient) GetStream(ctx context.Context, in *GetStreamRequest, opts ...grpc.CallOption) (KV_GetStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &KV_ServiceDesc.Streams[0], KV_GetStream_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &kVGetStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type KV_GetStreamClient interface {
+	Recv() (*GetStreamChunk, error)
+	grpc.ClientStream
+}
+
+type kVGetStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *kVGetStreamClient) Recv() (*GetStreamChunk, error) {
+	m := new(GetStreamChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // KVServer is the server API for KV service.
 // All implementations should embed UnimplementedKVServer
 // for forward compatibility
 type KVServer interface {
 	Get(context.Context, *GetRequest) (*GetResponse, error)
 	Put(context.Context, *PutRequest) (*Empty, error)
+	// GetStream returns a value in chunks, for values larger than the
+	// maximum gRPC message size
+	GetStream(*GetStreamRequest, KV_GetStreamServer) error
 }
 
 // UnimplementedKVServer should be embedded to have forward compatible implementations.
@@ -78,6 +117,9 @@ func (UnimplementedKVServer) Get(context.Context, *GetRequest) (*GetResponse, er
 func (UnimplementedKVServer) Put(context.Context, *PutRequest) (*Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Put not implemented")
 }
+func (UnimplementedKVServer) GetStream(*GetStreamRequest, KV_GetStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method GetStream not implemented")
+}
 
 // UnsafeKVServer may be embedded to opt out of forward compatibility for this service.
 // Use of this interface is not recommended, as added methods to KVServer will
@@ -126,6 +168,27 @@ func _KV_Put_Handler(srv interface{}, ctx context.Context, dec func(interface{})
 	return interceptor(ctx, in, info, handler)
 }
 
+func _KV_GetStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetStreamRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(KVServer).GetStream(m, &kVGetStreamServer{stream})
+}
+
+type KV_GetStreamServer interface {
+	Send(*GetStreamChunk) error
+	grpc.ServerStream
+}
+
+type kVGetStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *kVGetStreamServer) Send(m *GetStreamChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // KV_ServiceDesc is the grpc.ServiceDesc for KV service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -142,7 +205,13 @@ var KV_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _KV_Put_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "GetStream",
+			Handler:       _KV_GetStream_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "proto/kv.proto",
 }
 
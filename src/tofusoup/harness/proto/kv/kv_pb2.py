@@ -20,7 +20,7 @@ _sym_db = _symbol_database.Default()
 
 
 DESCRIPTOR = _descriptor_pool.Default().AddSerializedFile(
-    b'\n\x08kv.proto\x12\x05proto"\x19\n\nGetRequest\x12\x0b\n\x03key\x18\x01 \x01(\t"\x1c\n\x0bGetResponse\x12\r\n\x05value\x18\x01 \x01(\x0c"(\n\nPutRequest\x12\x0b\n\x03key\x18\x01 \x01(\t\x12\r\n\x05value\x18\x02 \x01(\x0c"\x07\n\x05\x45mpty2Z\n\x02KV\x12,\n\x03Get\x12\x11.proto.GetRequest\x1a\x12.proto.GetResponse\x12&\n\x03Put\x12\x11.proto.PutRequest\x1a\x0c.proto.EmptyB\tZ\x07./protob\x06proto3'
+    b'\n\x08kv.proto\x12\x05proto"\x19\n\nGetRequest\x12\x0b\n\x03key\x18\x01 \x01(\t"\x1c\n\x0bGetResponse\x12\r\n\x05value\x18\x01 \x01(\x0c"(\n\nPutRequest\x12\x0b\n\x03key\x18\x01 \x01(\t\x12\r\n\x05value\x18\x02 \x01(\x0c"\x07\n\x05\x45mpty"3\n\x10GetStreamRequest\x12\x0b\n\x03key\x18\x01 \x01(\t\x12\x12\n\nchunk_size\x18\x02 \x01(\x05"\x1e\n\x0eGetStreamChunk\x12\x0c\n\x04\x64\x61ta\x18\x01 \x01(\x0c\x32\x99\x01\n\x02KV\x12,\n\x03Get\x12\x11.proto.GetRequest\x1a\x12.proto.GetResponse\x12&\n\x03Put\x12\x11.proto.PutRequest\x1a\x0c.proto.Empty\x12=\n\tGetStream\x12\x17.proto.GetStreamRequest\x1a\x15.proto.GetStreamChunk0\x01\x42\tZ\x07./protob\x06proto3'
 )
 
 _globals = globals()
@@ -37,8 +37,12 @@ if not _descriptor._USE_C_DESCRIPTORS:
     _globals["_PUTREQUEST"]._serialized_end = 116
     _globals["_EMPTY"]._serialized_start = 118
     _globals["_EMPTY"]._serialized_end = 125
-    _globals["_KV"]._serialized_start = 127
-    _globals["_KV"]._serialized_end = 217
+    _globals["_GETSTREAMREQUEST"]._serialized_start = 127
+    _globals["_GETSTREAMREQUEST"]._serialized_end = 178
+    _globals["_GETSTREAMCHUNK"]._serialized_start = 180
+    _globals["_GETSTREAMCHUNK"]._serialized_end = 210
+    _globals["_KV"]._serialized_start = 213
+    _globals["_KV"]._serialized_end = 366
 # @@protoc_insertion_point(module_scope)
 
 # 🥣🔬🔚
@@ -27,3 +27,17 @@ class PutRequest(_message.Message):
 class Empty(_message.Message):
     __slots__ = ()
     def __init__(self) -> None: ...
+
+class GetStreamRequest(_message.Message):
+    __slots__ = ("key", "chunk_size")
+    KEY_FIELD_NUMBER: _ClassVar[int]
+    CHUNK_SIZE_FIELD_NUMBER: _ClassVar[int]
+    key: str
+    chunk_size: int
+    def __init__(self, key: str | None = ..., chunk_size: int | None = ...) -> None: ...
+
+class GetStreamChunk(_message.Message):
+    __slots__ = ("data",)
+    DATA_FIELD_NUMBER: _ClassVar[int]
+    data: bytes
+    def __init__(self, data: bytes | None = ...) -> None: ...
@@ -54,6 +54,12 @@ class KVStub:
             response_deserializer=kv__pb2.Empty.FromString,
             _registered_method=True,
         )
+        self.GetStream = channel.unary_stream(
+            "/proto.KV/GetStream",
+            request_serializer=kv__pb2.GetStreamRequest.SerializeToString,
+            response_deserializer=kv__pb2.GetStreamChunk.FromString,
+            _registered_method=True,
+        )
 
 
 class KVServicer:
@@ -71,6 +77,14 @@ class KVServicer:
         context.set_details("Method not implemented!")
         raise NotImplementedError("Method not implemented!")
 
+    def GetStream(self, request, context) -> Never:
+        """GetStream returns a value in chunks, for values larger than the
+        maximum gRPC message size
+        """
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details("Method not implemented!")
+        raise NotImplementedError("Method not implemented!")
+
 
 def add_KVServicer_to_server(servicer, server) -> None:
     rpc_method_handlers = {
@@ -84,6 +98,11 @@ def add_KVServicer_to_server(servicer, server) -> None:
             request_deserializer=kv__pb2.PutRequest.FromString,
             response_serializer=kv__pb2.Empty.SerializeToString,
         ),
+        "GetStream": grpc.unary_stream_rpc_method_handler(
+            servicer.GetStream,
+            request_deserializer=kv__pb2.GetStreamRequest.FromString,
+            response_serializer=kv__pb2.GetStreamChunk.SerializeToString,
+        ),
     }
     generic_handler = grpc.method_handlers_generic_handler("proto.KV", rpc_method_handlers)
     server.add_generic_rpc_handlers((generic_handler,))
@@ -154,5 +173,35 @@ class KV:
             _registered_method=True,
         )
 
+    @staticmethod
+    def GetStream(
+        request,
+        target,
+        options=(),
+        channel_credentials=None,
+        call_credentials=None,
+        insecure=False,
+        compression=None,
+        wait_for_ready=None,
+        timeout=None,
+        metadata=None,
+    ):
+        return grpc.experimental.unary_stream(
+            request,
+            target,
+            "/proto.KV/GetStream",
+            kv__pb2.GetStreamRequest.SerializeToString,
+            kv__pb2.GetStreamChunk.FromString,
+            options,
+            channel_credentials,
+            insecure,
+            call_credentials,
+            compression,
+            wait_for_ready,
+            timeout,
+            metadata,
+            _registered_method=True,
+        )
+
 
 # 🥣🔬🔚
@@ -0,0 +1,48 @@
+#
+# SPDX-FileCopyrightText: Copyright (c) provide.io llc. All rights reserved.
+# SPDX-License-Identifier: Apache-2.0
+#
+
+"""Generated protocol buffer code."""
+
+from google.protobuf import (
+    descriptor as _descriptor,
+    descriptor_pool as _descriptor_pool,
+    runtime_version as _runtime_version,
+    symbol_database as _symbol_database,
+)
+from google.protobuf.internal import builder as _builder
+
+_runtime_version.ValidateProtobufRuntimeVersion(_runtime_version.Domain.PUBLIC, 6, 31, 0, "", "kv/v1/kv.proto")
+# @@protoc_insertion_point(imports)
+
+_sym_db = _symbol_database.Default()
+
+
+DESCRIPTOR = _descriptor_pool.Default().AddSerializedFile(
+    b'\n\x0ekv/v1/kv.proto\x12\x05kv.v1"\x19\n\nGetRequest\x12\x0b\n\x03key\x18\x01 \x01(\t"\x1c\n\x0bGetResponse\x12\r\n\x05value\x18\x01 \x01(\x0c"(\n\nPutRequest\x12\x0b\n\x03key\x18\x01 \x01(\t\x12\r\n\x05value\x18\x02 \x01(\x0c"\r\n\x0bPutResponse"3\n\x10GetStreamRequest\x12\x0b\n\x03key\x18\x01 \x01(\t\x12\x12\n\nchunk_size\x18\x02 \x01(\x05"\x1e\n\x0eGetStreamChunk\x12\x0c\n\x04\x64\x61ta\x18\x01 \x01(\x0c\x32\x9f\x01\n\x02KV\x12,\n\x03Get\x12\x11.kv.v1.GetRequest\x1a\x12.kv.v1.GetResponse\x12,\n\x03Put\x12\x11.kv.v1.PutRequest\x1a\x12.kv.v1.PutResponse\x12=\n\tGetStream\x12\x17.kv.v1.GetStreamRequest\x1a\x15.kv.v1.GetStreamChunk0\x01\x42\x31Z/github.com/provide-io/tofusoup/proto/kv/v1;kvv1b\x06proto3'
+)
+
+_globals = globals()
+_builder.BuildMessageAndEnumDescriptors(DESCRIPTOR, _globals)
+_builder.BuildTopDescriptorsAndMessages(DESCRIPTOR, "kv.v1.kv_pb2", _globals)
+if not _descriptor._USE_C_DESCRIPTORS:
+    _globals["DESCRIPTOR"]._loaded_options = None
+    _globals["DESCRIPTOR"]._serialized_options = b"Z/github.com/provide-io/tofusoup/proto/kv/v1;kvv1"
+    _globals["_GETREQUEST"]._serialized_start = 25
+    _globals["_GETREQUEST"]._serialized_end = 50
+    _globals["_GETRESPONSE"]._serialized_start = 52
+    _globals["_GETRESPONSE"]._serialized_end = 80
+    _globals["_PUTREQUEST"]._serialized_start = 82
+    _globals["_PUTREQUEST"]._serialized_end = 122
+    _globals["_PUTRESPONSE"]._serialized_start = 124
+    _globals["_PUTRESPONSE"]._serialized_end = 137
+    _globals["_GETSTREAMREQUEST"]._serialized_start = 139
+    _globals["_GETSTREAMREQUEST"]._serialized_end = 190
+    _globals["_GETSTREAMCHUNK"]._serialized_start = 192
+    _globals["_GETSTREAMCHUNK"]._serialized_end = 222
+    _globals["_KV"]._serialized_start = 225
+    _globals["_KV"]._serialized_end = 384
+# @@protoc_insertion_point(module_scope)
+
+# 🥣🔬🔚
@@ -0,0 +1,43 @@
+from typing import ClassVar as _ClassVar
+
+from google.protobuf import descriptor as _descriptor, message as _message
+
+DESCRIPTOR: _descriptor.FileDescriptor
+
+class GetRequest(_message.Message):
+    __slots__ = ("key",)
+    KEY_FIELD_NUMBER: _ClassVar[int]
+    key: str
+    def __init__(self, key: str | None = ...) -> None: ...
+
+class GetResponse(_message.Message):
+    __slots__ = ("value",)
+    VALUE_FIELD_NUMBER: _ClassVar[int]
+    value: bytes
+    def __init__(self, value: bytes | None = ...) -> None: ...
+
+class PutRequest(_message.Message):
+    __slots__ = ("key", "value")
+    KEY_FIELD_NUMBER: _ClassVar[int]
+    VALUE_FIELD_NUMBER: _ClassVar[int]
+    key: str
+    value: bytes
+    def __init__(self, key: str | None = ..., value: bytes | None = ...) -> None: ...
+
+class PutResponse(_message.Message):
+    __slots__ = ()
+    def __init__(self) -> None: ...
+
+class GetStreamRequest(_message.Message):
+    __slots__ = ("key", "chunk_size")
+    KEY_FIELD_NUMBER: _ClassVar[int]
+    CHUNK_SIZE_FIELD_NUMBER: _ClassVar[int]
+    key: str
+    chunk_size: int
+    def __init__(self, key: str | None = ..., chunk_size: int | None = ...) -> None: ...
+
+class GetStreamChunk(_message.Message):
+    __slots__ = ("data",)
+    DATA_FIELD_NUMBER: _ClassVar[int]
+    data: bytes
+    def __init__(self, data: bytes | None = ...) -> None: ...
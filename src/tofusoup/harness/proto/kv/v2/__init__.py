@@ -0,0 +1,12 @@
+#
+# SPDX-FileCopyrightText: Copyright (c) provide.io llc. All rights reserved.
+# SPDX-License-Identifier: Apache-2.0
+#
+
+"""Generated code for the kv.v2 KV API."""
+
+from . import kv_pb2, kv_pb2_grpc
+
+__all__ = ["kv_pb2", "kv_pb2_grpc"]
+
+# 🥣🔬🔚
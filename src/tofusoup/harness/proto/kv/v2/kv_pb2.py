@@ -0,0 +1,62 @@
+#
+# SPDX-FileCopyrightText: Copyright (c) provide.io llc. All rights reserved.
+# SPDX-License-Identifier: Apache-2.0
+#
+
+"""Generated protocol buffer code."""
+
+from google.protobuf import (
+    descriptor as _descriptor,
+    descriptor_pool as _descriptor_pool,
+    runtime_version as _runtime_version,
+    symbol_database as _symbol_database,
+)
+from google.protobuf.internal import builder as _builder
+
+_runtime_version.ValidateProtobufRuntimeVersion(_runtime_version.Domain.PUBLIC, 6, 31, 0, "", "kv/v2/kv.proto")
+# @@protoc_insertion_point(imports)
+
+_sym_db = _symbol_database.Default()
+
+
+DESCRIPTOR = _descriptor_pool.Default().AddSerializedFile(
+    b'\n\x0ekv/v2/kv.proto\x12\x05kv.v2"\x19\n\nGetRequest\x12\x0b\n\x03key\x18\x01 \x01(\t"\x1c\n\x0bGetResponse\x12\r\n\x05value\x18\x01 \x01(\x0c"(\n\nPutRequest\x12\x0b\n\x03key\x18\x01 \x01(\t\x12\r\n\x05value\x18\x02 \x01(\x0c"\r\n\x0bPutResponse"3\n\x10GetStreamRequest\x12\x0b\n\x03key\x18\x01 \x01(\t\x12\x12\n\nchunk_size\x18\x02 \x01(\x05"\x1e\n\x0eGetStreamChunk\x12\x0c\n\x04\x64\x61ta\x18\x01 \x01(\x0c"\x1c\n\rDeleteRequest\x12\x0b\n\x03key\x18\x01 \x01(\t"!\n\x0e\x44\x65leteResponse\x12\x0f\n\x07\x65xisted\x18\x01 \x01(\x08"\x1d\n\x0bListRequest\x12\x0e\n\x06prefix\x18\x01 \x01(\t"\x1c\n\x0cListResponse\x12\x0c\n\x04keys\x18\x01 \x03(\t"\x1e\n\x0cWatchRequest\x12\x0e\n\x06prefix\x18\x01 \x01(\t"\x8b\x01\n\nWatchEvent\x12$\n\x04type\x18\x01 \x01(\x0e\x32\x16.kv.v2.WatchEvent.Type\x12\x0b\n\x03key\x18\x02 \x01(\t\x12\r\n\x05value\x18\x03 \x01(\x0c";\n\x04Type\x12\x14\n\x10TYPE_UNSPECIFIED\x10\x00\x12\x0c\n\x08TYPE_PUT\x10\x01\x12\x0f\n\x0bTYPE_DELETE\x10\x02\x32\xba\x02\n\x02KV\x12,\n\x03Get\x12\x11.kv.v2.GetRequest\x1a\x12.kv.v2.GetResponse\x12,\n\x03Put\x12\x11.kv.v2.PutRequest\x1a\x12.kv.v2.PutResponse\x12=\n\tGetStream\x12\x17.kv.v2.GetStreamRequest\x1a\x15.kv.v2.GetStreamChunk0\x01\x12\x35\n\x06\x44\x65lete\x12\x14.kv.v2.DeleteRequest\x1a\x15.kv.v2.DeleteResponse\x12/\n\x04List\x12\x12.kv.v2.ListRequest\x1a\x13.kv.v2.ListResponse\x12\x31\n\x05Watch\x12\x13.kv.v2.WatchRequest\x1a\x11.kv.v2.WatchEvent0\x01\x42\x31Z/github.com/provide-io/tofusoup/proto/kv/v2;kvv2b\x06proto3'
+)
+
+_globals = globals()
+_builder.BuildMessageAndEnumDescriptors(DESCRIPTOR, _globals)
+_builder.BuildTopDescriptorsAndMessages(DESCRIPTOR, "kv.v2.kv_pb2", _globals)
+if not _descriptor._USE_C_DESCRIPTORS:
+    _globals["DESCRIPTOR"]._loaded_options = None
+    _globals["DESCRIPTOR"]._serialized_options = b"Z/github.com/provide-io/tofusoup/proto/kv/v2;kvv2"
+    _globals["_GETREQUEST"]._serialized_start = 25
+    _globals["_GETREQUEST"]._serialized_end = 50
+    _globals["_GETRESPONSE"]._serialized_start = 52
+    _globals["_GETRESPONSE"]._serialized_end = 80
+    _globals["_PUTREQUEST"]._serialized_start = 82
+    _globals["_PUTREQUEST"]._serialized_end = 122
+    _globals["_PUTRESPONSE"]._serialized_start = 124
+    _globals["_PUTRESPONSE"]._serialized_end = 137
+    _globals["_GETSTREAMREQUEST"]._serialized_start = 139
+    _globals["_GETSTREAMREQUEST"]._serialized_end = 190
+    _globals["_GETSTREAMCHUNK"]._serialized_start = 192
+    _globals["_GETSTREAMCHUNK"]._serialized_end = 222
+    _globals["_DELETEREQUEST"]._serialized_start = 224
+    _globals["_DELETEREQUEST"]._serialized_end = 252
+    _globals["_DELETERESPONSE"]._serialized_start = 254
+    _globals["_DELETERESPONSE"]._serialized_end = 287
+    _globals["_LISTREQUEST"]._serialized_start = 289
+    _globals["_LISTREQUEST"]._serialized_end = 318
+    _globals["_LISTRESPONSE"]._serialized_start = 320
+    _globals["_LISTRESPONSE"]._serialized_end = 348
+    _globals["_WATCHREQUEST"]._serialized_start = 350
+    _globals["_WATCHREQUEST"]._serialized_end = 380
+    _globals["_WATCHEVENT"]._serialized_start = 383
+    _globals["_WATCHEVENT"]._serialized_end = 522
+    _globals["_WATCHEVENT_TYPE"]._serialized_start = 463
+    _globals["_WATCHEVENT_TYPE"]._serialized_end = 522
+    _globals["_KV"]._serialized_start = 525
+    _globals["_KV"]._serialized_end = 839
+# @@protoc_insertion_point(module_scope)
+
+# 🥣🔬🔚
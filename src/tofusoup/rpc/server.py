@@ -11,6 +11,7 @@ pyvider-rpcplugin for proper go-plugin protocol support. It handles:
 - go-plugin handshake protocol (via pyvider-rpcplugin)
 - Auto-mTLS certificate generation (via pyvider-rpcplugin)
 - gRPC service hosting (via pyvider-rpcplugin)
+- The unversioned proto.KV API alongside kv.v1 and kv.v2, backed by one store
 
 Usage:
     As a plugin (spawned by client):
@@ -23,12 +24,17 @@ Usage:
 """
 
 import asyncio
+from collections.abc import Iterator
+import contextlib
 from datetime import datetime
 import hashlib
 import json
 import os
 from pathlib import Path
+import queue
 import re
+import tempfile
+import threading
 import time
 from typing import Any
 
@@ -40,6 +46,69 @@ from pyvider.rpcplugin.server import RPCPluginServer
 from tofusoup.common.utils import get_cache_dir
 from tofusoup.config.defaults import DEFAULT_GRPC_PORT, ENV_KV_STORAGE_DIR
 from tofusoup.harness.proto.kv import kv_pb2, kv_pb2_grpc
+from tofusoup.harness.proto.kv.v1 import kv_pb2 as kv_v1_pb2, kv_pb2_grpc as kv_v1_pb2_grpc
+from tofusoup.harness.proto.kv.v2 import kv_pb2 as kv_v2_pb2, kv_pb2_grpc as kv_v2_pb2_grpc
+
+try:
+    import fcntl
+except ImportError:  # pragma: no cover - Windows has no flock
+    fcntl = None
+
+# GetStream chunk sizes, capped below gRPC's default 4MiB maximum message size
+DEFAULT_STREAM_CHUNK_SIZE = 1 << 20
+MAX_STREAM_CHUNK_SIZE = (4 << 20) - (64 << 10)
+
+# Suffix of the checksum file the Go server keeps next to each value
+CHECKSUM_SUFFIX = ".sha256"
+
+# How many events a kv.v2 watcher may fall behind by before it is dropped,
+# and how often a waiting watcher checks whether its client went away
+WATCH_BUFFER = 256
+WATCH_POLL_INTERVAL = 0.5
+
+
+class _Watcher:
+    """A kv.v2 Watch call's queue of changes to keys starting with prefix."""
+
+    def __init__(self, prefix: str) -> None:
+        self.prefix = prefix
+        self.events: queue.Queue[kv_v2_pb2.WatchEvent] = queue.Queue(maxsize=WATCH_BUFFER)
+        # Set when the watcher fell behind and was unsubscribed
+        self.dropped = False
+
+
+class WatchHub:
+    """Fans changes made through the server out to the kv.v2 watchers they match.
+
+    Only changes made through this server are seen; other processes sharing
+    the storage directory change it unobserved, as with the Go server.
+    """
+
+    def __init__(self) -> None:
+        self._lock = threading.Lock()
+        self._watchers: set[_Watcher] = set()
+
+    def subscribe(self, prefix: str) -> _Watcher:
+        watcher = _Watcher(prefix)
+        with self._lock:
+            self._watchers.add(watcher)
+        return watcher
+
+    def unsubscribe(self, watcher: _Watcher) -> None:
+        with self._lock:
+            self._watchers.discard(watcher)
+
+    def publish(self, event: kv_v2_pb2.WatchEvent) -> None:
+        """Queue an event for every matching watcher without blocking."""
+        with self._lock:
+            for watcher in list(self._watchers):
+                if not event.key.startswith(watcher.prefix):
+                    continue
+                try:
+                    watcher.events.put_nowait(event)
+                except queue.Full:
+                    watcher.dropped = True
+                    self._watchers.discard(watcher)
 
 
 class KV(kv_pb2_grpc.KVServicer):
@@ -56,16 +125,109 @@ class KV(kv_pb2_grpc.KVServicer):
         self.storage_dir = storage_dir
         self.key_pattern = re.compile(r"^[a-zA-Z0-9._-]+$")
         self.start_time = time.time()
+        self.watch_hub = WatchHub()
         logger.debug("Initialized KV servicer", storage_dir=storage_dir)
 
     def _validate_key(self, key: str) -> bool:
         """Validate that key contains only allowed characters [a-zA-Z0-9._-]"""
         return bool(self.key_pattern.match(key))
 
+    def _get_shard_dir(self, key: str) -> str:
+        """Get the fan-out directory holding a key.
+
+        This is the layout the Go server uses: the first byte of the key's
+        SHA-256 in hex, so both servers can share a storage directory.
+        """
+        return os.path.join(self.storage_dir, hashlib.sha256(key.encode()).hexdigest()[:2])
+
     def _get_file_path(self, key: str) -> str:
         """Get the file path for a given key"""
+        return os.path.join(self._get_shard_dir(key), f"kv-data-{key}")
+
+    def _get_flat_file_path(self, key: str) -> str:
+        """Get the file path the flat layout, used before sharding, kept a key in"""
         return f"{self.storage_dir}/kv-data-{key}"
 
+    @contextlib.contextmanager
+    def _lock(self, key: str, shared: bool = False) -> Iterator[None]:
+        """Hold the key's lock file, the one the Go server locks, creating its shard."""
+        os.makedirs(self._get_shard_dir(key), exist_ok=True)
+        if fcntl is None:
+            yield
+            return
+        with Path(self._get_file_path(key) + ".lock").open("a") as lock_file:
+            fcntl.flock(lock_file, fcntl.LOCK_SH if shared else fcntl.LOCK_EX)
+            try:
+                yield
+            finally:
+                fcntl.flock(lock_file, fcntl.LOCK_UN)
+
+    def _write_atomic(self, path: str, data: bytes) -> None:
+        """Replace path with data via a synced temporary file and a rename."""
+        directory = os.path.dirname(path)
+        fd, tmp_path = tempfile.mkstemp(dir=directory, prefix=f".{os.path.basename(path)}.tmp-")
+        try:
+            with os.fdopen(fd, "wb") as f:
+                f.write(data)
+                f.flush()
+                os.fsync(f.fileno())
+            os.chmod(tmp_path, 0o644)
+            os.replace(tmp_path, path)
+        except BaseException:
+            with contextlib.suppress(FileNotFoundError):
+                os.remove(tmp_path)
+            raise
+
+    def _migrate_flat(self, key: str) -> None:
+        """Move a key from the flat layout into its shard, as the Go server does on read."""
+        flat_path = self._get_flat_file_path(key)
+        if not os.path.exists(flat_path):
+            return
+        with self._lock(key):
+            # A Put may have sharded the key while we waited for the lock
+            if os.path.exists(self._get_file_path(key)):
+                return
+            with contextlib.suppress(FileNotFoundError):
+                os.replace(flat_path, self._get_file_path(key))
+                logger.debug("Migrated entry to sharded layout", key=key)
+
+    def delete_key(self, key: str) -> bool:
+        """Remove a key's value and checksum, in both layouts, reporting whether it existed.
+
+        The lock file is left in place, as the Go server leaves it for GC.
+        """
+        existed = False
+        with self._lock(key):
+            for path in (self._get_file_path(key), self._get_flat_file_path(key)):
+                with contextlib.suppress(FileNotFoundError):
+                    os.remove(path)
+                    existed = True
+            with contextlib.suppress(FileNotFoundError):
+                os.remove(self._get_file_path(key) + CHECKSUM_SUFFIX)
+        if existed:
+            self.watch_hub.publish(kv_v2_pb2.WatchEvent(type=kv_v2_pb2.WatchEvent.TYPE_DELETE, key=key))
+        return existed
+
+    def list_keys(self, prefix: str) -> list[str]:
+        """List the keys starting with prefix, in both layouts, in lexical order."""
+        storage = Path(self.storage_dir)
+        if not storage.is_dir():
+            return []
+        files = [entry for entry in storage.iterdir() if entry.is_file()]
+        for shard in storage.iterdir():
+            if shard.is_dir() and re.fullmatch(r"[0-9a-f]{2}", shard.name):
+                files.extend(entry for entry in shard.iterdir() if entry.is_file())
+
+        keys = set()
+        for entry in files:
+            name = entry.name
+            if not name.startswith("kv-data-") or name.endswith((".lock", CHECKSUM_SUFFIX)):
+                continue
+            key = name.removeprefix("kv-data-")
+            if key.startswith(prefix):
+                keys.add(key)
+        return sorted(keys)
+
     def _enrich_json_with_handshake(self, value_bytes: bytes, context: grpc.ServicerContext) -> bytes:
         """Enrich JSON value with server handshake information.
 
@@ -163,7 +325,11 @@ class KV(kv_pb2_grpc.KVServicer):
         logger.debug("Retrieving value from file", key=request.key, file=file_path)
 
         try:
-            with Path(file_path).open("rb") as f:
+            if not os.path.exists(file_path):
+                self._migrate_flat(request.key)
+                if not os.path.exists(file_path):
+                    raise FileNotFoundError(file_path)
+            with self._lock(request.key, shared=True), Path(file_path).open("rb") as f:
                 raw_value = f.read()
 
             # Enrich JSON values with server handshake information on Get
@@ -193,6 +359,18 @@ class KV(kv_pb2_grpc.KVServicer):
             context.set_details(f'Failed to read key "{request.key}" from file: {e}')
             return kv_pb2.GetResponse()
 
+    def GetStream(
+        self, request: kv_pb2.GetStreamRequest, context: grpc.ServicerContext
+    ) -> Iterator[kv_pb2.GetStreamChunk]:
+        """Stream a value in chunks, for values larger than the maximum message size."""
+        chunk_size = request.chunk_size if request.chunk_size > 0 else DEFAULT_STREAM_CHUNK_SIZE
+        chunk_size = min(chunk_size, MAX_STREAM_CHUNK_SIZE)
+
+        response = self.Get(kv_pb2.GetRequest(key=request.key), context)
+        value = response.value
+        for offset in range(0, len(value), chunk_size):
+            yield kv_pb2.GetStreamChunk(data=value[offset : offset + chunk_size])
+
     def Put(self, request: kv_pb2.PutRequest, context: grpc.ServicerContext) -> kv_pb2.Empty:
         if not self._validate_key(request.key):
             logger.error("Invalid key for Put operation", key=request.key)
@@ -206,9 +384,17 @@ class KV(kv_pb2_grpc.KVServicer):
         logger.debug("Storing value to file", key=request.key, file=file_path)
 
         try:
-            # Store raw value without enrichment (enrichment happens on Get)
-            with Path(file_path).open("wb") as f:
-                f.write(request.value)
+            # Store raw value without enrichment (enrichment happens on Get),
+            # with the checksum the Go server verifies on read
+            with self._lock(request.key):
+                self._write_atomic(file_path, request.value)
+                checksum = hashlib.sha256(request.value).hexdigest() + "\n"
+                self._write_atomic(file_path + CHECKSUM_SUFFIX, checksum.encode())
+                with contextlib.suppress(FileNotFoundError):
+                    os.remove(self._get_flat_file_path(request.key))
+            self.watch_hub.publish(
+                kv_v2_pb2.WatchEvent(type=kv_v2_pb2.WatchEvent.TYPE_PUT, key=request.key, value=request.value)
+            )
             logger.info(
                 "Successfully stored value",
                 key=request.key,
@@ -228,6 +414,114 @@ class KV(kv_pb2_grpc.KVServicer):
             return kv_pb2.Empty()
 
 
+class KVv1(kv_v1_pb2_grpc.KVServicer):
+    """kv.v1 served through the unversioned KV servicer's store."""
+
+    def __init__(self, base: KV) -> None:
+        self.base = base
+
+    def Get(self, request: kv_v1_pb2.GetRequest, context: grpc.ServicerContext) -> kv_v1_pb2.GetResponse:
+        response = self.base.Get(kv_pb2.GetRequest(key=request.key), context)
+        return kv_v1_pb2.GetResponse(value=response.value)
+
+    def Put(self, request: kv_v1_pb2.PutRequest, context: grpc.ServicerContext) -> kv_v1_pb2.PutResponse:
+        self.base.Put(kv_pb2.PutRequest(key=request.key, value=request.value), context)
+        return kv_v1_pb2.PutResponse()
+
+    def GetStream(
+        self, request: kv_v1_pb2.GetStreamRequest, context: grpc.ServicerContext
+    ) -> Iterator[kv_v1_pb2.GetStreamChunk]:
+        legacy_request = kv_pb2.GetStreamRequest(key=request.key, chunk_size=request.chunk_size)
+        for chunk in self.base.GetStream(legacy_request, context):
+            yield kv_v1_pb2.GetStreamChunk(data=chunk.data)
+
+
+class KVv2(kv_v2_pb2_grpc.KVServicer):
+    """kv.v2 served through the unversioned KV servicer's store, adding Delete, List and Watch."""
+
+    def __init__(self, base: KV) -> None:
+        self.base = base
+
+    def Get(self, request: kv_v2_pb2.GetRequest, context: grpc.ServicerContext) -> kv_v2_pb2.GetResponse:
+        response = self.base.Get(kv_pb2.GetRequest(key=request.key), context)
+        return kv_v2_pb2.GetResponse(value=response.value)
+
+    def Put(self, request: kv_v2_pb2.PutRequest, context: grpc.ServicerContext) -> kv_v2_pb2.PutResponse:
+        self.base.Put(kv_pb2.PutRequest(key=request.key, value=request.value), context)
+        return kv_v2_pb2.PutResponse()
+
+    def GetStream(
+        self, request: kv_v2_pb2.GetStreamRequest, context: grpc.ServicerContext
+    ) -> Iterator[kv_v2_pb2.GetStreamChunk]:
+        legacy_request = kv_pb2.GetStreamRequest(key=request.key, chunk_size=request.chunk_size)
+        for chunk in self.base.GetStream(legacy_request, context):
+            yield kv_v2_pb2.GetStreamChunk(data=chunk.data)
+
+    def Delete(
+        self, request: kv_v2_pb2.DeleteRequest, context: grpc.ServicerContext
+    ) -> kv_v2_pb2.DeleteResponse:
+        if not self.base._validate_key(request.key):
+            logger.error("Invalid key for Delete operation", key=request.key)
+            context.set_code(grpc.StatusCode.INVALID_ARGUMENT)
+            context.set_details(
+                f'Key "{request.key}" contains invalid characters, only [a-zA-Z0-9._-] are allowed'
+            )
+            return kv_v2_pb2.DeleteResponse()
+
+        try:
+            existed = self.base.delete_key(request.key)
+        except Exception as e:
+            logger.error("Failed to delete key", key=request.key, error=str(e))
+            context.set_code(grpc.StatusCode.INTERNAL)
+            context.set_details(f'Failed to delete key "{request.key}": {e}')
+            return kv_v2_pb2.DeleteResponse()
+        logger.info("Deleted key", key=request.key, existed=existed)
+        return kv_v2_pb2.DeleteResponse(existed=existed)
+
+    def List(self, request: kv_v2_pb2.ListRequest, context: grpc.ServicerContext) -> kv_v2_pb2.ListResponse:
+        try:
+            keys = self.base.list_keys(request.prefix)
+        except Exception as e:
+            logger.error("Failed to list keys", prefix=request.prefix, error=str(e))
+            context.set_code(grpc.StatusCode.INTERNAL)
+            context.set_details(f'Failed to list keys with prefix "{request.prefix}": {e}')
+            return kv_v2_pb2.ListResponse()
+        return kv_v2_pb2.ListResponse(keys=keys)
+
+    def Watch(
+        self, request: kv_v2_pb2.WatchRequest, context: grpc.ServicerContext
+    ) -> Iterator[kv_v2_pb2.WatchEvent]:
+        """Stream changes until the client cancels.
+
+        Headers are sent once the watcher is registered, so clients can wait
+        for them before making changes they expect to see.
+        """
+        done = threading.Event()
+        context.add_callback(done.set)
+        watcher = self.base.watch_hub.subscribe(request.prefix)
+        try:
+            context.send_initial_metadata(())
+            while not done.is_set():
+                try:
+                    event = watcher.events.get(timeout=WATCH_POLL_INTERVAL)
+                except queue.Empty:
+                    if watcher.dropped:
+                        context.set_code(grpc.StatusCode.RESOURCE_EXHAUSTED)
+                        context.set_details("watcher fell too far behind")
+                        return
+                    continue
+                yield event
+        finally:
+            self.base.watch_hub.unsubscribe(watcher)
+
+
+def add_kv_servicers_to_server(handler: KV, server: grpc.Server | grpc.aio.Server) -> None:
+    """Register proto.KV, kv.v1 and kv.v2 on server, all backed by handler."""
+    kv_pb2_grpc.add_KVServicer_to_server(handler, server)
+    kv_v1_pb2_grpc.add_KVServicer_to_server(KVv1(handler), server)
+    kv_v2_pb2_grpc.add_KVServicer_to_server(KVv2(handler), server)
+
+
 def serve(server: grpc.aio.Server, storage_dir: str | None = None) -> None:
     """Set up KV handlers on a gRPC server.
 
@@ -239,8 +533,8 @@ def serve(server: grpc.aio.Server, storage_dir: str | None = None) -> None:
         storage_dir: Directory to store KV data files.
     """
     handler = KV(storage_dir=storage_dir)
-    kv_pb2_grpc.add_KVServicer_to_server(handler, server)
-    logger.info("Added KV servicer to gRPC server", storage_dir=storage_dir)
+    add_kv_servicers_to_server(handler, server)
+    logger.info("Added KV servicers to gRPC server", storage_dir=storage_dir)
 
 
 class KVProtocol(RPCPluginProtocol[grpc.aio.Server, KV]):
@@ -257,9 +551,9 @@ class KVProtocol(RPCPluginProtocol[grpc.aio.Server, KV]):
         return None, self.service_name
 
     async def add_to_server(self, server: grpc.aio.Server, handler: KV) -> None:
-        """Add the KV service to the gRPC server."""
-        kv_pb2_grpc.add_KVServicer_to_server(handler, server)
-        logger.info("Added KV servicer to gRPC server")
+        """Add the KV services, unversioned and versioned, to the gRPC server."""
+        add_kv_servicers_to_server(handler, server)
+        logger.info("Added KV servicers to gRPC server")
 
 
 async def serve_plugin(
@@ -364,7 +658,7 @@ def main() -> None:
 
     server = grpc.server(futures.ThreadPoolExecutor(max_workers=10))
     kv = KV()
-    kv_pb2_grpc.add_KVServicer_to_server(kv, server)
+    add_kv_servicers_to_server(kv, server)
     server.add_insecure_port(f"[::]:{DEFAULT_GRPC_PORT}")
     server.start()
 